@@ -29,6 +29,8 @@ import (
 	_ "github.com/anviod/edgex/internal/driver/s7"
 	_ "github.com/anviod/edgex/internal/driver/snmp"
 	"github.com/anviod/edgex/internal/model"
+	_ "github.com/anviod/edgex/internal/northbound/kafka"
+	_ "github.com/anviod/edgex/internal/northbound/tsdb"
 	"github.com/anviod/edgex/internal/pkg/logger"
 	"github.com/anviod/edgex/internal/server"
 	"github.com/anviod/edgex/internal/storage"
@@ -170,6 +172,12 @@ func main() {
 		return cfgManager.SaveConfig(current)
 	})
 	ecm.LoadRules(cfg.EdgeRules)
+	// 规则版本历史（含影子运行配置）随主备配置镜像，升主后重新加载
+	if histories, err := cfgManager.LoadEdgeRuleHistories(); err == nil {
+		ecm.LoadRuleHistory(histories, cfgManager.SaveEdgeRuleHistory)
+	} else {
+		zap.L().Warn("Failed to load edge rule history", zap.Error(err))
+	}
 	ecm.Start()
 	zap.L().Info("Edge Compute Manager started")
 
@@ -183,9 +191,40 @@ func main() {
 
 	dsm := core.NewDeviceStorageManager(store, pipeline)
 
+	// 写联锁：所有写入路径经 ChannelManager.WritePoint 统一检查
+	interlocks := core.NewInterlockManager(cfgManager.SaveInterlocks)
+	if items, err := cfgManager.LoadInterlocks(); err == nil {
+		interlocks.Load(items)
+	} else {
+		zap.L().Warn("Failed to load write interlocks", zap.Error(err))
+	}
+	pointValue := func(channelID, deviceID, pointID string) (model.Value, bool) {
+		if shadowCore != nil {
+			if p, err := shadowCore.GetShadowPoint(deviceID, pointID); err == nil {
+				return model.Value{ChannelID: channelID, DeviceID: deviceID, PointID: pointID, Value: p.Value, Quality: p.Quality, TS: p.Timestamp}, true
+			}
+		}
+		return ecm.CachedValue(channelID, deviceID, pointID)
+	}
+	interlocks.SetValueSource(pointValue)
+	cm.SetInterlocks(interlocks)
+
+	// 配方：写入步骤经 ChannelManager.WritePoint，同样受写联锁约束
+	recipes := core.NewRecipeManager(store, cfgManager.SaveRecipes)
+	if items, err := cfgManager.LoadRecipes(); err == nil {
+		recipes.Load(items)
+	} else {
+		zap.L().Warn("Failed to load recipes", zap.Error(err))
+	}
+	recipes.SetDeviceIO(cm)
+	recipes.SetValueSource(pointValue)
+	recipes.Start()
+	ecm.SetRecipeManager(recipes)
+
 	wireShadowStack := func(sc *core.ShadowCore) {
 		core.NewShadowBridge(pipeline).Attach(sc)
 		dsm.SetShadowCore(sc)
+		ecm.SetShadowCore(sc)
 		virtualShadow = core.NewVirtualShadowEngine(sc)
 		shadowIngress = core.NewShadowIngress(sc, 256, 8*time.Millisecond)
 		shadowIngress.Start()
@@ -218,6 +257,7 @@ func main() {
 	})
 	cm.SetTopologyChangeHandler(func() {
 		nbm.RebuildOPCUAServers()
+		ecm.SyncRuleTemplates()
 		if vsm != nil {
 			vsm.ReloadAll()
 		}
@@ -226,9 +266,20 @@ func main() {
 	// Connect Edge Compute to Northbound
 	ecm.SetNorthboundManager(nbm)
 
+	// 告警通知：规则 notify 动作按策略发送并逐级升级
+	notifier := core.NewNotificationManager(cfgManager.SaveNotificationConfig)
+	if notifyCfg, err := cfgManager.LoadNotificationConfig(); err == nil {
+		notifier.Load(notifyCfg)
+	} else {
+		zap.L().Warn("Failed to load notification config", zap.Error(err))
+	}
+	notifier.Start()
+	ecm.SetNotificationManager(notifier)
+
 	// Init System Manager
 	sm := core.NewSystemManager(cfg)
 	sm.SetConfigManager(cfgManager)
+	pipeline.SetTimeQualityFunc(sm.TimeSync().TimeQuality)
 
 	for _, ch := range cfg.Channels {
 		for _, dev := range ch.Devices {
@@ -244,6 +295,18 @@ func main() {
 	// 4. Init Web Server
 	zap.L().Info("Initializing Web Server...")
 	srv := server.NewServer(cm, store, pipeline, nbm, ecm, sm, dsm, cfgManager, nil, logBroadcaster)
+	srv.SetInterlockManager(interlocks)
+	srv.SetNotificationManager(notifier)
+	srv.SetRecipeManager(recipes)
+	loadRuleTemplates := func() {
+		templates, err := cfgManager.LoadEdgeRuleTemplates()
+		if err != nil {
+			zap.L().Warn("Failed to load edge rule templates", zap.Error(err))
+			return
+		}
+		ecm.LoadRuleTemplates(templates, cfgManager.SaveEdgeRuleTemplates)
+	}
+	loadRuleTemplates()
 	if shadowCore != nil {
 		srv.SetShadowCore(shadowCore)
 	}
@@ -320,9 +383,52 @@ func main() {
 		}()
 	}
 
+	// 主备冗余：备机只镜像配置，升主后才启动采集与北向；降备时卸载通道、静默北向。
+	ham := core.NewHAManager(cfg.System.HA, core.HAHooks{
+		Activate: func() {
+			current := cfgManager.GetConfig()
+			nbm.LoadConfig(current.Northbound)
+			ecm.ReplaceRules(current.EdgeRules)
+			if histories, err := cfgManager.LoadEdgeRuleHistories(); err == nil {
+				ecm.LoadRuleHistory(histories, cfgManager.SaveEdgeRuleHistory)
+			}
+			loadRuleTemplates()
+			if items, err := cfgManager.LoadInterlocks(); err == nil {
+				interlocks.Load(items)
+			}
+			if notifyCfg, err := cfgManager.LoadNotificationConfig(); err == nil {
+				notifier.Load(notifyCfg)
+			}
+			if items, err := cfgManager.LoadRecipes(); err == nil {
+				recipes.Load(items)
+			}
+			if vsm != nil {
+				if configs, err := cfgManager.LoadVirtualShadows(); err == nil {
+					vsm.Load(configs)
+				}
+			}
+			startDataPlane()
+		},
+		// 配方安全态须在切换为备机前写出，否则会被写入保护拒绝
+		BeforeDeactivate: recipes.Stop,
+		Deactivate: func() {
+			cm.UnloadChannels()
+			nbm.Suspend()
+		},
+		ExportConfig: func() ([]byte, error) {
+			return core.ExportHAConfig(cfgManager)
+		},
+		ImportConfig: func(data []byte) error {
+			return core.ImportHAConfig(cfgManager, data)
+		},
+	})
+	sm.SetHAManager(ham)
+	cm.SetWriteGuard(ham.WriteGuard)
+	defer ham.Stop()
+
 	var dataPlaneOnce sync.Once
 	startDataPlaneOnce := func() {
-		dataPlaneOnce.Do(startDataPlane)
+		dataPlaneOnce.Do(ham.Start)
 	}
 	srv.SetRuntimeStartHook(startDataPlaneOnce)
 
@@ -359,5 +465,7 @@ func main() {
 	zap.L().Info("Shutting down...")
 
 	srv.StopBackgroundTasks()
+	notifier.Stop()
+	recipes.Stop()
 	cm.Shutdown()
 }
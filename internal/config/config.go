@@ -88,6 +88,7 @@ func DefaultConfig() *Config {
 		SparkplugB: []model.SparkplugBConfig{},
 		EdgeOSMQTT: []model.EdgeOSMQTTConfig{},
 		EdgeOSNATS: []model.EdgeOSNATSConfig{},
+		Connectors: []model.ConnectorConfig{},
 	}
 	cfg.Channels = []model.Channel{}
 	cfg.EdgeRules = []model.EdgeRule{}
@@ -276,3 +277,124 @@ func (cm *ConfigManager) SaveVirtualShadows(devices []model.VirtualShadowDeviceC
 	}
 	return configStore.SaveVirtualShadows(devices)
 }
+
+func (cm *ConfigManager) LoadEdgeRuleTemplates() ([]model.EdgeRuleTemplate, error) {
+	if !cm.useDB || cm.db == nil {
+		return []model.EdgeRuleTemplate{}, nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return nil, err
+	}
+	return configStore.LoadEdgeRuleTemplates()
+}
+
+func (cm *ConfigManager) SaveEdgeRuleTemplates(templates []model.EdgeRuleTemplate) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.SaveEdgeRuleTemplates(templates)
+}
+
+func (cm *ConfigManager) LoadInterlocks() ([]model.WriteInterlock, error) {
+	if !cm.useDB || cm.db == nil {
+		return []model.WriteInterlock{}, nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return nil, err
+	}
+	return configStore.LoadInterlocks()
+}
+
+func (cm *ConfigManager) SaveInterlocks(interlocks []model.WriteInterlock) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.SaveInterlocks(interlocks)
+}
+
+func (cm *ConfigManager) LoadNotificationConfig() (model.NotificationConfig, error) {
+	if !cm.useDB || cm.db == nil {
+		return model.NotificationConfig{}, nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return model.NotificationConfig{}, err
+	}
+	return configStore.LoadNotificationConfig()
+}
+
+func (cm *ConfigManager) SaveNotificationConfig(cfg model.NotificationConfig) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.SaveNotificationConfig(cfg)
+}
+
+func (cm *ConfigManager) LoadRecipes() ([]model.Recipe, error) {
+	if !cm.useDB || cm.db == nil {
+		return []model.Recipe{}, nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return nil, err
+	}
+	return configStore.LoadRecipes()
+}
+
+func (cm *ConfigManager) SaveRecipes(recipes []model.Recipe) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.SaveRecipes(recipes)
+}
+
+func (cm *ConfigManager) LoadEdgeRuleHistories() ([]model.EdgeRuleHistory, error) {
+	if !cm.useDB || cm.db == nil {
+		return nil, nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return nil, err
+	}
+	return configStore.LoadEdgeRuleHistories()
+}
+
+func (cm *ConfigManager) SaveEdgeRuleHistory(history model.EdgeRuleHistory) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.SaveEdgeRuleHistory(history)
+}
+
+func (cm *ConfigManager) ReplaceEdgeRuleHistories(histories []model.EdgeRuleHistory) error {
+	if !cm.useDB || cm.db == nil {
+		return nil
+	}
+	configStore, err := storage.NewConfigStore(cm.db)
+	if err != nil {
+		return err
+	}
+	return configStore.ReplaceEdgeRuleHistories(histories)
+}
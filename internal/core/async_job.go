@@ -24,8 +24,9 @@ const (
 type AsyncJobType string
 
 const (
-	AsyncJobScanChannel AsyncJobType = "scan_channel"
-	AsyncJobScanDevice  AsyncJobType = "scan_device"
+	AsyncJobScanChannel  AsyncJobType = "scan_channel"
+	AsyncJobScanDevice   AsyncJobType = "scan_device"
+	AsyncJobImportPoints AsyncJobType = "import_points"
 )
 
 const (
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	drv "github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+// BrowseImportResult is the outcome of importing browsed address-space nodes as points.
+type BrowseImportResult struct {
+	Added   int              `json:"added"`
+	DryRun  bool             `json:"dry_run"`
+	Points  []model.Point    `json:"points"`
+	Skipped []drv.ImportSkip `json:"skipped"`
+}
+
+// browseTarget resolves the address-space browser of a channel and the
+// connection params of one of its devices (endpoint, security, auth).
+func (cm *ChannelManager) browseTarget(channelID, deviceID string, params map[string]any) (drv.AddressSpaceBrowser, map[string]any, error) {
+	cm.mu.RLock()
+	d, okDrv := cm.drivers[channelID]
+	ch, okCh := cm.channels[channelID]
+	var devCfg map[string]any
+	found := false
+	if okCh {
+		for _, dev := range ch.Devices {
+			if dev.ID == deviceID {
+				devCfg = dev.Config
+				found = true
+				break
+			}
+		}
+	}
+	cm.mu.RUnlock()
+
+	if !okDrv || !okCh {
+		return nil, nil, fmt.Errorf("channel or driver not found")
+	}
+	browser, ok := d.(drv.AddressSpaceBrowser)
+	if !ok {
+		return nil, nil, fmt.Errorf("driver does not support address space browsing")
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("device not found")
+	}
+
+	out := make(map[string]any)
+	if ch.Protocol == "opc-ua" {
+		for k, v := range model.MergeOpcUaDeviceConfig(ch.Config, devCfg) {
+			out[k] = v
+		}
+	}
+	// Request keys (node_id, offset, node_ids, ...) must not override the
+	// persisted connection settings.
+	for k, v := range params {
+		if _, exists := out[k]; !exists {
+			out[k] = v
+		}
+	}
+	return browser, out, nil
+}
+
+// BrowseDevice returns one level of the device address space below params["node_id"].
+func (cm *ChannelManager) BrowseDevice(ctx context.Context, channelID, deviceID string, params map[string]any) (any, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	browser, browseParams, err := cm.browseTarget(channelID, deviceID, params)
+	if err != nil {
+		return nil, err
+	}
+	// Like ScanDevice, browsing uses its own session and must not hold the channel mutex.
+	return browser.BrowseAddressSpace(ctx, browseParams)
+}
+
+// ImportBrowsedPoints expands the selected nodes (params["node_ids"]) into points
+// and adds those not yet configured on the device. With params["dry_run"]=true
+// the mapped points are returned without being added.
+func (cm *ChannelManager) ImportBrowsedPoints(ctx context.Context, channelID, deviceID string, params map[string]any) (*BrowseImportResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	browser, browseParams, err := cm.browseTarget(channelID, deviceID, params)
+	if err != nil {
+		return nil, err
+	}
+	points, skipped, err := browser.ResolveImportPoints(ctx, browseParams)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{})
+	if dev := cm.GetDevice(channelID, deviceID); dev != nil {
+		for _, p := range dev.Points {
+			existing[p.ID] = struct{}{}
+		}
+	}
+	res := &BrowseImportResult{Points: make([]model.Point, 0, len(points)), Skipped: skipped}
+	for _, p := range points {
+		if _, ok := existing[p.ID]; ok {
+			res.Skipped = append(res.Skipped, drv.ImportSkip{NodeID: p.Address, Reason: "point already exists"})
+			continue
+		}
+		res.Points = append(res.Points, p)
+	}
+	if res.Skipped == nil {
+		res.Skipped = []drv.ImportSkip{}
+	}
+
+	if dryRun, _ := params["dry_run"].(bool); dryRun {
+		res.DryRun = true
+		return res, nil
+	}
+	if len(res.Points) == 0 {
+		return res, nil
+	}
+	if err := cm.AddPoints(channelID, deviceID, res.Points); err != nil {
+		return nil, err
+	}
+	res.Added = len(res.Points)
+	return res, nil
+}
+
+// StartImportBrowsedPointsJob submits ImportBrowsedPoints as an async job;
+// walking large subtrees can take as long as a full object scan.
+func (cm *ChannelManager) StartImportBrowsedPointsJob(channelID, deviceID string, params map[string]any) (*AsyncJob, error) {
+	cm.mu.RLock()
+	_, okDrv := cm.drivers[channelID]
+	ch, okCh := cm.channels[channelID]
+	cm.mu.RUnlock()
+	if !okDrv || !okCh {
+		return nil, fmt.Errorf("channel or driver not found")
+	}
+	timeout := scanDeviceTimeout(ch.Protocol)
+	paramsCopy := copyScanParams(params)
+	return cm.jobs.Submit(AsyncJobImportPoints, channelID, deviceID, func(ctx context.Context) (any, error) {
+		jobCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return cm.ImportBrowsedPoints(jobCtx, channelID, deviceID, paramsCopy)
+	}), nil
+}
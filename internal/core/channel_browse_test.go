@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+const browseMockProtocol = "browse-mock"
+
+type browseMockDriver struct {
+	addChannelMockDriver
+	lastParams map[string]any
+}
+
+func (m *browseMockDriver) BrowseAddressSpace(_ context.Context, params map[string]any) (any, error) {
+	m.lastParams = params
+	return []string{"child"}, nil
+}
+
+func (m *browseMockDriver) ResolveImportPoints(_ context.Context, params map[string]any) ([]model.Point, []driver.ImportSkip, error) {
+	m.lastParams = params
+	return []model.Point{
+		{ID: "ns=2;s=A", Name: "A", Address: "ns=2;s=A", DataType: "float64", ReadWrite: "R"},
+		{ID: "ns=2;s=B", Name: "B", Address: "ns=2;s=B", DataType: "int32", ReadWrite: "RW"},
+	}, []driver.ImportSkip{
+		{NodeID: "ns=2;s=C", Reason: "unsupported data type ns=2;i=5001"},
+	}, nil
+}
+
+func init() {
+	driver.RegisterDriver(browseMockProtocol, func() driver.Driver {
+		return &browseMockDriver{}
+	})
+}
+
+func TestChannelManager_ImportBrowsedPoints(t *testing.T) {
+	cm := NewChannelManager(nil, nil)
+	defer cm.cancel()
+
+	channelID := "ch-browse"
+	if err := cm.AddChannel(&model.Channel{
+		ID:       channelID,
+		Name:     "Browse Channel",
+		Protocol: browseMockProtocol,
+		Config:   map[string]any{},
+		Devices: []model.Device{{
+			ID:     "dev-1",
+			Name:   "Device",
+			Points: []model.Point{{ID: "ns=2;s=A", Name: "A", Address: "ns=2;s=A", DataType: "float64"}},
+		}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+
+	res, err := cm.BrowseDevice(context.Background(), channelID, "dev-1", map[string]any{"node_id": "i=85"})
+	if err != nil {
+		t.Fatalf("BrowseDevice: %v", err)
+	}
+	if got := res.([]string); len(got) != 1 {
+		t.Fatalf("browse result = %v", got)
+	}
+	if _, err := cm.BrowseDevice(context.Background(), channelID, "missing", nil); err == nil {
+		t.Fatal("expected device not found error")
+	}
+
+	dry, err := cm.ImportBrowsedPoints(context.Background(), channelID, "dev-1", map[string]any{"node_ids": []any{"i=85"}, "dry_run": true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !dry.DryRun || dry.Added != 0 || len(dry.Points) != 1 || len(dry.Skipped) != 2 {
+		t.Fatalf("dry run result = %+v", dry)
+	}
+	if n := len(cm.GetDevice(channelID, "dev-1").Points); n != 1 {
+		t.Fatalf("dry run must not add points, device has %d", n)
+	}
+
+	res2, err := cm.ImportBrowsedPoints(context.Background(), channelID, "dev-1", map[string]any{"node_ids": []any{"i=85"}})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res2.Added != 1 || res2.Points[0].ID != "ns=2;s=B" {
+		t.Fatalf("import result = %+v", res2)
+	}
+	if n := len(cm.GetDevice(channelID, "dev-1").Points); n != 2 {
+		t.Fatalf("device has %d points after import, want 2", n)
+	}
+}
+
+func TestChannelManager_BrowseRequiresBrowser(t *testing.T) {
+	cm := NewChannelManager(nil, nil)
+	defer cm.cancel()
+
+	channelID := "ch-no-browse"
+	if err := cm.AddChannel(&model.Channel{
+		ID:       channelID,
+		Name:     "Plain Channel",
+		Protocol: addChannelMockProtocol,
+		Config:   map[string]any{},
+		Devices:  []model.Device{{ID: "dev-1", Name: "Device"}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+	if _, err := cm.BrowseDevice(context.Background(), channelID, "dev-1", nil); err == nil {
+		t.Fatal("expected unsupported driver error")
+	}
+}
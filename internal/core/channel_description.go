@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	drv "github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+// DescriptionImportResult is the outcome of importing a device description
+// file (EtherCAT ESI, PROFINET GSDML) as points.
+type DescriptionImportResult struct {
+	Added        int              `json:"added"`
+	DryRun       bool             `json:"dry_run"`
+	Points       []model.Point    `json:"points"`
+	Skipped      []drv.ImportSkip `json:"skipped"`
+	DeviceConfig map[string]any   `json:"device_config,omitempty"`
+	Info         map[string]any   `json:"info,omitempty"`
+}
+
+// descriptionOverwriteKeys are derived device settings that always follow the
+// description file; other derived keys only fill in missing values.
+var descriptionOverwriteKeys = map[string]bool{
+	"tx_pdo_size": true,
+	"rx_pdo_size": true,
+	// PROFINET module configuration chosen at import time.
+	"gsdml_dap":     true,
+	"gsdml_modules": true,
+}
+
+// ImportDeviceDescription parses an uploaded description file with the channel
+// driver, adds the generated points not yet configured on the device and merges
+// the derived device settings. With params["dry_run"]=true nothing is changed.
+func (cm *ChannelManager) ImportDeviceDescription(ctx context.Context, channelID, deviceID string, data []byte, params map[string]any) (*DescriptionImportResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("description file is empty")
+	}
+
+	cm.mu.RLock()
+	d, okDrv := cm.drivers[channelID]
+	ch, okCh := cm.channels[channelID]
+	cm.mu.RUnlock()
+	if !okDrv || !okCh {
+		return nil, fmt.Errorf("channel or driver not found")
+	}
+	importer, ok := d.(drv.DescriptionImporter)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support device description import")
+	}
+	dev := cm.GetDevice(channelID, deviceID)
+	if dev == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	// Request options override the persisted device config (e.g. an explicit product_code).
+	importParams := make(map[string]any, len(dev.Config)+len(params))
+	for k, v := range dev.Config {
+		importParams[k] = v
+	}
+	for k, v := range params {
+		importParams[k] = v
+	}
+
+	var imported *drv.DescriptionImport
+	err := cm.withDriverIO(channelID, ch.Protocol, func() error {
+		var err error
+		imported, err = importer.ImportDeviceDescription(ctx, data, importParams)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]struct{}, len(dev.Points))
+	for _, p := range dev.Points {
+		existing[p.ID] = struct{}{}
+	}
+	res := &DescriptionImportResult{
+		Points:       make([]model.Point, 0, len(imported.Points)),
+		Skipped:      imported.Skipped,
+		DeviceConfig: imported.DeviceConfig,
+		Info:         imported.Info,
+	}
+	for _, p := range imported.Points {
+		if _, ok := existing[p.ID]; ok {
+			res.Skipped = append(res.Skipped, drv.ImportSkip{NodeID: p.Address, Reason: "point already exists"})
+			continue
+		}
+		res.Points = append(res.Points, p)
+	}
+	if res.Skipped == nil {
+		res.Skipped = []drv.ImportSkip{}
+	}
+
+	if dryRun, _ := params["dry_run"].(bool); dryRun {
+		res.DryRun = true
+		return res, nil
+	}
+
+	if updated, changed := mergeDescriptionConfig(dev.Config, imported.DeviceConfig); changed {
+		devCopy := *dev
+		devCopy.Config = updated
+		if err := cm.UpdateDevice(channelID, &devCopy); err != nil {
+			return nil, fmt.Errorf("update device config: %w", err)
+		}
+	}
+	if len(res.Points) > 0 {
+		if err := cm.AddPoints(channelID, deviceID, res.Points); err != nil {
+			return nil, err
+		}
+		res.Added = len(res.Points)
+	}
+	return res, nil
+}
+
+// mergeDescriptionConfig returns a copy of cfg with the derived settings applied.
+func mergeDescriptionConfig(cfg, derived map[string]any) (map[string]any, bool) {
+	out := make(map[string]any, len(cfg)+len(derived))
+	for k, v := range cfg {
+		out[k] = v
+	}
+	changed := false
+	for k, v := range derived {
+		cur, exists := out[k]
+		if exists && cur != "" && cur != nil && !descriptionOverwriteKeys[k] {
+			continue
+		}
+		if exists && fmt.Sprint(cur) == fmt.Sprint(v) {
+			continue
+		}
+		out[k] = v
+		changed = true
+	}
+	return out, changed
+}
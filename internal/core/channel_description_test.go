@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+const descriptionMockProtocol = "description-mock"
+
+type descriptionMockDriver struct {
+	addChannelMockDriver
+	lastParams map[string]any
+}
+
+func (m *descriptionMockDriver) ImportDeviceDescription(_ context.Context, data []byte, params map[string]any) (*driver.DescriptionImport, error) {
+	m.lastParams = params
+	return &driver.DescriptionImport{
+		Points: []model.Point{
+			{ID: "tx_6000_01", Name: "Input 1", Address: "1:Tx:0.0#LE", DataType: "bool", ReadWrite: "R"},
+			{ID: "rx_7000_01", Name: "Output 1", Address: "1:Rx:0.0#LE", DataType: "bool", ReadWrite: "RW"},
+		},
+		DeviceConfig: map[string]any{"product_code": "0x07D43052", "vendor_id": "0x00000002", "tx_pdo_size": 4},
+		Info:         map[string]any{"type": string(data)},
+	}, nil
+}
+
+func init() {
+	driver.RegisterDriver(descriptionMockProtocol, func() driver.Driver {
+		return &descriptionMockDriver{}
+	})
+}
+
+func TestChannelManager_ImportDeviceDescription(t *testing.T) {
+	cm := NewChannelManager(nil, nil)
+	defer cm.cancel()
+
+	channelID := "ch-desc"
+	if err := cm.AddChannel(&model.Channel{
+		ID:       channelID,
+		Name:     "Description Channel",
+		Protocol: descriptionMockProtocol,
+		Config:   map[string]any{},
+		Devices: []model.Device{{
+			ID:     "dev-1",
+			Name:   "Slave 1",
+			Config: map[string]any{"position": 1, "vendor_id": "0x2", "tx_pdo_size": 2},
+			Points: []model.Point{{ID: "tx_6000_01", Name: "Input 1", Address: "1:Tx:0.0#LE", DataType: "bool"}},
+		}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+	cm.mu.RLock()
+	mock := cm.drivers[channelID].(*descriptionMockDriver)
+	cm.mu.RUnlock()
+
+	if _, err := cm.ImportDeviceDescription(context.Background(), channelID, "dev-1", nil, nil); err == nil {
+		t.Fatal("expected error for empty file")
+	}
+
+	dry, err := cm.ImportDeviceDescription(context.Background(), channelID, "dev-1", []byte("EL2004"), map[string]any{"dry_run": true, "include_sdo": true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !dry.DryRun || len(dry.Points) != 1 || len(dry.Skipped) != 1 || dry.Info["type"] != "EL2004" {
+		t.Fatalf("dry run result = %+v", dry)
+	}
+	if mock.lastParams["position"] != 1 || mock.lastParams["include_sdo"] != true {
+		t.Fatalf("driver params = %v", mock.lastParams)
+	}
+	if n := len(cm.GetDevice(channelID, "dev-1").Points); n != 1 {
+		t.Fatalf("dry run must not add points, device has %d", n)
+	}
+
+	res, err := cm.ImportDeviceDescription(context.Background(), channelID, "dev-1", []byte("EL2004"), nil)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Added != 1 {
+		t.Fatalf("added = %d", res.Added)
+	}
+	dev := cm.GetDevice(channelID, "dev-1")
+	if len(dev.Points) != 2 {
+		t.Fatalf("device has %d points", len(dev.Points))
+	}
+	if dev.Config["product_code"] != "0x07D43052" || dev.Config["tx_pdo_size"] != 4 {
+		t.Fatalf("derived config not merged: %v", dev.Config)
+	}
+	if dev.Config["vendor_id"] != "0x2" {
+		t.Fatalf("existing identity must be kept: %v", dev.Config["vendor_id"])
+	}
+}
@@ -47,6 +47,8 @@ type ChannelManager struct {
 	saveFunc              func([]model.Channel) error
 	statusHandler         func(deviceID string, status int)
 	topologyChangeHandler func()
+	writeGuard            func(channelID, deviceID, target string) error
+	interlocks            *InterlockManager
 	topologyDebounceMu    sync.Mutex
 	topologyDebounceTimer *time.Timer
 	tagRegistry           *TagRegistry
@@ -347,6 +349,38 @@ func (cm *ChannelManager) SetStatusHandler(h func(deviceID string, status int))
 	cm.statusHandler = h
 }
 
+// SetWriteGuard registers a check run before every device write or method
+// call; a non-nil error rejects the write (e.g. HA standby node).
+func (cm *ChannelManager) SetWriteGuard(guard func(channelID, deviceID, target string) error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.writeGuard = guard
+}
+
+func (cm *ChannelManager) checkWriteGuard(channelID, deviceID, target string) error {
+	cm.mu.RLock()
+	guard := cm.writeGuard
+	cm.mu.RUnlock()
+	if guard == nil {
+		return nil
+	}
+	return guard(channelID, deviceID, target)
+}
+
+// SetInterlocks registers the write interlocks checked before every point write.
+func (cm *ChannelManager) SetInterlocks(im *InterlockManager) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.interlocks = im
+}
+
+func (cm *ChannelManager) checkInterlocks(channelID, deviceID, pointID string, value any) error {
+	cm.mu.RLock()
+	im := cm.interlocks
+	cm.mu.RUnlock()
+	return im.CheckWrite(channelID, deviceID, pointID, value)
+}
+
 // SetTopologyChangeHandler registers a callback invoked when channels/devices/points change.
 // Used to rebuild northbound OPC UA address space.
 func (cm *ChannelManager) SetTopologyChangeHandler(h func()) {
@@ -494,6 +528,7 @@ func (cm *ChannelManager) AddChannel(ch *model.Channel) error {
 	cm.driverMus[ch.ID] = &sync.Mutex{}
 	cm.bindDriverLinkMutex(ch.ID, d)
 	cm.wireBACnetAddressNotifier(ch.ID, d)
+	cm.wireValuePublisher(ch.ID, d)
 	cm.stateManager.RegisterNode(ch.ID, ch.Name)
 
 	// Register all devices in state manager
@@ -520,6 +555,11 @@ func (cm *ChannelManager) UpdateChannel(ch *model.Channel) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	// API 响应不回传 BACnet/SC 私钥，更新时未提供则沿用已保存的证书
+	if old, ok := cm.channels[ch.ID]; ok {
+		model.MergeChannelConfigSecrets(old.Config, ch.Config)
+	}
+
 	if ch.Protocol == "opc-ua" {
 		model.NormalizeOpcUaChannelConfig(ch.Config)
 	}
@@ -556,6 +596,7 @@ func (cm *ChannelManager) UpdateChannel(ch *model.Channel) error {
 	}
 	cm.bindDriverLinkMutex(ch.ID, d)
 	cm.wireBACnetAddressNotifier(ch.ID, d)
+	cm.wireValuePublisher(ch.ID, d)
 
 	// Register all devices in state manager
 	for _, dev := range ch.Devices {
@@ -592,6 +633,33 @@ func (cm *ChannelManager) RemoveChannel(channelID string) error {
 	return nil
 }
 
+// UnloadChannels stops every channel and drops it from the runtime without
+// touching the persisted configuration. Used when an HA node is demoted; the
+// channels are added again from config on promotion.
+func (cm *ChannelManager) UnloadChannels() {
+	cm.mu.RLock()
+	ids := make([]string, 0, len(cm.channels))
+	for id := range cm.channels {
+		ids = append(ids, id)
+	}
+	cm.mu.RUnlock()
+
+	for _, id := range ids {
+		_ = cm.StopChannel(id)
+	}
+
+	cm.mu.Lock()
+	for _, id := range ids {
+		delete(cm.channels, id)
+		delete(cm.drivers, id)
+		delete(cm.driverMus, id)
+	}
+	cm.mu.Unlock()
+
+	zap.L().Info("Channels unloaded", zap.Int("count", len(ids)))
+	cm.notifyTopologyChange()
+}
+
 // bindDriverLinkMutex wires channelMu into ConnectionManager for shared-link drivers.
 func (cm *ChannelManager) bindDriverLinkMutex(channelID string, d drv.Driver) {
 	mu := cm.driverMus[channelID]
@@ -1187,6 +1255,13 @@ func (cm *ChannelManager) validateEtherCATPoint(point *model.Point) error {
 
 // WritePoint 写入指定通道下设备点位的值
 func (cm *ChannelManager) WritePoint(channelID, deviceID, pointID string, value any) error {
+	if err := cm.checkWriteGuard(channelID, deviceID, pointID); err != nil {
+		return err
+	}
+	if err := cm.checkInterlocks(channelID, deviceID, pointID, value); err != nil {
+		return err
+	}
+
 	cm.mu.RLock()
 	ch, ok := cm.channels[channelID]
 	d, okDrv := cm.drivers[channelID]
@@ -1265,26 +1340,51 @@ func pointAllowsWrite(readWrite string) bool {
 }
 
 func (cm *ChannelManager) publishWrittenValue(channelID, deviceID, pointID string, value any) {
-	now := time.Now()
+	cm.publishValue(model.Value{
+		ChannelID: channelID,
+		DeviceID:  deviceID,
+		PointID:   pointID,
+		Value:     value,
+		Quality:   "Good",
+		TS:        time.Now(),
+	}, "write_point")
+}
+
+// publishDriverValue receives values pushed by drivers outside the scan cycle
+// (drv.ValuePublisher), e.g. OPC UA event notifications.
+func (cm *ChannelManager) publishDriverValue(v model.Value) {
+	if v.TS.IsZero() {
+		v.TS = time.Now()
+	}
+	if v.Quality == "" {
+		v.Quality = "Good"
+	}
+	cm.publishValue(v, "driver_event")
+}
+
+// publishValue 写入影子（经 ShadowBridge 扇出到 pipeline），无影子时直接推送 pipeline。
+func (cm *ChannelManager) publishValue(v model.Value, source string) {
 	if cm.shadowCore != nil {
 		msg := model.ShadowIngressMessage{
-			DeviceID:  deviceID,
-			ChannelID: channelID,
-			Timestamp: now,
+			DeviceID:  v.DeviceID,
+			ChannelID: v.ChannelID,
+			Timestamp: time.Now(),
 			Points: []model.ShadowIngressPoint{
 				{
-					PointID:     pointID,
-					Value:       value,
-					Quality:     "Good",
-					CollectedAt: now,
+					PointID:     v.PointID,
+					Value:       v.Value,
+					Quality:     v.Quality,
+					CollectedAt: v.TS,
+					Meta:        v.Meta,
 				},
 			},
-			Meta: model.ShadowIngressMeta{Source: "write_point"},
+			Meta: model.ShadowIngressMeta{Source: source},
 		}
 		if _, err := cm.shadowCore.WriteShadowDevice(msg); err != nil {
-			zap.L().Warn("Failed to sync write to shadow",
-				zap.String("device_id", deviceID),
-				zap.String("point_id", pointID),
+			zap.L().Warn("Failed to sync value to shadow",
+				zap.String("source", source),
+				zap.String("device_id", v.DeviceID),
+				zap.String("point_id", v.PointID),
 				zap.Error(err),
 			)
 		}
@@ -1292,14 +1392,7 @@ func (cm *ChannelManager) publishWrittenValue(channelID, deviceID, pointID strin
 	}
 
 	if cm.pipeline != nil {
-		cm.pipeline.Push(model.Value{
-			ChannelID: channelID,
-			DeviceID:  deviceID,
-			PointID:   pointID,
-			Value:     value,
-			Quality:   "Good",
-			TS:        now,
-		})
+		cm.pipeline.Push(v)
 	}
 }
 
@@ -2525,6 +2618,17 @@ func (cm *ChannelManager) wireBACnetAddressNotifier(channelID string, d drv.Driv
 	}
 }
 
+func (cm *ChannelManager) wireValuePublisher(channelID string, d drv.Driver) {
+	if setter, ok := d.(drv.ValuePublisherSetter); ok {
+		setter.SetValuePublisher(func(v model.Value) {
+			if v.ChannelID == "" {
+				v.ChannelID = channelID
+			}
+			cm.publishDriverValue(v)
+		})
+	}
+}
+
 // OnBACnetAddressDiscovered persists a runtime address change (e.g. UDP port after device reboot).
 func (cm *ChannelManager) OnBACnetAddressDiscovered(deviceKey, ip string, port int) {
 	if deviceKey == "" || ip == "" || port <= 0 {
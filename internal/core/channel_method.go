@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	drv "github.com/anviod/edgex/internal/driver"
+)
+
+// CallMethod invokes a server-side method (e.g. OPC UA Call) on a device and
+// returns its output arguments. Like WritePoint, the call runs under the
+// channel I/O lock with the device configuration applied, and is subject to
+// write interlocks keyed on the method ID (value is the single argument, or
+// the argument list when there are several).
+func (cm *ChannelManager) CallMethod(channelID, deviceID string, call drv.MethodCall) ([]any, error) {
+	if err := cm.checkWriteGuard(channelID, deviceID, call.MethodID); err != nil {
+		return nil, err
+	}
+	var value any = call.Args
+	if len(call.Args) == 1 {
+		value = call.Args[0]
+	}
+	if err := cm.checkInterlocks(channelID, deviceID, call.MethodID, value); err != nil {
+		return nil, err
+	}
+
+	cm.mu.RLock()
+	ch, ok := cm.channels[channelID]
+	d, okDrv := cm.drivers[channelID]
+	cm.mu.RUnlock()
+
+	if !ok || !okDrv {
+		return nil, fmt.Errorf("channel not found")
+	}
+	caller, ok := d.(drv.MethodCaller)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support method calls")
+	}
+	if call.MethodID == "" {
+		return nil, fmt.Errorf("method_id is required")
+	}
+
+	dev := cm.GetDevice(channelID, deviceID)
+	if dev == nil {
+		return nil, fmt.Errorf("device not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var outputs []any
+	err := cm.withDriverIO(channelID, ch.Protocol, func() error {
+		config := buildDriverDeviceConfig(ch, dev.Config, map[string]any{
+			"_internal_device_id": dev.ID,
+		})
+		d.SetDeviceConfig(config)
+		var err error
+		outputs, err = caller.CallMethod(ctx, call)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
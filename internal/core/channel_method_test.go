@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+const methodMockProtocol = "method-mock"
+
+type methodMockDriver struct {
+	addChannelMockDriver
+	lastCall  driver.MethodCall
+	publisher driver.ValuePublisher
+}
+
+func (m *methodMockDriver) CallMethod(_ context.Context, call driver.MethodCall) ([]any, error) {
+	m.lastCall = call
+	return []any{"ok", len(call.Args)}, nil
+}
+
+func (m *methodMockDriver) SetValuePublisher(p driver.ValuePublisher) {
+	m.publisher = p
+}
+
+func init() {
+	driver.RegisterDriver(methodMockProtocol, func() driver.Driver {
+		return &methodMockDriver{}
+	})
+}
+
+func TestChannelManager_CallMethodAndDriverEvents(t *testing.T) {
+	pipeline := NewDataPipeline(10)
+	got := make(chan model.Value, 1)
+	pipeline.AddHandler(func(v model.Value) { got <- v })
+	pipeline.Start()
+
+	cm := NewChannelManager(pipeline, nil)
+	defer cm.cancel()
+
+	channelID := "ch-method"
+	if err := cm.AddChannel(&model.Channel{
+		ID:       channelID,
+		Name:     "Method Channel",
+		Protocol: methodMockProtocol,
+		Config:   map[string]any{},
+		Devices:  []model.Device{{ID: "dev-1", Name: "Device"}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+	cm.mu.RLock()
+	mock := cm.drivers[channelID].(*methodMockDriver)
+	cm.mu.RUnlock()
+
+	out, err := cm.CallMethod(channelID, "dev-1", driver.MethodCall{ObjectID: "ns=2;s=Line", MethodID: "ns=2;s=Line.Start", Args: []any{1.0, "x"}})
+	if err != nil {
+		t.Fatalf("CallMethod: %v", err)
+	}
+	if len(out) != 2 || out[0] != "ok" || mock.lastCall.MethodID != "ns=2;s=Line.Start" {
+		t.Fatalf("outputs = %v, call = %+v", out, mock.lastCall)
+	}
+	if _, err := cm.CallMethod(channelID, "dev-1", driver.MethodCall{}); err == nil {
+		t.Fatal("expected error for missing method_id")
+	}
+	if _, err := cm.CallMethod(channelID, "missing", driver.MethodCall{MethodID: "i=1"}); err == nil {
+		t.Fatal("expected device not found error")
+	}
+
+	if mock.publisher == nil {
+		t.Fatal("value publisher was not wired")
+	}
+	mock.publisher(model.Value{DeviceID: "dev-1", PointID: "alarms", Value: map[string]any{"Severity": 800}})
+	select {
+	case v := <-got:
+		if v.ChannelID != channelID || v.PointID != "alarms" || v.Quality != "Good" || v.TS.IsZero() {
+			t.Fatalf("published value = %+v", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("driver event was not published")
+	}
+}
+
+type methodTestWriter struct {
+	TestDeviceWriter
+	channelID, deviceID string
+	call                driver.MethodCall
+}
+
+func (w *methodTestWriter) CallMethod(channelID, deviceID string, call driver.MethodCall) ([]any, error) {
+	w.channelID, w.deviceID, w.call = channelID, deviceID, call
+	return nil, nil
+}
+
+func TestEdgeAction_DeviceControlMethod(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	writer := &methodTestWriter{}
+	em.SetDeviceWriter(writer)
+
+	action := model.RuleAction{
+		Type: "device_control",
+		Config: map[string]any{
+			"channel_id": "ch1",
+			"device_id":  "dev1",
+			"method": map[string]any{
+				"object_id": "ns=2;s=Line1",
+				"method_id": "ns=2;s=Line1.SetSpeed",
+				"args":      []any{"${v}", map[string]any{"type": "String", "value": "${mode}"}},
+			},
+		},
+	}
+	env := map[string]any{"v": 42, "mode": "auto"}
+	if err := em.executeSingleAction(context.Background(), "rule1", action, model.Value{Value: 42}, env); err != nil {
+		t.Fatalf("Execution failed: %v", err)
+	}
+	if writer.WriteCount != 0 {
+		t.Fatalf("method action must not write points")
+	}
+	if writer.channelID != "ch1" || writer.deviceID != "dev1" || writer.call.MethodID != "ns=2;s=Line1.SetSpeed" {
+		t.Fatalf("unexpected call %s/%s %+v", writer.channelID, writer.deviceID, writer.call)
+	}
+	if writer.call.Args[0] != "42" {
+		t.Errorf("template arg = %v", writer.call.Args[0])
+	}
+	if typed := writer.call.Args[1].(map[string]any); typed["value"] != "auto" || typed["type"] != "String" {
+		t.Errorf("typed arg = %v", typed)
+	}
+
+	delete(action.Config["method"].(map[string]any), "method_id")
+	if err := em.executeSingleAction(context.Background(), "rule1", action, model.Value{}, env); err == nil {
+		t.Fatal("expected error for missing method_id")
+	}
+}
@@ -310,3 +310,55 @@ func (m *DeviceStorageManager) GetHistoryByTimeRange(deviceID string, start, end
 
 	return records, err
 }
+
+// SourceHistory 按规则数据源读取设备历史快照，展开为按时间升序的点位值，用于规则回放。
+// 规则输出（rules 通道）没有设备历史，跳过。
+func (m *DeviceStorageManager) SourceHistory(sources []model.RuleSource, start, end time.Time, limit int) ([]model.Value, error) {
+	if m.storage == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var devices []string
+	bySource := make(map[string][]model.RuleSource)
+	for _, src := range sources {
+		if src.DeviceID == "" || src.PointID == "" || src.ChannelID == RuleOutputChannel {
+			continue
+		}
+		if _, ok := bySource[src.DeviceID]; !ok {
+			devices = append(devices, src.DeviceID)
+		}
+		bySource[src.DeviceID] = append(bySource[src.DeviceID], src)
+	}
+
+	var values []model.Value
+	for _, deviceID := range devices {
+		records, err := m.GetHistoryByTimeRange(deviceID, start, end, limit)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, rec := range records {
+			sec, _ := toFloat(rec["ts"])
+			data, _ := rec["data"].(map[string]any)
+			ts := time.Unix(int64(sec), 0)
+			for _, src := range bySource[deviceID] {
+				v, ok := data[src.PointID]
+				if !ok || seen[src.PointID+"\x00"+ts.String()] {
+					continue
+				}
+				seen[src.PointID+"\x00"+ts.String()] = true
+				values = append(values, model.Value{
+					ChannelID: src.ChannelID,
+					DeviceID:  deviceID,
+					PointID:   src.PointID,
+					Value:     v,
+					Quality:   "Good",
+					TS:        ts,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(values, func(i, j int) bool { return values[i].TS.Before(values[j].TS) })
+	return values, nil
+}
@@ -10,6 +10,7 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
@@ -17,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	drv "github.com/anviod/edgex/internal/driver"
 	"github.com/anviod/edgex/internal/model"
 	"github.com/anviod/edgex/internal/storage"
 
@@ -41,6 +43,8 @@ type EdgeComputeManager struct {
 	rules      map[string]model.EdgeRule
 	pipeline   *DataPipeline
 	nbm        *NorthboundManager
+	notifier   *NotificationManager
+	recipes    *RecipeManager
 	cm         *ChannelManager
 	store      *storage.Storage
 	mu         sync.RWMutex
@@ -48,6 +52,24 @@ type EdgeComputeManager struct {
 	ruleStates map[string]*model.RuleRuntimeState
 	windows    map[string][]model.Value
 	stateMu    sync.RWMutex
+	// 有状态表达式函数的状态，Key: RuleID -> 调用位置
+	exprStates map[string]map[string]*model.ExprFuncState
+	exprMu     sync.Mutex
+
+	// Rule chaining: dependency level per rule (-1 = blocked by a cycle)
+	ruleLevels map[string]int
+	// 规则模板及其持久化函数；实例保存在 rules 中
+	templates    map[string]model.EdgeRuleTemplate
+	templateSave func([]model.EdgeRuleTemplate) error
+	shadow       *ShadowCore
+	// 规则版本历史（em.mu）与影子运行中的候选版本（shadowMu）
+	histories   map[string]*model.EdgeRuleHistory
+	historySave func(model.EdgeRuleHistory) error
+	shadowRuns  map[string]*ruleShadowRun
+	shadowMu    sync.RWMutex
+	// 规则仿真：替换时钟并记录求值轨迹，动作不执行（仅用于隔离的仿真实例）
+	now        func() time.Time
+	sim        *ruleSimulation
 	valueCache map[string]model.Value
 	cacheMu    sync.RWMutex
 
@@ -93,6 +115,12 @@ type DeviceIO interface {
 	ReadPoint(channelID, deviceID, pointID string) (model.Value, error)
 }
 
+// MethodInvoker is optionally implemented by the DeviceIO (ChannelManager) to
+// back device_control actions that call a server method instead of writing a point.
+type MethodInvoker interface {
+	CallMethod(channelID, deviceID string, call drv.MethodCall) ([]any, error)
+}
+
 type EdgeComputeMetrics struct {
 	WorkerPoolSize        int   `json:"worker_pool_size"`
 	WorkerPoolUsage       int   `json:"worker_pool_usage"`
@@ -122,6 +150,7 @@ func NewEdgeComputeManager(pipeline *DataPipeline, store *storage.Storage, saveF
 		saveFunc:    saveFunc,
 		ruleStates:  make(map[string]*model.RuleRuntimeState),
 		windows:     make(map[string][]model.Value),
+		exprStates:  make(map[string]map[string]*model.ExprFuncState),
 		valueCache:  make(map[string]model.Value),
 		minuteCache: make(map[string]*model.RuleMinuteSnapshot),
 		ruleIndex:   make(map[string][]string),
@@ -244,6 +273,14 @@ func (em *EdgeComputeManager) SetNorthboundManager(nbm *NorthboundManager) {
 	em.nbm = nbm
 }
 
+func (em *EdgeComputeManager) SetNotificationManager(nm *NotificationManager) {
+	em.notifier = nm
+}
+
+func (em *EdgeComputeManager) SetRecipeManager(rm *RecipeManager) {
+	em.recipes = rm
+}
+
 func (em *EdgeComputeManager) SetChannelManager(cm *ChannelManager) {
 	em.cm = cm
 	if em.writer == nil {
@@ -267,12 +304,28 @@ func (em *EdgeComputeManager) LoadRules(rules []model.EdgeRule) {
 	em.mu.Lock()
 	defer em.mu.Unlock()
 	for _, r := range rules {
+		normalizeRuleSources(&r)
 		em.rules[r.ID] = r
 	}
 	em.rebuildIndex()
+	em.refreshRuleGraph()
 	//log.Printf("Loaded %d edge computing rules", len(rules))
 }
 
+// ReplaceRules swaps the whole rule set, dropping rules not in rules (e.g.
+// after an HA node mirrored the configuration of its peer).
+func (em *EdgeComputeManager) ReplaceRules(rules []model.EdgeRule) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.rules = make(map[string]model.EdgeRule, len(rules))
+	for _, r := range rules {
+		normalizeRuleSources(&r)
+		em.rules[r.ID] = r
+	}
+	em.rebuildIndex()
+	em.refreshRuleGraph()
+}
+
 func (em *EdgeComputeManager) Start() {
 	// Restore state from DB
 	em.restoreState()
@@ -302,6 +355,7 @@ func (em *EdgeComputeManager) Stop() {
 		}
 		close(em.workerPool)
 		em.wg.Wait()
+		em.stopShadowRuns()
 	})
 }
 
@@ -375,6 +429,8 @@ func (em *EdgeComputeManager) handleValue(val model.Value) {
 	em.trimValueCacheLocked()
 	em.cacheMu.Unlock()
 
+	em.feedShadowRuns(val)
+
 	// Find Matched Rules via Index (O(1) lookup)
 	em.indexMu.RLock()
 	ruleIDs, exists := em.ruleIndex[cacheKey]
@@ -386,6 +442,7 @@ func (em *EdgeComputeManager) handleValue(val model.Value) {
 
 	em.mu.RLock()
 	var matchedRules []model.EdgeRule
+	levels := em.ruleLevels
 	for _, id := range ruleIDs {
 		if rule, ok := em.rules[id]; ok {
 			if rule.Enable {
@@ -409,9 +466,13 @@ func (em *EdgeComputeManager) handleValue(val model.Value) {
 	}
 	em.mu.RUnlock()
 
-	// Sort by Priority (High to Low) before debounce scheduling.
+	// Sort by dependency level (upstream first), then Priority (High to Low) before debounce scheduling.
 	if len(matchedRules) > 1 {
-		sort.Slice(matchedRules, func(i, j int) bool {
+		sort.SliceStable(matchedRules, func(i, j int) bool {
+			li, lj := levels[matchedRules[i].ID], levels[matchedRules[j].ID]
+			if li != lj {
+				return li < lj
+			}
 			return matchedRules[i].Priority > matchedRules[j].Priority
 		})
 	}
@@ -515,7 +576,7 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 	} else {
 		state.ExecutionPhase = "evaluate"
 	}
-	state.LastCheckTime = time.Now()
+	state.LastCheckTime = em.clock()
 	em.stateMu.Unlock()
 
 	// Prepare Env for Expression
@@ -575,6 +636,7 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 		}
 		em.cacheMu.RUnlock()
 	}
+	em.bindExprFuncs(rule.ID, "", env, em.clock(), staleSources(rule, val))
 
 	var rawTriggered bool
 	var err error
@@ -591,16 +653,52 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 			rawTriggered = true
 		}
 	case "window":
-		rawTriggered, outputVal, err = em.evaluateWindow(rule, val, env)
+		var results []windowResult
+		results, err = em.evaluateWindow(rule, val, env)
+		if err == nil && len(results) == 0 {
+			err = errWindowStepPending
+		}
+		if err == nil {
+			// 一次到达可能关闭多个窗口，按时间顺序逐个求值
+			for _, r := range results[:len(results)-1] {
+				em.finishEvaluation(rule, val, r.env, state, r.triggered, r.value, nil)
+			}
+			last := results[len(results)-1]
+			rawTriggered, outputVal, env = last.triggered, last.value, last.env
+		}
+	case "script":
+		var res any
+		rawTriggered, res, err = em.evaluateScriptRule(rule, val, env)
+		if err == nil {
+			outputVal.Value = res
+		}
 	default:
 		if rule.Condition != "" {
 			rawTriggered, err = evaluateThreshold(rule.Condition, env)
 		}
 	}
 
+	em.finishEvaluation(rule, val, env, state, rawTriggered, outputVal, err)
+}
+
+// finishEvaluation 处理一次求值结果：状态保持、触发与动作执行
+func (em *EdgeComputeManager) finishEvaluation(rule model.EdgeRule, val model.Value, env map[string]any, state *model.RuleRuntimeState, rawTriggered bool, outputVal model.Value, err error) {
 	if errors.Is(err, errWindowStepPending) {
+		em.sim.traceEvaluation(env, nil, false, err)
 		return
 	}
+	if err == nil {
+		var result any = rawTriggered
+		if rule.Type == "calculation" || rule.Type == "window" || rule.Type == "script" {
+			result = outputVal.Value
+		}
+		em.sim.traceEvaluation(env, result, rawTriggered, nil)
+		em.publishRuleOutputs(rule, val, result, env)
+	} else {
+		em.sim.traceEvaluation(env, nil, false, err)
+	}
+	// 动作中的子表达式不共享规则条件的函数状态
+	unbindExprFuncs(env)
 
 	em.stateMu.Lock()
 	defer em.stateMu.Unlock()
@@ -637,7 +735,7 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 		state.ExecutionActionIndex = 0
 	} else {
 		if state.ConditionStart.IsZero() {
-			state.ConditionStart = time.Now()
+			state.ConditionStart = em.clock()
 		}
 		state.ConditionCount++
 
@@ -645,7 +743,7 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 		if rule.State != nil {
 			if rule.State.Duration != "" {
 				if dur, err := time.ParseDuration(rule.State.Duration); err == nil {
-					if time.Since(state.ConditionStart) < dur {
+					if em.clock().Sub(state.ConditionStart) < dur {
 						constraintsMet = false
 						state.CurrentStatus = "WARNING"
 					}
@@ -668,6 +766,8 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 		}
 	}
 
+	em.sim.traceHold(rule, state, em.clock())
+
 	if !finalTriggered {
 		em.recordMinuteSnapshot(state)
 		return
@@ -675,7 +775,7 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 
 	prevStatus := state.CurrentStatus
 
-	state.LastTrigger = time.Now()
+	state.LastTrigger = em.clock()
 	state.TriggerCount++
 	state.CurrentStatus = "ALARM"
 	state.LastValue = outputVal.Value
@@ -702,6 +802,12 @@ func (em *EdgeComputeManager) executeRule(rule model.EdgeRule, val model.Value)
 		}
 	}
 
+	if em.sim != nil {
+		// 仿真只记录将要执行的动作
+		em.sim.traceActions(rule.Actions, shouldExecute)
+		shouldExecute = false
+	}
+
 	if shouldExecute && len(rule.Actions) > 0 {
 		state.ExecutionPhase = "action"
 		state.ExecutionActionIndex = 0
@@ -811,143 +917,6 @@ func (em *EdgeComputeManager) QueryLogs(start, end time.Time, ruleID string) ([]
 	return logs, err
 }
 
-func (em *EdgeComputeManager) evaluateWindow(rule model.EdgeRule, val model.Value, baseEnv map[string]any) (bool, model.Value, error) {
-	if rule.Window == nil {
-		return false, val, fmt.Errorf("missing window config")
-	}
-
-	em.stateMu.Lock()
-	history := em.windows[rule.ID]
-	history = append(history, val)
-
-	// Window Logic (Simplified: Count based or Time based)
-	// For now, assume Size is duration "10s" or count "10"
-	// Parse Size
-	sizeDur, errDur := time.ParseDuration(rule.Window.Size)
-	isTimeWindow := errDur == nil
-
-	var filtered []model.Value
-	if isTimeWindow {
-		cutoff := val.TS.Add(-sizeDur)
-		for _, v := range history {
-			if v.TS.After(cutoff) || v.TS.Equal(cutoff) {
-				filtered = append(filtered, v)
-			}
-		}
-	} else {
-		// Count window
-		count := 10 // Default
-		fmt.Sscanf(rule.Window.Size, "%d", &count)
-		if len(history) > count {
-			filtered = history[len(history)-count:]
-		} else {
-			filtered = history
-		}
-	}
-
-	em.windows[rule.ID] = em.trimWindowSamples(filtered)
-
-	// Sliding/tumbling step: buffer always, aggregate only on Interval tick.
-	if rule.Window.Interval != "" {
-		if step, err := time.ParseDuration(rule.Window.Interval); err == nil && step > 0 {
-			state := em.ruleStates[rule.ID]
-			lastEval := time.Time{}
-			if state != nil {
-				lastEval = state.LastWindowEval
-			}
-			if !lastEval.IsZero() && time.Since(lastEval) < step {
-				em.stateMu.Unlock()
-				go em.saveWindowData(rule.ID)
-				return false, val, errWindowStepPending
-			}
-		}
-	}
-	em.stateMu.Unlock()
-
-	// Persist window data asynchronously
-	go em.saveWindowData(rule.ID)
-
-	// Aggregation
-	var result float64
-	var count int
-	var minVal, maxVal float64
-	var firstVal, lastVal float64
-	var firstTime, lastTime time.Time
-
-	for i, v := range filtered {
-		f, ok := toFloat(v.Value)
-		if ok {
-			if i == 0 {
-				minVal = f
-				maxVal = f
-				firstVal = f
-				firstTime = v.TS
-			}
-			lastVal = f
-			lastTime = v.TS
-
-			switch rule.Window.AggrFunc {
-			case "sum", "avg":
-				result += f
-			case "max":
-				if f > maxVal {
-					maxVal = f
-				}
-			case "min":
-				if f < minVal {
-					minVal = f
-				}
-			}
-			count++
-		}
-	}
-
-	switch rule.Window.AggrFunc {
-	case "max":
-		result = maxVal
-	case "min":
-		result = minVal
-	case "avg":
-		if count > 0 {
-			result = result / float64(count)
-		}
-	case "count":
-		result = float64(count)
-	case "rate":
-		// (Last - First) / Duration (in seconds)
-		if count > 1 {
-			duration := lastTime.Sub(firstTime).Seconds()
-			if duration > 0 {
-				result = (lastVal - firstVal) / duration
-			} else {
-				result = 0
-			}
-		} else {
-			result = 0
-		}
-	}
-
-	// Evaluate Condition against Result
-	env := make(map[string]any)
-	for k, v := range baseEnv {
-		env[k] = v
-	}
-	env["value"] = result
-
-	triggered, err := evaluateThreshold(rule.Condition, env)
-
-	outputVal := val
-	outputVal.Value = result
-
-	em.stateMu.Lock()
-	if state := em.ruleStates[rule.ID]; state != nil {
-		state.LastWindowEval = time.Now()
-	}
-	em.stateMu.Unlock()
-
-	return triggered, outputVal, err
-}
-
 // evaluateState is removed as logic is merged into executeRule
 
 func toFloat(v any) (float64, bool) {
@@ -989,6 +958,8 @@ func (em *EdgeComputeManager) GetRuleStates() map[string]*model.RuleRuntimeState
 	for k, v := range em.ruleStates {
 		// Deep copy or shallow copy? Ptr is fine if we don't modify it outside
 		c := *v
+		c.ExprState = em.cloneExprState(k)
+		c.Windows = cloneWindowStates(v.Windows)
 		copy[k] = &c
 	}
 	return copy
@@ -998,13 +969,30 @@ func (em *EdgeComputeManager) GetWindowData(ruleID string) []model.Value {
 	em.stateMu.RLock()
 	defer em.stateMu.RUnlock()
 
-	if data, ok := em.windows[ruleID]; ok {
-		// Return a copy
-		res := make([]model.Value, len(data))
-		copy(res, data)
-		return res
+	res := []model.Value{}
+	for key, data := range em.windows {
+		if key == ruleID || strings.HasPrefix(key, ruleID+"|") {
+			res = append(res, data...)
+		}
 	}
-	return []model.Value{}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].TS.Before(res[j].TS) })
+	return res
+}
+
+// GetWindowDataByKey 返回按 key_by 分组的窗口样本，未分组的规则键为空字符串
+func (em *EdgeComputeManager) GetWindowDataByKey(ruleID string) map[string][]model.Value {
+	em.stateMu.RLock()
+	defer em.stateMu.RUnlock()
+
+	res := make(map[string][]model.Value)
+	for key, data := range em.windows {
+		if key == ruleID {
+			res[""] = cloneValues(data)
+		} else if k, ok := strings.CutPrefix(key, ruleID+"|"); ok {
+			res[k] = cloneValues(data)
+		}
+	}
+	return res
 }
 
 var bitAccessRegex = regexp.MustCompile(`\b([a-zA-Z_]\w*)\.(?:bit\.)?(\d+)\b`)
@@ -1013,6 +1001,7 @@ var bitSetRegex = regexp.MustCompile(`^bitset\((\d+),\s*(\d+)\)$`)
 var bitSetValueRegex = regexp.MustCompile(`^bitset\((\d+),\s*value\)$`)
 
 func preprocessExpression(input string) string {
+	input = injectStatefulCallKeys(input)
 	return bitAccessRegex.ReplaceAllStringFunc(input, func(match string) string {
 		submatches := bitAccessRegex.FindStringSubmatch(match)
 		if len(submatches) == 3 {
@@ -1070,6 +1059,11 @@ func prepareExprEnv(env map[string]any) map[string]any {
 	if _, ok := env["bitand"]; ok {
 		return env
 	}
+	// 规则执行时已绑定带持久化状态的版本；其他求值路径（动作检查、设备控制等）使用一次性状态
+	if _, ok := env["prev"]; !ok {
+		(&exprFuncs{now: time.Now(), mu: &sync.Mutex{}, states: make(map[string]*model.ExprFuncState)}).bind(env)
+	}
+	env["lookup"] = exprLookup
 
 	env["bitand"] = func(a, b any) (int64, error) { return bitwiseOp(a, b, func(x, y int64) int64 { return x & y }) }
 	env["bitor"] = func(a, b any) (int64, error) { return bitwiseOp(a, b, func(x, y int64) int64 { return x | y }) }
@@ -1353,6 +1347,12 @@ func (em *EdgeComputeManager) executeSingleAction(ctx context.Context, ruleID st
 		return em.executeDatabase(ctx, ruleID, action, val, env)
 	case "http":
 		return em.executeHttp(ctx, ruleID, action, val, env)
+	case "script":
+		return em.executeScript(ctx, ruleID, action, val, env)
+	case "notify":
+		return em.executeNotify(ctx, ruleID, action, val, env)
+	case "recipe":
+		return em.executeRecipe(ruleID, action, val, env)
 	default:
 		return fmt.Errorf("unsupported action type: %s", action.Type)
 	}
@@ -1770,11 +1770,84 @@ func (em *EdgeComputeManager) executeHttp(ctx context.Context, ruleID string, ac
 	return nil
 }
 
+// executeNotify 按通知策略发送告警，subject/message 支持 ${alias} 变量
+func (em *EdgeComputeManager) executeNotify(ctx context.Context, ruleID string, action model.RuleAction, val model.Value, env map[string]any) error {
+	if em.notifier == nil {
+		return fmt.Errorf("NotificationManager not available")
+	}
+	policyID, _ := action.Config["policy_id"].(string)
+	if policyID == "" {
+		return fmt.Errorf("notify action requires policy_id")
+	}
+	expand := func(s string) string {
+		return os.Expand(s, func(k string) string {
+			switch k {
+			case "rule_id":
+				return ruleID
+			case "value":
+				return fmt.Sprintf("%v", val.Value)
+			}
+			if v, ok := env[k]; ok {
+				return fmt.Sprintf("%v", v)
+			}
+			return ""
+		})
+	}
+	severity, _ := action.Config["severity"].(string)
+	subject, _ := action.Config["subject"].(string)
+	message, _ := action.Config["message"].(string)
+	key, _ := action.Config["key"].(string)
+	if subject == "" {
+		subject = fmt.Sprintf("规则 %s 触发告警", ruleID)
+	}
+	if message == "" {
+		message = fmt.Sprintf("%s/%s/%s = %v", val.ChannelID, val.DeviceID, val.PointID, val.Value)
+	}
+	_, err := em.notifier.Notify(ctx, NotifyRequest{
+		PolicyID: policyID,
+		RuleID:   ruleID,
+		Key:      expand(key),
+		Severity: severity,
+		Subject:  expand(subject),
+		Message:  expand(message),
+	})
+	return err
+}
+
+// executeRecipe 启动配方，params 中的字符串值支持 ${alias} 变量（整串引用时保留原类型）
+func (em *EdgeComputeManager) executeRecipe(ruleID string, action model.RuleAction, val model.Value, env map[string]any) error {
+	if em.recipes == nil {
+		return fmt.Errorf("RecipeManager not available")
+	}
+	recipeID, _ := action.Config["recipe_id"].(string)
+	if recipeID == "" {
+		return fmt.Errorf("recipe action requires recipe_id")
+	}
+	vars := make(map[string]any, len(env)+2)
+	for k, v := range env {
+		vars[k] = v
+	}
+	vars["rule_id"] = ruleID
+	vars["value"] = val.Value
+	params := make(map[string]any)
+	if raw, ok := action.Config["params"].(map[string]any); ok {
+		for k, v := range raw {
+			params[k] = resolveRecipeValue(v, vars)
+		}
+	}
+	_, err := em.recipes.StartRun(recipeID, params, "rule:"+ruleID)
+	return err
+}
+
 func (em *EdgeComputeManager) executeDeviceControl(ctx context.Context, ruleID string, action model.RuleAction, val model.Value, env map[string]any) error {
 	if em.writer == nil {
 		return fmt.Errorf("DeviceWriter not available")
 	}
 
+	if method, ok := action.Config["method"].(map[string]any); ok {
+		return em.executeMethodCall(ruleID, action, method, env)
+	}
+
 	if targets, ok := action.Config["targets"].([]interface{}); ok && len(targets) > 0 {
 		var errs []error
 		for _, t := range targets {
@@ -1909,31 +1982,90 @@ func (em *EdgeComputeManager) executeDeviceControl(ctx context.Context, ruleID s
 	return em.writer.WritePoint(channelID, deviceID, pointID, valToWrite)
 }
 
+// executeMethodCall handles the device_control method variant:
+//
+//	{"channel_id": "...", "device_id": "...", "method": {"object_id": "ns=2;s=Line1", "method_id": "ns=2;s=Line1.Start", "args": [1, "${v}"]}}
+//
+// String arguments support ${var} templates like point writes.
+func (em *EdgeComputeManager) executeMethodCall(ruleID string, action model.RuleAction, method map[string]any, env map[string]any) error {
+	invoker, ok := em.writer.(MethodInvoker)
+	if !ok {
+		return fmt.Errorf("method calls not supported by device writer")
+	}
+	channelID, _ := action.Config["channel_id"].(string)
+	deviceID, _ := action.Config["device_id"].(string)
+	if channelID == "" || deviceID == "" {
+		return fmt.Errorf("missing channel_id or device_id")
+	}
+
+	call := drv.MethodCall{}
+	call.ObjectID, _ = method["object_id"].(string)
+	call.MethodID, _ = method["method_id"].(string)
+	if call.MethodID == "" {
+		return fmt.Errorf("missing method.method_id")
+	}
+	if args, ok := method["args"].([]any); ok {
+		call.Args = make([]any, len(args))
+		for i, a := range args {
+			if typed, ok := a.(map[string]any); ok && typed["type"] != nil {
+				resolved := make(map[string]any, len(typed))
+				for k, v := range typed {
+					resolved[k] = v
+				}
+				resolved["value"] = em.resolveValueTemplate(typed["value"], env)
+				call.Args[i] = resolved
+				continue
+			}
+			call.Args[i] = em.resolveValueTemplate(a, env)
+		}
+	}
+
+	outputs, err := invoker.CallMethod(channelID, deviceID, call)
+	if err != nil {
+		return err
+	}
+	log.Printf("[EdgeAction] Rule %s called method %s, outputs: %v", ruleID, call.MethodID, outputs)
+	return nil
+}
+
 // CRUD Operations
 
 func (em *EdgeComputeManager) UpsertRule(rule model.EdgeRule) error {
-	em.mu.Lock()
-	defer em.mu.Unlock()
+	return em.UpsertRuleBy(rule, "", "")
+}
 
+// prepareRuleLocked 规范化并校验待生效的规则。调用方需持有 em.mu
+func (em *EdgeComputeManager) prepareRuleLocked(rule *model.EdgeRule) error {
 	// Sanitize rule configuration to remove redundant UI data
-	em.sanitizeRule(&rule)
+	em.sanitizeRule(rule)
+	normalizeRuleSources(rule)
+	if err := em.checkManagedRule(rule.ID); err != nil {
+		return err
+	}
+	if rule.TemplateID != "" {
+		return fmt.Errorf("规则 template_id 无效: 模板实例只能由模板生成")
+	}
+	if err := validateRuleOutputs(*rule); err != nil {
+		return err
+	}
+	if err := validateRuleScripts(*rule); err != nil {
+		return err
+	}
+	if err := validateRuleWindow(*rule); err != nil {
+		return err
+	}
+	return em.checkRuleCycle(*rule)
+}
 
+// applyRuleLocked 替换规则、重建索引与依赖层级并持久化。调用方需持有 em.mu
+func (em *EdgeComputeManager) applyRuleLocked(rule model.EdgeRule) error {
 	// If update, remove old index entries first
-	if _, exists := em.rules[rule.ID]; exists {
-		// Note: We need to remove index entries for the OLD rule, not the new one
-		// But here we are holding the lock and overwriting.
-		// Ideally we should have removed it before.
-		// Since we overwrite the map, let's just use removeFromIndex with ruleID
-		// But removeFromIndex locks indexMu, so we need to be careful about deadlock if we hold mu.
-		// Actually, removeFromIndex uses indexMu, UpsertRule uses mu. Separate locks.
-		// BUT we need to call removeFromIndex OUTSIDE of mu if possible or just handle it carefully.
-		// However, removeFromIndex iterates ruleIndex.
-
-		// To be safe and clean:
-		// 1. We have the old rule in em.rules[rule.ID]
-		// 2. We can't call removeFromIndex while holding mu if removeFromIndex might need mu?
-		//    No, removeFromIndex only needs indexMu. UpsertRule holds mu.
-		//    So it is safe to call removeFromIndex inside UpsertRule.
+	if old, exists := em.rules[rule.ID]; exists {
+		// 表达式变化后调用位置不再对应，丢弃有状态函数的历史
+		if old.Condition != rule.Condition || old.Expression != rule.Expression || !reflect.DeepEqual(old.Outputs, rule.Outputs) {
+			em.resetExprState(rule.ID)
+		}
+		// removeFromIndex only needs indexMu, so it is safe to call while holding mu.
 		em.removeFromIndex(rule.ID)
 	}
 
@@ -1941,22 +2073,50 @@ func (em *EdgeComputeManager) UpsertRule(rule model.EdgeRule) error {
 
 	// Add new index
 	em.indexRule(rule)
+	em.refreshRuleGraph()
 
 	return em.persist()
 }
 
 func (em *EdgeComputeManager) DeleteRule(id string) error {
+	return em.DeleteRuleBy(id, "")
+}
+
+// DeleteRuleBy 删除规则并在版本历史中记录删除人；历史保留，可回滚恢复
+func (em *EdgeComputeManager) DeleteRuleBy(id, author string) error {
 	em.mu.Lock()
 	defer em.mu.Unlock()
 
 	if _, ok := em.rules[id]; !ok {
 		return fmt.Errorf("rule not found")
 	}
+	if err := em.checkManagedRule(id); err != nil {
+		return err
+	}
+
+	em.recordDeleteLocked(id, author)
+	em.dropRuleLocked(id)
+	em.refreshRuleGraph()
 
+	return em.persist()
+}
+
+// dropRuleLocked 移除规则及其索引、函数状态和输出影子设备。调用方需持有 em.mu
+func (em *EdgeComputeManager) dropRuleLocked(id string) {
 	em.removeFromIndex(id)
 	delete(em.rules, id)
+	em.resetExprState(id)
+	if em.shadow != nil {
+		em.shadow.DeleteVirtualShadowDevice(id)
+	}
+}
 
-	return em.persist()
+// CachedValue 返回规则值缓存中点位的最新值
+func (em *EdgeComputeManager) CachedValue(channelID, deviceID, pointID string) (model.Value, bool) {
+	em.cacheMu.RLock()
+	defer em.cacheMu.RUnlock()
+	v, ok := em.valueCache[fmt.Sprintf("%s/%s/%s", channelID, deviceID, pointID)]
+	return v, ok
 }
 
 func (em *EdgeComputeManager) GetRules() []model.EdgeRule {
@@ -1992,6 +2152,10 @@ func (em *EdgeComputeManager) ClearRuntimeState() {
 	em.windows = make(map[string][]model.Value)
 	em.stateMu.Unlock()
 
+	em.exprMu.Lock()
+	em.exprStates = make(map[string]map[string]*model.ExprFuncState)
+	em.exprMu.Unlock()
+
 	em.cacheMu.Lock()
 	em.valueCache = make(map[string]model.Value)
 	em.cacheMu.Unlock()
@@ -2020,6 +2184,12 @@ func (em *EdgeComputeManager) restoreState() {
 	em.store.LoadAll(storage.BucketRuleState, func(k, v []byte) error {
 		var state model.RuleRuntimeState
 		if err := json.Unmarshal(v, &state); err == nil {
+			if len(state.ExprState) > 0 {
+				em.exprMu.Lock()
+				em.exprStates[state.RuleID] = state.ExprState
+				em.exprMu.Unlock()
+				state.ExprState = nil
+			}
 			em.stateMu.Lock()
 			em.ruleStates[state.RuleID] = &state
 			em.stateMu.Unlock()
@@ -2049,10 +2219,12 @@ func (em *EdgeComputeManager) saveRuleState(ruleID string) {
 	var stateCopy model.RuleRuntimeState
 	if ok && statePtr != nil {
 		stateCopy = *statePtr // Shallow copy to avoid race during marshal
+		stateCopy.Windows = cloneWindowStates(statePtr.Windows)
 	}
 	em.stateMu.RUnlock()
 
 	if ok && statePtr != nil {
+		stateCopy.ExprState = em.cloneExprState(ruleID)
 		if err := em.store.SaveData(storage.BucketRuleState, ruleID, stateCopy); err != nil {
 			log.Printf("Failed to save rule state for %s: %v", ruleID, err)
 		}
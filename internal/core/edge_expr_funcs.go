@@ -0,0 +1,539 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// 有状态表达式函数：prev / delta / rate / integral / twa / stddev / percentile / changed。
+// 预处理时为每个调用注入调用位置键（如 rate("rate#1@x", x, "30s")），状态按「规则 + 调用位置」保存，
+// 随 saveRuleState 持久化，重启后累计值与历史不丢失。lookup 为无状态插值函数。
+// 参数为单个变量时键中带上变量名（@x）：多数据源规则中，只有该数据源本次更新时才采样，
+// 其他数据源触发时返回上次结果（changed 返回 false），避免重复采样缓存值。
+
+var statefulFuncNames = []string{"prev", "delta", "rate", "integral", "twa", "stddev", "percentile", "changed"}
+
+var statefulCallRegex = regexp.MustCompile(`\b(` + strings.Join(statefulFuncNames, "|") + `)\s*\(`)
+
+// statefulArgIdent 匹配调用的第一个参数为单个变量的情况
+var statefulArgIdent = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*[,)]`)
+
+// injectStatefulCallKeys 为有状态函数调用插入调用位置键作为第一个参数。
+// 跳过成员调用（x.rate(...)）与字符串字面量内的文本。
+func injectStatefulCallKeys(input string) string {
+	matches := statefulCallRegex.FindAllStringSubmatchIndex(input, -1)
+	if len(matches) == 0 {
+		return input
+	}
+	var b strings.Builder
+	last, n := 0, 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if start > 0 && input[start-1] == '.' || insideStringLiteral(input, start) {
+			continue
+		}
+		name := input[m[2]:m[3]]
+		if strings.HasPrefix(strings.TrimLeft(input[end:], " "), `"`+name+"#") {
+			// 已预处理过
+			continue
+		}
+		key := fmt.Sprintf("%s#%d", name, n)
+		if arg := statefulArgIdent.FindStringSubmatch(input[end:]); arg != nil {
+			key += "@" + arg[1]
+		}
+		b.WriteString(input[last:end])
+		fmt.Fprintf(&b, "%q, ", key)
+		n++
+		last = end
+	}
+	b.WriteString(input[last:])
+	return b.String()
+}
+
+func insideStringLiteral(s string, pos int) bool {
+	var quote byte
+	for i := 0; i < pos; i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+		}
+	}
+	return quote != 0
+}
+
+// exprFuncs 绑定到一次规则求值的有状态函数集合
+type exprFuncs struct {
+	now    time.Time
+	mu     *sync.Mutex
+	states map[string]*model.ExprFuncState
+	scope  string          // 同一规则内不同表达式（条件、输出点）的状态键前缀
+	stale  map[string]bool // 本次求值未更新的数据源变量名，对其调用不采样
+}
+
+// bindExprFuncs 将规则 ruleID 的有状态函数加入 env，scope 区分同一规则内的不同表达式，
+// stale 为本次未更新的数据源变量名（见 staleSources）
+func (em *EdgeComputeManager) bindExprFuncs(ruleID, scope string, env map[string]any, now time.Time, stale map[string]bool) {
+	em.exprMu.Lock()
+	states := em.exprStates[ruleID]
+	if states == nil {
+		states = make(map[string]*model.ExprFuncState)
+		em.exprStates[ruleID] = states
+	}
+	em.exprMu.Unlock()
+
+	(&exprFuncs{now: now, mu: &em.exprMu, states: states, scope: scope, stale: stale}).bind(env)
+}
+
+// staleSources 返回规则中不是由 val 触发的数据源变量名（别名与点位 ID）；
+// val 不属于任何数据源时返回 nil，此时所有调用照常采样
+func staleSources(rule model.EdgeRule, val model.Value) map[string]bool {
+	if len(rule.Sources) < 2 {
+		return nil
+	}
+	fresh := make(map[string]bool, 2)
+	for _, src := range rule.Sources {
+		if matchSource(src, val) {
+			fresh[src.Alias] = true
+			fresh[src.PointID] = true
+		}
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+	stale := make(map[string]bool, 2*len(rule.Sources))
+	for _, src := range rule.Sources {
+		for _, name := range []string{src.Alias, src.PointID} {
+			if name != "" && !fresh[name] {
+				stale[name] = true
+			}
+		}
+	}
+	return stale
+}
+
+// unbindExprFuncs 从 env 移除有状态函数，之后的动作求值改用一次性状态
+func unbindExprFuncs(env map[string]any) {
+	for _, name := range statefulFuncNames {
+		delete(env, name)
+	}
+}
+
+// cloneExprState 复制规则的函数状态，用于持久化与查询
+func (em *EdgeComputeManager) cloneExprState(ruleID string) map[string]*model.ExprFuncState {
+	em.exprMu.Lock()
+	defer em.exprMu.Unlock()
+
+	states := em.exprStates[ruleID]
+	if len(states) == 0 {
+		return nil
+	}
+	out := make(map[string]*model.ExprFuncState, len(states))
+	for k, st := range states {
+		c := *st
+		c.Samples = append([]model.ExprSample(nil), st.Samples...)
+		out[k] = &c
+	}
+	return out
+}
+
+// resetExprState 丢弃规则的函数状态（表达式变化或规则删除时）
+func (em *EdgeComputeManager) resetExprState(ruleID string) {
+	em.exprMu.Lock()
+	delete(em.exprStates, ruleID)
+	em.exprMu.Unlock()
+}
+
+func (f *exprFuncs) bind(env map[string]any) {
+	env["prev"] = f.wrap("prev", 1, 2, f.prev)
+	env["delta"] = f.wrap("delta", 1, 2, f.delta)
+	env["rate"] = f.wrap("rate", 1, 2, f.rate)
+	env["integral"] = f.wrap("integral", 1, 2, f.integral)
+	env["twa"] = f.wrap("twa", 2, 2, f.twa)
+	env["stddev"] = f.wrap("stddev", 2, 2, f.stddev)
+	env["percentile"] = f.wrap("percentile", 3, 3, f.percentile)
+	env["changed"] = f.wrap("changed", 1, 1, f.changed)
+}
+
+// wrap 校验参数个数（不含调用位置键）并在锁内取出对应状态
+func (f *exprFuncs) wrap(name string, min, max int, fn func(st *model.ExprFuncState, args []any) (any, error)) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s: missing arguments", name)
+		}
+		key, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid call", name)
+		}
+		args = args[1:]
+		if len(args) < min || len(args) > max {
+			return nil, fmt.Errorf("%s: expected %d to %d arguments, got %d", name, min, max, len(args))
+		}
+
+		var source string
+		if i := strings.LastIndexByte(key, '@'); i >= 0 {
+			source = key[i+1:]
+		}
+		key = f.scope + key
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		st := f.states[key]
+		if st == nil {
+			st = &model.ExprFuncState{}
+			f.states[key] = st
+		}
+		if source != "" && f.stale[source] {
+			// 数据源未更新：不采样，返回上次结果
+			if name == "changed" {
+				return false, nil
+			}
+			if st.Last != nil {
+				return st.Last, nil
+			}
+			c := *st
+			c.Samples = append([]model.ExprSample(nil), st.Samples...)
+			return fn(&c, args)
+		}
+		res, err := fn(st, args)
+		if err == nil {
+			st.Last = res
+		}
+		return res, err
+	}
+}
+
+// prev(x) 上一次求值时的 x；prev(x, "5m") 约 5 分钟前的 x（历史不足时取最早值）
+func (f *exprFuncs) prev(st *model.ExprFuncState, args []any) (any, error) {
+	x := args[0]
+	if len(args) == 1 {
+		res := x
+		if st.HasPrev {
+			res = st.Prev
+		}
+		st.HasPrev, st.Prev, st.PrevTS = true, x, f.now
+		return res, nil
+	}
+	start, horizon, err := parseExprWindow(args[1], f.now)
+	if err != nil {
+		return nil, err
+	}
+	var res any = x
+	if s, ok := sampleAtOrBefore(st.Samples, start); ok {
+		res = s.V
+	}
+	f.record(st, x, horizon)
+	return res, nil
+}
+
+// delta(x[, window]) = x - prev(x[, window])
+func (f *exprFuncs) delta(st *model.ExprFuncState, args []any) (any, error) {
+	x, ok := exprNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("delta: value is not numeric")
+	}
+	p, err := f.prev(st, args)
+	if err != nil {
+		return nil, err
+	}
+	pv, ok := exprNumber(p)
+	if !ok {
+		return 0.0, nil
+	}
+	return x - pv, nil
+}
+
+// rate(x[, window]) 每秒变化率；无窗口时相对上一次求值
+func (f *exprFuncs) rate(st *model.ExprFuncState, args []any) (any, error) {
+	x, ok := exprNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("rate: value is not numeric")
+	}
+	if len(args) == 1 {
+		res := 0.0
+		if pv, ok := exprNumber(st.Prev); ok && st.HasPrev {
+			if dt := f.now.Sub(st.PrevTS).Seconds(); dt > 0 {
+				res = (x - pv) / dt
+			}
+		}
+		st.HasPrev, st.Prev, st.PrevTS = true, x, f.now
+		return res, nil
+	}
+	start, horizon, err := parseExprWindow(args[1], f.now)
+	if err != nil {
+		return nil, err
+	}
+	res := 0.0
+	base, ok := sampleAtOrBefore(st.Samples, start)
+	if ok {
+		if dt := f.now.Sub(base.TS).Seconds(); dt > 0 {
+			res = (x - base.V) / dt
+		}
+	}
+	f.record(st, x, horizon)
+	return res, nil
+}
+
+// integral(x[, "HH:MM"]) 按梯形法对时间（秒）积分；给出时刻时每天在该时刻清零（如班次起点）
+func (f *exprFuncs) integral(st *model.ExprFuncState, args []any) (any, error) {
+	x, ok := exprNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("integral: value is not numeric")
+	}
+	from := st.PrevTS
+	if len(args) == 2 {
+		clock, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("integral: reset must be a \"HH:MM\" string")
+		}
+		period, err := lastClockTime(clock, f.now)
+		if err != nil {
+			return nil, err
+		}
+		if !st.Period.Equal(period) {
+			if !st.Period.IsZero() {
+				st.Total = 0
+			}
+			st.Period = period
+		}
+		if from.Before(period) {
+			from = period
+		}
+	}
+	if pv, ok := exprNumber(st.Prev); ok && st.HasPrev {
+		if dt := f.now.Sub(from).Seconds(); dt > 0 {
+			st.Total += (pv + x) / 2 * dt
+		}
+	}
+	st.HasPrev, st.Prev, st.PrevTS = true, x, f.now
+	return st.Total, nil
+}
+
+// twa(x, window) 时间加权平均，样本值保持到下一个样本
+func (f *exprFuncs) twa(st *model.ExprFuncState, args []any) (any, error) {
+	x, ok := exprNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("twa: value is not numeric")
+	}
+	start, horizon, err := parseExprWindow(args[1], f.now)
+	if err != nil {
+		return nil, err
+	}
+	f.record(st, x, horizon)
+
+	var area, span float64
+	var cur *model.ExprSample
+	curTS := start
+	for i := range st.Samples {
+		s := &st.Samples[i]
+		if !s.TS.After(start) {
+			cur = s
+			continue
+		}
+		if cur != nil {
+			d := s.TS.Sub(curTS).Seconds()
+			area += cur.V * d
+			span += d
+		}
+		cur, curTS = s, s.TS
+	}
+	if span <= 0 {
+		return x, nil
+	}
+	return area / span, nil
+}
+
+// stddev(x, window) 窗口内样本的总体标准差
+func (f *exprFuncs) stddev(st *model.ExprFuncState, args []any) (any, error) {
+	values, err := f.windowValues("stddev", st, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(values))), nil
+}
+
+// percentile(x, p, window) 窗口内样本的第 p 百分位（0-100，线性插值）
+func (f *exprFuncs) percentile(st *model.ExprFuncState, args []any) (any, error) {
+	p, ok := exprNumber(args[1])
+	if !ok || p < 0 || p > 100 {
+		return nil, fmt.Errorf("percentile: p must be between 0 and 100")
+	}
+	values, err := f.windowValues("percentile", st, args[0], args[2])
+	if err != nil {
+		return nil, err
+	}
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return values[lo] + (values[hi]-values[lo])*(rank-float64(lo)), nil
+}
+
+// changed(x) x 与上一次求值不同时为 true，首次求值为 false
+func (f *exprFuncs) changed(st *model.ExprFuncState, args []any) (any, error) {
+	x := args[0]
+	res := st.HasPrev && !exprValuesEqual(st.Prev, x)
+	st.HasPrev, st.Prev, st.PrevTS = true, x, f.now
+	return res, nil
+}
+
+// windowValues 记录 x 并返回窗口内（含当前值）的全部样本值
+func (f *exprFuncs) windowValues(name string, st *model.ExprFuncState, xArg, window any) ([]float64, error) {
+	x, ok := exprNumber(xArg)
+	if !ok {
+		return nil, fmt.Errorf("%s: value is not numeric", name)
+	}
+	start, horizon, err := parseExprWindow(window, f.now)
+	if err != nil {
+		return nil, err
+	}
+	f.record(st, x, horizon)
+	values := make([]float64, 0, len(st.Samples))
+	for _, s := range st.Samples {
+		if !s.TS.Before(start) {
+			values = append(values, s.V)
+		}
+	}
+	if len(values) == 0 {
+		values = append(values, x)
+	}
+	return values, nil
+}
+
+// record 追加样本并裁剪到最长窗口（保留窗口起点之前的一个样本供 prev/twa 使用）
+func (f *exprFuncs) record(st *model.ExprFuncState, x any, horizon time.Duration) {
+	if h := horizon.Milliseconds(); h > st.Horizon {
+		st.Horizon = h
+	}
+	if v, ok := exprNumber(x); ok {
+		st.Samples = append(st.Samples, model.ExprSample{TS: f.now, V: v})
+	}
+	cutoff := f.now.Add(-time.Duration(st.Horizon) * time.Millisecond)
+	drop := 0
+	for drop+1 < len(st.Samples) && !st.Samples[drop+1].TS.After(cutoff) {
+		drop++
+	}
+	if over := len(st.Samples) - drop - maxEdgeWindowSamples; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		st.Samples = append(st.Samples[:0], st.Samples[drop:]...)
+	}
+}
+
+func sampleAtOrBefore(samples []model.ExprSample, t time.Time) (model.ExprSample, bool) {
+	if len(samples) == 0 {
+		return model.ExprSample{}, false
+	}
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].TS.After(t) })
+	if i == 0 {
+		return samples[0], true
+	}
+	return samples[i-1], true
+}
+
+// parseExprWindow 解析窗口参数：时长（"30s"、"5m"）、秒数，或 "HH:MM"（最近一次到达该时刻以来）。
+// 返回窗口起点与需要保留的历史长度。
+func parseExprWindow(arg any, now time.Time) (time.Time, time.Duration, error) {
+	switch w := arg.(type) {
+	case string:
+		if d, err := time.ParseDuration(w); err == nil && d > 0 {
+			return now.Add(-d), d, nil
+		}
+		if start, err := lastClockTime(w, now); err == nil {
+			return start, 24 * time.Hour, nil
+		}
+		return time.Time{}, 0, fmt.Errorf("invalid window %q", w)
+	default:
+		if sec, ok := exprNumber(arg); ok && sec > 0 {
+			d := time.Duration(sec * float64(time.Second))
+			return now.Add(-d), d, nil
+		}
+		return time.Time{}, 0, fmt.Errorf("invalid window %v", arg)
+	}
+}
+
+// lastClockTime 返回 now 之前最近一次本地时刻 HH:MM
+func lastClockTime(clock string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time of day %q", clock)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start, nil
+}
+
+func exprNumber(v any) (float64, bool) {
+	f, ok := toFloat(v)
+	if !ok || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+func exprValuesEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// exprLookup 按 [[x0, y0], [x1, y1], ...]（x 递增）线性插值，超出范围取端点值
+func exprLookup(table any, xArg any) (float64, error) {
+	x, ok := exprNumber(xArg)
+	if !ok {
+		return 0, fmt.Errorf("lookup: value is not numeric")
+	}
+	rows, ok := table.([]any)
+	if !ok || len(rows) == 0 {
+		return 0, fmt.Errorf("lookup: table must be a list of [x, y] pairs")
+	}
+	xs := make([]float64, len(rows))
+	ys := make([]float64, len(rows))
+	for i, r := range rows {
+		pair, ok := r.([]any)
+		if !ok || len(pair) != 2 {
+			return 0, fmt.Errorf("lookup: row %d must be [x, y]", i)
+		}
+		px, ok1 := exprNumber(pair[0])
+		py, ok2 := exprNumber(pair[1])
+		if !ok1 || !ok2 || (i > 0 && px <= xs[i-1]) {
+			return 0, fmt.Errorf("lookup: row %d must be numeric with increasing x", i)
+		}
+		xs[i], ys[i] = px, py
+	}
+	if x <= xs[0] {
+		return ys[0], nil
+	}
+	for i := 1; i < len(xs); i++ {
+		if x <= xs[i] {
+			return ys[i-1] + (ys[i]-ys[i-1])*(x-xs[i-1])/(xs[i]-xs[i-1]), nil
+		}
+	}
+	return ys[len(ys)-1], nil
+}
@@ -0,0 +1,213 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+// evalAt 在指定时刻以给定的函数状态求值表达式
+func evalAt(t *testing.T, em *EdgeComputeManager, ruleID, expression string, now time.Time, x float64) float64 {
+	t.Helper()
+	env := map[string]any{"x": x}
+	em.bindExprFuncs(ruleID, "", env, now, nil)
+	res, err := evaluateCalculation(expression, env)
+	if err != nil {
+		t.Fatalf("%s: %v", expression, err)
+	}
+	f, ok := toFloat(res)
+	if !ok {
+		t.Fatalf("%s: non-numeric result %v", expression, res)
+	}
+	return f
+}
+
+func TestInjectStatefulCallKeys(t *testing.T) {
+	cases := map[string]string{
+		"rate(x) > 5":                   `rate("rate#0@x", x) > 5`,
+		"delta(prev(x), '5m')":          `delta("delta#0", prev("prev#1@x", x), '5m')`,
+		"obj.rate(x) + rate (y)":        `obj.rate(x) + rate ("rate#0@y", y)`,
+		"rate(a + b)":                   `rate("rate#0", a + b)`,
+		`"prev(x)" == s`:                `"prev(x)" == s`,
+		`changed("changed#0", x)`:       `changed("changed#0", x)`,
+		"percentile(x, 95, '1h') > 100": `percentile("percentile#0@x", x, 95, '1h') > 100`,
+	}
+	for in, want := range cases {
+		if got := injectStatefulCallKeys(in); got != want {
+			t.Errorf("%q => %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExprFuncsPrevDeltaRateChanged(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	t0 := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+
+	if got := evalAt(t, em, "r", "prev(x)", t0, 10); got != 10 {
+		t.Fatalf("first prev = %v", got)
+	}
+	if got := evalAt(t, em, "r", "prev(x)", t0.Add(time.Second), 12); got != 10 {
+		t.Fatalf("prev = %v", got)
+	}
+	if got := evalAt(t, em, "r2", "rate(x)", t0, 100); got != 0 {
+		t.Fatalf("first rate = %v", got)
+	}
+	if got := evalAt(t, em, "r2", "rate(x)", t0.Add(10*time.Second), 150); got != 5 {
+		t.Fatalf("rate = %v", got)
+	}
+	for i, v := range []float64{1, 2, 3, 4} {
+		got := evalAt(t, em, "r3", "delta(x, '2s')", t0.Add(time.Duration(i)*time.Second), v)
+		if want := []float64{0, 1, 2, 2}[i]; got != want {
+			t.Fatalf("delta #%d = %v, want %v", i, got, want)
+		}
+	}
+
+	env := map[string]any{"x": 1.0}
+	for i, want := range []bool{false, false, true} {
+		env["x"] = []float64{1, 1, 2}[i]
+		em.bindExprFuncs("r4", "", env, t0, nil)
+		got, err := evaluateThreshold("changed(x)", env)
+		if err != nil || got != want {
+			t.Fatalf("changed #%d = %v, %v", i, got, err)
+		}
+	}
+}
+
+// 多数据源规则中，b 触发的求值不得对 a 的缓存值重复采样
+func TestExprFuncsSampleOnlyOnSourceUpdate(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{
+		Type: "calculation",
+		Sources: []model.RuleSource{
+			{Alias: "a", ChannelID: "ch", DeviceID: "d", PointID: "pa"},
+			{Alias: "b", ChannelID: "ch", DeviceID: "d", PointID: "pb"},
+		},
+		Expression: "rate(a) * 1000 + stddev(a, '1m') * 100 + (changed(b) ? 1 : 0)",
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(point string, v float64, s int) model.Value {
+		return model.Value{ChannelID: "ch", DeviceID: "d", PointID: point, Value: v, TS: base.Add(time.Duration(s) * time.Second)}
+	}
+	values := []model.Value{
+		at("pa", 0, 0),
+		at("pb", 1, 5),   // a not sampled again
+		at("pb", 2, 6),   // b changed
+		at("pa", 10, 10), // rate over 10s, stddev of {0, 10}
+		at("pb", 2, 11),  // a keeps its last results, b unchanged
+	}
+	res, err := em.SimulateRule(rule, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0, 0, 1, 1500, 1500}
+	for i, st := range res.Steps {
+		if got, _ := toFloat(st.Result); got != want[i] {
+			t.Fatalf("step %d = %v (%s), want %v", i, st.Result, st.Error, want[i])
+		}
+	}
+}
+
+func TestExprFuncsWindowStatistics(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	t0 := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+
+	// 0..9s 值为 10，10..20s 值为 20：时间加权平均 15
+	var twa float64
+	for _, p := range []struct {
+		s int
+		v float64
+	}{{0, 10}, {10, 20}, {20, 20}} {
+		twa = evalAt(t, em, "twa", "twa(x, '1m')", t0.Add(time.Duration(p.s)*time.Second), p.v)
+	}
+	if twa != 15 {
+		t.Fatalf("twa = %v", twa)
+	}
+
+	var sd, p50 float64
+	for i, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		ts := t0.Add(time.Duration(i) * time.Second)
+		sd = evalAt(t, em, "sd", "stddev(x, 60)", ts, v)
+		p50 = evalAt(t, em, "p", "percentile(x, 50, '1m')", ts, v)
+	}
+	if sd != 2 {
+		t.Fatalf("stddev = %v", sd)
+	}
+	if p50 != 4.5 {
+		t.Fatalf("percentile = %v", p50)
+	}
+
+	// 窗口外的样本不参与统计
+	sd = evalAt(t, em, "sd", "stddev(x, 60)", t0.Add(10*time.Minute), 3)
+	if sd != 0 {
+		t.Fatalf("stddev after window = %v", sd)
+	}
+	if n := len(em.exprStates["sd"]["stddev#0@x"].Samples); n != 2 {
+		t.Fatalf("samples not trimmed: %d", n)
+	}
+}
+
+func TestExprFuncsIntegralShiftReset(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	t0 := time.Date(2024, 1, 1, 7, 59, 0, 0, time.Local)
+
+	// 恒定 2 m³/s，08:00 换班清零
+	evalAt(t, em, "i", "integral(x, '08:00')", t0, 2)
+	if got := evalAt(t, em, "i", "integral(x, '08:00')", t0.Add(30*time.Second), 2); got != 60 {
+		t.Fatalf("integral = %v", got)
+	}
+	if got := evalAt(t, em, "i", "integral(x, '08:00')", t0.Add(90*time.Second), 2); got != 60 {
+		t.Fatalf("integral after reset = %v", got)
+	}
+}
+
+func TestExprLookup(t *testing.T) {
+	env := map[string]any{"x": 15.0}
+	res, err := evaluateCalculation("lookup([[0, 0], [10, 100], [20, 300]], x)", env)
+	if err != nil || res != 200.0 {
+		t.Fatalf("lookup = %v, %v", res, err)
+	}
+	env["x"] = 99.0
+	if res, _ := evaluateCalculation("lookup([[0, 0], [10, 100]], x)", env); res != 100.0 {
+		t.Fatalf("lookup clamp = %v", res)
+	}
+	if _, err := evaluateCalculation("lookup([[10, 0], [0, 1]], x)", env); err == nil {
+		t.Fatal("expected error for unsorted table")
+	}
+}
+
+func TestExprStatePersistence(t *testing.T) {
+	store, err := storage.NewStorage(testOutputDir(t))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	t0 := time.Now()
+	em := NewEdgeComputeManager(nil, store, nil)
+	em.ruleStates["flow"] = &model.RuleRuntimeState{RuleID: "flow"}
+	evalAt(t, em, "flow", "integral(x)", t0, 1)
+	evalAt(t, em, "flow", "integral(x)", t0.Add(10*time.Second), 1)
+	em.saveRuleState("flow")
+
+	restarted := NewEdgeComputeManager(nil, store, nil)
+	restarted.restoreState()
+	if restarted.ruleStates["flow"].ExprState != nil {
+		t.Fatal("expr state must not stay on the runtime state")
+	}
+	if got := evalAt(t, restarted, "flow", "integral(x)", t0.Add(20*time.Second), 1); math.Abs(got-20) > 1e-9 {
+		t.Fatalf("integral after restart = %v", got)
+	}
+
+	if err := restarted.UpsertRule(model.EdgeRule{ID: "flow", Expression: "integral(x)"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.UpsertRule(model.EdgeRule{ID: "flow", Expression: "integral(x) * 2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restarted.exprStates["flow"]; ok {
+		t.Fatal("expr state must be reset when the expression changes")
+	}
+}
@@ -0,0 +1,309 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// 规则输出虚拟点位与规则链：规则声明的输出写入 ShadowCore（通道 rules，设备为规则 ID），
+// 经 ShadowBridge 进入 DataPipeline，从而可作为其他规则的数据源并由北向发布。
+// 依赖关系构成有向图，保存规则时拒绝产生循环。
+
+// RuleOutputChannel 规则输出点所在的通道 ID
+const RuleOutputChannel = "rules"
+
+var ruleOutputNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RuleOutputRef 返回规则输出点的引用 rules.<rule_id>.<name>
+func RuleOutputRef(ruleID, name string) string {
+	return RuleOutputChannel + "." + ruleID + "." + name
+}
+
+// parseRuleOutputRef 解析 rules.<rule_id>.<name>
+func parseRuleOutputRef(ref string) (ruleID, name string, ok bool) {
+	rest, found := strings.CutPrefix(ref, RuleOutputChannel+".")
+	if !found {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// normalizeRuleSources 将 point_id 写作 rules.<rule_id>.<name> 的数据源展开为通道/设备/点位
+func normalizeRuleSources(rule *model.EdgeRule) {
+	for i := range rule.Sources {
+		src := &rule.Sources[i]
+		if src.ChannelID != "" || src.DeviceID != "" {
+			continue
+		}
+		if ruleID, name, ok := parseRuleOutputRef(src.PointID); ok {
+			src.ChannelID, src.DeviceID, src.PointID = RuleOutputChannel, ruleID, name
+		}
+	}
+}
+
+// validateRuleOutputs 校验输出点名称
+func validateRuleOutputs(rule model.EdgeRule) error {
+	seen := make(map[string]struct{}, len(rule.Outputs))
+	for _, out := range rule.Outputs {
+		if !ruleOutputNameRegex.MatchString(out.Name) {
+			return fmt.Errorf("规则输出名称无效: %q", out.Name)
+		}
+		if _, ok := seen[out.Name]; ok {
+			return fmt.Errorf("规则输出名称重复: %q", out.Name)
+		}
+		seen[out.Name] = struct{}{}
+	}
+	return nil
+}
+
+// ruleDependencies 返回规则引用的上游规则 ID（去重、排序）
+func ruleDependencies(rule model.EdgeRule) []string {
+	set := make(map[string]struct{})
+	for _, src := range rule.Sources {
+		// 自引用同样计入，构成循环
+		if src.ChannelID == RuleOutputChannel && src.DeviceID != "" {
+			set[src.DeviceID] = struct{}{}
+		}
+	}
+	deps := make([]string, 0, len(set))
+	for id := range set {
+		deps = append(deps, id)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// buildRuleGraph 按依赖计算层级（Kahn 拓扑排序），无法排序的规则属于循环
+func buildRuleGraph(rules map[string]model.EdgeRule) model.RuleGraph {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	deps := make(map[string][]string, len(rules))
+	dependents := make(map[string][]string)
+	indegree := make(map[string]int, len(rules))
+	for _, id := range ids {
+		for _, up := range ruleDependencies(rules[id]) {
+			deps[id] = append(deps[id], up)
+			if _, ok := rules[up]; !ok {
+				// 引用不存在的规则不影响排序
+				continue
+			}
+			dependents[up] = append(dependents[up], id)
+			indegree[id]++
+		}
+	}
+
+	levels := make(map[string]int, len(rules))
+	var queue, order []string
+	for _, id := range ids {
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, down := range dependents[id] {
+			if l := levels[id] + 1; l > levels[down] {
+				levels[down] = l
+			}
+			if indegree[down]--; indegree[down] == 0 {
+				queue = append(queue, down)
+			}
+		}
+	}
+
+	graph := model.RuleGraph{Order: order, Nodes: make([]model.RuleGraphNode, 0, len(ids))}
+	cyclic := make(map[string]bool)
+	for _, id := range ids {
+		if indegree[id] > 0 {
+			cyclic[id] = true
+		}
+	}
+	for _, id := range ids {
+		rule := rules[id]
+		node := model.RuleGraphNode{RuleID: id, RuleName: rule.Name, Level: levels[id], DependsOn: deps[id]}
+		if cyclic[id] {
+			node.Level = -1
+		}
+		for _, out := range rule.Outputs {
+			node.Outputs = append(node.Outputs, RuleOutputRef(id, out.Name))
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// 提取每个循环的路径，便于提示用户；循环下游的规则同样无法排序，但不单独报告
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !cyclic[id] {
+			continue
+		}
+		cycle := findRuleCycle(id, deps, cyclic)
+		if len(cycle) == 0 {
+			continue
+		}
+		members := append([]string(nil), cycle[:len(cycle)-1]...)
+		sort.Strings(members)
+		if key := strings.Join(members, "\x00"); !seen[key] {
+			seen[key] = true
+			graph.Cycles = append(graph.Cycles, cycle)
+		}
+	}
+	return graph
+}
+
+// findRuleCycle 从 start 沿依赖边查找回到已访问节点的路径
+func findRuleCycle(start string, deps map[string][]string, cyclic map[string]bool) []string {
+	var path []string
+	pos := make(map[string]int)
+	cur := start
+	for {
+		if i, ok := pos[cur]; ok {
+			return append(path[i:], cur)
+		}
+		pos[cur] = len(path)
+		path = append(path, cur)
+		next := ""
+		for _, up := range deps[cur] {
+			if cyclic[up] {
+				next = up
+				break
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cur = next
+	}
+}
+
+// checkRuleCycle 检查用 rule 替换/新增后是否产生循环依赖。调用方需持有 em.mu
+func (em *EdgeComputeManager) checkRuleCycle(rule model.EdgeRule) error {
+	candidate := make(map[string]model.EdgeRule, len(em.rules)+1)
+	for id, r := range em.rules {
+		candidate[id] = r
+	}
+	candidate[rule.ID] = rule
+	for _, cycle := range buildRuleGraph(candidate).Cycles {
+		for _, id := range cycle {
+			if id == rule.ID {
+				return fmt.Errorf("规则依赖存在循环: %s", strings.Join(cycle, " -> "))
+			}
+		}
+	}
+	return nil
+}
+
+// refreshRuleGraph 重新计算规则层级。调用方需持有 em.mu
+func (em *EdgeComputeManager) refreshRuleGraph() {
+	graph := buildRuleGraph(em.rules)
+	levels := make(map[string]int, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		levels[n.RuleID] = n.Level
+	}
+	for _, cycle := range graph.Cycles {
+		log.Printf("Edge rules form a dependency cycle, outputs disabled: %s", strings.Join(cycle, " -> "))
+	}
+	em.ruleLevels = levels
+}
+
+// GetRuleGraph 返回当前规则依赖图
+func (em *EdgeComputeManager) GetRuleGraph() model.RuleGraph {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return buildRuleGraph(em.rules)
+}
+
+// SetShadowCore 设置规则输出点写入的影子核心；未设置时直接推送到数据管道
+func (em *EdgeComputeManager) SetShadowCore(sc *ShadowCore) {
+	em.shadow = sc
+}
+
+// publishRuleOutputs 计算并发布规则输出点
+func (em *EdgeComputeManager) publishRuleOutputs(rule model.EdgeRule, val model.Value, result any, env map[string]any) {
+	if len(rule.Outputs) == 0 {
+		return
+	}
+	em.mu.RLock()
+	level, ok := em.ruleLevels[rule.ID]
+	em.mu.RUnlock()
+	if ok && level < 0 {
+		return
+	}
+
+	now := em.clock()
+	stale := staleSources(rule, val)
+	values := make([]model.Value, 0, len(rule.Outputs))
+	points := make(map[string]model.ShadowPoint, len(rule.Outputs))
+	for _, out := range rule.Outputs {
+		v := result
+		if out.Expression != "" {
+			outEnv := make(map[string]any, len(env)+1)
+			for k, val := range env {
+				outEnv[k] = val
+			}
+			outEnv["result"] = result
+			em.bindExprFuncs(rule.ID, "output."+out.Name+".", outEnv, now, stale)
+			res, err := evaluateCalculation(out.Expression, outEnv)
+			if err != nil {
+				log.Printf("Rule %s output %s evaluation error: %v", rule.Name, out.Name, err)
+				continue
+			}
+			v = res
+		}
+		quality := "Good"
+		if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			quality = "Bad"
+		}
+		values = append(values, model.Value{
+			ChannelID: RuleOutputChannel,
+			DeviceID:  rule.ID,
+			PointID:   out.Name,
+			Value:     v,
+			Quality:   quality,
+			TS:        now,
+		})
+		points[out.Name] = model.ShadowPoint{
+			Value:       v,
+			Unit:        out.Unit,
+			RW:          "r",
+			Quality:     strings.ToLower(quality),
+			Timestamp:   now,
+			CollectedAt: now,
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	em.sim.traceOutputs(values)
+
+	// 先更新本地缓存，下游规则即使由其他数据源先触发也能读到最新结果
+	em.cacheMu.Lock()
+	for _, v := range values {
+		em.valueCache[fmt.Sprintf("%s/%s/%s", v.ChannelID, v.DeviceID, v.PointID)] = v
+	}
+	em.cacheMu.Unlock()
+
+	if em.shadow != nil {
+		// ShadowBridge 将变更推送到数据管道
+		em.shadow.WriteVirtualShadowDevice(RuleOutputChannel, rule.ID, points)
+		return
+	}
+	if em.pipeline != nil {
+		em.pipeline.PushBatch(values)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func chainSource(alias, ref string) model.RuleSource {
+	return model.RuleSource{Alias: alias, PointID: ref}
+}
+
+func TestBuildRuleGraphLevelsAndCycles(t *testing.T) {
+	rules := map[string]model.EdgeRule{
+		"a": {ID: "a", Sources: []model.RuleSource{{Alias: "t1", ChannelID: "ch", DeviceID: "d", PointID: "p"}}, Outputs: []model.RuleOutput{{Name: "avg"}}},
+		"b": {ID: "b", Sources: []model.RuleSource{chainSource("x", "rules.a.avg")}, Outputs: []model.RuleOutput{{Name: "y"}}},
+		"c": {ID: "c", Sources: []model.RuleSource{chainSource("x", "rules.a.avg"), chainSource("y", "rules.b.y")}},
+		"x": {ID: "x", Sources: []model.RuleSource{chainSource("v", "rules.y.out")}},
+		"y": {ID: "y", Sources: []model.RuleSource{chainSource("v", "rules.x.out")}},
+	}
+	for id, r := range rules {
+		normalizeRuleSources(&r)
+		rules[id] = r
+	}
+
+	graph := buildRuleGraph(rules)
+	levels := map[string]int{}
+	for _, n := range graph.Nodes {
+		levels[n.RuleID] = n.Level
+	}
+	if levels["a"] != 0 || levels["b"] != 1 || levels["c"] != 2 || levels["x"] != -1 || levels["y"] != -1 {
+		t.Fatalf("levels = %v", levels)
+	}
+	if strings.Join(graph.Order, ",") != "a,b,c" {
+		t.Fatalf("order = %v", graph.Order)
+	}
+	if len(graph.Cycles) != 1 || len(graph.Cycles[0]) != 3 {
+		t.Fatalf("cycles = %v", graph.Cycles)
+	}
+}
+
+func TestUpsertRuleRejectsCyclesAndInvalidOutputs(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	if err := em.UpsertRule(model.EdgeRule{ID: "a", Outputs: []model.RuleOutput{{Name: "out"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := em.UpsertRule(model.EdgeRule{ID: "b", Sources: []model.RuleSource{chainSource("v", "rules.a.out")}, Outputs: []model.RuleOutput{{Name: "out"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := em.rules["b"].Sources[0]; got.ChannelID != RuleOutputChannel || got.DeviceID != "a" || got.PointID != "out" {
+		t.Fatalf("source not normalized: %+v", got)
+	}
+
+	err := em.UpsertRule(model.EdgeRule{ID: "a", Sources: []model.RuleSource{chainSource("v", "rules.b.out")}, Outputs: []model.RuleOutput{{Name: "out"}}})
+	if err == nil || !strings.Contains(err.Error(), "循环") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if err := em.UpsertRule(model.EdgeRule{ID: "self", Sources: []model.RuleSource{chainSource("v", "rules.self.out")}}); err == nil {
+		t.Fatal("expected self reference to be rejected")
+	}
+	if err := em.UpsertRule(model.EdgeRule{ID: "c", Outputs: []model.RuleOutput{{Name: "a.b"}}}); err == nil {
+		t.Fatal("expected invalid output name to be rejected")
+	}
+}
+
+func TestRuleOutputsChainThroughShadow(t *testing.T) {
+	pipeline := NewDataPipeline(10)
+	sc := NewShadowCore()
+	sc.Start()
+	defer sc.Stop()
+	NewShadowBridge(pipeline).Attach(sc)
+
+	em := NewEdgeComputeManager(pipeline, nil, nil)
+	em.SetBatchWindow(0)
+	em.SetShadowCore(sc)
+	em.LoadRules([]model.EdgeRule{
+		{
+			ID: "double", Name: "double", Type: "calculation", Enable: true,
+			Sources:    []model.RuleSource{{Alias: "t1", ChannelID: "ch", DeviceID: "dev", PointID: "p1"}},
+			Expression: "t1 * 2",
+			Outputs:    []model.RuleOutput{{Name: "value"}, {Name: "high", Expression: "result > 10"}},
+		},
+		{
+			ID: "alarm", Name: "alarm", Type: "threshold", Enable: true,
+			Sources:   []model.RuleSource{chainSource("d", "rules.double.value")},
+			Condition: "d > 10",
+		},
+	})
+	pipeline.Start()
+	em.Start()
+	defer em.Stop()
+
+	pipeline.Push(model.Value{ChannelID: "ch", DeviceID: "dev", PointID: "p1", Value: 6.0, TS: time.Now()})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st := em.GetRuleStates()["alarm"]
+		if st != nil && st.TriggerCount > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("downstream rule not triggered: %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pt, err := sc.GetVirtualShadowPoint("double", "high")
+	if err != nil || pt.Value != true {
+		t.Fatalf("shadow output = %+v, %v", pt, err)
+	}
+
+	if err := em.DeleteRule("alarm"); err != nil {
+		t.Fatal(err)
+	}
+	if err := em.DeleteRule("double"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.GetVirtualShadowDevice("double"); err == nil {
+		t.Fatal("output device must be removed with the rule")
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// 规则仿真（试运行 / 历史回放）：在隔离的 EdgeComputeManager 中按给定值序列逐条求值规则，
+// 使用值的时间戳作为时钟，记录每一步的环境、条件结果、状态维持进度与窗口聚合；动作只记录不执行，
+// 也不写入影子设备、数据管道或存储。
+
+// maxRuleSimulationSteps 单次仿真最多输入的值数量
+const maxRuleSimulationSteps = 10000
+
+// ruleSimulation 记录当前求值步骤的轨迹；方法允许 nil 接收者，非仿真时为空操作
+type ruleSimulation struct {
+	rule model.EdgeRule
+	step *model.RuleSimulationStep
+}
+
+func (s *ruleSimulation) traceEvaluation(env map[string]any, result any, triggered bool, err error) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.Env = simulationEnv(env)
+	if errors.Is(err, errWindowStepPending) {
+		s.step.WindowPending = true
+		return
+	}
+	if err != nil {
+		s.step.Error = err.Error()
+		return
+	}
+	s.step.Result = simulationValue(result)
+	s.step.Triggered = triggered
+	if s.rule.Type == "window" {
+		s.step.WindowValue = s.step.Result
+	}
+}
+
+func (s *ruleSimulation) traceWindowLate() {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.WindowLate = true
+}
+
+func (s *ruleSimulation) traceWindowSamples(n int) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.WindowSamples = n
+}
+
+func (s *ruleSimulation) traceHold(rule model.EdgeRule, state *model.RuleRuntimeState, now time.Time) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.HoldCount = state.ConditionCount
+	if !state.ConditionStart.IsZero() {
+		s.step.HoldElapsedMs = now.Sub(state.ConditionStart).Milliseconds()
+	}
+	if rule.State != nil && (rule.State.Duration != "" || rule.State.Count > 0) {
+		s.step.HoldRequired = fmt.Sprintf("%s / %d 次", rule.State.Duration, rule.State.Count)
+	}
+}
+
+func (s *ruleSimulation) traceActions(actions []model.RuleAction, execute bool) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.Fired = true
+	if !execute {
+		s.step.ActionsSuppressed = len(actions) > 0
+		return
+	}
+	for _, a := range actions {
+		s.step.Actions = append(s.step.Actions, a.Type)
+	}
+}
+
+// traceWrite 记录脚本的设备写入（仿真时不执行）
+func (s *ruleSimulation) traceWrite(channelID, deviceID, pointID string, value any) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.Actions = append(s.step.Actions, fmt.Sprintf("write %s/%s/%s=%v", channelID, deviceID, pointID, value))
+}
+
+func (s *ruleSimulation) traceOutputs(values []model.Value) {
+	if s == nil || s.step == nil {
+		return
+	}
+	s.step.Outputs = make(map[string]any, len(values))
+	for _, v := range values {
+		s.step.Outputs[v.PointID] = simulationValue(v.Value)
+	}
+}
+
+// simulationEnv 复制求值环境，去掉函数并把 NaN/Inf 转为 nil 以便 JSON 序列化
+func simulationEnv(env map[string]any) map[string]any {
+	out := make(map[string]any, len(env))
+	for k, v := range env {
+		if v != nil && reflect.TypeOf(v).Kind() == reflect.Func {
+			continue
+		}
+		out[k] = simulationValue(v)
+	}
+	return out
+}
+
+func simulationValue(v any) any {
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil
+	}
+	return v
+}
+
+// resolveSimulationValue 补全值的通道/设备：未指定时按数据源别名、点位 ID 或 rules.<id>.<name> 匹配
+func resolveSimulationValue(rule model.EdgeRule, val model.Value) model.Value {
+	if val.ChannelID != "" || val.DeviceID != "" {
+		return val
+	}
+	if ruleID, name, ok := parseRuleOutputRef(val.PointID); ok {
+		val.ChannelID, val.DeviceID, val.PointID = RuleOutputChannel, ruleID, name
+		return val
+	}
+	for _, src := range rule.Sources {
+		if (src.Alias != "" && src.Alias == val.PointID) || (src.PointID != "" && src.PointID == val.PointID) {
+			val.ChannelID, val.DeviceID, val.PointID = src.ChannelID, src.DeviceID, src.PointID
+			return val
+		}
+	}
+	return val
+}
+
+// SimulateRule 用给定值序列试运行规则（规则无需保存或启用），返回逐步轨迹。
+// 缺少时间戳的值按 1 秒间隔依次排列；规则中未提供值的数据源在表达式中为 NaN。
+func (em *EdgeComputeManager) SimulateRule(rule model.EdgeRule, values []model.Value) (*model.RuleSimulationResult, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("仿真输入值不能为空")
+	}
+	if len(values) > maxRuleSimulationSteps {
+		return nil, fmt.Errorf("仿真输入值过多: %d（上限 %d）", len(values), maxRuleSimulationSteps)
+	}
+	if rule.ID == "" {
+		rule.ID = "simulation"
+	}
+	em.sanitizeRule(&rule)
+	normalizeRuleSources(&rule)
+	if err := validateRuleOutputs(rule); err != nil {
+		return nil, err
+	}
+	if err := validateRuleScripts(rule); err != nil {
+		return nil, err
+	}
+	if err := validateRuleWindow(rule); err != nil {
+		return nil, err
+	}
+	rule.Enable = true
+
+	inputs := make([]model.Value, len(values))
+	last := time.Now().Truncate(time.Second)
+	for i, v := range values {
+		v = resolveSimulationValue(rule, v)
+		if v.TS.IsZero() {
+			v.TS = last.Add(time.Second)
+		}
+		if v.Quality == "" {
+			v.Quality = "Good"
+		}
+		last = v.TS
+		inputs[i] = v
+	}
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return inputs[order[a]].TS.Before(inputs[order[b]].TS) })
+
+	var now time.Time
+	sim := newRuleSandbox(rule)
+	defer sim.Stop()
+	sim.now = func() time.Time { return now }
+
+	interval, _ := time.ParseDuration(rule.CheckInterval)
+	res := &model.RuleSimulationResult{RuleID: rule.ID, Inputs: len(inputs), Steps: []model.RuleSimulationStep{}}
+	for _, idx := range order {
+		val := inputs[idx]
+		now = val.TS
+		sim.cacheMu.Lock()
+		sim.valueCache[fmt.Sprintf("%s/%s/%s", val.ChannelID, val.DeviceID, val.PointID)] = val
+		sim.cacheMu.Unlock()
+		if !matchRule(rule, val) {
+			continue
+		}
+		if state := sim.ruleStates[rule.ID]; state != nil && interval > 0 && now.Sub(state.LastCheckTime) < interval {
+			res.Throttled++
+			continue
+		}
+
+		step := &model.RuleSimulationStep{Index: idx, TS: val.TS, Input: val}
+		sim.sim.step = step
+		sim.executeRule(rule, val)
+		sim.sim.step = nil
+
+		if state := sim.ruleStates[rule.ID]; state != nil {
+			step.Status = state.CurrentStatus
+		}
+		res.Evaluated++
+		if step.Triggered {
+			res.Triggered++
+		}
+		if step.Fired {
+			res.Fired++
+		}
+		if step.Error != "" {
+			res.Errors++
+		}
+		res.Steps = append(res.Steps, *step)
+	}
+	return res, nil
+}
+
+// clock 返回规则引擎使用的当前时间；仿真时为被回放值的时间戳
+func (em *EdgeComputeManager) clock() time.Time {
+	if em.now != nil {
+		return em.now()
+	}
+	return time.Now()
+}
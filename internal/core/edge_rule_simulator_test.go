@@ -0,0 +1,69 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func TestSimulateRuleStateHoldUsesValueTimestamps(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{
+		Type:      "threshold",
+		Sources:   []model.RuleSource{{Alias: "t", ChannelID: "ch", DeviceID: "d", PointID: "temp"}},
+		Condition: "t > 12",
+		State:     &model.StateConfig{Duration: "20s"},
+		Actions:   []model.RuleAction{{Type: "log"}},
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []model.Value{
+		{PointID: "t", Value: 10.0, TS: base},
+		{PointID: "t", Value: 13.0, TS: base.Add(10 * time.Second)},
+		{PointID: "t", Value: 14.0, TS: base.Add(20 * time.Second)},
+		{PointID: "t", Value: 15.0, TS: base.Add(30 * time.Second)},
+	}
+
+	res, err := em.SimulateRule(rule, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Evaluated != 4 || res.Triggered != 3 || res.Fired != 1 {
+		t.Fatalf("unexpected counters: %+v", res)
+	}
+	if s := res.Steps[2]; s.Fired || s.Status != "WARNING" || s.HoldElapsedMs != 10000 {
+		t.Fatalf("step 2 = %+v", s)
+	}
+	if s := res.Steps[3]; !s.Fired || len(s.Actions) != 1 || s.Actions[0] != "log" || s.Env["t"] != 15.0 {
+		t.Fatalf("step 3 = %+v", s)
+	}
+	if len(em.rules) != 0 || len(em.ruleStates) != 0 {
+		t.Fatal("simulation must not touch the live manager")
+	}
+}
+
+func TestSimulateRuleWindowAggregate(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{
+		Type:      "window",
+		Sources:   []model.RuleSource{{Alias: "p", ChannelID: "ch", DeviceID: "d", PointID: "power"}},
+		Window:    &model.WindowConfig{Type: "sliding", Size: "3", AggrFunc: "avg"},
+		Condition: "value > 5",
+	}
+	var values []model.Value
+	for _, v := range []float64{2, 4, 9, 11} {
+		values = append(values, model.Value{PointID: "power", Value: v})
+	}
+
+	res, err := em.SimulateRule(rule, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := res.Steps[len(res.Steps)-1]
+	if last.WindowSamples != 3 || last.WindowValue != 8.0 || !last.Triggered {
+		t.Fatalf("last step = %+v", last)
+	}
+	if _, err := em.SimulateRule(rule, nil); err == nil {
+		t.Fatal("expected error for empty input")
+	}
+}
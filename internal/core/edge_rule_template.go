@@ -0,0 +1,431 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// 规则模板：按设备选择器展开为受管规则实例。实例与普通规则一起持久化（template_id 指向模板），
+// 模板保存、删除或通道拓扑变化时重新展开，新增/移除的匹配设备随之增删实例。
+
+// templateInstanceID 返回模板在指定设备上的实例 ID
+func templateInstanceID(templateID, channelID, deviceID string) string {
+	return templateID + "." + channelID + "." + deviceID
+}
+
+// validateRuleTemplate 校验模板配置
+func validateRuleTemplate(tpl model.EdgeRuleTemplate) error {
+	if strings.TrimSpace(tpl.ID) == "" {
+		return fmt.Errorf("规则模板 ID 无效")
+	}
+	if tpl.Selector.NamePattern != "" {
+		if _, err := path.Match(tpl.Selector.NamePattern, ""); err != nil {
+			return fmt.Errorf("设备名称匹配模式无效: %q", tpl.Selector.NamePattern)
+		}
+	}
+	aliases := make(map[string]struct{}, len(tpl.Sources)+len(tpl.Rule.Sources))
+	for _, src := range tpl.Rule.Sources {
+		aliases[src.Alias] = struct{}{}
+	}
+	for _, src := range tpl.Sources {
+		if src.Alias == "" || (src.Point == "" && src.Tag == "") {
+			return fmt.Errorf("模板数据源无效: alias 不能为空，point 与 tag 至少填写一个")
+		}
+		if _, ok := aliases[src.Alias]; ok {
+			return fmt.Errorf("模板数据源别名重复: %q", src.Alias)
+		}
+		aliases[src.Alias] = struct{}{}
+	}
+	if err := validateRuleOutputs(tpl.Rule); err != nil {
+		return err
+	}
+	if err := validateRuleScripts(tpl.Rule); err != nil {
+		return err
+	}
+	return validateRuleWindow(tpl.Rule)
+}
+
+// matchDeviceSelector 判断设备是否满足选择器的全部条件
+func matchDeviceSelector(sel model.DeviceSelector, ch model.Channel, dev model.Device) bool {
+	if len(sel.ChannelIDs) > 0 && !slices.Contains(sel.ChannelIDs, ch.ID) {
+		return false
+	}
+	if sel.Protocol != "" && !strings.EqualFold(sel.Protocol, ch.Protocol) {
+		return false
+	}
+	if sel.NamePattern != "" {
+		byName, _ := path.Match(sel.NamePattern, dev.Name)
+		byID, _ := path.Match(sel.NamePattern, dev.ID)
+		if !byName && !byID {
+			return false
+		}
+	}
+	for _, tag := range sel.Tags {
+		if !slices.Contains(dev.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveTemplatePoint 在设备上查找模板数据源对应的点位，依次按 ID、名称、<group>.<name> 匹配；
+// 设置 tag 时只考虑带该标签的点位，仅按标签解析时须恰好匹配一个点位
+func resolveTemplatePoint(dev model.Device, src model.RuleTemplateSource) (string, bool) {
+	var candidates []model.Point
+	for _, p := range dev.Points {
+		if src.Group != "" && p.Group != src.Group {
+			continue
+		}
+		if src.Tag != "" && !slices.Contains(p.Tags, src.Tag) {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	if src.Point == "" {
+		if len(candidates) != 1 {
+			return "", false
+		}
+		return candidates[0].ID, true
+	}
+	matchers := []func(p model.Point) bool{
+		func(p model.Point) bool { return p.ID == src.Point },
+		func(p model.Point) bool { return p.Name == src.Point },
+		func(p model.Point) bool { return p.Group != "" && p.Group+"."+p.Name == src.Point },
+	}
+	for _, match := range matchers {
+		for _, p := range candidates {
+			if match(p) {
+				return p.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// expandRuleTemplate 为每台匹配设备生成规则实例；缺少点位的设备记入 skipped
+func expandRuleTemplate(tpl model.EdgeRuleTemplate, channels []model.Channel) ([]model.EdgeRule, []string) {
+	var rules []model.EdgeRule
+	var skipped []string
+	for _, ch := range channels {
+		for _, dev := range ch.Devices {
+			if !matchDeviceSelector(tpl.Selector, ch, dev) {
+				continue
+			}
+			repl := strings.NewReplacer(
+				"${channel_id}", ch.ID,
+				"${device_id}", dev.ID,
+				"${device_name}", dev.Name,
+			)
+
+			sources := make([]model.RuleSource, 0, len(tpl.Sources))
+			var missing []string
+			for _, src := range tpl.Sources {
+				ref := repl.Replace(src.Point)
+				if strings.HasPrefix(ref, RuleOutputChannel+".") {
+					sources = append(sources, model.RuleSource{Alias: src.Alias, PointID: ref})
+					continue
+				}
+				pointID, ok := resolveTemplatePoint(dev, model.RuleTemplateSource{Point: ref, Group: src.Group, Tag: repl.Replace(src.Tag)})
+				if !ok {
+					if src.Point == "" {
+						missing = append(missing, "tag:"+src.Tag)
+					} else {
+						missing = append(missing, src.Point)
+					}
+					continue
+				}
+				sources = append(sources, model.RuleSource{Alias: src.Alias, ChannelID: ch.ID, DeviceID: dev.ID, PointID: pointID})
+			}
+			if len(missing) > 0 {
+				skipped = append(skipped, fmt.Sprintf("%s/%s: 缺少点位 %s", ch.ID, dev.ID, strings.Join(missing, ", ")))
+				continue
+			}
+
+			rule := instantiateRulePrototype(tpl.Rule, repl)
+			rule.ID = templateInstanceID(tpl.ID, ch.ID, dev.ID)
+			rule.TemplateID = tpl.ID
+			rule.Enable = tpl.Enable
+			if rule.Name == "" {
+				rule.Name = tpl.Name + " - " + dev.Name
+			}
+			rule.Source = model.RuleSource{}
+			rule.Sources = append(rule.Sources, sources...)
+			normalizeRuleSources(&rule)
+			rules = append(rules, rule)
+		}
+	}
+	return rules, skipped
+}
+
+// instantiateRulePrototype 深拷贝规则原型并替换字符串中的占位符
+func instantiateRulePrototype(proto model.EdgeRule, repl *strings.Replacer) model.EdgeRule {
+	var rule model.EdgeRule
+	data, _ := json.Marshal(proto)
+	_ = json.Unmarshal(data, &rule)
+
+	rule.Name = repl.Replace(rule.Name)
+	rule.Condition = repl.Replace(rule.Condition)
+	rule.Expression = repl.Replace(rule.Expression)
+	for i := range rule.Sources {
+		src := &rule.Sources[i]
+		src.ChannelID = repl.Replace(src.ChannelID)
+		src.DeviceID = repl.Replace(src.DeviceID)
+		src.PointID = repl.Replace(src.PointID)
+	}
+	for i := range rule.Outputs {
+		rule.Outputs[i].Expression = repl.Replace(rule.Outputs[i].Expression)
+	}
+	for i := range rule.Actions {
+		if rule.Actions[i].Config != nil {
+			rule.Actions[i].Config = replacePlaceholders(rule.Actions[i].Config, repl).(map[string]any)
+		}
+	}
+	return rule
+}
+
+func replacePlaceholders(v any, repl *strings.Replacer) any {
+	switch val := v.(type) {
+	case string:
+		return repl.Replace(val)
+	case map[string]any:
+		for k, item := range val {
+			val[k] = replacePlaceholders(item, repl)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = replacePlaceholders(item, repl)
+		}
+		return val
+	}
+	return v
+}
+
+// LoadRuleTemplates 加载规则模板并设置持久化函数；设置通道管理器后由 SyncRuleTemplates 展开
+func (em *EdgeComputeManager) LoadRuleTemplates(templates []model.EdgeRuleTemplate, saveFunc func([]model.EdgeRuleTemplate) error) {
+	em.mu.Lock()
+	em.templates = make(map[string]model.EdgeRuleTemplate, len(templates))
+	for _, tpl := range templates {
+		em.templates[tpl.ID] = tpl
+	}
+	em.templateSave = saveFunc
+	em.mu.Unlock()
+	em.SyncRuleTemplates()
+}
+
+// GetRuleTemplates 返回全部规则模板（含实例与跳过设备）
+func (em *EdgeComputeManager) GetRuleTemplates() []model.EdgeRuleTemplate {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	templates := make([]model.EdgeRuleTemplate, 0, len(em.templates))
+	for _, tpl := range em.templates {
+		templates = append(templates, tpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
+
+// UpsertRuleTemplate 保存模板并立即重新展开实例
+func (em *EdgeComputeManager) UpsertRuleTemplate(tpl model.EdgeRuleTemplate) error {
+	em.sanitizeRule(&tpl.Rule)
+	if err := validateRuleTemplate(tpl); err != nil {
+		return err
+	}
+	tpl.Instances, tpl.Skipped = nil, nil
+
+	var channels []model.Channel
+	if em.cm != nil {
+		channels = em.cm.GetChannels()
+		sort.Slice(channels, func(i, j int) bool { return channels[i].ID < channels[j].ID })
+	}
+
+	em.mu.Lock()
+	if err := em.checkTemplateCycle(tpl, channels); err != nil {
+		em.mu.Unlock()
+		return err
+	}
+	if em.templates == nil {
+		em.templates = make(map[string]model.EdgeRuleTemplate)
+	}
+	em.templates[tpl.ID] = tpl
+	err := em.persistTemplates()
+	em.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	em.SyncRuleTemplates()
+	return nil
+}
+
+// DeleteRuleTemplate 删除模板及其全部实例
+func (em *EdgeComputeManager) DeleteRuleTemplate(id string) error {
+	em.mu.Lock()
+	if _, ok := em.templates[id]; !ok {
+		em.mu.Unlock()
+		return fmt.Errorf("rule template not found")
+	}
+	delete(em.templates, id)
+	if err := em.persistTemplates(); err != nil {
+		em.mu.Unlock()
+		return err
+	}
+	for ruleID, rule := range em.rules {
+		if rule.TemplateID == id {
+			em.dropRuleLocked(ruleID)
+		}
+	}
+	em.refreshRuleGraph()
+	err := em.persist()
+	em.mu.Unlock()
+	return err
+}
+
+// SyncRuleTemplates 按当前通道拓扑重新展开全部模板，增删或更新受管实例
+func (em *EdgeComputeManager) SyncRuleTemplates() {
+	if em.cm == nil {
+		return
+	}
+	em.syncRuleTemplates(em.cm.GetChannels())
+}
+
+func (em *EdgeComputeManager) syncRuleTemplates(channels []model.Channel) {
+	sort.Slice(channels, func(i, j int) bool { return channels[i].ID < channels[j].ID })
+
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	desired := make(map[string]model.EdgeRule)
+	for id, tpl := range em.templates {
+		rules, skipped := expandRuleTemplate(tpl, channels)
+		tpl.Instances = nil
+		for _, rule := range rules {
+			if old, exists := em.rules[rule.ID]; exists && old.TemplateID != tpl.ID {
+				skipped = append(skipped, fmt.Sprintf("%s: 与已有规则 ID 冲突", rule.ID))
+				continue
+			}
+			desired[rule.ID] = rule
+			tpl.Instances = append(tpl.Instances, rule.ID)
+		}
+		tpl.Skipped = skipped
+		em.templates[id] = tpl
+	}
+	em.dropCyclicInstances(desired)
+
+	changed := false
+	for id, rule := range em.rules {
+		if rule.TemplateID == "" {
+			continue
+		}
+		if _, ok := desired[id]; !ok {
+			em.dropRuleLocked(id)
+			changed = true
+		}
+	}
+	for id, rule := range desired {
+		old, exists := em.rules[id]
+		if exists && reflect.DeepEqual(old, rule) {
+			continue
+		}
+		if exists {
+			if old.Condition != rule.Condition || old.Expression != rule.Expression || !reflect.DeepEqual(old.Outputs, rule.Outputs) {
+				em.resetExprState(id)
+			}
+			em.removeFromIndex(id)
+		}
+		em.rules[id] = rule
+		em.indexRule(rule)
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	em.refreshRuleGraph()
+	if err := em.persist(); err != nil {
+		log.Printf("Failed to persist rule template instances: %v", err)
+	}
+}
+
+// templateCandidateRules 返回普通规则与给定实例合并后的规则集合。调用方需持有 em.mu
+func (em *EdgeComputeManager) templateCandidateRules(instances map[string]model.EdgeRule, excludeTemplate string) map[string]model.EdgeRule {
+	candidate := make(map[string]model.EdgeRule, len(em.rules)+len(instances))
+	for id, r := range em.rules {
+		if r.TemplateID == "" || (excludeTemplate != "" && r.TemplateID != excludeTemplate) {
+			candidate[id] = r
+		}
+	}
+	for id, r := range instances {
+		candidate[id] = r
+	}
+	return candidate
+}
+
+// checkTemplateCycle 检查模板在当前拓扑下展开的实例是否与现有规则形成循环依赖。调用方需持有 em.mu
+func (em *EdgeComputeManager) checkTemplateCycle(tpl model.EdgeRuleTemplate, channels []model.Channel) error {
+	rules, _ := expandRuleTemplate(tpl, channels)
+	instances := make(map[string]model.EdgeRule, len(rules))
+	for _, rule := range rules {
+		instances[rule.ID] = rule
+	}
+	for _, cycle := range buildRuleGraph(em.templateCandidateRules(instances, tpl.ID)).Cycles {
+		for _, id := range cycle {
+			if _, ok := instances[id]; ok {
+				return fmt.Errorf("规则依赖存在循环: %s", strings.Join(cycle, " -> "))
+			}
+		}
+	}
+	return nil
+}
+
+// dropCyclicInstances 从 desired 中移除处于循环依赖中的实例，并记入所属模板的 skipped。调用方需持有 em.mu
+func (em *EdgeComputeManager) dropCyclicInstances(desired map[string]model.EdgeRule) {
+	for {
+		dropped := false
+		for _, cycle := range buildRuleGraph(em.templateCandidateRules(desired, "")).Cycles {
+			for _, id := range cycle {
+				rule, ok := desired[id]
+				if !ok {
+					continue
+				}
+				delete(desired, id)
+				dropped = true
+				tpl := em.templates[rule.TemplateID]
+				tpl.Instances = slices.DeleteFunc(tpl.Instances, func(s string) bool { return s == id })
+				tpl.Skipped = append(tpl.Skipped, fmt.Sprintf("%s: 规则依赖存在循环: %s", id, strings.Join(cycle, " -> ")))
+				em.templates[rule.TemplateID] = tpl
+			}
+		}
+		if !dropped {
+			return
+		}
+	}
+}
+
+// checkManagedRule 拒绝直接修改模板生成的实例。调用方需持有 em.mu
+func (em *EdgeComputeManager) checkManagedRule(id string) error {
+	if rule, ok := em.rules[id]; ok && rule.TemplateID != "" {
+		return fmt.Errorf("规则 %s 由模板 %s 管理，请修改模板", id, rule.TemplateID)
+	}
+	return nil
+}
+
+// persistTemplates 保存模板配置（不含运行时字段）。调用方需持有 em.mu
+func (em *EdgeComputeManager) persistTemplates() error {
+	if em.templateSave == nil {
+		return nil
+	}
+	templates := make([]model.EdgeRuleTemplate, 0, len(em.templates))
+	for _, tpl := range em.templates {
+		tpl.Instances, tpl.Skipped = nil, nil
+		templates = append(templates, tpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return em.templateSave(templates)
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func templateTestChannels(devices ...model.Device) []model.Channel {
+	return []model.Channel{{ID: "ch1", Protocol: "modbus-tcp", Devices: devices}}
+}
+
+func pumpDevice(id string, tags []string, points ...model.Point) model.Device {
+	return model.Device{ID: id, Name: "pump-" + id, Tags: tags, Points: points}
+}
+
+func TestExpandRuleTemplateResolvesPointsPerDevice(t *testing.T) {
+	tpl := model.EdgeRuleTemplate{
+		ID:       "overtemp",
+		Name:     "Overtemperature",
+		Enable:   true,
+		Selector: model.DeviceSelector{Protocol: "modbus-tcp", NamePattern: "pump-*", Tags: []string{"cooling"}},
+		Sources:  []model.RuleTemplateSource{{Alias: "t", Point: "motor.temp"}},
+		Rule: model.EdgeRule{
+			Type:      "threshold",
+			Condition: "t > 80",
+			Actions:   []model.RuleAction{{Type: "log", Config: map[string]any{"message": "${device_name} overheated"}}},
+		},
+	}
+	channels := templateTestChannels(
+		pumpDevice("1", []string{"cooling"}, model.Point{ID: "40001", Name: "temp", Group: "motor"}),
+		pumpDevice("2", []string{"cooling"}, model.Point{ID: "40002", Name: "speed"}),
+		pumpDevice("3", nil, model.Point{ID: "40001", Name: "temp", Group: "motor"}),
+	)
+
+	rules, skipped := expandRuleTemplate(tpl, channels)
+	if len(rules) != 1 || len(skipped) != 1 || !strings.HasPrefix(skipped[0], "ch1/2") {
+		t.Fatalf("rules=%d skipped=%v", len(rules), skipped)
+	}
+	r := rules[0]
+	if r.ID != "overtemp.ch1.1" || r.TemplateID != "overtemp" || !r.Enable {
+		t.Fatalf("unexpected instance: %+v", r)
+	}
+	if src := r.Sources[0]; src.ChannelID != "ch1" || src.DeviceID != "1" || src.PointID != "40001" {
+		t.Fatalf("source = %+v", src)
+	}
+	if msg := r.Actions[0].Config["message"]; msg != "pump-1 overheated" {
+		t.Fatalf("placeholder not replaced: %v", msg)
+	}
+	if tpl.Rule.Actions[0].Config["message"] != "${device_name} overheated" {
+		t.Fatal("prototype must not be modified")
+	}
+}
+
+func TestSyncRuleTemplatesFollowsTopology(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	var saved []model.EdgeRuleTemplate
+	em.LoadRuleTemplates(nil, func(templates []model.EdgeRuleTemplate) error {
+		saved = templates
+		return nil
+	})
+	if err := em.UpsertRule(model.EdgeRule{ID: "manual"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := model.EdgeRuleTemplate{
+		ID:      "runtime",
+		Enable:  true,
+		Sources: []model.RuleTemplateSource{{Alias: "run", Point: "running"}},
+		Rule:    model.EdgeRule{Type: "calculation", Expression: "run"},
+	}
+	if err := em.UpsertRuleTemplate(tpl); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].ID != "runtime" {
+		t.Fatalf("template not persisted: %+v", saved)
+	}
+
+	running := model.Point{ID: "p1", Name: "running"}
+	em.syncRuleTemplates(templateTestChannels(pumpDevice("1", nil, running), pumpDevice("2", nil, running)))
+	if len(em.rules) != 3 || em.GetRuleTemplates()[0].Instances[1] != "runtime.ch1.2" {
+		t.Fatalf("rules after add = %v", em.GetRules())
+	}
+	if err := em.DeleteRule("runtime.ch1.1"); err == nil || !strings.Contains(err.Error(), "由模板") {
+		t.Fatalf("expected managed rule error, got %v", err)
+	}
+
+	em.syncRuleTemplates(templateTestChannels(pumpDevice("2", nil, running)))
+	if _, ok := em.rules["runtime.ch1.1"]; ok || len(em.rules) != 2 {
+		t.Fatalf("instance for removed device not dropped: %v", em.GetRules())
+	}
+
+	if err := em.DeleteRuleTemplate("runtime"); err != nil {
+		t.Fatal(err)
+	}
+	if len(em.rules) != 1 || em.rules["manual"].ID == "" {
+		t.Fatalf("rules after template delete = %v", em.GetRules())
+	}
+}
+
+func TestResolveTemplatePointByTag(t *testing.T) {
+	tpl := model.EdgeRuleTemplate{
+		ID:      "overtemp",
+		Enable:  true,
+		Sources: []model.RuleTemplateSource{{Alias: "t", Tag: "temperature"}, {Alias: "s", Point: "speed", Tag: "${device_id}"}},
+		Rule:    model.EdgeRule{Type: "threshold", Condition: "t > 80 && s > 0"},
+	}
+	if err := validateRuleTemplate(model.EdgeRuleTemplate{ID: "x", Sources: []model.RuleTemplateSource{{Alias: "t"}}}); err == nil {
+		t.Fatal("expected error for source without point and tag")
+	}
+	channels := templateTestChannels(
+		pumpDevice("1", nil,
+			model.Point{ID: "40001", Name: "motor_temp", Tags: []string{"temperature"}},
+			model.Point{ID: "40002", Name: "speed"},
+			model.Point{ID: "40003", Name: "speed", Tags: []string{"1"}},
+		),
+		pumpDevice("2", nil,
+			model.Point{ID: "40001", Name: "inlet", Tags: []string{"temperature"}},
+			model.Point{ID: "40002", Name: "outlet", Tags: []string{"temperature"}},
+			model.Point{ID: "40003", Name: "speed", Tags: []string{"2"}},
+		),
+	)
+
+	rules, skipped := expandRuleTemplate(tpl, channels)
+	if len(rules) != 1 || len(skipped) != 1 || !strings.Contains(skipped[0], "tag:temperature") {
+		t.Fatalf("rules=%d skipped=%v", len(rules), skipped)
+	}
+	if got := rules[0].Sources; got[0].PointID != "40001" || got[1].PointID != "40003" {
+		t.Fatalf("sources = %+v", got)
+	}
+}
+
+func TestRuleTemplateInstancesRejectCycles(t *testing.T) {
+	tpl := model.EdgeRuleTemplate{
+		ID:      "loop",
+		Enable:  true,
+		Sources: []model.RuleTemplateSource{{Alias: "v", Point: "rules.loop.${channel_id}.${device_id}.out"}},
+		Rule:    model.EdgeRule{Type: "calculation", Expression: "v", Outputs: []model.RuleOutput{{Name: "out", Expression: "v + 1"}}},
+	}
+	channels := templateTestChannels(pumpDevice("1", nil))
+
+	cm := NewChannelManager(nil, nil)
+	cm.channels["ch1"] = &channels[0]
+	em := NewEdgeComputeManager(nil, nil, nil)
+	em.SetChannelManager(cm)
+	if err := em.UpsertRuleTemplate(tpl); err == nil || !strings.Contains(err.Error(), "循环") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if len(em.GetRuleTemplates()) != 0 {
+		t.Fatal("template with cyclic instances must not be saved")
+	}
+
+	// 已保存的模板在拓扑变化后形成循环时，跳过对应实例
+	em = NewEdgeComputeManager(nil, nil, nil)
+	em.LoadRuleTemplates([]model.EdgeRuleTemplate{tpl}, nil)
+	em.syncRuleTemplates(channels)
+	got := em.GetRuleTemplates()[0]
+	if len(em.rules) != 0 || len(got.Instances) != 0 || len(got.Skipped) != 1 || !strings.Contains(got.Skipped[0], "循环") {
+		t.Fatalf("rules=%v template=%+v", em.GetRules(), got)
+	}
+}
@@ -0,0 +1,527 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// 规则版本与影子运行：每次保存、晋升、回滚和删除都在规则历史中追加一个不可变版本（含作者与字段差异）。
+// 影子模式下候选版本与生效版本在各自的隔离引擎中对同一输入求值，动作、设备写入与输出只记录不执行，
+// 结果不一致时写日志并保留最近样本；确认无误后晋升为生效版本，也可随时回滚到任一历史版本。
+
+const (
+	shadowQueueSize     = 256
+	maxShadowRecentDiff = 50
+)
+
+// LoadRuleHistory 加载规则版本历史并设置持久化函数，恢复处于影子模式的候选版本；
+// 可重复调用（主备切换后重新加载），已有影子运行先停止
+func (em *EdgeComputeManager) LoadRuleHistory(histories []model.EdgeRuleHistory, saveFunc func(model.EdgeRuleHistory) error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.stopShadowRuns()
+	em.histories = make(map[string]*model.EdgeRuleHistory, len(histories))
+	em.historySave = saveFunc
+	for i := range histories {
+		h := histories[i]
+		em.histories[h.RuleID] = &h
+		if h.Shadow != nil {
+			em.startShadowLocked(&h)
+		}
+	}
+}
+
+// GetRuleHistory 返回规则的全部版本
+func (em *EdgeComputeManager) GetRuleHistory(ruleID string) (model.EdgeRuleHistory, error) {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	if h, ok := em.histories[ruleID]; ok {
+		return cloneRuleHistory(h), nil
+	}
+	if _, ok := em.rules[ruleID]; ok {
+		// 功能启用前创建且未再修改的规则尚无历史
+		return model.EdgeRuleHistory{RuleID: ruleID, Versions: []model.EdgeRuleVersion{}}, nil
+	}
+	return model.EdgeRuleHistory{}, fmt.Errorf("rule not found")
+}
+
+// GetRuleVersion 返回规则的指定版本
+func (em *EdgeComputeManager) GetRuleVersion(ruleID string, version int) (model.EdgeRuleVersion, error) {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return em.versionLocked(ruleID, version)
+}
+
+// UpsertRuleBy 保存规则并记录作者与备注；与生效版本相同的保存不产生新版本
+func (em *EdgeComputeManager) UpsertRuleBy(rule model.EdgeRule, author, comment string) error {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if err := em.prepareRuleLocked(&rule); err != nil {
+		return err
+	}
+	h := em.historyLocked(rule.ID)
+	if err := em.applyRuleLocked(rule); err != nil {
+		return err
+	}
+	em.appendVersionLocked(h, model.EdgeRuleVersion{Action: "save", Author: author, Comment: comment, Rule: rule}, true)
+	return nil
+}
+
+// StageRule 以影子模式运行候选版本：记录为新版本但不生效，与生效版本并行求值并对比结果
+func (em *EdgeComputeManager) StageRule(rule model.EdgeRule, author, comment string) (model.EdgeRuleVersion, error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if err := em.prepareRuleLocked(&rule); err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	h := em.historyLocked(rule.ID)
+	v := model.EdgeRuleVersion{Action: "shadow", Author: author, Comment: comment, Rule: rule}
+	v = em.appendVersionLocked(h, v, false)
+	h.Shadow = &model.RuleShadowConfig{Version: v.Version, StartedAt: em.clock(), StartedBy: author}
+	em.saveHistoryLocked(h)
+	em.startShadowLocked(h)
+	return v, nil
+}
+
+// CancelShadow 结束影子运行，候选版本保留在历史中
+func (em *EdgeComputeManager) CancelShadow(ruleID string) error {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	h, ok := em.histories[ruleID]
+	if !ok || h.Shadow == nil {
+		return fmt.Errorf("规则 %s 未处于影子模式", ruleID)
+	}
+	h.Shadow = nil
+	em.saveHistoryLocked(h)
+	em.stopShadowLocked(ruleID)
+	return nil
+}
+
+// PromoteShadow 将影子运行中的候选版本晋升为生效版本
+func (em *EdgeComputeManager) PromoteShadow(ruleID, author, comment string) (model.EdgeRuleVersion, error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	h, ok := em.histories[ruleID]
+	if !ok || h.Shadow == nil {
+		return model.EdgeRuleVersion{}, fmt.Errorf("规则 %s 未处于影子模式", ruleID)
+	}
+	from := h.Shadow.Version
+	rule := h.Versions[from-1].Rule
+	// 影子运行期间其他规则可能已变化，按当前规则集重新校验
+	if err := em.prepareRuleLocked(&rule); err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	if err := em.applyRuleLocked(rule); err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	h.Shadow = nil
+	em.stopShadowLocked(ruleID)
+	v := model.EdgeRuleVersion{Action: "promote", Author: author, Comment: comment, From: from, Rule: rule}
+	return em.appendVersionLocked(h, v, true), nil
+}
+
+// RollbackRule 将规则恢复为指定历史版本的内容（已删除的规则也可恢复），并记录为新版本
+func (em *EdgeComputeManager) RollbackRule(ruleID string, version int, author, comment string) (model.EdgeRuleVersion, error) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	target, err := em.versionLocked(ruleID, version)
+	if err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	if target.Action == "delete" {
+		return model.EdgeRuleVersion{}, fmt.Errorf("回滚目标无效: 版本 %d 为删除记录", version)
+	}
+	rule := target.Rule
+	if err := em.prepareRuleLocked(&rule); err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	h := em.historyLocked(ruleID)
+	if err := em.applyRuleLocked(rule); err != nil {
+		return model.EdgeRuleVersion{}, err
+	}
+	v := model.EdgeRuleVersion{Action: "rollback", Author: author, Comment: comment, From: version, Rule: rule}
+	return em.appendVersionLocked(h, v, true), nil
+}
+
+// ShadowStatus 返回规则影子运行的对比统计
+func (em *EdgeComputeManager) ShadowStatus(ruleID string) (model.RuleShadowStatus, error) {
+	em.shadowMu.RLock()
+	run, ok := em.shadowRuns[ruleID]
+	em.shadowMu.RUnlock()
+	if !ok {
+		return model.RuleShadowStatus{}, fmt.Errorf("规则 %s 未处于影子模式", ruleID)
+	}
+	return run.snapshot(), nil
+}
+
+// versionLocked 查找规则版本。调用方需持有 em.mu
+func (em *EdgeComputeManager) versionLocked(ruleID string, version int) (model.EdgeRuleVersion, error) {
+	h, ok := em.histories[ruleID]
+	if !ok {
+		return model.EdgeRuleVersion{}, fmt.Errorf("rule history not found")
+	}
+	if version < 1 || version > len(h.Versions) {
+		return model.EdgeRuleVersion{}, fmt.Errorf("rule version not found")
+	}
+	return h.Versions[version-1], nil
+}
+
+// historyLocked 返回规则历史，不存在时创建；已有规则首次记录时先把当前内容存为 import 版本。
+// 需在修改 em.rules 之前调用。调用方需持有 em.mu
+func (em *EdgeComputeManager) historyLocked(ruleID string) *model.EdgeRuleHistory {
+	if em.histories == nil {
+		em.histories = make(map[string]*model.EdgeRuleHistory)
+	}
+	h, ok := em.histories[ruleID]
+	if ok {
+		return h
+	}
+	h = &model.EdgeRuleHistory{RuleID: ruleID}
+	em.histories[ruleID] = h
+	if old, exists := em.rules[ruleID]; exists {
+		h.Versions = append(h.Versions, model.EdgeRuleVersion{Version: 1, Action: "import", CreatedAt: em.clock(), Rule: old})
+		h.Live = 1
+	}
+	return h
+}
+
+// appendVersionLocked 追加版本并持久化。live 为 true 时版本立即生效；
+// 生效内容未变化的保存不记录，返回当前生效版本。调用方需持有 em.mu
+func (em *EdgeComputeManager) appendVersionLocked(h *model.EdgeRuleHistory, v model.EdgeRuleVersion, live bool) model.EdgeRuleVersion {
+	var base *model.EdgeRule
+	if h.Live > 0 {
+		base = &h.Versions[h.Live-1].Rule
+	}
+	v.Diff = diffRules(base, v.Rule)
+	if v.Action == "save" && base != nil && len(v.Diff) == 0 {
+		return h.Versions[h.Live-1]
+	}
+	v.Version = len(h.Versions) + 1
+	v.CreatedAt = em.clock()
+	h.Versions = append(h.Versions, v)
+	if live {
+		h.Live = v.Version
+		if h.Shadow != nil {
+			// 生效版本变化后以新版本为基准重新对比
+			em.startShadowLocked(h)
+		}
+	}
+	em.saveHistoryLocked(h)
+	return v
+}
+
+// recordDeleteLocked 记录规则删除并结束影子运行。需在删除规则之前调用，调用方需持有 em.mu
+func (em *EdgeComputeManager) recordDeleteLocked(ruleID, author string) {
+	old, ok := em.rules[ruleID]
+	if !ok {
+		return
+	}
+	h := em.historyLocked(ruleID)
+	h.Versions = append(h.Versions, model.EdgeRuleVersion{
+		Version:   len(h.Versions) + 1,
+		Action:    "delete",
+		Author:    author,
+		CreatedAt: em.clock(),
+		Rule:      old,
+	})
+	h.Live = 0
+	h.Shadow = nil
+	em.stopShadowLocked(ruleID)
+	em.saveHistoryLocked(h)
+}
+
+func (em *EdgeComputeManager) saveHistoryLocked(h *model.EdgeRuleHistory) {
+	if em.historySave == nil {
+		return
+	}
+	if err := em.historySave(cloneRuleHistory(h)); err != nil {
+		log.Printf("Failed to persist rule history for %s: %v", h.RuleID, err)
+	}
+}
+
+func cloneRuleHistory(h *model.EdgeRuleHistory) model.EdgeRuleHistory {
+	out := *h
+	out.Versions = append([]model.EdgeRuleVersion{}, h.Versions...)
+	if h.Shadow != nil {
+		shadow := *h.Shadow
+		out.Shadow = &shadow
+	}
+	return out
+}
+
+// diffRules 按 JSON 顶层字段比较两个规则版本；nil 与空数组、空对象视为相同
+func diffRules(old *model.EdgeRule, rule model.EdgeRule) []model.RuleFieldChange {
+	if old == nil {
+		return nil
+	}
+	before, after := ruleFields(*old), ruleFields(rule)
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	var changes []model.RuleFieldChange
+	for k := range keys {
+		o, n := before[k], after[k]
+		if (isEmptyField(o) && isEmptyField(n)) || reflect.DeepEqual(o, n) {
+			continue
+		}
+		changes = append(changes, model.RuleFieldChange{Field: k, Old: o, New: n})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func ruleFields(rule model.EdgeRule) map[string]any {
+	fields := map[string]any{}
+	data, err := json.Marshal(rule)
+	if err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+	return fields
+}
+
+func isEmptyField(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
+
+// ruleShadowRun 一个影子运行：生效版本与候选版本分别在隔离引擎中求值
+type ruleShadowRun struct {
+	live   *EdgeComputeManager // nil when the rule is not live (new or disabled)
+	shadow *EdgeComputeManager
+	input  chan model.Value
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	status model.RuleShadowStatus
+}
+
+// newRuleSandbox 创建只含一条规则的隔离引擎：动作、设备写入与规则输出只记录到仿真轨迹
+func newRuleSandbox(rule model.EdgeRule) *EdgeComputeManager {
+	rule.Enable = true
+	sb := NewEdgeComputeManager(nil, nil, nil)
+	sb.sim = &ruleSimulation{rule: rule}
+	sb.rules[rule.ID] = rule
+	sb.refreshRuleGraph()
+	return sb
+}
+
+// startShadowLocked 按历史中的影子配置（重新）启动影子运行。调用方需持有 em.mu
+func (em *EdgeComputeManager) startShadowLocked(h *model.EdgeRuleHistory) {
+	em.stopShadowLocked(h.RuleID)
+	if h.Shadow == nil || h.Shadow.Version < 1 || h.Shadow.Version > len(h.Versions) {
+		return
+	}
+	run := &ruleShadowRun{
+		shadow: newRuleSandbox(h.Versions[h.Shadow.Version-1].Rule),
+		input:  make(chan model.Value, shadowQueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		status: model.RuleShadowStatus{
+			RuleID:      h.RuleID,
+			Version:     h.Shadow.Version,
+			LiveVersion: h.Live,
+			StartedAt:   h.Shadow.StartedAt,
+			StartedBy:   h.Shadow.StartedBy,
+			Recent:      []model.RuleShadowSample{},
+		},
+	}
+	if live, ok := em.rules[h.RuleID]; ok && live.Enable {
+		run.live = newRuleSandbox(live)
+	}
+	go run.loop()
+
+	em.shadowMu.Lock()
+	if em.shadowRuns == nil {
+		em.shadowRuns = make(map[string]*ruleShadowRun)
+	}
+	em.shadowRuns[h.RuleID] = run
+	em.shadowMu.Unlock()
+}
+
+// stopShadowLocked 停止影子运行。调用方需持有 em.mu
+func (em *EdgeComputeManager) stopShadowLocked(ruleID string) {
+	em.shadowMu.Lock()
+	run, ok := em.shadowRuns[ruleID]
+	delete(em.shadowRuns, ruleID)
+	em.shadowMu.Unlock()
+	if ok {
+		run.close()
+	}
+}
+
+// stopShadowRuns 停止全部影子运行（引擎停止时）
+func (em *EdgeComputeManager) stopShadowRuns() {
+	em.shadowMu.Lock()
+	runs := em.shadowRuns
+	em.shadowRuns = nil
+	em.shadowMu.Unlock()
+	for _, run := range runs {
+		run.close()
+	}
+}
+
+// feedShadowRuns 把数据值投递给关心它的影子运行，队列满时丢弃
+func (em *EdgeComputeManager) feedShadowRuns(val model.Value) {
+	em.shadowMu.RLock()
+	defer em.shadowMu.RUnlock()
+	for _, run := range em.shadowRuns {
+		run.offer(val)
+	}
+}
+
+func (r *ruleShadowRun) offer(val model.Value) {
+	if !matchRule(r.shadow.sim.rule, val) && (r.live == nil || !matchRule(r.live.sim.rule, val)) {
+		return
+	}
+	select {
+	case r.input <- val:
+	default:
+		r.mu.Lock()
+		r.status.Dropped++
+		r.mu.Unlock()
+	}
+}
+
+func (r *ruleShadowRun) close() {
+	close(r.stop)
+	<-r.done
+	if r.live != nil {
+		r.live.Stop()
+	}
+	r.shadow.Stop()
+}
+
+func (r *ruleShadowRun) loop() {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case val := <-r.input:
+			r.evaluate(val)
+		}
+	}
+}
+
+func (r *ruleShadowRun) evaluate(val model.Value) {
+	var live *model.RuleSimulationStep
+	if r.live != nil {
+		live = r.live.evaluateTraced(val)
+	}
+	shadow := r.shadow.evaluateTraced(val)
+	if live == nil && shadow == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := &r.status
+	st.Evaluations++
+	if live != nil && live.Fired {
+		st.LiveFired++
+	}
+	if shadow != nil && shadow.Fired {
+		st.ShadowFired++
+	}
+	if shadow != nil && shadow.Error != "" {
+		st.ShadowErrors++
+	}
+	if !shadowMismatch(live, shadow) {
+		return
+	}
+	st.Mismatches++
+	st.Recent = append(st.Recent, model.RuleShadowSample{TS: val.TS, Input: val, Live: live, Shadow: shadow})
+	if len(st.Recent) > maxShadowRecentDiff {
+		st.Recent = st.Recent[len(st.Recent)-maxShadowRecentDiff:]
+	}
+	log.Printf("Rule %s shadow v%d differs from live v%d on %s/%s/%s=%v: live %s, shadow %s",
+		st.RuleID, st.Version, st.LiveVersion, val.ChannelID, val.DeviceID, val.PointID, val.Value,
+		describeShadowStep(live), describeShadowStep(shadow))
+}
+
+func (r *ruleShadowRun) snapshot() model.RuleShadowStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := r.status
+	out.Recent = append([]model.RuleShadowSample{}, r.status.Recent...)
+	return out
+}
+
+// shadowMismatch 判断两次求值结果是否不同；仅一侧参与求值时，以该侧是否触发动作为准
+func shadowMismatch(live, shadow *model.RuleSimulationStep) bool {
+	if live == nil || shadow == nil {
+		step := live
+		if step == nil {
+			step = shadow
+		}
+		return step.Fired
+	}
+	return live.Fired != shadow.Fired ||
+		live.Triggered != shadow.Triggered ||
+		(live.Error == "") != (shadow.Error == "") ||
+		fmt.Sprint(live.Result) != fmt.Sprint(shadow.Result)
+}
+
+func describeShadowStep(step *model.RuleSimulationStep) string {
+	switch {
+	case step == nil:
+		return "not evaluated"
+	case step.Error != "":
+		return "error: " + step.Error
+	default:
+		return fmt.Sprintf("result=%v fired=%v", step.Result, step.Fired)
+	}
+}
+
+// evaluateTraced 在隔离引擎中求值一次并返回轨迹；值不属于规则数据源或被 check_interval 节流时返回 nil
+func (em *EdgeComputeManager) evaluateTraced(val model.Value) *model.RuleSimulationStep {
+	rule := em.sim.rule
+	em.cacheMu.Lock()
+	em.valueCache[fmt.Sprintf("%s/%s/%s", val.ChannelID, val.DeviceID, val.PointID)] = val
+	em.cacheMu.Unlock()
+	if !matchRule(rule, val) {
+		return nil
+	}
+	em.stateMu.RLock()
+	state := em.ruleStates[rule.ID]
+	em.stateMu.RUnlock()
+	if interval, err := time.ParseDuration(rule.CheckInterval); err == nil && state != nil && em.clock().Sub(state.LastCheckTime) < interval {
+		return nil
+	}
+
+	step := &model.RuleSimulationStep{TS: val.TS, Input: val}
+	em.sim.step = step
+	em.executeRule(rule, val)
+	em.sim.step = nil
+
+	em.stateMu.RLock()
+	if state := em.ruleStates[rule.ID]; state != nil {
+		step.Status = state.CurrentStatus
+	}
+	em.stateMu.RUnlock()
+	return step
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/config"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+func versionTestRule(cond string) model.EdgeRule {
+	return model.EdgeRule{
+		ID: "boiler", Name: "Boiler", Type: "threshold", Enable: true, Condition: cond,
+		Sources: []model.RuleSource{{Alias: "p", ChannelID: "ch", DeviceID: "d", PointID: "p"}},
+		Actions: []model.RuleAction{{Type: "log", Config: map[string]any{"message": "high"}}},
+	}
+}
+
+func TestRuleVersionHistoryAndRollback(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	var saved model.EdgeRuleHistory
+	em.LoadRuleHistory(nil, func(h model.EdgeRuleHistory) error {
+		saved = h
+		return nil
+	})
+
+	if err := em.UpsertRuleBy(versionTestRule("p > 10"), "alice", "initial"); err != nil {
+		t.Fatalf("save v1: %v", err)
+	}
+	if err := em.UpsertRuleBy(versionTestRule("p > 12"), "bob", "raise limit"); err != nil {
+		t.Fatalf("save v2: %v", err)
+	}
+	// Saving identical content does not create a version
+	if err := em.UpsertRuleBy(versionTestRule("p > 12"), "bob", ""); err != nil {
+		t.Fatalf("save same: %v", err)
+	}
+	h, _ := em.GetRuleHistory("boiler")
+	if len(h.Versions) != 2 || h.Live != 2 {
+		t.Fatalf("expected 2 versions with v2 live, got %d live=%d", len(h.Versions), h.Live)
+	}
+	v2 := h.Versions[1]
+	if v2.Author != "bob" || v2.Comment != "raise limit" || len(v2.Diff) != 1 ||
+		v2.Diff[0].Field != "condition" || v2.Diff[0].Old != "p > 10" || v2.Diff[0].New != "p > 12" {
+		t.Fatalf("unexpected v2: %+v", v2)
+	}
+
+	if err := em.DeleteRuleBy("boiler", "carol"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	h, err := em.GetRuleHistory("boiler")
+	if err != nil || h.Live != 0 || h.Versions[2].Action != "delete" || h.Versions[2].Author != "carol" {
+		t.Fatalf("history should keep the deleted rule: %+v, %v", h, err)
+	}
+	if _, err := em.RollbackRule("boiler", 3, "carol", ""); err == nil {
+		t.Fatal("rolling back to a delete record should fail")
+	}
+
+	v, err := em.RollbackRule("boiler", 1, "carol", "restore")
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if v.Version != 4 || v.Action != "rollback" || v.From != 1 {
+		t.Fatalf("unexpected rollback version: %+v", v)
+	}
+	rules := em.GetRules()
+	if len(rules) != 1 || rules[0].Condition != "p > 10" {
+		t.Fatalf("rollback did not restore v1: %+v", rules)
+	}
+	if saved.Live != 4 || len(saved.Versions) != 4 {
+		t.Fatalf("history not persisted: live=%d versions=%d", saved.Live, len(saved.Versions))
+	}
+}
+
+func TestRuleVersionImportsExistingRule(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	em.LoadRules([]model.EdgeRule{versionTestRule("p > 10")})
+	em.LoadRuleHistory(nil, nil)
+
+	if err := em.UpsertRuleBy(versionTestRule("p > 11"), "alice", ""); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	h, _ := em.GetRuleHistory("boiler")
+	if len(h.Versions) != 2 || h.Versions[0].Action != "import" || h.Versions[0].Rule.Condition != "p > 10" {
+		t.Fatalf("expected the pre-existing rule as version 1: %+v", h.Versions)
+	}
+}
+
+func waitShadowEvaluations(t *testing.T, em *EdgeComputeManager, n int64) model.RuleShadowStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st, err := em.ShadowStatus("boiler")
+		if err != nil {
+			t.Fatalf("ShadowStatus: %v", err)
+		}
+		if st.Evaluations >= n || time.Now().After(deadline) {
+			return st
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRuleShadowCompareAndPromote(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	defer em.Stop()
+	em.LoadRuleHistory(nil, nil)
+	if err := em.UpsertRuleBy(versionTestRule("p > 10"), "alice", ""); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	staged, err := em.StageRule(versionTestRule("p > 20"), "bob", "try higher limit")
+	if err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if em.GetRules()[0].Condition != "p > 10" {
+		t.Fatal("staging must not change the live rule")
+	}
+
+	for _, v := range []float64{15, 25, 5} {
+		em.feedShadowRuns(model.Value{ChannelID: "ch", DeviceID: "d", PointID: "p", Value: v, TS: time.Now()})
+	}
+	em.feedShadowRuns(model.Value{ChannelID: "ch", DeviceID: "d", PointID: "other", Value: 99.0})
+
+	st := waitShadowEvaluations(t, em, 3)
+	if st.Evaluations != 3 || st.LiveFired != 2 || st.ShadowFired != 1 || st.Mismatches != 1 {
+		t.Fatalf("unexpected shadow stats: %+v", st)
+	}
+	if len(st.Recent) != 1 || st.Recent[0].Input.Value != 15.0 || !st.Recent[0].Live.Fired || st.Recent[0].Shadow.Fired {
+		t.Fatalf("unexpected mismatch sample: %+v", st.Recent)
+	}
+
+	v, err := em.PromoteShadow("boiler", "carol", "")
+	if err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if v.Action != "promote" || v.From != staged.Version || em.GetRules()[0].Condition != "p > 20" {
+		t.Fatalf("unexpected promote result: %+v", v)
+	}
+	if _, err := em.ShadowStatus("boiler"); err == nil {
+		t.Fatal("shadow run should end after promotion")
+	}
+	if _, err := em.PromoteShadow("boiler", "carol", ""); err == nil {
+		t.Fatal("promoting without a shadow version should fail")
+	}
+}
+
+func newHATestConfig(t *testing.T) *config.ConfigManager {
+	t.Helper()
+	store, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	cfg := config.NewConfigManagerWithEmptyConfig(t.TempDir())
+	cfg.AttachDB(store.GetConfigDB())
+	return cfg
+}
+
+func TestRuleHistoryMirroredToStandby(t *testing.T) {
+	master := newHATestConfig(t)
+	em := NewEdgeComputeManager(nil, nil, nil)
+	defer em.Stop()
+	em.LoadRuleHistory(nil, master.SaveEdgeRuleHistory)
+	if err := em.UpsertRuleBy(versionTestRule("p > 10"), "alice", ""); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := em.UpsertRuleBy(versionTestRule("p > 12"), "bob", ""); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := em.StageRule(versionTestRule("p > 20"), "bob", ""); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+
+	standby := newHATestConfig(t)
+	// A stale local history must not survive the import
+	if err := standby.SaveEdgeRuleHistory(model.EdgeRuleHistory{RuleID: "gone", Live: 1}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ExportHAConfig(master)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := ImportHAConfig(standby, data); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	histories, err := standby.LoadEdgeRuleHistories()
+	if err != nil || len(histories) != 1 {
+		t.Fatalf("expected the master's single history, got %+v, %v", histories, err)
+	}
+	h := histories[0]
+	if h.RuleID != "boiler" || len(h.Versions) != 3 || h.Live != 2 || h.Shadow == nil || h.Shadow.Version != 3 {
+		t.Fatalf("history not mirrored: live=%d versions=%d shadow=%+v", h.Live, len(h.Versions), h.Shadow)
+	}
+
+	// After promotion the standby keeps numbering and can roll back
+	promoted := NewEdgeComputeManager(nil, nil, nil)
+	defer promoted.Stop()
+	promoted.LoadRules([]model.EdgeRule{versionTestRule("p > 12")})
+	promoted.LoadRuleHistory(histories, standby.SaveEdgeRuleHistory)
+	v, err := promoted.RollbackRule("boiler", 1, "carol", "")
+	if err != nil || v.Version != 4 {
+		t.Fatalf("rollback on promoted node: %+v, %v", v, err)
+	}
+	if _, err := promoted.ShadowStatus("boiler"); err != nil {
+		t.Fatalf("shadow run should resume on the promoted node: %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// 脚本规则与脚本动作：使用 Starlark（纯 Go、无文件/网络/系统调用）执行多步逻辑。
+// 脚本可读取影子与规则值缓存，只能通过 DeviceIO 写设备；每次执行受步数、时长
+// 与写次数限制，错误沿用规则求值/动作失败的事件记录。Starlark 无法按线程统计内存，
+// 因此不设内存上限，内存占用由步数与时长间接约束。
+
+const (
+	defaultScriptTimeout   = time.Second
+	defaultScriptMaxSteps  = 1_000_000
+	defaultScriptMaxWrites = 16
+)
+
+var scriptFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// scriptProgramCache 按源码缓存编译结果
+var scriptProgramCache sync.Map // sha256(source) -> *starlark.Program
+
+// scriptPredeclaredNames 编译时可见的预声明名称
+var scriptPredeclaredNames = []string{
+	"value", "env", "rule_id", "channel_id", "device_id", "point_id",
+	"read", "write", "log", "math", "json",
+}
+
+func compileScript(source string) (*starlark.Program, error) {
+	key := sha256.Sum256([]byte(source))
+	if prog, ok := scriptProgramCache.Load(key); ok {
+		return prog.(*starlark.Program), nil
+	}
+	isPredeclared := func(name string) bool { return slices.Contains(scriptPredeclaredNames, name) }
+	_, prog, err := starlark.SourceProgramOptions(scriptFileOptions, "script", source, isPredeclared)
+	if err != nil {
+		return nil, fmt.Errorf("脚本无效: %w", err)
+	}
+	scriptProgramCache.Store(key, prog)
+	return prog, nil
+}
+
+// scriptConfigFromAction 将 script 动作的 config 解析为 ScriptConfig
+func scriptConfigFromAction(action model.RuleAction) (model.ScriptConfig, error) {
+	var cfg model.ScriptConfig
+	data, err := json.Marshal(action.Config)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("脚本配置无效: %w", err)
+	}
+	return cfg, nil
+}
+
+// validateRuleScripts 保存前编译规则及其动作中的脚本
+func validateRuleScripts(rule model.EdgeRule) error {
+	if rule.Type == "script" {
+		if rule.Script == nil || strings.TrimSpace(rule.Script.Source) == "" {
+			return fmt.Errorf("脚本规则无效: 缺少 script.source")
+		}
+		if _, err := compileScript(rule.Script.Source); err != nil {
+			return err
+		}
+	}
+	for i, action := range rule.Actions {
+		if action.Type != "script" {
+			continue
+		}
+		cfg, err := scriptConfigFromAction(action)
+		if err != nil {
+			return err
+		}
+		if _, err := compileScript(cfg.Source); err != nil {
+			return fmt.Errorf("动作 %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// scriptRun 单次脚本执行的上下文
+type scriptRun struct {
+	em     *EdgeComputeManager
+	ruleID string
+	writes int
+	limit  int
+}
+
+// runScript 执行脚本，返回脚本结束时的全局变量
+func (em *EdgeComputeManager) runScript(ctx context.Context, ruleID string, cfg model.ScriptConfig, val model.Value, env map[string]any) (starlark.StringDict, error) {
+	if strings.TrimSpace(cfg.Source) == "" {
+		return nil, fmt.Errorf("脚本为空")
+	}
+	prog, err := compileScript(cfg.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultScriptTimeout
+	if cfg.Timeout != "" {
+		if d, err := time.ParseDuration(cfg.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	maxSteps := cfg.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = defaultScriptMaxSteps
+	}
+	run := &scriptRun{em: em, ruleID: ruleID, limit: cfg.MaxWrites}
+	if run.limit <= 0 {
+		run.limit = defaultScriptMaxWrites
+	}
+
+	thread := &starlark.Thread{
+		Name:  "rule:" + ruleID,
+		Print: func(_ *starlark.Thread, msg string) { log.Printf("[EdgeScript] rule=%s %s", ruleID, msg) },
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	thread.OnMaxSteps = func(t *starlark.Thread) {
+		t.Cancel(fmt.Sprintf("超过最大执行步数 %d", maxSteps))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel("执行超时 " + timeout.String())
+		case <-done:
+		}
+	}()
+
+	envDict := starlark.NewDict(len(env))
+	for k, v := range env {
+		if sv, err := toStarlarkValue(v); err == nil {
+			_ = envDict.SetKey(starlark.String(k), sv)
+		}
+	}
+	envDict.Freeze()
+	trigger, _ := toStarlarkValue(val.Value)
+
+	predeclared := starlark.StringDict{
+		"value":      trigger,
+		"env":        envDict,
+		"rule_id":    starlark.String(ruleID),
+		"channel_id": starlark.String(val.ChannelID),
+		"device_id":  starlark.String(val.DeviceID),
+		"point_id":   starlark.String(val.PointID),
+		"read":       starlark.NewBuiltin("read", run.read),
+		"write":      starlark.NewBuiltin("write", run.write),
+		"log":        starlark.NewBuiltin("log", run.log),
+		"math":       math.Module,
+		"json":       starjson.Module,
+	}
+	globals, err := prog.Init(thread, predeclared)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return nil, fmt.Errorf("脚本执行失败: %s", evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("脚本执行失败: %w", err)
+	}
+	return globals, nil
+}
+
+// read(channel_id, device_id, point_id) 读取影子或规则值缓存中的最新值，不存在时返回 None
+func (r *scriptRun) read(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var channelID, deviceID, pointID string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "channel_id", &channelID, "device_id", &deviceID, "point_id", &pointID); err != nil {
+		return nil, err
+	}
+	if r.em.shadow != nil {
+		if p, err := r.em.shadow.GetShadowPoint(deviceID, pointID); err == nil {
+			return toStarlarkValue(p.Value)
+		}
+	}
+	r.em.cacheMu.RLock()
+	v, ok := r.em.valueCache[fmt.Sprintf("%s/%s/%s", channelID, deviceID, pointID)]
+	r.em.cacheMu.RUnlock()
+	if !ok {
+		return starlark.None, nil
+	}
+	return toStarlarkValue(v.Value)
+}
+
+// write(channel_id, device_id, point_id, value) 通过 DeviceIO 写点位，失败时脚本报错
+func (r *scriptRun) write(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var channelID, deviceID, pointID string
+	var value starlark.Value
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "channel_id", &channelID, "device_id", &deviceID, "point_id", &pointID, "value", &value); err != nil {
+		return nil, err
+	}
+	if r.writes >= r.limit {
+		return nil, fmt.Errorf("超过单次执行写入次数限制 %d", r.limit)
+	}
+	r.writes++
+	v, err := fromStarlarkValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if r.em.sim != nil {
+		r.em.sim.traceWrite(channelID, deviceID, pointID, v)
+		return starlark.None, nil
+	}
+	if r.em.writer == nil {
+		return nil, fmt.Errorf("device writer not configured")
+	}
+	if err := r.em.writer.WritePoint(channelID, deviceID, pointID, v); err != nil {
+		return nil, fmt.Errorf("写入 %s/%s/%s 失败: %w", channelID, deviceID, pointID, err)
+	}
+	return starlark.None, nil
+}
+
+// log(*args) 写入网关日志
+func (r *scriptRun) log(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if s, ok := starlark.AsString(a); ok {
+			parts[i] = s
+		} else {
+			parts[i] = a.String()
+		}
+	}
+	log.Printf("[EdgeScript] rule=%s %s", r.ruleID, strings.Join(parts, " "))
+	return starlark.None, nil
+}
+
+// evaluateScriptRule 执行 script 规则：脚本设置全局 result（输出值）与可选的 triggered；
+// 未设置 triggered 时，布尔 result 即触发结果，其他非 None 的 result 视为触发。
+func (em *EdgeComputeManager) evaluateScriptRule(rule model.EdgeRule, val model.Value, env map[string]any) (bool, any, error) {
+	if rule.Script == nil {
+		return false, nil, fmt.Errorf("脚本规则缺少 script 配置")
+	}
+	globals, err := em.runScript(em.ctx, rule.ID, *rule.Script, val, env)
+	if err != nil {
+		return false, nil, err
+	}
+	var result any
+	if v, ok := globals["result"]; ok {
+		if result, err = fromStarlarkValue(v); err != nil {
+			return false, nil, err
+		}
+	}
+	if v, ok := globals["triggered"]; ok {
+		return bool(v.Truth()), result, nil
+	}
+	if b, ok := result.(bool); ok {
+		return b, result, nil
+	}
+	return result != nil, result, nil
+}
+
+func (em *EdgeComputeManager) executeScript(ctx context.Context, ruleID string, action model.RuleAction, val model.Value, env map[string]any) error {
+	cfg, err := scriptConfigFromAction(action)
+	if err != nil {
+		return err
+	}
+	_, err = em.runScript(ctx, ruleID, cfg, val, env)
+	return err
+}
+
+func toStarlarkValue(v any) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case starlark.Value:
+		return val, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		f, _ := toFloat(val)
+		return starlark.MakeInt64(int64(f)), nil
+	case float32:
+		return starlark.Float(val), nil
+	case float64:
+		return starlark.Float(val), nil
+	case time.Time:
+		return starlark.String(val.Format(time.RFC3339Nano)), nil
+	case []any:
+		items := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			sv, err := toStarlarkValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		d := starlark.NewDict(len(val))
+		for k, item := range val {
+			sv, err := toStarlarkValue(item)
+			if err != nil {
+				return nil, err
+			}
+			_ = d.SetKey(starlark.String(k), sv)
+		}
+		return d, nil
+	}
+	if f, ok := toFloat(v); ok {
+		return starlark.Float(f), nil
+	}
+	return starlark.String(fmt.Sprint(v)), nil
+}
+
+func fromStarlarkValue(v starlark.Value) (any, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.Int:
+		if i, ok := val.Int64(); ok {
+			return i, nil
+		}
+		return nil, fmt.Errorf("整数超出范围: %s", val)
+	case starlark.Float:
+		return float64(val), nil
+	case starlark.String:
+		return string(val), nil
+	case *starlark.List:
+		out := make([]any, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			item, err := fromStarlarkValue(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, item)
+		}
+		return out, nil
+	case starlark.Tuple:
+		out := make([]any, 0, len(val))
+		for _, elem := range val {
+			item, err := fromStarlarkValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, item)
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, val.Len())
+		for _, kv := range val.Items() {
+			key, ok := starlark.AsString(kv[0])
+			if !ok {
+				key = kv[0].String()
+			}
+			item, err := fromStarlarkValue(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			out[key] = item
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("不支持的脚本返回类型: %s", v.Type())
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func TestScriptRuleParsesAndWrites(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	writer := &TestDeviceWriter{}
+	em.SetDeviceWriter(writer)
+	em.valueCache["ch1/dev1/setpoint"] = model.Value{Value: 40.0}
+
+	rule := model.EdgeRule{
+		ID:   "parse",
+		Type: "script",
+		Script: &model.ScriptConfig{Source: `
+fields = {}
+for part in value.split(";"):
+    k, v = part.split("=")
+    fields[k] = float(v)
+total = 0
+for v in fields.values():
+    total += v
+if total > read("ch1", "dev1", "setpoint"):
+    write("ch1", "dev1", "fan", True)
+result = total
+`},
+	}
+	triggered, result, err := em.evaluateScriptRule(rule, model.Value{Value: "a=12.5;b=30"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !triggered || result != 42.5 {
+		t.Fatalf("triggered=%v result=%v", triggered, result)
+	}
+	if writer.WriteCount != 1 || writer.LastValue != true {
+		t.Fatalf("writes=%d last=%v", writer.WriteCount, writer.LastValue)
+	}
+}
+
+func TestScriptLimits(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	em.SetDeviceWriter(&TestDeviceWriter{})
+
+	cases := []struct {
+		name string
+		cfg  model.ScriptConfig
+		want string
+	}{
+		{"steps", model.ScriptConfig{Source: "while True:\n    pass", MaxSteps: 50000, Timeout: "10s"}, "最大执行步数"},
+		{"timeout", model.ScriptConfig{Source: "while True:\n    pass", Timeout: "20ms", MaxSteps: 1 << 40}, "超时"},
+		{"writes", model.ScriptConfig{Source: "for i in range(3):\n    write('c', 'd', 'p', i)", MaxWrites: 2}, "写入次数限制"},
+	}
+	for _, tc := range cases {
+		start := time.Now()
+		_, err := em.runScript(context.Background(), "limits", tc.cfg, model.Value{}, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %q error, got %v", tc.name, tc.want, err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("%s: limit not enforced promptly", tc.name)
+		}
+	}
+}
+
+func TestScriptErrorsRecordedAsEvents(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{
+		ID: "bad-script", Name: "Bad Script", Type: "script", Enable: true,
+		Sources: []model.RuleSource{{Alias: "t1", ChannelID: "ch1", DeviceID: "dev1", PointID: "p1"}},
+		Script:  &model.ScriptConfig{Source: "result = 1 / 0"},
+	}
+	if err := em.UpsertRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	em.executeRule(rule, model.Value{ChannelID: "ch1", DeviceID: "dev1", PointID: "p1", Value: 1.0, TS: time.Now()})
+
+	failures := em.GetFailures("bad-script", 10)
+	if len(failures) == 0 || !strings.Contains(failures[0].Error, "division by zero") {
+		t.Fatalf("failures = %+v", failures)
+	}
+
+	rule.Script.Source = "result = ("
+	if err := em.UpsertRule(rule); err == nil || !strings.Contains(err.Error(), "脚本无效") {
+		t.Fatalf("expected compile error, got %v", err)
+	}
+}
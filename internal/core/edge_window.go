@@ -0,0 +1,475 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// maxWindowEmits 单次求值最多关闭的窗口数，防止时间戳跳变时长时间循环
+const maxWindowEmits = 1000
+
+// windowSpec 解析后的窗口配置
+type windowSpec struct {
+	kind     string        // sliding, tumbling, hopping, session
+	size     time.Duration // 0 表示计数窗口
+	count    int
+	hop      time.Duration
+	gap      time.Duration
+	lateness time.Duration
+	step     time.Duration // sliding 的求值步长
+}
+
+// windowResult 一个关闭（或滑动）窗口的聚合结果
+type windowResult struct {
+	triggered bool
+	value     model.Value
+	env       map[string]any
+	samples   int
+}
+
+type windowEmit struct {
+	start, end time.Time
+	samples    []model.Value
+}
+
+var windowAggrFuncs = map[string]bool{
+	"": true, "avg": true, "min": true, "max": true, "sum": true, "count": true, "rate": true,
+	"first": true, "last": true, "delta": true, "stddev": true, "median": true, "percentile": true,
+}
+
+func parseWindowSpec(w *model.WindowConfig) (windowSpec, error) {
+	spec := windowSpec{kind: w.Type}
+	if spec.kind == "" {
+		spec.kind = "sliding"
+	}
+	if d, err := time.ParseDuration(w.Size); err == nil {
+		if d <= 0 {
+			return spec, fmt.Errorf("窗口 size 无效: %q", w.Size)
+		}
+		spec.size = d
+	} else if n, err := strconv.Atoi(strings.TrimSpace(w.Size)); err == nil && n > 0 {
+		spec.count = n
+	} else if spec.kind == "sliding" {
+		spec.count = 10 // 兼容旧规则的默认计数窗口
+	} else if spec.kind != "session" {
+		return spec, fmt.Errorf("窗口 size 无效: %q", w.Size)
+	}
+	if w.Lateness != "" {
+		d, err := time.ParseDuration(w.Lateness)
+		if err != nil || d < 0 {
+			return spec, fmt.Errorf("窗口 allowed_lateness 无效: %q", w.Lateness)
+		}
+		spec.lateness = d
+	}
+
+	switch spec.kind {
+	case "sliding":
+		if w.Interval != "" {
+			if d, err := time.ParseDuration(w.Interval); err == nil && d > 0 {
+				spec.step = d
+			}
+		}
+	case "tumbling":
+		spec.hop = spec.size
+	case "hopping":
+		if spec.size == 0 {
+			return spec, fmt.Errorf("窗口 size 无效: hopping 窗口需要时间长度")
+		}
+		d, err := time.ParseDuration(w.Interval)
+		if err != nil || d <= 0 {
+			return spec, fmt.Errorf("窗口 interval 无效: hopping 窗口需要步长")
+		}
+		spec.hop = d
+	case "session":
+		d, err := time.ParseDuration(w.Gap)
+		if err != nil || d <= 0 {
+			return spec, fmt.Errorf("窗口 gap 无效: session 窗口需要间隔")
+		}
+		spec.gap = d
+	default:
+		return spec, fmt.Errorf("窗口类型无效: %q", w.Type)
+	}
+	return spec, nil
+}
+
+// validateRuleWindow 保存前校验窗口规则配置
+func validateRuleWindow(rule model.EdgeRule) error {
+	if rule.Type != "window" {
+		return nil
+	}
+	if rule.Window == nil {
+		return fmt.Errorf("窗口配置无效: window 不能为空")
+	}
+	if _, err := parseWindowSpec(rule.Window); err != nil {
+		return err
+	}
+	if !windowAggrFuncs[rule.Window.AggrFunc] {
+		return fmt.Errorf("窗口聚合函数无效: %q", rule.Window.AggrFunc)
+	}
+	if rule.Window.AggrFunc == "percentile" && (rule.Window.Percentile < 0 || rule.Window.Percentile > 100) {
+		return fmt.Errorf("窗口 percentile 无效: 需在 0 ~ 100 之间")
+	}
+	switch rule.Window.KeyBy {
+	case "", "source", "device":
+	default:
+		return fmt.Errorf("窗口 key_by 无效: %q", rule.Window.KeyBy)
+	}
+	return nil
+}
+
+// windowKey 按 key_by 返回样本所属窗口
+func windowKey(w *model.WindowConfig, val model.Value) string {
+	switch w.KeyBy {
+	case "source":
+		return val.ChannelID + "/" + val.DeviceID + "/" + val.PointID
+	case "device":
+		return val.ChannelID + "/" + val.DeviceID
+	default:
+		return ""
+	}
+}
+
+// windowStoreKey 返回 em.windows 与持久化使用的键
+func windowStoreKey(ruleID, key string) string {
+	if key == "" {
+		return ruleID
+	}
+	return ruleID + "|" + key
+}
+
+// isLate 判断样本是否落后于水位线，或属于已经输出的窗口
+func (spec windowSpec) isLate(ks *model.WindowKeyState, ts time.Time) bool {
+	if ks.MaxTS.IsZero() {
+		return false
+	}
+	switch spec.kind {
+	case "sliding":
+		if spec.lateness > 0 && ts.Before(ks.MaxTS.Add(-spec.lateness)) {
+			return true
+		}
+		return spec.size > 0 && ts.Before(ks.MaxTS.Add(-spec.size))
+	case "tumbling", "hopping":
+		if spec.size == 0 {
+			return false
+		}
+		// 包含该样本的最后一个窗口已输出
+		return !ts.Truncate(spec.hop).Add(spec.size).After(ks.EmittedUntil)
+	case "session":
+		return ts.Before(ks.EmittedUntil)
+	}
+	return false
+}
+
+// insertSample 按时间戳有序插入，乱序样本插到对应位置
+func insertSample(samples []model.Value, val model.Value) []model.Value {
+	i := len(samples)
+	for i > 0 && samples[i-1].TS.After(val.TS) {
+		i--
+	}
+	samples = append(samples, model.Value{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = val
+	return samples
+}
+
+// collect 返回需要求值的窗口与仍需保留的样本
+func (spec windowSpec) collect(ks *model.WindowKeyState, samples []model.Value) ([]windowEmit, []model.Value) {
+	switch spec.kind {
+	case "sliding":
+		if spec.size > 0 {
+			cutoff := ks.MaxTS.Add(-spec.size)
+			i := sort.Search(len(samples), func(i int) bool { return !samples[i].TS.Before(cutoff) })
+			samples = samples[i:]
+			return []windowEmit{{start: cutoff, end: ks.MaxTS, samples: cloneValues(samples)}}, samples
+		}
+		if len(samples) > spec.count {
+			samples = samples[len(samples)-spec.count:]
+		}
+		return []windowEmit{{start: samples[0].TS, end: ks.MaxTS, samples: cloneValues(samples)}}, samples
+	case "tumbling", "hopping":
+		if spec.size == 0 {
+			var emits []windowEmit
+			for len(samples) >= spec.count {
+				batch := samples[:spec.count]
+				emits = append(emits, windowEmit{start: batch[0].TS, end: batch[len(batch)-1].TS, samples: cloneValues(batch)})
+				samples = samples[spec.count:]
+			}
+			return emits, samples
+		}
+		return spec.collectFixed(ks, samples)
+	case "session":
+		return spec.collectSessions(ks, samples)
+	}
+	return nil, samples
+}
+
+// collectFixed 输出水位线已越过结束时间的滚动/跳跃窗口
+func (spec windowSpec) collectFixed(ks *model.WindowKeyState, samples []model.Value) ([]windowEmit, []model.Value) {
+	if len(samples) == 0 {
+		return nil, samples
+	}
+	watermark := ks.MaxTS.Add(-spec.lateness)
+	var start time.Time
+	if ks.EmittedUntil.IsZero() {
+		// 包含最早样本的第一个窗口
+		start = samples[0].TS.Add(-spec.size).Truncate(spec.hop).Add(spec.hop)
+	} else {
+		start = ks.EmittedUntil.Add(spec.hop - spec.size)
+	}
+
+	var emits []windowEmit
+	for n := 0; n < maxWindowEmits && !start.Add(spec.size).After(watermark); n++ {
+		end := start.Add(spec.size)
+		lo := sort.Search(len(samples), func(i int) bool { return !samples[i].TS.Before(start) })
+		hi := sort.Search(len(samples), func(i int) bool { return !samples[i].TS.Before(end) })
+		if lo < hi {
+			emits = append(emits, windowEmit{start: start, end: end, samples: cloneValues(samples[lo:hi])})
+		}
+		ks.EmittedUntil = end
+		if lo == len(samples) {
+			break
+		}
+		next := start.Add(spec.hop)
+		if hi == lo {
+			// 跳过没有数据的窗口
+			if jump := samples[lo].TS.Add(-spec.size).Truncate(spec.hop).Add(spec.hop); jump.After(next) {
+				next = jump
+			}
+		}
+		start = next
+	}
+
+	keepFrom := ks.EmittedUntil.Add(spec.hop - spec.size)
+	i := sort.Search(len(samples), func(i int) bool { return !samples[i].TS.Before(keepFrom) })
+	return emits, samples[i:]
+}
+
+// collectSessions 输出最后一个样本之后 gap 内无新数据（按水位线判断）的会话
+func (spec windowSpec) collectSessions(ks *model.WindowKeyState, samples []model.Value) ([]windowEmit, []model.Value) {
+	watermark := ks.MaxTS.Add(-spec.lateness)
+	var emits []windowEmit
+	for len(samples) > 0 && len(emits) < maxWindowEmits {
+		j := 1
+		for j < len(samples) && samples[j].TS.Sub(samples[j-1].TS) < spec.gap {
+			j++
+		}
+		end := samples[j-1].TS.Add(spec.gap)
+		if end.After(watermark) {
+			break
+		}
+		emits = append(emits, windowEmit{start: samples[0].TS, end: end, samples: cloneValues(samples[:j])})
+		ks.EmittedUntil = end
+		samples = samples[j:]
+	}
+	return emits, samples
+}
+
+func cloneValues(v []model.Value) []model.Value {
+	return append([]model.Value(nil), v...)
+}
+
+// aggregateWindow 计算窗口聚合值，非数值样本被忽略
+func aggregateWindow(fn string, pct float64, samples []model.Value) float64 {
+	vals := make([]float64, 0, len(samples))
+	var firstTS, lastTS time.Time
+	for _, v := range samples {
+		f, ok := toFloat(v.Value)
+		if !ok {
+			continue
+		}
+		if len(vals) == 0 {
+			firstTS = v.TS
+		}
+		lastTS = v.TS
+		vals = append(vals, f)
+	}
+	if len(vals) == 0 {
+		return 0
+	}
+	first, last := vals[0], vals[len(vals)-1]
+
+	switch fn {
+	case "sum", "avg":
+		var sum float64
+		for _, f := range vals {
+			sum += f
+		}
+		if fn == "avg" {
+			return sum / float64(len(vals))
+		}
+		return sum
+	case "max", "min":
+		res := vals[0]
+		for _, f := range vals[1:] {
+			if (fn == "max" && f > res) || (fn == "min" && f < res) {
+				res = f
+			}
+		}
+		return res
+	case "count":
+		return float64(len(vals))
+	case "rate":
+		// (Last - First) / Duration (in seconds)
+		if d := lastTS.Sub(firstTS).Seconds(); len(vals) > 1 && d > 0 {
+			return (last - first) / d
+		}
+		return 0
+	case "first":
+		return first
+	case "last":
+		return last
+	case "delta":
+		return last - first
+	case "stddev":
+		var mean float64
+		for _, f := range vals {
+			mean += f
+		}
+		mean /= float64(len(vals))
+		var sq float64
+		for _, f := range vals {
+			sq += (f - mean) * (f - mean)
+		}
+		return math.Sqrt(sq / float64(len(vals)))
+	case "median":
+		return percentile(vals, 50)
+	case "percentile":
+		return percentile(vals, pct)
+	}
+	return 0
+}
+
+// percentile 线性插值百分位数
+func percentile(vals []float64, p float64) float64 {
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// evaluateWindow 将样本写入所属窗口，返回本次需要求值的窗口结果；
+// 滚动、跳跃与会话窗口在水位线越过窗口结束时间后才输出，结果为空表示仍在缓冲。
+func (em *EdgeComputeManager) evaluateWindow(rule model.EdgeRule, val model.Value, baseEnv map[string]any) ([]windowResult, error) {
+	if rule.Window == nil {
+		return nil, fmt.Errorf("missing window config")
+	}
+	spec, err := parseWindowSpec(rule.Window)
+	if err != nil {
+		return nil, err
+	}
+	if val.TS.IsZero() {
+		val.TS = em.clock()
+	}
+	key := windowKey(rule.Window, val)
+	storeKey := windowStoreKey(rule.ID, key)
+
+	em.stateMu.Lock()
+	ks := &model.WindowKeyState{}
+	state := em.ruleStates[rule.ID]
+	if state != nil {
+		if state.Windows == nil {
+			state.Windows = make(map[string]*model.WindowKeyState)
+		}
+		if existing := state.Windows[key]; existing != nil {
+			ks = existing
+		} else {
+			state.Windows[key] = ks
+		}
+	}
+	if ks.MaxTS.IsZero() {
+		// 从持久化窗口恢复的样本
+		if history := em.windows[storeKey]; len(history) > 0 {
+			ks.MaxTS = history[len(history)-1].TS
+		}
+	}
+	if spec.isLate(ks, val.TS) {
+		ks.LateDropped++
+		em.stateMu.Unlock()
+		em.sim.traceWindowLate()
+		return nil, nil
+	}
+
+	samples := insertSample(em.windows[storeKey], val)
+	if val.TS.After(ks.MaxTS) {
+		ks.MaxTS = val.TS
+	}
+	emits, samples := spec.collect(ks, samples)
+	em.windows[storeKey] = em.trimWindowSamples(samples)
+
+	// Sliding step: buffer always, aggregate only on Interval tick.
+	if spec.step > 0 && state != nil {
+		if !state.LastWindowEval.IsZero() && em.clock().Sub(state.LastWindowEval) < spec.step {
+			emits = nil
+		}
+	}
+	em.stateMu.Unlock()
+
+	// Persist window data asynchronously
+	go em.saveWindowData(storeKey)
+	if len(emits) == 0 {
+		return nil, nil
+	}
+
+	results := make([]windowResult, 0, len(emits))
+	for _, e := range emits {
+		result := aggregateWindow(rule.Window.AggrFunc, rule.Window.Percentile, e.samples)
+		env := make(map[string]any, len(baseEnv)+4)
+		for k, v := range baseEnv {
+			env[k] = v
+		}
+		env["window_key"] = key
+		env["window_count"] = len(e.samples)
+		env["window_start"] = e.start
+		env["window_end"] = e.end
+
+		// Evaluate Condition against Result
+		condEnv := make(map[string]any, len(env))
+		for k, v := range env {
+			condEnv[k] = v
+		}
+		condEnv["value"] = result
+		triggered, err := evaluateThreshold(rule.Condition, condEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		outputVal := val
+		outputVal.Value = result
+		if spec.kind != "sliding" {
+			outputVal.TS = e.end
+		}
+		results = append(results, windowResult{triggered: triggered, value: outputVal, env: env, samples: len(e.samples)})
+	}
+	em.sim.traceWindowSamples(results[len(results)-1].samples)
+
+	em.stateMu.Lock()
+	if state != nil {
+		state.LastWindowEval = em.clock()
+	}
+	em.stateMu.Unlock()
+	return results, nil
+}
+
+// cloneWindowStates 复制窗口进度，供状态快照与持久化使用
+func cloneWindowStates(in map[string]*model.WindowKeyState) map[string]*model.WindowKeyState {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]*model.WindowKeyState, len(in))
+	for k, v := range in {
+		c := *v
+		out[k] = &c
+	}
+	return out
+}
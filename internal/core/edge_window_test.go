@@ -0,0 +1,171 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+var windowBase = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// feedWindow 依次送入样本，返回每次输出的窗口聚合值
+func feedWindow(t *testing.T, em *EdgeComputeManager, rule model.EdgeRule, values []model.Value) [][]windowResult {
+	t.Helper()
+	if err := validateRuleWindow(rule); err != nil {
+		t.Fatalf("validateRuleWindow: %v", err)
+	}
+	if em.ruleStates[rule.ID] == nil {
+		em.ruleStates[rule.ID] = &model.RuleRuntimeState{RuleID: rule.ID}
+	}
+	var out [][]windowResult
+	for _, v := range values {
+		res, err := em.evaluateWindow(rule, v, map[string]any{})
+		if err != nil {
+			t.Fatalf("evaluateWindow: %v", err)
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+func sample(point string, v float64, offset time.Duration) model.Value {
+	return model.Value{ChannelID: "ch", DeviceID: "d", PointID: point, Value: v, TS: windowBase.Add(offset)}
+}
+
+func TestWindowTumblingWithWatermark(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{ID: "tw", Type: "window", Condition: "value > 0",
+		Window: &model.WindowConfig{Type: "tumbling", Size: "10s", AggrFunc: "sum", Lateness: "2s"}}
+
+	out := feedWindow(t, em, rule, []model.Value{
+		sample("p", 1, 1*time.Second),
+		sample("p", 2, 9*time.Second),
+		sample("p", 4, 11*time.Second),  // watermark 9s: [0,10) still open
+		sample("p", 8, 5*time.Second),   // out of order but within lateness
+		sample("p", 16, 13*time.Second), // watermark 11s: closes [0,10)
+		sample("p", 32, 3*time.Second),  // window already emitted: late
+	})
+	for i := 0; i < 4; i++ {
+		if len(out[i]) != 0 {
+			t.Fatalf("step %d emitted before watermark passed the window: %+v", i, out[i])
+		}
+	}
+	if len(out[4]) != 1 || out[4][0].value.Value != 11.0 || !out[4][0].value.TS.Equal(windowBase.Add(10*time.Second)) {
+		t.Fatalf("unexpected [0,10) result: %+v", out[4])
+	}
+	if len(out[5]) != 0 {
+		t.Fatal("late sample should not emit")
+	}
+	ks := em.ruleStates["tw"].Windows[""]
+	if ks.LateDropped != 1 || !ks.EmittedUntil.Equal(windowBase.Add(10*time.Second)) {
+		t.Fatalf("unexpected window state: %+v", ks)
+	}
+	if data := em.GetWindowData("tw"); len(data) != 2 {
+		t.Fatalf("expected the open window to keep 2 samples, got %d", len(data))
+	}
+}
+
+func TestWindowHoppingEmitsOverlappingWindows(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{ID: "hw", Type: "window", Condition: "value >= 0",
+		Window: &model.WindowConfig{Type: "hopping", Size: "10s", Interval: "5s", AggrFunc: "count"}}
+
+	out := feedWindow(t, em, rule, []model.Value{
+		sample("p", 1, 1*time.Second),
+		sample("p", 1, 6*time.Second), // closes [-5,5)
+		sample("p", 1, 8*time.Second),
+		sample("p", 1, 21*time.Second), // closes [0,10), [5,15); [10,20) is empty
+	})
+	if len(out[1]) != 1 || out[1][0].value.Value != 1.0 {
+		t.Fatalf("unexpected [-5,5) result: %+v", out[1])
+	}
+	var counts []float64
+	for _, r := range out[3] {
+		counts = append(counts, r.value.Value.(float64))
+	}
+	if len(counts) != 2 || counts[0] != 3 || counts[1] != 2 {
+		t.Fatalf("counts = %v, want [3 2]", counts)
+	}
+	if data := em.GetWindowData("hw"); len(data) != 1 {
+		t.Fatalf("expected only the 21s sample to be retained, got %d", len(data))
+	}
+}
+
+func TestWindowSessionKeyedBySource(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{ID: "sw", Type: "window", Condition: "value > 0",
+		Window: &model.WindowConfig{Type: "session", Gap: "5s", AggrFunc: "delta", KeyBy: "source"}}
+
+	out := feedWindow(t, em, rule, []model.Value{
+		sample("a", 10, 0),
+		sample("b", 100, 1*time.Second),
+		sample("a", 13, 2*time.Second),
+		sample("a", 20, 4*time.Second),
+		sample("b", 90, 3*time.Second),
+		sample("a", 50, 20*time.Second), // closes session a [0,4]
+	})
+	last := out[5]
+	if len(last) != 1 || last[0].value.Value != 10.0 || last[0].env["window_key"] != "ch/d/a" || last[0].samples != 3 {
+		t.Fatalf("unexpected session result: %+v", last)
+	}
+	byKey := em.GetWindowDataByKey("sw")
+	if len(byKey["ch/d/b"]) != 2 || len(byKey["ch/d/a"]) != 1 {
+		t.Fatalf("unexpected keyed buffers: %+v", byKey)
+	}
+}
+
+func TestWindowSlidingDropsSamplesBehindWatermark(t *testing.T) {
+	em := NewEdgeComputeManager(nil, nil, nil)
+	rule := model.EdgeRule{ID: "sl", Type: "window", Condition: "value > 0",
+		Window: &model.WindowConfig{Type: "sliding", Size: "1m", AggrFunc: "last", Lateness: "5s"}}
+
+	out := feedWindow(t, em, rule, []model.Value{
+		sample("p", 1, 10*time.Second),
+		sample("p", 2, 20*time.Second),
+		sample("p", 3, 17*time.Second), // within lateness, inserted in order
+		sample("p", 4, 5*time.Second),  // behind watermark
+	})
+	if len(out[2]) != 1 || out[2][0].value.Value != 2.0 || out[2][0].samples != 3 {
+		t.Fatalf("out-of-order sample should be inserted before the latest: %+v", out[2])
+	}
+	if len(out[3]) != 0 || em.ruleStates["sl"].Windows[""].LateDropped != 1 {
+		t.Fatal("sample behind the watermark should be dropped")
+	}
+}
+
+func TestAggregateWindowFunctions(t *testing.T) {
+	var samples []model.Value
+	for i, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		samples = append(samples, model.Value{Value: v, TS: windowBase.Add(time.Duration(i) * time.Second)})
+	}
+	cases := map[string]float64{
+		"first": 2, "last": 9, "delta": 7, "stddev": 2, "median": 4.5, "count": 8, "avg": 5, "rate": 1,
+	}
+	for fn, want := range cases {
+		if got := aggregateWindow(fn, 0, samples); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", fn, got, want)
+		}
+	}
+	if got := aggregateWindow("percentile", 90, samples); math.Abs(got-7.6) > 1e-9 {
+		t.Errorf("p90 = %v, want 7.6", got)
+	}
+}
+
+func TestValidateRuleWindow(t *testing.T) {
+	bad := []model.WindowConfig{
+		{Type: "hopping", Size: "10s"},
+		{Type: "session"},
+		{Type: "tumbling", Size: "abc"},
+		{Type: "sliding", Size: "10s", AggrFunc: "mode"},
+		{Type: "sliding", Size: "10s", KeyBy: "channel"},
+		{Type: "sliding", Size: "10s", Lateness: "soon"},
+	}
+	for _, w := range bad {
+		w := w
+		if err := validateRuleWindow(model.EdgeRule{Type: "window", Window: &w}); err == nil {
+			t.Errorf("expected %+v to be rejected", w)
+		}
+	}
+}
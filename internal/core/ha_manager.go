@@ -0,0 +1,633 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"go.uber.org/zap"
+)
+
+// HA node states.
+const (
+	HAStateActive  = "active"
+	HAStateStandby = "standby"
+)
+
+const haMaxHistory = 20
+
+// HAHooks connect the redundancy manager to the gateway runtime.
+type HAHooks struct {
+	// Activate starts southbound polling and northbound connectors.
+	Activate func()
+	// BeforeDeactivate runs while the node is still active, so outputs can be
+	// driven to a safe state before the write guard starts rejecting writes.
+	BeforeDeactivate func()
+	// Deactivate stops polling and suppresses northbound output.
+	Deactivate func()
+	// ExportConfig returns the configuration snapshot mirrored to the standby.
+	ExportConfig func() ([]byte, error)
+	// ImportConfig persists a snapshot received from the active node.
+	ImportConfig func(data []byte) error
+}
+
+// HASwitchover records one role change of the local node.
+type HASwitchover struct {
+	Time   time.Time `json:"time"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// HAPeerStatus is what the local node knows about its peer.
+type HAPeerStatus struct {
+	Address     string    `json:"address"`
+	Reachable   bool      `json:"reachable"`
+	NodeID      string    `json:"node_id,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	State       string    `json:"state,omitempty"`
+	Term        uint64    `json:"term"`
+	LastSeen    time.Time `json:"last_seen,omitempty"`
+	MissedBeats int       `json:"missed_beats"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// HAStatus is returned by the HA status API.
+type HAStatus struct {
+	Enabled        bool           `json:"enabled"`
+	NodeID         string         `json:"node_id"`
+	Role           string         `json:"role"`
+	State          string         `json:"state"`
+	Term           uint64         `json:"term"`
+	HeartbeatType  string         `json:"heartbeat_type"`
+	ListenError    string         `json:"listen_error,omitempty"`
+	Peer           HAPeerStatus   `json:"peer"`
+	Guard          string         `json:"split_brain_guard,omitempty"`
+	LastSwitchover *HASwitchover  `json:"last_switchover,omitempty"`
+	History        []HASwitchover `json:"history"`
+	ConfigVersion  string         `json:"config_version,omitempty"`
+	MirroredAt     time.Time      `json:"mirrored_at,omitempty"`
+	MirrorError    string         `json:"mirror_error,omitempty"`
+}
+
+// HAManager runs active/standby redundancy between two gateways.
+//
+// Both nodes probe each other every interval. A standby mirrors the config of
+// the active node and takes over after `retries` missed heartbeats, unless a
+// configured witness is unreachable (then the standby is the isolated one).
+// When both nodes are active (split brain after a partition heals) the node
+// with the lower term yields; ties go to the configured master, then to the
+// lower node ID. Promotion is never preemptive: a recovered master stays
+// standby while the backup is active.
+type HAManager struct {
+	mu      sync.Mutex
+	cfg     model.HAConfig
+	hooks   HAHooks
+	nodeID  string
+	started bool
+
+	state string // "" until Start
+	term  uint64
+	peer  HAPeerStatus
+	guard string
+
+	history         []HASwitchover
+	listenErr       string
+	mirroredVersion string
+	mirroredAt      time.Time
+	mirrorErr       string
+
+	// The export cache has its own lock: building a snapshot reads the config
+	// store and must not block heartbeats or status queries on h.mu.
+	exportMu      sync.Mutex
+	exportData    []byte
+	exportVersion string
+	exportAt      time.Time
+
+	interval time.Duration
+	timeout  time.Duration
+	retries  int
+	unit     time.Duration // length of one configured "second"; shortened in tests
+
+	listener *haListener
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewHAManager creates a redundancy manager; nothing runs until Start.
+func NewHAManager(cfg model.HAConfig, hooks HAHooks) *HAManager {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "edgex"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return &HAManager{
+		cfg:    cfg,
+		hooks:  hooks,
+		nodeID: host + "-" + hex.EncodeToString(suffix),
+		unit:   time.Second,
+	}
+}
+
+func normalizeHAConfig(cfg model.HAConfig) model.HAConfig {
+	cfg.HeartbeatType = strings.ToUpper(strings.TrimSpace(cfg.HeartbeatType))
+	switch cfg.HeartbeatType {
+	case "TCP", "UDP", "HTTP":
+	default:
+		cfg.HeartbeatType = "UDP"
+	}
+	cfg.Role = strings.ToLower(strings.TrimSpace(cfg.Role))
+	if cfg.Role != "backup" {
+		cfg.Role = "master"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 2
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5
+	}
+	if cfg.Retries <= 0 {
+		cfg.Retries = 3
+	}
+	if cfg.Port <= 0 {
+		cfg.Port = defaultHAPort
+	}
+	return cfg
+}
+
+// ValidateHAConfig rejects enabling redundancy without a shared key: the key
+// authenticates heartbeats and encrypts the mirrored config, which contains
+// connector credentials and private keys.
+func ValidateHAConfig(cfg model.HAConfig) error {
+	if cfg.Enabled && strings.TrimSpace(cfg.SharedKey) == "" {
+		return fmt.Errorf("启用主备冗余必须配置 shared_key")
+	}
+	return nil
+}
+
+// Start applies the configured HA settings. With HA disabled the node
+// activates immediately; otherwise it starts as standby and lets the
+// heartbeat decide.
+func (h *HAManager) Start() {
+	h.mu.Lock()
+	if h.started {
+		h.mu.Unlock()
+		return
+	}
+	h.started = true
+	cfg := h.cfg
+	h.mu.Unlock()
+	h.apply(cfg)
+}
+
+// ApplyConfig restarts the heartbeat with new settings, keeping the current state.
+func (h *HAManager) ApplyConfig(cfg model.HAConfig) {
+	h.mu.Lock()
+	started := h.started
+	unchanged := normalizeHAConfig(cfg) == normalizeHAConfig(h.cfg)
+	h.cfg = cfg
+	h.mu.Unlock()
+	if started && !unchanged {
+		h.apply(cfg)
+	}
+}
+
+// Stop ends heartbeating; the runtime is left as is.
+func (h *HAManager) Stop() {
+	h.stopLoop()
+}
+
+func (h *HAManager) apply(cfg model.HAConfig) {
+	h.stopLoop()
+	cfg = normalizeHAConfig(cfg)
+
+	h.mu.Lock()
+	h.cfg = cfg
+	h.interval = time.Duration(cfg.Interval) * h.unit
+	h.timeout = time.Duration(cfg.Timeout) * h.unit
+	h.retries = cfg.Retries
+	h.peer = HAPeerStatus{Address: haPeerAddress(cfg.PeerAddress, cfg.Port)}
+	h.guard = ""
+	h.listenErr = ""
+	first := h.state == ""
+	if !cfg.Enabled {
+		h.mu.Unlock()
+		if first {
+			h.mu.Lock()
+			h.state = HAStateActive
+			h.mu.Unlock()
+			h.runHook(h.hooks.Activate)
+			return
+		}
+		h.promote("HA disabled")
+		return
+	}
+	if cfg.SharedKey == "" {
+		zap.L().Warn("[HA] no shared_key configured: heartbeats are unauthenticated and config mirroring is disabled")
+	}
+	if first {
+		// The runtime is not started yet, so no Deactivate is needed.
+		h.state = HAStateStandby
+	}
+	h.mu.Unlock()
+
+	listener, err := startHAListener(cfg.HeartbeatType, cfg.Port, cfg.SharedKey, h.handle)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	h.mu.Lock()
+	if err != nil {
+		h.listenErr = err.Error()
+		zap.L().Error("[HA] heartbeat listener failed", zap.Error(err))
+	}
+	h.listener = listener
+	h.cancel = cancel
+	h.done = done
+	h.mu.Unlock()
+
+	zap.L().Info("[HA] redundancy started",
+		zap.String("node_id", h.nodeID),
+		zap.String("role", cfg.Role),
+		zap.String("heartbeat", cfg.HeartbeatType),
+		zap.String("peer", h.peer.Address),
+	)
+	go h.run(ctx, done)
+}
+
+func (h *HAManager) stopLoop() {
+	h.mu.Lock()
+	cancel, done, listener := h.cancel, h.done, h.listener
+	h.cancel, h.done, h.listener = nil, nil, nil
+	h.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	if listener != nil {
+		listener.close()
+	}
+}
+
+func (h *HAManager) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	h.beat(ctx)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.beat(ctx)
+		}
+	}
+}
+
+// beat probes the peer once and runs the state machine.
+func (h *HAManager) beat(ctx context.Context) {
+	version := h.activeConfigVersion()
+	h.mu.Lock()
+	cfg := h.cfg
+	addr := h.peer.Address
+	req := h.selfMessageLocked(haMsgHeartbeat, version)
+	h.mu.Unlock()
+
+	var reply *haMessage
+	err := fmt.Errorf("ha: peer_address is not configured")
+	if addr != "" {
+		reply, err = haExchange(ctx, cfg.HeartbeatType, addr, cfg.SharedKey, req, h.timeout)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	h.mu.Lock()
+	if err != nil {
+		h.peer.Reachable = false
+		h.peer.MissedBeats++
+		h.peer.LastError = err.Error()
+	} else {
+		h.recordPeerLocked(reply)
+		h.peer.Reachable = true
+		h.peer.MissedBeats = 0
+		h.peer.LastError = ""
+	}
+	missed := h.peer.MissedBeats
+	state := h.state
+	h.mu.Unlock()
+
+	switch {
+	case err != nil:
+		if state != HAStateStandby || missed < h.retries {
+			return
+		}
+		if cfg.Witness != "" && !haWitnessReachable(ctx, cfg.Witness, h.timeout) {
+			h.setGuard(fmt.Sprintf("peer lost but witness %s is unreachable; staying standby", cfg.Witness))
+			return
+		}
+		h.promote(fmt.Sprintf("heartbeat lost: %d missed heartbeats from %s (%v)", missed, addr, err))
+	case state == HAStateStandby && reply.State == HAStateActive:
+		h.setGuard("")
+		h.mirror(ctx, cfg, addr, reply)
+	case state == HAStateStandby && reply.State == HAStateStandby:
+		if h.preferredOver(reply) {
+			h.promote(fmt.Sprintf("peer %s is standby and this node is preferred", reply.NodeID))
+		}
+	case state == HAStateActive && reply.State == HAStateActive:
+		if h.preferredOver(reply) {
+			h.setGuard(fmt.Sprintf("split brain: peer %s is also active (term %d); waiting for it to yield", reply.NodeID, reply.Term))
+			return
+		}
+		h.demote(fmt.Sprintf("split brain: peer %s is active with term %d", reply.NodeID, reply.Term))
+	}
+}
+
+// preferredOver decides which node should be active when both claim (or
+// neither claims) the active role.
+func (h *HAManager) preferredOver(peer *haMessage) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.term != peer.Term {
+		return h.term > peer.Term
+	}
+	selfMaster, peerMaster := h.cfg.Role == "master", peer.Role == "master"
+	if selfMaster != peerMaster {
+		return selfMaster
+	}
+	return h.nodeID < peer.NodeID
+}
+
+func (h *HAManager) promote(reason string) {
+	h.mu.Lock()
+	if h.state == HAStateActive {
+		h.mu.Unlock()
+		return
+	}
+	if h.peer.Term > h.term {
+		h.term = h.peer.Term
+	}
+	h.term++
+	h.recordLocked(HAStateActive, reason)
+	h.mu.Unlock()
+
+	zap.L().Warn("[HA] promoted to active", zap.String("reason", reason))
+	h.runHook(h.hooks.Activate)
+}
+
+func (h *HAManager) demote(reason string) {
+	h.mu.Lock()
+	if h.state == HAStateStandby {
+		h.mu.Unlock()
+		return
+	}
+	h.mu.Unlock()
+	h.runHook(h.hooks.BeforeDeactivate)
+
+	h.mu.Lock()
+	if h.state == HAStateStandby {
+		h.mu.Unlock()
+		return
+	}
+	h.recordLocked(HAStateStandby, reason)
+	// A fresh mirror is needed before the next takeover.
+	h.mirroredVersion = ""
+	h.mu.Unlock()
+
+	zap.L().Warn("[HA] demoted to standby", zap.String("reason", reason))
+	h.runHook(h.hooks.Deactivate)
+}
+
+func (h *HAManager) runHook(fn func()) {
+	if fn != nil {
+		fn()
+	}
+}
+
+func (h *HAManager) recordLocked(to, reason string) {
+	entry := HASwitchover{Time: time.Now(), From: h.state, To: to, Reason: reason}
+	h.state = to
+	h.history = append([]HASwitchover{entry}, h.history...)
+	if len(h.history) > haMaxHistory {
+		h.history = h.history[:haMaxHistory]
+	}
+}
+
+func (h *HAManager) setGuard(msg string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if msg != "" && msg != h.guard {
+		zap.L().Warn("[HA] split-brain guard", zap.String("detail", msg))
+	}
+	h.guard = msg
+}
+
+func (h *HAManager) recordPeerLocked(msg *haMessage) {
+	h.peer.NodeID = msg.NodeID
+	h.peer.Role = msg.Role
+	h.peer.State = msg.State
+	h.peer.Term = msg.Term
+	h.peer.LastSeen = time.Now()
+}
+
+// selfMessageLocked builds a message describing the local node; version is
+// advertised only while active.
+func (h *HAManager) selfMessageLocked(kind, version string) haMessage {
+	msg := haMessage{
+		Type:   kind,
+		NodeID: h.nodeID,
+		Role:   h.cfg.Role,
+		State:  h.state,
+		Term:   h.term,
+		Time:   time.Now().UnixMilli(),
+	}
+	if h.state == HAStateActive {
+		msg.ConfigVersion = version
+	}
+	return msg
+}
+
+// activeConfigVersion returns the version of the local snapshot while active.
+// It must be called without h.mu held.
+func (h *HAManager) activeConfigVersion() string {
+	h.mu.Lock()
+	active := h.state == HAStateActive
+	h.mu.Unlock()
+	if !active {
+		return ""
+	}
+	_, version, err := h.export()
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// export returns the config snapshot, cached for one heartbeat interval.
+// It must be called without h.mu held.
+func (h *HAManager) export() ([]byte, string, error) {
+	if h.hooks.ExportConfig == nil {
+		return nil, "", fmt.Errorf("config export not available")
+	}
+	h.mu.Lock()
+	interval := h.interval
+	h.mu.Unlock()
+
+	h.exportMu.Lock()
+	defer h.exportMu.Unlock()
+	if h.exportData != nil && time.Since(h.exportAt) < interval {
+		return h.exportData, h.exportVersion, nil
+	}
+	data, err := h.hooks.ExportConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	h.exportData = data
+	h.exportVersion = hex.EncodeToString(sum[:8])
+	h.exportAt = time.Now()
+	return h.exportData, h.exportVersion, nil
+}
+
+func (h *HAManager) cachedExportVersion() string {
+	h.exportMu.Lock()
+	defer h.exportMu.Unlock()
+	return h.exportVersion
+}
+
+// handle answers a request from the peer.
+func (h *HAManager) handle(req *haMessage) haMessage {
+	switch req.Type {
+	case haMsgHeartbeat:
+		version := h.activeConfigVersion()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.recordPeerLocked(req)
+		return h.selfMessageLocked(haMsgHeartbeat, version)
+	case haMsgConfig:
+		h.mu.Lock()
+		active, key := h.state == HAStateActive, h.cfg.SharedKey
+		h.mu.Unlock()
+		if key == "" {
+			return haMessage{Error: "config mirroring requires a shared key"}
+		}
+		if !active {
+			return haMessage{Error: "node is not active"}
+		}
+		data, version, err := h.export()
+		if err != nil {
+			return haMessage{Error: err.Error()}
+		}
+		sealed, err := haSeal(data, key)
+		if err != nil {
+			return haMessage{Error: err.Error()}
+		}
+		h.mu.Lock()
+		msg := h.selfMessageLocked(haMsgConfig, version)
+		h.mu.Unlock()
+		msg.Config = sealed
+		return msg
+	default:
+		return haMessage{Error: fmt.Sprintf("unknown message type %q", req.Type)}
+	}
+}
+
+// mirror pulls the active node's config when its version changed.
+func (h *HAManager) mirror(ctx context.Context, cfg model.HAConfig, addr string, beat *haMessage) {
+	h.mu.Lock()
+	if beat.ConfigVersion == "" || beat.ConfigVersion == h.mirroredVersion {
+		h.mu.Unlock()
+		return
+	}
+	if cfg.SharedKey == "" {
+		h.mirrorErr = "config mirroring requires a shared key"
+		h.mu.Unlock()
+		return
+	}
+	req := h.selfMessageLocked(haMsgConfig, "")
+	h.mu.Unlock()
+
+	timeout := h.timeout
+	if timeout < 10*time.Second {
+		timeout = 10 * time.Second
+	}
+	resp, err := haExchange(ctx, cfg.HeartbeatType, addr, cfg.SharedKey, req, timeout)
+	var data []byte
+	if err == nil {
+		data, err = haOpen(resp.Config, cfg.SharedKey)
+	}
+	if err == nil && h.hooks.ImportConfig != nil {
+		err = h.hooks.ImportConfig(data)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.mirrorErr = err.Error()
+		zap.L().Warn("[HA] config mirror failed", zap.Error(err))
+		return
+	}
+	h.mirrorErr = ""
+	h.mirroredVersion = resp.ConfigVersion
+	h.mirroredAt = time.Now()
+	zap.L().Info("[HA] config mirrored from active node", zap.String("version", resp.ConfigVersion))
+}
+
+// State returns the local node state ("active" or "standby").
+func (h *HAManager) State() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+// IsStandby reports whether the node is an HA standby.
+func (h *HAManager) IsStandby() bool {
+	return h.State() == HAStateStandby
+}
+
+// WriteGuard rejects device writes while the node is standby.
+func (h *HAManager) WriteGuard(channelID, deviceID, target string) error {
+	if h.IsStandby() {
+		return fmt.Errorf("write rejected: gateway is HA standby")
+	}
+	return nil
+}
+
+// Status returns a snapshot for the status API.
+func (h *HAManager) Status() HAStatus {
+	exportVersion := h.cachedExportVersion()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cfg := normalizeHAConfig(h.cfg)
+	st := HAStatus{
+		Enabled:       h.cfg.Enabled,
+		NodeID:        h.nodeID,
+		Role:          cfg.Role,
+		State:         h.state,
+		Term:          h.term,
+		HeartbeatType: cfg.HeartbeatType,
+		ListenError:   h.listenErr,
+		Peer:          h.peer,
+		Guard:         h.guard,
+		History:       append([]HASwitchover{}, h.history...),
+		ConfigVersion: h.mirroredVersion,
+		MirroredAt:    h.mirroredAt,
+		MirrorError:   h.mirrorErr,
+	}
+	if h.state == HAStateActive {
+		st.ConfigVersion = exportVersion
+	}
+	if len(h.history) > 0 {
+		last := h.history[0]
+		st.LastSwitchover = &last
+	}
+	return st
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// haTestNode wraps an HAManager whose configured seconds last 50ms.
+type haTestNode struct {
+	*HAManager
+	mu          sync.Mutex
+	activated   int
+	deactivated int
+	imported    []string
+	export      string
+}
+
+func newHATestNode(cfg model.HAConfig) *haTestNode {
+	n := &haTestNode{export: "{}"}
+	n.HAManager = NewHAManager(cfg, HAHooks{
+		Activate: func() {
+			n.mu.Lock()
+			n.activated++
+			n.mu.Unlock()
+		},
+		Deactivate: func() {
+			n.mu.Lock()
+			n.deactivated++
+			n.mu.Unlock()
+		},
+		ExportConfig: func() ([]byte, error) {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			return []byte(n.export), nil
+		},
+		ImportConfig: func(data []byte) error {
+			n.mu.Lock()
+			n.imported = append(n.imported, string(data))
+			n.mu.Unlock()
+			return nil
+		},
+	})
+	n.unit = 50 * time.Millisecond
+	return n
+}
+
+func (n *haTestNode) counts() (int, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.activated, n.deactivated
+}
+
+func freeHAPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func haTestPair(t *testing.T, heartbeat string) (*haTestNode, *haTestNode) {
+	t.Helper()
+	portA, portB := freeHAPort(t), freeHAPort(t)
+	base := model.HAConfig{Enabled: true, HeartbeatType: heartbeat, Interval: 1, Timeout: 2, Retries: 2, SharedKey: "secret"}
+
+	cfgA := base
+	cfgA.Role = "master"
+	cfgA.Port = portA
+	cfgA.PeerAddress = "127.0.0.1:" + strconv.Itoa(portB)
+
+	cfgB := base
+	cfgB.Role = "backup"
+	cfgB.Port = portB
+	cfgB.PeerAddress = "127.0.0.1:" + strconv.Itoa(portA)
+
+	return newHATestNode(cfgA), newHATestNode(cfgB)
+}
+
+func waitHA(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestHAManager_DisabledActivatesImmediately(t *testing.T) {
+	n := newHATestNode(model.HAConfig{})
+	n.Start()
+	defer n.Stop()
+
+	if n.State() != HAStateActive {
+		t.Fatalf("state = %q, want active", n.State())
+	}
+	if act, _ := n.counts(); act != 1 {
+		t.Fatalf("Activate called %d times, want 1", act)
+	}
+	if err := n.WriteGuard("ch", "dev", "p"); err != nil {
+		t.Fatalf("write guard on active node: %v", err)
+	}
+}
+
+func TestHAManager_FailoverAndMirror(t *testing.T) {
+	for _, hb := range []string{"TCP", "UDP", "HTTP"} {
+		t.Run(hb, func(t *testing.T) {
+			a, b := haTestPair(t, hb)
+			a.export = `{"channels":[]}`
+			a.Start()
+			b.Start()
+			defer b.Stop()
+
+			waitHA(t, "master active", func() bool { return a.State() == HAStateActive })
+			waitHA(t, "config mirrored", func() bool {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				return len(b.imported) > 0
+			})
+			if b.State() != HAStateStandby {
+				t.Fatalf("backup state = %q, want standby", b.State())
+			}
+			b.mu.Lock()
+			imported := b.imported[0]
+			b.mu.Unlock()
+			if imported != a.export {
+				t.Fatalf("imported config = %q, want %q", imported, a.export)
+			}
+			if err := b.WriteGuard("ch", "dev", "p"); err == nil {
+				t.Fatal("expected write guard to reject writes on standby")
+			}
+			if got := b.Status().Peer.NodeID; got != a.nodeID {
+				t.Fatalf("peer node id = %q, want %q", got, a.nodeID)
+			}
+
+			a.Stop()
+			waitHA(t, "backup promoted", func() bool { return b.State() == HAStateActive })
+
+			st := b.Status()
+			if st.LastSwitchover == nil || !strings.Contains(st.LastSwitchover.Reason, "heartbeat lost") {
+				t.Fatalf("unexpected last switchover: %+v", st.LastSwitchover)
+			}
+			if st.Term <= a.Status().Term {
+				t.Fatalf("backup term %d should exceed master term %d", st.Term, a.Status().Term)
+			}
+			if act, _ := b.counts(); act != 1 {
+				t.Fatalf("backup Activate called %d times, want 1", act)
+			}
+		})
+	}
+}
+
+func TestHAManager_RecoveredMasterStaysStandby(t *testing.T) {
+	a, b := haTestPair(t, "TCP")
+	// The backup already took over while the master was down.
+	b.state, b.term = HAStateActive, 3
+	b.Start()
+	defer b.Stop()
+	a.Start()
+	defer a.Stop()
+
+	waitHA(t, "master mirrors backup config", func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return len(a.imported) > 0
+	})
+	if a.State() != HAStateStandby || b.State() != HAStateActive {
+		t.Fatalf("states master=%q backup=%q, want standby/active", a.State(), b.State())
+	}
+}
+
+func TestHAManager_SplitBrainLowerTermYields(t *testing.T) {
+	a, b := haTestPair(t, "UDP")
+	a.state, a.term = HAStateActive, 1
+	b.state, b.term = HAStateActive, 2
+	a.Start()
+	defer a.Stop()
+	b.Start()
+	defer b.Stop()
+
+	waitHA(t, "master demoted", func() bool { return a.State() == HAStateStandby })
+	if _, deact := a.counts(); deact != 1 {
+		t.Fatalf("Deactivate called %d times, want 1", deact)
+	}
+	if !strings.Contains(a.Status().LastSwitchover.Reason, "split brain") {
+		t.Fatalf("unexpected reason: %s", a.Status().LastSwitchover.Reason)
+	}
+	if b.State() != HAStateActive {
+		t.Fatalf("backup state = %q, want active", b.State())
+	}
+}
+
+func TestHAManager_WitnessUnreachableBlocksPromotion(t *testing.T) {
+	witnessAddr := "127.0.0.1:" + strconv.Itoa(freeHAPort(t))
+	n := newHATestNode(model.HAConfig{
+		Enabled:       true,
+		Role:          "backup",
+		HeartbeatType: "TCP",
+		Interval:      1,
+		Timeout:       2,
+		Retries:       1,
+		Port:          freeHAPort(t),
+		PeerAddress:   "127.0.0.1:" + strconv.Itoa(freeHAPort(t)),
+		Witness:       witnessAddr, // nothing listens yet
+	})
+	n.Start()
+	defer n.Stop()
+
+	waitHA(t, "guard reason", func() bool { return n.Status().Guard != "" })
+	if n.State() != HAStateStandby {
+		t.Fatalf("state = %q, want standby", n.State())
+	}
+	if act, _ := n.counts(); act != 0 {
+		t.Fatalf("Activate called %d times, want 0", act)
+	}
+
+	// Once the witness answers, the isolated-peer case is confirmed and the
+	// standby takes over.
+	witness, err := net.Listen("tcp", witnessAddr)
+	if err != nil {
+		t.Fatalf("witness listen: %v", err)
+	}
+	defer witness.Close()
+	waitHA(t, "promotion", func() bool { return n.State() == HAStateActive })
+}
+
+func TestHAExchange_RejectsWrongKey(t *testing.T) {
+	port := freeHAPort(t)
+	l, err := startHAListener("UDP", port, "right", func(req *haMessage) haMessage {
+		return haMessage{Type: req.Type, State: HAStateActive}
+	})
+	if err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	defer l.close()
+
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+	req := haMessage{Type: haMsgHeartbeat}
+	if _, err := haExchange(context.Background(), "UDP", addr, "wrong", req, time.Second); err == nil {
+		t.Fatal("expected authentication failure with a wrong key")
+	}
+	reply, err := haExchange(context.Background(), "UDP", addr, "right", req, time.Second)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if reply.State != HAStateActive {
+		t.Fatalf("reply state = %q", reply.State)
+	}
+}
+
+func TestHAManager_ConfigMirrorRequiresSharedKey(t *testing.T) {
+	if err := ValidateHAConfig(model.HAConfig{Enabled: true}); err == nil {
+		t.Fatal("expected HA without shared_key to be rejected")
+	}
+	if err := ValidateHAConfig(model.HAConfig{Enabled: true, SharedKey: "secret"}); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	n := newHATestNode(model.HAConfig{Enabled: true})
+	n.export = `{"northbound":{"password":"p"}}`
+	n.state = HAStateActive
+	if reply := n.handle(&haMessage{Type: haMsgConfig}); reply.Error == "" || len(reply.Config) > 0 {
+		t.Fatalf("config served without a shared key: %+v", reply)
+	}
+
+	n.cfg.SharedKey = "secret"
+	reply := n.handle(&haMessage{Type: haMsgConfig})
+	if reply.Error != "" || strings.Contains(string(reply.Config), "password") {
+		t.Fatalf("config snapshot must be sealed: %+v", reply)
+	}
+	if _, err := haOpen(reply.Config, "wrong"); err == nil {
+		t.Fatal("expected decryption with a wrong key to fail")
+	}
+	data, err := haOpen(reply.Config, "secret")
+	if err != nil || string(data) != n.export {
+		t.Fatalf("open = %q, %v", data, err)
+	}
+}
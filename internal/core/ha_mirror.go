@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/anviod/edgex/internal/config"
+	"github.com/anviod/edgex/internal/model"
+)
+
+// HAConfigSnapshot is the part of the configuration mirrored from the active
+// node to the standby. System, user and server settings stay node-local.
+type HAConfigSnapshot struct {
+	Channels       []model.Channel                   `json:"channels"`
+	Northbound     model.NorthboundConfig            `json:"northbound"`
+	EdgeRules      []model.EdgeRule                  `json:"edge_rules"`
+	VirtualShadows []model.VirtualShadowDeviceConfig `json:"virtual_shadows"`
+	RuleTemplates  []model.EdgeRuleTemplate          `json:"rule_templates"`
+	Interlocks     []model.WriteInterlock            `json:"interlocks"`
+	Notifications  *model.NotificationConfig         `json:"notifications,omitempty"`
+	Recipes        []model.Recipe                    `json:"recipes"`
+	RuleHistories  []model.EdgeRuleHistory           `json:"rule_histories"` // Versions and shadow-mode state
+}
+
+// ExportHAConfig serializes the mirrored configuration of cfgManager.
+func ExportHAConfig(cfgManager *config.ConfigManager) ([]byte, error) {
+	if cfgManager == nil {
+		return nil, fmt.Errorf("config manager not attached")
+	}
+	current := cfgManager.GetConfig()
+	shadows, err := cfgManager.LoadVirtualShadows()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := cfgManager.LoadEdgeRuleTemplates()
+	if err != nil {
+		return nil, err
+	}
+	interlocks, err := cfgManager.LoadInterlocks()
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := cfgManager.LoadNotificationConfig()
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := cfgManager.LoadRecipes()
+	if err != nil {
+		return nil, err
+	}
+	histories, err := cfgManager.LoadEdgeRuleHistories()
+	if err != nil {
+		return nil, err
+	}
+	if histories == nil {
+		histories = []model.EdgeRuleHistory{}
+	}
+	return json.Marshal(HAConfigSnapshot{
+		Channels:       current.Channels,
+		Northbound:     current.Northbound,
+		EdgeRules:      current.EdgeRules,
+		VirtualShadows: shadows,
+		RuleTemplates:  templates,
+		Interlocks:     interlocks,
+		Notifications:  &notifications,
+		Recipes:        recipes,
+		RuleHistories:  histories,
+	})
+}
+
+// ImportHAConfig persists a snapshot received from the active node.
+func ImportHAConfig(cfgManager *config.ConfigManager, data []byte) error {
+	if cfgManager == nil {
+		return fmt.Errorf("config manager not attached")
+	}
+	var snap HAConfigSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("invalid HA config snapshot: %w", err)
+	}
+	current := cfgManager.GetConfig()
+	current.Channels = snap.Channels
+	current.Northbound = snap.Northbound
+	current.EdgeRules = snap.EdgeRules
+	if err := cfgManager.SaveConfig(current); err != nil {
+		return err
+	}
+	if err := cfgManager.SaveVirtualShadows(snap.VirtualShadows); err != nil {
+		return err
+	}
+	// 旧版本主机的快照不含模板、联锁、通知、配方与规则历史，保留本机配置
+	if snap.RuleTemplates != nil {
+		if err := cfgManager.SaveEdgeRuleTemplates(snap.RuleTemplates); err != nil {
+			return err
+		}
+	}
+	if snap.Interlocks != nil {
+		if err := cfgManager.SaveInterlocks(snap.Interlocks); err != nil {
+			return err
+		}
+	}
+	if snap.Notifications != nil {
+		if err := cfgManager.SaveNotificationConfig(*snap.Notifications); err != nil {
+			return err
+		}
+	}
+	if snap.Recipes != nil {
+		if err := cfgManager.SaveRecipes(snap.Recipes); err != nil {
+			return err
+		}
+	}
+	if snap.RuleHistories != nil {
+		return cfgManager.ReplaceEdgeRuleHistories(snap.RuleHistories)
+	}
+	return nil
+}
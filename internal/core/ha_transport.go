@@ -0,0 +1,380 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HA heartbeat wire protocol. Every exchange is one JSON request answered by
+// one JSON reply:
+//   - TCP:  newline-delimited JSON on a short-lived connection
+//   - UDP:  one datagram each way (heartbeats only; config pulls use TCP on the same port)
+//   - HTTP: POST /ha/v1 with a JSON body
+//
+// With the shared key every message carries an HMAC-SHA256 over its JSON form. Config snapshots
+// (which hold connector credentials and private keys) are additionally sealed
+// with AES-256-GCM under a key derived from the shared key, and are never
+// served when no shared key is configured.
+
+const (
+	defaultHAPort = 9460
+
+	haMsgHeartbeat = "heartbeat"
+	haMsgConfig    = "config"
+
+	haMaxMessageSize = 64 << 20 // config snapshots of large gateways
+	haMaxDatagram    = 8 << 10
+)
+
+type haMessage struct {
+	Type          string `json:"type"`
+	NodeID        string `json:"node_id"`
+	Role          string `json:"role"`
+	State         string `json:"state"`
+	Term          uint64 `json:"term"`
+	ConfigVersion string `json:"config_version,omitempty"`
+	Time          int64  `json:"time"`             // unix ms
+	Config        []byte `json:"config,omitempty"` // sealed snapshot, see haSeal
+	Error         string `json:"error,omitempty"`
+	MAC           string `json:"mac,omitempty"`
+}
+
+func haSign(msg *haMessage, key string) error {
+	msg.MAC = ""
+	if key == "" {
+		return nil
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	msg.MAC = hex.EncodeToString(mac.Sum(nil))
+	return nil
+}
+
+func haVerify(msg *haMessage, key string) error {
+	if key == "" {
+		return nil
+	}
+	got := msg.MAC
+	check := *msg
+	if err := haSign(&check, key); err != nil {
+		return err
+	}
+	if got == "" || !hmac.Equal([]byte(got), []byte(check.MAC)) {
+		return fmt.Errorf("ha: message authentication failed")
+	}
+	return nil
+}
+
+// haCipher derives the AES-256-GCM cipher for config snapshots from the shared key.
+func haCipher(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, fmt.Errorf("ha: shared key is required for config mirroring")
+	}
+	sum := sha256.Sum256([]byte("edgex-ha-config\x00" + key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// haSeal encrypts a config snapshot as nonce || ciphertext.
+func haSeal(data []byte, key string) ([]byte, error) {
+	aead, err := haCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// haOpen decrypts a snapshot sealed by haSeal.
+func haOpen(sealed []byte, key string) ([]byte, error) {
+	aead, err := haCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ha: sealed config is truncated")
+	}
+	nonce, body := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ha: config snapshot decryption failed")
+	}
+	return data, nil
+}
+
+func haEncode(msg haMessage, key string) ([]byte, error) {
+	if err := haSign(&msg, key); err != nil {
+		return nil, err
+	}
+	return json.Marshal(msg)
+}
+
+func haDecode(data []byte, key string) (*haMessage, error) {
+	var msg haMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("ha: invalid message: %w", err)
+	}
+	if err := haVerify(&msg, key); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// haHandler answers one request from the peer.
+type haHandler func(req *haMessage) haMessage
+
+// haListener serves the local heartbeat endpoint for one heartbeat type.
+type haListener struct {
+	key     string
+	handler haHandler
+
+	tcp  net.Listener
+	udp  net.PacketConn
+	http *http.Server
+	wg   sync.WaitGroup
+}
+
+func startHAListener(heartbeatType string, port int, key string, handler haHandler) (*haListener, error) {
+	l := &haListener{key: key, handler: handler}
+	addr := ":" + strconv.Itoa(port)
+
+	switch heartbeatType {
+	case "HTTP":
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("ha: listen %s: %w", addr, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/ha/v1", l.serveHTTP)
+		l.http = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			_ = l.http.Serve(ln)
+		}()
+		return l, nil
+	case "UDP":
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("ha: listen udp %s: %w", addr, err)
+		}
+		l.udp = pc
+		l.wg.Add(1)
+		go l.serveUDP()
+	}
+
+	// TCP carries heartbeats for TCP and config pulls for TCP and UDP.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		l.close()
+		return nil, fmt.Errorf("ha: listen %s: %w", addr, err)
+	}
+	l.tcp = ln
+	l.wg.Add(1)
+	go l.serveTCP()
+	return l, nil
+}
+
+func (l *haListener) close() {
+	if l.tcp != nil {
+		_ = l.tcp.Close()
+	}
+	if l.udp != nil {
+		_ = l.udp.Close()
+	}
+	if l.http != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_ = l.http.Shutdown(ctx)
+		cancel()
+	}
+	l.wg.Wait()
+}
+
+func (l *haListener) reply(data []byte) []byte {
+	req, err := haDecode(data, l.key)
+	var resp haMessage
+	if err != nil {
+		resp = haMessage{Error: err.Error()}
+	} else {
+		resp = l.handler(req)
+	}
+	out, err := haEncode(resp, l.key)
+	if err != nil {
+		out, _ = json.Marshal(haMessage{Error: err.Error()})
+	}
+	return out
+}
+
+func (l *haListener) serveTCP() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+			line, err := bufio.NewReaderSize(conn, 4096).ReadBytes('\n')
+			if err != nil && len(line) == 0 {
+				return
+			}
+			_, _ = conn.Write(append(l.reply(line), '\n'))
+		}(conn)
+	}
+}
+
+func (l *haListener) serveUDP() {
+	defer l.wg.Done()
+	buf := make([]byte, haMaxDatagram)
+	for {
+		n, addr, err := l.udp.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		_, _ = l.udp.WriteTo(l.reply(buf[:n]), addr)
+	}
+}
+
+func (l *haListener) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(l.reply(body))
+}
+
+// haPeerAddress returns host:port of the peer, defaulting the port.
+func haPeerAddress(addr string, defaultPort int) string {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), strconv.Itoa(defaultPort))
+}
+
+// haExchange sends req to the peer and returns its reply.
+func haExchange(ctx context.Context, heartbeatType, addr, key string, req haMessage, timeout time.Duration) (*haMessage, error) {
+	payload, err := haEncode(req, key)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var raw []byte
+	switch {
+	case heartbeatType == "HTTP":
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+addr+"/ha/v1", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ha: peer answered HTTP %d", resp.StatusCode)
+		}
+		if raw, err = io.ReadAll(io.LimitReader(resp.Body, haMaxMessageSize)); err != nil {
+			return nil, err
+		}
+	case heartbeatType == "UDP" && req.Type == haMsgHeartbeat:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		deadline, _ := ctx.Deadline()
+		_ = conn.SetDeadline(deadline)
+		if _, err := conn.Write(payload); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, haMaxDatagram)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		raw = buf[:n]
+	default:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		deadline, _ := ctx.Deadline()
+		_ = conn.SetDeadline(deadline)
+		if _, err := conn.Write(append(payload, '\n')); err != nil {
+			return nil, err
+		}
+		if raw, err = io.ReadAll(io.LimitReader(conn, haMaxMessageSize)); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := haDecode(bytes.TrimSpace(raw), key)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, fmt.Errorf("ha: peer: %s", reply.Error)
+	}
+	return reply, nil
+}
+
+// haWitnessReachable reports whether the witness accepts a TCP connection.
+func haWitnessReachable(ctx context.Context, witness string, timeout time.Duration) bool {
+	addr := haPeerAddress(witness, 80)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
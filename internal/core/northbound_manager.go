@@ -9,12 +9,15 @@ import (
 
 	"github.com/anviod/edgex/internal/model"
 	"github.com/anviod/edgex/internal/northbound/bacnet"
+	"github.com/anviod/edgex/internal/northbound/connector"
 	"github.com/anviod/edgex/internal/northbound/edgos_mqtt"
 	"github.com/anviod/edgex/internal/northbound/edgos_nats"
 	"github.com/anviod/edgex/internal/northbound/http"
 	"github.com/anviod/edgex/internal/northbound/mqtt"
+	"github.com/anviod/edgex/internal/northbound/offline"
 	"github.com/anviod/edgex/internal/northbound/opcua"
 	"github.com/anviod/edgex/internal/northbound/sparkplugb"
+	"github.com/anviod/edgex/internal/pkg/bacnetsc"
 	"github.com/anviod/edgex/internal/storage"
 )
 
@@ -23,6 +26,16 @@ type NorthboundStatus struct {
 	Name   string `json:"name"`
 	Type   string `json:"type"`
 	Status string `json:"status"`
+	// 离线缓存积压（backlog_count / backlog_bytes / backlog_age_ms 等），未启用缓存时为空
+	Backlog map[string]int64 `json:"backlog,omitempty"`
+}
+
+// offlineBacklog 读取北向连接离线队列的积压指标，与客户端是否运行无关
+func (nm *NorthboundManager) offlineBacklog(id string, cache model.DataCacheConfig) map[string]int64 {
+	if !cache.Enable || nm.storage == nil {
+		return nil
+	}
+	return offline.BacklogMetrics(nm.storage.OfflineQueue(id).Stats())
 }
 
 // connectorStartWarning logs a connector start failure after config was persisted
@@ -44,6 +57,7 @@ type NorthboundManager struct {
 	edgeOSMQTTClients map[string]*edgos_mqtt.Client
 	edgeOSNATSClients map[string]*edgos_nats.Client
 	bacnetServers     map[string]*bacnet.Server
+	connectors        map[string]connector.Connector // Registry-based connectors, key: config ID
 	pipeline          *DataPipeline
 	sb                model.SouthboundManager
 	cm                *ChannelManager // Reference to ChannelManager for device lookups
@@ -52,6 +66,8 @@ type NorthboundManager struct {
 	ctx               context.Context
 	cancel            context.CancelFunc
 	saveFunc          func(model.NorthboundConfig) error
+	running           bool
+	subscribed        bool
 	mu                sync.RWMutex
 }
 
@@ -66,6 +82,7 @@ func NewNorthboundManager(cfg model.NorthboundConfig, pipeline *DataPipeline, sb
 		edgeOSMQTTClients: make(map[string]*edgos_mqtt.Client),
 		edgeOSNATSClients: make(map[string]*edgos_nats.Client),
 		bacnetServers:     make(map[string]*bacnet.Server),
+		connectors:        make(map[string]connector.Connector),
 		pipeline:          pipeline,
 		sb:                sb,
 		cm:                nil, // Set via SetChannelManager
@@ -91,10 +108,11 @@ func (nm *NorthboundManager) GetNorthboundStats() []NorthboundStatus {
 			status = "Running"
 		}
 		stats = append(stats, NorthboundStatus{
-			ID:     cfg.ID,
-			Name:   cfg.Name,
-			Type:   "MQTT",
-			Status: status,
+			ID:      cfg.ID,
+			Name:    cfg.Name,
+			Type:    "MQTT",
+			Status:  status,
+			Backlog: nm.offlineBacklog(cfg.ID, cfg.Cache),
 		})
 	}
 
@@ -178,6 +196,32 @@ func (nm *NorthboundManager) GetNorthboundStats() []NorthboundStatus {
 		})
 	}
 
+	// 插件式连接器
+	for _, cfg := range nm.config.Connectors {
+		status := "Stopped"
+		if !cfg.Enable {
+			status = "Disabled"
+		} else if _, ok := nm.connectors[cfg.ID]; ok {
+			status = "Running"
+		}
+		typ := cfg.Type
+		if d, ok := connector.Lookup(cfg.Type); ok {
+			typ = d.DisplayName
+		}
+		// 带离线缓存的连接器（如 Kafka、时序数据库）在 settings.cache 中配置缓存
+		var settings struct {
+			Cache model.DataCacheConfig `json:"cache"`
+		}
+		_ = cfg.DecodeSettings(&settings)
+		stats = append(stats, NorthboundStatus{
+			ID:      cfg.ID,
+			Name:    cfg.Name,
+			Type:    typ,
+			Status:  status,
+			Backlog: nm.offlineBacklog(cfg.ID, settings.Cache),
+		})
+	}
+
 	return stats
 }
 
@@ -197,6 +241,11 @@ func (nm *NorthboundManager) Start() {
 	nm.mu.Lock()
 	defer nm.mu.Unlock()
 
+	if nm.running {
+		return
+	}
+	nm.running = true
+
 	// Start MQTT Clients
 	for _, cfg := range nm.config.MQTT {
 		if cfg.Enable {
@@ -213,7 +262,7 @@ func (nm *NorthboundManager) Start() {
 	// Start HTTP Clients
 	for _, cfg := range nm.config.HTTP {
 		if cfg.Enable {
-			client := http.NewClient(cfg, nm.storage)
+			client := http.NewClient(cfg, nm.sb, nm.storage)
 			client.Start()
 			nm.httpClients[cfg.ID] = client
 			log.Printf("Northbound HTTP client [%s] started", cfg.Name)
@@ -285,8 +334,20 @@ func (nm *NorthboundManager) Start() {
 		}
 	}
 
-	// Subscribe to pipeline
-	nm.pipeline.AddHandler(nm.handleValue)
+	// Start registry-based connectors
+	for _, cfg := range nm.config.Connectors {
+		if cfg.Enable {
+			if err := nm.startConnectorLocked(cfg); err != nil {
+				log.Printf("Failed to start northbound connector %s [%s]: %v", cfg.Type, cfg.Name, err)
+			}
+		}
+	}
+
+	// Subscribe to pipeline once; Suspend/Start cycles reuse the handler.
+	if !nm.subscribed {
+		nm.pipeline.AddHandler(nm.handleValue)
+		nm.subscribed = true
+	}
 }
 
 func (nm *NorthboundManager) handleValue(v model.Value) {
@@ -314,6 +375,7 @@ func (nm *NorthboundManager) handleValue(v model.Value) {
 	for _, server := range nm.bacnetServers {
 		server.Update(v)
 	}
+	nm.publishToConnectors(v)
 }
 
 // OnDeviceStatusChange handles device status changes and notifies northbound clients.
@@ -354,6 +416,7 @@ func (nm *NorthboundManager) OnDeviceStatusChange(deviceID string, status int) {
 		}
 	}
 
+	nm.publishDeviceStatusToConnectors(deviceID, status)
 	nm.publishDeviceLifecycleNotification(deviceID, status)
 }
 
@@ -568,7 +631,29 @@ func (nm *NorthboundManager) Stop() {
 	nm.cancel()
 	nm.mu.Lock()
 	defer nm.mu.Unlock()
+	nm.stopConnectorsLocked()
+}
 
+// Suspend stops every connector but keeps the manager reusable; Start brings
+// them back. Used to silence northbound output on an HA standby node.
+func (nm *NorthboundManager) Suspend() {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	nm.stopConnectorsLocked()
+}
+
+// LoadConfig replaces the northbound configuration without starting any
+// connector; the next Start uses it. Has no effect while running.
+func (nm *NorthboundManager) LoadConfig(cfg model.NorthboundConfig) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	if nm.running {
+		return
+	}
+	nm.config = cfg
+}
+
+func (nm *NorthboundManager) stopConnectorsLocked() {
 	for _, client := range nm.mqttClients {
 		client.Stop()
 	}
@@ -590,6 +675,18 @@ func (nm *NorthboundManager) Stop() {
 	for _, server := range nm.bacnetServers {
 		server.Stop()
 	}
+	for _, conn := range nm.connectors {
+		conn.Stop()
+	}
+	nm.mqttClients = make(map[string]*mqtt.Client)
+	nm.httpClients = make(map[string]*http.Client)
+	nm.opcuaServers = make(map[string]*opcua.Server)
+	nm.sparkplugClients = make(map[string]*sparkplugb.Client)
+	nm.edgeOSMQTTClients = make(map[string]*edgos_mqtt.Client)
+	nm.edgeOSNATSClients = make(map[string]*edgos_nats.Client)
+	nm.bacnetServers = make(map[string]*bacnet.Server)
+	nm.connectors = make(map[string]connector.Connector)
+	nm.running = false
 }
 
 func (nm *NorthboundManager) GetConfig() model.NorthboundConfig {
@@ -610,6 +707,11 @@ func (nm *NorthboundManager) GetConfig() model.NorthboundConfig {
 	for id, client := range nm.edgeOSNATSClients {
 		status[id] = client.GetStatus()
 	}
+	for id, conn := range nm.connectors {
+		if r, ok := conn.(connector.StatusReporter); ok {
+			status[id] = r.Status()
+		}
+	}
 	// OPC UA status usually implies running if in the map
 
 	cfg := nm.config
@@ -859,15 +961,32 @@ func (nm *NorthboundManager) UpsertBACnetServerConfig(cfg model.BACnetServerConf
 		return model.BACnetServerConfig{}, "", err
 	}
 
-	found := false
+	idx := -1
 	for i, c := range nm.config.BACnetServer {
 		if c.ID == cfg.ID {
-			nm.config.BACnetServer[i] = cfg
-			found = true
+			cfg = model.MergeBACnetServerConfig(c, cfg)
+			idx = i
 			break
 		}
 	}
-	if !found {
+
+	if model.IsBACnetSC(cfg.Transport) {
+		if strings.TrimSpace(cfg.SC.PrimaryHubURI) == "" {
+			return model.BACnetServerConfig{}, "", fmt.Errorf("BACnet/SC primary hub URI is required")
+		}
+		if strings.TrimSpace(cfg.SC.CertPEM) != "" || strings.TrimSpace(cfg.SC.KeyPEM) != "" {
+			if strings.TrimSpace(cfg.SC.CertPEM) == "" || strings.TrimSpace(cfg.SC.KeyPEM) == "" {
+				return model.BACnetServerConfig{}, "", fmt.Errorf("node certificate and private key must both be configured")
+			}
+			if err := bacnetsc.ValidateNodePEMPair(cfg.SC.CertPEM, cfg.SC.KeyPEM); err != nil {
+				return model.BACnetServerConfig{}, "", err
+			}
+		}
+	}
+
+	if idx >= 0 {
+		nm.config.BACnetServer[idx] = cfg
+	} else {
 		nm.config.BACnetServer = append(nm.config.BACnetServer, cfg)
 	}
 
@@ -876,13 +995,15 @@ func (nm *NorthboundManager) UpsertBACnetServerConfig(cfg model.BACnetServerConf
 	}
 
 	server, exists := nm.bacnetServers[cfg.ID]
+	clientCfg := cfg
+	clientCfg.SC = model.SanitizeBACnetSCForClient(cfg.SC)
 
 	if !cfg.Enable {
 		if exists {
 			server.Stop()
 			delete(nm.bacnetServers, cfg.ID)
 		}
-		return cfg, "", nil
+		return clientCfg, "", nil
 	}
 
 	var startErr error
@@ -896,7 +1017,7 @@ func (nm *NorthboundManager) UpsertBACnetServerConfig(cfg model.BACnetServerConf
 		startErr = server.UpdateConfig(cfg)
 	}
 
-	return cfg, connectorStartWarning("BACnet Server", cfg.Name, startErr), nil
+	return clientCfg, connectorStartWarning("BACnet Server", cfg.Name, startErr), nil
 }
 
 // DeleteBACnetServerConfig 删除 BACnet Server 配置
@@ -1052,6 +1173,9 @@ func (nm *NorthboundManager) UpdateConfig(newConfig model.NorthboundConfig) {
 
 	// 处理 BACnet Server 配置变更
 	nm.updateBACnetServers(oldConfig.BACnetServer, newConfig.BACnetServer)
+
+	// 处理插件式连接器配置变更
+	nm.updateConnectors(oldConfig.Connectors, newConfig.Connectors)
 }
 
 // updateMQTTClients 更新 MQTT 客户端
@@ -1130,7 +1254,7 @@ func (nm *NorthboundManager) updateHTTPClients(oldConfigs, newConfigs []model.HT
 				client.UpdateConfig(newCfg)
 			} else {
 				// 创建新客户端
-				client := http.NewClient(newCfg, nm.storage)
+				client := http.NewClient(newCfg, nm.sb, nm.storage)
 				client.Start()
 				nm.httpClients[newCfg.ID] = client
 				log.Printf("Northbound HTTP client [%s] started", newCfg.Name)
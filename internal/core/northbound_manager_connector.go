@@ -0,0 +1,195 @@
+package core
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+)
+
+// 插件式北向连接器：通过 connector.Register 注册的类型统一在此管理，
+// 新增连接器无需修改 NorthboundManager。
+
+func (nm *NorthboundManager) connectorDeps() connector.Deps {
+	return connector.Deps{Southbound: nm.sb, Storage: nm.storage}
+}
+
+// startConnectorLocked 创建并启动连接器，失败时返回错误且不登记。调用方需持有 nm.mu
+func (nm *NorthboundManager) startConnectorLocked(cfg model.ConnectorConfig) error {
+	conn, err := connector.New(cfg, nm.connectorDeps())
+	if err != nil {
+		return err
+	}
+	if err := conn.Start(); err != nil {
+		return err
+	}
+	nm.connectors[cfg.ID] = conn
+	log.Printf("Northbound connector %s [%s] started", cfg.Type, cfg.Name)
+	return nil
+}
+
+// updateConnectors 按新旧配置差异停止、更新或启动连接器
+func (nm *NorthboundManager) updateConnectors(oldConfigs, newConfigs []model.ConnectorConfig) {
+	next := make(map[string]model.ConnectorConfig, len(newConfigs))
+	for _, cfg := range newConfigs {
+		next[cfg.ID] = cfg
+	}
+	for _, oldCfg := range oldConfigs {
+		conn, exists := nm.connectors[oldCfg.ID]
+		if !exists {
+			continue
+		}
+		// 删除、禁用或类型变化时停止
+		if newCfg, ok := next[oldCfg.ID]; !ok || !newCfg.Enable || newCfg.Type != oldCfg.Type {
+			conn.Stop()
+			delete(nm.connectors, oldCfg.ID)
+		}
+	}
+
+	for _, newCfg := range newConfigs {
+		if !newCfg.Enable {
+			continue
+		}
+		if conn, exists := nm.connectors[newCfg.ID]; exists {
+			if err := conn.UpdateConfig(newCfg); err != nil {
+				log.Printf("Failed to update northbound connector %s [%s]: %v", newCfg.Type, newCfg.Name, err)
+			}
+			continue
+		}
+		if err := nm.startConnectorLocked(newCfg); err != nil {
+			log.Printf("Failed to start northbound connector %s [%s]: %v", newCfg.Type, newCfg.Name, err)
+		}
+	}
+}
+
+// publishToConnectors 将数据分发给已选择该设备的连接器。调用方需持有 nm.mu 读锁
+func (nm *NorthboundManager) publishToConnectors(v model.Value) {
+	for _, cfg := range nm.config.Connectors {
+		conn, ok := nm.connectors[cfg.ID]
+		if !ok {
+			continue
+		}
+		if devCfg, ok := model.LookupNorthboundPublishConfig(v.DeviceID, cfg.Devices, cfg.VirtualDevices); ok && devCfg.Enable {
+			conn.Publish(v)
+		}
+	}
+}
+
+// publishDeviceStatusToConnectors 通知实现了 DeviceStatusPublisher 的连接器。调用方需持有 nm.mu 读锁
+func (nm *NorthboundManager) publishDeviceStatusToConnectors(deviceID string, status int) {
+	for _, cfg := range nm.config.Connectors {
+		pub, ok := nm.connectors[cfg.ID].(connector.DeviceStatusPublisher)
+		if !ok {
+			continue
+		}
+		if devCfg, ok := model.LookupNorthboundPublishConfig(deviceID, cfg.Devices, cfg.VirtualDevices); ok && devCfg.Enable {
+			pub.PublishDeviceStatus(deviceID, status)
+		}
+	}
+}
+
+// UpsertConnectorConfig 更新或插入插件式连接器配置
+func (nm *NorthboundManager) UpsertConnectorConfig(cfg model.ConnectorConfig) (string, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	if err := nm.validateNorthboundChannelName(cfg.ID, cfg.Name); err != nil {
+		return "", err
+	}
+	if err := connector.Validate(cfg); err != nil {
+		return "", err
+	}
+
+	oldCfgs := append([]model.ConnectorConfig(nil), nm.config.Connectors...)
+	found := false
+	for i, c := range nm.config.Connectors {
+		if c.ID == cfg.ID {
+			nm.config.Connectors[i] = cfg
+			found = true
+			break
+		}
+	}
+	if !found {
+		nm.config.Connectors = append(nm.config.Connectors, cfg)
+	}
+
+	if err := nm.saveConfig(); err != nil {
+		return "", err
+	}
+
+	display := cfg.Type
+	if d, ok := connector.Lookup(cfg.Type); ok {
+		display = d.DisplayName
+	}
+	conn, exists := nm.connectors[cfg.ID]
+	if exists && (!cfg.Enable || !sameConnectorType(oldCfgs, cfg)) {
+		conn.Stop()
+		delete(nm.connectors, cfg.ID)
+		exists = false
+	}
+	if !cfg.Enable {
+		return "", nil
+	}
+
+	var startErr error
+	if exists {
+		startErr = conn.UpdateConfig(cfg)
+	} else {
+		startErr = nm.startConnectorLocked(cfg)
+	}
+	return connectorStartWarning(display, cfg.Name, startErr), nil
+}
+
+func sameConnectorType(cfgs []model.ConnectorConfig, cfg model.ConnectorConfig) bool {
+	for _, c := range cfgs {
+		if c.ID == cfg.ID {
+			return c.Type == cfg.Type
+		}
+	}
+	return false
+}
+
+// DeleteConnectorConfig 删除插件式连接器配置
+func (nm *NorthboundManager) DeleteConnectorConfig(id string) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	if conn, exists := nm.connectors[id]; exists {
+		conn.Stop()
+		delete(nm.connectors, id)
+	}
+
+	newConfigs := []model.ConnectorConfig{}
+	for _, c := range nm.config.Connectors {
+		if c.ID != id {
+			newConfigs = append(newConfigs, c)
+		}
+	}
+	nm.config.Connectors = newConfigs
+
+	return nm.saveConfig()
+}
+
+// GetConnectorStats 获取插件式连接器统计信息
+func (nm *NorthboundManager) GetConnectorStats(id string) (map[string]any, error) {
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
+
+	if conn, ok := nm.connectors[id]; ok {
+		return conn.Stats(), nil
+	}
+	return nil, fmt.Errorf("northbound connector %s not found or not running", id)
+}
+
+// WriteConnector 经指定连接器写入南向点位（用于上游下发写命令）
+func (nm *NorthboundManager) WriteConnector(id, channelID, deviceID, pointID string, value any) error {
+	nm.mu.RLock()
+	conn, ok := nm.connectors[id]
+	nm.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("northbound connector %s not found or not running", id)
+	}
+	return conn.Write(channelID, deviceID, pointID, value)
+}
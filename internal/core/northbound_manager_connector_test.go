@@ -0,0 +1,189 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+)
+
+type fakeConnector struct {
+	mu        sync.Mutex
+	cfg       model.ConnectorConfig
+	started   bool
+	stopped   bool
+	updates   int
+	published []model.Value
+	statuses  map[string]int
+}
+
+func (f *fakeConnector) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = true
+	return nil
+}
+
+func (f *fakeConnector) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+}
+
+func (f *fakeConnector) UpdateConfig(cfg model.ConnectorConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cfg = cfg
+	f.updates++
+	return nil
+}
+
+func (f *fakeConnector) Publish(v model.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, v)
+}
+
+func (f *fakeConnector) Stats() map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return map[string]any{"published": len(f.published)}
+}
+
+func (f *fakeConnector) Write(channelID, deviceID, pointID string, value any) error {
+	return connector.ErrWriteNotSupported
+}
+
+func (f *fakeConnector) PublishDeviceStatus(deviceID string, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[deviceID] = status
+}
+
+func (f *fakeConnector) Status() int { return 1 }
+
+// registerFakeConnector registers the "fake" type and returns the instances it creates.
+func registerFakeConnector(t *testing.T) func() []*fakeConnector {
+	t.Helper()
+	var mu sync.Mutex
+	var created []*fakeConnector
+	connector.Register(connector.Descriptor{
+		Type:        "fake",
+		DisplayName: "Fake",
+		New: func(cfg model.ConnectorConfig, deps connector.Deps) (connector.Connector, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			c := &fakeConnector{cfg: cfg, statuses: map[string]int{}}
+			created = append(created, c)
+			return c, nil
+		},
+		Validate: func(cfg model.ConnectorConfig) error {
+			if _, ok := cfg.Settings["url"]; !ok {
+				return errors.New("Fake URL 不能为空")
+			}
+			return nil
+		},
+	})
+	return func() []*fakeConnector {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*fakeConnector(nil), created...)
+	}
+}
+
+func TestNorthboundManager_ConnectorLifecycle(t *testing.T) {
+	instances := registerFakeConnector(t)
+
+	var saved model.NorthboundConfig
+	nm := NewNorthboundManager(model.NorthboundConfig{
+		HTTP: []model.HTTPConfig{{ID: "http-1", Name: "云端 HTTP"}},
+	}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		saved = cfg
+		return nil
+	})
+
+	cfg := model.ConnectorConfig{
+		ID:       "c1",
+		Name:     "插件通道",
+		Type:     "fake",
+		Enable:   true,
+		Settings: map[string]any{"url": "x"},
+		Devices:  model.OpcUaDeviceMap{"dev-1": {Enable: true}},
+	}
+
+	for name, bad := range map[string]model.ConnectorConfig{
+		"duplicate name": {ID: "c2", Name: "云端 HTTP", Type: "fake", Settings: cfg.Settings},
+		"unknown type":   {ID: "c2", Name: "x", Type: "nope"},
+		"validator":      {ID: "c2", Name: "x", Type: "fake"},
+	} {
+		if _, err := nm.UpsertConnectorConfig(bad); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if _, err := nm.UpsertConnectorConfig(cfg); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if len(saved.Connectors) != 1 {
+		t.Fatalf("saved connectors = %+v", saved.Connectors)
+	}
+	conns := instances()
+	if len(conns) != 1 || !conns[0].started {
+		t.Fatalf("connector not started: %+v", conns)
+	}
+	c := conns[0]
+
+	nm.handleValue(model.Value{DeviceID: "dev-1", PointID: "p"})
+	nm.handleValue(model.Value{DeviceID: "dev-2", PointID: "p"})
+	if len(c.published) != 1 {
+		t.Fatalf("only selected devices are published: %+v", c.published)
+	}
+	nm.mu.RLock()
+	nm.publishDeviceStatusToConnectors("dev-1", 0)
+	nm.publishDeviceStatusToConnectors("dev-2", 0)
+	nm.mu.RUnlock()
+	if c.statuses["dev-1"] != 0 || len(c.statuses) != 1 {
+		t.Fatalf("statuses = %v", c.statuses)
+	}
+
+	stats, err := nm.GetConnectorStats("c1")
+	if err != nil || stats["published"] != 1 {
+		t.Fatalf("stats = %v, %v", stats, err)
+	}
+	if err := nm.WriteConnector("c1", "ch", "dev-1", "p", 1); !errors.Is(err, connector.ErrWriteNotSupported) {
+		t.Fatalf("write: %v", err)
+	}
+	if got := nm.GetConfig().Status["c1"]; got != 1 {
+		t.Fatalf("status = %d", got)
+	}
+	found := false
+	for _, s := range nm.GetNorthboundStats() {
+		if s.ID == "c1" {
+			found = s.Type == "Fake" && s.Status == "Running"
+		}
+	}
+	if !found {
+		t.Fatal("connector missing from northbound stats")
+	}
+
+	cfg.Name = "插件通道 2"
+	if _, err := nm.UpsertConnectorConfig(cfg); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if c.updates != 1 || len(instances()) != 1 {
+		t.Fatalf("running connector must be updated in place, updates %d", c.updates)
+	}
+
+	next := nm.GetConfig()
+	next.Connectors = nil
+	nm.UpdateConfig(next)
+	if !c.stopped {
+		t.Fatal("removed connector must be stopped")
+	}
+	if _, err := nm.GetConnectorStats("c1"); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("stats after removal: %v", err)
+	}
+}
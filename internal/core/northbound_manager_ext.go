@@ -15,6 +15,9 @@ func (nm *NorthboundManager) UpsertHTTPConfig(cfg model.HTTPConfig) error {
 	if err := nm.validateNorthboundChannelName(cfg.ID, cfg.Name); err != nil {
 		return err
 	}
+	if err := http.ValidateConfig(cfg); err != nil {
+		return err
+	}
 
 	var oldCfg model.HTTPConfig
 	found := false
@@ -72,7 +75,7 @@ func (nm *NorthboundManager) UpsertHTTPConfig(cfg model.HTTPConfig) error {
 
 	var targetClient *http.Client
 	if !exists {
-		newClient := http.NewClient(cfg, nm.storage)
+		newClient := http.NewClient(cfg, nm.sb, nm.storage)
 		newClient.Start()
 		nm.httpClients[cfg.ID] = newClient
 		targetClient = newClient
@@ -155,6 +158,30 @@ func (nm *NorthboundManager) PublishHTTP(configID string, payload []byte) error
 	return fmt.Errorf("HTTP config %s not found or not running", configID)
 }
 
+// PullHTTP 取出 HTTP 拉取队列中的数据，供无法接收推送的消费方轮询
+func (nm *NorthboundManager) PullHTTP(configID, token string, max int) ([]byte, int, error) {
+	nm.mu.RLock()
+	client, ok := nm.httpClients[configID]
+	nm.mu.RUnlock()
+
+	if !ok {
+		return nil, 0, fmt.Errorf("HTTP config %s not found or not running", configID)
+	}
+	return client.Pull(token, max)
+}
+
+// CommandHTTP 处理 HTTP 北向下发的写点命令，校验通道 Token 后经南向写入
+func (nm *NorthboundManager) CommandHTTP(configID, token string, req http.CommandRequest) (http.CommandResult, bool, error) {
+	nm.mu.RLock()
+	client, ok := nm.httpClients[configID]
+	nm.mu.RUnlock()
+
+	if !ok {
+		return http.CommandResult{}, false, fmt.Errorf("HTTP config %s not found or not running", configID)
+	}
+	return client.Command(token, req)
+}
+
 // PublishMQTTClient publishes to a specific client
 func (nm *NorthboundManager) PublishMQTTClient(clientID string, topic string, payload []byte) error {
 	nm.mu.RLock()
@@ -166,6 +193,57 @@ func (nm *NorthboundManager) PublishMQTTClient(clientID string, topic string, pa
 	return fmt.Errorf("MQTT client %s not found", clientID)
 }
 
+// PreviewMQTTPayload renders the payload and topic templates of cfg against
+// the live shadow of a device. channelID may be empty for real devices; virtual
+// shadow devices are looked up by ID.
+func (nm *NorthboundManager) PreviewMQTTPayload(cfg model.MQTTConfig, channelID, deviceID string) ([]mqtt.Message, error) {
+	deviceName, found := "", false
+	if nm.cm != nil {
+		nm.cm.mu.RLock()
+		for _, ch := range nm.cm.channels {
+			if channelID != "" && ch.ID != channelID {
+				continue
+			}
+			for _, dev := range ch.Devices {
+				if dev.ID == deviceID {
+					channelID, deviceName, found = ch.ID, dev.Name, true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		nm.cm.mu.RUnlock()
+	}
+
+	if !found && nm.vsm != nil {
+		if vd, points, err := nm.vsm.GetRuntime(deviceID); err == nil {
+			data := make([]model.PointData, 0, len(points))
+			for id, p := range points {
+				data = append(data, model.PointData{
+					ID:          id,
+					Value:       p.Value,
+					Quality:     p.Quality,
+					Unit:        p.Unit,
+					Timestamp:   p.Timestamp,
+					CollectedAt: p.CollectedAt,
+				})
+			}
+			return mqtt.Preview(cfg, vd.ChannelID, deviceID, deviceID, data)
+		}
+	}
+
+	if channelID == "" || nm.sb == nil {
+		return nil, fmt.Errorf("device %s not found", deviceID)
+	}
+	points, err := nm.sb.GetDevicePoints(channelID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return mqtt.Preview(cfg, channelID, deviceID, deviceName, points)
+}
+
 func (nm *NorthboundManager) UpsertMQTTConfig(cfg model.MQTTConfig) (string, error) {
 	nm.mu.Lock()
 	defer nm.mu.Unlock()
@@ -175,16 +253,22 @@ func (nm *NorthboundManager) UpsertMQTTConfig(cfg model.MQTTConfig) (string, err
 	}
 
 	var oldCfg model.MQTTConfig
-	found := false
+	idx := -1
 	for i, c := range nm.config.MQTT {
 		if c.ID == cfg.ID {
 			oldCfg = c
-			nm.config.MQTT[i] = cfg
-			found = true
+			cfg = model.MergeMQTTConfig(c, cfg)
+			idx = i
 			break
 		}
 	}
-	if !found {
+	if err := mqtt.ValidateConfig(cfg); err != nil {
+		return "", err
+	}
+	found := idx >= 0
+	if found {
+		nm.config.MQTT[idx] = cfg
+	} else {
 		nm.config.MQTT = append(nm.config.MQTT, cfg)
 	}
 
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/kafka"
+	"github.com/anviod/edgex/internal/northbound/tsdb"
 )
 
 func TestNorthboundManager_UpsertMQTT_PersistViaSaveFunc(t *testing.T) {
@@ -106,3 +108,150 @@ func TestNorthboundManager_UpsertOPCUA_PersistDeviceMapping(t *testing.T) {
 		t.Fatalf("device mapping not persisted: %+v", saved.OPCUA[0].Devices)
 	}
 }
+
+func TestNorthboundManager_UpsertKafka_PersistViaSaveFunc(t *testing.T) {
+	var saved model.NorthboundConfig
+	nm := NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		saved = cfg
+		return nil
+	})
+
+	cfg := model.ConnectorConfig{
+		ID:     "nb-kafka-1",
+		Name:   "Test Kafka",
+		Type:   kafka.ConnectorType,
+		Enable: false,
+		Settings: map[string]any{
+			"brokers": []string{"127.0.0.1:9092"},
+			"topic":   "edgex.{channel_id}",
+		},
+	}
+	if _, err := nm.UpsertConnectorConfig(cfg); err != nil {
+		t.Fatalf("UpsertConnectorConfig: %v", err)
+	}
+	if len(saved.Connectors) != 1 || saved.Connectors[0].ID != "nb-kafka-1" || saved.Connectors[0].Type != "kafka" {
+		t.Fatalf("Kafka config not saved correctly: %+v", saved.Connectors)
+	}
+
+	// Channel names are unique across protocols.
+	if _, err := nm.UpsertMQTTConfig(model.MQTTConfig{ID: "nb-mqtt-1", Name: "test kafka"}); err == nil {
+		t.Fatal("expected duplicate name to be rejected")
+	}
+
+	// Cache settings are validated before saving.
+	cfg.Settings["cache"] = map[string]any{"enable": true, "ttl": "soon"}
+	if _, err := nm.UpsertConnectorConfig(cfg); err == nil {
+		t.Fatal("expected invalid cache TTL to be rejected")
+	}
+
+	if err := nm.DeleteConnectorConfig("nb-kafka-1"); err != nil {
+		t.Fatalf("DeleteConnectorConfig: %v", err)
+	}
+	if len(saved.Connectors) != 0 {
+		t.Fatalf("expected 0 connectors after delete, got %d", len(saved.Connectors))
+	}
+}
+
+func TestNorthboundManager_UpsertTSDB_PersistAndWarn(t *testing.T) {
+	var saved model.NorthboundConfig
+	nm := NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		saved = cfg
+		return nil
+	})
+
+	// An invalid target is saved, but reported as a start warning.
+	settings := map[string]any{
+		"type": "influxdb_v2",
+		"url":  "http://127.0.0.1:8086",
+	}
+	cfg := model.ConnectorConfig{
+		ID:       "nb-tsdb-1",
+		Name:     "Historian",
+		Type:     tsdb.ConnectorType,
+		Enable:   true,
+		Settings: settings,
+	}
+	warning, err := nm.UpsertConnectorConfig(cfg)
+	if err != nil {
+		t.Fatalf("UpsertConnectorConfig: %v", err)
+	}
+	if warning == "" {
+		t.Fatal("expected a warning for a missing bucket")
+	}
+	if len(saved.Connectors) != 1 || saved.Connectors[0].ID != "nb-tsdb-1" {
+		t.Fatalf("TSDB config not saved correctly: %+v", saved.Connectors)
+	}
+	if _, err := nm.GetConnectorStats("nb-tsdb-1"); err == nil {
+		t.Fatal("writer with invalid config should not be running")
+	}
+
+	settings["bucket"] = "edge"
+	if warning, err := nm.UpsertConnectorConfig(cfg); err != nil || warning != "" {
+		t.Fatalf("UpsertConnectorConfig = %q, %v", warning, err)
+	}
+	stats, err := nm.GetConnectorStats("nb-tsdb-1")
+	if err != nil {
+		t.Fatalf("GetConnectorStats: %v", err)
+	}
+	if _, ok := stats["pending_count"]; !ok {
+		t.Fatalf("stats = %v", stats)
+	}
+
+	if err := nm.DeleteConnectorConfig("nb-tsdb-1"); err != nil {
+		t.Fatalf("DeleteConnectorConfig: %v", err)
+	}
+	if len(saved.Connectors) != 0 {
+		t.Fatalf("expected 0 connectors after delete, got %d", len(saved.Connectors))
+	}
+}
+
+func TestNorthboundManager_UpsertMQTT_RejectsInvalidTemplate(t *testing.T) {
+	saves := 0
+	nm := NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		saves++
+		return nil
+	})
+
+	_, err := nm.UpsertMQTTConfig(model.MQTTConfig{ID: "nb-mqtt-1", Name: "Cloud", PayloadTemplate: "{{.Values"})
+	if err == nil {
+		t.Fatal("expected invalid payload template to be rejected")
+	}
+	if saves != 0 || len(nm.GetConfig().MQTT) != 0 {
+		t.Fatalf("invalid config was saved")
+	}
+
+	if _, err := nm.PreviewMQTTPayload(model.MQTTConfig{}, "", "missing"); err == nil {
+		t.Fatal("expected preview of an unknown device to fail")
+	}
+}
+
+func TestNorthboundManager_UpsertMQTT_KeepsTLSKey(t *testing.T) {
+	var saved model.NorthboundConfig
+	nm := NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		saved = cfg
+		return nil
+	})
+
+	cfg := model.MQTTConfig{ID: "nb-mqtt-1", Name: "Cloud", TLS: model.MQTTTLSConfig{ClientCertPEM: "cert", ClientKeyPEM: "key"}}
+	if _, err := nm.UpsertMQTTConfig(cfg); err != nil {
+		t.Fatalf("UpsertMQTTConfig: %v", err)
+	}
+
+	// The UI posts back the sanitized config without the private key.
+	client := nm.GetConfig().MQTT[0]
+	if client.TLS.ClientKeyPEM != "" || !client.TLS.HasClientKey || !client.TLS.HasClientCert {
+		t.Fatalf("expected key stripped with has flags, got %+v", client.TLS)
+	}
+	client.QoS = 1
+	if _, err := nm.UpsertMQTTConfig(client); err != nil {
+		t.Fatalf("UpsertMQTTConfig: %v", err)
+	}
+	if saved.MQTT[0].TLS.ClientKeyPEM != "key" || saved.MQTT[0].QoS != 1 {
+		t.Fatalf("expected stored key preserved, got %+v", saved.MQTT[0])
+	}
+
+	client.ProtocolVersion = 7
+	if _, err := nm.UpsertMQTTConfig(client); err == nil {
+		t.Fatal("expected invalid protocol version to be rejected")
+	}
+}
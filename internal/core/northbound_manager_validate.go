@@ -43,5 +43,10 @@ func (nm *NorthboundManager) validateNorthboundChannelName(excludeID, name strin
 			return fmt.Errorf("通道名称「%s」已存在", name)
 		}
 	}
+	for _, c := range nm.config.Connectors {
+		if c.ID != excludeID && strings.EqualFold(strings.TrimSpace(c.Name), name) {
+			return fmt.Errorf("通道名称「%s」已存在", name)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,652 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationTickInterval = 5 * time.Second
+	maxNotificationHistory   = 500
+	notificationSendTimeout  = 10 * time.Second
+)
+
+// NotifyRequest 一次告警通知请求，Key 相同且未确认的通知只累计次数不重复发送
+type NotifyRequest struct {
+	PolicyID string
+	RuleID   string
+	Key      string
+	Severity string
+	Subject  string
+	Message  string
+}
+
+// NotificationManager 管理通知通道、接收人与升级策略，并跟踪告警的确认与逐级升级
+type NotificationManager struct {
+	mu       sync.Mutex
+	cfg      model.NotificationConfig
+	saveFunc func(model.NotificationConfig) error
+
+	notifications []*model.Notification
+	lastSent      map[string]time.Time // recipient ID -> last delivery, for throttling
+	senders       map[string]notificationSender
+
+	now      func() time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewNotificationManager(saveFunc func(model.NotificationConfig) error) *NotificationManager {
+	return &NotificationManager{
+		saveFunc: saveFunc,
+		lastSent: make(map[string]time.Time),
+		senders: map[string]notificationSender{
+			"smtp":    smtpSender{},
+			"syslog":  syslogSender{},
+			"webhook": webhookSender{},
+		},
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+}
+
+func (nm *NotificationManager) Start() {
+	go nm.loop()
+}
+
+func (nm *NotificationManager) Stop() {
+	nm.stopOnce.Do(func() {
+		close(nm.stopCh)
+	})
+}
+
+func (nm *NotificationManager) loop() {
+	ticker := time.NewTicker(notificationTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-nm.stopCh:
+			return
+		case <-ticker.C:
+			nm.escalate()
+		}
+	}
+}
+
+func (nm *NotificationManager) Load(cfg model.NotificationConfig) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	nm.cfg = cfg
+}
+
+func (nm *NotificationManager) Config() model.NotificationConfig {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	return model.NotificationConfig{
+		Channels:   append([]model.NotificationChannel{}, nm.cfg.Channels...),
+		Recipients: append([]model.NotificationRecipient{}, nm.cfg.Recipients...),
+		Policies:   append([]model.NotificationPolicy{}, nm.cfg.Policies...),
+	}
+}
+
+// update 在配置副本上修改并整体校验，成功后替换并持久化
+func (nm *NotificationManager) update(fn func(cfg *model.NotificationConfig) error) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	cfg := model.NotificationConfig{
+		Channels:   append([]model.NotificationChannel{}, nm.cfg.Channels...),
+		Recipients: append([]model.NotificationRecipient{}, nm.cfg.Recipients...),
+		Policies:   append([]model.NotificationPolicy{}, nm.cfg.Policies...),
+	}
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+	if err := validateNotificationConfig(cfg); err != nil {
+		return err
+	}
+	nm.cfg = cfg
+	if nm.saveFunc == nil {
+		return nil
+	}
+	return nm.saveFunc(cfg)
+}
+
+func (nm *NotificationManager) UpsertChannel(ch model.NotificationChannel) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Channels {
+			if cfg.Channels[i].ID == ch.ID {
+				cfg.Channels[i] = ch
+				return nil
+			}
+		}
+		cfg.Channels = append(cfg.Channels, ch)
+		return nil
+	})
+}
+
+func (nm *NotificationManager) DeleteChannel(id string) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Channels {
+			if cfg.Channels[i].ID == id {
+				cfg.Channels = append(cfg.Channels[:i], cfg.Channels[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("notification channel not found")
+	})
+}
+
+func (nm *NotificationManager) UpsertRecipient(r model.NotificationRecipient) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Recipients {
+			if cfg.Recipients[i].ID == r.ID {
+				cfg.Recipients[i] = r
+				return nil
+			}
+		}
+		cfg.Recipients = append(cfg.Recipients, r)
+		return nil
+	})
+}
+
+func (nm *NotificationManager) DeleteRecipient(id string) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Recipients {
+			if cfg.Recipients[i].ID == id {
+				cfg.Recipients = append(cfg.Recipients[:i], cfg.Recipients[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("notification recipient not found")
+	})
+}
+
+func (nm *NotificationManager) UpsertPolicy(p model.NotificationPolicy) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Policies {
+			if cfg.Policies[i].ID == p.ID {
+				cfg.Policies[i] = p
+				return nil
+			}
+		}
+		cfg.Policies = append(cfg.Policies, p)
+		return nil
+	})
+}
+
+func (nm *NotificationManager) DeletePolicy(id string) error {
+	return nm.update(func(cfg *model.NotificationConfig) error {
+		for i := range cfg.Policies {
+			if cfg.Policies[i].ID == id {
+				cfg.Policies = append(cfg.Policies[:i], cfg.Policies[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("notification policy not found")
+	})
+}
+
+func validateNotificationConfig(cfg model.NotificationConfig) error {
+	channels := make(map[string]struct{}, len(cfg.Channels))
+	for _, ch := range cfg.Channels {
+		if ch.ID == "" {
+			return fmt.Errorf("通知通道 ID 无效")
+		}
+		if _, ok := channels[ch.ID]; ok {
+			return fmt.Errorf("通知通道 ID 重复: %s", ch.ID)
+		}
+		channels[ch.ID] = struct{}{}
+		if err := validateNotificationChannel(ch); err != nil {
+			return err
+		}
+	}
+	recipients := make(map[string]struct{}, len(cfg.Recipients))
+	for _, r := range cfg.Recipients {
+		if r.ID == "" {
+			return fmt.Errorf("接收人 ID 无效")
+		}
+		if _, ok := recipients[r.ID]; ok {
+			return fmt.Errorf("接收人 ID 重复: %s", r.ID)
+		}
+		recipients[r.ID] = struct{}{}
+		if r.Throttle != "" {
+			if _, err := time.ParseDuration(r.Throttle); err != nil {
+				return fmt.Errorf("接收人 %s throttle 无效: %v", r.ID, err)
+			}
+		}
+		if r.QuietHours != nil {
+			if _, _, _, err := parseQuietHours(*r.QuietHours); err != nil {
+				return fmt.Errorf("接收人 %s quiet_hours 无效: %v", r.ID, err)
+			}
+		}
+	}
+	policies := make(map[string]struct{}, len(cfg.Policies))
+	for _, p := range cfg.Policies {
+		if p.ID == "" {
+			return fmt.Errorf("通知策略 ID 无效")
+		}
+		if _, ok := policies[p.ID]; ok {
+			return fmt.Errorf("通知策略 ID 重复: %s", p.ID)
+		}
+		policies[p.ID] = struct{}{}
+		if len(p.Levels) == 0 {
+			return fmt.Errorf("通知策略 %s 无效: 至少需要一个升级级别", p.ID)
+		}
+		for i, lvl := range p.Levels {
+			if i > 0 {
+				if d, err := time.ParseDuration(lvl.Delay); err != nil || d <= 0 {
+					return fmt.Errorf("通知策略 %s 第 %d 级 delay 无效", p.ID, i+1)
+				}
+			}
+			if len(lvl.Channels) == 0 {
+				return fmt.Errorf("通知策略 %s 第 %d 级无效: channels 不能为空", p.ID, i+1)
+			}
+			for _, id := range lvl.Channels {
+				if _, ok := channels[id]; !ok {
+					return fmt.Errorf("通知策略 %s 引用的通道无效: %s", p.ID, id)
+				}
+			}
+			for _, id := range lvl.Recipients {
+				if _, ok := recipients[id]; !ok {
+					return fmt.Errorf("通知策略 %s 引用的接收人无效: %s", p.ID, id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateNotificationChannel(ch model.NotificationChannel) error {
+	switch ch.Type {
+	case "smtp":
+		if ch.SMTP == nil || ch.SMTP.Host == "" || ch.SMTP.From == "" {
+			return fmt.Errorf("通知通道 %s 无效: smtp.host 与 smtp.from 不能为空", ch.ID)
+		}
+		switch ch.SMTP.TLS {
+		case "", "none", "starttls", "tls":
+		default:
+			return fmt.Errorf("通知通道 %s 无效: 不支持的 tls 模式 %q", ch.ID, ch.SMTP.TLS)
+		}
+		return validateOptionalDuration(ch.ID, ch.SMTP.Timeout)
+	case "syslog":
+		if ch.Syslog == nil || ch.Syslog.Address == "" {
+			return fmt.Errorf("通知通道 %s 无效: syslog.address 不能为空", ch.ID)
+		}
+		switch ch.Syslog.Network {
+		case "", "udp", "tcp":
+		default:
+			return fmt.Errorf("通知通道 %s 无效: 不支持的 network %q", ch.ID, ch.Syslog.Network)
+		}
+		if ch.Syslog.Facility < 0 || ch.Syslog.Facility > 23 {
+			return fmt.Errorf("通知通道 %s 无效: facility 需在 0 ~ 23 之间", ch.ID)
+		}
+		return nil
+	case "webhook":
+		if ch.Webhook == nil || ch.Webhook.URL == "" {
+			return fmt.Errorf("通知通道 %s 无效: webhook.url 不能为空", ch.ID)
+		}
+		return validateOptionalDuration(ch.ID, ch.Webhook.Timeout)
+	default:
+		return fmt.Errorf("通知通道 %s 类型无效: %q", ch.ID, ch.Type)
+	}
+}
+
+func validateOptionalDuration(id, s string) error {
+	if s == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return fmt.Errorf("通知通道 %s timeout 无效: %v", id, err)
+	}
+	return nil
+}
+
+// parseQuietHours 返回起止分钟数与时区
+func parseQuietHours(q model.QuietHours) (int, int, *time.Location, error) {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	loc := time.Local
+	if q.Timezone != "" {
+		if loc, err = time.LoadLocation(q.Timezone); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	return start, end, loc, nil
+}
+
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || hh > 23 || mm < 0 || mm > 59 {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	return hh*60 + mm, nil
+}
+
+func inQuietHours(q *model.QuietHours, severity string, now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	for _, s := range q.Except {
+		if strings.EqualFold(s, severity) {
+			return false
+		}
+	}
+	start, end, loc, err := parseQuietHours(*q)
+	if err != nil || start == end {
+		return false
+	}
+	t := now.In(loc)
+	mins := t.Hour()*60 + t.Minute()
+	if start < end {
+		return mins >= start && mins < end
+	}
+	return mins >= start || mins < end
+}
+
+// Notify 按策略发送第一级通知；同一 Key 存在未确认通知时只累计次数
+func (nm *NotificationManager) Notify(ctx context.Context, req NotifyRequest) (model.Notification, error) {
+	if req.Severity == "" {
+		req.Severity = "warning"
+	}
+	if req.Key == "" {
+		req.Key = req.PolicyID + "/" + req.RuleID
+	}
+
+	nm.mu.Lock()
+	if _, ok := nm.policyLocked(req.PolicyID); !ok {
+		nm.mu.Unlock()
+		return model.Notification{}, fmt.Errorf("通知策略无效: %s", req.PolicyID)
+	}
+	for _, n := range nm.notifications {
+		if n.Key == req.Key && !n.Acked {
+			n.Occurrences++
+			out := copyNotification(n)
+			nm.mu.Unlock()
+			return out, nil
+		}
+	}
+	now := nm.now()
+	n := &model.Notification{
+		ID:          uuid.New().String(),
+		PolicyID:    req.PolicyID,
+		RuleID:      req.RuleID,
+		Key:         req.Key,
+		Severity:    req.Severity,
+		Subject:     req.Subject,
+		Message:     req.Message,
+		Occurrences: 1,
+		CreatedAt:   now,
+		EscalatedAt: now,
+	}
+	nm.notifications = append(nm.notifications, n)
+	nm.trimLocked()
+	jobs := nm.planLevelLocked(n, 0, now)
+	nm.mu.Unlock()
+
+	err := nm.deliver(ctx, n, jobs)
+	out, _ := nm.Get(n.ID)
+	return out, err
+}
+
+func (nm *NotificationManager) policyLocked(id string) (model.NotificationPolicy, bool) {
+	for _, p := range nm.cfg.Policies {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return model.NotificationPolicy{}, false
+}
+
+type notificationJob struct {
+	level     int
+	channel   model.NotificationChannel
+	recipient *model.NotificationRecipient
+}
+
+// planLevelLocked 生成某一级的投递任务，免打扰与节流的接收人直接记录跳过原因
+func (nm *NotificationManager) planLevelLocked(n *model.Notification, level int, now time.Time) []notificationJob {
+	policy, ok := nm.policyLocked(n.PolicyID)
+	if !ok || level >= len(policy.Levels) {
+		return nil
+	}
+	n.Level = level
+	n.EscalatedAt = now
+	lvl := policy.Levels[level]
+
+	var recipients []*model.NotificationRecipient
+	for _, id := range lvl.Recipients {
+		for i := range nm.cfg.Recipients {
+			r := nm.cfg.Recipients[i]
+			if r.ID != id {
+				continue
+			}
+			if inQuietHours(r.QuietHours, n.Severity, now) {
+				n.Deliveries = append(n.Deliveries, model.NotificationDelivery{Level: level, Recipient: r.ID, Status: "quiet", Time: now})
+				break
+			}
+			if r.Throttle != "" {
+				d, _ := time.ParseDuration(r.Throttle)
+				if last, ok := nm.lastSent[r.ID]; ok && now.Sub(last) < d {
+					n.Deliveries = append(n.Deliveries, model.NotificationDelivery{Level: level, Recipient: r.ID, Status: "throttled", Time: now})
+					break
+				}
+			}
+			nm.lastSent[r.ID] = now
+			recipients = append(recipients, &r)
+			break
+		}
+	}
+
+	var jobs []notificationJob
+	for _, chID := range lvl.Channels {
+		for _, ch := range nm.cfg.Channels {
+			if ch.ID != chID || !ch.Enable {
+				continue
+			}
+			// syslog 面向运维系统而非个人，每级只发送一次
+			if ch.Type == "syslog" {
+				jobs = append(jobs, notificationJob{level: level, channel: ch})
+				continue
+			}
+			for _, r := range recipients {
+				jobs = append(jobs, notificationJob{level: level, channel: ch, recipient: r})
+			}
+		}
+	}
+	return jobs
+}
+
+// deliver 执行投递并记录结果，全部投递失败时返回最后一个错误
+func (nm *NotificationManager) deliver(ctx context.Context, n *model.Notification, jobs []notificationJob) error {
+	nm.mu.Lock()
+	msg := *n
+	msg.Deliveries = nil
+	nm.mu.Unlock()
+
+	var lastErr error
+	sent := 0
+	for _, job := range jobs {
+		err := nm.send(ctx, job.channel, job.recipient, msg)
+		d := model.NotificationDelivery{Level: job.level, Channel: job.channel.ID, Status: "sent", Time: nm.now()}
+		if job.recipient != nil {
+			d.Recipient = job.recipient.ID
+		}
+		if err != nil {
+			d.Status = "failed"
+			d.Error = err.Error()
+			lastErr = err
+			log.Printf("[Notification] %s via %s to %s failed: %v", n.ID, job.channel.ID, d.Recipient, err)
+		} else {
+			sent++
+		}
+		nm.mu.Lock()
+		n.Deliveries = append(n.Deliveries, d)
+		nm.mu.Unlock()
+	}
+	if sent == 0 && lastErr != nil {
+		return fmt.Errorf("通知发送失败: %w", lastErr)
+	}
+	return nil
+}
+
+func (nm *NotificationManager) send(ctx context.Context, ch model.NotificationChannel, r *model.NotificationRecipient, n model.Notification) error {
+	sender, ok := nm.senders[ch.Type]
+	if !ok {
+		return fmt.Errorf("unsupported notification channel type: %s", ch.Type)
+	}
+	ctx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	return sender.Send(ctx, ch, r, n)
+}
+
+// escalate 将超过 delay 仍未确认的通知升级到下一级
+func (nm *NotificationManager) escalate() {
+	type pending struct {
+		n    *model.Notification
+		jobs []notificationJob
+	}
+	var todo []pending
+
+	nm.mu.Lock()
+	now := nm.now()
+	for _, n := range nm.notifications {
+		if n.Acked {
+			continue
+		}
+		policy, ok := nm.policyLocked(n.PolicyID)
+		next := n.Level + 1
+		if !ok || next >= len(policy.Levels) {
+			continue
+		}
+		delay, _ := time.ParseDuration(policy.Levels[next].Delay)
+		if now.Sub(n.EscalatedAt) < delay {
+			continue
+		}
+		log.Printf("[Notification] %s not acknowledged, escalating to level %d", n.ID, next+1)
+		todo = append(todo, pending{n: n, jobs: nm.planLevelLocked(n, next, now)})
+	}
+	nm.mu.Unlock()
+
+	for _, p := range todo {
+		_ = nm.deliver(context.Background(), p.n, p.jobs)
+	}
+}
+
+// Acknowledge 确认通知，停止后续升级
+func (nm *NotificationManager) Acknowledge(id, user string) (model.Notification, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	for _, n := range nm.notifications {
+		if n.ID != id {
+			continue
+		}
+		if !n.Acked {
+			n.Acked = true
+			n.AckedBy = user
+			n.AckedAt = nm.now()
+		}
+		return copyNotification(n), nil
+	}
+	return model.Notification{}, fmt.Errorf("notification not found")
+}
+
+func (nm *NotificationManager) Get(id string) (model.Notification, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	for _, n := range nm.notifications {
+		if n.ID == id {
+			return copyNotification(n), nil
+		}
+	}
+	return model.Notification{}, fmt.Errorf("notification not found")
+}
+
+// List 返回通知记录，最新的在前
+func (nm *NotificationManager) List(activeOnly bool) []model.Notification {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	out := make([]model.Notification, 0, len(nm.notifications))
+	for i := len(nm.notifications) - 1; i >= 0; i-- {
+		n := nm.notifications[i]
+		if activeOnly && n.Acked {
+			continue
+		}
+		out = append(out, copyNotification(n))
+	}
+	return out
+}
+
+// TestChannel 通过指定通道向接收人发送测试通知
+func (nm *NotificationManager) TestChannel(ctx context.Context, channelID, recipientID string) error {
+	nm.mu.Lock()
+	var ch *model.NotificationChannel
+	for i := range nm.cfg.Channels {
+		if nm.cfg.Channels[i].ID == channelID {
+			c := nm.cfg.Channels[i]
+			ch = &c
+		}
+	}
+	var r *model.NotificationRecipient
+	for i := range nm.cfg.Recipients {
+		if nm.cfg.Recipients[i].ID == recipientID {
+			rc := nm.cfg.Recipients[i]
+			r = &rc
+		}
+	}
+	now := nm.now()
+	nm.mu.Unlock()
+
+	if ch == nil {
+		return fmt.Errorf("notification channel not found")
+	}
+	if r == nil && recipientID != "" {
+		return fmt.Errorf("notification recipient not found")
+	}
+	return nm.send(ctx, *ch, r, model.Notification{
+		ID:        "test",
+		Key:       "test",
+		Severity:  "info",
+		Subject:   "EdgeX 测试通知",
+		Message:   "这是一条来自网关的测试通知。",
+		CreatedAt: now,
+	})
+}
+
+// trimLocked 超出历史上限时优先淘汰最早的已确认通知
+func (nm *NotificationManager) trimLocked() {
+	for len(nm.notifications) > maxNotificationHistory {
+		idx := 0
+		for i, n := range nm.notifications {
+			if n.Acked {
+				idx = i
+				break
+			}
+		}
+		nm.notifications = append(nm.notifications[:idx], nm.notifications[idx+1:]...)
+	}
+}
+
+func copyNotification(n *model.Notification) model.Notification {
+	out := *n
+	out.Deliveries = append([]model.NotificationDelivery(nil), n.Deliveries...)
+	return out
+}
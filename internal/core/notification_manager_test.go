@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// fakeSMTPServer 本地 SMTP 替身，记录收到的邮件
+type fakeSMTPServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	mail []string // "rcpt\n<data>"
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	var rcpt string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			tp.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt = strings.Trim(line[len("RCPT TO:"):], "<>")
+			tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.mail = append(s.mail, rcpt+"\n"+string(data))
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mail...)
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+type webhookRecorder struct {
+	srv  *httptest.Server
+	mu   sync.Mutex
+	hits []map[string]any
+}
+
+func startWebhook(t *testing.T) *webhookRecorder {
+	t.Helper()
+	w := &webhookRecorder{}
+	w.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.mu.Lock()
+		w.hits = append(w.hits, body)
+		w.mu.Unlock()
+	}))
+	t.Cleanup(w.srv.Close)
+	return w
+}
+
+func (w *webhookRecorder) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.hits)
+}
+
+func newTestNotifier(t *testing.T, smtpPort int, webhookURL string) *NotificationManager {
+	t.Helper()
+	nm := NewNotificationManager(nil)
+	steps := []error{
+		nm.UpsertChannel(model.NotificationChannel{ID: "mail", Type: "smtp", Enable: true,
+			SMTP: &model.SMTPConfig{Host: "127.0.0.1", Port: smtpPort, From: "edgex@plant.local", Timeout: "2s"}}),
+		nm.UpsertChannel(model.NotificationChannel{ID: "pager", Type: "webhook", Enable: true,
+			Webhook: &model.WebhookConfig{URL: webhookURL, Timeout: "2s"}}),
+		nm.UpsertRecipient(model.NotificationRecipient{ID: "op", Name: "Operator", Email: "op@plant.local"}),
+		nm.UpsertRecipient(model.NotificationRecipient{ID: "lead", Name: "Shift Lead", Email: "lead@plant.local"}),
+		nm.UpsertPolicy(model.NotificationPolicy{ID: "boiler", Levels: []model.EscalationLevel{
+			{Recipients: []string{"op"}, Channels: []string{"mail"}},
+			{Delay: "15m", Recipients: []string{"lead"}, Channels: []string{"mail", "pager"}},
+		}}),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+	return nm
+}
+
+func TestNotificationEscalationAndAck(t *testing.T) {
+	smtpSrv := startFakeSMTP(t)
+	hook := startWebhook(t)
+	nm := newTestNotifier(t, smtpSrv.port(), hook.srv.URL)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	nm.now = func() time.Time { return now }
+
+	n, err := nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", RuleID: "r1", Severity: "critical", Subject: "锅炉超压", Message: "P=1.8MPa"})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	mails := smtpSrv.messages()
+	if len(mails) != 1 || !strings.HasPrefix(mails[0], "op@plant.local\n") || !strings.Contains(mails[0], "P=1.8MPa") {
+		t.Fatalf("unexpected level 1 mail: %q", mails)
+	}
+
+	// Re-trigger while unacknowledged only counts occurrences
+	again, _ := nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", RuleID: "r1", Subject: "锅炉超压"})
+	if again.ID != n.ID || again.Occurrences != 2 || len(smtpSrv.messages()) != 1 {
+		t.Fatalf("expected dedup into %s, got %+v", n.ID, again)
+	}
+
+	now = now.Add(10 * time.Minute)
+	nm.escalate()
+	if len(smtpSrv.messages()) != 1 {
+		t.Fatal("escalated before delay elapsed")
+	}
+	now = now.Add(6 * time.Minute)
+	nm.escalate()
+	mails = smtpSrv.messages()
+	if len(mails) != 2 || !strings.HasPrefix(mails[1], "lead@plant.local\n") || hook.count() != 1 {
+		t.Fatalf("expected level 2 mail and page, got %d mails, %d pages", len(mails), hook.count())
+	}
+
+	if _, err := nm.Acknowledge(n.ID, "lead"); err != nil {
+		t.Fatalf("Acknowledge: %v", err)
+	}
+	got, _ := nm.Get(n.ID)
+	if !got.Acked || got.AckedBy != "lead" || got.Level != 1 || len(got.Deliveries) != 3 {
+		t.Fatalf("unexpected notification state: %+v", got)
+	}
+	if len(nm.List(true)) != 0 {
+		t.Fatal("acknowledged notification still listed as active")
+	}
+
+	// After ack a new occurrence opens a fresh notification
+	next, _ := nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", RuleID: "r1"})
+	if next.ID == n.ID {
+		t.Fatal("expected a new notification after acknowledgement")
+	}
+}
+
+func TestNotificationQuietHoursAndThrottle(t *testing.T) {
+	smtpSrv := startFakeSMTP(t)
+	nm := newTestNotifier(t, smtpSrv.port(), "http://127.0.0.1:1")
+	if err := nm.UpsertRecipient(model.NotificationRecipient{ID: "op", Email: "op@plant.local", Throttle: "10m",
+		QuietHours: &model.QuietHours{Start: "22:00", End: "07:00", Except: []string{"critical"}}}); err != nil {
+		t.Fatalf("UpsertRecipient: %v", err)
+	}
+	now := time.Date(2026, 3, 2, 23, 30, 0, 0, time.Local)
+	nm.now = func() time.Time { return now }
+
+	n, _ := nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", Key: "a", Severity: "warning"})
+	if len(smtpSrv.messages()) != 0 || n.Deliveries[0].Status != "quiet" {
+		t.Fatalf("expected quiet-hours suppression, got %+v", n.Deliveries)
+	}
+	if _, err := nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", Key: "b", Severity: "critical"}); err != nil {
+		t.Fatalf("critical Notify: %v", err)
+	}
+	if len(smtpSrv.messages()) != 1 {
+		t.Fatal("critical notification should bypass quiet hours")
+	}
+	n, _ = nm.Notify(context.Background(), NotifyRequest{PolicyID: "boiler", Key: "c", Severity: "critical"})
+	if len(smtpSrv.messages()) != 1 || n.Deliveries[0].Status != "throttled" {
+		t.Fatalf("expected throttling, got %+v", n.Deliveries)
+	}
+}
+
+func TestNotificationConfigValidation(t *testing.T) {
+	nm := newTestNotifier(t, 25, "http://127.0.0.1:1")
+	if err := nm.DeleteChannel("mail"); err == nil {
+		t.Fatal("deleting a channel referenced by a policy should fail")
+	}
+	if err := nm.UpsertPolicy(model.NotificationPolicy{ID: "p2", Levels: []model.EscalationLevel{
+		{Channels: []string{"mail"}}, {Channels: []string{"mail"}},
+	}}); err == nil {
+		t.Fatal("escalation level without delay should be rejected")
+	}
+	if err := nm.UpsertChannel(model.NotificationChannel{ID: "x", Type: "sms"}); err == nil {
+		t.Fatal("unknown channel type should be rejected")
+	}
+}
+
+func TestSyslogSenderRFC5424(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	ch := model.NotificationChannel{ID: "sys", Type: "syslog", Syslog: &model.SyslogConfig{Address: pc.LocalAddr().String(), Facility: 16}}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = syslogSender{}.Send(ctx, ch, nil, model.Notification{ID: "n1", Severity: "critical", Subject: "锅炉超压", Message: "P=1.8MPa"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+	// local0 (16) * 8 + critical (2) = 130
+	if !strings.HasPrefix(msg, "<130>1 ") || !strings.Contains(msg, " edgex "+strconv.Itoa(os.Getpid())+" ALARM - 锅炉超压: P=1.8MPa") {
+		t.Fatalf("unexpected syslog message: %q", msg)
+	}
+}
+
+func TestEdgeNotifyAction(t *testing.T) {
+	smtpSrv := startFakeSMTP(t)
+	em := NewEdgeComputeManager(nil, nil, nil)
+	em.SetNotificationManager(newTestNotifier(t, smtpSrv.port(), "http://127.0.0.1:1"))
+
+	action := model.RuleAction{Type: "notify", Config: map[string]any{
+		"policy_id": "boiler",
+		"severity":  "major",
+		"subject":   "${rule_id} 压力高",
+		"message":   "当前压力 ${p}",
+	}}
+	err := em.executeSingleAction(context.Background(), "boiler-high", action, model.Value{Value: 1.8}, map[string]any{"p": 1.8})
+	if err != nil {
+		t.Fatalf("notify action: %v", err)
+	}
+	mails := smtpSrv.messages()
+	if len(mails) != 1 || !strings.Contains(mails[0], "当前压力 1.8") {
+		t.Fatalf("unexpected mail: %q", mails)
+	}
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// notificationSender 通知通道的发送实现；recipient 为 nil 表示不面向个人的通道（如 syslog）
+type notificationSender interface {
+	Send(ctx context.Context, ch model.NotificationChannel, recipient *model.NotificationRecipient, n model.Notification) error
+}
+
+func notificationText(n model.Notification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", n.Message)
+	fmt.Fprintf(&b, "级别: %s\n", n.Severity)
+	if n.RuleID != "" {
+		fmt.Fprintf(&b, "规则: %s\n", n.RuleID)
+	}
+	fmt.Fprintf(&b, "升级级别: %d\n", n.Level+1)
+	fmt.Fprintf(&b, "发生次数: %d\n", n.Occurrences)
+	fmt.Fprintf(&b, "首次发生: %s\n", n.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "通知 ID: %s\n", n.ID)
+	return b.String()
+}
+
+func channelTimeout(ctx context.Context, s string) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return notificationSendTimeout
+}
+
+type smtpSender struct{}
+
+func (smtpSender) Send(ctx context.Context, ch model.NotificationChannel, r *model.NotificationRecipient, n model.Notification) error {
+	cfg := ch.SMTP
+	if cfg == nil {
+		return fmt.Errorf("smtp config missing")
+	}
+	if r == nil || r.Email == "" {
+		return fmt.Errorf("recipient has no email address")
+	}
+	port := cfg.Port
+	if port == 0 {
+		switch cfg.TLS {
+		case "tls":
+			port = 465
+		case "starttls":
+			port = 587
+		default:
+			port = 25
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	timeout := channelTimeout(ctx, cfg.Timeout)
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if cfg.TLS == "starttls" {
+		if err := c.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(r.Email); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(n.Severity), n.Subject)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", r.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notificationText(n), "\n", "\r\n"))
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+type syslogSender struct{}
+
+// syslogSeverity 将告警级别映射为 RFC5424 severity
+func syslogSeverity(s string) int {
+	switch strings.ToLower(s) {
+	case "emergency":
+		return 0
+	case "alert":
+		return 1
+	case "critical":
+		return 2
+	case "error", "major":
+		return 3
+	case "notice":
+		return 5
+	case "info":
+		return 6
+	default:
+		return 4 // warning
+	}
+}
+
+// formatSyslog 按 RFC5424 组装消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+func formatSyslog(cfg model.SyslogConfig, n model.Notification, now time.Time) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "-"
+	}
+	app := cfg.AppName
+	if app == "" {
+		app = "edgex"
+	}
+	pri := cfg.Facility*8 + syslogSeverity(n.Severity)
+	msg := strings.ReplaceAll(fmt.Sprintf("%s: %s (rule=%s level=%d occurrences=%d id=%s)",
+		n.Subject, n.Message, n.RuleID, n.Level+1, n.Occurrences, n.ID), "\n", " ")
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		pri, now.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), host, app, os.Getpid(), "ALARM", msg)
+}
+
+func (syslogSender) Send(ctx context.Context, ch model.NotificationChannel, _ *model.NotificationRecipient, n model.Notification) error {
+	cfg := ch.Syslog
+	if cfg == nil {
+		return fmt.Errorf("syslog config missing")
+	}
+	network := cfg.Network
+	if network == "" {
+		network = "udp"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, cfg.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	msg := formatSyslog(*cfg, n, time.Now())
+	if network == "tcp" {
+		// RFC6587 octet counting 帧
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	_, err = conn.Write([]byte(msg))
+	return err
+}
+
+type webhookSender struct{}
+
+func (webhookSender) Send(ctx context.Context, ch model.NotificationChannel, r *model.NotificationRecipient, n model.Notification) error {
+	cfg := ch.Webhook
+	if cfg == nil {
+		return fmt.Errorf("webhook config missing")
+	}
+	url := cfg.URL
+	payload := map[string]any{
+		"id":          n.ID,
+		"policy_id":   n.PolicyID,
+		"rule_id":     n.RuleID,
+		"severity":    n.Severity,
+		"subject":     n.Subject,
+		"message":     n.Message,
+		"level":       n.Level + 1,
+		"occurrences": n.Occurrences,
+		"created_at":  n.CreatedAt,
+	}
+	if r != nil {
+		if r.Webhook != "" {
+			url = r.Webhook
+		}
+		payload["recipient"] = map[string]any{"id": r.ID, "name": r.Name, "email": r.Email}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	ctx, cancel := context.WithTimeout(ctx, channelTimeout(ctx, cfg.Timeout))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
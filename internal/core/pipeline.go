@@ -14,6 +14,7 @@ type DataPipeline struct {
 	handlers      []func(model.Value)
 	batchHandlers []func([]model.Value)
 	shadowIngress *ShadowIngress
+	timeQuality   func() string
 }
 
 func NewDataPipeline(bufferSize int) *DataPipeline {
@@ -39,6 +40,14 @@ func (dp *DataPipeline) SetShadowIngress(si *ShadowIngress) {
 	dp.shadowIngress = si
 }
 
+// SetTimeQualityFunc 注册时钟质量来源；返回非空时写入 Value.Meta["time_quality"]，
+// 供北向区分时钟未同步期间采集的数据。
+func (dp *DataPipeline) SetTimeQualityFunc(fn func() string) {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	dp.timeQuality = fn
+}
+
 func (dp *DataPipeline) Start() {
 	go func() {
 		for range dp.signalChan {
@@ -57,7 +66,14 @@ func (dp *DataPipeline) PushBatch(vals []model.Value) {
 	}
 
 	dp.mu.Lock()
+	quality := ""
+	if dp.timeQuality != nil {
+		quality = dp.timeQuality()
+	}
 	for _, val := range vals {
+		if quality != "" {
+			val.Meta = withTimeQuality(val.Meta, quality)
+		}
 		key := val.ChannelID + "/" + val.DeviceID + "/" + val.PointID
 		buf := dp.pointBuf[key]
 		if len(buf) >= 2 {
@@ -136,3 +152,13 @@ func (dp *DataPipeline) processValue(val model.Value, handlers []func(model.Valu
 		h(val)
 	}
 }
+
+// withTimeQuality 复制 meta 后写入时间质量，避免修改驱动共享的 map。
+func withTimeQuality(meta map[string]any, quality string) map[string]any {
+	out := make(map[string]any, len(meta)+1)
+	for k, v := range meta {
+		out[k] = v
+	}
+	out["time_quality"] = quality
+	return out
+}
@@ -0,0 +1,988 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+const (
+	defaultRecipePollInterval = time.Second
+	maxRecipeRunHistory       = 200
+	maxPersistedRecipeRuns    = 5000
+	recipeSafeStateTimeout    = 10 * time.Second
+	recipeStopTimeout         = 30 * time.Second
+)
+
+var (
+	recipeParamRef  = regexp.MustCompile(`^\$\{(\w+)\}$`)
+	recipeParamName = regexp.MustCompile(`^\w+$`)
+)
+
+// RecipeManager 管理配方定义并执行配方（ISA-88 简化）：每次运行在独立的 goroutine 中按顺序执行步骤，
+// 支持保持/恢复/中止，中止或失败时执行安全态步骤。写入经 ChannelManager.WritePoint，受写联锁约束。
+type RecipeManager struct {
+	mu       sync.Mutex
+	recipes  map[string]model.Recipe
+	saveFunc func([]model.Recipe) error
+	writer   DeviceIO
+	values   func(channelID, deviceID, pointID string) (model.Value, bool)
+	store    *storage.Storage
+
+	active   map[string]*recipeRunner // Run ID -> running, holding, held or aborting run
+	finished []*model.RecipeRun       // Newest last
+
+	now  func() time.Time
+	poll time.Duration
+}
+
+func NewRecipeManager(store *storage.Storage, saveFunc func([]model.Recipe) error) *RecipeManager {
+	return &RecipeManager{
+		recipes:  make(map[string]model.Recipe),
+		saveFunc: saveFunc,
+		store:    store,
+		active:   make(map[string]*recipeRunner),
+		now:      time.Now,
+		poll:     defaultRecipePollInterval,
+	}
+}
+
+// SetDeviceIO 设置写入步骤使用的设备写入接口
+func (rm *RecipeManager) SetDeviceIO(w DeviceIO) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.writer = w
+}
+
+// SetValueSource 设置等待步骤读取点位当前值的来源（影子或规则值缓存）
+func (rm *RecipeManager) SetValueSource(f func(channelID, deviceID, pointID string) (model.Value, bool)) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.values = f
+}
+
+func (rm *RecipeManager) Load(recipes []model.Recipe) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.recipes = make(map[string]model.Recipe, len(recipes))
+	for _, r := range recipes {
+		rm.recipes[r.ID] = r
+	}
+}
+
+// Start 恢复最近的运行记录；上次进程退出时仍在运行的记录标记为中止
+func (rm *RecipeManager) Start() {
+	if rm.store == nil {
+		return
+	}
+	var runs []*model.RecipeRun
+	_ = rm.store.LoadLatest(storage.BucketRecipeRuns, maxRecipeRunHistory, func(k, v []byte) error {
+		var run model.RecipeRun
+		if err := json.Unmarshal(v, &run); err == nil {
+			runs = append(runs, &run)
+		}
+		return nil
+	})
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	for _, run := range runs {
+		if run.State != "completed" && run.State != "aborted" {
+			now := rm.now()
+			run.State = "aborted"
+			run.EndedAt = now
+			run.Error = "网关重启，运行中断（未执行安全态步骤）"
+			run.Events = append(run.Events, model.RecipeRunEvent{TS: now, Event: "aborted", Message: run.Error})
+			rm.saveRun(*run)
+		}
+	}
+	rm.mu.Lock()
+	rm.finished = runs
+	rm.mu.Unlock()
+}
+
+// Stop 中止全部运行中的配方并等待安全态步骤完成
+func (rm *RecipeManager) Stop() {
+	rm.mu.Lock()
+	runners := make([]*recipeRunner, 0, len(rm.active))
+	for _, r := range rm.active {
+		runners = append(runners, r)
+	}
+	rm.mu.Unlock()
+	for _, r := range runners {
+		_ = rm.Abort(r.run.ID, "system")
+	}
+	deadline := time.After(recipeStopTimeout)
+	for _, r := range runners {
+		select {
+		case <-r.done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+func (rm *RecipeManager) List() []model.Recipe {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	out := make([]model.Recipe, 0, len(rm.recipes))
+	for _, r := range rm.recipes {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (rm *RecipeManager) Get(id string) (model.Recipe, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	r, ok := rm.recipes[id]
+	return r, ok
+}
+
+func validateRecipe(r model.Recipe) error {
+	if r.ID == "" {
+		return fmt.Errorf("配方 ID 无效")
+	}
+	if len(r.Steps) == 0 {
+		return fmt.Errorf("配方步骤无效: 至少需要一个步骤")
+	}
+	params := make(map[string]struct{}, len(r.Parameters))
+	for _, p := range r.Parameters {
+		if !recipeParamName.MatchString(p.Name) {
+			return fmt.Errorf("配方参数名无效: %q", p.Name)
+		}
+		if _, ok := params[p.Name]; ok {
+			return fmt.Errorf("配方参数名重复: %q", p.Name)
+		}
+		params[p.Name] = struct{}{}
+		switch p.Type {
+		case "", "number", "string", "bool":
+		default:
+			return fmt.Errorf("配方参数 %s 类型无效: %s", p.Name, p.Type)
+		}
+		if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+			return fmt.Errorf("配方参数 %s 范围无效: min > max", p.Name)
+		}
+		if p.Default != nil {
+			if _, err := coerceRecipeParam(p, p.Default); err != nil {
+				return fmt.Errorf("配方参数 %s 默认值无效: %v", p.Name, err)
+			}
+		}
+	}
+	for i, s := range r.Steps {
+		if err := validateRecipeStep(s, false); err != nil {
+			return fmt.Errorf("配方步骤 %d (%s) 无效: %v", i+1, s.Name, err)
+		}
+	}
+	for i, s := range r.SafeState {
+		if err := validateRecipeStep(s, true); err != nil {
+			return fmt.Errorf("安全态步骤 %d (%s) 无效: %v", i+1, s.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateRecipeStep(s model.RecipeStep, safeState bool) error {
+	switch s.Type {
+	case "write":
+		if s.ChannelID == "" || s.DeviceID == "" || s.PointID == "" {
+			return fmt.Errorf("channel_id、device_id、point_id 不能为空")
+		}
+		if s.Value == nil && s.Expression == "" {
+			return fmt.Errorf("value 与 expression 不能同时为空")
+		}
+	case "wait":
+		if safeState {
+			return fmt.Errorf("安全态只支持 write 与 timer 步骤")
+		}
+		if strings.TrimSpace(s.Condition) == "" {
+			return fmt.Errorf("condition 不能为空")
+		}
+		seen := make(map[string]struct{}, len(s.Sources))
+		for _, src := range s.Sources {
+			if src.Alias == "" || src.ChannelID == "" || src.DeviceID == "" || src.PointID == "" {
+				return fmt.Errorf("数据源 alias 与点位不能为空")
+			}
+			if _, ok := seen[src.Alias]; ok {
+				return fmt.Errorf("数据源别名重复: %q", src.Alias)
+			}
+			seen[src.Alias] = struct{}{}
+		}
+		for _, d := range []string{s.Interval, s.Timeout} {
+			if err := checkRecipeDuration(d); err != nil {
+				return err
+			}
+		}
+		switch s.OnTimeout {
+		case "", "abort", "hold", "continue":
+		default:
+			return fmt.Errorf("on_timeout 只能为 abort、hold 或 continue")
+		}
+	case "timer":
+		if s.Duration == "" {
+			return fmt.Errorf("duration 不能为空")
+		}
+		if err := checkRecipeDuration(s.Duration); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("不支持的步骤类型: %s", s.Type)
+	}
+	return nil
+}
+
+// checkRecipeDuration 校验时长；含参数引用时在运行时解析
+func checkRecipeDuration(s string) error {
+	if s == "" || strings.Contains(s, "${") {
+		return nil
+	}
+	if _, err := parseRecipeDuration(s); err != nil {
+		return fmt.Errorf("时长 %q 无效", s)
+	}
+	return nil
+}
+
+// parseRecipeDuration 解析 Go 时长，纯数字按秒计
+func parseRecipeDuration(s string) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, fmt.Errorf("negative duration")
+	}
+	return d, err
+}
+
+func (rm *RecipeManager) Upsert(r model.Recipe) error {
+	if err := validateRecipe(r); err != nil {
+		return err
+	}
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.recipes[r.ID] = r
+	return rm.persistLocked()
+}
+
+// Delete 删除配方定义；已开始的运行使用启动时的定义快照，不受影响
+func (rm *RecipeManager) Delete(id string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if _, ok := rm.recipes[id]; !ok {
+		return fmt.Errorf("recipe not found")
+	}
+	delete(rm.recipes, id)
+	return rm.persistLocked()
+}
+
+func (rm *RecipeManager) persistLocked() error {
+	if rm.saveFunc == nil {
+		return nil
+	}
+	out := make([]model.Recipe, 0, len(rm.recipes))
+	for _, r := range rm.recipes {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return rm.saveFunc(out)
+}
+
+// coerceRecipeParam 按参数类型转换并检查范围
+func coerceRecipeParam(p model.RecipeParameter, v any) (any, error) {
+	switch p.Type {
+	case "string":
+		return fmt.Sprint(v), nil
+	case "bool":
+		switch t := v.(type) {
+		case bool:
+			return t, nil
+		case string:
+			return strconv.ParseBool(t)
+		}
+		return nil, fmt.Errorf("需要布尔值")
+	default:
+		f, ok := toFloat(v)
+		if !ok {
+			s, isStr := v.(string)
+			if !isStr {
+				return nil, fmt.Errorf("需要数值")
+			}
+			var err error
+			if f, err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+				return nil, fmt.Errorf("需要数值")
+			}
+		}
+		if p.Min != nil && f < *p.Min {
+			return nil, fmt.Errorf("%v 小于下限 %v", f, *p.Min)
+		}
+		if p.Max != nil && f > *p.Max {
+			return nil, fmt.Errorf("%v 大于上限 %v", f, *p.Max)
+		}
+		return f, nil
+	}
+}
+
+// resolveRecipeParams 合并默认值并校验启动参数
+func resolveRecipeParams(r model.Recipe, given map[string]any) (map[string]any, error) {
+	declared := make(map[string]model.RecipeParameter, len(r.Parameters))
+	for _, p := range r.Parameters {
+		declared[p.Name] = p
+	}
+	for name := range given {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("配方参数无效: 未定义参数 %s", name)
+		}
+	}
+	params := make(map[string]any, len(r.Parameters))
+	for _, p := range r.Parameters {
+		v, ok := given[p.Name]
+		if !ok || v == nil {
+			if p.Default == nil {
+				if p.Required {
+					return nil, fmt.Errorf("配方参数无效: 缺少必填参数 %s", p.Name)
+				}
+				continue
+			}
+			v = p.Default
+		}
+		cv, err := coerceRecipeParam(p, v)
+		if err != nil {
+			return nil, fmt.Errorf("配方参数 %s 无效: %v", p.Name, err)
+		}
+		params[p.Name] = cv
+	}
+	return params, nil
+}
+
+// StartRun 以给定参数启动配方，返回运行记录；同一单元同时只能运行一个配方
+func (rm *RecipeManager) StartRun(recipeID string, params map[string]any, user string) (model.RecipeRun, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	recipe, ok := rm.recipes[recipeID]
+	if !ok {
+		return model.RecipeRun{}, fmt.Errorf("recipe not found")
+	}
+	if rm.writer == nil {
+		return model.RecipeRun{}, fmt.Errorf("设备写入不可用")
+	}
+	resolved, err := resolveRecipeParams(recipe, params)
+	if err != nil {
+		return model.RecipeRun{}, err
+	}
+	unit := recipe.Unit
+	if unit == "" {
+		unit = recipe.ID
+	}
+	for _, r := range rm.active {
+		if r.run.Unit == unit {
+			return model.RecipeRun{}, fmt.Errorf("单元 %s 正在运行配方 %s (%s)", unit, r.run.RecipeID, r.run.ID)
+		}
+	}
+
+	now := rm.now()
+	run := &model.RecipeRun{
+		ID:          fmt.Sprintf("run-%d", now.UnixNano()),
+		RecipeID:    recipe.ID,
+		RecipeName:  recipe.Name,
+		Unit:        unit,
+		Recipe:      recipe,
+		Params:      resolved,
+		State:       "running",
+		StartedBy:   user,
+		StartedAt:   now,
+		CurrentStep: -1,
+		Events:      []model.RecipeRunEvent{{TS: now, Event: "start", User: user}},
+	}
+	for _, s := range recipe.Steps {
+		run.Steps = append(run.Steps, model.RecipeStepStatus{Name: s.Name, Type: s.Type, State: "pending"})
+	}
+	for _, s := range recipe.SafeState {
+		run.SafeState = append(run.SafeState, model.RecipeStepStatus{Name: s.Name, Type: s.Type, State: "pending"})
+	}
+
+	env := make(map[string]any, len(resolved)+2)
+	for k, v := range resolved {
+		env[k] = v
+	}
+	env["recipe_id"] = recipe.ID
+	env["run_id"] = run.ID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &recipeRunner{
+		rm:       rm,
+		run:      run,
+		env:      env,
+		writer:   rm.writer,
+		values:   rm.values,
+		ctx:      ctx,
+		cancel:   cancel,
+		holdCh:   make(chan struct{}),
+		resumeCh: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	rm.active[run.ID] = r
+	snapshot := cloneRecipeRun(run)
+	go func() {
+		rm.saveRun(snapshot)
+		r.execute()
+	}()
+	log.Printf("[Recipe] run %s of %s started by %s", run.ID, recipe.ID, user)
+	return snapshot, nil
+}
+
+// Hold 请求保持：写入步骤完成后、计时与等待步骤立即暂停（剩余时间保留）
+func (rm *RecipeManager) Hold(runID, user string) error {
+	return rm.control(runID, func(r *recipeRunner) error {
+		if r.run.State != "running" {
+			return fmt.Errorf("配方运行 %s 当前状态 %s 不允许保持", runID, r.run.State)
+		}
+		r.requestHoldLocked(user, "")
+		return nil
+	})
+}
+
+// Resume 从保持状态恢复运行
+func (rm *RecipeManager) Resume(runID, user string) error {
+	return rm.control(runID, func(r *recipeRunner) error {
+		if r.run.State != "holding" && r.run.State != "held" {
+			return fmt.Errorf("配方运行 %s 当前状态 %s 不允许恢复", runID, r.run.State)
+		}
+		r.run.State = "running"
+		r.eventLocked("resume", user, "")
+		r.holdCh = make(chan struct{})
+		close(r.resumeCh)
+		return nil
+	})
+}
+
+// Abort 中止运行：停止当前步骤并执行安全态步骤
+func (rm *RecipeManager) Abort(runID, user string) error {
+	return rm.control(runID, func(r *recipeRunner) error {
+		if r.run.State == "aborting" {
+			return fmt.Errorf("配方运行 %s 当前状态 %s 不允许中止", runID, r.run.State)
+		}
+		r.run.State = "aborting"
+		r.eventLocked("abort", user, "")
+		r.cancel()
+		return nil
+	})
+}
+
+func (rm *RecipeManager) control(runID string, fn func(r *recipeRunner) error) error {
+	rm.mu.Lock()
+	r, ok := rm.active[runID]
+	if !ok {
+		rm.mu.Unlock()
+		if _, err := rm.GetRun(runID); err == nil {
+			return fmt.Errorf("配方运行 %s 已结束，不允许操作", runID)
+		}
+		return fmt.Errorf("recipe run not found")
+	}
+	if err := fn(r); err != nil {
+		rm.mu.Unlock()
+		return err
+	}
+	snapshot := cloneRecipeRun(r.run)
+	rm.mu.Unlock()
+	rm.saveRun(snapshot)
+	return nil
+}
+
+// GetRun 返回运行记录（含实时步骤状态）；内存中没有时从运行时库读取
+func (rm *RecipeManager) GetRun(runID string) (model.RecipeRun, error) {
+	rm.mu.Lock()
+	if r, ok := rm.active[runID]; ok {
+		out := cloneRecipeRun(r.run)
+		rm.mu.Unlock()
+		fillRecipeRemaining(&out, rm.now())
+		return out, nil
+	}
+	for _, run := range rm.finished {
+		if run.ID == runID {
+			out := cloneRecipeRun(run)
+			rm.mu.Unlock()
+			return out, nil
+		}
+	}
+	rm.mu.Unlock()
+	if rm.store != nil {
+		var run model.RecipeRun
+		if err := rm.store.GetData(storage.BucketRecipeRuns, runID, &run); err == nil && run.ID != "" {
+			return run, nil
+		}
+	}
+	return model.RecipeRun{}, fmt.Errorf("recipe run not found")
+}
+
+// ListRuns 返回运行记录，新的在前；recipeID 为空时返回全部配方，activeOnly 只返回未结束的运行
+func (rm *RecipeManager) ListRuns(recipeID string, activeOnly bool, limit int) []model.RecipeRun {
+	if limit <= 0 {
+		limit = 100
+	}
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	now := rm.now()
+	out := make([]model.RecipeRun, 0)
+	for _, r := range rm.active {
+		if recipeID == "" || r.run.RecipeID == recipeID {
+			run := cloneRecipeRun(r.run)
+			fillRecipeRemaining(&run, now)
+			out = append(out, run)
+		}
+	}
+	if !activeOnly {
+		for _, run := range rm.finished {
+			if recipeID == "" || run.RecipeID == recipeID {
+				out = append(out, cloneRecipeRun(run))
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (rm *RecipeManager) saveRun(run model.RecipeRun) {
+	if rm.store == nil {
+		return
+	}
+	if err := rm.store.SaveData(storage.BucketRecipeRuns, run.ID, run); err != nil {
+		log.Printf("[Recipe] Failed to persist run %s: %v", run.ID, err)
+	}
+}
+
+// finishRun 把运行从活动列表移入历史并持久化
+func (rm *RecipeManager) finishRun(r *recipeRunner) {
+	rm.mu.Lock()
+	delete(rm.active, r.run.ID)
+	rm.finished = append(rm.finished, r.run)
+	if len(rm.finished) > maxRecipeRunHistory {
+		rm.finished = rm.finished[len(rm.finished)-maxRecipeRunHistory:]
+	}
+	snapshot := cloneRecipeRun(r.run)
+	rm.mu.Unlock()
+	rm.saveRun(snapshot)
+	if rm.store != nil {
+		_ = rm.store.PruneOldest(storage.BucketRecipeRuns, maxPersistedRecipeRuns)
+	}
+	log.Printf("[Recipe] run %s of %s %s %s", snapshot.ID, snapshot.RecipeID, snapshot.State, snapshot.Error)
+}
+
+func cloneRecipeRun(run *model.RecipeRun) model.RecipeRun {
+	out := *run
+	out.Steps = append([]model.RecipeStepStatus(nil), run.Steps...)
+	out.SafeState = append([]model.RecipeStepStatus(nil), run.SafeState...)
+	out.Events = append([]model.RecipeRunEvent(nil), run.Events...)
+	return out
+}
+
+func fillRecipeRemaining(run *model.RecipeRun, now time.Time) {
+	for i := range run.Steps {
+		if d := run.Steps[i].Deadline; !d.IsZero() && run.Steps[i].State == "running" {
+			if left := d.Sub(now); left > 0 {
+				run.Steps[i].RemainingMs = left.Milliseconds()
+			} else {
+				run.Steps[i].RemainingMs = 0
+			}
+		}
+	}
+}
+
+// recipeRunner 执行一次配方运行；run 的字段由 rm.mu 保护
+type recipeRunner struct {
+	rm     *RecipeManager
+	run    *model.RecipeRun
+	env    map[string]any
+	writer DeviceIO
+	values func(channelID, deviceID, pointID string) (model.Value, bool)
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	holdCh   chan struct{} // Closed when a hold is requested
+	resumeCh chan struct{} // Closed when the run resumes
+	done     chan struct{}
+}
+
+func (r *recipeRunner) eventLocked(event, user, msg string) {
+	r.run.Events = append(r.run.Events, model.RecipeRunEvent{TS: r.rm.now(), Event: event, User: user, Message: msg})
+}
+
+func (r *recipeRunner) requestHoldLocked(user, msg string) {
+	r.run.State = "holding"
+	r.eventLocked("hold", user, msg)
+	r.resumeCh = make(chan struct{})
+	close(r.holdCh)
+}
+
+// update 在锁内修改运行记录；persist 为 true 时随后持久化
+func (r *recipeRunner) update(persist bool, fn func(run *model.RecipeRun)) {
+	r.rm.mu.Lock()
+	fn(r.run)
+	snapshot := cloneRecipeRun(r.run)
+	r.rm.mu.Unlock()
+	if persist {
+		r.rm.saveRun(snapshot)
+	}
+}
+
+func (r *recipeRunner) execute() {
+	defer close(r.done)
+	defer r.cancel()
+
+	var failure error
+	for i := range r.run.Recipe.Steps {
+		if err := r.checkpoint(i); err != nil {
+			failure = err
+			break
+		}
+		if err := r.runStep(i); err != nil {
+			failure = err
+			break
+		}
+	}
+
+	if failure == nil {
+		r.update(false, func(run *model.RecipeRun) {
+			run.State = "completed"
+			run.EndedAt = r.rm.now()
+			r.eventLocked("completed", "", "")
+		})
+		r.rm.finishRun(r)
+		return
+	}
+
+	r.update(true, func(run *model.RecipeRun) {
+		if r.ctx.Err() == nil {
+			run.Error = failure.Error()
+			r.eventLocked("step_failed", "", failure.Error())
+		}
+		run.State = "aborting"
+	})
+	r.runSafeState()
+	r.update(false, func(run *model.RecipeRun) {
+		run.State = "aborted"
+		run.EndedAt = r.rm.now()
+		r.eventLocked("aborted", "", run.Error)
+	})
+	r.rm.finishRun(r)
+}
+
+// checkpoint 处于保持请求时进入 held 并等待恢复或中止
+func (r *recipeRunner) checkpoint(step int) error {
+	r.rm.mu.Lock()
+	if r.run.State != "holding" && r.run.State != "held" {
+		r.rm.mu.Unlock()
+		return r.ctx.Err()
+	}
+	if r.run.State == "holding" {
+		r.run.State = "held"
+		r.eventLocked("held", "", "")
+	}
+	if step >= 0 && step < len(r.run.Steps) && r.run.Steps[step].State == "running" {
+		r.run.Steps[step].State = "held"
+	}
+	resume := r.resumeCh
+	snapshot := cloneRecipeRun(r.run)
+	r.rm.mu.Unlock()
+	r.rm.saveRun(snapshot)
+
+	select {
+	case <-resume:
+		r.update(false, func(run *model.RecipeRun) {
+			if step >= 0 && step < len(run.Steps) && run.Steps[step].State == "held" {
+				run.Steps[step].State = "running"
+			}
+		})
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
+
+// sleep 等待 d 的有效运行时间；保持期间暂停计时，恢复后继续剩余时间
+func (r *recipeRunner) sleep(step int, d time.Duration, countdown bool) error {
+	remaining := d
+	for remaining > 0 {
+		r.rm.mu.Lock()
+		hold := r.holdCh
+		if countdown {
+			r.run.Steps[step].Deadline = r.rm.now().Add(remaining)
+			r.run.Steps[step].RemainingMs = remaining.Milliseconds()
+		}
+		r.rm.mu.Unlock()
+
+		start := time.Now()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-timer.C:
+			remaining = 0
+		case <-hold:
+			timer.Stop()
+			remaining -= time.Since(start)
+			if countdown {
+				r.update(false, func(run *model.RecipeRun) {
+					run.Steps[step].Deadline = time.Time{}
+					run.Steps[step].RemainingMs = remaining.Milliseconds()
+				})
+			}
+			if err := r.checkpoint(step); err != nil {
+				return err
+			}
+		case <-r.ctx.Done():
+			timer.Stop()
+			return r.ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (r *recipeRunner) runStep(i int) error {
+	step := r.run.Recipe.Steps[i]
+	r.update(true, func(run *model.RecipeRun) {
+		run.CurrentStep = i
+		run.Steps[i].State = "running"
+		run.Steps[i].StartedAt = r.rm.now()
+	})
+
+	var value any
+	var err error
+	state := "completed"
+	switch step.Type {
+	case "write":
+		value, err = r.write(step)
+	case "wait":
+		var timedOut bool
+		timedOut, err = r.wait(i, step)
+		if timedOut {
+			state = "timeout"
+		}
+	case "timer":
+		var d time.Duration
+		if d, err = parseRecipeDuration(expandRecipeString(step.Duration, r.env)); err == nil {
+			err = r.sleep(i, d, true)
+		}
+	}
+
+	r.update(true, func(run *model.RecipeRun) {
+		st := &run.Steps[i]
+		st.EndedAt = r.rm.now()
+		st.Deadline, st.RemainingMs = time.Time{}, 0
+		switch {
+		case err == nil:
+			st.State = state
+			if value != nil {
+				st.Value = value
+			}
+		case r.ctx.Err() != nil:
+			st.State = "aborted"
+		default:
+			st.State = "failed"
+			st.Message = err.Error()
+		}
+	})
+	return err
+}
+
+func (r *recipeRunner) write(step model.RecipeStep) (any, error) {
+	var value any
+	if step.Expression != "" {
+		env := make(map[string]any, len(r.env))
+		for k, v := range r.env {
+			env[k] = v
+		}
+		v, err := evaluateCalculation(step.Expression, env)
+		if err != nil {
+			return nil, fmt.Errorf("表达式计算失败: %v", err)
+		}
+		value = v
+	} else {
+		value = resolveRecipeValue(step.Value, r.env)
+	}
+	if err := r.writer.WritePoint(step.ChannelID, step.DeviceID, step.PointID, value); err != nil {
+		return nil, fmt.Errorf("写入 %s/%s/%s 失败: %w", step.ChannelID, step.DeviceID, step.PointID, err)
+	}
+	return value, nil
+}
+
+// wait 轮询条件直到成立；超时按 on_timeout 处理，continue 时返回 timedOut
+func (r *recipeRunner) wait(i int, step model.RecipeStep) (bool, error) {
+	interval := r.rm.poll
+	if step.Interval != "" {
+		if d, err := parseRecipeDuration(expandRecipeString(step.Interval, r.env)); err == nil && d > 0 {
+			interval = d
+		}
+	}
+	var timeout time.Duration
+	if step.Timeout != "" {
+		d, err := parseRecipeDuration(expandRecipeString(step.Timeout, r.env))
+		if err != nil {
+			return false, fmt.Errorf("timeout 无效: %v", err)
+		}
+		timeout = d
+	}
+
+	for {
+		remaining := timeout
+		for {
+			ok, values, msg, err := r.evaluateCondition(step)
+			if err != nil {
+				return false, err
+			}
+			r.update(false, func(run *model.RecipeRun) {
+				run.Steps[i].Value = values
+				run.Steps[i].Message = msg
+				if timeout > 0 {
+					run.Steps[i].Deadline = r.rm.now().Add(remaining)
+				}
+			})
+			if ok {
+				return false, nil
+			}
+			if timeout > 0 && remaining <= 0 {
+				break
+			}
+			d := interval
+			if timeout > 0 && remaining < d {
+				d = remaining
+			}
+			if err := r.sleep(i, d, false); err != nil {
+				return false, err
+			}
+			remaining -= d
+		}
+
+		switch step.OnTimeout {
+		case "continue":
+			return true, nil
+		case "hold":
+			r.update(true, func(run *model.RecipeRun) {
+				r.requestHoldLocked("", fmt.Sprintf("步骤 %s 等待超时", step.Name))
+			})
+			if err := r.checkpoint(i); err != nil {
+				return false, err
+			}
+			// 操作员恢复后重新计时等待
+		default:
+			return false, fmt.Errorf("等待条件超时 (%s): %s", timeout, step.Condition)
+		}
+	}
+}
+
+// evaluateCondition 读取数据源当前值并求值条件；数据缺失或质量差时视为不满足
+func (r *recipeRunner) evaluateCondition(step model.RecipeStep) (bool, map[string]any, string, error) {
+	env := make(map[string]any, len(r.env)+len(step.Sources))
+	for k, v := range r.env {
+		env[k] = v
+	}
+	values := make(map[string]any, len(step.Sources))
+	for _, src := range step.Sources {
+		if r.values == nil {
+			return false, values, "数据源不可用", nil
+		}
+		v, ok := r.values(src.ChannelID, src.DeviceID, src.PointID)
+		if !ok || v.Value == nil {
+			return false, values, fmt.Sprintf("数据源 %s 无数据", src.Alias), nil
+		}
+		if v.Quality != "" && v.Quality != "Good" {
+			return false, values, fmt.Sprintf("数据源 %s 质量异常 (%s)", src.Alias, v.Quality), nil
+		}
+		val := v.Value
+		if f, ok := toFloat(val); ok {
+			val = f
+		}
+		env[src.Alias] = val
+		values[src.Alias] = val
+	}
+	ok, err := evaluateThreshold(step.Condition, env)
+	if err != nil {
+		return false, values, "", fmt.Errorf("条件表达式错误: %v", err)
+	}
+	if ok {
+		return true, values, "条件已满足", nil
+	}
+	return false, values, "条件未满足", nil
+}
+
+// runSafeState 依次执行安全态步骤；单个步骤失败不影响后续步骤
+func (r *recipeRunner) runSafeState() {
+	steps := r.run.Recipe.SafeState
+	if len(steps) == 0 {
+		return
+	}
+	r.update(true, func(run *model.RecipeRun) {
+		r.eventLocked("safe_state", "", "")
+	})
+	for i, step := range steps {
+		r.update(false, func(run *model.RecipeRun) {
+			run.SafeState[i].State = "running"
+			run.SafeState[i].StartedAt = r.rm.now()
+		})
+		var value any
+		var err error
+		switch step.Type {
+		case "write":
+			value, err = r.write(step)
+		case "timer":
+			var d time.Duration
+			if d, err = parseRecipeDuration(expandRecipeString(step.Duration, r.env)); err == nil {
+				if d > recipeSafeStateTimeout {
+					d = recipeSafeStateTimeout
+				}
+				time.Sleep(d)
+			}
+		}
+		if err != nil {
+			log.Printf("[Recipe] run %s safe-state step %s failed: %v", r.run.ID, step.Name, err)
+		}
+		r.update(true, func(run *model.RecipeRun) {
+			st := &run.SafeState[i]
+			st.EndedAt = r.rm.now()
+			if err != nil {
+				st.State = "failed"
+				st.Message = err.Error()
+				return
+			}
+			st.State = "completed"
+			st.Value = value
+		})
+	}
+}
+
+// resolveRecipeValue 解析写入值：整串为 ${param} 时保留参数类型，其余按字符串模板展开
+func resolveRecipeValue(v any, env map[string]any) any {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	if m := recipeParamRef.FindStringSubmatch(s); m != nil {
+		if pv, ok := env[m[1]]; ok {
+			return pv
+		}
+	}
+	return expandRecipeString(s, env)
+}
+
+func expandRecipeString(s string, env map[string]any) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return os.Expand(s, func(k string) string {
+		if v, ok := env[k]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+		return ""
+	})
+}
@@ -0,0 +1,328 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// recipeTestIO 记录写入，并把写入值回显为点位当前值
+type recipeTestIO struct {
+	mu     sync.Mutex
+	writes []string
+	values map[string]any
+	fail   map[string]error
+}
+
+func (io *recipeTestIO) WritePoint(channelID, deviceID, pointID string, value any) error {
+	io.mu.Lock()
+	defer io.mu.Unlock()
+	if err := io.fail[pointID]; err != nil {
+		return err
+	}
+	io.writes = append(io.writes, fmt.Sprintf("%s=%v", pointID, value))
+	io.values[pointID] = value
+	return nil
+}
+
+func (io *recipeTestIO) ReadPoint(channelID, deviceID, pointID string) (model.Value, error) {
+	return model.Value{}, fmt.Errorf("not supported")
+}
+
+func (io *recipeTestIO) value(channelID, deviceID, pointID string) (model.Value, bool) {
+	io.mu.Lock()
+	defer io.mu.Unlock()
+	v, ok := io.values[pointID]
+	return model.Value{Value: v, Quality: "Good"}, ok
+}
+
+func (io *recipeTestIO) set(pointID string, v any) {
+	io.mu.Lock()
+	defer io.mu.Unlock()
+	io.values[pointID] = v
+}
+
+func (io *recipeTestIO) written() string {
+	io.mu.Lock()
+	defer io.mu.Unlock()
+	return strings.Join(io.writes, ",")
+}
+
+func newTestRecipes(t *testing.T, r model.Recipe) (*RecipeManager, *recipeTestIO) {
+	t.Helper()
+	io := &recipeTestIO{values: map[string]any{}, fail: map[string]error{}}
+	rm := NewRecipeManager(nil, nil)
+	rm.SetDeviceIO(io)
+	rm.SetValueSource(io.value)
+	rm.poll = 5 * time.Millisecond
+	if err := rm.Upsert(r); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	return rm, io
+}
+
+func recipeWrite(name, point string, value any) model.RecipeStep {
+	return model.RecipeStep{Name: name, Type: "write", ChannelID: "ch", DeviceID: "reactor", PointID: point, Value: value}
+}
+
+func heatRecipe() model.Recipe {
+	lo, hi := 20.0, 90.0
+	return model.Recipe{
+		ID: "heat", Name: "Heat and soak", Unit: "reactor1",
+		Parameters: []model.RecipeParameter{
+			{Name: "setpoint", Required: true, Min: &lo, Max: &hi},
+			{Name: "soak", Type: "string", Default: "20ms"},
+		},
+		Steps: []model.RecipeStep{
+			recipeWrite("set temperature", "SP", "${setpoint}"),
+			recipeWrite("heater on", "heater", 1),
+			{
+				Name: "reach temperature", Type: "wait",
+				Sources:   []model.RuleSource{{Alias: "pv", ChannelID: "ch", DeviceID: "reactor", PointID: "PV"}},
+				Condition: "pv >= setpoint - 1", Timeout: "1s",
+			},
+			{Name: "soak", Type: "timer", Duration: "${soak}"},
+			recipeWrite("heater off", "heater", 0),
+		},
+		SafeState: []model.RecipeStep{recipeWrite("heater off", "heater", 0)},
+	}
+}
+
+func waitRecipeState(t *testing.T, rm *RecipeManager, runID string, states ...string) model.RecipeRun {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		run, err := rm.GetRun(runID)
+		if err != nil {
+			t.Fatalf("GetRun: %v", err)
+		}
+		for _, s := range states {
+			if run.State == s {
+				return run
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run stuck in %s, want %v: %+v", run.State, states, run.Steps)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestRecipeValidation(t *testing.T) {
+	rm, _ := newTestRecipes(t, heatRecipe())
+
+	bad := heatRecipe()
+	bad.SafeState = append(bad.SafeState, model.RecipeStep{Name: "w", Type: "wait", Condition: "true"})
+	if err := rm.Upsert(bad); err == nil {
+		t.Fatal("wait steps should be rejected in the safe state")
+	}
+	bad = heatRecipe()
+	bad.Steps[3].Duration = "soon"
+	if err := rm.Upsert(bad); err == nil {
+		t.Fatal("invalid timer duration should be rejected")
+	}
+
+	cases := []map[string]any{
+		{},                                // missing required setpoint
+		{"setpoint": 95.0},                // above max
+		{"setpoint": "hot"},               // not a number
+		{"setpoint": 50.0, "speed": 10.0}, // undeclared
+	}
+	for _, params := range cases {
+		if _, err := rm.StartRun("heat", params, "alice"); err == nil || !strings.Contains(err.Error(), "无效") {
+			t.Fatalf("params %v: expected invalid parameter error, got %v", params, err)
+		}
+	}
+	if _, err := rm.StartRun("missing", nil, "alice"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRecipeRunCompletes(t *testing.T) {
+	rm, io := newTestRecipes(t, heatRecipe())
+	run, err := rm.StartRun("heat", map[string]any{"setpoint": "60"}, "alice")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if run.Params["setpoint"] != 60.0 || run.Params["soak"] != "20ms" {
+		t.Fatalf("unexpected resolved params: %v", run.Params)
+	}
+	if _, err := rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "bob"); err == nil || !strings.Contains(err.Error(), "正在运行") {
+		t.Fatalf("second run on the same unit should be rejected, got %v", err)
+	}
+
+	// The wait step holds until PV reaches the setpoint
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := rm.GetRun(run.ID); got.CurrentStep != 2 || got.Steps[2].State != "running" {
+		t.Fatalf("expected to be waiting on step 3: %+v", got)
+	}
+	io.set("PV", 59.5)
+
+	done := waitRecipeState(t, rm, run.ID, "completed", "aborted")
+	if done.State != "completed" || done.Error != "" {
+		t.Fatalf("expected completion, got %s: %s", done.State, done.Error)
+	}
+	if got := io.written(); got != "SP=60,heater=1,heater=0" {
+		t.Fatalf("unexpected writes: %s", got)
+	}
+	for _, st := range done.Steps {
+		if st.State != "completed" {
+			t.Fatalf("step %s ended in %s", st.Name, st.State)
+		}
+	}
+	if done.SafeState[0].State != "pending" {
+		t.Fatal("safe state should not run on completion")
+	}
+	if runs := rm.ListRuns("heat", false, 10); len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("run missing from history: %+v", runs)
+	}
+}
+
+func TestRecipeHoldResumeTimer(t *testing.T) {
+	r := model.Recipe{
+		ID: "soak",
+		Steps: []model.RecipeStep{
+			{Name: "soak", Type: "timer", Duration: "100ms"},
+			recipeWrite("done", "done", true),
+		},
+	}
+	rm, io := newTestRecipes(t, r)
+	run, err := rm.StartRun("soak", nil, "alice")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := rm.Hold(run.ID, "bob"); err != nil {
+		t.Fatalf("Hold: %v", err)
+	}
+	held := waitRecipeState(t, rm, run.ID, "held")
+	if held.Steps[0].State != "held" || held.Steps[0].RemainingMs <= 0 || held.Steps[0].RemainingMs > 80 {
+		t.Fatalf("timer should pause with remaining time kept: %+v", held.Steps[0])
+	}
+	if err := rm.Hold(run.ID, "bob"); err == nil {
+		t.Fatal("holding a held run should be rejected")
+	}
+
+	// Time spent in hold does not count against the timer
+	time.Sleep(150 * time.Millisecond)
+	if io.written() != "" {
+		t.Fatal("run must not advance while held")
+	}
+	if err := rm.Resume(run.ID, "bob"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	done := waitRecipeState(t, rm, run.ID, "completed", "aborted")
+	if done.State != "completed" || io.written() != "done=true" {
+		t.Fatalf("expected completion after resume, got %s, writes %s", done.State, io.written())
+	}
+	var events []string
+	for _, e := range done.Events {
+		events = append(events, e.Event)
+	}
+	if got := strings.Join(events, ","); got != "start,hold,held,resume,completed" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	if err := rm.Resume(run.ID, "bob"); err == nil || !strings.Contains(err.Error(), "不允许") {
+		t.Fatalf("controlling a finished run should be rejected, got %v", err)
+	}
+}
+
+func TestRecipeAbortRunsSafeState(t *testing.T) {
+	rm, io := newTestRecipes(t, heatRecipe())
+	run, err := rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := rm.Abort(run.ID, "bob"); err != nil {
+		t.Fatalf("Abort: %v", err)
+	}
+	done := waitRecipeState(t, rm, run.ID, "aborted", "completed")
+	if done.State != "aborted" || done.Steps[2].State != "aborted" || done.Steps[3].State != "pending" {
+		t.Fatalf("unexpected aborted run: %+v", done.Steps)
+	}
+	if done.SafeState[0].State != "completed" || io.written() != "SP=50,heater=1,heater=0" {
+		t.Fatalf("safe state not applied: %+v, writes %s", done.SafeState, io.written())
+	}
+
+	// The unit is free again once the run has ended
+	if _, err := rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice"); err != nil {
+		t.Fatalf("restart after abort: %v", err)
+	}
+	rm.Stop()
+}
+
+func TestRecipeWaitTimeoutAndWriteFailure(t *testing.T) {
+	r := heatRecipe()
+	r.Steps[2].Timeout = "30ms"
+	rm, io := newTestRecipes(t, r)
+	run, err := rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	done := waitRecipeState(t, rm, run.ID, "aborted", "completed")
+	if done.State != "aborted" || done.Steps[2].State != "failed" || !strings.Contains(done.Error, "超时") {
+		t.Fatalf("expected timeout abort, got %s: %s %+v", done.State, done.Error, done.Steps[2])
+	}
+	if done.SafeState[0].State != "completed" {
+		t.Fatalf("safe state should run after a failed step: %+v", done.SafeState)
+	}
+
+	// on_timeout=continue marks the step and moves on
+	r.Steps[2].OnTimeout = "continue"
+	if err := rm.Upsert(r); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	run, _ = rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice")
+	done = waitRecipeState(t, rm, run.ID, "aborted", "completed")
+	if done.State != "completed" || done.Steps[2].State != "timeout" {
+		t.Fatalf("expected completion with timed out wait, got %s %+v", done.State, done.Steps[2])
+	}
+
+	// A rejected write (e.g. interlock) fails the run
+	io.fail["heater"] = fmt.Errorf("interlocked")
+	run, _ = rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice")
+	done = waitRecipeState(t, rm, run.ID, "aborted", "completed")
+	if done.State != "aborted" || done.Steps[1].State != "failed" || done.SafeState[0].State != "failed" {
+		t.Fatalf("expected write failure abort: %+v %+v", done.Steps[1], done.SafeState)
+	}
+}
+
+// haGuardedIO 按 HA 写入保护拒绝备机上的写入
+type haGuardedIO struct {
+	*recipeTestIO
+	ham *HAManager
+}
+
+func (io haGuardedIO) WritePoint(channelID, deviceID, pointID string, value any) error {
+	if err := io.ham.WriteGuard(channelID, deviceID, pointID); err != nil {
+		return err
+	}
+	return io.recipeTestIO.WritePoint(channelID, deviceID, pointID, value)
+}
+
+func TestRecipeSafeStateWrittenBeforeHADemotion(t *testing.T) {
+	rm, io := newTestRecipes(t, heatRecipe())
+	ham := NewHAManager(model.HAConfig{Enabled: true}, HAHooks{BeforeDeactivate: rm.Stop})
+	ham.state = HAStateActive
+	rm.SetDeviceIO(haGuardedIO{recipeTestIO: io, ham: ham})
+
+	run, err := rm.StartRun("heat", map[string]any{"setpoint": 50.0}, "alice")
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	ham.demote("test")
+
+	done := waitRecipeState(t, rm, run.ID, "aborted", "completed", "failed")
+	if done.State != "aborted" || done.SafeState[0].State != "completed" || io.written() != "SP=50,heater=1,heater=0" {
+		t.Fatalf("safe state not applied before demotion: %s %+v, writes %s", done.State, done.SafeState, io.written())
+	}
+	if !ham.IsStandby() {
+		t.Fatal("node not demoted")
+	}
+}
@@ -45,6 +45,7 @@ func (sb *ShadowBridge) pushFromShadow(sc *ShadowCore, shadowDeviceID string, po
 			Value:     pt.Value,
 			Quality:   normalizeQuality(pt.Quality),
 			TS:        collectedAt,
+			Meta:      pt.Meta,
 		})
 	}
 	sb.pipeline.PushBatch(batch)
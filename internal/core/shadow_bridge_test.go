@@ -115,3 +115,32 @@ func TestChannelManager_FinalizeScanCollect(t *testing.T) {
 		t.Error("expected offline/degraded after total failure")
 	}
 }
+
+func TestChannelManager_DriverEventMetaThroughShadow(t *testing.T) {
+	sc := NewShadowCore()
+	pipeline := NewDataPipeline(10)
+
+	received := make(chan model.Value, 1)
+	pipeline.AddHandler(func(v model.Value) { received <- v })
+	pipeline.Start()
+	NewShadowBridge(pipeline).Attach(sc)
+
+	cm := NewChannelManager(pipeline, nil)
+	cm.SetShadowCore(sc)
+	cm.publishDriverValue(model.Value{
+		ChannelID: "ch-ua",
+		DeviceID:  "plc",
+		PointID:   "alarms",
+		Value:     map[string]any{"Message": "Overtemperature", "Severity": 800},
+		Meta:      map[string]any{"source": "opcua_event", "alarm": true, "severity": 800},
+	})
+
+	select {
+	case v := <-received:
+		if v.PointID != "alarms" || v.Meta["source"] != "opcua_event" || v.Meta["alarm"] != true || v.Meta["severity"] != 800 {
+			t.Fatalf("event meta lost on the shadow path: %+v", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event value did not reach the pipeline")
+	}
+}
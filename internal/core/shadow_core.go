@@ -265,6 +265,7 @@ func (sc *ShadowCore) applyShadowWriteLocked(msg model.ShadowIngressMessage) (
 			CollectedAt:    collectedAt,
 			UpdatedAt:      updatedAt,
 			Version:        version,
+			Meta:           point.Meta,
 		}
 		changed[point.PointID] = shadowPoint
 	}
@@ -577,6 +578,13 @@ func (sc *ShadowCore) DeleteShadowDevice(deviceID string) error {
 	return nil
 }
 
+// DeleteVirtualShadowDevice 删除虚拟影子设备（如规则删除后的输出点）。
+func (sc *ShadowCore) DeleteVirtualShadowDevice(virtualDeviceID string) {
+	sc.mu.Lock()
+	delete(sc.virtualShadows, virtualDeviceID)
+	sc.mu.Unlock()
+}
+
 // ClearAllShadowDevices 清空全部内存态影子设备（含虚拟影子与优化画像）。
 func (sc *ShadowCore) ClearAllShadowDevices() {
 	sc.mu.Lock()
@@ -16,7 +16,7 @@ type StoreForwardPolicy struct {
 	MaxNorthboundPerID   int
 }
 
-// StoreForwardManager 统一 Store & Forward：南向 values 与北向 NorthboundCache。
+// StoreForwardManager 统一 Store & Forward：南向 values 与北向离线队列（NorthboundCache）。
 type StoreForwardManager struct {
 	store  *storage.Storage
 	policy StoreForwardPolicy
@@ -80,12 +80,12 @@ func (m *StoreForwardManager) pruneSouthbound() {
 	})
 }
 
-// CacheNorthbound 缓存北向离线消息（复用 NorthboundCache bucket）。
+// CacheNorthbound 将消息追加到该北向连接的离线队列，与各客户端共用同一队列与回放。
 func (m *StoreForwardManager) CacheNorthbound(configID string, data []byte) error {
 	if m == nil || m.store == nil {
 		return nil
 	}
-	return m.store.SaveOfflineMessage(configID, data, m.policy.MaxNorthboundPerID)
+	return m.store.OfflineQueue(configID).Push(data, storage.OfflinePolicy{MaxCount: m.policy.MaxNorthboundPerID})
 }
 
 // ReplaySouthbound 回放最近缓存的南向值。
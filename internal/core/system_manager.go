@@ -16,6 +16,8 @@ type SystemManager struct {
 	mdnsServer *network.MDNSServer
 	dnsProxy   *network.DNSProxy
 	netManager *network.NetworkManager
+	ha         *HAManager
+	timeSync   *TimeSyncManager
 }
 
 // persist 持久化当前配置到数据库。
@@ -33,6 +35,20 @@ func (sm *SystemManager) SetConfigManager(cm *config.ConfigManager) {
 	sm.cfgManager = cm
 }
 
+// SetHAManager 注入主备冗余管理器，系统配置更新时同步 HA 参数。
+func (sm *SystemManager) SetHAManager(ha *HAManager) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.ha = ha
+}
+
+// HAManager 返回主备冗余管理器（未启用时为 nil）。
+func (sm *SystemManager) HAManager() *HAManager {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.ha
+}
+
 func NewSystemManager(cfg *config.Config) *SystemManager {
 	// Initialize with defaults if empty
 	if cfg.System.Time.Mode == "" {
@@ -46,16 +62,23 @@ func NewSystemManager(cfg *config.Config) *SystemManager {
 		dnsProxy:   network.NewDNSProxy(),
 		netManager: network.NewNetworkManager(),
 	}
+	sm.timeSync = NewTimeSyncManager(sm.netManager)
 	sm.normalizeHostnameConfig()
 
 	// Start network services (hostname discovery must run at startup, not only on settings save).
 	sm.startHostnameServices()
 
 	go sm.netManager.ApplyConfig(cfg.System.Network, cfg.System.Routes)
+	_ = sm.timeSync.Apply(cfg.System.Time, nil)
 
 	return sm
 }
 
+// TimeSync 返回时间同步管理器（NTP 状态、时间质量）。
+func (sm *SystemManager) TimeSync() *TimeSyncManager {
+	return sm.timeSync
+}
+
 func (sm *SystemManager) GetConfig() model.SystemConfig {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -67,6 +90,7 @@ func (sm *SystemManager) GetConfig() model.SystemConfig {
 func (sm *SystemManager) UpdateConfig(newConfig model.SystemConfig) error {
 	sm.mu.Lock()
 	previousRoutes := append([]model.StaticRoute(nil), sm.config.System.Routes...)
+	previousTime := sm.config.System.Time
 	sm.config.System = newConfig
 	if newConfig.Hostname.HTTPPort > 0 {
 		sm.config.Server.Port = newConfig.Hostname.HTTPPort
@@ -78,7 +102,7 @@ func (sm *SystemManager) UpdateConfig(newConfig model.SystemConfig) error {
 	}
 	sm.mu.Unlock()
 
-	go sm.applyConfig(newConfig, previousRoutes)
+	go sm.applyConfig(newConfig, previousRoutes, previousTime)
 
 	return nil
 }
@@ -96,16 +120,20 @@ func (sm *SystemManager) startHostnameServices() {
 	}
 }
 
-func (sm *SystemManager) applyConfig(cfg model.SystemConfig, previousRoutes []model.StaticRoute) {
+func (sm *SystemManager) applyConfig(cfg model.SystemConfig, previousRoutes []model.StaticRoute, previousTime model.TimeConfig) {
 	sm.startHostnameServices()
 
 	if err := sm.netManager.ApplyConfigWithRouteSync(cfg.Network, cfg.Routes, previousRoutes, cfg.ConnectivityTargets); err != nil {
 		fmt.Printf("Error updating network config: %v\n", err)
 	}
 
-	// TODO: Implement other system calls here
-	// 1. Set System Time
-	// 2. Configure HA/Keepalived
+	if err := sm.timeSync.Apply(cfg.Time, &previousTime); err != nil {
+		fmt.Printf("Error applying time config: %v\n", err)
+	}
+
+	if ha := sm.HAManager(); ha != nil {
+		ha.ApplyConfig(cfg.HA)
+	}
 
 	fmt.Printf("System configuration applied: %+v\n", cfg)
 }
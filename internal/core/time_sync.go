@@ -0,0 +1,294 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/network"
+	"go.uber.org/zap"
+)
+
+// TimeQualityUnsynchronized is set as Value.Meta["time_quality"] for values
+// collected while NTP is configured but the clock is not synchronised.
+const TimeQualityUnsynchronized = "unsynchronized"
+
+const (
+	timeSyncQueryTimeout = 5 * time.Second
+	timeSyncRetry        = 30 * time.Second
+	// Offsets below this are left alone; above it the clock counts as
+	// synchronised only if the correction succeeded.
+	timeSyncMinCorrection = time.Millisecond
+	timeSyncTolerance     = 100 * time.Millisecond
+)
+
+// ClockController is the part of network.NetworkManager used for time settings.
+type ClockController interface {
+	SetSystemTime(t time.Time) error
+	AdjustSystemTime(offset time.Duration) error
+	SetTimezone(name string) error
+	SyncHardwareClock() error
+}
+
+// TimeSyncStatus is returned by the time status API.
+type TimeSyncStatus struct {
+	Mode         string    `json:"mode"`
+	Synchronized bool      `json:"synchronized"`
+	TimeQuality  string    `json:"time_quality,omitempty"`
+	Server       string    `json:"server,omitempty"`
+	Stratum      int       `json:"stratum,omitempty"`
+	RefID        string    `json:"ref_id,omitempty"`
+	OffsetMs     float64   `json:"offset_ms"`
+	DelayMs      float64   `json:"delay_ms"`
+	Correction   string    `json:"correction,omitempty"` // step, slew
+	LastSync     time.Time `json:"last_sync,omitempty"`
+	LastAttempt  time.Time `json:"last_attempt,omitempty"`
+	NextPoll     time.Time `json:"next_poll,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+	ManualSetAt  time.Time `json:"manual_set_at,omitempty"`
+}
+
+// TimeSyncManager applies TimeConfig: timezone, manual clock set and an
+// embedded SNTP client that disciplines the system clock.
+type TimeSyncManager struct {
+	clock ClockController
+	query func(ctx context.Context, servers []string, timeout time.Duration) (network.NTPResult, error)
+	retry time.Duration
+
+	mu       sync.Mutex
+	status   TimeSyncStatus
+	unsynced atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewTimeSyncManager(clock ClockController) *TimeSyncManager {
+	return &TimeSyncManager{
+		clock: clock,
+		query: network.QueryNTPServers,
+		retry: timeSyncRetry,
+	}
+}
+
+// Apply applies cfg. previous is the config in effect before (nil at startup):
+// timezone and manual datetime only touch the OS when they changed, so saving
+// unrelated settings never winds the clock back to a stale manual value.
+func (ts *TimeSyncManager) Apply(cfg model.TimeConfig, previous *model.TimeConfig) error {
+	// Keep a running client (and its sync state) when the NTP settings did not change.
+	keep := previous != nil && cfg.Mode == "ntp" && previous.Mode == "ntp" &&
+		sameNTPConfig(cfg.NTP, previous.NTP) && ts.running()
+	if !keep {
+		ts.Stop()
+	}
+
+	var errs []error
+	if previous != nil {
+		if tz := cfg.Manual.Timezone; tz != "" && tz != previous.Manual.Timezone {
+			if err := ts.clock.SetTimezone(tz); err != nil {
+				errs = append(errs, fmt.Errorf("set timezone: %w", err))
+			}
+		}
+		manualChanged := cfg.Manual.Datetime != previous.Manual.Datetime || previous.Mode != "manual"
+		if cfg.Mode == "manual" && cfg.Manual.Datetime != "" && manualChanged {
+			if err := ts.setManual(cfg.Manual); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	ts.mu.Lock()
+	ts.status.Mode = cfg.Mode
+	ts.status.NextPoll = time.Time{}
+	if len(errs) > 0 {
+		ts.status.LastError = errors.Join(errs...).Error()
+	}
+	ts.mu.Unlock()
+
+	if cfg.Mode != "ntp" {
+		ts.unsynced.Store(false)
+		return errors.Join(errs...)
+	}
+	if keep {
+		return errors.Join(errs...)
+	}
+
+	// Until the first successful poll the clock is not trusted.
+	ts.unsynced.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ts.mu.Lock()
+	ts.status.Synchronized = false
+	ts.cancel, ts.done = cancel, done
+	ts.mu.Unlock()
+	go ts.run(ctx, cfg, done)
+	return errors.Join(errs...)
+}
+
+// Stop ends NTP polling.
+func (ts *TimeSyncManager) Stop() {
+	ts.mu.Lock()
+	cancel, done := ts.cancel, ts.done
+	ts.cancel, ts.done = nil, nil
+	ts.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (ts *TimeSyncManager) running() bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.cancel != nil
+}
+
+func sameNTPConfig(a, b model.NTPConfig) bool {
+	if a.Interval != b.Interval || len(a.Servers) != len(b.Servers) {
+		return false
+	}
+	for i := range a.Servers {
+		if a.Servers[i] != b.Servers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (ts *TimeSyncManager) setManual(m model.ManualTime) error {
+	loc := time.Local
+	if m.Timezone != "" {
+		if l, err := time.LoadLocation(m.Timezone); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", m.Datetime, loc)
+	if err != nil {
+		return fmt.Errorf("invalid manual datetime %q: %w", m.Datetime, err)
+	}
+	if err := ts.clock.SetSystemTime(t); err != nil {
+		return fmt.Errorf("set system time: %w", err)
+	}
+	if m.SyncRTC {
+		if err := ts.clock.SyncHardwareClock(); err != nil {
+			zap.L().Warn("[Time] RTC sync failed", zap.Error(err))
+		}
+	}
+	ts.mu.Lock()
+	ts.status.ManualSetAt = time.Now()
+	ts.mu.Unlock()
+	zap.L().Info("[Time] system clock set manually", zap.Time("time", t))
+	return nil
+}
+
+func (ts *TimeSyncManager) run(ctx context.Context, cfg model.TimeConfig, done chan struct{}) {
+	defer close(done)
+	interval := time.Duration(cfg.NTP.Interval) * time.Hour
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	for {
+		wait := ts.poll(ctx, cfg, interval)
+		ts.mu.Lock()
+		ts.status.NextPoll = time.Now().Add(wait)
+		ts.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// poll measures the offset once, corrects the clock and returns the delay
+// until the next poll.
+func (ts *TimeSyncManager) poll(ctx context.Context, cfg model.TimeConfig, interval time.Duration) time.Duration {
+	res, err := ts.query(ctx, cfg.NTP.Servers, timeSyncQueryTimeout)
+	if ctx.Err() != nil {
+		return ts.retry
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.status.LastAttempt = time.Now()
+	if err != nil {
+		ts.status.LastError = err.Error()
+		// A missed poll is tolerated while the last sync is recent.
+		if ts.status.LastSync.IsZero() || time.Since(ts.status.LastSync) > 2*interval {
+			ts.setSyncedLocked(false)
+		}
+		return ts.retry
+	}
+
+	offset := res.Offset
+	abs := offset
+	if abs < 0 {
+		abs = -abs
+	}
+	var adjErr error
+	ts.status.Correction = ""
+	if abs > timeSyncMinCorrection {
+		ts.status.Correction = "slew"
+		if abs > network.MaxSlewOffset {
+			ts.status.Correction = "step"
+		}
+		adjErr = ts.clock.AdjustSystemTime(offset)
+		if adjErr == nil && ts.status.Correction == "step" && cfg.Manual.SyncRTC {
+			_ = ts.clock.SyncHardwareClock()
+		}
+	}
+
+	ts.status.Server = res.Server
+	ts.status.Stratum = res.Stratum
+	ts.status.RefID = res.RefID
+	ts.status.OffsetMs = float64(offset) / float64(time.Millisecond)
+	ts.status.DelayMs = float64(res.Delay) / float64(time.Millisecond)
+	ts.status.LastSync = time.Now()
+	ts.status.LastError = ""
+	if adjErr != nil {
+		ts.status.LastError = "clock correction failed: " + adjErr.Error()
+	}
+
+	synced := adjErr == nil || abs <= timeSyncTolerance
+	ts.setSyncedLocked(synced)
+	if !synced {
+		return ts.retry
+	}
+	return interval
+}
+
+func (ts *TimeSyncManager) setSyncedLocked(synced bool) {
+	if ts.status.Synchronized != synced {
+		zap.L().Info("[Time] clock synchronisation changed",
+			zap.Bool("synchronized", synced),
+			zap.String("server", ts.status.Server),
+			zap.Float64("offset_ms", ts.status.OffsetMs),
+		)
+	}
+	ts.status.Synchronized = synced
+	ts.unsynced.Store(!synced)
+}
+
+// TimeQuality returns TimeQualityUnsynchronized while NTP is configured but
+// not synchronised, and "" otherwise. Cheap enough for the data path.
+func (ts *TimeSyncManager) TimeQuality() string {
+	if ts.unsynced.Load() {
+		return TimeQualityUnsynchronized
+	}
+	return ""
+}
+
+// Status returns a snapshot for the status API.
+func (ts *TimeSyncManager) Status() TimeSyncStatus {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st := ts.status
+	st.TimeQuality = ts.TimeQuality()
+	return st
+}
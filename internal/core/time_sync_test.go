@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/network"
+)
+
+type fakeClock struct {
+	mu        sync.Mutex
+	setTimes  []time.Time
+	adjusts   []time.Duration
+	timezones []string
+	rtcSyncs  int
+	adjustErr error
+}
+
+func (c *fakeClock) SetSystemTime(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setTimes = append(c.setTimes, t)
+	return nil
+}
+
+func (c *fakeClock) AdjustSystemTime(offset time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adjusts = append(c.adjusts, offset)
+	return c.adjustErr
+}
+
+func (c *fakeClock) SetTimezone(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timezones = append(c.timezones, name)
+	return nil
+}
+
+func (c *fakeClock) SyncHardwareClock() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rtcSyncs++
+	return nil
+}
+
+func newTestTimeSync(clock *fakeClock, query func() (network.NTPResult, error)) *TimeSyncManager {
+	ts := NewTimeSyncManager(clock)
+	ts.retry = 10 * time.Millisecond
+	ts.query = func(ctx context.Context, servers []string, timeout time.Duration) (network.NTPResult, error) {
+		return query()
+	}
+	return ts
+}
+
+func ntpConfig() model.TimeConfig {
+	return model.TimeConfig{Mode: "ntp", NTP: model.NTPConfig{Servers: []string{"ntp.test"}, Interval: 1}}
+}
+
+func TestTimeSync_NTPSlewsAndSynchronizes(t *testing.T) {
+	clock := &fakeClock{}
+	ts := newTestTimeSync(clock, func() (network.NTPResult, error) {
+		return network.NTPResult{Server: "ntp.test", Offset: 40 * time.Millisecond, Delay: 3 * time.Millisecond, Stratum: 2}, nil
+	})
+	defer ts.Stop()
+
+	if err := ts.Apply(ntpConfig(), nil); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	waitTimeSync(t, func() bool { return ts.Status().Synchronized })
+
+	st := ts.Status()
+	if st.Stratum != 2 || st.Correction != "slew" || st.OffsetMs != 40 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if ts.TimeQuality() != "" {
+		t.Fatalf("time quality = %q, want empty when synchronized", ts.TimeQuality())
+	}
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if len(clock.adjusts) == 0 || clock.adjusts[0] != 40*time.Millisecond {
+		t.Fatalf("adjusts = %v", clock.adjusts)
+	}
+}
+
+func TestTimeSync_UnsynchronizedUntilServerAnswers(t *testing.T) {
+	clock := &fakeClock{}
+	ts := newTestTimeSync(clock, func() (network.NTPResult, error) {
+		return network.NTPResult{}, errors.New("no route")
+	})
+	defer ts.Stop()
+
+	_ = ts.Apply(ntpConfig(), nil)
+	waitTimeSync(t, func() bool { return ts.Status().LastError != "" })
+	if ts.TimeQuality() != TimeQualityUnsynchronized {
+		t.Fatalf("time quality = %q, want unsynchronized", ts.TimeQuality())
+	}
+}
+
+func TestTimeSync_FailedStepKeepsUnsynchronized(t *testing.T) {
+	clock := &fakeClock{adjustErr: errors.New("operation not permitted")}
+	ts := newTestTimeSync(clock, func() (network.NTPResult, error) {
+		return network.NTPResult{Server: "ntp.test", Offset: 3 * time.Second, Stratum: 1}, nil
+	})
+	defer ts.Stop()
+
+	_ = ts.Apply(ntpConfig(), nil)
+	waitTimeSync(t, func() bool { return !ts.Status().LastSync.IsZero() })
+	st := ts.Status()
+	if st.Synchronized || st.Correction != "step" || st.TimeQuality != TimeQualityUnsynchronized {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+func TestTimeSync_ManualSetOnlyWhenChanged(t *testing.T) {
+	clock := &fakeClock{}
+	ts := NewTimeSyncManager(clock)
+
+	prev := model.TimeConfig{Mode: "manual", Manual: model.ManualTime{Timezone: "UTC"}}
+	cfg := model.TimeConfig{Mode: "manual", Manual: model.ManualTime{
+		Datetime: "2026-10-18 08:00:00", Timezone: "Asia/Shanghai", SyncRTC: true,
+	}}
+	if err := ts.Apply(cfg, &prev); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	// Saving again with the same datetime must not wind the clock back.
+	if err := ts.Apply(cfg, &cfg); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if len(clock.setTimes) != 1 {
+		t.Fatalf("SetSystemTime called %d times, want 1", len(clock.setTimes))
+	}
+	want := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	if !clock.setTimes[0].Equal(want) {
+		t.Fatalf("set time = %v, want %v", clock.setTimes[0], want)
+	}
+	if len(clock.timezones) != 1 || clock.timezones[0] != "Asia/Shanghai" || clock.rtcSyncs != 1 {
+		t.Fatalf("timezones=%v rtc=%d", clock.timezones, clock.rtcSyncs)
+	}
+	if ts.TimeQuality() != "" {
+		t.Fatalf("manual mode must not tag values, got %q", ts.TimeQuality())
+	}
+}
+
+func TestDataPipeline_TagsUnsynchronizedValues(t *testing.T) {
+	dp := NewDataPipeline(10)
+	quality := TimeQualityUnsynchronized
+	dp.SetTimeQualityFunc(func() string { return quality })
+
+	got := make(chan model.Value, 2)
+	dp.AddHandler(func(v model.Value) { got <- v })
+	dp.Start()
+
+	shared := map[string]any{"qos": 1}
+	dp.Push(model.Value{DeviceID: "d", PointID: "p", Value: 1, Meta: shared})
+	v := <-got
+	if v.Meta["time_quality"] != TimeQualityUnsynchronized || v.Meta["qos"] != 1 {
+		t.Fatalf("meta = %v", v.Meta)
+	}
+	if _, ok := shared["time_quality"]; ok {
+		t.Fatal("driver meta map must not be modified")
+	}
+
+	quality = ""
+	dp.Push(model.Value{DeviceID: "d", PointID: "p2", Value: 2})
+	if v := <-got; v.Meta != nil {
+		t.Fatalf("synchronized value should not be tagged: %v", v.Meta)
+	}
+}
+
+func waitTimeSync(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for time sync state")
+}
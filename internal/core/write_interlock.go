@@ -0,0 +1,265 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// maxInterlockBypass 单次旁路的最长时间
+const maxInterlockBypass = 24 * time.Hour
+
+// InterlockError 写入被联锁拒绝，Reason 返回给调用方
+type InterlockError struct {
+	InterlockID string
+	Name        string
+	Reason      string
+}
+
+func (e *InterlockError) Error() string {
+	return fmt.Sprintf("写入被联锁 %s 拒绝: %s", e.Name, e.Reason)
+}
+
+// InterlockManager 集中管理写联锁，由 ChannelManager.WritePoint 在每次写入前调用，
+// 覆盖 REST、MCP、北向命令与边缘规则等全部写入路径。
+type InterlockManager struct {
+	mu         sync.RWMutex
+	interlocks map[string]*model.WriteInterlock
+	saveFunc   func([]model.WriteInterlock) error
+	values     func(channelID, deviceID, pointID string) (model.Value, bool)
+	now        func() time.Time
+}
+
+func NewInterlockManager(saveFunc func([]model.WriteInterlock) error) *InterlockManager {
+	return &InterlockManager{
+		interlocks: make(map[string]*model.WriteInterlock),
+		saveFunc:   saveFunc,
+		now:        time.Now,
+	}
+}
+
+// SetValueSource 设置联锁条件读取点位当前值的来源（影子或规则值缓存）
+func (im *InterlockManager) SetValueSource(f func(channelID, deviceID, pointID string) (model.Value, bool)) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.values = f
+}
+
+func (im *InterlockManager) Load(interlocks []model.WriteInterlock) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.interlocks = make(map[string]*model.WriteInterlock, len(interlocks))
+	for i := range interlocks {
+		il := interlocks[i]
+		im.interlocks[il.ID] = &il
+	}
+}
+
+func (im *InterlockManager) List() []model.WriteInterlock {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+	out := make([]model.WriteInterlock, 0, len(im.interlocks))
+	for _, il := range im.interlocks {
+		out = append(out, *il)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func validateInterlock(il model.WriteInterlock) error {
+	if il.ID == "" {
+		return fmt.Errorf("联锁 ID 无效")
+	}
+	if il.ChannelID == "" || il.DeviceID == "" || il.PointID == "" {
+		return fmt.Errorf("联锁目标点位无效: channel_id、device_id、point_id 不能为空")
+	}
+	if strings.TrimSpace(il.Condition) == "" {
+		return fmt.Errorf("联锁条件无效: condition 不能为空")
+	}
+	if il.MaxAge != "" {
+		if _, err := time.ParseDuration(il.MaxAge); err != nil {
+			return fmt.Errorf("联锁 max_age 无效: %v", err)
+		}
+	}
+	seen := make(map[string]struct{}, len(il.Sources))
+	for _, src := range il.Sources {
+		if src.Alias == "" || src.ChannelID == "" || src.DeviceID == "" || src.PointID == "" {
+			return fmt.Errorf("联锁数据源无效: alias 与点位不能为空")
+		}
+		if _, ok := seen[src.Alias]; ok {
+			return fmt.Errorf("联锁数据源别名重复: %q", src.Alias)
+		}
+		seen[src.Alias] = struct{}{}
+	}
+	return nil
+}
+
+// Upsert 保存联锁定义，保留已有的旁路与统计
+func (im *InterlockManager) Upsert(il model.WriteInterlock) error {
+	if err := validateInterlock(il); err != nil {
+		return err
+	}
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if old, ok := im.interlocks[il.ID]; ok {
+		il.Bypass = old.Bypass
+		il.RejectCount, il.LastRejected, il.LastReason = old.RejectCount, old.LastRejected, old.LastReason
+	} else {
+		il.Bypass = nil
+		il.RejectCount, il.LastRejected, il.LastReason = 0, time.Time{}, ""
+	}
+	im.interlocks[il.ID] = &il
+	return im.persistLocked()
+}
+
+func (im *InterlockManager) Delete(id string) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if _, ok := im.interlocks[id]; !ok {
+		return fmt.Errorf("interlock not found")
+	}
+	delete(im.interlocks, id)
+	return im.persistLocked()
+}
+
+// SetBypass 临时旁路联锁（调用方负责校验管理员权限）
+func (im *InterlockManager) SetBypass(id, user, reason string, d time.Duration) (*model.InterlockBypass, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("旁路原因无效: reason 不能为空")
+	}
+	if d <= 0 || d > maxInterlockBypass {
+		return nil, fmt.Errorf("旁路时长无效: 需在 0 ~ %s 之间", maxInterlockBypass)
+	}
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	il, ok := im.interlocks[id]
+	if !ok {
+		return nil, fmt.Errorf("interlock not found")
+	}
+	now := im.now()
+	il.Bypass = &model.InterlockBypass{User: user, Reason: reason, Since: now, Until: now.Add(d)}
+	log.Printf("[Interlock] %s bypassed by %s until %s: %s", il.ID, user, il.Bypass.Until.Format(time.RFC3339), reason)
+	bypass := *il.Bypass
+	return &bypass, im.persistLocked()
+}
+
+func (im *InterlockManager) ClearBypass(id string) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	il, ok := im.interlocks[id]
+	if !ok {
+		return fmt.Errorf("interlock not found")
+	}
+	il.Bypass = nil
+	return im.persistLocked()
+}
+
+// CheckWrite 检查对目标点位的写入是否满足全部生效联锁，拒绝时返回 *InterlockError
+func (im *InterlockManager) CheckWrite(channelID, deviceID, pointID string, value any) error {
+	if im == nil {
+		return nil
+	}
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	now := im.now()
+	ids := make([]string, 0, 1)
+	for id, il := range im.interlocks {
+		if il.Enable && il.ChannelID == channelID && il.DeviceID == deviceID && il.PointID == pointID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		il := im.interlocks[id]
+		if il.Bypass != nil && now.Before(il.Bypass.Until) {
+			continue
+		}
+		reason := im.evaluateLocked(il, value, now)
+		if reason == "" {
+			continue
+		}
+		il.RejectCount++
+		il.LastRejected = now
+		il.LastReason = reason
+		log.Printf("[Interlock] write %s/%s/%s=%v rejected by %s: %s", channelID, deviceID, pointID, value, il.ID, reason)
+		name := il.Name
+		if name == "" {
+			name = il.ID
+		}
+		return &InterlockError{InterlockID: il.ID, Name: name, Reason: reason}
+	}
+	return nil
+}
+
+// evaluateLocked 返回拒绝原因；空字符串表示允许。数据缺失、质量差或过期时按拒绝处理
+func (im *InterlockManager) evaluateLocked(il *model.WriteInterlock, value any, now time.Time) string {
+	if v, ok := toFloat(value); ok {
+		value = v
+	}
+	if il.When != "" {
+		applies, err := evaluateThreshold(il.When, map[string]any{"value": value})
+		if err != nil {
+			return fmt.Sprintf("when 表达式错误: %v", err)
+		}
+		if !applies {
+			return ""
+		}
+	}
+
+	var maxAge time.Duration
+	if il.MaxAge != "" {
+		maxAge, _ = time.ParseDuration(il.MaxAge)
+	}
+	env := map[string]any{"value": value}
+	for _, src := range il.Sources {
+		if im.values == nil {
+			return "联锁数据源不可用"
+		}
+		v, ok := im.values(src.ChannelID, src.DeviceID, src.PointID)
+		if !ok || v.Value == nil {
+			return fmt.Sprintf("数据源 %s 无数据", src.Alias)
+		}
+		if v.Quality != "" && v.Quality != "Good" {
+			return fmt.Sprintf("数据源 %s 质量异常 (%s)", src.Alias, v.Quality)
+		}
+		if maxAge > 0 && !v.TS.IsZero() && now.Sub(v.TS) > maxAge {
+			return fmt.Sprintf("数据源 %s 数据过期 (%s)", src.Alias, now.Sub(v.TS).Truncate(time.Second))
+		}
+		if f, ok := toFloat(v.Value); ok {
+			env[src.Alias] = f
+		} else {
+			env[src.Alias] = v.Value
+		}
+	}
+
+	permitted, err := evaluateThreshold(il.Condition, env)
+	if err != nil {
+		return fmt.Sprintf("条件表达式错误: %v", err)
+	}
+	if permitted {
+		return ""
+	}
+	if il.Message != "" {
+		return il.Message
+	}
+	return "许可条件不满足: " + il.Condition
+}
+
+func (im *InterlockManager) persistLocked() error {
+	if im.saveFunc == nil {
+		return nil
+	}
+	out := make([]model.WriteInterlock, 0, len(im.interlocks))
+	for _, il := range im.interlocks {
+		cp := *il
+		cp.RejectCount, cp.LastRejected, cp.LastReason = 0, time.Time{}, ""
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return im.saveFunc(out)
+}
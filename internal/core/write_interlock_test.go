@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	drv "github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+func newTestInterlocks(t *testing.T, values map[string]model.Value) *InterlockManager {
+	t.Helper()
+	im := NewInterlockManager(nil)
+	im.SetValueSource(func(ch, dev, pt string) (model.Value, bool) {
+		v, ok := values[ch+"/"+dev+"/"+pt]
+		return v, ok
+	})
+	err := im.Upsert(model.WriteInterlock{
+		ID:        "v1-open",
+		Name:      "V1 开阀许可",
+		Enable:    true,
+		ChannelID: "ch1", DeviceID: "valve", PointID: "V1",
+		When: "value == 1",
+		Sources: []model.RuleSource{
+			{Alias: "pump", ChannelID: "ch1", DeviceID: "pump", PointID: "P1"},
+			{Alias: "level", ChannelID: "ch1", DeviceID: "tank", PointID: "L1"},
+		},
+		Condition: "pump == 1 && level < 90",
+		MaxAge:    "30s",
+		Message:   "泵未运行或液位过高",
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	return im
+}
+
+func TestInterlockPermissive(t *testing.T) {
+	now := time.Now()
+	values := map[string]model.Value{
+		"ch1/pump/P1": {Value: 1, Quality: "Good", TS: now},
+		"ch1/tank/L1": {Value: 95.0, Quality: "Good", TS: now},
+	}
+	im := newTestInterlocks(t, values)
+
+	err := im.CheckWrite("ch1", "valve", "V1", 1)
+	var ilErr *InterlockError
+	if !errors.As(err, &ilErr) || ilErr.Reason != "泵未运行或液位过高" {
+		t.Fatalf("expected interlock rejection, got %v", err)
+	}
+	if err := im.CheckWrite("ch1", "valve", "V1", 0); err != nil {
+		t.Fatalf("closing the valve should not be interlocked: %v", err)
+	}
+	if err := im.CheckWrite("ch1", "valve", "V2", 1); err != nil {
+		t.Fatalf("other points should not be interlocked: %v", err)
+	}
+
+	values["ch1/tank/L1"] = model.Value{Value: 50.0, Quality: "Good", TS: now}
+	if err := im.CheckWrite("ch1", "valve", "V1", "1"); err != nil {
+		t.Fatalf("permissive satisfied, got %v", err)
+	}
+	if got := im.List()[0].RejectCount; got != 1 {
+		t.Fatalf("reject count = %d, want 1", got)
+	}
+}
+
+func TestInterlockFailsSafeOnBadData(t *testing.T) {
+	now := time.Now()
+	values := map[string]model.Value{
+		"ch1/pump/P1": {Value: 1, Quality: "Bad", TS: now},
+		"ch1/tank/L1": {Value: 10.0, Quality: "Good", TS: now},
+	}
+	im := newTestInterlocks(t, values)
+
+	if err := im.CheckWrite("ch1", "valve", "V1", 1); err == nil || !strings.Contains(err.Error(), "质量异常") {
+		t.Fatalf("expected bad quality rejection, got %v", err)
+	}
+	values["ch1/pump/P1"] = model.Value{Value: 1, Quality: "Good", TS: now.Add(-time.Minute)}
+	if err := im.CheckWrite("ch1", "valve", "V1", 1); err == nil || !strings.Contains(err.Error(), "过期") {
+		t.Fatalf("expected stale rejection, got %v", err)
+	}
+	delete(values, "ch1/pump/P1")
+	if err := im.CheckWrite("ch1", "valve", "V1", 1); err == nil || !strings.Contains(err.Error(), "无数据") {
+		t.Fatalf("expected missing data rejection, got %v", err)
+	}
+}
+
+func TestInterlockBypass(t *testing.T) {
+	im := newTestInterlocks(t, map[string]model.Value{})
+	now := time.Now()
+	im.now = func() time.Time { return now }
+
+	if _, err := im.SetBypass("v1-open", "admin", "", time.Hour); err == nil {
+		t.Fatal("bypass without reason should be rejected")
+	}
+	if _, err := im.SetBypass("v1-open", "admin", "检修", 48*time.Hour); err == nil {
+		t.Fatal("bypass longer than 24h should be rejected")
+	}
+	if _, err := im.SetBypass("v1-open", "admin", "检修", time.Hour); err != nil {
+		t.Fatalf("SetBypass: %v", err)
+	}
+	if err := im.CheckWrite("ch1", "valve", "V1", 1); err != nil {
+		t.Fatalf("bypassed interlock should allow write: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := im.CheckWrite("ch1", "valve", "V1", 1); err == nil {
+		t.Fatal("expired bypass should no longer allow write")
+	}
+}
+
+func TestChannelManagerWritePointChecksInterlocks(t *testing.T) {
+	cm := NewChannelManager(nil, nil)
+	defer cm.cancel()
+	ch := &model.Channel{
+		ID:       "ch1",
+		Protocol: addChannelMockProtocol,
+		Config:   map[string]any{},
+		Devices: []model.Device{{
+			ID:     "valve",
+			Enable: true,
+			Points: []model.Point{{ID: "V1", Address: "0", DataType: "int16", ReadWrite: "RW"}},
+		}},
+	}
+	if err := cm.AddChannel(ch); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+	cm.SetInterlocks(newTestInterlocks(t, map[string]model.Value{}))
+
+	var ilErr *InterlockError
+	if err := cm.WritePoint("ch1", "valve", "V1", 1); !errors.As(err, &ilErr) {
+		t.Fatalf("expected interlock error from WritePoint, got %v", err)
+	}
+}
+
+func TestChannelManagerCallMethodChecksInterlocks(t *testing.T) {
+	cm := NewChannelManager(nil, nil)
+	defer cm.cancel()
+	cm.SetInterlocks(newTestInterlocks(t, map[string]model.Value{}))
+
+	var ilErr *InterlockError
+	_, err := cm.CallMethod("ch1", "valve", drv.MethodCall{ObjectID: "ns=2;s=Valve", MethodID: "V1", Args: []any{1}})
+	if !errors.As(err, &ilErr) {
+		t.Fatalf("expected interlock error from CallMethod, got %v", err)
+	}
+	// Arguments outside the interlock's when-condition pass on to the channel lookup
+	_, err = cm.CallMethod("ch1", "valve", drv.MethodCall{MethodID: "V1", Args: []any{0}})
+	if err == nil || errors.As(err, &ilErr) {
+		t.Fatalf("expected channel error, got %v", err)
+	}
+}
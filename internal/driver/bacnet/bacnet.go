@@ -17,6 +17,7 @@ import (
 	"github.com/anviod/bacnet/datalink"
 	drv "github.com/anviod/edgex/internal/driver"
 	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/pkg/bacnetsc"
 
 	"go.uber.org/zap"
 )
@@ -125,6 +126,11 @@ type BACnetDriver struct {
 	historicalObjects map[int]map[string]ObjectResult
 
 	addressNotifier drv.BACnetAddressNotifier
+
+	// BACnet/SC transport (transport: "sc"); scLink is the live hub connection.
+	transport string
+	scConfig  model.BACnetSCConfig
+	scLink    *bacnetsc.HubConnector
 }
 
 type DeviceConfig struct {
@@ -215,6 +221,16 @@ func (d *BACnetDriver) Init(config model.DriverConfig) error {
 		}
 	}
 
+	d.transport = model.BACnetTransportIP
+	if v, ok := config.Config["transport"].(string); ok && model.IsBACnetSC(v) {
+		scCfg, err := parseSCConfig(config.Config["sc"])
+		if err != nil {
+			return err
+		}
+		d.transport = model.BACnetTransportSC
+		d.scConfig = scCfg
+	}
+
 	// NOTE: We do not set targetDeviceID/IP/Port here anymore.
 	// Each device is configured individually via SetDeviceConfig.
 
@@ -275,6 +291,7 @@ func (d *BACnetDriver) connectOnce(ctx context.Context) error {
 		ifaceIP = resolveOutboundIP()
 	}
 	subnetCIDR := d.subnetCIDR
+	d.scLink = nil
 	d.mu.Unlock()
 
 	// Step 2: Create new client outside lock (involves UDP socket bind).
@@ -286,14 +303,29 @@ func (d *BACnetDriver) connectOnce(ctx context.Context) error {
 		MaxPDU:     btypes.MaxAPDU,
 	}
 
+	// BACnet/SC: the hub connection replaces the UDP socket as the datalink.
+	var scLink *bacnetsc.HubConnector
+	if d.isSC() {
+		link, err := d.dialSCLink(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect BACnet/SC hub: %w", err)
+		}
+		scLink = link
+		cb.DataLink = link
+	}
+
 	client, err := d.clientFactory(cb)
 	if err != nil {
+		if scLink != nil {
+			_ = scLink.Close()
+		}
 		return fmt.Errorf("failed to create BACnet client: %v", err)
 	}
 
 	// Step 3: Store new client under lock.
 	d.mu.Lock()
 	d.client = client
+	d.scLink = scLink
 	d.connected = true
 	d.mu.Unlock()
 
@@ -303,6 +335,13 @@ func (d *BACnetDriver) connectOnce(ctx context.Context) error {
 
 	time.Sleep(100 * time.Millisecond)
 
+	if scLink != nil {
+		st := scLink.Status()
+		zap.L().Info("BACnet Connect client started over BACnet/SC",
+			zap.String("hub", st.HubURI),
+			zap.String("vmac", st.VMAC))
+		return nil
+	}
 	zap.L().Info("BACnet Connect client started",
 		zap.String("ip", ifaceIP),
 		zap.Int("port", connectPort))
@@ -386,6 +425,7 @@ func (d *BACnetDriver) Disconnect() error {
 	d.lastDisconnectTime = time.Now()
 	client := d.client
 	d.client = nil
+	d.scLink = nil
 	d.connected = false
 	d.mu.Unlock()
 
@@ -880,7 +920,23 @@ func (d *BACnetDriver) SetDeviceConfig(config map[string]any) error {
 				zap.Bool("connected", d.connected))
 		}
 
-		if needDiscovery {
+		if needDiscovery && d.isSC() {
+			// BACnet/SC: a configured VMAC is authoritative; otherwise resolve via hub Who-Is.
+			applied, err := d.applySCDeviceConfigLocked(newID, config)
+			if err != nil {
+				return err
+			}
+			if !applied && d.connected && d.client != nil {
+				client := d.client
+				go func() {
+					if found, ok := d.whoIsDiscoverDevice(client, newID, "", 0); ok {
+						d.applyDiscoveredDevice(newID, "", 0, found)
+						zap.L().Info("Async BACnet/SC discovery confirmed device",
+							zap.Int("device_id", newID), zap.String("vmac", vmacFromDevice(found)))
+					}
+				}()
+			}
+		} else if needDiscovery {
 			// 同步创建 DeviceContext（不等待 WhoIs 发现）
 			// 如果已有配置的 IP:port，直接使用配置地址初始化
 			// 避免 WhoIs 广播超时导致 API 响应超时
@@ -957,6 +1013,10 @@ func (d *BACnetDriver) GetConnectionMetrics() (connectionSeconds int64, reconnec
 
 	// 获取本地地址信息
 	local := fmt.Sprintf("%s:%d", d.interfaceIP, d.interfacePort)
+	if d.scLink != nil {
+		st := d.scLink.Status()
+		return connSec, d.reconnectCount, "sc:" + st.VMAC, fmt.Sprintf("%s (%s)", st.HubURI, st.State), d.lastDisconnectTime
+	}
 
 	// 对于BACnet，远程地址显示为广播或已发现设备数量
 	remote := "广播"
@@ -1082,7 +1142,8 @@ func (d *BACnetDriver) Scan(ctx context.Context, params map[string]any) (any, er
 		scanClient := defaultClient
 		// Create ephemeral client on a specific interface if requested
 		// 如果指定了 interface_ip，创建临时客户端绑定到该网卡
-		if v, ok := params["interface_ip"]; ok {
+		// BACnet/SC 通道只能经 hub 连接访问，忽略 interface_ip
+		if v, ok := params["interface_ip"]; ok && !d.isSC() {
 			if ifaceIP, ok := v.(string); ok && ifaceIP != "" {
 				cb := &bacnetlib.ClientBuilder{
 					Ip:         ifaceIP,
@@ -1135,6 +1196,11 @@ func (d *BACnetDriver) Scan(ctx context.Context, params map[string]any) (any, er
 	// 2. Broadcast WhoIs (Low:0, High:0 = unlimited range) to discover all devices
 	// 3. Collect IAm responses, enrich with ObjectName via ReadProperty
 
+	// BACnet/SC: discovery goes through the hub connection, not a UDP broadcast.
+	if d.isSC() {
+		return d.scanSC(defaultClient, params)
+	}
+
 	// Step 1: Bind to INADDR_ANY
 	cb := &bacnetlib.ClientBuilder{
 		Ip:         "0.0.0.0",
@@ -1231,6 +1297,7 @@ type ScanResult struct {
 	Step1Verified   bool   `json:"step1_verified"`
 	Step2Discovered bool   `json:"step2_discovered"`
 	DiscoveryPhase  string `json:"discovery_phase"` // broadcast
+	VMAC            string `json:"vmac,omitempty"`  // BACnet/SC virtual MAC
 }
 
 func parseExistingDeviceIDs(params map[string]any) map[int]struct{} {
@@ -260,7 +260,8 @@ func (d *BACnetDriver) deviceKeyForInstance(deviceID int) string {
 }
 
 func (d *BACnetDriver) notifyAddressChange(deviceKey, ip string, port int) {
-	if d.addressNotifier == nil || deviceKey == "" || ip == "" || port <= 0 {
+	// BACnet/SC devices are addressed by VMAC; there is no IP:port to persist.
+	if d.addressNotifier == nil || d.isSC() || deviceKey == "" || ip == "" || port <= 0 {
 		return
 	}
 	d.addressNotifier.OnBACnetAddressDiscovered(deviceKey, ip, port)
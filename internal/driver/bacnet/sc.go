@@ -0,0 +1,142 @@
+package bacnet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bacnetlib "github.com/anviod/bacnet"
+	"github.com/anviod/bacnet/btypes"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/pkg/bacnetsc"
+
+	"go.uber.org/zap"
+)
+
+// BACnet/SC transport support.
+//
+// With channel config `transport: "sc"` the driver replaces the UDP datalink with a
+// BACnet/SC hub connector (`sc: {primary_hub_uri, failover_hub_uri, cert_pem, ...}`).
+// Devices are then addressed by VMAC: either configured per device (`vmac`) or
+// resolved by Who-Is, which the hub fans out to every connected node.
+//
+// BACnet/SC 传输：通道配置 transport=sc 时，以 hub 连接替代 UDP 数据链路，
+// 设备按 VMAC 寻址（设备配置 vmac，或经 hub 转发的 Who-Is 解析）。
+
+// parseSCConfig decodes the nested `sc` object from channel config.
+func parseSCConfig(raw any) (model.BACnetSCConfig, error) {
+	var cfg model.BACnetSCConfig
+	if raw == nil {
+		return cfg, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid bacnet sc config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (d *BACnetDriver) isSC() bool {
+	return d.transport == model.BACnetTransportSC
+}
+
+// dialSCLink opens the hub connection used as the client's datalink.
+func (d *BACnetDriver) dialSCLink(ctx context.Context) (*bacnetsc.HubConnector, error) {
+	d.mu.RLock()
+	nodeID := d.config.ChannelID
+	scCfg := d.scConfig
+	d.mu.RUnlock()
+
+	cfg, err := bacnetsc.ConfigFromModel(nodeID, scCfg)
+	if err != nil {
+		return nil, err
+	}
+	return bacnetsc.Dial(ctx, cfg)
+}
+
+// SCStatus returns the hub connection status, or nil when the channel is not BACnet/SC.
+func (d *BACnetDriver) SCStatus() *bacnetsc.Status {
+	d.mu.RLock()
+	link := d.scLink
+	d.mu.RUnlock()
+	if link == nil {
+		return nil
+	}
+	st := link.Status()
+	return &st
+}
+
+func buildSCDevice(deviceID int, vmac bacnetsc.VMAC) btypes.Device {
+	return btypes.Device{
+		Addr:     btypes.Address{Mac: append([]byte(nil), vmac[:]...), MacLen: 6},
+		ID:       btypes.ObjectID{Type: btypes.DeviceType, Instance: btypes.ObjectInstance(deviceID)},
+		DeviceID: deviceID,
+		MaxApdu:  btypes.MaxAPDU,
+	}
+}
+
+func vmacFromDevice(dev btypes.Device) string {
+	if len(dev.Addr.Mac) < 6 {
+		return ""
+	}
+	var v bacnetsc.VMAC
+	copy(v[:], dev.Addr.Mac[:6])
+	return v.String()
+}
+
+// applySCDeviceConfigLocked creates the device context from a configured VMAC.
+// Returns false when no VMAC is configured and discovery must run instead.
+func (d *BACnetDriver) applySCDeviceConfigLocked(deviceID int, config map[string]any) (bool, error) {
+	raw, _ := config["vmac"].(string)
+	if raw == "" {
+		return false, nil
+	}
+	vmac, err := bacnetsc.ParseVMAC(raw)
+	if err != nil {
+		return false, err
+	}
+	d.applyDiscoveredDeviceLocked(deviceID, "", 0, buildSCDevice(deviceID, vmac))
+	zap.L().Info("SetDeviceConfig: created BACnet/SC device context from VMAC",
+		zap.Int("device_id", deviceID), zap.String("vmac", vmac.String()))
+	return true, nil
+}
+
+// scanSC discovers devices with a global Who-Is sent through the hub.
+func (d *BACnetDriver) scanSC(client bacnetlib.Client, params map[string]any) ([]ScanResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("bacnet scan: BACnet/SC hub connection is not established")
+	}
+	devices, err := client.WhoIs(&bacnetlib.WhoIsOpts{Low: 0, High: 0, GlobalBroadcast: true})
+	if err != nil {
+		zap.L().Warn("BACnet/SC Scan: WhoIs failed", zap.Error(err))
+	}
+
+	unique := make(map[int]btypes.Device)
+	for _, dev := range devices {
+		unique[dev.DeviceID] = dev
+	}
+	existingIDs := parseExistingDeviceIDs(params)
+	results := make([]ScanResult, 0, len(unique))
+	for _, dev := range unique {
+		r := ScanResult{
+			DeviceID:       dev.DeviceID,
+			VMAC:           vmacFromDevice(dev),
+			MaxAPDU:        dev.MaxApdu,
+			Segmentation:   uint32(dev.Segmentation),
+			VendorID:       dev.Vendor,
+			Network:        dev.Addr.Net,
+			Status:         "online",
+			DiscoveryPhase: "sc_whois",
+			ObjectName:     fmt.Sprintf("Device %d", dev.DeviceID),
+			DiffStatus:     "new",
+		}
+		if _, ok := existingIDs[dev.DeviceID]; ok {
+			r.DiffStatus = "existing"
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
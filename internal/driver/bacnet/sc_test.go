@@ -0,0 +1,105 @@
+package bacnet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anviod/bacnet/btypes"
+	"github.com/anviod/edgex/internal/model"
+)
+
+type recordingNotifier struct{ calls int }
+
+func (n *recordingNotifier) OnBACnetAddressDiscovered(string, string, int) { n.calls++ }
+
+func TestInitParsesSCTransport(t *testing.T) {
+	d := NewBACnetDriver().(*BACnetDriver)
+	err := d.Init(model.DriverConfig{ChannelID: "ch-sc", Config: map[string]any{
+		"transport": "sc",
+		"sc": map[string]any{
+			"primary_hub_uri":    "wss://hub.example:443/",
+			"failover_hub_uri":   "wss://hub2.example:443/",
+			"heartbeat_interval": float64(60),
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.isSC() {
+		t.Fatal("transport sc not selected")
+	}
+	if d.scConfig.PrimaryHubURI != "wss://hub.example:443/" || d.scConfig.HeartbeatInterval != 60 {
+		t.Fatalf("sc config = %+v", d.scConfig)
+	}
+
+	ip := NewBACnetDriver().(*BACnetDriver)
+	if err := ip.Init(model.DriverConfig{Config: map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+	if ip.isSC() {
+		t.Fatal("default transport must be BACnet/IP")
+	}
+}
+
+func TestSCConnectRequiresNodeCertificate(t *testing.T) {
+	d := NewBACnetDriver().(*BACnetDriver)
+	_ = d.Init(model.DriverConfig{ChannelID: "ch-sc", Config: map[string]any{
+		"transport": "sc",
+		"sc":        map[string]any{"primary_hub_uri": "wss://127.0.0.1:1/"},
+	}})
+	if err := d.connectOnce(context.Background()); err == nil {
+		t.Fatal("expected connect to fail without a node certificate")
+	}
+	if d.client != nil {
+		t.Fatal("client must not be created when the hub link fails")
+	}
+}
+
+func TestSetDeviceConfigSCUsesVMAC(t *testing.T) {
+	d := NewBACnetDriver().(*BACnetDriver)
+	_ = d.Init(model.DriverConfig{ChannelID: "ch-sc", Config: map[string]any{"transport": "sc"}})
+	notifier := &recordingNotifier{}
+	d.SetBACnetAddressNotifier(notifier)
+
+	err := d.SetDeviceConfig(map[string]any{
+		"bacnet_device_id":    float64(1201),
+		"vmac":                "02:11:22:33:44:55",
+		"_internal_device_id": "dev-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, ok := d.deviceContexts[1201]
+	if !ok || ctx.Scheduler == nil {
+		t.Fatal("device context not created from VMAC")
+	}
+	if ctx.Device.Addr.MacLen != 6 || vmacFromDevice(ctx.Device) != "02:11:22:33:44:55" {
+		t.Fatalf("device address = %+v", ctx.Device.Addr)
+	}
+
+	d.applyDiscoveredDevice(1201, "", 0, buildSCDevice(1201, [6]byte{0x02, 1, 2, 3, 4, 5}))
+	if notifier.calls != 0 {
+		t.Fatal("BACnet/SC discovery must not persist IP:port address updates")
+	}
+
+	if err := d.SetDeviceConfig(map[string]any{"bacnet_device_id": float64(1202), "vmac": "zz"}); err == nil {
+		t.Fatal("invalid VMAC must be rejected")
+	}
+}
+
+func TestScanSCUsesHubClient(t *testing.T) {
+	d := NewBACnetDriver().(*BACnetDriver)
+	_ = d.Init(model.DriverConfig{ChannelID: "ch-sc", Config: map[string]any{"transport": "sc"}})
+
+	dev := buildSCDevice(77, [6]byte{0x02, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE})
+	d.client = &SmartMockClient{Devices: map[int]btypes.Device{77: dev}}
+
+	out, err := d.Scan(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := out.([]ScanResult)
+	if len(results) != 1 || results[0].VMAC != "02:AA:BB:CC:DD:EE" || results[0].DiscoveryPhase != "sc_whois" {
+		t.Fatalf("results = %+v", results)
+	}
+}
@@ -0,0 +1,438 @@
+package ethercat
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ESI (EtherCAT Slave Information) import.
+//
+// The uploaded ESI XML is matched against the slave's vendor ID / product code
+// (device config, or the bus scan at the device position). The default PDO
+// assignment (TxPdo/RxPdo with an Sm attribute) is laid out in order with
+// bit offsets, and every byte- or bit-addressable entry becomes a typed point:
+//
+//	TxPdo entry → "POS:Tx:OFFSET[.BIT]#LE"  (R)
+//	RxPdo entry → "POS:Rx:OFFSET[.BIT]#LE"  (RW)
+//
+// With include_sdo=true the CoE object dictionary (index >= 0x2000) is also
+// mapped to "POS:SDO:0xINDEX:0xSUB#LE" points.
+
+// esiInfo mirrors the parts of an EtherCATInfo document used for import.
+type esiInfo struct {
+	Vendor struct {
+		ID   string `xml:"Id"`
+		Name string `xml:"Name"`
+	} `xml:"Vendor"`
+	Devices []esiDevice `xml:"Descriptions>Devices>Device"`
+}
+
+type esiDevice struct {
+	Type struct {
+		ProductCode string `xml:"ProductCode,attr"`
+		RevisionNo  string `xml:"RevisionNo,attr"`
+		Name        string `xml:",chardata"`
+	} `xml:"Type"`
+	Names   []string      `xml:"Name"`
+	TxPdos  []esiPdo      `xml:"TxPdo"`
+	RxPdos  []esiPdo      `xml:"RxPdo"`
+	Objects []esiObject   `xml:"Profile>Dictionary>Objects>Object"`
+	Types   []esiDataType `xml:"Profile>Dictionary>DataTypes>DataType"`
+}
+
+type esiPdo struct {
+	Sm        string     `xml:"Sm,attr"`
+	Mandatory string     `xml:"Mandatory,attr"`
+	Index     string     `xml:"Index"`
+	Name      string     `xml:"Name"`
+	Entries   []esiEntry `xml:"Entry"`
+}
+
+type esiEntry struct {
+	Index    string `xml:"Index"`
+	SubIndex string `xml:"SubIndex"`
+	BitLen   int    `xml:"BitLen"`
+	Name     string `xml:"Name"`
+	DataType string `xml:"DataType"`
+}
+
+type esiObject struct {
+	Index   string `xml:"Index"`
+	Name    string `xml:"Name"`
+	Type    string `xml:"Type"`
+	BitSize int    `xml:"BitSize"`
+	Access  string `xml:"Flags>Access"`
+}
+
+type esiDataType struct {
+	Name     string       `xml:"Name"`
+	BitSize  int          `xml:"BitSize"`
+	SubItems []esiSubItem `xml:"SubItem"`
+}
+
+type esiSubItem struct {
+	SubIdx string `xml:"SubIdx"`
+	Name   string `xml:"Name"`
+	Type   string `xml:"Type"`
+	Access string `xml:"Flags>Access"`
+}
+
+// esiLayout is the PDO layout built for one ESI device.
+type esiLayout struct {
+	Points    []model.Point
+	Skipped   []driver.ImportSkip
+	TxPDOSize int
+	RxPDOSize int
+}
+
+// parseESI decodes an ESI document. Latin-1 encoded files are accepted.
+func parseESI(data []byte) (*esiInfo, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "iso-8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+			raw, err := io.ReadAll(input)
+			if err != nil {
+				return nil, err
+			}
+			buf := make([]byte, 0, len(raw))
+			for _, b := range raw {
+				buf = utf8.AppendRune(buf, rune(b))
+			}
+			return bytes.NewReader(buf), nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	var info esiInfo
+	if err := dec.Decode(&info); err != nil {
+		return nil, fmt.Errorf("ethercat ESI: invalid XML: %w", err)
+	}
+	if len(info.Devices) == 0 {
+		return nil, fmt.Errorf("ethercat ESI: no device descriptions found")
+	}
+	return &info, nil
+}
+
+// parseESINumber parses ESI numbers: "#x1A00", "0x1A00" or decimal.
+func parseESINumber(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "#x"):
+		return strconv.ParseUint(lower[2:], 16, 64)
+	case strings.HasPrefix(lower, "0x"):
+		return strconv.ParseUint(lower[2:], 16, 64)
+	case s == "":
+		return 0, fmt.Errorf("empty number")
+	default:
+		return strconv.ParseUint(s, 10, 64)
+	}
+}
+
+// matchESIDevice selects the description for vendor/product (and revision
+// when several revisions are present). Zero product matches the only device.
+func matchESIDevice(info *esiInfo, vendorID, productCode, revision uint64) (*esiDevice, error) {
+	if vendorID != 0 {
+		if v, err := parseESINumber(info.Vendor.ID); err == nil && v != vendorID {
+			return nil, fmt.Errorf("ethercat ESI: vendor 0x%08X does not match slave vendor 0x%08X", v, vendorID)
+		}
+	}
+	if productCode == 0 {
+		if len(info.Devices) == 1 {
+			return &info.Devices[0], nil
+		}
+		return nil, fmt.Errorf("ethercat ESI: %d devices described, product_code is required to select one", len(info.Devices))
+	}
+
+	var candidate *esiDevice
+	for i := range info.Devices {
+		dev := &info.Devices[i]
+		pc, err := parseESINumber(dev.Type.ProductCode)
+		if err != nil || pc != productCode {
+			continue
+		}
+		if revision == 0 {
+			return dev, nil
+		}
+		if rev, err := parseESINumber(dev.Type.RevisionNo); err == nil && rev == revision {
+			return dev, nil
+		}
+		if candidate == nil {
+			candidate = dev
+		}
+	}
+	if candidate != nil {
+		return candidate, nil
+	}
+	return nil, fmt.Errorf("ethercat ESI: no device with product code 0x%08X", productCode)
+}
+
+// esiPointType maps an ESI base data type to the decoder data type.
+func esiPointType(dataType string, bitLen int) string {
+	switch strings.ToUpper(strings.TrimSpace(dataType)) {
+	case "BOOL", "BIT", "BIT1":
+		return "bool"
+	case "SINT":
+		return "int8"
+	case "USINT", "BYTE", "BIT8":
+		return "uint8"
+	case "INT":
+		return "int16"
+	case "UINT", "WORD":
+		return "uint16"
+	case "DINT":
+		return "int32"
+	case "UDINT", "DWORD":
+		return "uint32"
+	case "LINT":
+		return "int64"
+	case "ULINT", "LWORD":
+		return "uint64"
+	case "REAL":
+		return "float32"
+	case "LREAL":
+		return "float64"
+	case "":
+		switch bitLen {
+		case 1:
+			return "bool"
+		case 8:
+			return "uint8"
+		case 16:
+			return "uint16"
+		case 32:
+			return "uint32"
+		case 64:
+			return "uint64"
+		}
+	}
+	return ""
+}
+
+// selectedPdos returns the PDOs of the default assignment: those bound to a
+// sync manager or marked mandatory. Devices that do not mark any PDO use all.
+func selectedPdos(pdos []esiPdo) []esiPdo {
+	var out []esiPdo
+	for _, p := range pdos {
+		if p.Sm != "" || p.Mandatory == "1" || strings.EqualFold(p.Mandatory, "true") {
+			out = append(out, p)
+		}
+	}
+	if out == nil {
+		return pdos
+	}
+	return out
+}
+
+// buildESILayout lays out the default Tx/Rx PDO assignment of dev for the
+// slave at position and maps every entry to a point.
+func buildESILayout(dev *esiDevice, position int) *esiLayout {
+	layout := &esiLayout{Points: []model.Point{}, Skipped: []driver.ImportSkip{}}
+	layout.TxPDOSize = layoutPdos(layout, selectedPdos(dev.TxPdos), position, "Tx")
+	layout.RxPDOSize = layoutPdos(layout, selectedPdos(dev.RxPdos), position, "Rx")
+	return layout
+}
+
+func layoutPdos(layout *esiLayout, pdos []esiPdo, position int, pdoType string) int {
+	bitOffset := 0
+	for _, pdo := range pdos {
+		for _, e := range pdo.Entries {
+			start := bitOffset
+			bitOffset += e.BitLen
+			idx, err := parseESINumber(e.Index)
+			if err != nil || idx == 0 {
+				continue // padding / gap entry
+			}
+			sub, _ := parseESINumber(e.SubIndex)
+			ref := fmt.Sprintf("%s 0x%04X:%02X", pdoType, idx, sub)
+
+			dt := esiPointType(e.DataType, e.BitLen)
+			if dt == "" {
+				layout.Skipped = append(layout.Skipped, driver.ImportSkip{NodeID: ref, Reason: fmt.Sprintf("unsupported data type %q", e.DataType)})
+				continue
+			}
+			addr := &ParsedAddress{Position: position, PDOType: pdoType, Offset: start / 8, Bit: -1, Endian: "LE"}
+			if e.BitLen == 1 {
+				addr.Bit = start % 8
+			} else if start%8 != 0 {
+				layout.Skipped = append(layout.Skipped, driver.ImportSkip{NodeID: ref, Reason: fmt.Sprintf("entry at bit offset %d is not byte aligned", start)})
+				continue
+			}
+
+			rw := "R"
+			if pdoType == "Rx" {
+				rw = "RW"
+			}
+			name := strings.TrimSpace(e.Name)
+			if pdoName := strings.TrimSpace(pdo.Name); pdoName != "" && name != "" {
+				name = pdoName + " / " + name
+			} else if name == "" {
+				name = pdoName
+			}
+			layout.Points = append(layout.Points, model.Point{
+				ID:        fmt.Sprintf("%s_%04X_%02X", strings.ToLower(pdoType), idx, sub),
+				Name:      name,
+				Address:   addr.String(),
+				DataType:  dt,
+				ReadWrite: rw,
+				Group:     strings.TrimSpace(pdo.Name),
+			})
+		}
+	}
+	return (bitOffset + 7) / 8
+}
+
+// buildESISDOPoints maps CoE dictionary objects (index >= 0x2000) to SDO points.
+// Record/array objects yield one point per sub-index (sub-index 0 excluded).
+func buildESISDOPoints(dev *esiDevice, position int) ([]model.Point, []driver.ImportSkip) {
+	types := make(map[string]*esiDataType, len(dev.Types))
+	for i := range dev.Types {
+		types[dev.Types[i].Name] = &dev.Types[i]
+	}
+	var points []model.Point
+	var skipped []driver.ImportSkip
+
+	add := func(idx, sub uint64, name, dataType string, bitSize int, access string) {
+		ref := fmt.Sprintf("SDO 0x%04X:%02X", idx, sub)
+		dt := esiPointType(dataType, bitSize)
+		if dt == "" {
+			skipped = append(skipped, driver.ImportSkip{NodeID: ref, Reason: fmt.Sprintf("unsupported data type %q", dataType)})
+			return
+		}
+		rw := "R"
+		if strings.Contains(strings.ToLower(access), "w") {
+			rw = "RW"
+		}
+		points = append(points, model.Point{
+			ID:        fmt.Sprintf("sdo_%04X_%02X", idx, sub),
+			Name:      name,
+			Address:   fmt.Sprintf("%d:SDO:0x%04X:0x%02X#LE", position, idx, sub),
+			DataType:  dt,
+			ReadWrite: rw,
+			Group:     "SDO",
+		})
+	}
+
+	for _, obj := range dev.Objects {
+		idx, err := parseESINumber(obj.Index)
+		if err != nil || idx < 0x2000 {
+			continue
+		}
+		name := strings.TrimSpace(obj.Name)
+		dt, ok := types[obj.Type]
+		if !ok || len(dt.SubItems) == 0 {
+			add(idx, 0, name, obj.Type, obj.BitSize, obj.Access)
+			continue
+		}
+		for _, si := range dt.SubItems {
+			sub, err := parseESINumber(si.SubIdx)
+			if err != nil || sub == 0 {
+				continue
+			}
+			typ := si.Type
+			bits := 0
+			if nested, ok := types[typ]; ok {
+				bits = nested.BitSize
+			}
+			access := si.Access
+			if access == "" {
+				access = obj.Access
+			}
+			add(idx, sub, name+" / "+strings.TrimSpace(si.Name), typ, bits, access)
+		}
+	}
+	return points, skipped
+}
+
+// ImportDeviceDescription implements driver.DescriptionImporter for ESI files.
+// params: position, vendor_id, product_code, revision (device config) and
+// include_sdo. Missing identity fields are taken from the bus scan.
+func (d *EtherCATDriver) ImportDeviceDescription(ctx context.Context, data []byte, params map[string]any) (*driver.DescriptionImport, error) {
+	info, err := parseESI(data)
+	if err != nil {
+		return nil, err
+	}
+	position := firstInt(params, "position")
+	if position <= 0 {
+		return nil, fmt.Errorf("ethercat ESI: device position is required")
+	}
+
+	vendorID, _ := parseESINumber(firstString(params, "vendor_id", "vendorId"))
+	productCode, _ := parseESINumber(firstString(params, "product_code", "productCode"))
+	revision, _ := parseESINumber(firstString(params, "revision"))
+	if productCode == 0 {
+		if sl, ok := d.scannedSlave(position); ok {
+			vendorID, productCode, revision = uint64(sl.VendorID), uint64(sl.ProductCode), uint64(sl.Revision)
+		}
+	}
+
+	dev, err := matchESIDevice(info, vendorID, productCode, revision)
+	if err != nil {
+		return nil, err
+	}
+	layout := buildESILayout(dev, position)
+	if firstBool(params, "include_sdo", "includeSdo") {
+		pts, skipped := buildESISDOPoints(dev, position)
+		layout.Points = append(layout.Points, pts...)
+		layout.Skipped = append(layout.Skipped, skipped...)
+	}
+
+	pc, _ := parseESINumber(dev.Type.ProductCode)
+	rev, _ := parseESINumber(dev.Type.RevisionNo)
+	vid, _ := parseESINumber(info.Vendor.ID)
+	devName := strings.TrimSpace(dev.Type.Name)
+	if len(dev.Names) > 0 {
+		devName = strings.TrimSpace(dev.Names[0])
+	}
+
+	zap.L().Info("ethercat: ESI imported",
+		zap.Int("position", position),
+		zap.String("type", strings.TrimSpace(dev.Type.Name)),
+		zap.Int("points", len(layout.Points)),
+		zap.Int("skipped", len(layout.Skipped)),
+	)
+	return &driver.DescriptionImport{
+		Points:  layout.Points,
+		Skipped: layout.Skipped,
+		DeviceConfig: map[string]any{
+			"vendor_id":    fmt.Sprintf("0x%08X", vid),
+			"product_code": fmt.Sprintf("0x%08X", pc),
+			"revision":     fmt.Sprintf("0x%08X", rev),
+			"tx_pdo_size":  layout.TxPDOSize,
+			"rx_pdo_size":  layout.RxPDOSize,
+		},
+		Info: map[string]any{
+			"vendor": strings.TrimSpace(info.Vendor.Name),
+			"type":   strings.TrimSpace(dev.Type.Name),
+			"name":   devName,
+		},
+	}, nil
+}
+
+// scannedSlave returns the bus scan entry at position when the master is up.
+func (d *EtherCATDriver) scannedSlave(position int) (slaveInfo, bool) {
+	if d.transport == nil || !d.transport.IsConnected() || d.transport.master == nil {
+		return slaveInfo{}, false
+	}
+	slaves, err := d.transport.master.scanSlaves()
+	if err != nil {
+		return slaveInfo{}, false
+	}
+	for _, sl := range slaves {
+		if sl.Position == position {
+			return sl, true
+		}
+	}
+	return slaveInfo{}, false
+}
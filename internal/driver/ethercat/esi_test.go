@@ -0,0 +1,197 @@
+package ethercat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testESI = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<EtherCATInfo>
+  <Vendor><Id>#x00000002</Id><Name>Beckhoff Automation GmbH &amp; Co. KG</Name></Vendor>
+  <Descriptions>
+    <Devices>
+      <Device>
+        <Type ProductCode="#x03ec3052" RevisionNo="#x00100000">EL1004</Type>
+        <Name>EL1004 4Ch. Dig. Input 24V</Name>
+      </Device>
+      <Device>
+        <Type ProductCode="#x07D43052" RevisionNo="#x00010000">EL2004-MIX</Type>
+        <Name>Mixed I/O Terminal</Name>
+        <TxPdo Fixed="1" Sm="3">
+          <Index>#x1a00</Index><Name>Status</Name>
+          <Entry><Index>#x6000</Index><SubIndex>1</SubIndex><BitLen>1</BitLen><Name>Input 1</Name><DataType>BOOL</DataType></Entry>
+          <Entry><Index>#x6000</Index><SubIndex>2</SubIndex><BitLen>1</BitLen><Name>Input 2</Name><DataType>BOOL</DataType></Entry>
+          <Entry><Index>#x0</Index><BitLen>6</BitLen></Entry>
+          <Entry><Index>#x6010</Index><SubIndex>#x11</SubIndex><BitLen>16</BitLen><Name>Value</Name><DataType>INT</DataType></Entry>
+          <Entry><Index>#x6010</Index><SubIndex>#x12</SubIndex><BitLen>2</BitLen><Name>State</Name><DataType>BIT2</DataType></Entry>
+        </TxPdo>
+        <TxPdo>
+          <Index>#x1a10</Index><Name>Optional</Name>
+          <Entry><Index>#x6020</Index><SubIndex>1</SubIndex><BitLen>32</BitLen><Name>Extra</Name><DataType>UDINT</DataType></Entry>
+        </TxPdo>
+        <RxPdo Fixed="1" Sm="2">
+          <Index>#x1600</Index><Name>Outputs</Name>
+          <Entry><Index>#x7000</Index><SubIndex>1</SubIndex><BitLen>1</BitLen><Name>Output 1</Name><DataType>BOOL</DataType></Entry>
+          <Entry><Index>#x7000</Index><SubIndex>2</SubIndex><BitLen>1</BitLen><Name>Output 2</Name><DataType>BOOL</DataType></Entry>
+          <Entry><Index>#x0</Index><BitLen>6</BitLen></Entry>
+          <Entry><Index>#x7010</Index><SubIndex>1</SubIndex><BitLen>32</BitLen><Name>Setpoint</Name><DataType>REAL</DataType></Entry>
+        </RxPdo>
+        <Profile>
+          <Dictionary>
+            <DataTypes>
+              <DataType><Name>UINT</Name><BitSize>16</BitSize></DataType>
+              <DataType>
+                <Name>DT8000</Name><BitSize>48</BitSize>
+                <SubItem><SubIdx>0</SubIdx><Name>SubIndex 000</Name><Type>USINT</Type></SubItem>
+                <SubItem><SubIdx>1</SubIdx><Name>Filter</Name><Type>UINT</Type><Flags><Access>rw</Access></Flags></SubItem>
+                <SubItem><SubIdx>17</SubIdx><Name>Comment</Name><Type>STRING(8)</Type></SubItem>
+              </DataType>
+            </DataTypes>
+            <Objects>
+              <Object><Index>#x1000</Index><Name>Device type</Name><Type>UDINT</Type><BitSize>32</BitSize></Object>
+              <Object><Index>#x8000</Index><Name>Settings</Name><Type>DT8000</Type><Flags><Access>ro</Access></Flags></Object>
+              <Object><Index>#xF800</Index><Name>Mode</Name><Type>USINT</Type><BitSize>8</BitSize><Flags><Access>rw</Access></Flags></Object>
+            </Objects>
+          </Dictionary>
+        </Profile>
+      </Device>
+    </Devices>
+  </Descriptions>
+</EtherCATInfo>`
+
+func TestESI_ParseAndMatch(t *testing.T) {
+	info, err := parseESI([]byte(testESI))
+	require.NoError(t, err)
+	assert.Equal(t, "Beckhoff Automation GmbH & Co. KG", info.Vendor.Name)
+	require.Len(t, info.Devices, 2)
+
+	dev, err := matchESIDevice(info, 2, 0x07D43052, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "EL2004-MIX", dev.Type.Name)
+
+	_, err = matchESIDevice(info, 3, 0x07D43052, 0)
+	assert.ErrorContains(t, err, "vendor")
+	_, err = matchESIDevice(info, 2, 0x1234, 0)
+	assert.ErrorContains(t, err, "no device")
+	_, err = matchESIDevice(info, 0, 0, 0)
+	assert.ErrorContains(t, err, "product_code is required")
+
+	_, err = parseESI([]byte("<EtherCATInfo/>"))
+	assert.Error(t, err)
+
+	n, err := parseESINumber("#x1A00")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x1A00), n)
+	n, err = parseESINumber("17")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(17), n)
+}
+
+func TestESI_BuildLayout(t *testing.T) {
+	info, err := parseESI([]byte(testESI))
+	require.NoError(t, err)
+	dev, err := matchESIDevice(info, 2, 0x07D43052, 0)
+	require.NoError(t, err)
+
+	layout := buildESILayout(dev, 3)
+	// Tx: 2 bits + 6 pad + INT + BIT2 = 26 bits → 4 bytes; optional PDO excluded.
+	assert.Equal(t, 4, layout.TxPDOSize)
+	// Rx: 2 bits + 6 pad + REAL = 40 bits → 5 bytes.
+	assert.Equal(t, 5, layout.RxPDOSize)
+
+	byID := map[string]model.Point{}
+	for _, p := range layout.Points {
+		byID[p.ID] = p
+		_, err := ParseAddress(p.Address)
+		require.NoError(t, err, p.Address)
+	}
+	assert.Len(t, byID, 6)
+	assert.Equal(t, "3:Tx:0.1#LE", byID["tx_6000_02"].Address)
+	assert.Equal(t, "bool", byID["tx_6000_02"].DataType)
+	assert.Equal(t, "Status / Input 2", byID["tx_6000_02"].Name)
+	assert.Equal(t, "R", byID["tx_6000_02"].ReadWrite)
+	assert.Equal(t, "3:Tx:1#LE", byID["tx_6010_11"].Address)
+	assert.Equal(t, "int16", byID["tx_6010_11"].DataType)
+	assert.Equal(t, "3:Rx:0.1#LE", byID["rx_7000_02"].Address)
+	assert.Equal(t, "RW", byID["rx_7000_02"].ReadWrite)
+	assert.Equal(t, "3:Rx:1#LE", byID["rx_7010_01"].Address)
+	assert.Equal(t, "float32", byID["rx_7010_01"].DataType)
+	assert.NotContains(t, byID, "tx_6020_01")
+
+	require.Len(t, layout.Skipped, 1)
+	assert.Contains(t, layout.Skipped[0].Reason, "BIT2")
+
+	sdo, skipped := buildESISDOPoints(dev, 3)
+	sdoByID := map[string]model.Point{}
+	for _, p := range sdo {
+		sdoByID[p.ID] = p
+		addr, err := ParseAddress(p.Address)
+		require.NoError(t, err, p.Address)
+		assert.True(t, addr.IsSDO)
+	}
+	assert.NotContains(t, sdoByID, "sdo_1000_00", "communication area is excluded")
+	assert.Equal(t, "3:SDO:0x8000:0x01#LE", sdoByID["sdo_8000_01"].Address)
+	assert.Equal(t, "uint16", sdoByID["sdo_8000_01"].DataType)
+	assert.Equal(t, "RW", sdoByID["sdo_8000_01"].ReadWrite)
+	assert.Equal(t, "uint8", sdoByID["sdo_F800_00"].DataType)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "SDO 0x8000:11", skipped[0].NodeID)
+
+	addr, err := ParseAddress(sdoByID["sdo_8000_01"].Address)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x8000), addr.Index)
+	assert.Equal(t, uint16(1), addr.SubIndex)
+}
+
+func TestESI_ImportDeviceDescriptionFromScan(t *testing.T) {
+	transport := newSimulationTransport()
+	defer transport.Disconnect()
+	d := &EtherCATDriver{
+		transport: transport,
+		scheduler: NewEtherCATScheduler(transport, NewEtherCATDecoder()),
+		decoder:   NewEtherCATDecoder(),
+	}
+
+	// Product code comes from the bus scan of slave 1.
+	res, err := d.ImportDeviceDescription(context.Background(), []byte(testESI), map[string]any{
+		"position":    1,
+		"include_sdo": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "EL2004-MIX", res.Info["type"])
+	assert.Equal(t, "0x07D43052", res.DeviceConfig["product_code"])
+	assert.Equal(t, 4, res.DeviceConfig["tx_pdo_size"])
+	assert.Len(t, res.Points, 6+2)
+
+	// Slave 2 has an unknown product code.
+	_, err = d.ImportDeviceDescription(context.Background(), []byte(testESI), map[string]any{"position": 2})
+	assert.Error(t, err)
+	_, err = d.ImportDeviceDescription(context.Background(), []byte(testESI), map[string]any{})
+	assert.ErrorContains(t, err, "position")
+}
+
+func TestScheduler_RxBitWriteKeepsNeighbours(t *testing.T) {
+	transport := newSimulationTransport()
+	defer transport.Disconnect()
+	s := NewEtherCATScheduler(transport, NewEtherCATDecoder())
+	ctx := context.Background()
+
+	out1 := model.Point{ID: "o1", Address: "1:Rx:0.0#LE", DataType: "bool"}
+	out2 := model.Point{ID: "o2", Address: "1:Rx:0.1#LE", DataType: "bool"}
+	require.NoError(t, s.WritePoint(ctx, out1, true))
+	require.NoError(t, s.WritePoint(ctx, out2, true))
+	require.NoError(t, s.WritePoint(ctx, out1, false))
+
+	sim := transport.master.(*simulatorMaster)
+	assert.Equal(t, byte(0x02), sim.getRxPDO(1)[0])
+
+	// Output points read back the RxPDO buffer, not the TxPDO image.
+	vals, err := s.ReadPoints(ctx, []model.Point{out1, out2})
+	require.NoError(t, err)
+	assert.Equal(t, false, vals["o1"].Value)
+	assert.Equal(t, true, vals["o2"].Value)
+}
@@ -230,4 +230,5 @@ var (
 	_ driver.Driver                   = (*EtherCATDriver)(nil)
 	_ driver.Scanner                  = (*EtherCATDriver)(nil)
 	_ driver.DeviceCollectionResetter = (*EtherCATDriver)(nil)
+	_ driver.DescriptionImporter      = (*EtherCATDriver)(nil)
 )
@@ -3,6 +3,7 @@ package ethercat
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/anviod/edgex/internal/model"
@@ -96,7 +97,15 @@ func (s *EtherCATScheduler) readPDOValue(p model.Point, addr *ParsedAddress) (mo
 		byteSize = 1
 	}
 
-	data := s.transport.getTxPDOSnapshot(addr.Position, addr.Offset, byteSize)
+	var data []byte
+	if strings.EqualFold(addr.PDOType, "Rx") {
+		// Output entries read back the RxPDO buffer the gateway last wrote.
+		if buf := s.transport.getRxPDOBuffer(addr.Position); addr.Offset+byteSize <= len(buf) {
+			data = buf[addr.Offset : addr.Offset+byteSize]
+		}
+	} else {
+		data = s.transport.getTxPDOSnapshot(addr.Position, addr.Offset, byteSize)
+	}
 	if data == nil {
 		return model.Value{
 			PointID: p.ID,
@@ -204,6 +213,15 @@ func (s *EtherCATScheduler) WritePoint(ctx context.Context, p model.Point, value
 		return s.transport.writeSDO(ctx, addr.Position, addr.Index, addr.SubIndex, data)
 	}
 
+	// PDO bit write: read-modify-write so neighbouring bits of the byte are kept
+	if addr.Bit >= 0 {
+		on, err := toBool(value)
+		if err != nil {
+			return fmt.Errorf("ethercat WritePoint: encode: %w", err)
+		}
+		return s.transport.setRxPDOBit(addr.Position, addr.Offset, addr.Bit, on)
+	}
+
 	// PDO write: encode and write to RxPDO buffer
 	data, err := s.decoder.EncodeValue(value, p.DataType, addr)
 	if err != nil {
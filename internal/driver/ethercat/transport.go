@@ -307,6 +307,29 @@ func (t *EtherCATTransport) setRxPDOBuffer(position, offset int, data []byte) er
 	return nil
 }
 
+// setRxPDOBit sets or clears one bit of the RxPDO buffer at the given byte offset.
+func (t *EtherCATTransport) setRxPDOBit(position, offset, bit int, on bool) error {
+	val, ok := t.rxBuffers.Load(position)
+	if !ok {
+		return fmt.Errorf("ethercat: no RxPDO buffer for slave position %d", position)
+	}
+	buf := val.(*rxBuffer)
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+
+	if offset >= len(buf.data) {
+		return fmt.Errorf("ethercat: RxPDO bit offset %d exceeds buffer size %d for slave %d",
+			offset, len(buf.data), position)
+	}
+	if on {
+		buf.data[offset] |= 1 << uint(bit)
+	} else {
+		buf.data[offset] &^= 1 << uint(bit)
+	}
+	t.master.setRxPDO(position, buf.data)
+	return nil
+}
+
 // readSDO performs a CoE SDO read from the given slave.
 func (t *EtherCATTransport) readSDO(ctx context.Context, position int, index, subindex uint16) ([]byte, error) {
 	if t.master == nil {
@@ -51,6 +51,61 @@ type ObjectScanner interface {
 	ScanObjects(ctx context.Context, config map[string]any) (any, error)
 }
 
+// AddressSpaceBrowser is an optional interface for drivers with a hierarchical
+// address space (e.g. OPC UA). BrowseAddressSpace returns one level of children
+// per call so UIs can expand the tree lazily; ResolveImportPoints expands the
+// selected nodes (variables or whole subtrees) into point definitions.
+type AddressSpaceBrowser interface {
+	BrowseAddressSpace(ctx context.Context, params map[string]any) (any, error)
+	ResolveImportPoints(ctx context.Context, params map[string]any) ([]model.Point, []ImportSkip, error)
+}
+
+// ImportSkip records a browsed node that could not be mapped to a point.
+type ImportSkip struct {
+	NodeID string `json:"node_id"`
+	Reason string `json:"reason"`
+}
+
+// DescriptionImporter is optional. Drivers whose devices ship a description file
+// (EtherCAT ESI, PROFINET GSDML) implement it to turn an uploaded file into
+// point definitions. params carry the device config plus request options.
+type DescriptionImporter interface {
+	ImportDeviceDescription(ctx context.Context, data []byte, params map[string]any) (*DescriptionImport, error)
+}
+
+// DescriptionImport is the outcome of parsing a device description file.
+// DeviceConfig holds device settings derived from the file (e.g. PDO image
+// sizes) that should be merged into the device config.
+type DescriptionImport struct {
+	Points       []model.Point  `json:"points"`
+	Skipped      []ImportSkip   `json:"skipped"`
+	DeviceConfig map[string]any `json:"device_config,omitempty"`
+	Info         map[string]any `json:"info,omitempty"`
+}
+
+// ValuePublisher receives values a driver pushes outside the polling cycle.
+type ValuePublisher func(v model.Value)
+
+// ValuePublisherSetter is optional. Event-driven drivers (e.g. OPC UA event
+// monitored items) implement it to push notifications straight to the gateway.
+type ValuePublisherSetter interface {
+	SetValuePublisher(ValuePublisher)
+}
+
+// MethodCall describes a server-side method invocation. Args are plain values,
+// or {"type": "<DataType>", "value": v} objects to force an argument type.
+type MethodCall struct {
+	ObjectID string `json:"object_id"`
+	MethodID string `json:"method_id"`
+	Args     []any  `json:"args"`
+}
+
+// MethodCaller is optional. Drivers that can invoke server methods (OPC UA
+// Call service) implement it; the result holds the output arguments.
+type MethodCaller interface {
+	CallMethod(ctx context.Context, call MethodCall) ([]any, error)
+}
+
 // BACnetAddressNotifier receives runtime BACnet address updates (e.g. UDP port change after reboot).
 type BACnetAddressNotifier interface {
 	OnBACnetAddressDiscovered(deviceKey, ip string, port int)
@@ -0,0 +1,643 @@
+package opcua
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"go.uber.org/zap"
+)
+
+// Address-space browser and bulk point import.
+//
+// BrowseAddressSpace returns the children of a single node (References +
+// BrowseNext), so the UI can expand the server hierarchy on demand instead of
+// waiting for a full recursive ScanObjects. ResolveImportPoints walks the
+// selected subtrees and maps every Variable to a point definition.
+//
+// 地址空间浏览：每次只浏览一个节点的子节点（懒加载）；导入时展开所选子树并映射为点位。
+
+const (
+	defaultBrowseLimit     = 200
+	maxBrowseLimit         = 1000
+	defaultImportDepth     = 5
+	defaultImportMaxPoints = 2000
+	browseEnrichChunk      = 50
+	defaultBrowseTimeout   = 30 * time.Second
+	engineeringUnitsName   = "EngineeringUnits"
+)
+
+// BrowseNode is one child reference returned by BrowseAddressSpace.
+type BrowseNode struct {
+	NodeID           string `json:"node_id"`
+	BrowseName       string `json:"browse_name"`
+	DisplayName      string `json:"display_name"`
+	Class            string `json:"class"`
+	Type             string `json:"type"` // Folder / Variable, same as ScanObjects
+	HasChildren      bool   `json:"has_children"`
+	DataType         string `json:"data_type,omitempty"`
+	AccessLevel      string `json:"access_level,omitempty"`
+	EngineeringUnits string `json:"engineering_units,omitempty"`
+	UnitID           int32  `json:"unit_id,omitempty"`
+	Description      string `json:"description,omitempty"`
+	PointDataType    string `json:"point_datatype,omitempty"` // mapped point datatype; empty when import is unsupported
+
+	accessLevel byte
+}
+
+// BrowsePage is one page of children of NodeID.
+type BrowsePage struct {
+	NodeID   string       `json:"node_id"`
+	Children []BrowseNode `json:"children"`
+	Total    int          `json:"total"`
+	Offset   int          `json:"offset"`
+	Limit    int          `json:"limit"`
+	HasMore  bool         `json:"has_more"`
+}
+
+// BrowseAddressSpace browses one level below params["node_id"] (default: Objects folder).
+// Paging: params["offset"], params["limit"] (default 200, max 1000).
+func (d *OpcUaDriver) BrowseAddressSpace(ctx context.Context, params map[string]any) (any, error) {
+	if params == nil {
+		params = map[string]any{}
+	}
+	nodeID, err := parseBrowseNodeID(params["node_id"])
+	if err != nil {
+		return nil, err
+	}
+	offset := browseIntParam(params, "offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := browseIntParam(params, "limit", defaultBrowseLimit)
+	if limit <= 0 {
+		limit = defaultBrowseLimit
+	} else if limit > maxBrowseLimit {
+		limit = maxBrowseLimit
+	}
+
+	browseCtx, cancel := withDefaultTimeout(ctx, defaultBrowseTimeout)
+	defer cancel()
+
+	c, err := d.openBrowseClient(browseCtx, params)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close(context.Background())
+
+	refs, err := d.fetchReferences(browseCtx, c, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("browse %s failed: %v", nodeID.String(), err)
+	}
+	refs = filterBrowseReferences(refs)
+
+	page := BrowsePage{NodeID: nodeID.String(), Total: len(refs), Offset: offset, Limit: limit, Children: []BrowseNode{}}
+	if offset >= len(refs) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(refs) {
+		end = len(refs)
+	}
+	page.Children = d.describeReferences(browseCtx, c, refs[offset:end])
+	page.HasMore = end < len(refs)
+	return page, nil
+}
+
+// ResolveImportPoints expands params["node_ids"] into point definitions.
+// Variables map directly; Objects are walked up to params["max_depth"] levels
+// (default 5). At most params["max_points"] points are returned (default 2000).
+// Points are grouped by their folder path relative to the selected node.
+func (d *OpcUaDriver) ResolveImportPoints(ctx context.Context, params map[string]any) ([]model.Point, []driver.ImportSkip, error) {
+	if params == nil {
+		params = map[string]any{}
+	}
+	roots, err := parseBrowseNodeIDs(params["node_ids"])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roots) == 0 {
+		return nil, nil, fmt.Errorf("node_ids is required")
+	}
+	maxDepth := browseIntParam(params, "max_depth", defaultImportDepth)
+	maxPoints := browseIntParam(params, "max_points", defaultImportMaxPoints)
+	if maxPoints <= 0 {
+		maxPoints = defaultImportMaxPoints
+	}
+
+	importCtx, cancel := withDefaultTimeout(ctx, 180*time.Second)
+	defer cancel()
+
+	c, err := d.openBrowseClient(importCtx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close(context.Background())
+
+	rootNodes, err := d.describeNodes(importCtx, c, roots)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imp := &pointImporter{maxPoints: maxPoints, seen: make(map[string]struct{})}
+	for _, root := range rootNodes {
+		if imp.full() {
+			break
+		}
+		switch root.Type {
+		case "Variable":
+			imp.add(root, "")
+		case "Folder":
+			if err := d.importSubtree(importCtx, c, imp, root, root.DisplayName, 0, maxDepth); err != nil {
+				return imp.points, imp.skipped, err
+			}
+		default:
+			imp.skip(root.NodeID, "node is neither an object nor a variable")
+		}
+	}
+	return imp.points, imp.skipped, nil
+}
+
+type pointImporter struct {
+	maxPoints int
+	points    []model.Point
+	skipped   []driver.ImportSkip
+	seen      map[string]struct{}
+	truncated bool
+}
+
+func (imp *pointImporter) full() bool {
+	return len(imp.points) >= imp.maxPoints
+}
+
+func (imp *pointImporter) skip(nodeID, reason string) {
+	imp.skipped = append(imp.skipped, driver.ImportSkip{NodeID: nodeID, Reason: reason})
+}
+
+func (imp *pointImporter) add(n BrowseNode, group string) {
+	if _, ok := imp.seen[n.NodeID]; ok {
+		return
+	}
+	imp.seen[n.NodeID] = struct{}{}
+	if imp.full() {
+		if !imp.truncated {
+			imp.truncated = true
+			imp.skip(n.NodeID, fmt.Sprintf("max_points (%d) reached", imp.maxPoints))
+		}
+		return
+	}
+	p, reason := browseNodeToPoint(n, group)
+	if reason != "" {
+		imp.skip(n.NodeID, reason)
+		return
+	}
+	imp.points = append(imp.points, p)
+}
+
+func (d *OpcUaDriver) importSubtree(ctx context.Context, c *opcua.Client, imp *pointImporter, folder BrowseNode, group string, depth, maxDepth int) error {
+	if depth >= maxDepth || imp.full() {
+		return nil
+	}
+	id, err := ua.ParseNodeID(folder.NodeID)
+	if err != nil {
+		return nil
+	}
+	refs, err := d.fetchReferences(ctx, c, id)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		imp.skip(folder.NodeID, "browse failed: "+err.Error())
+		return nil
+	}
+	children := d.describeReferences(ctx, c, filterBrowseReferences(refs))
+	for _, child := range children {
+		if child.Type == "Variable" {
+			imp.add(child, group)
+		}
+	}
+	for _, child := range children {
+		if child.Type != "Folder" {
+			continue
+		}
+		if err := d.importSubtree(ctx, c, imp, child, joinBrowsePath(group, child.DisplayName), depth+1, maxDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openBrowseClient creates and connects a short-lived client for browsing,
+// so long address-space walks never contend with the collection session.
+func (d *OpcUaDriver) openBrowseClient(ctx context.Context, config map[string]any) (*opcua.Client, error) {
+	endpoint, err := d.resolveEndpointInConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := d.buildClientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	c, err := opcua.NewClient(endpoint, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scan client: %v", err)
+	}
+	if err := c.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect: %v", err)
+	}
+	return c, nil
+}
+
+// describeReferences converts browse references into BrowseNodes and reads
+// DataType / AccessLevel / Description / EngineeringUnits for variables.
+func (d *OpcUaDriver) describeReferences(ctx context.Context, c *opcua.Client, refs []ua.ReferenceDescription) []BrowseNode {
+	nodes := make([]BrowseNode, 0, len(refs))
+	var variables []int
+	for _, ref := range refs {
+		n := BrowseNode{
+			NodeID: ref.NodeID.NodeID.String(),
+			Class:  ref.NodeClass.String(),
+		}
+		if ref.BrowseName != nil {
+			n.BrowseName = ref.BrowseName.Name
+		}
+		if ref.DisplayName != nil {
+			n.DisplayName = ref.DisplayName.Text
+		}
+		if n.DisplayName == "" {
+			n.DisplayName = n.BrowseName
+		}
+		switch ref.NodeClass {
+		case ua.NodeClassVariable:
+			n.Type = "Variable"
+			variables = append(variables, len(nodes))
+		case ua.NodeClassObject:
+			n.Type = "Folder"
+			n.HasChildren = true // resolved lazily when the folder is expanded
+		}
+		nodes = append(nodes, n)
+	}
+
+	for start := 0; start < len(variables); start += browseEnrichChunk {
+		end := start + browseEnrichChunk
+		if end > len(variables) {
+			end = len(variables)
+		}
+		d.enrichVariables(ctx, c, nodes, variables[start:end])
+	}
+	return nodes
+}
+
+// describeNodes reads NodeClass / BrowseName / DisplayName of explicitly selected
+// nodes (import roots) and enriches the variables among them.
+func (d *OpcUaDriver) describeNodes(ctx context.Context, c *opcua.Client, ids []*ua.NodeID) ([]BrowseNode, error) {
+	req := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	for _, id := range ids {
+		req.NodesToRead = append(req.NodesToRead,
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDNodeClass},
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDBrowseName},
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDDisplayName},
+		)
+	}
+	resp, err := c.Read(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("read node attributes failed: %v", err)
+	}
+	if len(resp.Results) != len(req.NodesToRead) {
+		return nil, fmt.Errorf("read node attributes: got %d results, want %d", len(resp.Results), len(req.NodesToRead))
+	}
+
+	refs := make([]ua.ReferenceDescription, 0, len(ids))
+	for i, id := range ids {
+		classRes, nameRes, displayRes := resp.Results[3*i], resp.Results[3*i+1], resp.Results[3*i+2]
+		if classRes.Status != ua.StatusOK || classRes.Value == nil {
+			return nil, fmt.Errorf("node %s not found: %v", id.String(), classRes.Status)
+		}
+		ref := ua.ReferenceDescription{NodeID: ua.NewExpandedNodeID(id, "", 0)}
+		switch v := classRes.Value.Value().(type) {
+		case int32:
+			ref.NodeClass = ua.NodeClass(v)
+		case uint32:
+			ref.NodeClass = ua.NodeClass(v)
+		}
+		if nameRes.Status == ua.StatusOK && nameRes.Value != nil {
+			if qn, ok := nameRes.Value.Value().(*ua.QualifiedName); ok {
+				ref.BrowseName = qn
+			}
+		}
+		if displayRes.Status == ua.StatusOK && displayRes.Value != nil {
+			if lt, ok := displayRes.Value.Value().(*ua.LocalizedText); ok {
+				ref.DisplayName = lt
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return d.describeReferences(ctx, c, refs), nil
+}
+
+// enrichVariables fills attributes of nodes[idx] for one chunk of variables:
+// one Read for DataType/AccessLevel/Description, one Browse for child
+// properties (EngineeringUnits) and one Read for the EUInformation values.
+func (d *OpcUaDriver) enrichVariables(ctx context.Context, c *opcua.Client, nodes []BrowseNode, idx []int) {
+	ids := make([]*ua.NodeID, len(idx))
+	readReq := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	browseReq := &ua.BrowseRequest{}
+	for i, ni := range idx {
+		id, err := ua.ParseNodeID(nodes[ni].NodeID)
+		if err != nil {
+			return
+		}
+		ids[i] = id
+		readReq.NodesToRead = append(readReq.NodesToRead,
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDDataType},
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDAccessLevel},
+			&ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDDescription},
+		)
+		browseReq.NodesToBrowse = append(browseReq.NodesToBrowse, &ua.BrowseDescription{
+			NodeID:          id,
+			BrowseDirection: ua.BrowseDirectionForward,
+			ReferenceTypeID: ua.NewNumericNodeID(0, 33), // HierarchicalReferences
+			IncludeSubtypes: true,
+			NodeClassMask:   uint32(ua.NodeClassObject | ua.NodeClassVariable),
+			ResultMask:      uint32(ua.BrowseResultMaskBrowseName),
+		})
+	}
+
+	if resp, err := c.Read(ctx, readReq); err != nil {
+		zap.L().Warn("OPC UA browse: read variable attributes failed", zap.Error(err))
+	} else {
+		for j, res := range resp.Results {
+			if j/3 >= len(idx) || res.Status != ua.StatusOK || res.Value == nil {
+				continue
+			}
+			n := &nodes[idx[j/3]]
+			switch v := res.Value.Value().(type) {
+			case *ua.NodeID:
+				n.DataType = lookupDataType(v)
+			case byte:
+				n.accessLevel = v
+				n.AccessLevel = lookupAccessLevel(v)
+			case *ua.LocalizedText:
+				if v != nil {
+					n.Description = v.Text
+				}
+			}
+		}
+	}
+
+	for _, ni := range idx {
+		nodes[ni].PointDataType = mapBrowseDataType(nodes[ni].DataType)
+	}
+
+	euNodes := make(map[int]*ua.NodeID)
+	if resp, err := c.Browse(ctx, browseReq); err != nil {
+		zap.L().Warn("OPC UA browse: browse variable properties failed", zap.Error(err))
+	} else {
+		var release [][]byte
+		for j, res := range resp.Results {
+			if j >= len(idx) || res.StatusCode != ua.StatusOK {
+				continue
+			}
+			nodes[idx[j]].HasChildren = len(res.References) > 0 || len(res.ContinuationPoint) > 0
+			for _, ref := range res.References {
+				if ref.BrowseName != nil && ref.BrowseName.Name == engineeringUnitsName {
+					euNodes[j] = ref.NodeID.NodeID
+					break
+				}
+			}
+			if len(res.ContinuationPoint) > 0 {
+				release = append(release, res.ContinuationPoint)
+			}
+		}
+		if len(release) > 0 {
+			_, _ = c.BrowseNext(ctx, &ua.BrowseNextRequest{ReleaseContinuationPoints: true, ContinuationPoints: release})
+		}
+	}
+	if len(euNodes) == 0 {
+		return
+	}
+
+	euReq := &ua.ReadRequest{TimestampsToReturn: ua.TimestampsToReturnNeither}
+	order := make([]int, 0, len(euNodes))
+	for j := range idx {
+		if id, ok := euNodes[j]; ok {
+			euReq.NodesToRead = append(euReq.NodesToRead, &ua.ReadValueID{NodeID: id, AttributeID: ua.AttributeIDValue})
+			order = append(order, j)
+		}
+	}
+	resp, err := c.Read(ctx, euReq)
+	if err != nil {
+		zap.L().Warn("OPC UA browse: read EngineeringUnits failed", zap.Error(err))
+		return
+	}
+	for k, res := range resp.Results {
+		if k >= len(order) || res.Status != ua.StatusOK || res.Value == nil {
+			continue
+		}
+		if eu := euInformationFromValue(res.Value.Value()); eu != nil {
+			n := &nodes[idx[order[k]]]
+			n.UnitID = eu.UnitID
+			if eu.DisplayName != nil {
+				n.EngineeringUnits = eu.DisplayName.Text
+			}
+		}
+	}
+}
+
+func euInformationFromValue(v any) *ua.EUInformation {
+	switch eu := v.(type) {
+	case *ua.EUInformation:
+		return eu
+	case ua.EUInformation:
+		return &eu
+	case *ua.ExtensionObject:
+		if eu == nil {
+			return nil
+		}
+		return euInformationFromValue(eu.Value)
+	}
+	return nil
+}
+
+// filterBrowseReferences drops the standard Server object (and its
+// diagnostics siblings) the same way ScanObjects does.
+func filterBrowseReferences(refs []ua.ReferenceDescription) []ua.ReferenceDescription {
+	out := refs[:0:0]
+	for _, ref := range refs {
+		if ref.NodeID == nil || ref.NodeID.NodeID == nil {
+			continue
+		}
+		id := ref.NodeID.NodeID
+		if id.Namespace() == 0 && (id.IntID() == 2253 || id.IntID() == 23470 || id.IntID() == 31915) {
+			continue
+		}
+		if ref.NodeClass != ua.NodeClassVariable && ref.NodeClass != ua.NodeClassObject {
+			continue
+		}
+		out = append(out, ref)
+	}
+	return out
+}
+
+// mapBrowseDataType maps OPC UA built-in type names (lookupDataType) to point
+// datatypes. Returns "" for types that cannot be imported as a point.
+func mapBrowseDataType(dataType string) string {
+	switch strings.ToLower(strings.TrimSpace(dataType)) {
+	case "boolean":
+		return "bool"
+	case "sbyte":
+		return "int8"
+	case "byte":
+		return "uint8"
+	case "int16":
+		return "int16"
+	case "uint16":
+		return "uint16"
+	case "int32":
+		return "int32"
+	case "uint32":
+		return "uint32"
+	case "int64":
+		return "int64"
+	case "uint64":
+		return "uint64"
+	case "float":
+		return "float32"
+	case "double":
+		return "float64"
+	case "string", "datetime":
+		return "string"
+	case "bytestring":
+		return "bytestring"
+	default:
+		return ""
+	}
+}
+
+// browseNodeToPoint builds a point from a browsed variable; the second return
+// value is the skip reason when the variable cannot be imported.
+func browseNodeToPoint(n BrowseNode, group string) (model.Point, string) {
+	dt := n.PointDataType
+	if dt == "" {
+		dt = mapBrowseDataType(n.DataType)
+	}
+	if dt == "" {
+		if n.DataType == "" {
+			return model.Point{}, "data type unknown"
+		}
+		return model.Point{}, "unsupported data type " + n.DataType
+	}
+	name := n.DisplayName
+	if name == "" {
+		name = n.NodeID
+	}
+	return model.Point{
+		ID:        n.NodeID,
+		Name:      name,
+		Address:   n.NodeID,
+		DataType:  dt,
+		Scale:     1.0,
+		Unit:      n.EngineeringUnits,
+		ReadWrite: readWriteFromAccessLevel(n.accessLevel),
+		Group:     group,
+	}, ""
+}
+
+func readWriteFromAccessLevel(level byte) string {
+	canRead := level&1 != 0
+	canWrite := level&2 != 0
+	switch {
+	case canRead && canWrite:
+		return "RW"
+	case canWrite:
+		return "W"
+	default:
+		return "R"
+	}
+}
+
+func joinBrowsePath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+func parseBrowseNodeID(raw any) (*ua.NodeID, error) {
+	s, _ := raw.(string)
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ua.NewNumericNodeID(0, 85), nil // Objects folder
+	}
+	id, err := ua.ParseNodeID(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node_id %q: %v", s, err)
+	}
+	return id, nil
+}
+
+func parseBrowseNodeIDs(raw any) ([]*ua.NodeID, error) {
+	var items []string
+	switch v := raw.(type) {
+	case nil:
+	case string:
+		items = []string{v}
+	case []string:
+		items = v
+	case []any:
+		for _, it := range v {
+			s, ok := it.(string)
+			if !ok {
+				return nil, fmt.Errorf("node_ids must be strings")
+			}
+			items = append(items, s)
+		}
+	default:
+		return nil, fmt.Errorf("node_ids must be a list of node IDs")
+	}
+	ids := make([]*ua.NodeID, 0, len(items))
+	for _, s := range items {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		id, err := parseBrowseNodeID(s)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func browseIntParam(params map[string]any, key string, def int) int {
+	switch v := params[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
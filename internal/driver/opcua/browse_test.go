@@ -0,0 +1,175 @@
+package opcua_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/driver/opcua"
+	"github.com/anviod/edgex/internal/model"
+	nbopcua "github.com/anviod/edgex/internal/northbound/opcua"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startBrowseTestServer(t *testing.T, points []model.Point) string {
+	t.Helper()
+	mockSB := &MockSB{Channels: []model.Channel{{
+		ID:       "ch1",
+		Name:     "Browse Channel",
+		Protocol: "modbus",
+		Devices:  []model.Device{{ID: "dev1", Name: "BrowseDevice", Points: points}},
+	}}}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+	tmpDir := testOutputDir(t)
+	srv := nbopcua.NewServer(model.OPCUAConfig{
+		Enable:   true,
+		Name:     "BrowseTestServer",
+		Port:     port,
+		Endpoint: "/browse",
+		CertFile: filepath.Join(tmpDir, "server.crt"),
+		KeyFile:  filepath.Join(tmpDir, "server.key"),
+	}, mockSB, nil)
+	require.NoError(t, srv.Start())
+	t.Cleanup(srv.Stop)
+	time.Sleep(500 * time.Millisecond)
+	return fmt.Sprintf("opc.tcp://127.0.0.1:%d/browse", port)
+}
+
+func browseChild(t *testing.T, b driver.AddressSpaceBrowser, endpoint, nodeID string, names ...string) opcua.BrowseNode {
+	t.Helper()
+	for _, name := range names {
+		raw, err := b.BrowseAddressSpace(context.Background(), map[string]any{"endpoint": endpoint, "node_id": nodeID})
+		require.NoError(t, err)
+		page := raw.(opcua.BrowsePage)
+		var found *opcua.BrowseNode
+		for i := range page.Children {
+			if page.Children[i].DisplayName == name || page.Children[i].BrowseName == name {
+				found = &page.Children[i]
+				break
+			}
+		}
+		require.NotNil(t, found, "child %q not found under %s", name, nodeID)
+		if name == names[len(names)-1] {
+			return *found
+		}
+		nodeID = found.NodeID
+	}
+	t.Fatal("no path given")
+	return opcua.BrowseNode{}
+}
+
+func TestBrowseAddressSpaceLazyAndPaged(t *testing.T) {
+	points := make([]model.Point, 0, 12)
+	for i := 0; i < 10; i++ {
+		points = append(points, model.Point{ID: fmt.Sprintf("t%02d", i), Name: fmt.Sprintf("Temp %02d", i), DataType: "float64", ReadWrite: "R"})
+	}
+	points = append(points,
+		model.Point{ID: "sp", Name: "Setpoint", DataType: "int32", ReadWrite: "RW"},
+		model.Point{ID: "run", Name: "Running", DataType: "bool", ReadWrite: "R"},
+	)
+	endpoint := startBrowseTestServer(t, points)
+
+	b, ok := opcua.NewOpcUaDriver().(driver.AddressSpaceBrowser)
+	require.True(t, ok, "driver should implement AddressSpaceBrowser")
+
+	// Top level only: children of Objects are returned without their subtrees.
+	raw, err := b.BrowseAddressSpace(context.Background(), map[string]any{"endpoint": endpoint})
+	require.NoError(t, err)
+	top := raw.(opcua.BrowsePage)
+	assert.Equal(t, "i=85", top.NodeID)
+	for _, n := range top.Children {
+		assert.NotEqual(t, "i=2253", n.NodeID, "Server object must be filtered")
+	}
+
+	folder := browseChild(t, b, endpoint, "", "Gateway", "Channels", "ch1", "Devices", "dev1", "Points")
+	require.Equal(t, "Folder", folder.Type)
+	assert.True(t, folder.HasChildren)
+
+	first, err := b.BrowseAddressSpace(context.Background(), map[string]any{"endpoint": endpoint, "node_id": folder.NodeID, "limit": 5})
+	require.NoError(t, err)
+	page := first.(opcua.BrowsePage)
+	assert.Equal(t, 12, page.Total)
+	assert.Len(t, page.Children, 5)
+	assert.True(t, page.HasMore)
+
+	rest, err := b.BrowseAddressSpace(context.Background(), map[string]any{"endpoint": endpoint, "node_id": folder.NodeID, "offset": 5, "limit": 100})
+	require.NoError(t, err)
+	page = rest.(opcua.BrowsePage)
+	assert.Len(t, page.Children, 7)
+	assert.False(t, page.HasMore)
+
+	zero, err := b.BrowseAddressSpace(context.Background(), map[string]any{"endpoint": endpoint, "node_id": folder.NodeID, "limit": 0})
+	require.NoError(t, err)
+	assert.Equal(t, 200, zero.(opcua.BrowsePage).Limit, "non-positive limit falls back to the default")
+
+	byName := map[string]opcua.BrowseNode{}
+	for _, n := range append(first.(opcua.BrowsePage).Children, page.Children...) {
+		byName[n.DisplayName] = n
+	}
+	sp := byName["Setpoint"]
+	assert.Equal(t, "Variable", sp.Type)
+	assert.Equal(t, "Int32", sp.DataType)
+	assert.Equal(t, "int32", sp.PointDataType)
+	assert.Contains(t, sp.AccessLevel, "CurrentWrite")
+}
+
+func TestResolveImportPointsFromSubtree(t *testing.T) {
+	endpoint := startBrowseTestServer(t, []model.Point{
+		{ID: "temp", Name: "Temperature", DataType: "float64", ReadWrite: "R"},
+		{ID: "sp", Name: "Setpoint", DataType: "int32", ReadWrite: "RW"},
+		{ID: "run", Name: "Running", DataType: "bool", ReadWrite: "R"},
+	})
+	d := opcua.NewOpcUaDriver()
+	b := d.(driver.AddressSpaceBrowser)
+	device := browseChild(t, b, endpoint, "", "Gateway", "Channels", "ch1", "Devices", "dev1")
+
+	pts, skipped, err := b.ResolveImportPoints(context.Background(), map[string]any{
+		"endpoint": endpoint,
+		"node_ids": []any{device.NodeID},
+	})
+	require.NoError(t, err)
+	byName := map[string]model.Point{}
+	for _, p := range pts {
+		byName[p.Name] = p
+	}
+	require.Contains(t, byName, "Setpoint", "skipped: %+v", skipped)
+	sp := byName["Setpoint"]
+	assert.Equal(t, sp.Address, sp.ID)
+	assert.Equal(t, "int32", sp.DataType)
+	assert.Equal(t, "RW", sp.ReadWrite)
+	assert.Equal(t, "dev1/Points", sp.Group)
+	assert.Equal(t, "float64", byName["Temperature"].DataType)
+	assert.Equal(t, "R", byName["Temperature"].ReadWrite)
+	assert.Equal(t, "bool", byName["Running"].DataType)
+
+	// Selecting a variable directly imports just that variable, ungrouped.
+	one, _, err := b.ResolveImportPoints(context.Background(), map[string]any{
+		"endpoint": endpoint,
+		"node_ids": []any{sp.Address},
+	})
+	require.NoError(t, err)
+	require.Len(t, one, 1)
+	assert.Equal(t, "", one[0].Group)
+
+	limited, skipped, err := b.ResolveImportPoints(context.Background(), map[string]any{
+		"endpoint":   endpoint,
+		"node_ids":   []any{device.NodeID},
+		"max_points": 1,
+	})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+	assert.NotEmpty(t, skipped, "truncation must be reported")
+
+	_, _, err = b.ResolveImportPoints(context.Background(), map[string]any{"endpoint": endpoint})
+	assert.Error(t, err, "node_ids is required")
+}
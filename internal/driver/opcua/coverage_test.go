@@ -379,3 +379,37 @@ func TestCoverage_RecordReconnect(t *testing.T) {
 	d.recordReconnect()
 	assert.Equal(t, int64(1), d.reconnectCount)
 }
+
+func TestCoverage_BrowseImportHelpers(t *testing.T) {
+	assert.Equal(t, "float32", mapBrowseDataType("Float"))
+	assert.Equal(t, "bool", mapBrowseDataType("Boolean"))
+	assert.Equal(t, "", mapBrowseDataType("ns=0;i=887"))
+
+	eu := &ua.EUInformation{UnitID: 4408652, DisplayName: &ua.LocalizedText{Text: "°C"}}
+	got := euInformationFromValue(&ua.ExtensionObject{Value: eu})
+	require.NotNil(t, got)
+	assert.Equal(t, "°C", got.DisplayName.Text)
+	assert.Nil(t, euInformationFromValue("x"))
+
+	p, reason := browseNodeToPoint(BrowseNode{
+		NodeID:           "ns=2;s=Boiler.Temp",
+		DisplayName:      "Temp",
+		DataType:         "Double",
+		EngineeringUnits: "°C",
+		accessLevel:      3,
+	}, "Boiler")
+	assert.Empty(t, reason)
+	assert.Equal(t, model.Point{
+		ID: "ns=2;s=Boiler.Temp", Name: "Temp", Address: "ns=2;s=Boiler.Temp",
+		DataType: "float64", Scale: 1, Unit: "°C", ReadWrite: "RW", Group: "Boiler",
+	}, p)
+
+	_, reason = browseNodeToPoint(BrowseNode{NodeID: "ns=2;i=9", DataType: "ns=2;i=5001"}, "")
+	assert.Contains(t, reason, "unsupported data type")
+
+	ids, err := parseBrowseNodeIDs([]any{"ns=2;s=A", " ", "i=85"})
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+	_, err = parseBrowseNodeIDs([]any{1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,354 @@
+package opcua
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"go.uber.org/zap"
+)
+
+// Event monitored items.
+//
+// A point with datatype "event" subscribes to events of its notifier node
+// (address, e.g. "i=2253" for the Server object) instead of a data value.
+// Select and where clauses are given in the address query:
+//
+//	i=2253?select=Severity,Message,SourceName,ActiveState/Id&where=OfType(i=2915) AND Severity>=500
+//
+// Each notification is pushed through the driver's ValuePublisher as a point
+// value whose Value is a map of the selected fields, so PLC alarms
+// (AlarmConditionType) reach rules and northbound as gateway events.
+//
+// 事件点位：datatype=event，地址为事件通知节点，select/where 写在地址查询串中；
+// 每条事件经 ValuePublisher 推送为一次点位值（字段 map）。
+
+const (
+	pointTypeEvent  = "event"
+	pointTypeMethod = "method"
+)
+
+// defaultEventSelect covers BaseEventType plus the ConditionType/AlarmConditionType
+// state fields needed to treat an event as an alarm.
+var defaultEventSelect = []string{
+	"EventId", "EventType", "SourceName", "Time", "Message", "Severity",
+	"ConditionName", "ActiveState/Id", "AckedState/Id", "Retain",
+}
+
+// eventSpec is a parsed event point address.
+type eventSpec struct {
+	Notifier *ua.NodeID
+	Select   []string
+	Where    []eventCondition
+}
+
+// eventCondition is one AND-ed term of the where clause.
+type eventCondition struct {
+	Field    string // browse path relative to BaseEventType; empty for OfType
+	Operator ua.FilterOperator
+	Literal  any
+}
+
+func isEventPoint(p model.Point) bool {
+	return strings.EqualFold(strings.TrimSpace(p.DataType), pointTypeEvent)
+}
+
+func isMethodPoint(p model.Point) bool {
+	return strings.EqualFold(strings.TrimSpace(p.DataType), pointTypeMethod)
+}
+
+// splitAddressQuery separates "nodeID?k=v&k2=v2" into the node ID and its options.
+func splitAddressQuery(address string) (string, map[string]string) {
+	base, query, found := strings.Cut(address, "?")
+	opts := make(map[string]string)
+	if !found {
+		return strings.TrimSpace(base), opts
+	}
+	for _, part := range strings.Split(query, "&") {
+		k, v, _ := strings.Cut(part, "=")
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k != "" {
+			opts[k] = strings.TrimSpace(v)
+		}
+	}
+	return strings.TrimSpace(base), opts
+}
+
+// parseEventAddress parses an event point address (see package comment above).
+func parseEventAddress(address string) (*eventSpec, error) {
+	base, opts := splitAddressQuery(address)
+	if base == "" {
+		base = "i=2253" // Server object receives all events
+	}
+	notifier, err := ua.ParseNodeID(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event notifier %q: %w", base, err)
+	}
+	spec := &eventSpec{Notifier: notifier, Select: defaultEventSelect}
+	if sel := opts["select"]; sel != "" {
+		spec.Select = nil
+		for _, f := range strings.Split(sel, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				spec.Select = append(spec.Select, f)
+			}
+		}
+	}
+	if where := opts["where"]; where != "" {
+		conds, err := parseEventWhere(where)
+		if err != nil {
+			return nil, err
+		}
+		spec.Where = conds
+	}
+	return spec, nil
+}
+
+// parseEventWhere parses terms joined by AND: OfType(<nodeId>) or <field><op><literal>
+// with op one of ==, =, >=, <=, >, <.
+func parseEventWhere(where string) ([]eventCondition, error) {
+	var out []eventCondition
+	for _, term := range splitAndTerms(where) {
+		if term == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(term), "oftype(") && strings.HasSuffix(term, ")") {
+			id, err := ua.ParseNodeID(strings.TrimSpace(term[len("oftype(") : len(term)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid OfType in where clause %q: %w", term, err)
+			}
+			out = append(out, eventCondition{Operator: ua.FilterOperatorOfType, Literal: id})
+			continue
+		}
+		cond, err := parseComparison(term)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cond)
+	}
+	return out, nil
+}
+
+func splitAndTerms(where string) []string {
+	var terms []string
+	rest := where
+	for {
+		idx := strings.Index(strings.ToUpper(rest), " AND ")
+		if idx < 0 {
+			terms = append(terms, strings.TrimSpace(rest))
+			return terms
+		}
+		terms = append(terms, strings.TrimSpace(rest[:idx]))
+		rest = rest[idx+len(" AND "):]
+	}
+}
+
+func parseComparison(term string) (eventCondition, error) {
+	ops := []struct {
+		token string
+		op    ua.FilterOperator
+	}{
+		{">=", ua.FilterOperatorGreaterThanOrEqual},
+		{"<=", ua.FilterOperatorLessThanOrEqual},
+		{"==", ua.FilterOperatorEquals},
+		{">", ua.FilterOperatorGreaterThan},
+		{"<", ua.FilterOperatorLessThan},
+		{"=", ua.FilterOperatorEquals},
+	}
+	for _, o := range ops {
+		idx := strings.Index(term, o.token)
+		if idx <= 0 {
+			continue
+		}
+		field := strings.TrimSpace(term[:idx])
+		raw := strings.TrimSpace(term[idx+len(o.token):])
+		if field == "" || raw == "" {
+			break
+		}
+		return eventCondition{Field: field, Operator: o.op, Literal: parseWhereLiteral(raw)}, nil
+	}
+	return eventCondition{}, fmt.Errorf("invalid where term %q", term)
+}
+
+func parseWhereLiteral(raw string) any {
+	if len(raw) >= 2 && (raw[0] == '\'' || raw[0] == '"') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1]
+	}
+	if strings.EqualFold(raw, "true") || strings.EqualFold(raw, "false") {
+		return strings.EqualFold(raw, "true")
+	}
+	if n, err := strconv.ParseInt(raw, 10, 32); err == nil {
+		return int32(n)
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f
+	}
+	return raw
+}
+
+func eventFieldOperand(field string) *ua.SimpleAttributeOperand {
+	var path []*ua.QualifiedName
+	for _, seg := range strings.Split(field, "/") {
+		if seg = strings.TrimSpace(seg); seg != "" {
+			path = append(path, &ua.QualifiedName{NamespaceIndex: 0, Name: seg})
+		}
+	}
+	return &ua.SimpleAttributeOperand{
+		TypeDefinitionID: ua.NewNumericNodeID(0, 2041), // BaseEventType
+		BrowsePath:       path,
+		AttributeID:      ua.AttributeIDValue,
+	}
+}
+
+// eventFilter builds the EventFilter for a spec. Multiple where terms are
+// chained with And elements: [And(c0, And1), And1(c1, ...), ..., c0..cn-1].
+func (s *eventSpec) eventFilter() (*ua.EventFilter, error) {
+	filter := &ua.EventFilter{WhereClause: &ua.ContentFilter{}}
+	for _, f := range s.Select {
+		filter.SelectClauses = append(filter.SelectClauses, eventFieldOperand(f))
+	}
+
+	n := len(s.Where)
+	if n == 0 {
+		return filter, nil
+	}
+	elements := make([]*ua.ContentFilterElement, 0, 2*n-1)
+	for k := 0; k < n-1; k++ {
+		right := uint32(k + 1)
+		if k == n-2 {
+			right = uint32(2*n - 2)
+		}
+		elements = append(elements, &ua.ContentFilterElement{
+			FilterOperator: ua.FilterOperatorAnd,
+			FilterOperands: []*ua.ExtensionObject{
+				ua.NewExtensionObject(&ua.ElementOperand{Index: uint32(n - 1 + k)}),
+				ua.NewExtensionObject(&ua.ElementOperand{Index: right}),
+			},
+		})
+	}
+	for _, c := range s.Where {
+		lit, err := ua.NewVariant(c.Literal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid where literal %v: %w", c.Literal, err)
+		}
+		el := &ua.ContentFilterElement{FilterOperator: c.Operator}
+		if c.Operator == ua.FilterOperatorOfType {
+			el.FilterOperands = []*ua.ExtensionObject{ua.NewExtensionObject(&ua.LiteralOperand{Value: lit})}
+		} else {
+			el.FilterOperands = []*ua.ExtensionObject{
+				ua.NewExtensionObject(eventFieldOperand(c.Field)),
+				ua.NewExtensionObject(&ua.LiteralOperand{Value: lit}),
+			}
+		}
+		elements = append(elements, el)
+	}
+	filter.WhereClause.Elements = elements
+	return filter, nil
+}
+
+// eventMonitorRequest creates the monitored item request for an event point.
+func eventMonitorRequest(spec *eventSpec, handle uint32) (*ua.MonitoredItemCreateRequest, error) {
+	filter, err := spec.eventFilter()
+	if err != nil {
+		return nil, err
+	}
+	req := opcua.NewMonitoredItemCreateRequestWithDefaults(spec.Notifier, ua.AttributeIDEventNotifier, handle)
+	req.RequestedParameters.Filter = ua.NewExtensionObject(filter)
+	req.RequestedParameters.QueueSize = 100
+	return req, nil
+}
+
+// eventFieldsToValue converts one EventFieldList to the published point value.
+func eventFieldsToValue(fields []string, ev *ua.EventFieldList) (map[string]any, time.Time, bool) {
+	out := make(map[string]any, len(fields))
+	ts := time.Time{}
+	for i, name := range fields {
+		if i >= len(ev.EventFields) || ev.EventFields[i] == nil {
+			continue
+		}
+		v := eventFieldValue(ev.EventFields[i].Value())
+		if v == nil {
+			continue
+		}
+		out[name] = v
+		if name == "Time" {
+			if t, ok := v.(time.Time); ok {
+				ts = t
+			}
+		}
+	}
+	_, isAlarm := out["ActiveState/Id"]
+	return out, ts, isAlarm
+}
+
+func eventFieldValue(v any) any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return hex.EncodeToString(x)
+	case *ua.NodeID:
+		if x == nil {
+			return nil
+		}
+		return x.String()
+	case *ua.LocalizedText:
+		if x == nil {
+			return nil
+		}
+		return x.Text
+	case *ua.QualifiedName:
+		if x == nil {
+			return nil
+		}
+		return x.Name
+	case ua.StatusCode:
+		return x.Error()
+	default:
+		return v
+	}
+}
+
+// publishEvents pushes event notifications of a device subscription.
+func (d *OpcUaDriver) publishEvents(sub *DeviceSubscription, list *ua.EventNotificationList) {
+	d.mu.RLock()
+	publish := d.publish
+	channelID := d.config.ChannelID
+	d.mu.RUnlock()
+
+	for _, ev := range list.Events {
+		sub.mu.RLock()
+		item, ok := sub.EventItems[ev.ClientHandle]
+		sub.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		fields, ts, isAlarm := eventFieldsToValue(item.Select, ev)
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		if publish == nil {
+			zap.L().Debug("[OPC UA] Event dropped, no value publisher",
+				zap.String("point_id", item.PointID))
+			continue
+		}
+		meta := map[string]any{"source": "opcua_event", "alarm": isAlarm}
+		if sev, ok := fields["Severity"]; ok {
+			meta["severity"] = sev
+		}
+		publish(model.Value{
+			ChannelID: channelID,
+			DeviceID:  sub.DeviceID,
+			PointID:   item.PointID,
+			Value:     fields,
+			Quality:   "Good",
+			TS:        ts,
+			Meta:      meta,
+		})
+	}
+}
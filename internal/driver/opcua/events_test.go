@@ -0,0 +1,169 @@
+package opcua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventAddress(t *testing.T) {
+	spec, err := parseEventAddress("")
+	require.NoError(t, err)
+	assert.Equal(t, "i=2253", spec.Notifier.String())
+	assert.Equal(t, defaultEventSelect, spec.Select)
+	assert.Empty(t, spec.Where)
+
+	spec, err = parseEventAddress("ns=2;s=Line1?select=Severity, Message,ActiveState/Id&where=OfType(i=2915) and Severity>=500 AND SourceName='Pump 1'")
+	require.NoError(t, err)
+	assert.Equal(t, "ns=2;s=Line1", spec.Notifier.String())
+	assert.Equal(t, []string{"Severity", "Message", "ActiveState/Id"}, spec.Select)
+	require.Len(t, spec.Where, 3)
+	assert.Equal(t, ua.FilterOperatorOfType, spec.Where[0].Operator)
+	assert.Equal(t, "i=2915", spec.Where[0].Literal.(*ua.NodeID).String())
+	assert.Equal(t, eventCondition{Field: "Severity", Operator: ua.FilterOperatorGreaterThanOrEqual, Literal: int32(500)}, spec.Where[1])
+	assert.Equal(t, eventCondition{Field: "SourceName", Operator: ua.FilterOperatorEquals, Literal: "Pump 1"}, spec.Where[2])
+
+	_, err = parseEventAddress("i=2253?where=Severity")
+	assert.Error(t, err)
+	_, err = parseEventAddress("i=2253?where=Severity>=")
+	assert.Error(t, err)
+}
+
+func TestEventFilterAndChain(t *testing.T) {
+	spec, err := parseEventAddress("i=2253?select=Severity&where=OfType(i=2915) AND Severity>=500 AND Retain==true")
+	require.NoError(t, err)
+	filter, err := spec.eventFilter()
+	require.NoError(t, err)
+	require.Len(t, filter.SelectClauses, 1)
+	assert.Equal(t, "Severity", filter.SelectClauses[0].BrowsePath[0].Name)
+
+	// 3 terms: [0]And(2, 1), [1]And(3, 4), [2]OfType, [3]Severity>=500, [4]Retain==true
+	els := filter.WhereClause.Elements
+	require.Len(t, els, 5)
+	operand := func(el *ua.ContentFilterElement, i int) uint32 {
+		return el.FilterOperands[i].Value.(*ua.ElementOperand).Index
+	}
+	assert.Equal(t, ua.FilterOperatorAnd, els[0].FilterOperator)
+	assert.Equal(t, []uint32{2, 1}, []uint32{operand(els[0], 0), operand(els[0], 1)})
+	assert.Equal(t, ua.FilterOperatorAnd, els[1].FilterOperator)
+	assert.Equal(t, []uint32{3, 4}, []uint32{operand(els[1], 0), operand(els[1], 1)})
+	assert.Equal(t, ua.FilterOperatorOfType, els[2].FilterOperator)
+	assert.Equal(t, ua.FilterOperatorGreaterThanOrEqual, els[3].FilterOperator)
+	assert.Equal(t, ua.FilterOperatorEquals, els[4].FilterOperator)
+
+	single, err := parseEventAddress("i=2253?where=Severity>100")
+	require.NoError(t, err)
+	filter, err = single.eventFilter()
+	require.NoError(t, err)
+	require.Len(t, filter.WhereClause.Elements, 1)
+
+	req, err := eventMonitorRequest(single, 7)
+	require.NoError(t, err)
+	assert.Equal(t, ua.AttributeIDEventNotifier, req.ItemToMonitor.AttributeID)
+	assert.Equal(t, uint32(7), req.RequestedParameters.ClientHandle)
+}
+
+func TestPublishEvents(t *testing.T) {
+	d := NewOpcUaDriver().(*OpcUaDriver)
+	require.NoError(t, d.Init(model.DriverConfig{ChannelID: "ch1", Config: map[string]any{"url": "opc.tcp://127.0.0.1:4840"}}))
+	var got []model.Value
+	var pub driver.ValuePublisherSetter = d
+	pub.SetValuePublisher(func(v model.Value) { got = append(got, v) })
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	sub := &DeviceSubscription{
+		DeviceID: "dev1",
+		EventItems: map[uint32]eventItem{
+			1: {PointID: "alarms", Select: []string{"Time", "Severity", "Message", "ActiveState/Id", "EventId"}},
+		},
+	}
+	d.publishEvents(sub, &ua.EventNotificationList{Events: []*ua.EventFieldList{
+		{ClientHandle: 1, EventFields: []*ua.Variant{
+			ua.MustVariant(ts),
+			ua.MustVariant(uint16(800)),
+			ua.MustVariant(&ua.LocalizedText{Text: "Overtemp"}),
+			ua.MustVariant(true),
+			ua.MustVariant([]byte{0xab, 0x01}),
+		}},
+		{ClientHandle: 99},
+	}})
+
+	require.Len(t, got, 1)
+	v := got[0]
+	assert.Equal(t, "ch1", v.ChannelID)
+	assert.Equal(t, "dev1", v.DeviceID)
+	assert.Equal(t, "alarms", v.PointID)
+	assert.Equal(t, ts, v.TS)
+	fields := v.Value.(map[string]any)
+	assert.Equal(t, "Overtemp", fields["Message"])
+	assert.Equal(t, "ab01", fields["EventId"])
+	assert.Equal(t, true, v.Meta["alarm"])
+	assert.Equal(t, uint16(800), v.Meta["severity"])
+}
+
+func TestMethodArgsFromValue(t *testing.T) {
+	cases := []struct {
+		in   any
+		want []any
+	}{
+		{nil, nil},
+		{[]any{1.0, "a"}, []any{1.0, "a"}},
+		{map[string]any{"args": []any{true}}, []any{true}},
+		{`[1, "x"]`, []any{1.0, "x"}},
+		{"start", []any{"start"}},
+		{42.0, []any{42.0}},
+	}
+	for _, c := range cases {
+		got, err := methodArgsFromValue(c.in)
+		require.NoError(t, err)
+		assert.Equal(t, c.want, got, "input %v", c.in)
+	}
+	_, err := methodArgsFromValue(map[string]any{"args": "x"})
+	assert.Error(t, err)
+	_, err = methodArgsFromValue("[1,")
+	assert.Error(t, err)
+}
+
+func TestBuildMethodInputs(t *testing.T) {
+	d := NewOpcUaDriver().(*OpcUaDriver)
+	defs := []*ua.Argument{
+		{Name: "speed", DataType: ua.NewNumericNodeID(0, 11), ValueRank: -1}, // Double
+		{Name: "ids", DataType: ua.NewNumericNodeID(0, 6), ValueRank: 1},     // Int32[]
+		{Name: "mode", DataType: ua.NewNumericNodeID(0, 12), ValueRank: -1},  // String
+	}
+	in, err := d.buildMethodInputs([]any{"12.5", []any{1.0, 2.0}, "auto"}, defs)
+	require.NoError(t, err)
+	require.Len(t, in, 3)
+	assert.Equal(t, 12.5, in[0].Value())
+	assert.Equal(t, []int32{1, 2}, in[1].Value())
+	assert.Equal(t, "auto", in[2].Value())
+
+	_, err = d.buildMethodInputs([]any{1.0}, defs)
+	assert.Error(t, err, "argument count must match the method definition")
+
+	// Without definitions, explicit types win and plain values keep their Go type.
+	in, err = d.buildMethodInputs([]any{map[string]any{"type": "UInt16", "value": 7.0}, true}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(7), in[0].Value())
+	assert.Equal(t, true, in[1].Value())
+
+	args := argumentsFromValue([]*ua.ExtensionObject{ua.NewExtensionObject(defs[0])})
+	require.Len(t, args, 1)
+	assert.Equal(t, "speed", args[0].Name)
+	assert.Nil(t, argumentsFromValue("nope"))
+}
+
+func TestReadPointsSkipsMethodAndEventPoints(t *testing.T) {
+	assert.True(t, isEventPoint(model.Point{DataType: "Event"}))
+	assert.True(t, isMethodPoint(model.Point{DataType: " method "}))
+	assert.False(t, isMethodPoint(model.Point{DataType: "float64"}))
+
+	d := NewOpcUaDriver().(*OpcUaDriver)
+	err := d.WritePoint(t.Context(), model.Point{ID: "alarms", DataType: "event", Address: "i=2253"}, 1)
+	assert.ErrorContains(t, err, "read-only")
+}
@@ -0,0 +1,316 @@
+package opcua
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"go.uber.org/zap"
+)
+
+// Method calls.
+//
+// CallMethod (driver.MethodCaller) invokes a server method with typed input
+// arguments; it backs the device_control rule action's method variant.
+// A point with datatype "method" exposes the same call as a point write:
+//
+//	address: <methodNodeId>[?object=<objectNodeId>]
+//	value:   [arg1, arg2, ...] | {"args": [...]} | single scalar
+//
+// Argument types come from the method's InputArguments property unless an
+// argument is given as {"type": "Double", "value": 1.5}. When object is
+// omitted, the owning object is resolved through the inverse HasComponent
+// reference of the method node.
+//
+// 方法调用：CallMethod 供规则 device_control 的 method 变体使用；datatype=method 的点位写入即调用方法，
+// 入参类型取自方法的 InputArguments 属性，或由 {"type","value"} 显式指定。
+
+// CallMethod implements driver.MethodCaller.
+func (d *OpcUaDriver) CallMethod(ctx context.Context, call driver.MethodCall) ([]any, error) {
+	client, err := d.connectedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	methodID, err := ua.ParseNodeID(strings.TrimSpace(call.MethodID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid method node ID %q: %w", call.MethodID, err)
+	}
+	var objectID *ua.NodeID
+	if strings.TrimSpace(call.ObjectID) != "" {
+		if objectID, err = ua.ParseNodeID(strings.TrimSpace(call.ObjectID)); err != nil {
+			return nil, fmt.Errorf("invalid object node ID %q: %w", call.ObjectID, err)
+		}
+	} else if objectID, err = d.resolveMethodObject(ctx, client.Client, methodID); err != nil {
+		return nil, err
+	}
+
+	argDefs := d.methodInputArguments(ctx, client.Client, methodID)
+	inputs, err := d.buildMethodInputs(call.Args, argDefs)
+	if err != nil {
+		return nil, fmt.Errorf("method %s: %w", methodID.String(), err)
+	}
+
+	res, err := client.Client.Call(ctx, &ua.CallMethodRequest{
+		ObjectID:       objectID,
+		MethodID:       methodID,
+		InputArguments: inputs,
+	})
+	if err != nil {
+		client.RecordFailure(err)
+		return nil, fmt.Errorf("OPC-UA call %s failed: %w", methodID.String(), err)
+	}
+	if res.StatusCode != ua.StatusOK {
+		for i, sc := range res.InputArgumentResults {
+			if sc != ua.StatusOK {
+				return nil, fmt.Errorf("OPC-UA call %s failed: input argument %d: %s", methodID.String(), i, sc)
+			}
+		}
+		return nil, fmt.Errorf("OPC-UA call %s failed: %s (0x%X)", methodID.String(), res.StatusCode, uint32(res.StatusCode))
+	}
+
+	outputs := make([]any, 0, len(res.OutputArguments))
+	for _, v := range res.OutputArguments {
+		if v == nil {
+			outputs = append(outputs, nil)
+			continue
+		}
+		outputs = append(outputs, eventFieldValue(v.Value()))
+	}
+	zap.L().Info("[OPC UA] Method call success",
+		zap.String("object", objectID.String()),
+		zap.String("method", methodID.String()),
+		zap.Int("inputs", len(inputs)),
+		zap.Any("outputs", outputs))
+	return outputs, nil
+}
+
+// writeMethodPoint invokes the method behind a datatype=method point.
+func (d *OpcUaDriver) writeMethodPoint(ctx context.Context, point model.Point, value any) error {
+	methodID, opts := splitAddressQuery(point.Address)
+	args, err := methodArgsFromValue(value)
+	if err != nil {
+		return fmt.Errorf("method point %s: %w", point.ID, err)
+	}
+	_, err = d.CallMethod(ctx, driver.MethodCall{ObjectID: opts["object"], MethodID: methodID, Args: args})
+	return err
+}
+
+// methodArgsFromValue converts a point write value into method input arguments.
+func methodArgsFromValue(value any) ([]any, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	case map[string]any:
+		if raw, ok := v["args"]; ok {
+			args, ok := raw.([]any)
+			if !ok && raw != nil {
+				return nil, fmt.Errorf("args must be an array")
+			}
+			return args, nil
+		}
+		return []any{v}, nil
+	case string:
+		if s := strings.TrimSpace(v); strings.HasPrefix(s, "[") {
+			var args []any
+			if err := json.Unmarshal([]byte(s), &args); err != nil {
+				return nil, fmt.Errorf("invalid argument list: %w", err)
+			}
+			return args, nil
+		}
+		return []any{v}, nil
+	default:
+		return []any{v}, nil
+	}
+}
+
+// buildMethodInputs converts arguments to variants typed by the method's
+// InputArguments (when known) or by explicit {"type","value"} wrappers.
+func (d *OpcUaDriver) buildMethodInputs(args []any, defs []*ua.Argument) ([]*ua.Variant, error) {
+	if defs != nil && len(args) != len(defs) {
+		return nil, fmt.Errorf("expects %d input arguments, got %d", len(defs), len(args))
+	}
+	out := make([]*ua.Variant, 0, len(args))
+	for i, arg := range args {
+		typ, val := "", arg
+		if m, ok := arg.(map[string]any); ok {
+			if t, ok := m["type"].(string); ok {
+				typ, val = t, m["value"]
+			}
+		}
+		isArray := false
+		if i < len(defs) && defs[i] != nil {
+			if typ == "" && defs[i].DataType != nil {
+				typ = lookupDataType(defs[i].DataType)
+			}
+			isArray = defs[i].ValueRank >= 1
+		}
+		if typ == "" {
+			v, err := ua.NewVariant(val)
+			if err != nil {
+				return nil, fmt.Errorf("input argument %d: %w", i, err)
+			}
+			out = append(out, v)
+			continue
+		}
+		if elems, ok := val.([]any); ok && (isArray || strings.HasPrefix(strings.ToLower(typ), "array:")) {
+			v, err := d.typedArrayVariant(strings.TrimPrefix(typ, "array:"), elems)
+			if err != nil {
+				return nil, fmt.Errorf("input argument %d: %w", i, err)
+			}
+			out = append(out, v)
+			continue
+		}
+		parsed, err := d.parseWriteValue(val, typ)
+		if err != nil {
+			return nil, fmt.Errorf("input argument %d: %w", i, err)
+		}
+		v := d.createWriteVariant(typ, parsed)
+		if v == nil {
+			return nil, fmt.Errorf("input argument %d: cannot encode %v as %s", i, val, typ)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// typedArrayVariant encodes elements as a one-dimensional array of the element
+// type (e.g. []int32 for Int32), as required for array method arguments.
+func (d *OpcUaDriver) typedArrayVariant(elemType string, elems []any) (*ua.Variant, error) {
+	if len(elems) == 0 {
+		return ua.NewVariant([]string{})
+	}
+	var slice reflect.Value
+	for i, elem := range elems {
+		parsed, err := d.parseWriteValue(elem, elemType)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		v := d.createWriteVariant(elemType, parsed)
+		if v == nil {
+			return nil, fmt.Errorf("element %d: cannot encode %v as %s", i, elem, elemType)
+		}
+		rv := reflect.ValueOf(v.Value())
+		if i == 0 {
+			slice = reflect.MakeSlice(reflect.SliceOf(rv.Type()), 0, len(elems))
+		} else if rv.Type() != slice.Type().Elem() {
+			return nil, fmt.Errorf("element %d: mixed array element types", i)
+		}
+		slice = reflect.Append(slice, rv)
+	}
+	return ua.NewVariant(slice.Interface())
+}
+
+// methodInputArguments reads the InputArguments property of a method.
+// Returns nil when the method has no (readable) argument definition.
+func (d *OpcUaDriver) methodInputArguments(ctx context.Context, c *opcua.Client, methodID *ua.NodeID) []*ua.Argument {
+	resp, err := c.Browse(ctx, &ua.BrowseRequest{NodesToBrowse: []*ua.BrowseDescription{{
+		NodeID:          methodID,
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.NewNumericNodeID(0, 46), // HasProperty
+		IncludeSubtypes: true,
+		NodeClassMask:   uint32(ua.NodeClassVariable),
+		ResultMask:      uint32(ua.BrowseResultMaskBrowseName),
+	}}})
+	if err != nil || len(resp.Results) == 0 {
+		return nil
+	}
+	var propID *ua.NodeID
+	for _, ref := range resp.Results[0].References {
+		if ref.BrowseName != nil && ref.BrowseName.Name == "InputArguments" {
+			propID = ref.NodeID.NodeID
+			break
+		}
+	}
+	if propID == nil {
+		return []*ua.Argument{}
+	}
+	read, err := c.Read(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{{NodeID: propID, AttributeID: ua.AttributeIDValue}}})
+	if err != nil || len(read.Results) == 0 || read.Results[0].Status != ua.StatusOK || read.Results[0].Value == nil {
+		return nil
+	}
+	return argumentsFromValue(read.Results[0].Value.Value())
+}
+
+func argumentsFromValue(v any) []*ua.Argument {
+	list, ok := v.([]*ua.ExtensionObject)
+	if !ok {
+		return nil
+	}
+	args := make([]*ua.Argument, 0, len(list))
+	for _, eo := range list {
+		if eo == nil {
+			return nil
+		}
+		switch a := eo.Value.(type) {
+		case *ua.Argument:
+			args = append(args, a)
+		case ua.Argument:
+			args = append(args, &a)
+		default:
+			return nil
+		}
+	}
+	return args
+}
+
+// resolveMethodObject finds the object owning a method (inverse HasComponent).
+func (d *OpcUaDriver) resolveMethodObject(ctx context.Context, c *opcua.Client, methodID *ua.NodeID) (*ua.NodeID, error) {
+	key := methodID.String()
+	d.mu.RLock()
+	cached, ok := d.methodParents[key]
+	d.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	resp, err := c.Browse(ctx, &ua.BrowseRequest{NodesToBrowse: []*ua.BrowseDescription{{
+		NodeID:          methodID,
+		BrowseDirection: ua.BrowseDirectionInverse,
+		ReferenceTypeID: ua.NewNumericNodeID(0, 47), // HasComponent
+		IncludeSubtypes: true,
+		NodeClassMask:   uint32(ua.NodeClassObject | ua.NodeClassObjectType),
+		ResultMask:      uint32(ua.BrowseResultMaskNodeClass),
+	}}})
+	if err != nil {
+		return nil, fmt.Errorf("resolve object of method %s: %w", key, err)
+	}
+	if len(resp.Results) == 0 || len(resp.Results[0].References) == 0 {
+		return nil, fmt.Errorf("method %s has no owning object; set object explicitly", key)
+	}
+	parent := resp.Results[0].References[0].NodeID.NodeID
+
+	d.mu.Lock()
+	d.methodParents[key] = parent
+	d.mu.Unlock()
+	return parent, nil
+}
+
+// connectedClient returns the active client, connecting it if needed.
+func (d *OpcUaDriver) connectedClient(ctx context.Context) (*ClientWrapper, error) {
+	d.mu.Lock()
+	client := d.activeClient
+	d.mu.Unlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("no active OPC-UA client")
+	}
+	if !client.Connected {
+		if err := client.Client.Connect(ctx); err != nil {
+			return nil, fmt.Errorf("OPC-UA client not connected: %v", err)
+		}
+		d.mu.Lock()
+		client.Connected = true
+		d.mu.Unlock()
+	}
+	return client, nil
+}
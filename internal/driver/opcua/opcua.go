@@ -61,6 +61,9 @@ type OpcUaDriver struct {
 	maxRttMs int64
 
 	maxNodesPerRead int // OPC UA 批量读上限，自适应调整
+
+	publish       driver.ValuePublisher // 事件点位推送（由 ChannelManager 注入）
+	methodParents map[string]*ua.NodeID // 方法节点 -> 所属对象缓存
 }
 
 type DeviceSubscription struct {
@@ -75,6 +78,15 @@ type DeviceSubscription struct {
 	Ctx        context.Context
 	Cancel     context.CancelFunc
 	lastUpdate time.Time
+
+	DeviceID   string
+	EventItems map[uint32]eventItem // event monitored items by client handle
+}
+
+// eventItem maps an event monitored item back to its point.
+type eventItem struct {
+	PointID string
+	Select  []string
 }
 
 type ClientWrapper struct {
@@ -99,9 +111,17 @@ func NewOpcUaDriver() driver.Driver {
 	return &OpcUaDriver{
 		clients:         make(map[string]*ClientWrapper),
 		maxNodesPerRead: 100,
+		methodParents:   make(map[string]*ua.NodeID),
 	}
 }
 
+// SetValuePublisher implements driver.ValuePublisherSetter; event points push through it.
+func (d *OpcUaDriver) SetValuePublisher(p driver.ValuePublisher) {
+	d.mu.Lock()
+	d.publish = p
+	d.mu.Unlock()
+}
+
 func (d *OpcUaDriver) Init(cfg model.DriverConfig) error {
 	d.config = cfg
 	return nil
@@ -659,7 +679,22 @@ func (d *OpcUaDriver) ReadPoints(ctx context.Context, points []model.Point) (map
 
 	deviceID := points[0].DeviceID
 
-	sub := d.ensureSubscription(ctx, client, deviceID, points)
+	// Event points are served by the subscription only (values are pushed),
+	// method points are write-only; neither is part of the read result.
+	monitored := make([]model.Point, 0, len(points))
+	regular := make([]model.Point, 0, len(points))
+	for _, p := range points {
+		if isMethodPoint(p) {
+			continue
+		}
+		monitored = append(monitored, p)
+		if !isEventPoint(p) {
+			regular = append(regular, p)
+		}
+	}
+	points = regular
+
+	sub := d.ensureSubscription(ctx, client, deviceID, monitored)
 
 	if sub != nil {
 		sub.mu.RLock()
@@ -697,6 +732,10 @@ func (d *OpcUaDriver) ReadPoints(ctx context.Context, points []model.Point) (map
 		zap.L().Debug("[OPC UA] No subscription, using direct read")
 	}
 
+	if len(points) == 0 {
+		return map[string]model.Value{}, nil
+	}
+
 	result, err := d.readDirect(ctx, client, points)
 	if err != nil {
 		d.recordReadOutcome(startTime, false, classifyOpcUaReadError(err))
@@ -871,6 +910,8 @@ func (d *OpcUaDriver) createDeviceSubscription(ctx context.Context, w *ClientWra
 		NotifyCh:   notifyCh,
 		Ctx:        subCtx,
 		Cancel:     cancel,
+		DeviceID:   deviceID,
+		EventItems: make(map[uint32]eventItem),
 	}
 	for _, p := range points {
 		newSub.Points[p.ID] = p
@@ -890,6 +931,29 @@ func (d *OpcUaDriver) addMonitoredItems(ctx context.Context, sub *DeviceSubscrip
 	requests := make([]*ua.MonitoredItemCreateRequest, 0, len(points))
 	monitoredPoints := make([]model.Point, 0, len(points))
 	for _, p := range points {
+		if isMethodPoint(p) {
+			continue
+		}
+		if isEventPoint(p) {
+			spec, err := parseEventAddress(p.Address)
+			if err != nil {
+				zap.L().Error("[OPC UA] Invalid event point", zap.String("point_id", p.ID), zap.Error(err))
+				continue
+			}
+			req, err := eventMonitorRequest(spec, sub.NextHandle)
+			if err != nil {
+				zap.L().Error("[OPC UA] Invalid event filter", zap.String("point_id", p.ID), zap.Error(err))
+				continue
+			}
+			sub.mu.Lock()
+			sub.EventItems[sub.NextHandle] = eventItem{PointID: p.ID, Select: spec.Select}
+			sub.mu.Unlock()
+			sub.NextHandle++
+			monitoredPoints = append(monitoredPoints, p)
+			requests = append(requests, req)
+			continue
+		}
+
 		id, err := ua.ParseNodeID(p.Address)
 		if err != nil {
 			zap.L().Error("[OPC UA] Invalid node id", zap.String("address", p.Address), zap.Error(err))
@@ -997,6 +1061,8 @@ func (d *OpcUaDriver) subscriptionLoop(sub *DeviceSubscription) {
 				if client != nil {
 					client.RecordSuccess()
 				}
+			case *ua.EventNotificationList:
+				d.publishEvents(sub, x)
 			}
 		}
 	}
@@ -1098,6 +1164,13 @@ func (d *OpcUaDriver) readDirectBatch(ctx context.Context, client *ClientWrapper
 
 // WritePoint writes a value to an OPC-UA node with full type conversion and error handling
 func (d *OpcUaDriver) WritePoint(ctx context.Context, point model.Point, value any) error {
+	if isMethodPoint(point) {
+		return d.writeMethodPoint(ctx, point, value)
+	}
+	if isEventPoint(point) {
+		return fmt.Errorf("event point %s is read-only", point.ID)
+	}
+
 	d.mu.Lock()
 	client := d.activeClient
 	d.mu.Unlock()
@@ -2194,16 +2267,6 @@ func getAlternativeTypes(originalType string) []string {
 }
 
 func (d *OpcUaDriver) ScanObjects(ctx context.Context, config map[string]any) (any, error) {
-	endpoint, err := d.resolveEndpointInConfig(config)
-	if err != nil {
-		return nil, err
-	}
-
-	opts, err := d.buildClientOptions(config)
-	if err != nil {
-		return nil, err
-	}
-
 	// Start browsing from Objects folder
 	rootID := ua.NewNumericNodeID(0, 85)
 
@@ -2215,21 +2278,12 @@ func (d *OpcUaDriver) ScanObjects(ctx context.Context, config map[string]any) (a
 		}
 	}
 
-	// Create client
-	c, err := opcua.NewClient(endpoint, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create scan client: %v", err)
-	}
-
-	scanCtx := ctx
-	cancel := func() {}
-	if _, ok := ctx.Deadline(); !ok {
-		scanCtx, cancel = context.WithTimeout(ctx, 180*time.Second) // 增加到3分钟
-	}
+	scanCtx, cancel := withDefaultTimeout(ctx, 180*time.Second) // 增加到3分钟
 	defer cancel()
 
-	if err := c.Connect(scanCtx); err != nil {
-		return nil, fmt.Errorf("failed to connect: %v", err)
+	c, err := d.openBrowseClient(scanCtx, config)
+	if err != nil {
+		return nil, err
 	}
 	defer c.Close(context.Background())
 
@@ -0,0 +1,273 @@
+package profinetio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// PROFINET DCP (Discovery and Configuration Protocol) frame codec.
+//
+// DCP runs directly on Ethernet (EtherType 0x8892). Identify All is sent to
+// the multicast address 01:0E:CF:00:00:00 and every IO device answers with its
+// NameOfStation, IP suite and vendor/device identity. Set assigns the station
+// name or IP suite to a device addressed by MAC.
+
+const (
+	dcpEtherType = 0x8892
+
+	dcpFrameIDGetSet           = 0xFEFD
+	dcpFrameIDIdentifyRequest  = 0xFEFE
+	dcpFrameIDIdentifyResponse = 0xFEFF
+
+	dcpServiceGet      = 0x03
+	dcpServiceSet      = 0x04
+	dcpServiceIdentify = 0x05
+
+	dcpServiceTypeRequest  = 0x00
+	dcpServiceTypeResponse = 0x01
+
+	dcpOptionIP         = 0x01
+	dcpOptionDevice     = 0x02
+	dcpOptionControl    = 0x05
+	dcpOptionAll        = 0xFF
+	dcpSubIPMAC         = 0x01
+	dcpSubIPSuite       = 0x02
+	dcpSubDeviceType    = 0x01 // TypeOfStation (vendor text)
+	dcpSubDeviceName    = 0x02 // NameOfStation
+	dcpSubDeviceID      = 0x03
+	dcpSubDeviceRole    = 0x04
+	dcpSubDeviceAlias   = 0x06
+	dcpSubControlEnd    = 0x02
+	dcpSubControlResult = 0x04
+)
+
+var dcpIdentifyMulticast = net.HardwareAddr{0x01, 0x0E, 0xCF, 0x00, 0x00, 0x00}
+
+// DCPDevice is one IO device answering a DCP Identify request.
+type DCPDevice struct {
+	MAC           string `json:"mac"`
+	StationName   string `json:"station_name"`
+	TypeOfStation string `json:"type_of_station,omitempty"`
+	AliasName     string `json:"alias_name,omitempty"`
+	IP            string `json:"ip"`
+	Mask          string `json:"mask,omitempty"`
+	Gateway       string `json:"gateway,omitempty"`
+	VendorID      string `json:"vendor_id,omitempty"`
+	DeviceID      string `json:"device_id,omitempty"`
+	DeviceRole    int    `json:"device_role,omitempty"`
+}
+
+// DCPSetRequest assigns NameOfStation and/or the IP suite of a device.
+type DCPSetRequest struct {
+	MAC         string
+	StationName string
+	IP          string
+	Mask        string
+	Gateway     string
+	Permanent   bool
+}
+
+func dcpEthernetHeader(dst, src net.HardwareAddr, frameID uint16) []byte {
+	frame := make([]byte, 16)
+	copy(frame[0:6], dst)
+	copy(frame[6:12], src)
+	binary.BigEndian.PutUint16(frame[12:14], dcpEtherType)
+	binary.BigEndian.PutUint16(frame[14:16], frameID)
+	return frame
+}
+
+// buildDCPIdentifyRequest builds an Identify All request frame.
+// responseDelay is the factor (x10ms) devices use to spread their answers.
+func buildDCPIdentifyRequest(src net.HardwareAddr, xid uint32, responseDelay uint16) []byte {
+	frame := dcpEthernetHeader(dcpIdentifyMulticast, src, dcpFrameIDIdentifyRequest)
+	hdr := make([]byte, 10)
+	hdr[0] = dcpServiceIdentify
+	hdr[1] = dcpServiceTypeRequest
+	binary.BigEndian.PutUint32(hdr[2:6], xid)
+	binary.BigEndian.PutUint16(hdr[6:8], responseDelay)
+	binary.BigEndian.PutUint16(hdr[8:10], 4)
+	frame = append(frame, hdr...)
+	return append(frame, dcpOptionAll, dcpOptionAll, 0x00, 0x00)
+}
+
+// buildDCPSetRequest builds a Set request with the blocks of req followed by
+// the End-of-transaction control block.
+func buildDCPSetRequest(src net.HardwareAddr, xid uint32, req DCPSetRequest) ([]byte, error) {
+	dst, err := net.ParseMAC(req.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("profinet-io dcp: invalid MAC %q: %w", req.MAC, err)
+	}
+	qualifier := uint16(0)
+	if req.Permanent {
+		qualifier = 1
+	}
+
+	var blocks []byte
+	if req.StationName != "" {
+		name := strings.ToLower(strings.TrimSpace(req.StationName))
+		data := make([]byte, 2+len(name))
+		binary.BigEndian.PutUint16(data[0:2], qualifier)
+		copy(data[2:], name)
+		blocks = appendDCPBlock(blocks, dcpOptionDevice, dcpSubDeviceName, data)
+	}
+	if req.IP != "" {
+		data := make([]byte, 14)
+		binary.BigEndian.PutUint16(data[0:2], qualifier)
+		for i, s := range []string{req.IP, req.Mask, req.Gateway} {
+			if s == "" {
+				continue
+			}
+			ip := net.ParseIP(s).To4()
+			if ip == nil {
+				return nil, fmt.Errorf("profinet-io dcp: invalid IPv4 address %q", s)
+			}
+			copy(data[2+4*i:], ip)
+		}
+		blocks = appendDCPBlock(blocks, dcpOptionIP, dcpSubIPSuite, data)
+	}
+	if blocks == nil {
+		return nil, fmt.Errorf("profinet-io dcp: set requires station_name or ip")
+	}
+	blocks = appendDCPBlock(blocks, dcpOptionControl, dcpSubControlEnd, []byte{0x00, 0x00})
+
+	frame := dcpEthernetHeader(dst, src, dcpFrameIDGetSet)
+	hdr := make([]byte, 10)
+	hdr[0] = dcpServiceSet
+	hdr[1] = dcpServiceTypeRequest
+	binary.BigEndian.PutUint32(hdr[2:6], xid)
+	binary.BigEndian.PutUint16(hdr[8:10], uint16(len(blocks)))
+	frame = append(frame, hdr...)
+	return append(frame, blocks...), nil
+}
+
+func appendDCPBlock(dst []byte, option, sub byte, data []byte) []byte {
+	dst = append(dst, option, sub, byte(len(data)>>8), byte(len(data)))
+	dst = append(dst, data...)
+	if len(data)%2 == 1 {
+		dst = append(dst, 0x00)
+	}
+	return dst
+}
+
+// dcpPayload strips the Ethernet header (and an optional 802.1Q tag) and
+// returns the frame ID and the DCP PDU.
+func dcpPayload(frame []byte) (uint16, []byte, error) {
+	if len(frame) < 16 {
+		return 0, nil, fmt.Errorf("profinet-io dcp: frame too short")
+	}
+	off := 12
+	if binary.BigEndian.Uint16(frame[off:off+2]) == 0x8100 {
+		off += 4
+	}
+	if len(frame) < off+4 || binary.BigEndian.Uint16(frame[off:off+2]) != dcpEtherType {
+		return 0, nil, fmt.Errorf("profinet-io dcp: not a PROFINET frame")
+	}
+	return binary.BigEndian.Uint16(frame[off+2 : off+4]), frame[off+4:], nil
+}
+
+// parseDCPHeader validates the DCP header of a response and returns its blocks.
+func parseDCPHeader(pdu []byte, service byte, xid uint32) ([]byte, error) {
+	if len(pdu) < 10 {
+		return nil, fmt.Errorf("profinet-io dcp: short header")
+	}
+	if pdu[0] != service || pdu[1] != dcpServiceTypeResponse {
+		return nil, fmt.Errorf("profinet-io dcp: unexpected service %d/%d", pdu[0], pdu[1])
+	}
+	if got := binary.BigEndian.Uint32(pdu[2:6]); got != xid {
+		return nil, fmt.Errorf("profinet-io dcp: xid mismatch")
+	}
+	n := int(binary.BigEndian.Uint16(pdu[8:10]))
+	if len(pdu) < 10+n {
+		return nil, fmt.Errorf("profinet-io dcp: truncated blocks")
+	}
+	return pdu[10 : 10+n], nil
+}
+
+// walkDCPBlocks calls fn for each option block (data excludes padding).
+func walkDCPBlocks(blocks []byte, fn func(option, sub byte, data []byte)) {
+	for len(blocks) >= 4 {
+		option, sub := blocks[0], blocks[1]
+		n := int(binary.BigEndian.Uint16(blocks[2:4]))
+		if len(blocks) < 4+n {
+			return
+		}
+		fn(option, sub, blocks[4:4+n])
+		next := 4 + n + n%2
+		if next > len(blocks) {
+			return
+		}
+		blocks = blocks[next:]
+	}
+}
+
+// parseDCPIdentifyResponse decodes an Identify response frame for xid.
+func parseDCPIdentifyResponse(frame []byte, xid uint32) (*DCPDevice, error) {
+	frameID, pdu, err := dcpPayload(frame)
+	if err != nil {
+		return nil, err
+	}
+	if frameID != dcpFrameIDIdentifyResponse {
+		return nil, fmt.Errorf("profinet-io dcp: frame 0x%04X is not an identify response", frameID)
+	}
+	blocks, err := parseDCPHeader(pdu, dcpServiceIdentify, xid)
+	if err != nil {
+		return nil, err
+	}
+
+	dev := &DCPDevice{MAC: net.HardwareAddr(frame[6:12]).String()}
+	walkDCPBlocks(blocks, func(option, sub byte, data []byte) {
+		// Response blocks start with 2 bytes of BlockInfo.
+		if len(data) < 2 {
+			return
+		}
+		value := data[2:]
+		switch {
+		case option == dcpOptionDevice && sub == dcpSubDeviceName:
+			dev.StationName = string(value)
+		case option == dcpOptionDevice && sub == dcpSubDeviceType:
+			dev.TypeOfStation = string(value)
+		case option == dcpOptionDevice && sub == dcpSubDeviceAlias:
+			dev.AliasName = string(value)
+		case option == dcpOptionDevice && sub == dcpSubDeviceID && len(value) >= 4:
+			dev.VendorID = fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(value[0:2]))
+			dev.DeviceID = fmt.Sprintf("0x%04X", binary.BigEndian.Uint16(value[2:4]))
+		case option == dcpOptionDevice && sub == dcpSubDeviceRole && len(value) >= 1:
+			dev.DeviceRole = int(value[0])
+		case option == dcpOptionIP && sub == dcpSubIPSuite && len(value) >= 12:
+			dev.IP = net.IP(value[0:4]).String()
+			dev.Mask = net.IP(value[4:8]).String()
+			dev.Gateway = net.IP(value[8:12]).String()
+		}
+	})
+	return dev, nil
+}
+
+// parseDCPSetResponse checks the Control/Response blocks of a Set response.
+func parseDCPSetResponse(frame []byte, xid uint32) error {
+	frameID, pdu, err := dcpPayload(frame)
+	if err != nil {
+		return err
+	}
+	if frameID != dcpFrameIDGetSet {
+		return fmt.Errorf("profinet-io dcp: frame 0x%04X is not a set response", frameID)
+	}
+	blocks, err := parseDCPHeader(pdu, dcpServiceSet, xid)
+	if err != nil {
+		return err
+	}
+	var failures []string
+	walkDCPBlocks(blocks, func(option, sub byte, data []byte) {
+		if option != dcpOptionControl || sub != dcpSubControlResult || len(data) < 3 {
+			return
+		}
+		if data[2] != 0 {
+			failures = append(failures, fmt.Sprintf("option %d/%d error %d", data[0], data[1], data[2]))
+		}
+	})
+	if len(failures) > 0 {
+		return fmt.Errorf("profinet-io dcp: set rejected: %s", strings.Join(failures, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+package profinetio
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// dcpSocket is a raw AF_PACKET socket bound to one interface for DCP frames.
+// Opening it requires CAP_NET_RAW.
+type dcpSocket struct {
+	fd      int
+	ifIndex int
+	mac     net.HardwareAddr
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+func openDCPSocket(ifName string) (*dcpSocket, error) {
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, fmt.Errorf("profinet-io dcp: interface %q: %w", ifName, err)
+	}
+	if len(iface.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("profinet-io dcp: interface %q has no Ethernet address", ifName)
+	}
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(dcpEtherType)))
+	if err != nil {
+		return nil, fmt.Errorf("profinet-io dcp: open raw socket: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: htons(dcpEtherType), Ifindex: iface.Index}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("profinet-io dcp: bind %q: %w", ifName, err)
+	}
+	return &dcpSocket{fd: fd, ifIndex: iface.Index, mac: iface.HardwareAddr}, nil
+}
+
+func (s *dcpSocket) close() error {
+	return syscall.Close(s.fd)
+}
+
+func (s *dcpSocket) localMAC() net.HardwareAddr {
+	return s.mac
+}
+
+func (s *dcpSocket) send(frame []byte) error {
+	var dst [8]byte
+	copy(dst[:], frame[0:6])
+	addr := &syscall.SockaddrLinklayer{
+		Protocol: htons(dcpEtherType),
+		Ifindex:  s.ifIndex,
+		Halen:    6,
+		Addr:     dst,
+	}
+	return syscall.Sendto(s.fd, frame, 0, addr)
+}
+
+// receive calls fn for every frame until the deadline, ctx is done or fn
+// returns true.
+func (s *dcpSocket) receive(ctx context.Context, deadline time.Time, fn func(frame []byte) bool) error {
+	buf := make([]byte, 1518)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		// Short poll slices keep ctx cancellation responsive.
+		if remaining > 200*time.Millisecond {
+			remaining = 200 * time.Millisecond
+		}
+		tv := syscall.NsecToTimeval(remaining.Nanoseconds())
+		if err := syscall.SetsockoptTimeval(s.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+			return err
+		}
+		n, _, err := syscall.Recvfrom(s.fd, buf, 0)
+		if err != nil {
+			if err == syscall.EAGAIN || err == syscall.EWOULDBLOCK || err == syscall.EINTR {
+				continue
+			}
+			return err
+		}
+		if fn(buf[:n]) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package profinetio
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+type dcpSocket struct{}
+
+func openDCPSocket(ifName string) (*dcpSocket, error) {
+	return nil, fmt.Errorf("profinet-io dcp: raw Ethernet access is only supported on linux")
+}
+
+func (s *dcpSocket) close() error               { return nil }
+func (s *dcpSocket) localMAC() net.HardwareAddr { return nil }
+func (s *dcpSocket) send(frame []byte) error    { return nil }
+func (s *dcpSocket) receive(ctx context.Context, deadline time.Time, fn func(frame []byte) bool) error {
+	return nil
+}
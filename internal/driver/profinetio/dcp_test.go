@@ -0,0 +1,156 @@
+package profinetio
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dcpTestBlock(option, sub byte, value []byte) []byte {
+	// Identify response blocks carry 2 bytes of BlockInfo before the value.
+	return appendDCPBlock(nil, option, sub, append([]byte{0x00, 0x00}, value...))
+}
+
+func buildTestIdentifyResponse(xid uint32) []byte {
+	src := net.HardwareAddr{0x00, 0x1B, 0x1B, 0x11, 0x22, 0x33}
+	frame := dcpEthernetHeader(net.HardwareAddr{0x02, 0, 0, 0, 0, 0xAA}, src, dcpFrameIDIdentifyResponse)
+
+	var blocks []byte
+	blocks = append(blocks, dcpTestBlock(dcpOptionDevice, dcpSubDeviceType, []byte("ET 200SP"))...)
+	blocks = append(blocks, dcpTestBlock(dcpOptionDevice, dcpSubDeviceName, []byte("io-node-1"))...)
+	blocks = append(blocks, dcpTestBlock(dcpOptionDevice, dcpSubDeviceID, []byte{0x00, 0x2A, 0x03, 0x13})...)
+	blocks = append(blocks, dcpTestBlock(dcpOptionDevice, dcpSubDeviceRole, []byte{0x01, 0x00})...)
+	blocks = append(blocks, dcpTestBlock(dcpOptionIP, dcpSubIPSuite, []byte{192, 168, 0, 20, 255, 255, 255, 0, 192, 168, 0, 1})...)
+
+	hdr := make([]byte, 10)
+	hdr[0] = dcpServiceIdentify
+	hdr[1] = dcpServiceTypeResponse
+	binary.BigEndian.PutUint32(hdr[2:6], xid)
+	binary.BigEndian.PutUint16(hdr[8:10], uint16(len(blocks)))
+	return append(append(frame, hdr...), blocks...)
+}
+
+func TestDCP_IdentifyRequest(t *testing.T) {
+	src := net.HardwareAddr{0x02, 0, 0, 0, 0, 0xAA}
+	frame := buildDCPIdentifyRequest(src, 0x01020304, 1)
+
+	assert.Equal(t, []byte(dcpIdentifyMulticast), frame[0:6])
+	assert.Equal(t, []byte(src), frame[6:12])
+	frameID, pdu, err := dcpPayload(frame)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(dcpFrameIDIdentifyRequest), frameID)
+	assert.Equal(t, byte(dcpServiceIdentify), pdu[0])
+	assert.Equal(t, uint32(0x01020304), binary.BigEndian.Uint32(pdu[2:6]))
+	assert.Equal(t, []byte{0xFF, 0xFF, 0x00, 0x00}, pdu[10:])
+}
+
+func TestDCP_ParseIdentifyResponse(t *testing.T) {
+	frame := buildTestIdentifyResponse(7)
+	dev, err := parseDCPIdentifyResponse(frame, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "00:1b:1b:11:22:33", dev.MAC)
+	assert.Equal(t, "io-node-1", dev.StationName)
+	assert.Equal(t, "ET 200SP", dev.TypeOfStation)
+	assert.Equal(t, "0x002A", dev.VendorID)
+	assert.Equal(t, "0x0313", dev.DeviceID)
+	assert.Equal(t, 1, dev.DeviceRole)
+	assert.Equal(t, "192.168.0.20", dev.IP)
+	assert.Equal(t, "255.255.255.0", dev.Mask)
+	assert.Equal(t, "192.168.0.1", dev.Gateway)
+
+	_, err = parseDCPIdentifyResponse(frame, 8)
+	assert.ErrorContains(t, err, "xid")
+
+	// 802.1Q tagged frames are accepted as well.
+	tagged := append(append(append([]byte{}, frame[:12]...), 0x81, 0x00, 0xC0, 0x00), frame[12:]...)
+	dev, err = parseDCPIdentifyResponse(tagged, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "io-node-1", dev.StationName)
+
+	_, err = parseDCPIdentifyResponse(buildDCPIdentifyRequest(net.HardwareAddr{0, 0, 0, 0, 0, 1}, 7, 1), 7)
+	assert.Error(t, err)
+}
+
+func TestDCP_SetRequestAndResponse(t *testing.T) {
+	src := net.HardwareAddr{0x02, 0, 0, 0, 0, 0xAA}
+	frame, err := buildDCPSetRequest(src, 9, DCPSetRequest{
+		MAC:         "00:1b:1b:11:22:33",
+		StationName: "IO-Node-2",
+		IP:          "192.168.0.30",
+		Mask:        "255.255.255.0",
+		Permanent:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x1B, 0x1B, 0x11, 0x22, 0x33}, frame[0:6])
+
+	_, pdu, err := dcpPayload(frame)
+	require.NoError(t, err)
+	n := int(binary.BigEndian.Uint16(pdu[8:10]))
+	var got [][2]byte
+	walkDCPBlocks(pdu[10:10+n], func(option, sub byte, data []byte) {
+		got = append(got, [2]byte{option, sub})
+		switch {
+		case option == dcpOptionDevice && sub == dcpSubDeviceName:
+			assert.Equal(t, uint16(1), binary.BigEndian.Uint16(data[0:2]))
+			assert.Equal(t, "io-node-2", string(data[2:]))
+		case option == dcpOptionIP && sub == dcpSubIPSuite:
+			assert.Equal(t, []byte{192, 168, 0, 30, 255, 255, 255, 0, 0, 0, 0, 0}, data[2:])
+		}
+	})
+	assert.Equal(t, [][2]byte{{dcpOptionDevice, dcpSubDeviceName}, {dcpOptionIP, dcpSubIPSuite}, {dcpOptionControl, dcpSubControlEnd}}, got)
+
+	_, err = buildDCPSetRequest(src, 9, DCPSetRequest{MAC: "00:1b:1b:11:22:33"})
+	assert.ErrorContains(t, err, "station_name or ip")
+	_, err = buildDCPSetRequest(src, 9, DCPSetRequest{MAC: "bad", IP: "1.2.3.4"})
+	assert.ErrorContains(t, err, "MAC")
+
+	resp := func(blockErr byte) []byte {
+		f := dcpEthernetHeader(src, net.HardwareAddr{0x00, 0x1B, 0x1B, 0x11, 0x22, 0x33}, dcpFrameIDGetSet)
+		blocks := appendDCPBlock(nil, dcpOptionControl, dcpSubControlResult, []byte{dcpOptionDevice, dcpSubDeviceName, blockErr})
+		hdr := make([]byte, 10)
+		hdr[0] = dcpServiceSet
+		hdr[1] = dcpServiceTypeResponse
+		binary.BigEndian.PutUint32(hdr[2:6], 9)
+		binary.BigEndian.PutUint16(hdr[8:10], uint16(len(blocks)))
+		return append(append(f, hdr...), blocks...)
+	}
+	assert.NoError(t, parseDCPSetResponse(resp(0), 9))
+	assert.ErrorContains(t, parseDCPSetResponse(resp(3), 9), "rejected")
+}
+
+func TestDriverScanSimulation(t *testing.T) {
+	d := NewProfinetIODriver().(*ProfinetIODriver)
+	require.NoError(t, d.Init(model.DriverConfig{Config: map[string]any{"simulation": true}}))
+	require.NoError(t, d.SetDeviceConfig(map[string]any{"device_name": "plc-io", "ip": "10.0.0.5"}))
+	ctx := context.Background()
+
+	res, err := d.Scan(ctx, nil)
+	require.NoError(t, err)
+	devices := res.([]DCPDevice)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "plc-io", devices[0].StationName)
+	assert.Equal(t, "10.0.0.5", devices[0].IP)
+
+	_, err = d.Scan(ctx, map[string]any{"action": "set", "mac": devices[0].MAC, "station_name": "Renamed", "ip": "10.0.0.6", "mask": "255.0.0.0"})
+	require.NoError(t, err)
+	res, err = d.Scan(ctx, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", res.([]DCPDevice)[0].StationName)
+	assert.Equal(t, "10.0.0.6", res.([]DCPDevice)[0].IP)
+
+	_, err = d.Scan(ctx, map[string]any{"action": "set", "mac": "02:00:00:00:00:99", "ip": "10.0.0.7"})
+	assert.Error(t, err)
+	_, err = d.Scan(ctx, map[string]any{"action": "reboot"})
+	assert.ErrorContains(t, err, "unknown action")
+
+	// Real DCP needs an interface.
+	real := NewProfinetIODriver().(*ProfinetIODriver)
+	require.NoError(t, real.Init(model.DriverConfig{Config: map[string]any{}}))
+	_, err = real.Scan(ctx, nil)
+	assert.ErrorContains(t, err, "local_interface")
+}
@@ -0,0 +1,584 @@
+package profinetio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anviod/edgex/internal/driver"
+	"github.com/anviod/edgex/internal/model"
+)
+
+// GSDML (General Station Description Markup Language) import.
+//
+// The GSDML file describes the device access points (DAP), the modules that
+// can be plugged into each slot and the IO data of their submodules. Given a
+// module configuration (which module sits in which slot) the importer lays out
+// the cyclic data of every submodule and generates one point per data item.
+//
+// The driver addresses one IO image per slot/subslot, so input data starts at
+// byte 0 and output data follows directly after the input data.
+
+type gsdmlDocument struct {
+	XMLName xml.Name         `xml:"ISO15745Profile"`
+	Body    gsdmlProfileBody `xml:"ProfileBody"`
+}
+
+type gsdmlProfileBody struct {
+	Identity gsdmlIdentity    `xml:"DeviceIdentity"`
+	App      gsdmlApplication `xml:"ApplicationProcess"`
+}
+
+type gsdmlIdentity struct {
+	VendorID   string `xml:"VendorID,attr"`
+	DeviceID   string `xml:"DeviceID,attr"`
+	VendorName struct {
+		Value string `xml:"Value,attr"`
+	} `xml:"VendorName"`
+}
+
+type gsdmlApplication struct {
+	DAPs    []gsdmlModule `xml:"DeviceAccessPointList>DeviceAccessPointItem"`
+	Modules []gsdmlModule `xml:"ModuleList>ModuleItem"`
+	Texts   []gsdmlText   `xml:"ExternalTextList>PrimaryLanguage>Text"`
+}
+
+type gsdmlText struct {
+	ID    string `xml:"TextId,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+type gsdmlTextRef struct {
+	ID string `xml:"TextId,attr"`
+}
+
+type gsdmlModule struct {
+	ID           string           `xml:"ID,attr"`
+	IdentNumber  string           `xml:"ModuleIdentNumber,attr"`
+	FixedInSlots string           `xml:"FixedInSlots,attr"`
+	Name         gsdmlTextRef     `xml:"ModuleInfo>Name"`
+	Useable      []gsdmlModuleRef `xml:"UseableModules>ModuleItemRef"`
+	Submodules   []gsdmlSubmodule `xml:"VirtualSubmoduleList>VirtualSubmoduleItem"`
+}
+
+type gsdmlModuleRef struct {
+	Target         string `xml:"ModuleItemTarget,attr"`
+	AllowedInSlots string `xml:"AllowedInSlots,attr"`
+	FixedInSlots   string `xml:"FixedInSlots,attr"`
+}
+
+type gsdmlSubmodule struct {
+	ID              string          `xml:"ID,attr"`
+	IdentNumber     string          `xml:"SubmoduleIdentNumber,attr"`
+	FixedInSubslots string          `xml:"FixedInSubslots,attr"`
+	Name            gsdmlTextRef    `xml:"ModuleInfo>Name"`
+	Inputs          []gsdmlDataItem `xml:"IOData>Input>DataItem"`
+	Outputs         []gsdmlDataItem `xml:"IOData>Output>DataItem"`
+}
+
+type gsdmlDataItem struct {
+	DataType  string         `xml:"DataType,attr"`
+	Length    int            `xml:"Length,attr"`
+	TextID    string         `xml:"TextId,attr"`
+	UseAsBits bool           `xml:"UseAsBits,attr"`
+	Bits      []gsdmlBitItem `xml:"BitDataItem"`
+}
+
+type gsdmlBitItem struct {
+	BitOffset int    `xml:"BitOffset,attr"`
+	TextID    string `xml:"TextId,attr"`
+}
+
+// gsdmlFile is a parsed GSDML document with its text table resolved.
+type gsdmlFile struct {
+	doc   gsdmlDocument
+	texts map[string]string
+}
+
+func parseGSDML(data []byte) (*gsdmlFile, error) {
+	var doc gsdmlDocument
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// GSDML is UTF-8 by specification; accept mislabeled files as-is.
+		return input, nil
+	}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse GSDML: %w", err)
+	}
+	if len(doc.Body.App.DAPs) == 0 {
+		return nil, fmt.Errorf("GSDML contains no device access point")
+	}
+	f := &gsdmlFile{doc: doc, texts: make(map[string]string, len(doc.Body.App.Texts))}
+	for _, t := range doc.Body.App.Texts {
+		f.texts[t.ID] = t.Value
+	}
+	return f, nil
+}
+
+func (f *gsdmlFile) text(id, fallback string) string {
+	if v := strings.TrimSpace(f.texts[id]); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// dap returns the access point with the given ID, or the first one.
+func (f *gsdmlFile) dap(id string) (*gsdmlModule, error) {
+	daps := f.doc.Body.App.DAPs
+	if id == "" {
+		return &daps[0], nil
+	}
+	for i := range daps {
+		if daps[i].ID == id {
+			return &daps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("GSDML has no device access point %q", id)
+}
+
+// module looks a module up by ID or ModuleIdentNumber.
+func (f *gsdmlFile) module(ref string) *gsdmlModule {
+	mods := f.doc.Body.App.Modules
+	for i := range mods {
+		if mods[i].ID == ref {
+			return &mods[i]
+		}
+	}
+	if n, err := parseGSDMLNumber(ref); err == nil {
+		for i := range mods {
+			if m, err := parseGSDMLNumber(mods[i].IdentNumber); err == nil && m == n {
+				return &mods[i]
+			}
+		}
+	}
+	return nil
+}
+
+// parseGSDMLNumber accepts "0x…" hex or decimal.
+func parseGSDMLNumber(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if h, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		return strconv.ParseUint(h, 16, 64)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// parseGSDMLSlots expands a slot list such as "1..4 8" or "0x8000".
+func parseGSDMLSlots(s string) []int {
+	var out []int
+	for _, field := range strings.Fields(s) {
+		lo, hi, isRange := strings.Cut(field, "..")
+		a, err := parseGSDMLNumber(lo)
+		if err != nil {
+			continue
+		}
+		b := a
+		if isRange {
+			if b, err = parseGSDMLNumber(hi); err != nil || b < a {
+				continue
+			}
+		}
+		for n := a; n <= b; n++ {
+			out = append(out, int(n))
+		}
+	}
+	return out
+}
+
+// gsdmlTypes holds the byte sizes of the GSDML IO data types; the mapped
+// point type is empty for types the driver cannot decode.
+var gsdmlTypes = map[string]struct {
+	size      int
+	pointType string
+}{
+	"Integer8":              {1, "int8"},
+	"Integer16":             {2, "int16"},
+	"Integer32":             {4, "int32"},
+	"Integer64":             {8, "int64"},
+	"Unsigned8":             {1, "uint8"},
+	"Unsigned16":            {2, "uint16"},
+	"Unsigned32":            {4, "uint32"},
+	"Unsigned64":            {8, "uint64"},
+	"Float32":               {4, "float32"},
+	"Float64":               {8, "float64"},
+	"Boolean":               {1, "bool"},
+	"Unsigned8+Unsigned8":   {2, ""},
+	"Float32+Unsigned8":     {5, ""},
+	"Float32+Status8":       {5, ""},
+	"F_MessageTrailer4Byte": {4, ""},
+	"F_MessageTrailer5Byte": {5, ""},
+}
+
+// dataItemSize returns the size of a data item; OctetString/VisibleString and
+// friends take it from the Length attribute.
+func dataItemSize(it gsdmlDataItem) int {
+	if t, ok := gsdmlTypes[it.DataType]; ok {
+		return t.size
+	}
+	return it.Length
+}
+
+// GSDMLDataItem is one laid-out IO data item of a submodule.
+type GSDMLDataItem struct {
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+}
+
+// GSDMLSubmodule describes a submodule and its IO data layout.
+type GSDMLSubmodule struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	IdentNumber  string          `json:"ident_number"`
+	Subslot      int             `json:"subslot"`
+	InputLength  int             `json:"input_length"`
+	OutputLength int             `json:"output_length"`
+	Inputs       []GSDMLDataItem `json:"inputs"`
+	Outputs      []GSDMLDataItem `json:"outputs"`
+}
+
+// GSDMLModule is a catalog entry of a pluggable (or fixed) module.
+type GSDMLModule struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	IdentNumber  string           `json:"ident_number"`
+	AllowedSlots []int            `json:"allowed_slots,omitempty"`
+	FixedSlots   []int            `json:"fixed_slots,omitempty"`
+	Submodules   []GSDMLSubmodule `json:"submodules"`
+}
+
+// GSDMLSlot is one entry of a module configuration.
+type GSDMLSlot struct {
+	Slot   int    `json:"slot"`
+	Module string `json:"module"`
+}
+
+// describeSubmodules lays out the IO data of every virtual submodule.
+func (f *gsdmlFile) describeSubmodules(m *gsdmlModule) []GSDMLSubmodule {
+	out := make([]GSDMLSubmodule, 0, len(m.Submodules))
+	for i, sm := range m.Submodules {
+		subslot := i + 1
+		if s := parseGSDMLSlots(sm.FixedInSubslots); len(s) > 0 {
+			subslot = s[0]
+		}
+		desc := GSDMLSubmodule{
+			ID:          sm.ID,
+			Name:        f.text(sm.Name.ID, sm.ID),
+			IdentNumber: sm.IdentNumber,
+			Subslot:     subslot,
+		}
+		desc.Inputs, desc.InputLength = f.layoutItems(sm.Inputs, 0)
+		desc.Outputs, desc.OutputLength = f.layoutItems(sm.Outputs, desc.InputLength)
+		desc.OutputLength -= desc.InputLength
+		out = append(out, desc)
+	}
+	return out
+}
+
+// layoutItems assigns byte offsets starting at base and returns the end offset.
+// Layout stops at an item with unknown size since later offsets are unknowable.
+func (f *gsdmlFile) layoutItems(items []gsdmlDataItem, base int) ([]GSDMLDataItem, int) {
+	out := make([]GSDMLDataItem, 0, len(items))
+	off := base
+	for i, it := range items {
+		size := dataItemSize(it)
+		if size <= 0 {
+			break
+		}
+		out = append(out, GSDMLDataItem{
+			Name:     f.text(it.TextID, fmt.Sprintf("Item %d", i+1)),
+			DataType: it.DataType,
+			Offset:   off,
+			Length:   size,
+		})
+		off += size
+	}
+	return out, off
+}
+
+func (f *gsdmlFile) describeModule(m *gsdmlModule, ref *gsdmlModuleRef) GSDMLModule {
+	desc := GSDMLModule{
+		ID:          m.ID,
+		Name:        f.text(m.Name.ID, m.ID),
+		IdentNumber: m.IdentNumber,
+		Submodules:  f.describeSubmodules(m),
+	}
+	if ref != nil {
+		desc.AllowedSlots = parseGSDMLSlots(ref.AllowedInSlots)
+		desc.FixedSlots = parseGSDMLSlots(ref.FixedInSlots)
+	}
+	return desc
+}
+
+// catalog lists the modules usable with dap (all modules when the DAP has no
+// UseableModules list).
+func (f *gsdmlFile) catalog(dap *gsdmlModule) []GSDMLModule {
+	out := make([]GSDMLModule, 0)
+	if len(dap.Useable) == 0 {
+		for i := range f.doc.Body.App.Modules {
+			out = append(out, f.describeModule(&f.doc.Body.App.Modules[i], nil))
+		}
+		return out
+	}
+	for i := range dap.Useable {
+		if m := f.module(dap.Useable[i].Target); m != nil {
+			out = append(out, f.describeModule(m, &dap.Useable[i]))
+		}
+	}
+	return out
+}
+
+// resolveConfiguration validates the requested module configuration against
+// the DAP and adds modules fixed in their slots. Slot 0 holds the DAP itself.
+func (f *gsdmlFile) resolveConfiguration(dap *gsdmlModule, requested []GSDMLSlot) (map[int]*gsdmlModule, error) {
+	refs := make(map[string]*gsdmlModuleRef, len(dap.Useable))
+	for i := range dap.Useable {
+		refs[dap.Useable[i].Target] = &dap.Useable[i]
+	}
+
+	config := map[int]*gsdmlModule{0: dap}
+	for _, s := range parseGSDMLSlots(dap.FixedInSlots) {
+		config[s] = dap
+	}
+	for _, req := range requested {
+		m := f.module(req.Module)
+		if m == nil {
+			return nil, fmt.Errorf("slot %d: GSDML has no module %q", req.Slot, req.Module)
+		}
+		if _, taken := config[req.Slot]; taken {
+			return nil, fmt.Errorf("slot %d is already occupied", req.Slot)
+		}
+		if len(refs) > 0 {
+			ref, ok := refs[m.ID]
+			if !ok {
+				return nil, fmt.Errorf("slot %d: module %q is not usable with access point %q", req.Slot, m.ID, dap.ID)
+			}
+			if allowed := parseGSDMLSlots(ref.AllowedInSlots); len(allowed) > 0 && !containsInt(allowed, req.Slot) {
+				return nil, fmt.Errorf("slot %d: module %q is only allowed in slots %s", req.Slot, m.ID, ref.AllowedInSlots)
+			}
+		}
+		config[req.Slot] = m
+	}
+	for _, ref := range dap.Useable {
+		m := f.module(ref.Target)
+		if m == nil {
+			continue
+		}
+		for _, s := range parseGSDMLSlots(ref.FixedInSlots) {
+			if _, taken := config[s]; !taken {
+				config[s] = m
+			}
+		}
+	}
+	return config, nil
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// buildGSDMLPoints generates points for every IO data item of the configured
+// modules. Inputs are read-only, outputs read-write.
+func (f *gsdmlFile) buildGSDMLPoints(config map[int]*gsdmlModule) ([]model.Point, []driver.ImportSkip) {
+	slots := make([]int, 0, len(config))
+	for s := range config {
+		slots = append(slots, s)
+	}
+	sort.Ints(slots)
+
+	points := make([]model.Point, 0)
+	skipped := make([]driver.ImportSkip, 0)
+	for _, slot := range slots {
+		m := config[slot]
+		modName := f.text(m.Name.ID, m.ID)
+		group := fmt.Sprintf("Slot %d: %s", slot, modName)
+		descs := f.describeSubmodules(m)
+		for i, sm := range m.Submodules {
+			desc := descs[i]
+			for _, dir := range []struct {
+				prefix, rw string
+				raw        []gsdmlDataItem
+				laid       []GSDMLDataItem
+			}{
+				{"in", "R", sm.Inputs, desc.Inputs},
+				{"out", "RW", sm.Outputs, desc.Outputs},
+			} {
+				if len(dir.laid) < len(dir.raw) {
+					bad := dir.raw[len(dir.laid)]
+					skipped = append(skipped, driver.ImportSkip{
+						NodeID: fmt.Sprintf("%d:%d %s", slot, desc.Subslot, dir.prefix),
+						Reason: fmt.Sprintf("data type %q has no known length; following items not mapped", bad.DataType),
+					})
+				}
+				for j, item := range dir.laid {
+					p, skip := gsdmlItemPoints(dir.raw[j], item, slot, desc.Subslot, dir.prefix, dir.rw, f)
+					for k := range p {
+						p[k].Name = modName + " / " + p[k].Name
+						p[k].Group = group
+					}
+					points = append(points, p...)
+					skipped = append(skipped, skip...)
+				}
+			}
+		}
+	}
+	return points, skipped
+}
+
+// gsdmlItemPoints maps one data item; UseAsBits items with BitDataItems become
+// one bool point per bit. IO data is big-endian, so bit n of a multi-byte item
+// lives in the byte (size-1-n/8) of the item.
+func gsdmlItemPoints(raw gsdmlDataItem, item GSDMLDataItem, slot, subslot int, prefix, rw string, f *gsdmlFile) ([]model.Point, []driver.ImportSkip) {
+	baseID := fmt.Sprintf("%s_s%d_ss%d_%d", prefix, slot, subslot, item.Offset)
+	if raw.UseAsBits && len(raw.Bits) > 0 {
+		points := make([]model.Point, 0, len(raw.Bits))
+		for _, b := range raw.Bits {
+			if b.BitOffset < 0 || b.BitOffset >= item.Length*8 {
+				continue
+			}
+			byteOff := item.Offset + item.Length - 1 - b.BitOffset/8
+			points = append(points, model.Point{
+				ID:        fmt.Sprintf("%s_b%d", baseID, b.BitOffset),
+				Name:      f.text(b.TextID, fmt.Sprintf("%s.%d", item.Name, b.BitOffset)),
+				Address:   fmt.Sprintf("%d:%d:%d.%d", slot, subslot, byteOff, b.BitOffset%8),
+				DataType:  "bool",
+				ReadWrite: rw,
+			})
+		}
+		return points, nil
+	}
+	t := gsdmlTypes[raw.DataType]
+	if t.pointType == "" {
+		return nil, []driver.ImportSkip{{
+			NodeID: fmt.Sprintf("%d:%d:%d", slot, subslot, item.Offset),
+			Reason: fmt.Sprintf("unsupported data type %s", raw.DataType),
+		}}
+	}
+	return []model.Point{{
+		ID:        baseID,
+		Name:      item.Name,
+		Address:   fmt.Sprintf("%d:%d:%d", slot, subslot, item.Offset),
+		DataType:  t.pointType,
+		ReadWrite: rw,
+	}}, nil
+}
+
+// parseModuleConfiguration accepts modules as a list of {slot, module} objects
+// or its JSON string form (multipart uploads).
+func parseModuleConfiguration(v any) ([]GSDMLSlot, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		var list []any
+		if err := json.Unmarshal([]byte(s), &list); err != nil {
+			return nil, fmt.Errorf("invalid modules: %w", err)
+		}
+		v = list
+	}
+	var items []any
+	switch list := v.(type) {
+	case []any:
+		items = list
+	case []GSDMLSlot:
+		return list, nil
+	case []map[string]any:
+		for _, m := range list {
+			items = append(items, m)
+		}
+	default:
+		return nil, fmt.Errorf("invalid modules: expected a list of {slot, module}")
+	}
+	out := make([]GSDMLSlot, 0, len(items))
+	for _, it := range items {
+		m, ok := it.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid modules entry %v", it)
+		}
+		slot := parseInt(m["slot"], -1)
+		module := firstString(m, "module", "module_id", "id")
+		if slot < 1 || module == "" {
+			return nil, fmt.Errorf("invalid modules entry %v: slot >= 1 and module are required", it)
+		}
+		out = append(out, GSDMLSlot{Slot: slot, Module: module})
+	}
+	return out, nil
+}
+
+// ImportDeviceDescription parses a GSDML file and generates points for the
+// module configuration in params:
+//   - dap:     device access point ID (default: first DAP)
+//   - modules: [{slot, module}] where module is a module ID or ident number;
+//     modules fixed in their slots are added automatically
+//
+// Info carries the module catalog so clients can build a configuration.
+// Implements driver.DescriptionImporter interface.
+func (d *ProfinetIODriver) ImportDeviceDescription(_ context.Context, data []byte, params map[string]any) (*driver.DescriptionImport, error) {
+	f, err := parseGSDML(data)
+	if err != nil {
+		return nil, err
+	}
+	dap, err := f.dap(firstString(params, "dap", "gsdml_dap"))
+	if err != nil {
+		return nil, err
+	}
+	requested, err := parseModuleConfiguration(firstAny(params, "modules", "gsdml_modules"))
+	if err != nil {
+		return nil, err
+	}
+	config, err := f.resolveConfiguration(dap, requested)
+	if err != nil {
+		return nil, err
+	}
+	points, skipped := f.buildGSDMLPoints(config)
+
+	slots := make([]GSDMLSlot, 0, len(config))
+	for s, m := range config {
+		if s == 0 || m == dap {
+			continue
+		}
+		slots = append(slots, GSDMLSlot{Slot: s, Module: m.ID})
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Slot < slots[j].Slot })
+
+	id := f.doc.Body.Identity
+	daps := make([]map[string]any, 0, len(f.doc.Body.App.DAPs))
+	for _, a := range f.doc.Body.App.DAPs {
+		daps = append(daps, map[string]any{"id": a.ID, "name": f.text(a.Name.ID, a.ID), "ident_number": a.IdentNumber})
+	}
+	return &driver.DescriptionImport{
+		Points:  points,
+		Skipped: skipped,
+		DeviceConfig: map[string]any{
+			"vendor_id":     id.VendorID,
+			"device_id":     id.DeviceID,
+			"ident":         dap.IdentNumber,
+			"gsdml_dap":     dap.ID,
+			"gsdml_modules": slots,
+		},
+		Info: map[string]any{
+			"vendor_name":   id.VendorName.Value,
+			"vendor_id":     id.VendorID,
+			"device_id":     id.DeviceID,
+			"dap":           dap.ID,
+			"daps":          daps,
+			"modules":       f.catalog(dap),
+			"configuration": slots,
+		},
+	}, nil
+}
@@ -0,0 +1,176 @@
+package profinetio
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGSDML = `<?xml version="1.0" encoding="iso-8859-1"?>
+<ISO15745Profile xmlns="http://www.profibus.com/GSDML/2003/11/DeviceProfile">
+  <ProfileBody>
+    <DeviceIdentity VendorID="0x002A" DeviceID="0x0313">
+      <VendorName Value="ACME"/>
+    </DeviceIdentity>
+    <ApplicationProcess>
+      <DeviceAccessPointList>
+        <DeviceAccessPointItem ID="DAP1" ModuleIdentNumber="0x00000100" FixedInSlots="0" PhysicalSlots="0..8">
+          <ModuleInfo><Name TextId="T_DAP"/></ModuleInfo>
+          <UseableModules>
+            <ModuleItemRef ModuleItemTarget="IDM_STATUS" FixedInSlots="1" AllowedInSlots="1"/>
+            <ModuleItemRef ModuleItemTarget="IDM_DI16" AllowedInSlots="2..8"/>
+            <ModuleItemRef ModuleItemTarget="IDM_AIO" AllowedInSlots="2..8"/>
+          </UseableModules>
+          <VirtualSubmoduleList>
+            <VirtualSubmoduleItem ID="DAP_SUB" SubmoduleIdentNumber="0x0001"><IOData/></VirtualSubmoduleItem>
+          </VirtualSubmoduleList>
+        </DeviceAccessPointItem>
+      </DeviceAccessPointList>
+      <ModuleList>
+        <ModuleItem ID="IDM_STATUS" ModuleIdentNumber="0x00000010">
+          <ModuleInfo><Name TextId="T_STATUS"/></ModuleInfo>
+          <VirtualSubmoduleList>
+            <VirtualSubmoduleItem ID="IDS_STATUS" SubmoduleIdentNumber="0x0001">
+              <IOData><Input><DataItem DataType="Unsigned8" TextId="T_STATE"/></Input></IOData>
+            </VirtualSubmoduleItem>
+          </VirtualSubmoduleList>
+        </ModuleItem>
+        <ModuleItem ID="IDM_DI16" ModuleIdentNumber="0x00000020">
+          <ModuleInfo><Name TextId="T_DI16"/></ModuleInfo>
+          <VirtualSubmoduleList>
+            <VirtualSubmoduleItem ID="IDS_DI16" SubmoduleIdentNumber="0x0001" FixedInSubslots="1">
+              <IOData>
+                <Input>
+                  <DataItem DataType="Unsigned16" TextId="T_DI" UseAsBits="true">
+                    <BitDataItem BitOffset="0" TextId="T_DI0"/>
+                    <BitDataItem BitOffset="9"/>
+                  </DataItem>
+                </Input>
+              </IOData>
+            </VirtualSubmoduleItem>
+          </VirtualSubmoduleList>
+        </ModuleItem>
+        <ModuleItem ID="IDM_AIO" ModuleIdentNumber="0x00000030">
+          <ModuleInfo><Name TextId="T_AIO"/></ModuleInfo>
+          <VirtualSubmoduleList>
+            <VirtualSubmoduleItem ID="IDS_AIO" SubmoduleIdentNumber="0x0002">
+              <IOData>
+                <Input>
+                  <DataItem DataType="Float32" TextId="T_AI"/>
+                  <DataItem DataType="OctetString" Length="2"/>
+                  <DataItem DataType="Integer16" TextId="T_CNT"/>
+                </Input>
+                <Output>
+                  <DataItem DataType="Float32" TextId="T_AO"/>
+                </Output>
+              </IOData>
+            </VirtualSubmoduleItem>
+          </VirtualSubmoduleList>
+        </ModuleItem>
+      </ModuleList>
+      <ExternalTextList>
+        <PrimaryLanguage>
+          <Text TextId="T_DAP" Value="IM 1"/>
+          <Text TextId="T_STATUS" Value="Status"/>
+          <Text TextId="T_STATE" Value="State"/>
+          <Text TextId="T_DI16" Value="DI 16x24VDC"/>
+          <Text TextId="T_DI" Value="Inputs"/>
+          <Text TextId="T_DI0" Value="DI 0"/>
+          <Text TextId="T_AIO" Value="AI/AO"/>
+          <Text TextId="T_AI" Value="Temperature"/>
+          <Text TextId="T_CNT" Value="Counter"/>
+          <Text TextId="T_AO" Value="Setpoint"/>
+        </PrimaryLanguage>
+      </ExternalTextList>
+    </ApplicationProcess>
+  </ProfileBody>
+</ISO15745Profile>`
+
+func TestGSDML_ParseAndCatalog(t *testing.T) {
+	f, err := parseGSDML([]byte(testGSDML))
+	require.NoError(t, err)
+	dap, err := f.dap("")
+	require.NoError(t, err)
+	assert.Equal(t, "DAP1", dap.ID)
+	_, err = f.dap("DAP9")
+	assert.Error(t, err)
+
+	assert.Equal(t, "IDM_AIO", f.module("0x30").ID)
+	assert.Nil(t, f.module("IDM_NONE"))
+	assert.Equal(t, []int{1, 2, 3, 8}, parseGSDMLSlots("1..3 8"))
+	assert.Equal(t, []int{0x8000}, parseGSDMLSlots("0x8000"))
+
+	catalog := f.catalog(dap)
+	require.Len(t, catalog, 3)
+	aio := catalog[2]
+	assert.Equal(t, "AI/AO", aio.Name)
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8}, aio.AllowedSlots)
+	sm := aio.Submodules[0]
+	assert.Equal(t, 8, sm.InputLength)
+	assert.Equal(t, 4, sm.OutputLength)
+	assert.Equal(t, 6, sm.Inputs[2].Offset)
+	assert.Equal(t, 8, sm.Outputs[0].Offset, "outputs follow the inputs")
+
+	_, err = parseGSDML([]byte(`<ISO15745Profile><ProfileBody/></ISO15745Profile>`))
+	assert.ErrorContains(t, err, "access point")
+}
+
+func TestGSDML_ImportDeviceDescription(t *testing.T) {
+	d := NewProfinetIODriver().(*ProfinetIODriver)
+	ctx := context.Background()
+
+	res, err := d.ImportDeviceDescription(ctx, []byte(testGSDML), map[string]any{
+		"modules": `[{"slot": 2, "module": "IDM_DI16"}, {"slot": 3, "module": "0x00000030"}]`,
+	})
+	require.NoError(t, err)
+
+	byID := map[string]model.Point{}
+	for _, p := range res.Points {
+		byID[p.ID] = p
+		_, err := ParseAddress(p.Address)
+		require.NoError(t, err, p.Address)
+	}
+	// Fixed status module in slot 1 is added automatically.
+	assert.Equal(t, "1:1:0", byID["in_s1_ss1_0"].Address)
+	assert.Equal(t, "Status / State", byID["in_s1_ss1_0"].Name)
+	assert.Equal(t, "uint8", byID["in_s1_ss1_0"].DataType)
+	// Big-endian bit mapping: bit 0 is in the low byte, bit 9 in the high byte.
+	assert.Equal(t, "2:1:1.0", byID["in_s2_ss1_0_b0"].Address)
+	assert.Equal(t, "DI 16x24VDC / DI 0", byID["in_s2_ss1_0_b0"].Name)
+	assert.Equal(t, "2:1:0.1", byID["in_s2_ss1_0_b9"].Address)
+	assert.Equal(t, "bool", byID["in_s2_ss1_0_b9"].DataType)
+	assert.Equal(t, "float32", byID["in_s3_ss1_0"].DataType)
+	assert.Equal(t, "R", byID["in_s3_ss1_0"].ReadWrite)
+	assert.Equal(t, "3:1:6", byID["in_s3_ss1_6"].Address)
+	assert.Equal(t, "3:1:8", byID["out_s3_ss1_8"].Address)
+	assert.Equal(t, "RW", byID["out_s3_ss1_8"].ReadWrite)
+	assert.Equal(t, "Slot 3: AI/AO", byID["out_s3_ss1_8"].Group)
+	assert.Len(t, res.Points, 6)
+	require.Len(t, res.Skipped, 1)
+	assert.Contains(t, res.Skipped[0].Reason, "OctetString")
+
+	assert.Equal(t, "0x002A", res.DeviceConfig["vendor_id"])
+	assert.Equal(t, []GSDMLSlot{{1, "IDM_STATUS"}, {2, "IDM_DI16"}, {3, "IDM_AIO"}}, res.DeviceConfig["gsdml_modules"])
+	assert.Equal(t, "ACME", res.Info["vendor_name"])
+
+	// The persisted configuration (JSON-decoded) is accepted on re-import.
+	res, err = d.ImportDeviceDescription(ctx, []byte(testGSDML), map[string]any{
+		"gsdml_modules": []any{map[string]any{"slot": float64(4), "module": "IDM_DI16"}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, res.Points, 3)
+
+	_, err = d.ImportDeviceDescription(ctx, []byte(testGSDML), map[string]any{
+		"modules": []any{map[string]any{"slot": 9, "module": "IDM_DI16"}},
+	})
+	assert.ErrorContains(t, err, "only allowed in slots")
+	_, err = d.ImportDeviceDescription(ctx, []byte(testGSDML), map[string]any{
+		"modules": []any{map[string]any{"slot": 2, "module": "IDM_DI16"}, map[string]any{"slot": 2, "module": "IDM_AIO"}},
+	})
+	assert.ErrorContains(t, err, "occupied")
+	_, err = d.ImportDeviceDescription(ctx, []byte(testGSDML), map[string]any{"modules": "not json"})
+	assert.Error(t, err)
+}
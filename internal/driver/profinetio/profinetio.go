@@ -3,6 +3,7 @@ package profinetio
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/anviod/edgex/internal/driver"
@@ -24,6 +25,9 @@ type ProfinetIODriver struct {
 	transport  *ProfinetTransport
 	decoder    *ProfinetDecoder
 	scheduler  *ProfinetScheduler
+
+	simDCPOnce  sync.Once
+	simDCPState *simulatedDCP
 }
 
 func NewProfinetIODriver() driver.Driver {
@@ -151,3 +155,10 @@ func (d *ProfinetIODriver) calculateQualityScore(successRate float64) int {
 	}
 	return score
 }
+
+// Ensure ProfinetIODriver implements required interfaces.
+var (
+	_ driver.Driver              = (*ProfinetIODriver)(nil)
+	_ driver.Scanner             = (*ProfinetIODriver)(nil)
+	_ driver.DescriptionImporter = (*ProfinetIODriver)(nil)
+)
@@ -0,0 +1,202 @@
+package profinetio
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Scan discovers IO devices with DCP Identify All on the channel interface.
+// params:
+//   - interface: overrides the channel local_interface
+//   - timeout:   listen window in ms (default 3000)
+//   - action:    "identify" (default) or "set" to assign NameOfStation / IP suite
+//     of the device given by mac (station_name, ip, mask, gateway, permanent)
+//
+// Implements driver.Scanner interface.
+func (d *ProfinetIODriver) Scan(ctx context.Context, params map[string]any) (any, error) {
+	if params == nil {
+		params = map[string]any{}
+	}
+	action := strings.ToLower(firstString(params, "action"))
+	timeout := parseDurationMs(firstAny(params, "timeout"), 3*time.Second)
+
+	if action == "set" {
+		req := DCPSetRequest{
+			MAC:         firstString(params, "mac"),
+			StationName: firstString(params, "station_name", "device_name"),
+			IP:          firstString(params, "ip"),
+			Mask:        firstString(params, "mask", "subnet_mask"),
+			Gateway:     firstString(params, "gateway"),
+		}
+		req.Permanent, _ = params["permanent"].(bool)
+		if d.channelCfg.simulation {
+			return d.simDCP().set(req)
+		}
+		if err := dcpSet(ctx, d.scanInterface(params), req, timeout); err != nil {
+			return nil, err
+		}
+		return map[string]any{"mac": req.MAC, "success": true}, nil
+	}
+	if action != "" && action != "identify" {
+		return nil, fmt.Errorf("profinet-io Scan: unknown action %q", action)
+	}
+
+	if d.channelCfg.simulation {
+		return d.simDCP().identify(), nil
+	}
+	ifName := d.scanInterface(params)
+	if ifName == "" {
+		return nil, fmt.Errorf("profinet-io Scan: local_interface is required for DCP discovery")
+	}
+	devices, err := dcpIdentify(ctx, ifName, timeout)
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Info("[Profinet IO] DCP identify completed",
+		zap.String("interface", ifName),
+		zap.Int("device_count", len(devices)),
+	)
+	return devices, nil
+}
+
+func (d *ProfinetIODriver) scanInterface(params map[string]any) string {
+	if v := firstString(params, "interface", "local_interface"); v != "" {
+		return v
+	}
+	return d.channelCfg.localInterface
+}
+
+// dcpIdentify multicasts Identify All and collects answers until timeout.
+func dcpIdentify(ctx context.Context, ifName string, timeout time.Duration) ([]DCPDevice, error) {
+	sock, err := openDCPSocket(ifName)
+	if err != nil {
+		return nil, err
+	}
+	defer sock.close()
+
+	xid := rand.Uint32()
+	// Response delay factor 1 lets devices spread their answers over ~10ms slots.
+	if err := sock.send(buildDCPIdentifyRequest(sock.localMAC(), xid, 1)); err != nil {
+		return nil, fmt.Errorf("profinet-io dcp: send identify: %w", err)
+	}
+
+	seen := make(map[string]int)
+	devices := make([]DCPDevice, 0)
+	err = sock.receive(ctx, time.Now().Add(timeout), func(frame []byte) bool {
+		dev, err := parseDCPIdentifyResponse(frame, xid)
+		if err != nil {
+			return false
+		}
+		if i, ok := seen[dev.MAC]; ok {
+			devices[i] = *dev
+			return false
+		}
+		seen[dev.MAC] = len(devices)
+		devices = append(devices, *dev)
+		return false
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("profinet-io dcp: receive: %w", err)
+	}
+	return devices, nil
+}
+
+// dcpSet sends a Set request and waits for the device response.
+func dcpSet(ctx context.Context, ifName string, req DCPSetRequest, timeout time.Duration) error {
+	if ifName == "" {
+		return fmt.Errorf("profinet-io Scan: local_interface is required for DCP set")
+	}
+	sock, err := openDCPSocket(ifName)
+	if err != nil {
+		return err
+	}
+	defer sock.close()
+
+	xid := rand.Uint32()
+	frame, err := buildDCPSetRequest(sock.localMAC(), xid, req)
+	if err != nil {
+		return err
+	}
+	if err := sock.send(frame); err != nil {
+		return fmt.Errorf("profinet-io dcp: send set: %w", err)
+	}
+
+	var result error
+	answered := false
+	err = sock.receive(ctx, time.Now().Add(timeout), func(frame []byte) bool {
+		if _, pdu, err := dcpPayload(frame); err != nil || len(pdu) < 2 || pdu[0] != dcpServiceSet {
+			return false
+		}
+		result = parseDCPSetResponse(frame, xid)
+		answered = true
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("profinet-io dcp: receive: %w", err)
+	}
+	if !answered {
+		return fmt.Errorf("profinet-io dcp: no set response from %s within %s", req.MAC, timeout)
+	}
+	return result
+}
+
+// simulatedDCP answers DCP requests in simulation mode with a single device
+// built from the configured device settings.
+type simulatedDCP struct {
+	mu     sync.Mutex
+	device DCPDevice
+}
+
+func (d *ProfinetIODriver) simDCP() *simulatedDCP {
+	d.simDCPOnce.Do(func() {
+		dev := DCPDevice{
+			MAC:           "02:00:00:00:00:01",
+			StationName:   d.deviceCfg.deviceName,
+			TypeOfStation: "EdgeX Simulated IO-Device",
+			IP:            d.deviceCfg.ip,
+			Mask:          "255.255.255.0",
+			VendorID:      "0x0000",
+			DeviceID:      "0x0001",
+			DeviceRole:    1,
+		}
+		if dev.StationName == "" {
+			dev.StationName = "sim-io-device"
+		}
+		if dev.IP == "" {
+			dev.IP = "192.168.0.10"
+		}
+		d.simDCPState = &simulatedDCP{device: dev}
+	})
+	return d.simDCPState
+}
+
+func (s *simulatedDCP) identify() []DCPDevice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []DCPDevice{s.device}
+}
+
+func (s *simulatedDCP) set(req DCPSetRequest) (any, error) {
+	// Validate the request exactly like the real path does.
+	if _, err := buildDCPSetRequest(nil, 0, req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !strings.EqualFold(req.MAC, s.device.MAC) {
+		return nil, fmt.Errorf("profinet-io dcp: no set response from %s", req.MAC)
+	}
+	if req.StationName != "" {
+		s.device.StationName = strings.ToLower(strings.TrimSpace(req.StationName))
+	}
+	if req.IP != "" {
+		s.device.IP, s.device.Mask, s.device.Gateway = req.IP, req.Mask, req.Gateway
+	}
+	return map[string]any{"mac": s.device.MAC, "success": true}, nil
+}
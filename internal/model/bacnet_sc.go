@@ -0,0 +1,138 @@
+package model
+
+import "strings"
+
+// BACnet transport names shared by the bacnet-ip driver and the northbound BACnet server.
+const (
+	BACnetTransportIP = "ip"
+	BACnetTransportSC = "sc"
+)
+
+// BACnetSCConfig describes a BACnet/SC (Secure Connect, ASHRAE 135 Annex AB) hub connection.
+// Node certificates follow the OPC UA convention: PEM is persisted in the config store and
+// materialized to data/certs/bacnet-sc/{id}/ at start; file paths are a legacy fallback.
+type BACnetSCConfig struct {
+	PrimaryHubURI     string   `json:"primary_hub_uri" yaml:"primary_hub_uri"`       // wss://hub.example:443/
+	FailoverHubURI    string   `json:"failover_hub_uri" yaml:"failover_hub_uri"`     // optional
+	VMAC              string   `json:"vmac,omitempty" yaml:"vmac,omitempty"`         // 6-byte hex; empty derives a stable random-48 VMAC
+	UUID              string   `json:"uuid,omitempty" yaml:"uuid,omitempty"`         // device UUID; empty derives a stable UUID
+	HeartbeatInterval int      `json:"heartbeat_interval" yaml:"heartbeat_interval"` // seconds, default 300
+	ConnectTimeout    int      `json:"connect_timeout" yaml:"connect_timeout"`       // seconds, default 10
+	ReconnectMin      int      `json:"reconnect_min" yaml:"reconnect_min"`           // seconds, default 2
+	ReconnectMax      int      `json:"reconnect_max" yaml:"reconnect_max"`           // seconds, default 300
+	CertPEM           string   `json:"cert_pem,omitempty" yaml:"cert_pem,omitempty"`
+	KeyPEM            string   `json:"key_pem,omitempty" yaml:"key_pem,omitempty"`
+	CACertsPEM        []string `json:"ca_certs_pem,omitempty" yaml:"ca_certs_pem,omitempty"`
+	CertFile          string   `json:"cert_file,omitempty" yaml:"cert_file,omitempty"` // legacy path fallback
+	KeyFile           string   `json:"key_file,omitempty" yaml:"key_file,omitempty"`   // legacy path fallback
+	CAFile            string   `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`     // legacy path fallback
+	HasCert           bool     `json:"has_cert,omitempty" yaml:"-"`
+	HasKey            bool     `json:"has_key,omitempty" yaml:"-"`
+}
+
+// IsBACnetSC reports whether a transport string selects BACnet/SC.
+func IsBACnetSC(transport string) bool {
+	switch strings.ToLower(strings.TrimSpace(transport)) {
+	case BACnetTransportSC, "bacnet-sc", "secure-connect":
+		return true
+	}
+	return false
+}
+
+// MergeBACnetSCConfig keeps stored certificate material when the incoming config omits it
+// (API responses never carry the private key back to the UI).
+func MergeBACnetSCConfig(existing, incoming BACnetSCConfig) BACnetSCConfig {
+	out := incoming
+	if strings.TrimSpace(out.KeyPEM) == "" {
+		out.KeyPEM = existing.KeyPEM
+	}
+	if strings.TrimSpace(out.CertPEM) == "" {
+		out.CertPEM = existing.CertPEM
+	}
+	if len(out.CACertsPEM) == 0 && len(existing.CACertsPEM) > 0 {
+		out.CACertsPEM = append([]string(nil), existing.CACertsPEM...)
+	}
+	return out
+}
+
+// SanitizeBACnetSCForClient strips the private key before API responses.
+func SanitizeBACnetSCForClient(cfg BACnetSCConfig) BACnetSCConfig {
+	out := cfg
+	out.HasCert = strings.TrimSpace(out.CertPEM) != ""
+	out.HasKey = strings.TrimSpace(out.KeyPEM) != ""
+	out.KeyPEM = ""
+	return out
+}
+
+// MergeBACnetServerConfig merges incoming BACnet server config with stored SC secrets.
+func MergeBACnetServerConfig(existing, incoming BACnetServerConfig) BACnetServerConfig {
+	out := incoming
+	out.SC = MergeBACnetSCConfig(existing.SC, incoming.SC)
+	return out
+}
+
+// SanitizeChannelConfigForClient strips the BACnet/SC private key from channel config
+// (nested `sc` object) before API responses. The input map is not modified.
+func SanitizeChannelConfigForClient(config map[string]any) map[string]any {
+	sc, ok := config["sc"].(map[string]any)
+	if !ok {
+		return config
+	}
+	out := make(map[string]any, len(config))
+	for k, v := range config {
+		out[k] = v
+	}
+	scOut := make(map[string]any, len(sc)+2)
+	for k, v := range sc {
+		scOut[k] = v
+	}
+	scOut["has_cert"] = hasPEM(sc["cert_pem"])
+	scOut["has_key"] = hasPEM(sc["key_pem"])
+	delete(scOut, "key_pem")
+	out["sc"] = scOut
+	return out
+}
+
+// SanitizeChannelForClient returns a copy of the channel with secrets redacted from its config.
+func SanitizeChannelForClient(ch Channel) Channel {
+	ch.Config = SanitizeChannelConfigForClient(ch.Config)
+	return ch
+}
+
+// MergeChannelConfigSecrets keeps stored BACnet/SC certificate material when an incoming
+// channel config omits it, mirroring MergeBACnetSCConfig. incoming is updated in place.
+func MergeChannelConfigSecrets(existing, incoming map[string]any) {
+	oldSC, ok := existing["sc"].(map[string]any)
+	if !ok {
+		return
+	}
+	sc, ok := incoming["sc"].(map[string]any)
+	if !ok {
+		return
+	}
+	delete(sc, "has_cert")
+	delete(sc, "has_key")
+	for _, key := range []string{"key_pem", "cert_pem"} {
+		if !hasPEM(sc[key]) && hasPEM(oldSC[key]) {
+			sc[key] = oldSC[key]
+		}
+	}
+	if pemListLen(sc["ca_certs_pem"]) == 0 && pemListLen(oldSC["ca_certs_pem"]) > 0 {
+		sc["ca_certs_pem"] = oldSC["ca_certs_pem"]
+	}
+}
+
+func pemListLen(v any) int {
+	switch list := v.(type) {
+	case []any:
+		return len(list)
+	case []string:
+		return len(list)
+	}
+	return 0
+}
+
+func hasPEM(v any) bool {
+	s, _ := v.(string)
+	return strings.TrimSpace(s) != ""
+}
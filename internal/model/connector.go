@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// ConnectorConfig configures a registry-based northbound connector. Settings
+// is the connector-specific part, decoded by the connector itself.
+type ConnectorConfig struct {
+	ID             string         `json:"id" yaml:"id"`
+	Name           string         `json:"name" yaml:"name"`
+	Type           string         `json:"type" yaml:"type"` // Registered connector type
+	Enable         bool           `json:"enable" yaml:"enable"`
+	Settings       map[string]any `json:"settings,omitempty" yaml:"settings,omitempty"`
+	Devices        OpcUaDeviceMap `json:"devices" yaml:"devices"` // Key: DeviceID; legacy bool or DevicePublishConfig
+	VirtualDevices OpcUaDeviceMap `json:"virtual_devices" yaml:"virtual_devices"`
+}
+
+// DecodeSettings decodes Settings into v, a pointer to the connector's own
+// settings struct with json tags.
+func (c ConnectorConfig) DecodeSettings(v any) error {
+	data, err := json.Marshal(c.Settings)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
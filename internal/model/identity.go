@@ -168,6 +168,13 @@ func NormalizeNorthboundForSave(cfg NorthboundConfig) (NorthboundConfig, error)
 		}
 		out.BACnetServer[i].ID = id
 	}
+	for i := range out.Connectors {
+		id, err := ensureNamedID(out.Connectors[i].ID, out.Connectors[i].Name, "northbound connector")
+		if err != nil {
+			return NorthboundConfig{}, err
+		}
+		out.Connectors[i].ID = id
+	}
 
 	return out, nil
 }
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// WriteInterlock 写联锁：写入目标点位前检查的工艺许可条件，
+// 例如「泵 P1 运行且液位 < 90% 时才允许打开阀门 V1」。
+type WriteInterlock struct {
+	ID        string       `json:"id" yaml:"id"`
+	Name      string       `json:"name" yaml:"name"`
+	Enable    bool         `json:"enable" yaml:"enable"`
+	ChannelID string       `json:"channel_id" yaml:"channel_id"` // Target point
+	DeviceID  string       `json:"device_id" yaml:"device_id"`
+	PointID   string       `json:"point_id" yaml:"point_id"`
+	When      string       `json:"when,omitempty" yaml:"when,omitempty"`       // Expression on the written `value`; empty = every write
+	Sources   []RuleSource `json:"sources" yaml:"sources"`                     // Aliases available in Condition
+	Condition string       `json:"condition" yaml:"condition"`                 // Permissive: write allowed only when true
+	MaxAge    string       `json:"max_age,omitempty" yaml:"max_age,omitempty"` // Reject when a source value is older, e.g. "30s"
+	Message   string       `json:"message,omitempty" yaml:"message,omitempty"` // Rejection reason shown to the caller
+
+	Bypass *InterlockBypass `json:"bypass,omitempty" yaml:"bypass,omitempty"`
+
+	// Runtime fields
+	RejectCount  int64     `json:"reject_count" yaml:"-"`
+	LastRejected time.Time `json:"last_rejected,omitempty" yaml:"-"`
+	LastReason   string    `json:"last_reason,omitempty" yaml:"-"`
+}
+
+// InterlockBypass 临时旁路：到期前联锁不生效，只能由管理员设置
+type InterlockBypass struct {
+	User   string    `json:"user" yaml:"user"`
+	Reason string    `json:"reason" yaml:"reason"`
+	Since  time.Time `json:"since" yaml:"since"`
+	Until  time.Time `json:"until" yaml:"until"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "strings"
+
+// MQTTTopicOptions overrides the connection-wide QoS/retain for one topic kind.
+type MQTTTopicOptions struct {
+	QoS    byte `json:"qos" yaml:"qos"`
+	Retain bool `json:"retain" yaml:"retain"`
+}
+
+// MQTTTLSConfig describes a TLS (optionally mutual TLS) broker connection. Certificates
+// follow the BACnet/SC convention: PEM is persisted in the config store, never on disk.
+type MQTTTLSConfig struct {
+	Enable             bool   `json:"enable" yaml:"enable"`
+	CACertPEM          string `json:"ca_cert_pem,omitempty" yaml:"ca_cert_pem,omitempty"`         // empty = system roots
+	ClientCertPEM      string `json:"client_cert_pem,omitempty" yaml:"client_cert_pem,omitempty"` // mutual TLS
+	ClientKeyPEM       string `json:"client_key_pem,omitempty" yaml:"client_key_pem,omitempty"`
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"` // SNI / verification name override
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	HasClientCert      bool   `json:"has_client_cert,omitempty" yaml:"-"`
+	HasClientKey       bool   `json:"has_client_key,omitempty" yaml:"-"`
+}
+
+// MergeMQTTConfig keeps stored certificate material when the incoming config omits it
+// (API responses never carry the private key back to the UI).
+func MergeMQTTConfig(existing, incoming MQTTConfig) MQTTConfig {
+	out := incoming
+	if strings.TrimSpace(out.TLS.ClientKeyPEM) == "" {
+		out.TLS.ClientKeyPEM = existing.TLS.ClientKeyPEM
+	}
+	if strings.TrimSpace(out.TLS.ClientCertPEM) == "" {
+		out.TLS.ClientCertPEM = existing.TLS.ClientCertPEM
+	}
+	out.TLS.HasClientCert = false
+	out.TLS.HasClientKey = false
+	return out
+}
+
+// SanitizeMQTTForClient strips the client private key before API responses.
+func SanitizeMQTTForClient(cfg MQTTConfig) MQTTConfig {
+	out := cfg
+	out.TLS.HasClientCert = strings.TrimSpace(out.TLS.ClientCertPEM) != ""
+	out.TLS.HasClientKey = strings.TrimSpace(out.TLS.ClientKeyPEM) != ""
+	out.TLS.ClientKeyPEM = ""
+	return out
+}
@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+// NotificationConfig 告警通知配置：发送通道、接收人与升级策略
+type NotificationConfig struct {
+	Channels   []NotificationChannel   `json:"channels" yaml:"channels"`
+	Recipients []NotificationRecipient `json:"recipients" yaml:"recipients"`
+	Policies   []NotificationPolicy    `json:"policies" yaml:"policies"`
+}
+
+// NotificationChannel 通知发送通道
+type NotificationChannel struct {
+	ID      string         `json:"id" yaml:"id"`
+	Name    string         `json:"name" yaml:"name"`
+	Type    string         `json:"type" yaml:"type"` // smtp, syslog, webhook
+	Enable  bool           `json:"enable" yaml:"enable"`
+	SMTP    *SMTPConfig    `json:"smtp,omitempty" yaml:"smtp,omitempty"`
+	Syslog  *SyslogConfig  `json:"syslog,omitempty" yaml:"syslog,omitempty"`
+	Webhook *WebhookConfig `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	From     string `json:"from" yaml:"from"`
+	TLS      string `json:"tls,omitempty" yaml:"tls,omitempty"` // none, starttls, tls
+	Timeout  string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// SyslogConfig RFC5424 syslog 目标
+type SyslogConfig struct {
+	Network  string `json:"network" yaml:"network"` // udp, tcp
+	Address  string `json:"address" yaml:"address"` // host:port
+	Facility int    `json:"facility" yaml:"facility"`
+	AppName  string `json:"app_name,omitempty" yaml:"app_name,omitempty"`
+}
+
+type WebhookConfig struct {
+	URL     string            `json:"url" yaml:"url"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Timeout string            `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// NotificationRecipient 通知接收人
+type NotificationRecipient struct {
+	ID         string      `json:"id" yaml:"id"`
+	Name       string      `json:"name" yaml:"name"`
+	Email      string      `json:"email,omitempty" yaml:"email,omitempty"`
+	Webhook    string      `json:"webhook,omitempty" yaml:"webhook,omitempty"` // Overrides the channel URL, e.g. a personal pager endpoint
+	QuietHours *QuietHours `json:"quiet_hours,omitempty" yaml:"quiet_hours,omitempty"`
+	Throttle   string      `json:"throttle,omitempty" yaml:"throttle,omitempty"` // Minimum interval between notifications, e.g. "10m"
+}
+
+// QuietHours 免打扰时段，跨零点时 Start 晚于 End，如 22:00 ~ 07:00
+type QuietHours struct {
+	Start    string   `json:"start" yaml:"start"` // HH:MM
+	End      string   `json:"end" yaml:"end"`
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	Except   []string `json:"except,omitempty" yaml:"except,omitempty"` // Severities still delivered, e.g. ["critical"]
+}
+
+// NotificationPolicy 升级策略：第一级立即通知，未确认时按 Delay 逐级升级
+type NotificationPolicy struct {
+	ID     string            `json:"id" yaml:"id"`
+	Name   string            `json:"name" yaml:"name"`
+	Levels []EscalationLevel `json:"levels" yaml:"levels"`
+}
+
+type EscalationLevel struct {
+	Delay      string   `json:"delay,omitempty" yaml:"delay,omitempty"` // Wait after the previous level before escalating, e.g. "15m"
+	Recipients []string `json:"recipients" yaml:"recipients"`
+	Channels   []string `json:"channels" yaml:"channels"`
+}
+
+// Notification 一次告警通知及其升级、确认与投递记录（运行时，不持久化）
+type Notification struct {
+	ID          string                 `json:"id"`
+	PolicyID    string                 `json:"policy_id"`
+	RuleID      string                 `json:"rule_id,omitempty"`
+	Key         string                 `json:"key"`
+	Severity    string                 `json:"severity"`
+	Subject     string                 `json:"subject"`
+	Message     string                 `json:"message"`
+	Level       int                    `json:"level"`
+	Occurrences int                    `json:"occurrences"`
+	CreatedAt   time.Time              `json:"created_at"`
+	EscalatedAt time.Time              `json:"escalated_at"`
+	Acked       bool                   `json:"acked"`
+	AckedBy     string                 `json:"acked_by,omitempty"`
+	AckedAt     time.Time              `json:"acked_at,omitempty"`
+	Deliveries  []NotificationDelivery `json:"deliveries"`
+}
+
+type NotificationDelivery struct {
+	Level     int       `json:"level"`
+	Recipient string    `json:"recipient,omitempty"`
+	Channel   string    `json:"channel"`
+	Status    string    `json:"status"` // sent, failed, throttled, quiet
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+}
@@ -32,5 +32,18 @@ func SanitizeNorthboundForClient(cfg NorthboundConfig) NorthboundConfig {
 	for i := range out.OPCUA {
 		out.OPCUA[i] = SanitizeOPCUAForClient(out.OPCUA[i])
 	}
+	if len(cfg.BACnetServer) > 0 {
+		out.BACnetServer = make([]BACnetServerConfig, len(cfg.BACnetServer))
+		for i, c := range cfg.BACnetServer {
+			c.SC = SanitizeBACnetSCForClient(c.SC)
+			out.BACnetServer[i] = c
+		}
+	}
+	if len(cfg.MQTT) > 0 {
+		out.MQTT = make([]MQTTConfig, len(cfg.MQTT))
+		for i, c := range cfg.MQTT {
+			out.MQTT[i] = SanitizeMQTTForClient(c)
+		}
+	}
 	return out
 }
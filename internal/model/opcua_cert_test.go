@@ -42,3 +42,57 @@ func TestSanitizeOPCUAForClient(t *testing.T) {
 		t.Fatalf("expected has flags set")
 	}
 }
+
+func TestMergeAndSanitizeBACnetSC(t *testing.T) {
+	existing := BACnetServerConfig{SC: BACnetSCConfig{CertPEM: "cert", KeyPEM: "key", CACertsPEM: []string{"ca"}}}
+	incoming := BACnetServerConfig{ID: "b1", Transport: "sc", SC: BACnetSCConfig{PrimaryHubURI: "wss://hub/"}}
+
+	out := MergeBACnetServerConfig(existing, incoming)
+	if out.SC.KeyPEM != "key" || out.SC.CertPEM != "cert" || len(out.SC.CACertsPEM) != 1 {
+		t.Fatalf("expected SC secrets preserved, got %+v", out.SC)
+	}
+	if out.SC.PrimaryHubURI != "wss://hub/" || !IsBACnetSC(out.Transport) {
+		t.Fatalf("expected incoming fields kept, got %+v", out)
+	}
+
+	nb := SanitizeNorthboundForClient(NorthboundConfig{BACnetServer: []BACnetServerConfig{out}})
+	if nb.BACnetServer[0].SC.KeyPEM != "" || !nb.BACnetServer[0].SC.HasKey || !nb.BACnetServer[0].SC.HasCert {
+		t.Fatalf("expected key stripped with has flags, got %+v", nb.BACnetServer[0].SC)
+	}
+	if out.SC.KeyPEM != "key" {
+		t.Fatal("sanitizing must not mutate the stored config")
+	}
+}
+
+func TestMergeAndSanitizeBACnetSCChannelConfig(t *testing.T) {
+	stored := map[string]any{
+		"transport": "sc",
+		"sc":        map[string]any{"primary_hub_uri": "wss://hub/", "cert_pem": "cert", "key_pem": "key", "ca_certs_pem": []any{"ca"}},
+	}
+
+	client := SanitizeChannelForClient(Channel{ID: "ch", Config: stored})
+	sc := client.Config["sc"].(map[string]any)
+	if _, ok := sc["key_pem"]; ok || sc["has_key"] != true || sc["has_cert"] != true {
+		t.Fatalf("expected key stripped with has flags, got %+v", sc)
+	}
+	if stored["sc"].(map[string]any)["key_pem"] != "key" {
+		t.Fatal("sanitizing must not mutate the stored config")
+	}
+
+	// The UI sends back what it received: no key, plus the has_* flags
+	incoming := map[string]any{"transport": "sc", "sc": sc}
+	MergeChannelConfigSecrets(stored, incoming)
+	merged := incoming["sc"].(map[string]any)
+	if merged["key_pem"] != "key" || merged["cert_pem"] != "cert" || len(merged["ca_certs_pem"].([]any)) != 1 {
+		t.Fatalf("expected SC secrets preserved, got %+v", merged)
+	}
+	if _, ok := merged["has_key"]; ok {
+		t.Fatalf("has_* flags must not be stored, got %+v", merged)
+	}
+
+	replaced := map[string]any{"sc": map[string]any{"key_pem": "new-key"}}
+	MergeChannelConfigSecrets(stored, replaced)
+	if replaced["sc"].(map[string]any)["key_pem"] != "new-key" {
+		t.Fatal("an explicitly provided key must replace the stored one")
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// Recipe 配方（ISA-88 简化）：带参数的过程，由写入、等待条件与计时步骤顺序组成。
+// 中止或步骤失败时依次执行 SafeState 步骤，将设备置于安全状态。
+type Recipe struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Unit        string            `json:"unit,omitempty" yaml:"unit,omitempty"` // Equipment unit; one active run per unit (recipe ID when empty)
+	Parameters  []RecipeParameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Steps       []RecipeStep      `json:"steps" yaml:"steps"`
+	SafeState   []RecipeStep      `json:"safe_state,omitempty" yaml:"safe_state,omitempty"` // write / timer steps run on abort or failure
+}
+
+// RecipeParameter 配方参数，在步骤中以 ${name} 引用，表达式中直接作为变量
+type RecipeParameter struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type,omitempty" yaml:"type,omitempty"` // number (default), string, bool
+	Default     any      `json:"default,omitempty" yaml:"default,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Min         *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	Unit        string   `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// RecipeStep 配方步骤：write 写点位，wait 等待条件成立，timer 计时
+type RecipeStep struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"` // write, wait, timer
+
+	// write
+	ChannelID  string `json:"channel_id,omitempty" yaml:"channel_id,omitempty"`
+	DeviceID   string `json:"device_id,omitempty" yaml:"device_id,omitempty"`
+	PointID    string `json:"point_id,omitempty" yaml:"point_id,omitempty"`
+	Value      any    `json:"value,omitempty" yaml:"value,omitempty"`           // Literal or "${param}"
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"` // Computed value, overrides Value
+
+	// wait
+	Sources   []RuleSource `json:"sources,omitempty" yaml:"sources,omitempty"` // Aliases available in Condition
+	Condition string       `json:"condition,omitempty" yaml:"condition,omitempty"`
+	Interval  string       `json:"interval,omitempty" yaml:"interval,omitempty"`     // Poll interval, default 1s
+	Timeout   string       `json:"timeout,omitempty" yaml:"timeout,omitempty"`       // Empty = wait indefinitely
+	OnTimeout string       `json:"on_timeout,omitempty" yaml:"on_timeout,omitempty"` // abort (default), hold, continue
+
+	// timer
+	Duration string `json:"duration,omitempty" yaml:"duration,omitempty"` // e.g. "30s" or "${soak_time}"
+}
+
+// RecipeRun 一次配方执行的记录，状态变化时持久化用于追溯
+type RecipeRun struct {
+	ID          string             `json:"id"`
+	RecipeID    string             `json:"recipe_id"`
+	RecipeName  string             `json:"recipe_name"`
+	Unit        string             `json:"unit"`
+	Recipe      Recipe             `json:"recipe"` // Definition at start
+	Params      map[string]any     `json:"params"`
+	State       string             `json:"state"` // running, holding, held, aborting, aborted, completed
+	StartedBy   string             `json:"started_by,omitempty"`
+	StartedAt   time.Time          `json:"started_at"`
+	EndedAt     time.Time          `json:"ended_at,omitempty"`
+	CurrentStep int                `json:"current_step"` // Index into Steps, -1 before the first step
+	Steps       []RecipeStepStatus `json:"steps"`
+	SafeState   []RecipeStepStatus `json:"safe_state,omitempty"`
+	Events      []RecipeRunEvent   `json:"events"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// RecipeStepStatus 步骤执行状态：pending, running, held, completed, timeout, failed, aborted
+type RecipeStepStatus struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	State       string    `json:"state"`
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	EndedAt     time.Time `json:"ended_at,omitempty"`
+	Value       any       `json:"value,omitempty"`        // Written value, or source values of a wait step
+	Message     string    `json:"message,omitempty"`      // Last wait result or error
+	Deadline    time.Time `json:"deadline,omitempty"`     // Timer end / wait timeout while running
+	RemainingMs int64     `json:"remaining_ms,omitempty"` // Time left on the timer or wait timeout
+}
+
+// RecipeRunEvent 运行记录中的操作与状态变化
+type RecipeRunEvent struct {
+	TS      time.Time `json:"ts"`
+	Event   string    `json:"event"` // start, hold, held, resume, abort, step_failed, safe_state, completed, aborted
+	User    string    `json:"user,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
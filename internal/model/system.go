@@ -60,11 +60,16 @@ type StaticRoute struct {
 
 // HAConfig represents High Availability settings
 type HAConfig struct {
+	Enabled       bool   `json:"enabled"`
 	Role          string `json:"role"`           // master, backup
 	HeartbeatType string `json:"heartbeat_type"` // TCP, UDP, HTTP
 	Interval      int    `json:"interval"`       // Seconds
 	Timeout       int    `json:"timeout"`        // Seconds
 	Retries       int    `json:"retries"`
+	PeerAddress   string `json:"peer_address"` // host or host:port of the peer node
+	Port          int    `json:"port"`         // Local heartbeat listen port (default 9460)
+	Witness       string `json:"witness"`      // host[:port] that must be reachable before promoting (split-brain guard)
+	SharedKey     string `json:"shared_key"`   // HMAC key authenticating heartbeats and config mirroring
 }
 
 // HostnameConfig represents system hostname and access settings
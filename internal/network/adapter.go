@@ -24,6 +24,12 @@ type NetworkAdapter interface {
 	GetInterfaces() ([]model.NetworkInterface, error)
 	GetRoutes() ([]model.StaticRoute, error)
 	ValidateConnectivity(targets []model.ConnectivityTarget) (model.ConnectivityReport, error)
+
+	// Clock operations (see clock.go)
+	SetSystemTime(t time.Time) error
+	AdjustSystemTime(offset time.Duration) error
+	SetTimezone(name string) error
+	SyncHardwareClock() error
 }
 
 // NewNetworkAdapter creates a platform-specific network adapter
@@ -0,0 +1,165 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSlewOffset is the largest correction applied by slewing; larger offsets
+// step the clock. Matches the 0.5 s limit of adjtime(2) single-shot mode.
+const MaxSlewOffset = 500 * time.Millisecond
+
+// SetSystemTime steps the system clock to t.
+func (nm *NetworkManager) SetSystemTime(t time.Time) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	return nm.adapter.SetSystemTime(t)
+}
+
+// AdjustSystemTime corrects the clock by offset, slewing when the OS supports it.
+func (nm *NetworkManager) AdjustSystemTime(offset time.Duration) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	return nm.adapter.AdjustSystemTime(offset)
+}
+
+// SetTimezone sets the system timezone (IANA name, e.g. Asia/Shanghai).
+func (nm *NetworkManager) SetTimezone(name string) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	return nm.adapter.SetTimezone(name)
+}
+
+// SyncHardwareClock writes the system time to the RTC.
+func (nm *NetworkManager) SyncHardwareClock() error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	return nm.adapter.SyncHardwareClock()
+}
+
+func validTimezone(name string) error {
+	if name == "" {
+		return fmt.Errorf("timezone is empty")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return nil
+}
+
+// --- Linux ---
+
+func (a *LinuxAdapter) SetSystemTime(t time.Time) error {
+	return setSystemClock(t)
+}
+
+func (a *LinuxAdapter) AdjustSystemTime(offset time.Duration) error {
+	if offset > MaxSlewOffset || offset < -MaxSlewOffset {
+		return setSystemClock(time.Now().Add(offset))
+	}
+	return slewSystemClock(offset)
+}
+
+func (a *LinuxAdapter) SetTimezone(name string) error {
+	if err := validTimezone(name); err != nil {
+		return err
+	}
+	if commandExistsFn("timedatectl") {
+		if output, err := exec.Command("timedatectl", "set-timezone", name).CombinedOutput(); err == nil {
+			return nil
+		} else if !systemdActiveFn("systemd-timedated") && strings.Contains(string(output), "Failed to connect") {
+			// No systemd bus (containers, busybox images): fall through.
+		} else {
+			return fmt.Errorf("timedatectl set-timezone: %v, output: %s", err, output)
+		}
+	}
+	zone := filepath.Join("/usr/share/zoneinfo", name)
+	if _, err := os.Stat(zone); err != nil {
+		return fmt.Errorf("zoneinfo for %s not installed: %w", name, err)
+	}
+	_ = os.Remove("/etc/localtime")
+	if err := os.Symlink(zone, "/etc/localtime"); err != nil {
+		return fmt.Errorf("link /etc/localtime: %w", err)
+	}
+	_ = os.WriteFile("/etc/timezone", []byte(name+"\n"), 0644)
+	return nil
+}
+
+func (a *LinuxAdapter) SyncHardwareClock() error {
+	if !commandExistsFn("hwclock") {
+		return fmt.Errorf("hwclock not available")
+	}
+	if output, err := exec.Command("hwclock", "--systohc", "--utc").CombinedOutput(); err != nil {
+		return fmt.Errorf("hwclock --systohc: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+// --- macOS ---
+
+func (a *DarwinAdapter) SetSystemTime(t time.Time) error {
+	// date [-u] [[[mm]dd]HH]MM[[cc]yy][.ss]
+	arg := t.UTC().Format("010215042006.05")
+	if output, err := exec.Command("date", "-u", arg).CombinedOutput(); err != nil {
+		return fmt.Errorf("date: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+func (a *DarwinAdapter) AdjustSystemTime(offset time.Duration) error {
+	return a.SetSystemTime(time.Now().Add(offset))
+}
+
+func (a *DarwinAdapter) SetTimezone(name string) error {
+	if err := validTimezone(name); err != nil {
+		return err
+	}
+	if output, err := exec.Command("systemsetup", "-settimezone", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("systemsetup -settimezone: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+func (a *DarwinAdapter) SyncHardwareClock() error {
+	return nil
+}
+
+// --- Windows ---
+
+func (a *WindowsAdapter) SetSystemTime(t time.Time) error {
+	script := "Set-Date -Date ([DateTimeOffset]::FromUnixTimeMilliseconds(" +
+		strconv.FormatInt(t.UnixMilli(), 10) + ").LocalDateTime)"
+	if output, err := exec.Command("powershell", "-NoProfile", "-Command", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("Set-Date: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+func (a *WindowsAdapter) AdjustSystemTime(offset time.Duration) error {
+	return a.SetSystemTime(time.Now().Add(offset))
+}
+
+func (a *WindowsAdapter) SetTimezone(name string) error {
+	if err := validTimezone(name); err != nil {
+		return err
+	}
+	// tzutil expects Windows zone IDs; only UTC maps one to one.
+	id := name
+	if name == "UTC" || name == "Etc/UTC" {
+		id = "UTC"
+	}
+	if output, err := exec.Command("tzutil", "/s", id).CombinedOutput(); err != nil {
+		return fmt.Errorf("tzutil /s %s: %v, output: %s", id, err, output)
+	}
+	return nil
+}
+
+func (a *WindowsAdapter) SyncHardwareClock() error {
+	// Windows keeps the RTC in sync itself.
+	return nil
+}
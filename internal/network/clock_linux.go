@@ -0,0 +1,32 @@
+//go:build linux
+
+package network
+
+import (
+	"fmt"
+	"reflect"
+	"syscall"
+	"time"
+)
+
+// adjOffsetSingleshot is ADJ_OFFSET_SINGLESHOT from <sys/timex.h>: the old
+// adjtime(2) behaviour of slewing by a one-off offset.
+const adjOffsetSingleshot = 0x8001
+
+func setSystemClock(t time.Time) error {
+	tv := syscall.NsecToTimeval(t.UnixNano())
+	if err := syscall.Settimeofday(&tv); err != nil {
+		return fmt.Errorf("settimeofday: %w", err)
+	}
+	return nil
+}
+
+func slewSystemClock(offset time.Duration) error {
+	tx := syscall.Timex{Modes: adjOffsetSingleshot}
+	// Timex.Offset is a C long: int32 on 32-bit ARM, int64 elsewhere.
+	reflect.ValueOf(&tx.Offset).Elem().SetInt(offset.Microseconds())
+	if _, err := syscall.Adjtimex(&tx); err != nil {
+		return fmt.Errorf("adjtimex: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package network
+
+import (
+	"fmt"
+	"time"
+)
+
+func setSystemClock(t time.Time) error {
+	return fmt.Errorf("setting the system clock is only supported on linux")
+}
+
+func slewSystemClock(offset time.Duration) error {
+	return setSystemClock(time.Now().Add(offset))
+}
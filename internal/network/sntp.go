@@ -0,0 +1,148 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// SNTP client (RFC 4330). One request/response per server; the caller
+// decides how to discipline the clock with the measured offset.
+
+const (
+	ntpPacketSize  = 48
+	ntpDefaultPort = "123"
+	// ntpEpochOffset is the number of seconds between 1900-01-01 and 1970-01-01.
+	ntpEpochOffset = 2208988800
+)
+
+// NTPResult is one SNTP measurement against a server.
+type NTPResult struct {
+	Server  string        `json:"server"`
+	Offset  time.Duration `json:"offset"` // add to the local clock to match the server
+	Delay   time.Duration `json:"delay"`  // round trip minus server processing
+	Stratum int           `json:"stratum"`
+	RefID   string        `json:"ref_id,omitempty"`
+}
+
+// ntpNow is replaced in tests.
+var ntpNow = time.Now
+
+func toNTPTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+func fromNTPTime(v uint64) time.Time {
+	secs := int64(v>>32) - ntpEpochOffset
+	nanos := int64((v & 0xFFFFFFFF) * 1e9 >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// QueryNTP sends one SNTP v4 client request to server (host or host:port).
+func QueryNTP(ctx context.Context, server string, timeout time.Duration) (NTPResult, error) {
+	res := NTPResult{Server: server}
+	addr := server
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(server, ntpDefaultPort)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return res, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = 0<<6 | 4<<3 | 3 // LI=0, VN=4, Mode=3 (client)
+	t1 := ntpNow()
+	xmt := toNTPTime(t1)
+	binary.BigEndian.PutUint64(req[40:48], xmt)
+	if _, err := conn.Write(req); err != nil {
+		return res, err
+	}
+
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	t4 := ntpNow()
+	if err != nil {
+		return res, err
+	}
+	if n < ntpPacketSize {
+		return res, fmt.Errorf("ntp: short response from %s", server)
+	}
+
+	leap, mode := resp[0]>>6, resp[0]&0x07
+	res.Stratum = int(resp[1])
+	if mode != 4 && mode != 5 {
+		return res, fmt.Errorf("ntp: unexpected mode %d from %s", mode, server)
+	}
+	if res.Stratum == 0 {
+		return res, fmt.Errorf("ntp: kiss-o'-death %q from %s", string(resp[12:16]), server)
+	}
+	if leap == 3 || res.Stratum > 15 {
+		return res, fmt.Errorf("ntp: server %s is not synchronized", server)
+	}
+	if binary.BigEndian.Uint64(resp[24:32]) != xmt {
+		return res, fmt.Errorf("ntp: originate timestamp mismatch from %s", server)
+	}
+	if res.Stratum == 1 {
+		res.RefID = string(trimNUL(resp[12:16]))
+	} else {
+		res.RefID = net.IP(resp[12:16]).String()
+	}
+
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:40]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:48]))
+	res.Offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+	res.Delay = t4.Sub(t1) - t3.Sub(t2)
+	if res.Delay < 0 {
+		res.Delay = 0
+	}
+	return res, nil
+}
+
+// QueryNTPServers queries every server and returns the measurement with the
+// lowest round-trip delay, which carries the smallest offset error.
+func QueryNTPServers(ctx context.Context, servers []string, timeout time.Duration) (NTPResult, error) {
+	var best NTPResult
+	var lastErr error
+	found := false
+	for _, server := range servers {
+		if server == "" {
+			continue
+		}
+		res, err := QueryNTP(ctx, server, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !found || res.Delay < best.Delay {
+			best, found = res, true
+		}
+	}
+	if !found {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("ntp: no servers configured")
+		}
+		return best, lastErr
+	}
+	return best, nil
+}
+
+func trimNUL(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startFakeNTP answers client requests with a clock running skew ahead of ours.
+func startFakeNTP(t *testing.T, skew time.Duration, stratum byte) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, ntpPacketSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+			recv := time.Now().Add(skew)
+			resp := make([]byte, ntpPacketSize)
+			resp[0] = 0<<6 | 4<<3 | 4
+			resp[1] = stratum
+			copy(resp[12:16], "GPS\x00")
+			copy(resp[24:32], buf[40:48])
+			binary.BigEndian.PutUint64(resp[32:40], toNTPTime(recv))
+			binary.BigEndian.PutUint64(resp[40:48], toNTPTime(time.Now().Add(skew)))
+			_, _ = pc.WriteTo(resp, addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	in := time.Date(2026, 10, 18, 8, 30, 15, 123456789, time.UTC)
+	out := fromNTPTime(toNTPTime(in))
+	if diff := out.Sub(in); diff > time.Microsecond || diff < -time.Microsecond {
+		t.Fatalf("round trip drift %v", diff)
+	}
+}
+
+func TestQueryNTP_MeasuresOffset(t *testing.T) {
+	addr := startFakeNTP(t, 2*time.Second, 1)
+
+	res, err := QueryNTP(context.Background(), addr, time.Second)
+	if err != nil {
+		t.Fatalf("QueryNTP: %v", err)
+	}
+	if d := res.Offset - 2*time.Second; d > 50*time.Millisecond || d < -50*time.Millisecond {
+		t.Fatalf("offset = %v, want ~2s", res.Offset)
+	}
+	if res.Stratum != 1 || res.RefID != "GPS" {
+		t.Fatalf("stratum/refid = %d/%q", res.Stratum, res.RefID)
+	}
+}
+
+func TestQueryNTP_RejectsKissOfDeath(t *testing.T) {
+	addr := startFakeNTP(t, 0, 0)
+
+	_, err := QueryNTP(context.Background(), addr, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "kiss-o'-death") {
+		t.Fatalf("expected kiss-o'-death error, got %v", err)
+	}
+}
+
+func TestQueryNTPServers_SkipsFailingServer(t *testing.T) {
+	good := startFakeNTP(t, -time.Second, 2)
+	dead, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadAddr := dead.LocalAddr().String()
+	dead.Close()
+
+	res, err := QueryNTPServers(context.Background(), []string{deadAddr, good}, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("QueryNTPServers: %v", err)
+	}
+	if res.Server != good {
+		t.Fatalf("server = %s, want %s", res.Server, good)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"log"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -16,6 +17,7 @@ import (
 	"github.com/anviod/bacnet/btypes"
 	"github.com/anviod/bacnet/server"
 	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/pkg/bacnetsc"
 )
 
 // Server 是 BACnet Server 的核心实现，将 EdgeX 南向设备和点位映射为 BACnet 对象。
@@ -32,6 +34,7 @@ type Server struct {
 	cancel       context.CancelFunc
 	stats        Stats
 	writeHistory []WriteHistoryItem
+	scLink       *bacnetsc.HubConnector // Transport=sc 时的 hub 连接
 }
 
 // pointMapping 记录一个 EdgeX 点位到 BACnet 对象的映射关系
@@ -46,12 +49,14 @@ type pointMapping struct {
 
 // Stats 统计信息
 type Stats struct {
-	ObjectCount   int       `json:"object_count"`    // BACnet 对象总数
-	PointCount    int       `json:"point_count"`     // 已映射点位总数
-	WriteCount    int64     `json:"write_count"`     // 外部写入次数
-	UpdateCount   int64     `json:"update_count"`    // 南向数据更新次数
-	LastWriteTime time.Time `json:"last_write_time"` // 最近一次外部写入时间
-	StartTime     time.Time `json:"start_time"`      // 服务启动时间
+	ObjectCount   int              `json:"object_count"`    // BACnet 对象总数
+	PointCount    int              `json:"point_count"`     // 已映射点位总数
+	WriteCount    int64            `json:"write_count"`     // 外部写入次数
+	UpdateCount   int64            `json:"update_count"`    // 南向数据更新次数
+	LastWriteTime time.Time        `json:"last_write_time"` // 最近一次外部写入时间
+	StartTime     time.Time        `json:"start_time"`      // 服务启动时间
+	Transport     string           `json:"transport"`       // ip / sc
+	SC            *bacnetsc.Status `json:"sc,omitempty"`    // BACnet/SC hub 连接状态
 }
 
 // WriteRequest 外部写入请求
@@ -112,8 +117,8 @@ func (s *Server) startLocked() error {
 	log.Printf("[BACnetServer] Network: IP=%s, Interface=%s, Port=%d, SubnetCIDR=%d, MaxPDU=%d",
 		devCfg.Ip, devCfg.Interface, devCfg.Port, devCfg.SubnetCIDR, devCfg.MaxPDU)
 
-	// 创建底层 BACnet Server
-	srv, err := server.NewServer(devCfg)
+	// 创建底层 BACnet Server（BACnet/SC 以 hub 连接作为数据链路）
+	srv, err := s.newBACnetServer(devCfg)
 	if err != nil {
 		log.Printf("[BACnetServer] Failed to create server: %v", err)
 		return fmt.Errorf("bacnet server: failed to create server: %w", err)
@@ -223,6 +228,31 @@ func (s *Server) stopLocked() {
 		s.srv.Close()
 		s.srv = nil
 	}
+	s.scLink = nil
+}
+
+// newBACnetServer 按 Transport 创建底层 Server：ip 绑定 UDP，sc 连接 hub 并以其作为数据链路
+func (s *Server) newBACnetServer(devCfg *server.DeviceConfig) (server.Server, error) {
+	if !model.IsBACnetSC(s.config.Transport) {
+		return server.NewServer(devCfg)
+	}
+	scCfg, err := bacnetsc.ConfigFromModel(s.config.ID, s.config.SC)
+	if err != nil {
+		return nil, err
+	}
+	link, err := bacnetsc.Dial(s.ctx, scCfg)
+	if err != nil {
+		return nil, err
+	}
+	srv, err := server.NewServerWithDataLink(devCfg, link)
+	if err != nil {
+		_ = link.Close()
+		return nil, err
+	}
+	st := link.Status()
+	log.Printf("[BACnetServer] BACnet/SC hub connected: hub=%s vmac=%s", st.HubURI, st.VMAC)
+	s.scLink = link
+	return srv, nil
 }
 
 // UpdateConfig 更新配置。端口/IP/DeviceID 等结构性变更需要重启，设备映射变更可热更新。
@@ -277,6 +307,9 @@ func requiresServerRestart(oldCfg, newCfg model.BACnetServerConfig) bool {
 	if oldCfg.MaxPDU != newCfg.MaxPDU {
 		return true
 	}
+	if oldCfg.Transport != newCfg.Transport || !reflect.DeepEqual(oldCfg.SC, newCfg.SC) {
+		return true
+	}
 	return false
 }
 
@@ -381,8 +414,22 @@ func (s *Server) recordWriteHistory(channelID, deviceID, pointID string, value a
 // GetStats 获取统计信息
 func (s *Server) GetStats() Stats {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.stats
+	stats := s.stats
+	s.mu.RUnlock()
+
+	s.lifecycleMu.Lock()
+	link := s.scLink
+	transport := s.config.Transport
+	s.lifecycleMu.Unlock()
+	stats.Transport = model.BACnetTransportIP
+	if model.IsBACnetSC(transport) {
+		stats.Transport = model.BACnetTransportSC
+	}
+	if link != nil {
+		st := link.Status()
+		stats.SC = &st
+	}
+	return stats
 }
 
 // GetWriteHistory 获取写入历史
@@ -397,6 +397,8 @@ func TestRequiresServerRestart(t *testing.T) {
 		{"devices changed", func(cfg *model.BACnetServerConfig) {
 			cfg.Devices = model.OpcUaDeviceMap{"dev1": {Enable: true}}
 		}, false},
+		{"transport changed", func(cfg *model.BACnetServerConfig) { cfg.Transport = model.BACnetTransportSC }, true},
+		{"sc hub changed", func(cfg *model.BACnetServerConfig) { cfg.SC.PrimaryHubURI = "wss://hub:443/" }, true},
 	}
 
 	for _, tt := range tests {
@@ -501,6 +503,30 @@ func TestStats(t *testing.T) {
 	if stats.UpdateCount != 0 {
 		t.Errorf("expected update count 0, got %d", stats.UpdateCount)
 	}
+	if stats.Transport != model.BACnetTransportIP || stats.SC != nil {
+		t.Errorf("expected ip transport without sc status, got %s %+v", stats.Transport, stats.SC)
+	}
+}
+
+// TestStartSCRequiresNodeCertificate BACnet/SC 未配置节点证书时启动失败且不残留 Server
+func TestStartSCRequiresNodeCertificate(t *testing.T) {
+	cfg := model.BACnetServerConfig{
+		ID:        "sc-test",
+		Name:      "SC Test",
+		Transport: model.BACnetTransportSC,
+		SC:        model.BACnetSCConfig{PrimaryHubURI: "wss://127.0.0.1:1/"},
+	}
+	srv := NewServer(cfg, nil)
+	if err := srv.Start(); err == nil {
+		srv.Stop()
+		t.Fatal("expected start to fail without a node certificate")
+	}
+	if srv.IsRunning() {
+		t.Fatal("server must not run after failed BACnet/SC start")
+	}
+	if srv.GetStats().Transport != model.BACnetTransportSC {
+		t.Fatal("stats should report sc transport")
+	}
 }
 
 // TestWriteHistory 测试写入历史
@@ -0,0 +1,140 @@
+// Package connector is the registry of pluggable northbound connectors.
+//
+// A connector package registers itself from init, like southbound drivers do
+// with driver.RegisterDriver:
+//
+//	func init() {
+//		connector.Register(connector.Descriptor{
+//			Type:        "webhook",
+//			DisplayName: "Webhook",
+//			New:         NewConnector,
+//			Validate:    ValidateConfig,
+//		})
+//	}
+//
+// NorthboundManager then creates, updates, stops and reports on instances
+// configured in NorthboundConfig.Connectors without type-specific code.
+package connector
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+// ErrWriteNotSupported is returned by Write of connectors that only publish.
+var ErrWriteNotSupported = errors.New("connector does not support writes")
+
+// Connector is a running northbound connector instance.
+type Connector interface {
+	// Start connects or begins serving. An error leaves the connector stopped.
+	Start() error
+	Stop()
+	// UpdateConfig applies a changed config to a running connector. ID and
+	// Type never change.
+	UpdateConfig(cfg model.ConnectorConfig) error
+	// Publish receives values of the devices enabled in the config.
+	Publish(v model.Value)
+	Stats() map[string]any
+	// Write forwards an upstream write to a point; see ErrWriteNotSupported.
+	Write(channelID, deviceID, pointID string, value any) error
+}
+
+// DeviceStatusPublisher is optional. Connectors implementing it are told when
+// an enabled device goes online (1) or offline.
+type DeviceStatusPublisher interface {
+	PublishDeviceStatus(deviceID string, status int)
+}
+
+// StatusReporter is optional. Status is the connection status shown with the
+// config (same codes as the built-in clients: 0 disconnected, 1 connected,
+// 2 reconnecting).
+type StatusReporter interface {
+	Status() int
+}
+
+// Deps are the gateway services available to connector factories.
+type Deps struct {
+	Southbound model.SouthboundManager // Writes and shadow reads
+	Storage    *storage.Storage        // Offline cache; may be nil
+}
+
+// Factory creates a stopped connector.
+type Factory func(cfg model.ConnectorConfig, deps Deps) (Connector, error)
+
+// Descriptor describes a connector type.
+type Descriptor struct {
+	Type        string // Value of ConnectorConfig.Type
+	DisplayName string
+	New         Factory
+	// Validate checks Settings before a config is saved (optional). Error
+	// messages are shown to the user.
+	Validate func(cfg model.ConnectorConfig) error
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Descriptor)
+)
+
+// Register adds a connector type, replacing any earlier registration.
+func Register(d Descriptor) {
+	if d.Type == "" || d.New == nil {
+		panic("connector: Register needs a type and a factory")
+	}
+	if d.DisplayName == "" {
+		d.DisplayName = d.Type
+	}
+	mu.Lock()
+	registry[d.Type] = d
+	mu.Unlock()
+}
+
+// Lookup returns the descriptor of a connector type.
+func Lookup(typ string) (Descriptor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d, ok := registry[typ]
+	return d, ok
+}
+
+// Types lists the registered connector types ordered by type.
+func Types() []Descriptor {
+	mu.RLock()
+	out := make([]Descriptor, 0, len(registry))
+	for _, d := range registry {
+		out = append(out, d)
+	}
+	mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Type < out[j].Type })
+	return out
+}
+
+// Validate checks that cfg names a registered type and passes its validator.
+func Validate(cfg model.ConnectorConfig) error {
+	if strings.TrimSpace(cfg.Type) == "" {
+		return errors.New("连接器类型不能为空")
+	}
+	d, ok := Lookup(cfg.Type)
+	if !ok {
+		return fmt.Errorf("连接器类型无效: %q", cfg.Type)
+	}
+	if d.Validate != nil {
+		return d.Validate(cfg)
+	}
+	return nil
+}
+
+// New creates a stopped connector for cfg.
+func New(cfg model.ConnectorConfig, deps Deps) (Connector, error) {
+	d, ok := Lookup(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("连接器类型无效: %q", cfg.Type)
+	}
+	return d.New(cfg, deps)
+}
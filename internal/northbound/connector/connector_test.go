@@ -0,0 +1,62 @@
+package connector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+type nopConnector struct{}
+
+func (nopConnector) Start() error                             { return nil }
+func (nopConnector) Stop()                                    {}
+func (nopConnector) UpdateConfig(model.ConnectorConfig) error { return nil }
+func (nopConnector) Publish(model.Value)                      {}
+func (nopConnector) Stats() map[string]any                    { return nil }
+func (nopConnector) Write(string, string, string, any) error  { return ErrWriteNotSupported }
+
+func TestRegistry(t *testing.T) {
+	factory := func(model.ConnectorConfig, Deps) (Connector, error) { return nopConnector{}, nil }
+	Register(Descriptor{Type: "zz-test", New: factory, Validate: func(cfg model.ConnectorConfig) error {
+		if cfg.Settings["topic"] == nil {
+			return errors.New("topic 不能为空")
+		}
+		return nil
+	}})
+	Register(Descriptor{Type: "aa-test", DisplayName: "AA", New: factory})
+
+	d, ok := Lookup("zz-test")
+	if !ok || d.DisplayName != "zz-test" {
+		t.Fatalf("lookup = %+v, %v", d, ok)
+	}
+	types := Types()
+	if len(types) < 2 || types[0].Type != "aa-test" {
+		t.Fatalf("types not sorted: %+v", types)
+	}
+
+	if err := Validate(model.ConnectorConfig{}); err == nil || !strings.Contains(err.Error(), "不能为空") {
+		t.Errorf("empty type: %v", err)
+	}
+	if err := Validate(model.ConnectorConfig{Type: "missing"}); err == nil || !strings.Contains(err.Error(), "无效") {
+		t.Errorf("unknown type: %v", err)
+	}
+	if err := Validate(model.ConnectorConfig{Type: "zz-test"}); err == nil {
+		t.Error("validator not applied")
+	}
+	if _, err := New(model.ConnectorConfig{Type: "aa-test"}, Deps{}); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+}
+
+func TestDecodeSettings(t *testing.T) {
+	var s struct {
+		URL   string `json:"url"`
+		Batch int    `json:"batch"`
+	}
+	cfg := model.ConnectorConfig{Settings: map[string]any{"url": "http://x", "batch": 5}}
+	if err := cfg.DecodeSettings(&s); err != nil || s.URL != "http://x" || s.Batch != 5 {
+		t.Fatalf("settings = %+v, %v", s, err)
+	}
+}
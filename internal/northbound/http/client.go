@@ -2,14 +2,21 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
 	"github.com/anviod/edgex/internal/storage"
 
 	"go.uber.org/zap"
@@ -17,7 +24,8 @@ import (
 
 type Client struct {
 	config   model.HTTPConfig
-	storage  *storage.Storage
+	sb       model.SouthboundManager
+	offline  *offline.Buffer
 	client   *http.Client
 	stopChan chan struct{}
 	configMu sync.RWMutex
@@ -29,17 +37,43 @@ type Client struct {
 	periodicMu sync.Mutex
 	periodic   map[string]*periodicItem
 
+	// Compiled payload template, guarded by configMu
+	format *payloadFormat
+	tokens tokenSource
+
+	// Cross-device batch
+	batchMu    sync.Mutex
+	batch      []*aggregatedPayload
+	batchBytes int
+	batchTimer *time.Timer
+
+	// Pull-mode queue
+	pullMu    sync.Mutex
+	pullQueue []*aggregatedPayload
+
 	// Stats
 	successCount int64
 	failCount    int64
+	pullDropped  int64
+
+	commandCount  int64
+	commandFailed int64
 }
 
+var (
+	// ErrPullDisabled is returned by Pull when pull mode is off.
+	ErrPullDisabled = errors.New("pull mode is not enabled")
+	// ErrPullUnauthorized is returned by Pull for a wrong token.
+	ErrPullUnauthorized = errors.New("invalid pull token")
+)
+
 type aggregatedPayload struct {
 	Timestamp int64          `json:"timestamp"`
 	ChannelID string         `json:"channel_id"`
 	DeviceID  string         `json:"device_id"`
 	Values    map[string]any `json:"values"`
 	Errors    map[string]any `json:"errors,omitempty"`
+	Metas     map[string]any `json:"metas,omitempty"`
 }
 
 type bufferItem struct {
@@ -54,17 +88,30 @@ type periodicItem struct {
 	stop      chan struct{}
 }
 
-func NewClient(cfg model.HTTPConfig, s *storage.Storage) *Client {
+func NewClient(cfg model.HTTPConfig, sb model.SouthboundManager, s *storage.Storage) *Client {
 	return &Client{
 		config:   cfg,
-		storage:  s,
-		client:   &http.Client{Timeout: 10 * time.Second},
+		sb:       sb,
+		offline:  offline.New(s, cfg.ID, cfg.Cache),
+		client:   &http.Client{Timeout: parseDuration(cfg.Timeout, defaultTimeout)},
 		stopChan: make(chan struct{}),
 		buffers:  make(map[string]*bufferItem),
 		periodic: make(map[string]*periodicItem),
+		format:   compileFormat(cfg),
 	}
 }
 
+// compileFormat falls back to the default body when a hand-edited template is
+// broken; UpsertHTTPConfig rejects such templates.
+func compileFormat(cfg model.HTTPConfig) *payloadFormat {
+	f, err := newPayloadFormat(cfg)
+	if err != nil {
+		zap.L().Error("Invalid HTTP payload template, using default payload", zap.String("id", cfg.ID), zap.Error(err))
+		f, _ = newPayloadFormat(model.HTTPConfig{ID: cfg.ID, Batch: cfg.Batch})
+	}
+	return f
+}
+
 func (c *Client) Start() {
 	go c.retryLoop()
 	c.updatePeriodicTasks()
@@ -73,64 +120,158 @@ func (c *Client) Start() {
 
 func (c *Client) Stop() {
 	close(c.stopChan)
+	// Pending batch items are still delivered (or cached).
+	if items := c.takeBatch(); len(items) > 0 {
+		go c.sendItems(items, true)
+	}
 }
 
 func (c *Client) UpdateConfig(cfg model.HTTPConfig) {
+	format := compileFormat(cfg)
+
 	c.configMu.Lock()
+	old := c.config
 	c.config = cfg
+	c.format = format
+	if old.Timeout != cfg.Timeout {
+		c.client = &http.Client{Timeout: parseDuration(cfg.Timeout, defaultTimeout)}
+	}
 	c.configMu.Unlock()
+	c.offline.SetConfig(cfg.Cache)
+
+	if old.AuthType != cfg.AuthType || !reflect.DeepEqual(old.OAuth2, cfg.OAuth2) {
+		c.tokens.invalidate()
+	}
+	if !cfg.Batch.Enable {
+		if items := c.takeBatch(); len(items) > 0 {
+			go c.sendItems(items, true)
+		}
+	}
+	if !cfg.Pull.Enable {
+		c.pullMu.Lock()
+		c.pullQueue = nil
+		c.pullMu.Unlock()
+	}
 	c.updatePeriodicTasks()
 }
 
+// Send posts payload to the data endpoint, caching it offline on failure.
+// While older requests are queued it is cached behind them, keeping the order.
 func (c *Client) Send(payload []byte) error {
 	c.configMu.RLock()
-	url := c.config.URL
 	method := c.config.Method
-	headers := c.config.Headers
 	endpoint := c.config.DataEndpoint
-	cacheCfg := c.config.Cache
 	c.configMu.RUnlock()
 
-	if endpoint != "" {
-		// Simple join, assuming URL doesn't end with / or endpoint doesn't start with /?
-		// Better to use path.Join but that messes up http://
-		if url[len(url)-1] != '/' && endpoint[0] != '/' {
-			url += "/"
-		}
-		url += endpoint
+	if c.offline.Pending() {
+		return c.cacheRequest(method, endpoint, payload)
 	}
+	if err := c.deliver(method, endpoint, payload); err != nil {
+		atomic.AddInt64(&c.failCount, 1)
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(payload))
-	if err != nil {
+		if c.offline.Enabled() {
+			return c.cacheRequest(method, endpoint, payload)
+		}
 		return err
 	}
 
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	atomic.AddInt64(&c.successCount, 1)
+	return nil
+}
+
+// joinURL appends a relative endpoint to the base URL. Absolute endpoints are
+// used as is.
+func joinURL(base, endpoint string) string {
+	if endpoint == "" {
+		return base
 	}
-	req.Header.Set("Content-Type", "application/json")
-	c.addAuth(req)
+	if isAbsoluteURL(endpoint) {
+		return endpoint
+	}
+	// Simple join: path.Join would collapse the "//" of the scheme
+	if base != "" && base[len(base)-1] != '/' && endpoint[0] != '/' {
+		base += "/"
+	}
+	return base + endpoint
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode >= 300) {
-		atomic.AddInt64(&c.failCount, 1)
+func isAbsoluteURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
 
-		// Cache Logic
-		if cacheCfg.Enable && c.storage != nil {
-			c.storage.SaveOfflineMessage(c.config.ID, payload, cacheCfg.MaxCount)
-			return nil // Queued
-		}
+// deliver sends body once, applying gzip, headers, auth and signing. An OAuth2
+// token rejected with 401 is refreshed and the request retried once.
+func (c *Client) deliver(method, endpoint string, body []byte) error {
+	return c.deliverWithHeader(method, endpoint, body, nil)
+}
 
-		if err != nil {
+// deliverWithHeader is deliver with extra request headers.
+func (c *Client) deliverWithHeader(method, endpoint string, body []byte, header http.Header) error {
+	c.configMu.RLock()
+	cfg := c.config
+	client := c.client
+	c.configMu.RUnlock()
+
+	if cfg.URL == "" && !isAbsoluteURL(endpoint) {
+		return errors.New("http url is empty")
+	}
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	wire := body
+	if cfg.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-		return fmt.Errorf("http error: %s", resp.Status)
+		wire = buf.Bytes()
 	}
-	defer resp.Body.Close()
 
-	atomic.AddInt64(&c.successCount, 1)
-	return nil
+	target := joinURL(cfg.URL, endpoint)
+	status, err := c.do(client, cfg, method, target, wire, header)
+	if status == http.StatusUnauthorized && cfg.AuthType == "OAuth2" {
+		c.tokens.invalidate()
+		_, err = c.do(client, cfg, method, target, wire, header)
+	}
+	return err
+}
+
+func (c *Client) do(client *http.Client, cfg model.HTTPConfig, method, target string, wire []byte, header http.Header) (int, error) {
+	req, err := http.NewRequest(method, target, bytes.NewReader(wire))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if err := c.addAuth(req, client, cfg); err != nil {
+		return 0, err
+	}
+	if cfg.Signing.Enable {
+		sign(req, cfg.Signing, wire, time.Now())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("http error: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
 }
 
 func (c *Client) Publish(v model.Value) {
@@ -184,6 +325,12 @@ func (c *Client) Publish(v model.Value) {
 	if v.Quality != "Good" {
 		item.payload.Errors[v.PointID] = v.Quality
 	}
+	if len(v.Meta) > 0 {
+		if item.payload.Metas == nil {
+			item.payload.Metas = make(map[string]any)
+		}
+		item.payload.Metas[v.PointID] = v.Meta
+	}
 }
 
 func (c *Client) flushDevice(deviceID string) {
@@ -196,15 +343,141 @@ func (c *Client) flushDevice(deviceID string) {
 	delete(c.buffers, deviceID)
 	c.bufferMu.Unlock()
 
-	data, err := json.Marshal(item.payload)
-	if err != nil {
-		zap.L().Error("Failed to marshal HTTP payload", zap.Error(err))
+	c.emit(item.payload)
+}
+
+// emit routes one device payload to the pull queue and to the push target,
+// directly or through the batch.
+func (c *Client) emit(p *aggregatedPayload) {
+	c.configMu.RLock()
+	pull := c.config.Pull
+	push := c.config.URL != ""
+	batch := c.config.Batch
+	c.configMu.RUnlock()
+
+	if pull.Enable {
+		c.enqueuePull(p, pull.MaxItems)
+	}
+	if !push {
 		return
 	}
+	if batch.Enable {
+		c.addToBatch(p, batch)
+		return
+	}
+	c.sendItems([]*aggregatedPayload{p}, false)
+}
+
+func (c *Client) addToBatch(p *aggregatedPayload, cfg model.HTTPBatchConfig) {
+	maxItems := cfg.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchItems
+	}
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultBatchBytes
+	}
+	size := 0
+	if b, err := json.Marshal(p); err == nil {
+		size = len(b)
+	}
+
+	c.batchMu.Lock()
+	c.batch = append(c.batch, p)
+	c.batchBytes += size
+	if len(c.batch) >= maxItems || c.batchBytes >= maxBytes {
+		items := c.takeBatchLocked()
+		c.batchMu.Unlock()
+		c.sendItems(items, true)
+		return
+	}
+	if c.batchTimer == nil {
+		c.batchTimer = time.AfterFunc(parseDuration(cfg.Interval, defaultBatchInterval), func() {
+			if items := c.takeBatch(); len(items) > 0 {
+				c.sendItems(items, true)
+			}
+		})
+	}
+	c.batchMu.Unlock()
+}
+
+func (c *Client) takeBatch() []*aggregatedPayload {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+	return c.takeBatchLocked()
+}
+
+func (c *Client) takeBatchLocked() []*aggregatedPayload {
+	items := c.batch
+	c.batch = nil
+	c.batchBytes = 0
+	if c.batchTimer != nil {
+		c.batchTimer.Stop()
+		c.batchTimer = nil
+	}
+	return items
+}
 
+func (c *Client) sendItems(items []*aggregatedPayload, asArray bool) {
+	c.configMu.RLock()
+	format := c.format
+	c.configMu.RUnlock()
+
+	data, err := format.render(items, asArray)
+	if err != nil {
+		atomic.AddInt64(&c.failCount, 1)
+		zap.L().Error("Failed to render HTTP payload", zap.Error(err), zap.Int("items", len(items)))
+		return
+	}
 	if err := c.Send(data); err != nil {
-		zap.L().Error("Failed to send HTTP payload", zap.Error(err), zap.String("device", deviceID))
+		zap.L().Error("Failed to send HTTP payload", zap.Error(err), zap.Int("items", len(items)))
+	}
+}
+
+func (c *Client) enqueuePull(p *aggregatedPayload, capacity int) {
+	if capacity <= 0 {
+		capacity = defaultPullItems
+	}
+	c.pullMu.Lock()
+	defer c.pullMu.Unlock()
+	c.pullQueue = append(c.pullQueue, p)
+	if over := len(c.pullQueue) - capacity; over > 0 {
+		c.pullQueue = append([]*aggregatedPayload(nil), c.pullQueue[over:]...)
+		atomic.AddInt64(&c.pullDropped, int64(over))
+	}
+}
+
+// Pull removes up to max queued payloads and renders them as one body (a JSON
+// array unless a template is configured). It returns the number of payloads;
+// zero means the queue is empty.
+func (c *Client) Pull(token string, max int) ([]byte, int, error) {
+	c.configMu.RLock()
+	pull := c.config.Pull
+	format := c.format
+	c.configMu.RUnlock()
+
+	if !pull.Enable {
+		return nil, 0, ErrPullDisabled
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(pull.Token)) != 1 {
+		return nil, 0, ErrPullUnauthorized
 	}
+	if max <= 0 {
+		max = defaultBatchItems
+	}
+
+	c.pullMu.Lock()
+	defer c.pullMu.Unlock()
+	n := min(max, len(c.pullQueue))
+	if n == 0 {
+		return nil, 0, nil
+	}
+	data, err := format.render(c.pullQueue[:n], true)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.pullQueue = append([]*aggregatedPayload(nil), c.pullQueue[n:]...)
+	return data, n, nil
 }
 
 func (c *Client) updatePeriodicTasks() {
@@ -285,23 +558,21 @@ func (c *Client) flushPeriodic(deviceID string, item *periodicItem) {
 		if v.Quality != "Good" {
 			payload.Errors[v.PointID] = v.Quality
 		}
+		if len(v.Meta) > 0 {
+			if payload.Metas == nil {
+				payload.Metas = make(map[string]any)
+			}
+			payload.Metas[v.PointID] = v.Meta
+		}
 	}
 	c.periodicMu.Unlock()
 
-	data, err := json.Marshal(payload)
-	if err != nil {
-		zap.L().Error("Failed to marshal periodic HTTP payload", zap.Error(err))
-		return
-	}
-	if err := c.Send(data); err != nil {
-		zap.L().Error("Failed to send periodic HTTP payload", zap.Error(err), zap.String("device", deviceID))
-	}
+	c.emit(payload)
 }
 
 func (c *Client) PublishDeviceStatus(deviceID string, status int) {
 	c.configMu.RLock()
 	_, ok := model.LookupNorthboundPublishConfig(deviceID, c.config.Devices, c.config.VirtualDevices)
-	url := c.config.URL
 	endpoint := c.config.DeviceEventEndpoint
 	c.configMu.RUnlock()
 
@@ -322,12 +593,11 @@ func (c *Client) PublishDeviceStatus(deviceID string, status int) {
 	}
 	data, _ := json.Marshal(payload)
 
-	c.sendEvent(url, endpoint, data)
+	c.sendEvent(endpoint, data)
 }
 
 func (c *Client) PublishDeviceLifecycle(event string, device model.Device) {
 	c.configMu.RLock()
-	url := c.config.URL
 	endpoint := c.config.DeviceEventEndpoint
 	c.configMu.RUnlock()
 
@@ -339,164 +609,105 @@ func (c *Client) PublishDeviceLifecycle(event string, device model.Device) {
 	}
 	data, _ := json.Marshal(payload)
 
-	c.sendEvent(url, endpoint, data)
+	c.sendEvent(endpoint, data)
 }
 
-func (c *Client) sendEvent(baseURL, endpoint string, data []byte) {
-	if endpoint != "" {
-		if baseURL[len(baseURL)-1] != '/' && endpoint[0] != '/' {
-			baseURL += "/"
-		}
-		baseURL += endpoint
-	}
-
-	req, err := http.NewRequest("POST", baseURL, bytes.NewBuffer(data))
-	if err != nil {
-		zap.L().Error("Failed to create event request", zap.Error(err))
+func (c *Client) sendEvent(endpoint string, data []byte) {
+	if c.offline.Pending() {
+		c.cacheRequest(http.MethodPost, endpoint, data)
 		return
 	}
-
-	c.configMu.RLock()
-	headers := c.config.Headers
-	c.configMu.RUnlock()
-
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	c.addAuth(req)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
+	if err := c.deliver(http.MethodPost, endpoint, data); err != nil {
 		zap.L().Error("Failed to send event", zap.Error(err))
-		// Events are also cached?
-		// "子设备的添加和删除事件的上报" - implicitly yes, if offline.
-		// Let's cache events too using the same mechanism.
-		c.configMu.RLock()
-		cacheCfg := c.config.Cache
-		id := c.config.ID
-		c.configMu.RUnlock()
-
-		if cacheCfg.Enable && c.storage != nil {
-			c.storage.SaveOfflineMessage(id, data, cacheCfg.MaxCount)
+		if c.offline.Enabled() {
+			c.cacheRequest(http.MethodPost, endpoint, data)
 		}
-		return
 	}
-	defer resp.Body.Close()
 }
 
-func (c *Client) addAuth(req *http.Request) {
-	c.configMu.RLock()
-	defer c.configMu.RUnlock()
-
-	switch c.config.AuthType {
+// addAuth applies the configured authentication to req.
+func (c *Client) addAuth(req *http.Request, client *http.Client, cfg model.HTTPConfig) error {
+	switch cfg.AuthType {
 	case "Basic":
-		req.SetBasicAuth(c.config.Username, c.config.Password)
+		req.SetBasicAuth(cfg.Username, cfg.Password)
 	case "Bearer":
-		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
 	case "APIKey":
-		if c.config.APIKeyName != "" {
-			req.Header.Set(c.config.APIKeyName, c.config.APIKeyValue)
+		if cfg.APIKeyName != "" {
+			req.Header.Set(cfg.APIKeyName, cfg.APIKeyValue)
 		}
+	case "OAuth2":
+		token, err := c.tokens.get(req.Context(), client, cfg.OAuth2)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	return nil
 }
 
 func (c *Client) retryLoop() {
-	c.configMu.RLock()
-	intervalStr := c.config.Cache.FlushInterval
-	c.configMu.RUnlock()
-
-	interval := 1 * time.Minute
-	if d, err := time.ParseDuration(intervalStr); err == nil && d > 0 {
-		interval = d
-	}
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-c.stopChan:
-			return
-		case <-ticker.C:
-			c.flushOfflineMessages()
-		}
-	}
+	stop := c.stopChan
+	c.offline.Run(stop, func() { c.flushOfflineMessages(stop) })
 }
 
-func (c *Client) flushOfflineMessages() {
-	if c.storage == nil {
-		return
-	}
-	c.configMu.RLock()
-	configID := c.config.ID
-	enabled := c.config.Cache.Enable
-	c.configMu.RUnlock()
+// cachedRequest is the offline queue entry; keeping the endpoint lets events
+// be replayed to the event endpoint rather than the data endpoint.
+type cachedRequest struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	Body     []byte `json:"body"`
+}
 
-	if !enabled {
-		return
+func (c *Client) cacheRequest(method, endpoint string, body []byte) error {
+	data, err := json.Marshal(cachedRequest{Method: method, Endpoint: endpoint, Body: body})
+	if err != nil {
+		return err
 	}
-
-	msgs, err := c.storage.GetOfflineMessages(configID, 50)
-	if err != nil || len(msgs) == 0 {
-		return
+	if err := c.offline.Store(data); err != nil {
+		zap.L().Error("Failed to cache HTTP request", zap.Error(err))
+		return err
 	}
+	return nil
+}
 
-	zap.L().Info("Retrying offline HTTP messages", zap.String("client_id", configID), zap.Int("count", len(msgs)))
-
-	// Reuse Send logic but force direct send?
-	// The Send method has cache logic. If we call Send() and it fails, it will re-cache (actually append new).
-	// We should try send raw and only delete if success.
-
-	for _, msg := range msgs {
-		// Construct Request again (simplified, assuming generic data endpoint)
-		// Limitation: If the cached message was an EVENT, it should go to EventEndpoint.
-		// If it was DATA, it should go to DataEndpoint.
-		// The `Data` blob doesn't distinguish.
-		// Solution: We should probably store metadata with the message or assume all are data.
-		// But events are critical.
-		// For now, let's assume `DataEndpoint` is the primary target for retries.
-		// If strict separation is needed, `OfflineMessage` needs `Type` or `Endpoint` field.
-		// Given user requirements, I will assume using `DataEndpoint` for all cached messages is acceptable OR
-		// I can infer from content? No.
-		// Let's stick to `DataEndpoint` for recovery.
-
-		c.configMu.RLock()
-		url := c.config.URL
-		method := c.config.Method
-		endpoint := c.config.DataEndpoint
-		c.configMu.RUnlock()
-
-		if endpoint != "" {
-			if url[len(url)-1] != '/' && endpoint[0] != '/' {
-				url += "/"
-			}
-			url += endpoint
+// flushOfflineMessages replays cached requests oldest first and stops at the
+// first failure to preserve order.
+func (c *Client) flushOfflineMessages(stop <-chan struct{}) {
+	n, err := c.offline.Replay(stop, func(data []byte) error {
+		var req cachedRequest
+		if err := json.Unmarshal(data, &req); err != nil || req.Body == nil {
+			// Entries written before requests were stored hold the raw data body.
+			c.configMu.RLock()
+			req = cachedRequest{Method: c.config.Method, Endpoint: c.config.DataEndpoint, Body: data}
+			c.configMu.RUnlock()
 		}
-
-		req, err := http.NewRequest(method, url, bytes.NewBuffer(msg.Data))
-		if err == nil {
-			c.addAuth(req)
-			req.Header.Set("Content-Type", "application/json")
-
-			resp, err := c.client.Do(req)
-			if err == nil && resp.StatusCode < 300 {
-				c.storage.RemoveOfflineMessage(msg.Key)
-				resp.Body.Close()
-				continue
-			}
-			if resp != nil {
-				resp.Body.Close()
-			}
+		if err := c.deliver(req.Method, req.Endpoint, req.Body); err != nil {
+			return err
 		}
-		// If failed, stop this batch
-		break
+		atomic.AddInt64(&c.successCount, 1)
+		return nil
+	})
+	if n > 0 || err != nil {
+		zap.L().Info("Replayed offline HTTP messages", zap.String("client_id", c.config.ID), zap.Int("count", n), zap.Error(err))
 	}
 }
 
 func (c *Client) GetStats() map[string]int64 {
-	return map[string]int64{
-		"success_count": atomic.LoadInt64(&c.successCount),
-		"fail_count":    atomic.LoadInt64(&c.failCount),
-	}
+	c.batchMu.Lock()
+	batchPending := len(c.batch)
+	c.batchMu.Unlock()
+	c.pullMu.Lock()
+	pullPending := len(c.pullQueue)
+	c.pullMu.Unlock()
+
+	stats := c.offline.Stats()
+	stats["success_count"] = atomic.LoadInt64(&c.successCount)
+	stats["fail_count"] = atomic.LoadInt64(&c.failCount)
+	stats["batch_pending"] = int64(batchPending)
+	stats["pull_pending"] = int64(pullPending)
+	stats["pull_dropped"] = atomic.LoadInt64(&c.pullDropped)
+	stats["command_count"] = atomic.LoadInt64(&c.commandCount)
+	stats["command_failed"] = atomic.LoadInt64(&c.commandFailed)
+	return stats
 }
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int // Replies in order; 200 once exhausted
+}
+
+func (r *recorder) handler(w http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	data, _ := io.ReadAll(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, data)
+	r.headers = append(r.headers, req.Header.Clone())
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func payload(device string) *aggregatedPayload {
+	return &aggregatedPayload{Timestamp: 1000, ChannelID: "ch-1", DeviceID: device, Values: map[string]any{"v": 1}}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchFlushesOnItemsAndInterval(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer srv.Close()
+
+	c := NewClient(model.HTTPConfig{
+		ID: "h1", URL: srv.URL, Method: "POST",
+		Batch: model.HTTPBatchConfig{Enable: true, MaxItems: 2, Interval: "50ms"},
+	}, nil, nil)
+	defer c.Stop()
+
+	c.emit(payload("d1"))
+	c.emit(payload("d2"))
+	c.emit(payload("d3"))
+	waitFor(t, func() bool { return rec.count() == 2 })
+
+	var first, second []aggregatedPayload
+	if err := json.Unmarshal(rec.bodies[0], &first); err != nil || len(first) != 2 {
+		t.Fatalf("first batch = %s, %v", rec.bodies[0], err)
+	}
+	if err := json.Unmarshal(rec.bodies[1], &second); err != nil || len(second) != 1 || second[0].DeviceID != "d3" {
+		t.Fatalf("second batch = %s, %v", rec.bodies[1], err)
+	}
+}
+
+func TestSendQueuesBehindBacklog(t *testing.T) {
+	s, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rec := &recorder{statuses: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer srv.Close()
+
+	c := NewClient(model.HTTPConfig{
+		ID: "h1", URL: srv.URL, Method: "POST",
+		Cache: model.DataCacheConfig{Enable: true, FlushInterval: "1h"},
+	}, nil, s)
+	if err := c.Send([]byte(`{"n":1}`)); err != nil {
+		t.Fatalf("Send 1: %v", err)
+	}
+	// The endpoint is back, but m1 is still queued: m2 must not overtake it.
+	if err := c.Send([]byte(`{"n":2}`)); err != nil {
+		t.Fatalf("Send 2: %v", err)
+	}
+	if n := rec.count(); n != 1 {
+		t.Fatalf("requests = %d, want only the failed first attempt", n)
+	}
+
+	c.flushOfflineMessages(nil)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.bodies) != 3 || string(rec.bodies[1]) != `{"n":1}` || string(rec.bodies[2]) != `{"n":2}` {
+		t.Fatalf("bodies = %q", rec.bodies)
+	}
+}
+
+func TestGzipAndHMACSignature(t *testing.T) {
+	var wire []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		wire, _ = io.ReadAll(req.Body)
+		header = req.Header.Clone()
+	}))
+	defer srv.Close()
+
+	c := NewClient(model.HTTPConfig{
+		ID: "h1", URL: srv.URL, Method: "POST", Gzip: true,
+		Signing: model.HTTPSigningConfig{Enable: true, Secret: "s3cret"},
+	}, nil, nil)
+	if err := c.Send([]byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("headers = %v", header)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(wire))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != `{"a":1}` {
+		t.Fatalf("body = %s", body)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(header.Get("X-Timestamp") + "."))
+	mac.Write(wire)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); header.Get("X-Signature") != want {
+		t.Fatalf("signature = %q, want %q", header.Get("X-Signature"), want)
+	}
+}
+
+func TestOAuth2TokenIsCachedAndRefreshedOn401(t *testing.T) {
+	var mu sync.Mutex
+	issued := 0
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, pass, _ := req.BasicAuth()
+		req.ParseForm()
+		if user != "gw" || pass != "pw" || req.Form.Get("grant_type") != "client_credentials" || req.Form.Get("scope") != "a b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		issued++
+		n := issued
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "tok" + string(rune('0'+n)), "expires_in": 3600})
+	}))
+	defer tokenSrv.Close()
+
+	rec := &recorder{statuses: []int{http.StatusOK, http.StatusUnauthorized}}
+	srv := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer srv.Close()
+
+	c := NewClient(model.HTTPConfig{
+		ID: "h1", URL: srv.URL, Method: "POST", AuthType: "OAuth2",
+		OAuth2: model.HTTPOAuth2Config{TokenURL: tokenSrv.URL, ClientID: "gw", ClientSecret: "pw", Scopes: []string{"a", "b"}},
+	}, nil, nil)
+	for i := 0; i < 2; i++ {
+		if err := c.Send([]byte(`{}`)); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+
+	// Second send is rejected once, then retried with a fresh token.
+	want := []string{"Bearer tok1", "Bearer tok1", "Bearer tok2"}
+	if len(rec.headers) != len(want) {
+		t.Fatalf("requests = %d, want %d", len(rec.headers), len(want))
+	}
+	for i, h := range rec.headers {
+		if h.Get("Authorization") != want[i] {
+			t.Fatalf("request %d auth = %q, want %q", i, h.Get("Authorization"), want[i])
+		}
+	}
+}
+
+func TestTemplateRendersDevices(t *testing.T) {
+	f, err := newPayloadFormat(model.HTTPConfig{
+		ID:              "h1",
+		PayloadTemplate: `{"gw":{{json .ConfigID}},"n":{{len .Devices}},"devices":[{{range $i, $d := .Devices}}{{json $d.DeviceID}}{{if not (last $i $.Devices)}},{{end}}{{end}}]}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := f.render([]*aggregatedPayload{payload("d1"), payload("d2")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"gw":"h1","n":2,"devices":["d1","d2"]}` {
+		t.Fatalf("body = %s", body)
+	}
+
+	// Without a template a single payload keeps the legacy object body.
+	plain, _ := newPayloadFormat(model.HTTPConfig{})
+	body, _ = plain.render([]*aggregatedPayload{payload("d1")}, false)
+	if body[0] != '{' {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestPullQueue(t *testing.T) {
+	c := NewClient(model.HTTPConfig{ID: "h1", Pull: model.HTTPPullConfig{Enable: true, Token: "t", MaxItems: 2}}, nil, nil)
+	for _, d := range []string{"d1", "d2", "d3"} {
+		c.emit(payload(d))
+	}
+
+	if _, _, err := c.Pull("wrong", 10); err != ErrPullUnauthorized {
+		t.Fatalf("err = %v", err)
+	}
+	body, n, err := c.Pull("t", 10)
+	if err != nil || n != 2 {
+		t.Fatalf("Pull = %d, %v", n, err)
+	}
+	var items []aggregatedPayload
+	if err := json.Unmarshal(body, &items); err != nil || items[0].DeviceID != "d2" {
+		t.Fatalf("items = %s, %v (oldest must be dropped)", body, err)
+	}
+	if stats := c.GetStats(); stats["pull_dropped"] != 1 || stats["pull_pending"] != 0 {
+		t.Fatalf("stats = %v", stats)
+	}
+	if _, n, _ := c.Pull("t", 10); n != 0 {
+		t.Fatalf("queue should be empty, got %d", n)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := map[string]model.HTTPConfig{
+		"template": {PayloadTemplate: "{{.Devices"},
+		"timeout":  {Timeout: "fast"},
+		"auth":     {AuthType: "Digest"},
+		"oauth2":   {AuthType: "OAuth2"},
+		"signing":  {Signing: model.HTTPSigningConfig{Enable: true}},
+		"algo":     {Signing: model.HTTPSigningConfig{Enable: true, Secret: "s", Algorithm: "md5"}},
+		"pull":     {Pull: model.HTTPPullConfig{Enable: true}},
+	}
+	for name, cfg := range cases {
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrCommandDisabled is returned by Command when write-back is off.
+	ErrCommandDisabled = errors.New("command endpoint is not enabled")
+	// ErrCommandUnauthorized is returned by Command for a wrong token.
+	ErrCommandUnauthorized = errors.New("invalid command token")
+	// ErrInvalidCommand wraps validation failures; nothing has been written.
+	ErrInvalidCommand = errors.New("invalid command")
+)
+
+// CommandRequest writes one or more points of a southbound device.
+type CommandRequest struct {
+	CorrelationID string         `json:"correlation_id"`
+	ChannelID     string         `json:"channel_id"`
+	DeviceID      string         `json:"device_id"`
+	Values        map[string]any `json:"values"` // Key: point ID
+}
+
+// CommandResult is the outcome of a CommandRequest. Errors is keyed by point ID.
+type CommandResult struct {
+	CorrelationID string            `json:"correlation_id"`
+	ChannelID     string            `json:"channel_id"`
+	DeviceID      string            `json:"device_id"`
+	Success       bool              `json:"success"`
+	Errors        map[string]string `json:"errors,omitempty"`
+	Timestamp     int64             `json:"timestamp"`
+}
+
+// Command validates req and writes its values through the southbound manager.
+// With a callback URL configured, it returns once the command is accepted
+// (async true) and posts the CommandResult to the callback when done;
+// otherwise the writes complete before it returns.
+func (c *Client) Command(token string, req CommandRequest) (CommandResult, bool, error) {
+	c.configMu.RLock()
+	cmdCfg := c.config.Command
+	c.configMu.RUnlock()
+
+	if !cmdCfg.Enable {
+		return CommandResult{}, false, ErrCommandDisabled
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cmdCfg.Token)) != 1 {
+		return CommandResult{}, false, ErrCommandUnauthorized
+	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = uuid.NewString()
+	}
+	if err := c.validateCommand(req); err != nil {
+		return CommandResult{}, false, err
+	}
+
+	if cmdCfg.CallbackURL == "" {
+		return c.executeCommand(req), false, nil
+	}
+	go func() {
+		result := c.executeCommand(req)
+		c.postCommandResult(cmdCfg.CallbackURL, result)
+	}()
+	return CommandResult{
+		CorrelationID: req.CorrelationID,
+		ChannelID:     req.ChannelID,
+		DeviceID:      req.DeviceID,
+		Timestamp:     time.Now().UnixMilli(),
+	}, true, nil
+}
+
+// validateCommand rejects unknown devices, unknown points and points that are
+// not ReadWrite before any value is written.
+func (c *Client) validateCommand(req CommandRequest) error {
+	if req.ChannelID == "" || req.DeviceID == "" || len(req.Values) == 0 {
+		return fmt.Errorf("%w: channel_id, device_id and values are required", ErrInvalidCommand)
+	}
+	if c.sb == nil {
+		return errors.New("southbound manager not initialized")
+	}
+	dev := c.sb.GetDevice(req.ChannelID, req.DeviceID)
+	if dev == nil {
+		return fmt.Errorf("%w: device %s/%s not found", ErrInvalidCommand, req.ChannelID, req.DeviceID)
+	}
+
+	for pointID := range req.Values {
+		found := false
+		for _, p := range dev.Points {
+			if p.ID != pointID {
+				continue
+			}
+			found = true
+			if !strings.Contains(strings.ToUpper(p.ReadWrite), "W") {
+				return fmt.Errorf("%w: point %s is read-only", ErrInvalidCommand, pointID)
+			}
+			break
+		}
+		if !found {
+			return fmt.Errorf("%w: point %s not found", ErrInvalidCommand, pointID)
+		}
+	}
+	return nil
+}
+
+func (c *Client) executeCommand(req CommandRequest) CommandResult {
+	result := CommandResult{
+		CorrelationID: req.CorrelationID,
+		ChannelID:     req.ChannelID,
+		DeviceID:      req.DeviceID,
+		Success:       true,
+	}
+	for pointID, val := range req.Values {
+		if err := c.sb.WritePoint(req.ChannelID, req.DeviceID, pointID, val); err != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[pointID] = err.Error()
+			result.Success = false
+			zap.L().Warn("HTTP command write failed",
+				zap.String("correlation_id", req.CorrelationID),
+				zap.String("device", req.DeviceID),
+				zap.String("point", pointID),
+				zap.Error(err))
+		}
+	}
+	result.Timestamp = time.Now().UnixMilli()
+
+	if result.Success {
+		atomic.AddInt64(&c.commandCount, 1)
+	} else {
+		atomic.AddInt64(&c.commandFailed, 1)
+	}
+	return result
+}
+
+// postCommandResult delivers result to the callback with the connector's
+// auth and signing. The correlation ID is also sent as X-Correlation-ID.
+func (c *Client) postCommandResult(callbackURL string, result CommandResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	header := http.Header{"X-Correlation-Id": {result.CorrelationID}}
+	if err := c.deliverWithHeader(http.MethodPost, callbackURL, data, header); err != nil {
+		atomic.AddInt64(&c.failCount, 1)
+		zap.L().Warn("HTTP command callback failed",
+			zap.String("correlation_id", result.CorrelationID),
+			zap.String("url", callbackURL),
+			zap.Error(err))
+		return
+	}
+	atomic.AddInt64(&c.successCount, 1)
+}
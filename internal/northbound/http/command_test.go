@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+type fakeSouthbound struct {
+	model.SouthboundManager
+	mu     sync.Mutex
+	device model.Device
+	writes map[string]any
+}
+
+func (f *fakeSouthbound) GetDevice(channelID, deviceID string) *model.Device {
+	if channelID != "ch-1" || deviceID != f.device.ID {
+		return nil
+	}
+	return &f.device
+}
+
+func (f *fakeSouthbound) WritePoint(channelID, deviceID, pointID string, value any) error {
+	if pointID == "broken" {
+		return errors.New("device timeout")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes[pointID] = value
+	return nil
+}
+
+func newFakeSouthbound() *fakeSouthbound {
+	return &fakeSouthbound{
+		device: model.Device{ID: "dev-1", Points: []model.Point{
+			{ID: "sp", ReadWrite: "RW"},
+			{ID: "broken", ReadWrite: "RW"},
+			{ID: "temp", ReadWrite: "R"},
+		}},
+		writes: map[string]any{},
+	}
+}
+
+func TestCommandValidatesBeforeWriting(t *testing.T) {
+	sb := newFakeSouthbound()
+	c := NewClient(model.HTTPConfig{ID: "h1", Command: model.HTTPCommandConfig{Enable: true, Token: "t"}}, sb, nil)
+
+	if _, _, err := c.Command("wrong", CommandRequest{}); !errors.Is(err, ErrCommandUnauthorized) {
+		t.Fatalf("wrong token: %v", err)
+	}
+	invalid := map[string]CommandRequest{
+		"read-only": {ChannelID: "ch-1", DeviceID: "dev-1", Values: map[string]any{"sp": 1, "temp": 2}},
+		"point":     {ChannelID: "ch-1", DeviceID: "dev-1", Values: map[string]any{"nope": 1}},
+		"device":    {ChannelID: "ch-1", DeviceID: "dev-2", Values: map[string]any{"sp": 1}},
+		"values":    {ChannelID: "ch-1", DeviceID: "dev-1"},
+	}
+	for name, req := range invalid {
+		if _, _, err := c.Command("t", req); !errors.Is(err, ErrInvalidCommand) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if len(sb.writes) != 0 {
+		t.Fatalf("invalid commands must not write: %v", sb.writes)
+	}
+
+	result, async, err := c.Command("t", CommandRequest{ChannelID: "ch-1", DeviceID: "dev-1", Values: map[string]any{"sp": 5.5}})
+	if err != nil || async || !result.Success || result.CorrelationID == "" {
+		t.Fatalf("result = %+v, async %v, err %v", result, async, err)
+	}
+	if sb.writes["sp"] != 5.5 {
+		t.Fatalf("writes = %v", sb.writes)
+	}
+
+	c.UpdateConfig(model.HTTPConfig{ID: "h1"})
+	if _, _, err := c.Command("t", CommandRequest{}); !errors.Is(err, ErrCommandDisabled) {
+		t.Fatalf("disabled: %v", err)
+	}
+}
+
+func TestCommandPostsResultToCallback(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer srv.Close()
+
+	sb := newFakeSouthbound()
+	c := NewClient(model.HTTPConfig{
+		ID:      "h1",
+		URL:     srv.URL,
+		Signing: model.HTTPSigningConfig{Enable: true, Secret: "k"},
+		Command: model.HTTPCommandConfig{Enable: true, Token: "t", CallbackURL: "/commands/result"},
+	}, sb, nil)
+
+	ack, async, err := c.Command("t", CommandRequest{
+		CorrelationID: "cmd-7",
+		ChannelID:     "ch-1",
+		DeviceID:      "dev-1",
+		Values:        map[string]any{"sp": 1, "broken": 2},
+	})
+	if err != nil || !async || ack.CorrelationID != "cmd-7" {
+		t.Fatalf("ack = %+v, async %v, err %v", ack, async, err)
+	}
+	waitFor(t, func() bool { return rec.count() == 1 })
+
+	var result CommandResult
+	if err := json.Unmarshal(rec.bodies[0], &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.CorrelationID != "cmd-7" || result.Success || result.Errors["broken"] == "" || result.Errors["sp"] != "" {
+		t.Fatalf("result = %+v", result)
+	}
+	h := rec.headers[0]
+	if h.Get("X-Correlation-Id") != "cmd-7" || h.Get(defaultSignatureHeader) == "" {
+		t.Fatalf("headers = %v", h)
+	}
+	if stats := c.GetStats(); stats["command_failed"] != 1 {
+		t.Fatalf("stats = %v", stats)
+	}
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
+	nbtemplate "github.com/anviod/edgex/internal/northbound/template"
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultBatchItems    = 100
+	defaultBatchBytes    = 1 << 20
+	defaultBatchInterval = time.Second
+	defaultPullItems     = 1000
+)
+
+// payloadFormat is the compiled body options of an HTTPConfig.
+type payloadFormat struct {
+	configID string
+	tmpl     *template.Template
+	batch    bool
+}
+
+func newPayloadFormat(cfg model.HTTPConfig) (*payloadFormat, error) {
+	f := &payloadFormat{configID: cfg.ID, batch: cfg.Batch.Enable}
+	if strings.TrimSpace(cfg.PayloadTemplate) != "" {
+		t, err := nbtemplate.Parse("payload", cfg.PayloadTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP 负载模板无效: %w", err)
+		}
+		f.tmpl = t
+	}
+	return f, nil
+}
+
+// render builds one request body. Without a template, a single device payload
+// is sent as an object, as before batching existed; batches are JSON arrays.
+func (f *payloadFormat) render(items []*aggregatedPayload, asArray bool) ([]byte, error) {
+	if f.tmpl == nil {
+		if asArray {
+			return json.Marshal(items)
+		}
+		return json.Marshal(items[0])
+	}
+	devices := make([]nbtemplate.Device, 0, len(items))
+	for _, p := range items {
+		devices = append(devices, p.device())
+	}
+	data := nbtemplate.NewData(f.configID, "", devices)
+	body, err := nbtemplate.Execute(f.tmpl, &data)
+	if err != nil {
+		return nil, fmt.Errorf("render payload template: %w", err)
+	}
+	return body, nil
+}
+
+// device converts p to the shared template model. Point names and units are
+// not part of the payload, so points are named after their IDs.
+func (p *aggregatedPayload) device() nbtemplate.Device {
+	ts := time.UnixMilli(p.Timestamp)
+	values := make([]model.Value, 0, len(p.Values))
+	for id, v := range p.Values {
+		quality := "Good"
+		if q, ok := p.Errors[id].(string); ok {
+			quality = q
+		}
+		meta, _ := p.Metas[id].(map[string]any)
+		values = append(values, model.Value{
+			ChannelID: p.ChannelID,
+			DeviceID:  p.DeviceID,
+			PointID:   id,
+			Value:     v,
+			Quality:   quality,
+			TS:        ts,
+			Meta:      meta,
+		})
+	}
+	return nbtemplate.Format{}.NewDevice(p.ChannelID, p.DeviceID, "", ts, values, nil)
+}
+
+func parseDuration(s string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// ValidateConfig reports option errors in cfg.
+func ValidateConfig(cfg model.HTTPConfig) error {
+	if _, err := newPayloadFormat(cfg); err != nil {
+		return err
+	}
+	for name, value := range map[string]string{"超时": cfg.Timeout, "批量间隔": cfg.Batch.Interval} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("HTTP %s无效: %q", name, value)
+		}
+	}
+	switch cfg.AuthType {
+	case "", "None", "Basic", "Bearer", "APIKey":
+	case "OAuth2":
+		if strings.TrimSpace(cfg.OAuth2.TokenURL) == "" || strings.TrimSpace(cfg.OAuth2.ClientID) == "" {
+			return errors.New("OAuth2 Token URL 和 Client ID 不能为空")
+		}
+		switch cfg.OAuth2.AuthStyle {
+		case "", "header", "body":
+		default:
+			return fmt.Errorf("OAuth2 认证方式无效: %q", cfg.OAuth2.AuthStyle)
+		}
+	default:
+		return fmt.Errorf("HTTP 认证类型无效: %q", cfg.AuthType)
+	}
+	if cfg.Signing.Enable {
+		if cfg.Signing.Secret == "" {
+			return errors.New("HTTP 签名密钥不能为空")
+		}
+		if _, ok := signingHashes[strings.ToLower(cfg.Signing.Algorithm)]; !ok {
+			return fmt.Errorf("HTTP 签名算法无效: %q", cfg.Signing.Algorithm)
+		}
+	}
+	if err := offline.ValidateConfig(cfg.Cache); err != nil {
+		return err
+	}
+	if cfg.Pull.Enable && strings.TrimSpace(cfg.Pull.Token) == "" {
+		return errors.New("HTTP 拉取模式 Token 不能为空")
+	}
+	if cfg.Command.Enable && strings.TrimSpace(cfg.Command.Token) == "" {
+		return errors.New("HTTP 命令接口 Token 不能为空")
+	}
+	return nil
+}
@@ -0,0 +1,705 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
+	"github.com/anviod/edgex/internal/storage"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusDisconnected = 0
+	StatusConnected    = 1
+	StatusError        = 3
+)
+
+const (
+	defaultTopic          = "edgex.{channel_id}"
+	defaultLinger         = 10 * time.Millisecond
+	defaultDeliveryTime   = 30 * time.Second
+	defaultMaxBuffered    = 10000
+	offlineProduceTimeout = 10 * time.Second
+)
+
+// producer is the part of *kgo.Client used by Client; replaced in tests.
+type producer interface {
+	TryProduce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error))
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+	Flush(ctx context.Context) error
+	Ping(ctx context.Context) error
+	Close()
+}
+
+type Client struct {
+	config      model.KafkaConfig
+	offline     *offline.Buffer
+	producer    producer
+	newProducer func(model.KafkaConfig) (producer, error)
+	stopChan    chan struct{}
+	configMu    sync.RWMutex
+
+	lastValues sync.Map
+	bufferMu   sync.Mutex
+	buffers    map[string]*bufferItem
+
+	periodicMu sync.Mutex
+	periodic   map[string]*periodicItem
+
+	status int32
+
+	// Stats
+	successCount int64
+	failCount    int64
+	cachedCount  int64
+}
+
+type aggregatedPayload struct {
+	Timestamp int64          `json:"timestamp"`
+	ChannelID string         `json:"channel_id"`
+	DeviceID  string         `json:"device_id"`
+	Values    map[string]any `json:"values"`
+	Errors    map[string]any `json:"errors,omitempty"`
+	Metas     map[string]any `json:"metas,omitempty"`
+}
+
+// cachedRecord is what goes into the offline cache: the rendered record, so a
+// later topic template change does not reroute data collected before it.
+type cachedRecord struct {
+	Topic string          `json:"topic"`
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+type bufferItem struct {
+	payload *aggregatedPayload
+	timer   *time.Timer
+}
+
+type periodicItem struct {
+	channelID string
+	values    map[string]model.Value
+	ticker    *time.Ticker
+	stop      chan struct{}
+}
+
+func NewClient(cfg model.KafkaConfig, s *storage.Storage) *Client {
+	return &Client{
+		config:      cfg,
+		offline:     offline.New(s, cfg.ID, cfg.Cache),
+		newProducer: newKgoProducer,
+		stopChan:    make(chan struct{}),
+		buffers:     make(map[string]*bufferItem),
+		periodic:    make(map[string]*periodicItem),
+	}
+}
+
+// Start creates the producer. Connecting to the brokers is lazy; an
+// unreachable cluster only shows up as failed deliveries, which are cached.
+func (c *Client) Start() error {
+	c.configMu.RLock()
+	cfg := c.config
+	c.configMu.RUnlock()
+
+	p, err := c.newProducer(cfg)
+	if err != nil {
+		atomic.StoreInt32(&c.status, StatusError)
+		return err
+	}
+	c.configMu.Lock()
+	c.producer = p
+	c.configMu.Unlock()
+
+	go c.probe(p)
+	go c.retryLoop()
+	c.updatePeriodicTasks()
+	zap.L().Info("Kafka Northbound Client started", zap.String("id", cfg.ID), zap.Strings("brokers", cfg.Brokers))
+	return nil
+}
+
+func (c *Client) Stop() {
+	close(c.stopChan)
+
+	c.configMu.Lock()
+	p := c.producer
+	c.producer = nil
+	c.configMu.Unlock()
+	if p != nil {
+		closeProducer(p)
+	}
+	atomic.StoreInt32(&c.status, StatusDisconnected)
+}
+
+// UpdateConfig applies cfg. The producer is rebuilt only when a setting it was
+// created with changed; topic, key and device settings apply immediately.
+func (c *Client) UpdateConfig(cfg model.KafkaConfig) error {
+	c.configMu.Lock()
+	old := c.config
+	c.config = cfg
+	c.configMu.Unlock()
+	c.offline.SetConfig(cfg.Cache)
+	c.updatePeriodicTasks()
+
+	if producerConfigEqual(old, cfg) {
+		return nil
+	}
+	p, err := c.newProducer(cfg)
+	if err != nil {
+		atomic.StoreInt32(&c.status, StatusError)
+		return err
+	}
+	c.configMu.Lock()
+	oldProducer := c.producer
+	c.producer = p
+	c.configMu.Unlock()
+	if oldProducer != nil {
+		closeProducer(oldProducer)
+	}
+	atomic.StoreInt32(&c.status, StatusDisconnected)
+	go c.probe(p)
+	return nil
+}
+
+// probe checks broker reachability once so the status reflects the cluster
+// before the first record is delivered.
+func (c *Client) probe(p producer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.Ping(ctx); err != nil {
+		zap.L().Warn("Kafka cluster unreachable", zap.Error(err))
+		atomic.CompareAndSwapInt32(&c.status, StatusDisconnected, StatusError)
+		return
+	}
+	atomic.CompareAndSwapInt32(&c.status, StatusDisconnected, StatusConnected)
+	c.offline.Kick()
+}
+
+func closeProducer(p producer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.Flush(ctx)
+	p.Close()
+}
+
+func producerConfigEqual(a, b model.KafkaConfig) bool {
+	return reflect.DeepEqual(a.Brokers, b.Brokers) &&
+		a.ClientID == b.ClientID &&
+		a.Linger == b.Linger &&
+		a.BatchMaxBytes == b.BatchMaxBytes &&
+		a.Compression == b.Compression &&
+		a.Acks == b.Acks &&
+		a.Idempotent == b.Idempotent &&
+		a.SASL == b.SASL &&
+		a.TLS == b.TLS
+}
+
+func newKgoProducer(cfg model.KafkaConfig) (producer, error) {
+	opts, err := producerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return kgo.NewClient(opts...)
+}
+
+// producerOptions maps KafkaConfig onto franz-go options.
+func producerOptions(cfg model.KafkaConfig) ([]kgo.Opt, error) {
+	var brokers []string
+	for _, b := range cfg.Brokers {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, errors.New("kafka: no brokers configured")
+	}
+
+	linger := defaultLinger
+	if cfg.Linger != "" {
+		d, err := time.ParseDuration(cfg.Linger)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: invalid linger %q: %w", cfg.Linger, err)
+		}
+		linger = d
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ProducerLinger(linger),
+		kgo.RecordDeliveryTimeout(defaultDeliveryTime),
+		kgo.MaxBufferedRecords(defaultMaxBuffered),
+	}
+	if cfg.ClientID != "" {
+		opts = append(opts, kgo.ClientID(cfg.ClientID))
+	}
+	if cfg.BatchMaxBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(int32(cfg.BatchMaxBytes)))
+	}
+
+	switch strings.ToLower(cfg.Compression) {
+	case "", "none":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.NoCompression()))
+	case "gzip":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.GzipCompression()))
+	case "snappy":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.SnappyCompression()))
+	case "lz4":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.Lz4Compression()))
+	case "zstd":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.ZstdCompression()))
+	default:
+		return nil, fmt.Errorf("kafka: unsupported compression %q", cfg.Compression)
+	}
+
+	// Idempotent delivery needs acks from all in-sync replicas.
+	acksAll := true
+	switch strings.ToLower(cfg.Acks) {
+	case "", "all", "-1":
+		opts = append(opts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	case "leader", "1":
+		acksAll = false
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()))
+	case "none", "0":
+		acksAll = false
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()))
+	default:
+		return nil, fmt.Errorf("kafka: unsupported acks %q", cfg.Acks)
+	}
+	if !cfg.Idempotent || !acksAll {
+		if cfg.Idempotent {
+			zap.L().Warn("Kafka idempotent delivery requires acks=all, disabled", zap.String("id", cfg.ID), zap.String("acks", cfg.Acks))
+		}
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+
+	switch strings.ToUpper(cfg.SASL.Mechanism) {
+	case "", "NONE":
+	case "PLAIN":
+		opts = append(opts, kgo.SASL(plain.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsMechanism()))
+	case "SCRAM-SHA-256":
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsSha256Mechanism()))
+	case "SCRAM-SHA-512":
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.SASL.Username, Pass: cfg.SASL.Password}.AsSha512Mechanism()))
+	default:
+		return nil, fmt.Errorf("kafka: unsupported SASL mechanism %q", cfg.SASL.Mechanism)
+	}
+
+	if cfg.TLS.Enable {
+		tlsConfig, err := createTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+	return opts, nil
+}
+
+func createTLSConfig(cfg model.KafkaTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACert != "" {
+		caCert, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA cert: %v", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in CA cert %s", cfg.CACert)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if cfg.ClientCert != "" && cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client keypair: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// renderTopic expands the topic template and replaces characters Kafka does
+// not allow in topic names.
+func renderTopic(tmpl, clientID, channelID, deviceID string) string {
+	topic := strings.NewReplacer(
+		"{client_id}", clientID,
+		"{channel_id}", channelID,
+		"{device_id}", deviceID,
+	).Replace(tmpl)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, topic)
+}
+
+func (c *Client) recordTarget(tmpl, channelID, deviceID string) (topic, key string) {
+	c.configMu.RLock()
+	clientID := c.config.ClientID
+	keyBy := c.config.KeyBy
+	c.configMu.RUnlock()
+
+	topic = renderTopic(tmpl, clientID, channelID, deviceID)
+	switch keyBy {
+	case "none":
+	case "channel":
+		key = channelID
+	default:
+		key = deviceID
+	}
+	return topic, key
+}
+
+// Send produces one record. Failed deliveries go to the offline cache when it
+// is enabled; while older records are cached, new ones are cached behind them.
+func (c *Client) Send(topic, key string, value []byte) {
+	c.configMu.RLock()
+	p := c.producer
+	c.configMu.RUnlock()
+
+	if c.offline.Pending() {
+		c.cacheRecord(topic, key, value)
+		return
+	}
+	if p == nil {
+		atomic.AddInt64(&c.failCount, 1)
+		c.cacheRecord(topic, key, value)
+		return
+	}
+
+	rec := &kgo.Record{Topic: topic, Value: value}
+	if key != "" {
+		rec.Key = []byte(key)
+	}
+	p.TryProduce(context.Background(), rec, func(r *kgo.Record, err error) {
+		if err != nil {
+			atomic.AddInt64(&c.failCount, 1)
+			atomic.StoreInt32(&c.status, StatusError)
+			zap.L().Warn("Kafka delivery failed", zap.String("topic", r.Topic), zap.Error(err))
+			c.cacheRecord(r.Topic, string(r.Key), r.Value)
+			return
+		}
+		atomic.AddInt64(&c.successCount, 1)
+		atomic.StoreInt32(&c.status, StatusConnected)
+	})
+}
+
+func (c *Client) cacheRecord(topic, key string, value []byte) {
+	if !c.offline.Enabled() {
+		return
+	}
+	data, err := json.Marshal(cachedRecord{Topic: topic, Key: key, Value: value})
+	if err != nil {
+		return
+	}
+	if err := c.offline.Store(data); err != nil {
+		zap.L().Error("Failed to cache Kafka record", zap.Error(err))
+		return
+	}
+	atomic.AddInt64(&c.cachedCount, 1)
+}
+
+func (c *Client) Publish(v model.Value) {
+	c.configMu.RLock()
+	enable := c.config.Enable
+	devCfg, ok := model.LookupNorthboundPublishConfig(v.DeviceID, c.config.Devices, c.config.VirtualDevices)
+	c.configMu.RUnlock()
+
+	if !enable || !ok {
+		return
+	}
+
+	if devCfg.Strategy == "cov" || devCfg.Strategy == "change" {
+		key := v.DeviceID + ":" + v.PointID
+		lastVal, loaded := c.lastValues.Load(key)
+		if loaded && lastVal == v.Value {
+			return
+		}
+		c.lastValues.Store(key, v.Value)
+	} else if devCfg.Strategy == "periodic" && time.Duration(devCfg.Interval) > 0 {
+		c.periodicMu.Lock()
+		if item, exists := c.periodic[v.DeviceID]; exists {
+			item.channelID = v.ChannelID
+			item.values[v.PointID] = v
+		}
+		c.periodicMu.Unlock()
+		return
+	}
+
+	c.bufferMu.Lock()
+	defer c.bufferMu.Unlock()
+
+	item, exists := c.buffers[v.DeviceID]
+	if !exists {
+		item = &bufferItem{
+			payload: &aggregatedPayload{
+				Timestamp: v.TS.UnixMilli(),
+				ChannelID: v.ChannelID,
+				DeviceID:  v.DeviceID,
+				Values:    make(map[string]any),
+				Errors:    make(map[string]any),
+			},
+		}
+		item.timer = time.AfterFunc(100*time.Millisecond, func() {
+			c.flushDevice(v.DeviceID)
+		})
+		c.buffers[v.DeviceID] = item
+	}
+
+	item.payload.Values[v.PointID] = v.Value
+	if v.Quality != "Good" {
+		item.payload.Errors[v.PointID] = v.Quality
+	}
+	if len(v.Meta) > 0 {
+		if item.payload.Metas == nil {
+			item.payload.Metas = make(map[string]any)
+		}
+		item.payload.Metas[v.PointID] = v.Meta
+	}
+}
+
+func (c *Client) flushDevice(deviceID string) {
+	c.bufferMu.Lock()
+	item, ok := c.buffers[deviceID]
+	if !ok {
+		c.bufferMu.Unlock()
+		return
+	}
+	delete(c.buffers, deviceID)
+	c.bufferMu.Unlock()
+
+	c.sendPayload(item.payload)
+}
+
+func (c *Client) sendPayload(payload *aggregatedPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		zap.L().Error("Failed to marshal Kafka payload", zap.Error(err))
+		return
+	}
+
+	c.configMu.RLock()
+	tmpl := c.config.Topic
+	c.configMu.RUnlock()
+	if tmpl == "" {
+		tmpl = defaultTopic
+	}
+	topic, key := c.recordTarget(tmpl, payload.ChannelID, payload.DeviceID)
+	c.Send(topic, key, data)
+}
+
+func (c *Client) updatePeriodicTasks() {
+	c.periodicMu.Lock()
+	defer c.periodicMu.Unlock()
+
+	c.configMu.RLock()
+	devices := c.config.Devices
+	virtualDevices := c.config.VirtualDevices
+	c.configMu.RUnlock()
+
+	isPeriodicEnabled := func(devID string) bool {
+		cfg, ok := model.LookupNorthboundPublishConfig(devID, devices, virtualDevices)
+		return ok && cfg.Enable && cfg.Strategy == "periodic" && time.Duration(cfg.Interval) > 0
+	}
+
+	for devID, item := range c.periodic {
+		if !isPeriodicEnabled(devID) {
+			close(item.stop)
+			item.ticker.Stop()
+			delete(c.periodic, devID)
+		}
+	}
+
+	startPeriodic := func(devID string, devCfg model.DevicePublishConfig) {
+		if !devCfg.Enable || devCfg.Strategy != "periodic" || time.Duration(devCfg.Interval) <= 0 {
+			return
+		}
+		if _, exists := c.periodic[devID]; exists {
+			return
+		}
+		item := &periodicItem{
+			values: make(map[string]model.Value),
+			ticker: time.NewTicker(time.Duration(devCfg.Interval)),
+			stop:   make(chan struct{}),
+		}
+		c.periodic[devID] = item
+		go c.runPeriodicTask(devID, item)
+	}
+
+	for devID, devCfg := range devices {
+		startPeriodic(devID, devCfg)
+	}
+	for devID, devCfg := range virtualDevices {
+		startPeriodic(devID, devCfg)
+	}
+}
+
+func (c *Client) runPeriodicTask(deviceID string, item *periodicItem) {
+	for {
+		select {
+		case <-item.stop:
+			return
+		case <-c.stopChan:
+			return
+		case <-item.ticker.C:
+			c.flushPeriodic(deviceID, item)
+		}
+	}
+}
+
+func (c *Client) flushPeriodic(deviceID string, item *periodicItem) {
+	c.periodicMu.Lock()
+	if len(item.values) == 0 {
+		c.periodicMu.Unlock()
+		return
+	}
+
+	payload := &aggregatedPayload{
+		Timestamp: time.Now().UnixMilli(),
+		ChannelID: item.channelID,
+		DeviceID:  deviceID,
+		Values:    make(map[string]any),
+		Errors:    make(map[string]any),
+	}
+	for _, v := range item.values {
+		payload.Values[v.PointID] = v.Value
+		if v.Quality != "Good" {
+			payload.Errors[v.PointID] = v.Quality
+		}
+		if len(v.Meta) > 0 {
+			if payload.Metas == nil {
+				payload.Metas = make(map[string]any)
+			}
+			payload.Metas[v.PointID] = v.Meta
+		}
+	}
+	c.periodicMu.Unlock()
+
+	c.sendPayload(payload)
+}
+
+func (c *Client) PublishDeviceStatus(deviceID string, status int) {
+	c.configMu.RLock()
+	_, ok := model.LookupNorthboundPublishConfig(deviceID, c.config.Devices, c.config.VirtualDevices)
+	c.configMu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	statusStr := "offline"
+	if status == 0 {
+		statusStr = "online"
+	}
+
+	c.sendEvent(deviceID, map[string]any{
+		"event":     "status",
+		"device_id": deviceID,
+		"status":    statusStr,
+		"timestamp": time.Now().UnixMilli(),
+	})
+}
+
+func (c *Client) PublishDeviceLifecycle(event string, device model.Device) {
+	c.sendEvent(device.ID, map[string]any{
+		"event":     event, // "add" or "remove"
+		"device_id": device.ID,
+		"timestamp": time.Now().UnixMilli(),
+		"details":   device,
+	})
+}
+
+// sendEvent publishes to StatusTopic; events are dropped when it is unset.
+func (c *Client) sendEvent(deviceID string, payload map[string]any) {
+	c.configMu.RLock()
+	tmpl := c.config.StatusTopic
+	c.configMu.RUnlock()
+	if tmpl == "" {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		zap.L().Error("Failed to marshal Kafka event", zap.Error(err))
+		return
+	}
+	topic, key := c.recordTarget(tmpl, "", deviceID)
+	c.Send(topic, key, data)
+}
+
+func (c *Client) retryLoop() {
+	stop := c.stopChan
+	c.offline.Run(stop, func() { c.flushOfflineMessages(stop) })
+}
+
+// flushOfflineMessages replays cached records in order and stops at the first
+// failure so that per-key ordering is kept.
+func (c *Client) flushOfflineMessages(stop <-chan struct{}) {
+	c.configMu.RLock()
+	configID := c.config.ID
+	p := c.producer
+	c.configMu.RUnlock()
+
+	if p == nil {
+		return
+	}
+
+	n, err := c.offline.Replay(stop, func(data []byte) error {
+		var rec cachedRecord
+		if err := json.Unmarshal(data, &rec); err != nil || rec.Topic == "" {
+			// Unreadable entry; drop it rather than block the queue forever.
+			return offline.ErrDiscard
+		}
+		r := &kgo.Record{Topic: rec.Topic, Value: rec.Value}
+		if rec.Key != "" {
+			r.Key = []byte(rec.Key)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), offlineProduceTimeout)
+		err := p.ProduceSync(ctx, r).FirstErr()
+		cancel()
+		if err != nil {
+			atomic.StoreInt32(&c.status, StatusError)
+			return err
+		}
+		atomic.AddInt64(&c.successCount, 1)
+		atomic.StoreInt32(&c.status, StatusConnected)
+		return nil
+	})
+	if n > 0 || err != nil {
+		zap.L().Info("Replayed offline Kafka messages", zap.String("client_id", configID), zap.Int("count", n), zap.Error(err))
+	}
+}
+
+func (c *Client) GetStatus() int {
+	return int(atomic.LoadInt32(&c.status))
+}
+
+func (c *Client) GetStats() map[string]int64 {
+	stats := c.offline.Stats()
+	stats["success_count"] = atomic.LoadInt64(&c.successCount)
+	stats["fail_count"] = atomic.LoadInt64(&c.failCount)
+	stats["cached_count"] = atomic.LoadInt64(&c.cachedCount)
+	return stats
+}
@@ -0,0 +1,274 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+	"github.com/anviod/edgex/internal/storage"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type fakeProducer struct {
+	mu      sync.Mutex
+	records []*kgo.Record
+	err     error
+}
+
+func (p *fakeProducer) TryProduce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	p.mu.Lock()
+	err := p.err
+	if err == nil {
+		p.records = append(p.records, r)
+	}
+	p.mu.Unlock()
+	promise(r, err)
+}
+
+func (p *fakeProducer) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	var results kgo.ProduceResults
+	for _, r := range rs {
+		p.TryProduce(ctx, r, func(r *kgo.Record, err error) {
+			results = append(results, kgo.ProduceResult{Record: r, Err: err})
+		})
+	}
+	return results
+}
+
+func (p *fakeProducer) Flush(ctx context.Context) error { return nil }
+func (p *fakeProducer) Ping(ctx context.Context) error  { return nil }
+func (p *fakeProducer) Close()                          {}
+
+func (p *fakeProducer) setErr(err error) {
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+}
+
+func (p *fakeProducer) snapshot() []*kgo.Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*kgo.Record(nil), p.records...)
+}
+
+func newTestClient(t *testing.T, cfg model.KafkaConfig, s *storage.Storage) (*Client, *fakeProducer) {
+	t.Helper()
+	fp := &fakeProducer{}
+	c := NewClient(cfg, s)
+	c.newProducer = func(model.KafkaConfig) (producer, error) { return fp, nil }
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(c.Stop)
+	return c, fp
+}
+
+func waitRecords(t *testing.T, fp *fakeProducer, n int) []*kgo.Record {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if recs := fp.snapshot(); len(recs) >= n {
+			return recs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d records, got %d", n, len(fp.snapshot()))
+	return nil
+}
+
+func TestPublishRendersTopicAndKey(t *testing.T) {
+	c, fp := newTestClient(t, model.KafkaConfig{
+		ID:     "k1",
+		Enable: true,
+		Topic:  "plant.{channel_id}.{device_id}",
+		Devices: model.OpcUaDeviceMap{
+			"dev/1": {Enable: true, Strategy: "realtime"},
+		},
+	}, nil)
+
+	c.Publish(model.Value{ChannelID: "ch-1", DeviceID: "dev/1", PointID: "temp", Value: 21.5, Quality: "Good", TS: time.Now()})
+	c.Publish(model.Value{ChannelID: "ch-1", DeviceID: "dev/1", PointID: "hum", Value: 40, Quality: "Bad", TS: time.Now()})
+
+	recs := waitRecords(t, fp, 1)
+	if len(recs) != 1 {
+		t.Fatalf("expected values to be aggregated into one record, got %d", len(recs))
+	}
+	if recs[0].Topic != "plant.ch-1.dev_1" {
+		t.Fatalf("topic = %q", recs[0].Topic)
+	}
+	if string(recs[0].Key) != "dev/1" {
+		t.Fatalf("key = %q, want device id", recs[0].Key)
+	}
+
+	var payload aggregatedPayload
+	if err := json.Unmarshal(recs[0].Value, &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(payload.Values) != 2 || payload.Errors["hum"] != "Bad" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestPublishSkipsUnchangedValuesForCOV(t *testing.T) {
+	c, fp := newTestClient(t, model.KafkaConfig{
+		Enable: true,
+		KeyBy:  "channel",
+		Devices: model.OpcUaDeviceMap{
+			"dev-1": {Enable: true, Strategy: "cov"},
+		},
+	}, nil)
+
+	v := model.Value{ChannelID: "ch-1", DeviceID: "dev-1", PointID: "p1", Value: 1, Quality: "Good", TS: time.Now()}
+	c.Publish(v)
+	recs := waitRecords(t, fp, 1)
+	if recs[0].Topic != "edgex.ch-1" || string(recs[0].Key) != "ch-1" {
+		t.Fatalf("topic/key = %q/%q", recs[0].Topic, recs[0].Key)
+	}
+
+	c.Publish(v)
+	time.Sleep(200 * time.Millisecond)
+	if n := len(fp.snapshot()); n != 1 {
+		t.Fatalf("unchanged value was published again, records = %d", n)
+	}
+}
+
+func TestFailedDeliveryIsCachedAndReplayed(t *testing.T) {
+	s, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	defer s.Close()
+
+	c, fp := newTestClient(t, model.KafkaConfig{
+		ID:     "k-cache",
+		Enable: true,
+		Topic:  "{device_id}",
+		KeyBy:  "none",
+		Cache:  model.DataCacheConfig{Enable: true, MaxCount: 10},
+	}, s)
+
+	fp.setErr(errors.New("broker unreachable"))
+	c.Send("dev-1", "", []byte(`{"a":1}`))
+	c.Send("dev-2", "", []byte(`{"b":2}`))
+
+	// The second record goes straight behind the first without a delivery attempt.
+	if stats := c.GetStats(); stats["fail_count"] != 1 || stats["cached_count"] != 2 || stats["backlog_count"] != 2 {
+		t.Fatalf("stats = %v", stats)
+	}
+
+	fp.setErr(nil)
+	c.flushOfflineMessages(nil)
+
+	recs := fp.snapshot()
+	if len(recs) != 2 || recs[0].Topic != "dev-1" || recs[1].Topic != "dev-2" {
+		t.Fatalf("replayed records = %+v", recs)
+	}
+	if string(recs[0].Value) != `{"a":1}` || recs[0].Key != nil {
+		t.Fatalf("replayed record = %q key=%q", recs[0].Value, recs[0].Key)
+	}
+	if stats := c.GetStats(); stats["backlog_count"] != 0 || stats["offline_replayed"] != 2 {
+		t.Fatalf("cache not drained: %v", stats)
+	}
+	if c.GetStatus() != StatusConnected {
+		t.Fatalf("status = %d, want connected", c.GetStatus())
+	}
+}
+
+func TestDeviceStatusUsesStatusTopic(t *testing.T) {
+	c, fp := newTestClient(t, model.KafkaConfig{
+		Enable:  true,
+		Devices: model.OpcUaDeviceMap{"dev-1": {Enable: true}},
+	}, nil)
+
+	// Without a status topic, events are not produced.
+	c.PublishDeviceStatus("dev-1", 0)
+	if n := len(fp.snapshot()); n != 0 {
+		t.Fatalf("records = %d, want 0", n)
+	}
+
+	cfg := c.config
+	cfg.StatusTopic = "edgex.status"
+	if err := c.UpdateConfig(cfg); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	c.PublishDeviceStatus("dev-1", 0)
+	recs := waitRecords(t, fp, 1)
+	if recs[0].Topic != "edgex.status" || string(recs[0].Key) != "dev-1" {
+		t.Fatalf("topic/key = %q/%q", recs[0].Topic, recs[0].Key)
+	}
+}
+
+func TestProducerOptionsValidation(t *testing.T) {
+	base := model.KafkaConfig{Brokers: []string{"127.0.0.1:9092"}, Idempotent: true}
+	if _, err := producerOptions(base); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+
+	cases := map[string]func(*model.KafkaConfig){
+		"no brokers":  func(c *model.KafkaConfig) { c.Brokers = []string{" "} },
+		"linger":      func(c *model.KafkaConfig) { c.Linger = "soon" },
+		"compression": func(c *model.KafkaConfig) { c.Compression = "brotli" },
+		"acks":        func(c *model.KafkaConfig) { c.Acks = "some" },
+		"sasl":        func(c *model.KafkaConfig) { c.SASL.Mechanism = "GSSAPI" },
+		"ca file":     func(c *model.KafkaConfig) { c.TLS = model.KafkaTLSConfig{Enable: true, CACert: "/nonexistent/ca.pem"} },
+	}
+	for name, mutate := range cases {
+		cfg := base
+		mutate(&cfg)
+		if _, err := producerOptions(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestConnectorRegistersAndPublishesLifecycle(t *testing.T) {
+	cfg := model.ConnectorConfig{
+		ID:       "k1",
+		Name:     "Kafka",
+		Type:     ConnectorType,
+		Enable:   true,
+		Settings: map[string]any{"brokers": []string{"127.0.0.1:9092"}, "status_topic": "edgex.status"},
+	}
+	if err := connector.Validate(cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	conn, err := connector.New(cfg, connector.Deps{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	kc := conn.(*Connector)
+	if kc.config.ID != "k1" || kc.config.StatusTopic != "edgex.status" || len(kc.config.Brokers) != 1 {
+		t.Fatalf("config = %+v", kc.config)
+	}
+	fp := &fakeProducer{}
+	kc.newProducer = func(model.KafkaConfig) (producer, error) { return fp, nil }
+	if err := conn.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer conn.Stop()
+
+	cfg.Devices = model.OpcUaDeviceMap{"dev-1": {Enable: true}}
+	if err := conn.UpdateConfig(cfg); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	cfg.Devices = nil
+	if err := conn.UpdateConfig(cfg); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	// The device is unknown to the (absent) southbound, so only its removal
+	// is announced.
+	recs := waitRecords(t, fp, 1)
+	var event map[string]any
+	if err := json.Unmarshal(recs[0].Value, &event); err != nil || event["event"] != "remove" || event["device_id"] != "dev-1" {
+		t.Fatalf("event = %s (%v)", recs[0].Value, err)
+	}
+	if _, ok := conn.Stats()["success_count"]; !ok {
+		t.Fatalf("stats = %v", conn.Stats())
+	}
+}
@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+	"github.com/anviod/edgex/internal/northbound/offline"
+)
+
+// ConnectorType is the ConnectorConfig.Type of Kafka producers.
+const ConnectorType = "kafka"
+
+func init() {
+	connector.Register(connector.Descriptor{
+		Type:        ConnectorType,
+		DisplayName: "Kafka",
+		New:         NewConnector,
+		Validate:    ValidateConnectorConfig,
+	})
+}
+
+// Connector runs a Client as a registry-based northbound connector.
+type Connector struct {
+	*Client
+	sb model.SouthboundManager
+}
+
+// ConfigFromConnector decodes the KafkaConfig carried in cfg.Settings. ID,
+// name, enable and the device maps always come from cfg itself.
+func ConfigFromConnector(cfg model.ConnectorConfig) (model.KafkaConfig, error) {
+	var kc model.KafkaConfig
+	if err := cfg.DecodeSettings(&kc); err != nil {
+		return kc, fmt.Errorf("Kafka 配置无效: %w", err)
+	}
+	kc.ID = cfg.ID
+	kc.Name = cfg.Name
+	kc.Enable = cfg.Enable
+	kc.Devices = cfg.Devices
+	kc.VirtualDevices = cfg.VirtualDevices
+	return kc, nil
+}
+
+// ValidateConnectorConfig checks the settings before they are saved.
+func ValidateConnectorConfig(cfg model.ConnectorConfig) error {
+	kc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return err
+	}
+	return offline.ValidateConfig(kc.Cache)
+}
+
+// NewConnector creates a stopped Kafka producer. A start error is a
+// configuration problem (brokers, TLS files, SASL); an unreachable cluster is
+// handled by the offline cache.
+func NewConnector(cfg model.ConnectorConfig, deps connector.Deps) (connector.Connector, error) {
+	kc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{Client: NewClient(kc, deps.Storage), sb: deps.Southbound}, nil
+}
+
+// UpdateConfig applies cfg and publishes add/remove lifecycle events for
+// devices whose publishing was switched on or off.
+func (c *Connector) UpdateConfig(cfg model.ConnectorConfig) error {
+	kc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return err
+	}
+	c.configMu.RLock()
+	old := c.config
+	c.configMu.RUnlock()
+
+	if err := c.Client.UpdateConfig(kc); err != nil {
+		return err
+	}
+
+	for id, devCfg := range kc.Devices {
+		if prev, ok := old.Devices[id]; devCfg.Enable && (!ok || !prev.Enable) {
+			if dev := c.findDevice(id); dev != nil {
+				c.PublishDeviceLifecycle("add", *dev)
+			}
+		}
+	}
+	for id, prev := range old.Devices {
+		if devCfg, ok := kc.Devices[id]; prev.Enable && (!ok || !devCfg.Enable) {
+			dev := c.findDevice(id)
+			if dev == nil {
+				dev = &model.Device{ID: id}
+			}
+			c.PublishDeviceLifecycle("remove", *dev)
+		}
+	}
+	return nil
+}
+
+func (c *Connector) findDevice(id string) *model.Device {
+	if c.sb == nil {
+		return nil
+	}
+	for _, ch := range c.sb.GetChannels() {
+		for i := range ch.Devices {
+			if ch.Devices[i].ID == id {
+				return &ch.Devices[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Stats implements connector.Connector.
+func (c *Connector) Stats() map[string]any {
+	stats := make(map[string]any)
+	for k, v := range c.GetStats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// Status implements connector.StatusReporter.
+func (c *Connector) Status() int {
+	return c.GetStatus()
+}
+
+// Write implements connector.Connector; the producer only publishes.
+func (c *Connector) Write(channelID, deviceID, pointID string, value any) error {
+	return connector.ErrWriteNotSupported
+}
@@ -3,13 +3,16 @@ package mqtt
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
 	"github.com/anviod/edgex/internal/northbound/reconnect"
+	nbtemplate "github.com/anviod/edgex/internal/northbound/template"
 	"github.com/anviod/edgex/internal/storage"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -29,14 +32,26 @@ type MQTTStats struct {
 	ReconnectCount  int64 `json:"reconnect_count"`
 	LastOfflineTime int64 `json:"last_offline_time"`
 	LastOnlineTime  int64 `json:"last_online_time"`
+
+	// Offline store-and-forward backlog
+	BacklogCount     int64 `json:"backlog_count"`
+	BacklogBytes     int64 `json:"backlog_bytes"`
+	BacklogAgeMs     int64 `json:"backlog_age_ms"`
+	OfflineDropped   int64 `json:"offline_dropped"`
+	OfflineExpired   int64 `json:"offline_expired"`
+	OfflineReplayed  int64 `json:"offline_replayed"`
+	OfflineDiscarded int64 `json:"offline_discarded"`
 }
 
 type Client struct {
 	config     model.MQTTConfig
 	configMu   sync.RWMutex
-	client     mqtt.Client
 	lastValues sync.Map
 
+	// MQTT 3.x or 5 session, replaced on every connectLoop
+	sess   session
+	sessMu sync.RWMutex
+
 	status   int
 	statusMu sync.RWMutex
 	stopChan chan struct{}
@@ -50,8 +65,8 @@ type Client struct {
 	// Southbound Manager for writing
 	sb model.SouthboundManager
 
-	// Storage for offline caching
-	storage *storage.Storage
+	// Durable offline queue, replayed in order after reconnect
+	offline *offline.Buffer
 
 	// Stats counters (using atomic int64)
 	successCount    int64
@@ -61,20 +76,26 @@ type Client struct {
 	lastOnlineTime  int64
 
 	reconnectSched reconnect.Scheduler
+
+	// Compiled payload/topic templates, guarded by configMu
+	format *payloadFormat
 }
 
 type AggregatedPayload struct {
-	Timestamp int64          `json:"timestamp"`
-	Node      string         `json:"node"`
-	Group     string         `json:"group"`
-	Values    map[string]any `json:"values"`
-	Errors    map[string]any `json:"errors"`
-	Metas     map[string]any `json:"metas"`
+	Timestamp any               `json:"timestamp"` // Epoch milliseconds unless timestamp_format is set
+	Node      string            `json:"node"`
+	Group     string            `json:"group"`
+	Values    map[string]any    `json:"values"`
+	Errors    map[string]any    `json:"errors"`
+	Metas     map[string]any    `json:"metas"`
+	Units     map[string]string `json:"units,omitempty"`
 }
 
 type bufferItem struct {
-	payload *AggregatedPayload
-	timer   *time.Timer
+	channelID string
+	ts        time.Time
+	values    map[string]model.Value
+	timer     *time.Timer
 }
 
 type periodicItem struct {
@@ -85,13 +106,24 @@ type periodicItem struct {
 }
 
 func NewClient(cfg model.MQTTConfig, sb model.SouthboundManager, s *storage.Storage) *Client {
+	format, err := newPayloadFormat(cfg)
+	if err != nil {
+		// Upsert validates templates; a bad hand-edited config falls back to the default payload.
+		zap.L().Error("Invalid MQTT payload format, using default payload",
+			zap.String("id", cfg.ID),
+			zap.Error(err),
+			zap.String("component", "mqtt-client"),
+		)
+		format, _ = newPayloadFormat(model.MQTTConfig{Topic: cfg.Topic, ClientID: cfg.ClientID})
+	}
 	c := &Client{
 		config:   cfg,
 		sb:       sb,
-		storage:  s,
+		offline:  offline.New(s, cfg.ID, cfg.Cache),
 		stopChan: make(chan struct{}),
 		buffers:  make(map[string]*bufferItem),
 		periodic: make(map[string]*periodicItem),
+		format:   format,
 	}
 	return c
 }
@@ -103,12 +135,20 @@ func (c *Client) GetStatus() int {
 }
 
 func (c *Client) GetStats() MQTTStats {
+	backlog := c.offline.Stats()
 	return MQTTStats{
-		SuccessCount:    atomic.LoadInt64(&c.successCount),
-		FailCount:       atomic.LoadInt64(&c.failCount),
-		ReconnectCount:  atomic.LoadInt64(&c.reconnectCount),
-		LastOfflineTime: atomic.LoadInt64(&c.lastOfflineTime),
-		LastOnlineTime:  atomic.LoadInt64(&c.lastOnlineTime),
+		SuccessCount:     atomic.LoadInt64(&c.successCount),
+		FailCount:        atomic.LoadInt64(&c.failCount),
+		ReconnectCount:   atomic.LoadInt64(&c.reconnectCount),
+		LastOfflineTime:  atomic.LoadInt64(&c.lastOfflineTime),
+		LastOnlineTime:   atomic.LoadInt64(&c.lastOnlineTime),
+		BacklogCount:     backlog["backlog_count"],
+		BacklogBytes:     backlog["backlog_bytes"],
+		BacklogAgeMs:     backlog["backlog_age_ms"],
+		OfflineDropped:   backlog["offline_dropped"],
+		OfflineExpired:   backlog["offline_expired"],
+		OfflineReplayed:  backlog["offline_replayed"],
+		OfflineDiscarded: backlog["offline_discarded"],
 	}
 }
 
@@ -118,17 +158,58 @@ func (c *Client) setStatus(s int) {
 	c.status = s
 }
 
+func (c *Client) session() session {
+	c.sessMu.RLock()
+	defer c.sessMu.RUnlock()
+	return c.sess
+}
+
+func (c *Client) isConnected() bool {
+	sess := c.session()
+	return sess != nil && sess.IsConnected()
+}
+
+func (c *Client) topicOptions(kind string) model.MQTTTopicOptions {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return topicOptions(c.config, kind)
+}
+
+// needsReconnect reports whether cfg changes settings that are fixed for the
+// lifetime of an MQTT session.
+func needsReconnect(old, cfg model.MQTTConfig) bool {
+	cleanSession := func(c model.MQTTConfig) bool { return c.CleanSession == nil || *c.CleanSession }
+	return old.Broker != cfg.Broker ||
+		old.ClientID != cfg.ClientID ||
+		old.Username != cfg.Username ||
+		old.Password != cfg.Password ||
+		protocolVersion(old) != protocolVersion(cfg) ||
+		cleanSession(old) != cleanSession(cfg) ||
+		old.KeepAlive != cfg.KeepAlive ||
+		old.SessionExpiry != cfg.SessionExpiry ||
+		old.MessageExpiry != cfg.MessageExpiry ||
+		old.TLS != cfg.TLS ||
+		!maps.Equal(old.UserProperties, cfg.UserProperties) ||
+		old.SubscribeTopic != cfg.SubscribeTopic ||
+		topicOptions(old, TopicKindStatus) != topicOptions(cfg, TopicKindStatus) ||
+		topicOptions(old, TopicKindSubscribe) != topicOptions(cfg, TopicKindSubscribe)
+}
+
 func (c *Client) UpdateConfig(cfg model.MQTTConfig) error {
+	format, err := newPayloadFormat(cfg)
+	if err != nil {
+		return err
+	}
+
 	c.configMu.RLock()
-	needRestart := c.config.Broker != cfg.Broker ||
-		c.config.ClientID != cfg.ClientID ||
-		c.config.Username != cfg.Username ||
-		c.config.Password != cfg.Password
+	needRestart := needsReconnect(c.config, cfg)
 	c.configMu.RUnlock()
 
 	c.configMu.Lock()
 	c.config = cfg
+	c.format = format
 	c.configMu.Unlock()
+	c.offline.SetConfig(cfg.Cache)
 
 	if needRestart {
 		c.Stop()
@@ -236,116 +317,54 @@ func (c *Client) flushPeriodic(deviceID string, item *periodicItem) {
 		c.periodicMu.Unlock()
 		return
 	}
-
-	// Construct payload
-	payload := &AggregatedPayload{
-		Timestamp: time.Now().UnixMilli(),
-		Node:      deviceID,
-		Group:     item.channelID,
-		Values:    make(map[string]any),
-		Errors:    make(map[string]any),
-		Metas:     make(map[string]any),
-	}
-
+	channelID := item.channelID
+	values := make([]model.Value, 0, len(item.values))
 	for _, v := range item.values {
-		payload.Values[v.PointID] = v.Value
-		if v.Quality != "Good" {
-			payload.Errors[v.PointID] = v.Quality
-		}
+		values = append(values, v)
 	}
 	c.periodicMu.Unlock()
 
-	c.configMu.RLock()
-	ignoreOffline := c.config.IgnoreOfflineData
-	c.configMu.RUnlock()
-
-	if ignoreOffline {
-		if len(payload.Values) > 0 && len(payload.Errors) == len(payload.Values) {
-			return
-		}
-	}
-
-	data, err := json.Marshal(payload)
-	if err != nil {
-		zap.L().Error("Failed to marshal periodic value for MQTT",
-			zap.Error(err),
-			zap.String("component", "mqtt-client"),
-		)
-		return
-	}
-
-	if c.client == nil || !c.client.IsConnected() {
-		return
-	}
-
-	c.configMu.RLock()
-	topic := c.config.Topic
-	c.configMu.RUnlock()
-
-	topic = c.replaceGlobalVars(topic)
-
-	token := c.client.Publish(topic, 0, false, data)
-	go func() {
-		if token.Wait() && token.Error() != nil {
-			atomic.AddInt64(&c.failCount, 1)
-			zap.L().Error("Failed to publish to MQTT",
-				zap.Error(token.Error()),
-				zap.String("component", "mqtt-client"),
-			)
-		} else {
-			atomic.AddInt64(&c.successCount, 1)
-			zap.L().Debug("Published to MQTT",
-				zap.String("topic", topic),
-				zap.Int("bytes", len(data)),
-				zap.String("payload", string(data)),
-				zap.String("component", "mqtt-client"),
-			)
-		}
-	}()
+	c.publishDevice(channelID, deviceID, time.Now(), values)
 }
 
 // PublishRaw publishes raw data to a specific topic
 func (c *Client) PublishRaw(topic string, payload []byte) error {
+	return c.publishRaw(TopicKindData, outMessage{topic: topic, payload: payload})
+}
+
+// publishRaw publishes m with the QoS/retain of kind, caching it offline when
+// the broker is unreachable.
+func (c *Client) publishRaw(kind string, m outMessage) error {
 	// Check if client is enabled
 	c.configMu.RLock()
 	enable := c.config.Enable
+	opts := topicOptions(c.config, kind)
 	c.configMu.RUnlock()
 
 	if !enable {
 		return mqtt.ErrNotConnected
 	}
+	m.qos, m.retain = opts.QoS, opts.Retain
 
-	connected := c.client != nil && c.client.IsConnected()
+	sess := c.session()
+	connected := sess != nil && sess.IsConnected()
 
+	// Older messages are still queued: go behind them to keep the order.
+	if c.offline.Pending() {
+		return c.cacheMessage(m)
+	}
 	if !connected {
-		// Cache logic
-		c.configMu.RLock()
-		cacheCfg := c.config.Cache
-		configID := c.config.ID
-		c.configMu.RUnlock()
-
-		if cacheCfg.Enable && c.storage != nil {
-			if err := c.storage.SaveOfflineMessage(configID, payload, cacheCfg.MaxCount); err != nil {
-				return err
-			}
-			return nil // Queued successfully
+		if c.offline.Enabled() {
+			return c.cacheMessage(m)
 		}
 		return mqtt.ErrNotConnected
 	}
 
-	token := c.client.Publish(topic, 0, false, payload)
-	if token.Wait() && token.Error() != nil {
-		// Cache logic on failure
-		c.configMu.RLock()
-		cacheCfg := c.config.Cache
-		configID := c.config.ID
-		c.configMu.RUnlock()
-
-		if cacheCfg.Enable && c.storage != nil {
-			c.storage.SaveOfflineMessage(configID, payload, cacheCfg.MaxCount)
-			return nil // Queued
+	if err := sess.Publish(m); err != nil {
+		if c.offline.Enabled() {
+			return c.cacheMessage(m)
 		}
-		return token.Error()
+		return err
 	}
 	return nil
 }
@@ -364,43 +383,37 @@ func (c *Client) connectLoop() {
 	c.setStatus(StatusReconnecting)
 
 	c.configMu.RLock()
-	broker := c.config.Broker
-	clientID := c.config.ClientID
-	username := c.config.Username
-	password := c.config.Password
-	statusTopic := c.config.StatusTopic
-	lwtTopic := c.config.LwtTopic
-	topic := c.config.Topic
-	offlinePayload := c.config.OfflinePayload
-	lwtPayload := c.config.LwtPayload
+	cfg := c.config
 	c.configMu.RUnlock()
+	broker := cfg.Broker
+	clientID := cfg.ClientID
 
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(broker)
-	opts.SetClientID(clientID)
-	if username != "" {
-		opts.SetUsername(username)
-		opts.SetPassword(password)
+	opts, err := buildSessionOptions(cfg)
+	if err != nil {
+		zap.L().Error("Invalid MQTT connection settings",
+			zap.Error(err),
+			zap.String("component", "mqtt-client"),
+		)
+		c.setStatus(StatusError)
+		return
 	}
-	// Disable auto reconnect to control it manually
-	opts.SetAutoReconnect(false)
 
 	// Determine LWT Topic
-	finalLwtTopic := lwtTopic
+	finalLwtTopic := cfg.LwtTopic
 	if finalLwtTopic == "" {
-		finalLwtTopic = statusTopic
+		finalLwtTopic = cfg.StatusTopic
 	}
-	if finalLwtTopic == "" && topic != "" {
-		finalLwtTopic = topic + "/status"
+	if finalLwtTopic == "" && cfg.Topic != "" {
+		finalLwtTopic = cfg.Topic + "/status"
 	}
 	// Replace vars in LWT Topic
 	finalLwtTopic = c.replaceGlobalVars(finalLwtTopic)
 
 	if finalLwtTopic != "" {
 		// Determine LWT Payload
-		finalLwtPayload := lwtPayload
+		finalLwtPayload := cfg.LwtPayload
 		if finalLwtPayload == "" {
-			finalLwtPayload = offlinePayload // Fallback to OfflinePayload if LwtPayload is not set
+			finalLwtPayload = cfg.OfflinePayload // Fallback to OfflinePayload if LwtPayload is not set
 		}
 		if finalLwtPayload == "" {
 			b, _ := json.Marshal(map[string]any{
@@ -415,16 +428,21 @@ func (c *Client) connectLoop() {
 		payload := c.replaceDeviceVars(finalLwtPayload, clientID)
 		payload = strings.ReplaceAll(payload, "{status}", "lwt")
 
-		opts.SetWill(finalLwtTopic, payload, 1, true)
+		statusOpts := topicOptions(cfg, TopicKindStatus)
+		opts.will = &outMessage{topic: finalLwtTopic, payload: []byte(payload), qos: statusOpts.QoS, retain: statusOpts.Retain}
 	}
 
-	opts.SetOnConnectHandler(func(client mqtt.Client) {
+	opts.onConnect = func() {
 		zap.L().Info("Connected to MQTT Broker",
 			zap.String("broker", broker),
+			zap.Int("protocol_version", opts.version),
 			zap.String("component", "mqtt-client"),
 		)
 		c.setStatus(StatusConnected)
 		atomic.StoreInt64(&c.lastOnlineTime, time.Now().UnixMilli())
+		c.offline.Kick()
+
+		sess := c.session()
 
 		// Publish Online Status
 		// Re-evaluate statusTopic here in case config changed (though config update restarts client)
@@ -433,6 +451,8 @@ func (c *Client) connectLoop() {
 		topic := c.config.Topic
 		onlinePayload := c.config.OnlinePayload
 		subscribeTopic := c.config.SubscribeTopic
+		statusOpts := topicOptions(c.config, TopicKindStatus)
+		subscribeOpts := topicOptions(c.config, TopicKindSubscribe)
 		c.configMu.RUnlock()
 
 		if statusTopic == "" && topic != "" {
@@ -454,17 +474,22 @@ func (c *Client) connectLoop() {
 			payload := c.replaceDeviceVars(onlinePayload, clientID)
 			payload = strings.ReplaceAll(payload, "{status}", "online")
 
-			client.Publish(statusTopic, 1, true, payload)
+			if err := sess.Publish(outMessage{topic: statusTopic, payload: []byte(payload), qos: statusOpts.QoS, retain: statusOpts.Retain}); err != nil {
+				zap.L().Warn("Failed to publish online status",
+					zap.String("topic", statusTopic),
+					zap.Error(err),
+					zap.String("component", "mqtt-client"),
+				)
+			}
 		}
 
 		// Subscribe to write requests if configured
 		if subscribeTopic != "" {
 			subscribeTopic = c.replaceGlobalVars(subscribeTopic)
-			token := client.Subscribe(subscribeTopic, 0, c.handleWriteRequest)
-			if token.Wait() && token.Error() != nil {
+			if err := sess.Subscribe(subscribeTopic, subscribeOpts.QoS, c.handleWriteRequest); err != nil {
 				zap.L().Error("Failed to subscribe to write topic",
 					zap.String("topic", subscribeTopic),
-					zap.Error(token.Error()),
+					zap.Error(err),
 					zap.String("component", "mqtt-client"),
 				)
 			} else {
@@ -474,9 +499,9 @@ func (c *Client) connectLoop() {
 				)
 			}
 		}
-	})
+	}
 
-	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
+	opts.onConnectionLost = func(err error) {
 		zap.L().Warn("MQTT Connection Lost",
 			zap.Error(err),
 			zap.String("component", "mqtt-client"),
@@ -484,14 +509,17 @@ func (c *Client) connectLoop() {
 		c.setStatus(StatusDisconnected)
 		atomic.StoreInt64(&c.lastOfflineTime, time.Now().UnixMilli())
 		c.scheduleReconnect()
-	})
+	}
 
-	c.client = mqtt.NewClient(opts)
+	sess := newSession(opts)
+	c.sessMu.Lock()
+	c.sess = sess
+	c.sessMu.Unlock()
 
 	// Initial connection attempt
-	if token := c.client.Connect(); token.Wait() && token.Error() != nil {
+	if err := sess.Connect(); err != nil {
 		zap.L().Error("Initial MQTT connection failed",
-			zap.Error(token.Error()),
+			zap.Error(err),
 			zap.String("component", "mqtt-client"),
 		)
 		c.setStatus(StatusDisconnected)
@@ -517,14 +545,14 @@ type WriteResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-func (c *Client) handleWriteRequest(client mqtt.Client, msg mqtt.Message) {
+func (c *Client) handleWriteRequest(msg inMessage) {
 	zap.L().Info("Received write request",
-		zap.String("topic", msg.Topic()),
+		zap.String("topic", msg.topic),
 		zap.String("component", "mqtt-client"),
 	)
 
 	var req WriteRequest
-	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
+	if err := json.Unmarshal(msg.payload, &req); err != nil {
 		zap.L().Error("Failed to unmarshal write request",
 			zap.Error(err),
 			zap.String("component", "mqtt-client"),
@@ -569,14 +597,17 @@ func (c *Client) handleWriteRequest(client mqtt.Client, msg mqtt.Message) {
 		}
 	}
 
-	// Send response if UUID is present
-	if req.UUID != "" {
+	// Send response if UUID or an MQTT 5 response topic is present
+	if req.UUID != "" || msg.responseTopic != "" {
 		c.configMu.RLock()
 		respTopic := c.config.WriteResponseTopic
 		c.configMu.RUnlock()
-		if respTopic == "" {
+		if msg.responseTopic != "" {
+			// MQTT 5 request/response: the requester chooses the topic
+			respTopic = msg.responseTopic
+		} else if respTopic == "" {
 			// Derive response topic from request topic: replace trailing "req" with "resp" when present
-			t := msg.Topic()
+			t := msg.topic
 			parts := strings.Split(t, "/")
 			if len(parts) > 0 && parts[len(parts)-1] == "req" {
 				parts[len(parts)-1] = "resp"
@@ -604,7 +635,8 @@ func (c *Client) handleWriteRequest(client mqtt.Client, msg mqtt.Message) {
 		}
 
 		data, _ := json.Marshal(resp)
-		if err := c.PublishRaw(respTopic, data); err != nil {
+		out := outMessage{topic: respTopic, payload: data, correlationData: msg.correlationData}
+		if err := c.publishRaw(TopicKindWriteResponse, out); err != nil {
 			zap.L().Error("Failed to publish write response",
 				zap.String("topic", respTopic),
 				zap.Error(err),
@@ -640,7 +672,8 @@ func (c *Client) reconnectLogic() {
 		default:
 		}
 
-		if c.client != nil && c.client.IsConnected() {
+		sess := c.session()
+		if sess == nil || sess.IsConnected() {
 			return
 		}
 
@@ -665,8 +698,7 @@ func (c *Client) reconnectLogic() {
 			)
 		}
 
-		token := c.client.Connect()
-		if token.Wait() && token.Error() == nil {
+		if err := sess.Connect(); err == nil {
 			atomic.AddInt64(&c.reconnectCount, 1)
 			zap.L().Info("MQTT reconnected",
 				zap.String("broker", broker),
@@ -718,7 +750,7 @@ func (c *Client) Publish(v model.Value) {
 	enable := c.config.Enable
 	c.configMu.RUnlock()
 
-	if !enable || c.client == nil || !c.client.IsConnected() {
+	if !enable || !c.isConnected() {
 		return
 	}
 
@@ -755,16 +787,10 @@ func (c *Client) Publish(v model.Value) {
 
 	item, ok := c.buffers[v.DeviceID]
 	if !ok {
-		payload := &AggregatedPayload{
-			Timestamp: v.TS.UnixMilli(),
-			Node:      v.DeviceID,  // Node maps to DeviceID
-			Group:     v.ChannelID, // Group maps to ChannelID
-			Values:    make(map[string]any),
-			Errors:    make(map[string]any),
-			Metas:     make(map[string]any),
-		}
 		item = &bufferItem{
-			payload: payload,
+			channelID: v.ChannelID,
+			ts:        v.TS,
+			values:    make(map[string]model.Value),
 		}
 		// Start timer to flush (100ms delay to aggregate points)
 		item.timer = time.AfterFunc(100*time.Millisecond, func() {
@@ -774,10 +800,7 @@ func (c *Client) Publish(v model.Value) {
 	}
 
 	// Add value to buffer
-	item.payload.Values[v.PointID] = v.Value
-	if v.Quality != "Good" {
-		item.payload.Errors[v.PointID] = v.Quality
-	}
+	item.values[v.PointID] = v
 }
 
 func (c *Client) flushDevice(deviceID string) {
@@ -790,53 +813,103 @@ func (c *Client) flushDevice(deviceID string) {
 	delete(c.buffers, deviceID)
 	c.bufferMu.Unlock()
 
+	values := make([]model.Value, 0, len(item.values))
+	for _, v := range item.values {
+		values = append(values, v)
+	}
+	c.publishDevice(item.channelID, deviceID, item.ts, values)
+}
+
+// publishDevice renders values through the payload format and publishes the
+// resulting messages.
+func (c *Client) publishDevice(channelID, deviceID string, ts time.Time, values []model.Value) {
 	c.configMu.RLock()
 	ignoreOffline := c.config.IgnoreOfflineData
+	clientID := c.config.ClientID
+	format := c.format
 	c.configMu.RUnlock()
 
 	if ignoreOffline {
-		if len(item.payload.Values) > 0 && len(item.payload.Errors) == len(item.payload.Values) {
+		good := make([]model.Value, 0, len(values))
+		for _, v := range values {
+			if v.Quality == "Good" {
+				good = append(good, v)
+			}
+		}
+		// Device mode keeps bad points beside good ones, as before.
+		if len(good) == 0 {
 			return
 		}
+		if format.perPoint {
+			values = good
+		}
+	}
+
+	if !c.isConnected() {
+		return
+	}
+
+	snapshot := deviceSnapshot{
+		clientID:  clientID,
+		channelID: channelID,
+		deviceID:  deviceID,
+		time:      ts,
+		values:    values,
 	}
+	snapshot.deviceName, snapshot.points = c.pointInfo(channelID, deviceID)
 
-	data, err := json.Marshal(item.payload)
+	msgs, err := format.render(snapshot)
 	if err != nil {
-		zap.L().Error("Failed to marshal value for MQTT",
+		atomic.AddInt64(&c.failCount, 1)
+		zap.L().Error("Failed to render MQTT payload",
+			zap.String("device", deviceID),
 			zap.Error(err),
 			zap.String("component", "mqtt-client"),
 		)
 		return
 	}
-
-	if c.client == nil || !c.client.IsConnected() {
-		return
+	for _, msg := range msgs {
+		c.publishMessage(msg)
 	}
+}
 
-	c.configMu.RLock()
-	topic := c.config.Topic
-	c.configMu.RUnlock()
-
-	topic = c.replaceGlobalVars(topic)
+// pointInfo returns the device name and point names/units from the southbound configuration.
+func (c *Client) pointInfo(channelID, deviceID string) (string, map[string]nbtemplate.PointInfo) {
+	if c.sb == nil || channelID == "" {
+		return "", nil
+	}
+	dev := c.sb.GetDevice(channelID, deviceID)
+	if dev == nil {
+		return "", nil
+	}
+	points := make(map[string]nbtemplate.PointInfo, len(dev.Points))
+	for _, p := range dev.Points {
+		points[p.ID] = nbtemplate.PointInfo{Name: p.Name, Unit: p.Unit}
+	}
+	return dev.Name, points
+}
 
-	token := c.client.Publish(topic, 0, false, data)
-	go func() {
-		if token.Wait() && token.Error() != nil {
-			atomic.AddInt64(&c.failCount, 1)
-			zap.L().Error("Failed to publish to MQTT",
-				zap.Error(token.Error()),
-				zap.String("component", "mqtt-client"),
-			)
-		} else {
-			atomic.AddInt64(&c.successCount, 1)
-			zap.L().Debug("Published to MQTT",
-				zap.String("topic", topic),
-				zap.Int("bytes", len(data)),
-				zap.String("payload", string(data)),
-				zap.String("component", "mqtt-client"),
-			)
-		}
-	}()
+func (c *Client) publishMessage(msg Message) {
+	sess := c.session()
+	if sess == nil {
+		return
+	}
+	opts := c.topicOptions(TopicKindData)
+	if err := sess.Publish(outMessage{topic: msg.Topic, payload: msg.Payload, qos: opts.QoS, retain: opts.Retain}); err != nil {
+		atomic.AddInt64(&c.failCount, 1)
+		zap.L().Error("Failed to publish to MQTT",
+			zap.Error(err),
+			zap.String("component", "mqtt-client"),
+		)
+		return
+	}
+	atomic.AddInt64(&c.successCount, 1)
+	zap.L().Debug("Published to MQTT",
+		zap.String("topic", msg.Topic),
+		zap.Int("bytes", len(msg.Payload)),
+		zap.String("payload", string(msg.Payload)),
+		zap.String("component", "mqtt-client"),
+	)
 }
 
 // PublishDeviceStatus publishes device online/offline status
@@ -846,7 +919,7 @@ func (c *Client) PublishDeviceStatus(deviceID string, status int) {
 	enable := c.config.Enable
 	c.configMu.RUnlock()
 
-	if !enable || c.client == nil || !c.client.IsConnected() {
+	if !enable || !c.isConnected() {
 		return
 	}
 
@@ -902,14 +975,15 @@ func (c *Client) PublishDeviceStatus(deviceID string, status int) {
 	payload := c.replaceDeviceVars(payloadTmpl, deviceID)
 	payload = strings.ReplaceAll(payload, "{status}", statusStr)
 
-	token := c.client.Publish(topic, 0, false, payload)
+	sess := c.session()
+	opts := c.topicOptions(TopicKindDeviceStatus)
 	go func() {
-		if token.Wait() && token.Error() != nil {
+		if err := sess.Publish(outMessage{topic: topic, payload: []byte(payload), qos: opts.QoS, retain: opts.Retain}); err != nil {
 			atomic.AddInt64(&c.failCount, 1)
 			zap.L().Error("Failed to publish device status",
 				zap.String("device", deviceID),
 				zap.String("status", statusStr),
-				zap.Error(token.Error()),
+				zap.Error(err),
 				zap.String("component", "mqtt-client"),
 			)
 		} else {
@@ -927,18 +1001,21 @@ func (c *Client) PublishDeviceStatus(deviceID string, status int) {
 
 func (c *Client) Stop() {
 	close(c.stopChan)
-	if c.client != nil && c.client.IsConnected() {
+	if sess := c.session(); sess != nil && sess.IsConnected() {
 		// Publish Offline Status (Graceful)
 		c.configMu.RLock()
 		statusTopic := c.config.StatusTopic
 		topic := c.config.Topic
 		offlinePayload := c.config.OfflinePayload
 		clientID := c.config.ClientID
+		statusOpts := topicOptions(c.config, TopicKindStatus)
 		c.configMu.RUnlock()
 
 		if statusTopic == "" && topic != "" {
 			statusTopic = topic + "/status"
 		}
+		// Same topic as the online status published on connect
+		statusTopic = c.replaceGlobalVars(statusTopic)
 
 		if statusTopic != "" {
 			if offlinePayload == "" {
@@ -952,11 +1029,10 @@ func (c *Client) Stop() {
 			payload := c.replaceDeviceVars(offlinePayload, clientID)
 			payload = strings.ReplaceAll(payload, "{status}", "offline")
 
-			token := c.client.Publish(statusTopic, 1, true, payload)
-			token.WaitTimeout(2 * time.Second)
+			_ = sess.Publish(outMessage{topic: statusTopic, payload: []byte(payload), qos: statusOpts.QoS, retain: statusOpts.Retain})
 		}
 
-		c.client.Disconnect(250)
+		sess.Disconnect()
 	}
 	c.setStatus(StatusDisconnected)
 }
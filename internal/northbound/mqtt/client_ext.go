@@ -11,95 +11,75 @@ import (
 )
 
 func (c *Client) retryLoop() {
-	// Parse flush interval
-	interval := 1 * time.Minute
-	c.configMu.RLock()
-	if d, err := time.ParseDuration(c.config.Cache.FlushInterval); err == nil && d > 0 {
-		interval = d
-	}
-	c.configMu.RUnlock()
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	stop := c.stopChan
+	c.offline.Run(stop, func() {
+		// Check if client is enabled
+		c.configMu.RLock()
+		enable := c.config.Enable
+		c.configMu.RUnlock()
 
-	for {
-		select {
-		case <-c.stopChan:
-			return
-		case <-ticker.C:
-			// Check if client is enabled
-			c.configMu.RLock()
-			enable := c.config.Enable
-			c.configMu.RUnlock()
-
-			if enable {
-				c.flushOfflineMessages()
-			}
+		if enable {
+			c.flushOfflineMessages(stop)
 		}
-	}
+	})
 }
 
-func (c *Client) flushOfflineMessages() {
-	if c.storage == nil || c.client == nil || !c.client.IsConnected() {
-		return
-	}
+// cachedMessage is the offline queue entry; the topic is kept so that
+// messages published to rule or lifecycle topics are replayed to the same place.
+type cachedMessage struct {
+	Topic   string `json:"topic"`
+	Payload []byte `json:"payload"`
+	QoS     byte   `json:"qos"`
+	Retain  bool   `json:"retain,omitempty"`
+}
 
-	c.configMu.RLock()
-	configID := c.config.ID
-	enabled := c.config.Cache.Enable
-	c.configMu.RUnlock()
+func (c *Client) cacheMessage(m outMessage) error {
+	data, err := json.Marshal(cachedMessage{Topic: m.topic, Payload: m.payload, QoS: m.qos, Retain: m.retain})
+	if err != nil {
+		return err
+	}
+	return c.offline.Store(data)
+}
 
-	if !enabled {
-		return
+// decodeCached restores a queued message. Entries written before topics were
+// stored hold the raw payload and go to the data topic.
+func (c *Client) decodeCached(data []byte) outMessage {
+	var cm cachedMessage
+	if err := json.Unmarshal(data, &cm); err == nil && cm.Topic != "" {
+		return outMessage{topic: cm.Topic, payload: cm.Payload, qos: cm.QoS, retain: cm.Retain}
 	}
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	opts := topicOptions(c.config, TopicKindData)
+	return outMessage{topic: c.config.Topic, payload: data, qos: opts.QoS, retain: opts.Retain}
+}
 
-	// Fetch batch (limit 50 per cycle to avoid blocking too long)
-	msgs, err := c.storage.GetOfflineMessages(configID, 50)
-	if err != nil || len(msgs) == 0 {
+// flushOfflineMessages replays the offline queue oldest first and stops at
+// the first failure to preserve order.
+func (c *Client) flushOfflineMessages(stop <-chan struct{}) {
+	sess := c.session()
+	if sess == nil || !sess.IsConnected() {
 		return
 	}
 
-	zap.L().Info("Retrying offline MQTT messages", zap.String("client_id", configID), zap.Int("count", len(msgs)))
-
-	for _, msg := range msgs {
-		// Use PublishRaw directly but skip cache logic (to avoid infinite loop if fail)
-		// We use standard paho publish here
-		c.configMu.RLock()
-		topic := c.config.Topic
-		c.configMu.RUnlock()
-
-		// Note: The topic is not stored in OfflineMessage, we assume generic topic or we should have stored it?
-		// User requirement: "Store offline data". Usually payload.
-		// If payload doesn't contain topic, we use default topic.
-		// If PublishRaw was called with a specific topic (e.g. from Edge Rule), we might lose it if we don't store it.
-		// However, boltdb_ext.go `SaveOfflineMessage` only takes `data`.
-		// Assumption: The payload is self-contained OR we use the default topic.
-		// Edge Rules usually specify a topic in the action config.
-		// If the user wants to support variable topics in cache, we need to store topic in the DB value.
-		// For now, I will use the default topic `c.config.Topic`.
-		// If this is a limitation, I'll need to update `SaveOfflineMessage` to accept a struct/wrapper.
-		// Let's assume standard data reporting uses default topic.
-
-		token := c.client.Publish(topic, 0, false, msg.Data)
-		if token.Wait() && token.Error() == nil {
-			// Success -> Delete
-			c.storage.RemoveOfflineMessage(msg.Key)
-			atomic.AddInt64(&c.successCount, 1)
-		} else {
-			// Fail -> Stop retry loop for this cycle to preserve order
+	n, err := c.offline.Replay(stop, func(data []byte) error {
+		if err := sess.Publish(c.decodeCached(data)); err != nil {
 			atomic.AddInt64(&c.failCount, 1)
-			break
+			return err
 		}
+		atomic.AddInt64(&c.successCount, 1)
+		return nil
+	})
+	if n > 0 || err != nil {
+		c.configMu.RLock()
+		id := c.config.ID
+		c.configMu.RUnlock()
+		zap.L().Info("Replayed offline MQTT messages", zap.String("client_id", id), zap.Int("count", n), zap.Error(err))
 	}
 }
 
 // PublishDeviceLifecycle publishes device add/remove events
 func (c *Client) PublishDeviceLifecycle(event string, device model.Device) {
-	if c.client == nil || !c.client.IsConnected() {
-		// Should we cache this? Probably yes.
-		// But let's follow standard flow.
-	}
-
 	c.configMu.RLock()
 	topic := c.config.DeviceLifecycleTopic
 	baseTopic := c.config.Topic
@@ -124,5 +104,5 @@ func (c *Client) PublishDeviceLifecycle(event string, device model.Device) {
 
 	data, _ := json.Marshal(payloadMap)
 
-	c.PublishRaw(topic, data)
+	c.publishRaw(TopicKindLifecycle, outMessage{topic: topic, payload: data})
 }
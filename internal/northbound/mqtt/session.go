@@ -0,0 +1,525 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
+
+	"github.com/eclipse/paho.golang/packets"
+	paho5 "github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Topic kinds for per-topic QoS/retain (MQTTConfig.TopicOptions keys).
+const (
+	TopicKindData          = "data"
+	TopicKindStatus        = "status"
+	TopicKindDeviceStatus  = "device_status"
+	TopicKindLifecycle     = "lifecycle"
+	TopicKindWriteResponse = "write_response"
+	TopicKindSubscribe     = "subscribe"
+)
+
+var topicKinds = map[string]bool{
+	TopicKindData:          true,
+	TopicKindStatus:        true,
+	TopicKindDeviceStatus:  true,
+	TopicKindLifecycle:     true,
+	TopicKindWriteResponse: true,
+	TopicKindSubscribe:     true,
+}
+
+const (
+	connectTimeout = 10 * time.Second
+	publishTimeout = 10 * time.Second
+)
+
+// outMessage is one publish. The response fields are sent as MQTT 5 properties
+// and ignored by 3.x sessions.
+type outMessage struct {
+	topic           string
+	payload         []byte
+	qos             byte
+	retain          bool
+	responseTopic   string
+	correlationData []byte
+}
+
+// inMessage is one received publish.
+type inMessage struct {
+	topic           string
+	payload         []byte
+	responseTopic   string
+	correlationData []byte
+	userProperties  map[string]string
+}
+
+// sessionOptions is the connection part of an MQTTConfig, resolved for a session.
+type sessionOptions struct {
+	broker         string
+	clientID       string
+	username       string
+	password       string
+	version        int
+	cleanSession   bool
+	keepAlive      time.Duration
+	tls            *tls.Config
+	will           *outMessage
+	userProperties map[string]string
+	messageExpiry  uint32
+	sessionExpiry  uint32
+
+	onConnect        func()
+	onConnectionLost func(error)
+}
+
+// session hides the MQTT 3.1/3.1.1 and MQTT 5 client libraries. Connect may be
+// called again after a connection loss.
+type session interface {
+	Connect() error
+	IsConnected() bool
+	Publish(m outMessage) error
+	Subscribe(topic string, qos byte, handler func(inMessage)) error
+	Disconnect()
+}
+
+func newSession(o sessionOptions) session {
+	if o.version == 5 {
+		return &v5Session{opts: o}
+	}
+	return newV3Session(o)
+}
+
+// topicOptions resolves QoS/retain for a topic kind. Status messages keep the
+// historical QoS 1 + retain unless overridden; write responses are never retained
+// by default.
+func topicOptions(cfg model.MQTTConfig, kind string) model.MQTTTopicOptions {
+	if opt, ok := cfg.TopicOptions[kind]; ok {
+		return opt
+	}
+	switch kind {
+	case TopicKindStatus:
+		return model.MQTTTopicOptions{QoS: max(cfg.QoS, 1), Retain: true}
+	case TopicKindWriteResponse, TopicKindSubscribe:
+		return model.MQTTTopicOptions{QoS: cfg.QoS}
+	}
+	return model.MQTTTopicOptions{QoS: cfg.QoS, Retain: cfg.Retain}
+}
+
+func protocolVersion(cfg model.MQTTConfig) int {
+	if cfg.ProtocolVersion == 0 {
+		return 4
+	}
+	return cfg.ProtocolVersion
+}
+
+func buildSessionOptions(cfg model.MQTTConfig) (sessionOptions, error) {
+	tlsCfg, err := buildTLSConfig(cfg.TLS)
+	if err != nil {
+		return sessionOptions{}, err
+	}
+	o := sessionOptions{
+		broker:         brokerURL(cfg.Broker, tlsCfg != nil),
+		clientID:       cfg.ClientID,
+		username:       cfg.Username,
+		password:       cfg.Password,
+		version:        protocolVersion(cfg),
+		cleanSession:   cfg.CleanSession == nil || *cfg.CleanSession,
+		keepAlive:      30 * time.Second,
+		tls:            tlsCfg,
+		userProperties: cfg.UserProperties,
+		messageExpiry:  cfg.MessageExpiry,
+		sessionExpiry:  cfg.SessionExpiry,
+	}
+	if cfg.KeepAlive > 0 {
+		o.keepAlive = time.Duration(cfg.KeepAlive) * time.Second
+	}
+	return o, nil
+}
+
+// brokerURL adds a missing scheme and switches plain TCP to TLS when TLS is enabled.
+func brokerURL(broker string, useTLS bool) string {
+	broker = strings.TrimSpace(broker)
+	if broker == "" {
+		return ""
+	}
+	if !strings.Contains(broker, "://") {
+		broker = "tcp://" + broker
+	}
+	if useTLS {
+		for _, plain := range []string{"tcp://", "mqtt://"} {
+			if strings.HasPrefix(broker, plain) {
+				return "ssl://" + strings.TrimPrefix(broker, plain)
+			}
+		}
+	}
+	return broker
+}
+
+// buildTLSConfig returns nil when TLS is disabled.
+func buildTLSConfig(cfg model.MQTTTLSConfig) (*tls.Config, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if strings.TrimSpace(cfg.CACertPEM) != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACertPEM)) {
+			return nil, errors.New("MQTT TLS CA 证书无效")
+		}
+		tlsCfg.RootCAs = pool
+	}
+	hasCert := strings.TrimSpace(cfg.ClientCertPEM) != ""
+	hasKey := strings.TrimSpace(cfg.ClientKeyPEM) != ""
+	if hasCert != hasKey {
+		return nil, errors.New("MQTT TLS 客户端证书与私钥必须同时配置")
+	}
+	if hasCert {
+		pair, err := tls.X509KeyPair([]byte(cfg.ClientCertPEM), []byte(cfg.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("MQTT TLS 客户端证书无效: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{pair}
+	}
+	return tlsCfg, nil
+}
+
+// ValidateConfig reports connection, TLS and payload errors in cfg.
+func ValidateConfig(cfg model.MQTTConfig) error {
+	switch cfg.ProtocolVersion {
+	case 0, 3, 4, 5:
+	default:
+		return fmt.Errorf("MQTT 协议版本无效: %d", cfg.ProtocolVersion)
+	}
+	if cfg.QoS > 2 {
+		return fmt.Errorf("MQTT QoS 无效: %d", cfg.QoS)
+	}
+	for kind, opt := range cfg.TopicOptions {
+		if !topicKinds[kind] {
+			return fmt.Errorf("MQTT Topic 类型无效: %q", kind)
+		}
+		if opt.QoS > 2 {
+			return fmt.Errorf("MQTT %s QoS 无效: %d", kind, opt.QoS)
+		}
+	}
+	tlsCfg, err := buildTLSConfig(cfg.TLS)
+	if err != nil {
+		return err
+	}
+	if protocolVersion(cfg) == 5 && cfg.Broker != "" {
+		if _, err := dialAddress(brokerURL(cfg.Broker, tlsCfg != nil)); err != nil {
+			return err
+		}
+	}
+	if err := offline.ValidateConfig(cfg.Cache); err != nil {
+		return err
+	}
+	return ValidatePayloadConfig(cfg)
+}
+
+// v3Session wraps paho.mqtt.golang for MQTT 3.1 and 3.1.1.
+type v3Session struct {
+	client mqtt.Client
+}
+
+func newV3Session(o sessionOptions) *v3Session {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(o.broker)
+	opts.SetClientID(o.clientID)
+	if o.username != "" {
+		opts.SetUsername(o.username)
+		opts.SetPassword(o.password)
+	}
+	opts.SetProtocolVersion(uint(o.version))
+	opts.SetCleanSession(o.cleanSession)
+	opts.SetKeepAlive(o.keepAlive)
+	opts.SetConnectTimeout(connectTimeout)
+	// Reconnects are driven by the client's own backoff.
+	opts.SetAutoReconnect(false)
+	// Handlers publish responses and may wait for QoS 1 acks.
+	opts.SetOrderMatters(false)
+	if o.tls != nil {
+		opts.SetTLSConfig(o.tls)
+	}
+	if o.will != nil {
+		opts.SetBinaryWill(o.will.topic, o.will.payload, o.will.qos, o.will.retain)
+	}
+	if o.onConnect != nil {
+		opts.SetOnConnectHandler(func(mqtt.Client) { o.onConnect() })
+	}
+	if o.onConnectionLost != nil {
+		opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) { o.onConnectionLost(err) })
+	}
+	return &v3Session{client: mqtt.NewClient(opts)}
+}
+
+func (s *v3Session) Connect() error {
+	token := s.client.Connect()
+	token.Wait()
+	return token.Error()
+}
+
+func (s *v3Session) IsConnected() bool {
+	return s.client.IsConnected()
+}
+
+func (s *v3Session) Publish(m outMessage) error {
+	token := s.client.Publish(m.topic, m.qos, m.retain, m.payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return fmt.Errorf("publish to %s timed out", m.topic)
+	}
+	return token.Error()
+}
+
+func (s *v3Session) Subscribe(topic string, qos byte, handler func(inMessage)) error {
+	token := s.client.Subscribe(topic, qos, func(_ mqtt.Client, msg mqtt.Message) {
+		handler(inMessage{topic: msg.Topic(), payload: msg.Payload()})
+	})
+	token.Wait()
+	return token.Error()
+}
+
+func (s *v3Session) Disconnect() {
+	s.client.Disconnect(250)
+}
+
+// v5Session wraps paho.golang for MQTT 5. Each Connect dials a new network
+// connection; WebSocket brokers are not supported.
+type v5Session struct {
+	opts sessionOptions
+
+	mu        sync.Mutex
+	client    *paho5.Client
+	handler   func(inMessage)
+	connected atomic.Bool
+}
+
+// brokerTarget is the dial address of a broker URL.
+type brokerTarget struct {
+	addr   string
+	useTLS bool
+}
+
+// dialAddress maps a broker URL to host:port and whether TLS is used.
+func dialAddress(broker string) (brokerTarget, error) {
+	var out brokerTarget
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return out, fmt.Errorf("MQTT Broker 地址无效: %q", broker)
+	}
+	port := "1883"
+	switch strings.ToLower(u.Scheme) {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "tcps", "mqtts":
+		out.useTLS = true
+		port = "8883"
+	default:
+		return out, fmt.Errorf("MQTT 5 Broker 协议无效（仅支持 tcp/ssl）: %q", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	out.addr = net.JoinHostPort(u.Hostname(), port)
+	return out, nil
+}
+
+func (s *v5Session) dial(ctx context.Context) (net.Conn, error) {
+	target, err := dialAddress(s.opts.broker)
+	if err != nil {
+		return nil, err
+	}
+	if !target.useTLS {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", target.addr)
+	}
+	tlsCfg := s.opts.tls
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	d := tls.Dialer{Config: tlsCfg}
+	return d.DialContext(ctx, "tcp", target.addr)
+}
+
+func (s *v5Session) userProperties() paho5.UserProperties {
+	var props paho5.UserProperties
+	for k, v := range s.opts.userProperties {
+		props.Add(k, v)
+	}
+	return props
+}
+
+func (s *v5Session) Connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	client := paho5.NewClient(paho5.ClientConfig{
+		ClientID:          s.opts.clientID,
+		Conn:              packets.NewThreadSafeConn(conn),
+		OnPublishReceived: []func(paho5.PublishReceived) (bool, error){s.onPublish},
+		OnServerDisconnect: func(d *paho5.Disconnect) {
+			s.lost(fmt.Errorf("server disconnect, reason code %d", d.ReasonCode))
+		},
+		OnClientError: s.lost,
+	})
+
+	cp := &paho5.Connect{
+		ClientID:   s.opts.clientID,
+		KeepAlive:  uint16(s.opts.keepAlive / time.Second),
+		CleanStart: s.opts.cleanSession,
+	}
+	if s.opts.username != "" {
+		cp.UsernameFlag = true
+		cp.Username = s.opts.username
+		cp.PasswordFlag = true
+		cp.Password = []byte(s.opts.password)
+	}
+	if s.opts.sessionExpiry > 0 && !s.opts.cleanSession {
+		expiry := s.opts.sessionExpiry
+		cp.Properties = &paho5.ConnectProperties{SessionExpiryInterval: &expiry}
+	}
+	if w := s.opts.will; w != nil {
+		cp.WillMessage = &paho5.WillMessage{Topic: w.topic, Payload: w.payload, QoS: w.qos, Retain: w.retain}
+	}
+
+	ack, err := client.Connect(ctx, cp)
+	if err != nil {
+		conn.Close()
+		if ack != nil && ack.ReasonCode != 0 {
+			return fmt.Errorf("connect refused, reason code %d: %w", ack.ReasonCode, err)
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	s.client = client
+	s.mu.Unlock()
+	s.connected.Store(true)
+	if s.opts.onConnect != nil {
+		go s.opts.onConnect()
+	}
+	return nil
+}
+
+// lost reports a connection loss once per connection.
+func (s *v5Session) lost(err error) {
+	if s.connected.CompareAndSwap(true, false) && s.opts.onConnectionLost != nil {
+		s.opts.onConnectionLost(err)
+	}
+}
+
+func (s *v5Session) IsConnected() bool {
+	return s.connected.Load()
+}
+
+func (s *v5Session) current() *paho5.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+func (s *v5Session) Publish(m outMessage) error {
+	client := s.current()
+	if client == nil || !s.IsConnected() {
+		return mqtt.ErrNotConnected
+	}
+	props := &paho5.PublishProperties{
+		User:            s.userProperties(),
+		ResponseTopic:   m.responseTopic,
+		CorrelationData: m.correlationData,
+	}
+	if s.opts.messageExpiry > 0 {
+		expiry := s.opts.messageExpiry
+		props.MessageExpiry = &expiry
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	resp, err := client.Publish(ctx, &paho5.Publish{
+		Topic:      m.topic,
+		QoS:        m.qos,
+		Retain:     m.retain,
+		Payload:    m.payload,
+		Properties: props,
+	})
+	if err != nil {
+		return err
+	}
+	if resp != nil && resp.ReasonCode >= 0x80 {
+		return fmt.Errorf("publish to %s rejected, reason code %d", m.topic, resp.ReasonCode)
+	}
+	return nil
+}
+
+func (s *v5Session) Subscribe(topic string, qos byte, handler func(inMessage)) error {
+	client := s.current()
+	if client == nil || !s.IsConnected() {
+		return mqtt.ErrNotConnected
+	}
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	ack, err := client.Subscribe(ctx, &paho5.Subscribe{
+		Subscriptions: []paho5.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(ack.Reasons) > 0 && ack.Reasons[0] >= 0x80 {
+		return fmt.Errorf("subscribe to %s rejected, reason code %d", topic, ack.Reasons[0])
+	}
+	return nil
+}
+
+func (s *v5Session) onPublish(pr paho5.PublishReceived) (bool, error) {
+	s.mu.Lock()
+	handler := s.handler
+	s.mu.Unlock()
+	if handler == nil {
+		return false, nil
+	}
+	m := inMessage{topic: pr.Packet.Topic, payload: pr.Packet.Payload}
+	if p := pr.Packet.Properties; p != nil {
+		m.responseTopic = p.ResponseTopic
+		m.correlationData = p.CorrelationData
+		if len(p.User) > 0 {
+			m.userProperties = make(map[string]string, len(p.User))
+			for _, u := range p.User {
+				m.userProperties[u.Key] = u.Value
+			}
+		}
+	}
+	// The handler publishes a response; running it inline would block ack processing.
+	go handler(m)
+	return true, nil
+}
+
+func (s *v5Session) Disconnect() {
+	client := s.current()
+	if client == nil {
+		return
+	}
+	// Clear the flag first so the resulting client error is not reported as a loss.
+	s.connected.Store(false)
+	_ = client.Disconnect(&paho5.Disconnect{ReasonCode: 0})
+}
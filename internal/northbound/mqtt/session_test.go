@@ -0,0 +1,218 @@
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func testCertPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "edgex-gw"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestBuildTLSConfigFromPEM(t *testing.T) {
+	cert, key := testCertPEM(t)
+
+	tlsCfg, err := buildTLSConfig(model.MQTTTLSConfig{Enable: true, CACertPEM: cert, ClientCertPEM: cert, ClientKeyPEM: key, ServerName: "broker"})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if tlsCfg.RootCAs == nil || len(tlsCfg.Certificates) != 1 || tlsCfg.ServerName != "broker" {
+		t.Fatalf("tls config = %+v", tlsCfg)
+	}
+	if tlsCfg, _ := buildTLSConfig(model.MQTTTLSConfig{CACertPEM: cert}); tlsCfg != nil {
+		t.Fatal("disabled TLS must yield nil config")
+	}
+
+	bad := map[string]model.MQTTTLSConfig{
+		"ca":       {Enable: true, CACertPEM: "not a pem"},
+		"cert":     {Enable: true, ClientCertPEM: cert},
+		"mismatch": {Enable: true, ClientCertPEM: cert, ClientKeyPEM: "bad"},
+	}
+	for name, c := range bad {
+		if _, err := buildTLSConfig(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBrokerURL(t *testing.T) {
+	cases := []struct {
+		broker string
+		tls    bool
+		want   string
+	}{
+		{"localhost:1883", false, "tcp://localhost:1883"},
+		{"tcp://broker:8883", true, "ssl://broker:8883"},
+		{"mqtt://broker", true, "ssl://broker"},
+		{"ws://broker/mqtt", true, "ws://broker/mqtt"},
+	}
+	for _, c := range cases {
+		if got := brokerURL(c.broker, c.tls); got != c.want {
+			t.Errorf("brokerURL(%q, %v) = %q, want %q", c.broker, c.tls, got, c.want)
+		}
+	}
+
+	target, err := dialAddress("ssl://broker")
+	if err != nil || target.addr != "broker:8883" || !target.useTLS {
+		t.Fatalf("dialAddress = %+v, %v", target, err)
+	}
+}
+
+func TestTopicOptionsDefaultsAndOverrides(t *testing.T) {
+	cfg := model.MQTTConfig{QoS: 1, Retain: true, TopicOptions: map[string]model.MQTTTopicOptions{
+		TopicKindDeviceStatus: {QoS: 2, Retain: false},
+	}}
+	if got := topicOptions(cfg, TopicKindData); got != (model.MQTTTopicOptions{QoS: 1, Retain: true}) {
+		t.Errorf("data = %+v", got)
+	}
+	if got := topicOptions(cfg, TopicKindDeviceStatus); got != (model.MQTTTopicOptions{QoS: 2}) {
+		t.Errorf("device_status = %+v", got)
+	}
+	if got := topicOptions(cfg, TopicKindWriteResponse); got.Retain {
+		t.Errorf("write_response must not be retained by default: %+v", got)
+	}
+	// Status keeps the historical QoS 1 + retain on a QoS 0 connection.
+	if got := topicOptions(model.MQTTConfig{}, TopicKindStatus); got != (model.MQTTTopicOptions{QoS: 1, Retain: true}) {
+		t.Errorf("status = %+v", got)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := map[string]model.MQTTConfig{
+		"version":    {ProtocolVersion: 6},
+		"qos":        {QoS: 3},
+		"topic kind": {TopicOptions: map[string]model.MQTTTopicOptions{"alarm": {}}},
+		"topic qos":  {TopicOptions: map[string]model.MQTTTopicOptions{TopicKindData: {QoS: 3}}},
+		"tls":        {TLS: model.MQTTTLSConfig{Enable: true, CACertPEM: "x"}},
+		"v5 ws":      {ProtocolVersion: 5, Broker: "ws://broker:8083/mqtt"},
+	}
+	for name, cfg := range cases {
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := ValidateConfig(model.MQTTConfig{ProtocolVersion: 5, Broker: "tcp://broker:1883", QoS: 1}); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+}
+
+func TestNeedsReconnect(t *testing.T) {
+	base := model.MQTTConfig{Broker: "tcp://b:1883"}
+
+	changed := base
+	changed.TopicOptions = map[string]model.MQTTTopicOptions{TopicKindData: {QoS: 1, Retain: true}}
+	if needsReconnect(base, changed) {
+		t.Error("data QoS/retain is applied per publish and must not reconnect")
+	}
+	changed = base
+	changed.QoS = 1
+	if !needsReconnect(base, changed) {
+		t.Error("default QoS change must reconnect to resubscribe")
+	}
+	changed = base
+	changed.TLS.Enable = true
+	if !needsReconnect(base, changed) {
+		t.Error("TLS change must reconnect")
+	}
+	changed = base
+	changed.UserProperties = map[string]string{"site": "a"}
+	if !needsReconnect(base, changed) {
+		t.Error("user property change must reconnect")
+	}
+}
+
+type fakeSession struct {
+	mu        sync.Mutex
+	published []outMessage
+}
+
+func (f *fakeSession) Connect() error    { return nil }
+func (f *fakeSession) IsConnected() bool { return true }
+func (f *fakeSession) Disconnect()       {}
+func (f *fakeSession) Subscribe(string, byte, func(inMessage)) error {
+	return nil
+}
+func (f *fakeSession) Publish(m outMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, m)
+	return nil
+}
+
+type fakeWriter struct {
+	model.SouthboundManager
+	writes map[string]any
+}
+
+func (f *fakeWriter) WritePoint(channelID, deviceID, pointID string, value any) error {
+	f.writes[pointID] = value
+	return nil
+}
+
+func TestWriteRequestUsesMQTT5ResponseTopicAndCorrelation(t *testing.T) {
+	sb := &fakeWriter{writes: map[string]any{}}
+	c := NewClient(model.MQTTConfig{
+		Enable:             true,
+		WriteResponseTopic: "edgex/resp",
+		TopicOptions:       map[string]model.MQTTTopicOptions{TopicKindWriteResponse: {QoS: 2}},
+	}, sb, nil)
+	sess := &fakeSession{}
+	c.sess = sess
+
+	c.handleWriteRequest(inMessage{
+		topic:           "edgex/req",
+		payload:         []byte(`{"group":"ch-1","node":"dev-1","values":{"sp":5}}`),
+		responseTopic:   "app/replies/42",
+		correlationData: []byte("req-42"),
+	})
+
+	if sb.writes["sp"] != float64(5) {
+		t.Fatalf("writes = %v", sb.writes)
+	}
+	if len(sess.published) != 1 {
+		t.Fatalf("published = %d, want 1 response", len(sess.published))
+	}
+	resp := sess.published[0]
+	if resp.topic != "app/replies/42" || string(resp.correlationData) != "req-42" || resp.qos != 2 || resp.retain {
+		t.Fatalf("response = %+v", resp)
+	}
+	var body WriteResponse
+	if err := json.Unmarshal(resp.payload, &body); err != nil || !body.Success {
+		t.Fatalf("body = %s, %v", resp.payload, err)
+	}
+}
@@ -0,0 +1,218 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	nbtemplate "github.com/anviod/edgex/internal/northbound/template"
+)
+
+// Message is one rendered MQTT message.
+type Message struct {
+	Topic   string `json:"topic"`
+	Payload []byte `json:"-"`
+}
+
+// deviceSnapshot is the input of payloadFormat.render.
+type deviceSnapshot struct {
+	clientID   string
+	channelID  string
+	deviceID   string
+	deviceName string
+	time       time.Time
+	values     []model.Value
+	points     map[string]nbtemplate.PointInfo
+}
+
+// payloadFormat is the compiled form of the payload options of an MQTTConfig.
+type payloadFormat struct {
+	configID    string
+	topic       string
+	topicTmpl   *template.Template
+	payloadTmpl *template.Template
+	perPoint    bool
+	format      nbtemplate.Format
+	includeUnit bool
+}
+
+func newPayloadFormat(cfg model.MQTTConfig) (*payloadFormat, error) {
+	f := &payloadFormat{
+		configID:    cfg.ID,
+		topic:       cfg.Topic,
+		format:      nbtemplate.Format{TimestampFormat: cfg.TimestampFormat, QualityMap: cfg.QualityMap},
+		includeUnit: cfg.IncludeUnit,
+	}
+
+	switch cfg.PayloadMode {
+	case "", "device":
+	case "point":
+		f.perPoint = true
+	default:
+		return nil, fmt.Errorf("MQTT 负载模式无效: %q", cfg.PayloadMode)
+	}
+
+	if strings.Contains(cfg.Topic, "{{") {
+		t, err := nbtemplate.Parse("topic", cfg.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("MQTT Topic 模板无效: %w", err)
+		}
+		f.topicTmpl = t
+	}
+	if strings.TrimSpace(cfg.PayloadTemplate) != "" {
+		t, err := nbtemplate.Parse("payload", cfg.PayloadTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("MQTT 负载模板无效: %w", err)
+		}
+		f.payloadTmpl = t
+	}
+	return f, nil
+}
+
+// ValidatePayloadConfig reports template or option errors in cfg.
+func ValidatePayloadConfig(cfg model.MQTTConfig) error {
+	_, err := newPayloadFormat(cfg)
+	return err
+}
+
+// render builds the messages for one device flush.
+func (f *payloadFormat) render(s deviceSnapshot) ([]Message, error) {
+	values := append([]model.Value(nil), s.values...)
+	sort.Slice(values, func(i, j int) bool { return values[i].PointID < values[j].PointID })
+
+	if !f.perPoint {
+		msg, err := f.renderOne(s, values)
+		if err != nil {
+			return nil, err
+		}
+		return []Message{msg}, nil
+	}
+
+	msgs := make([]Message, 0, len(values))
+	for i := range values {
+		msg, err := f.renderOne(s, values[i:i+1])
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (f *payloadFormat) renderOne(s deviceSnapshot, values []model.Value) (Message, error) {
+	ts := s.time
+	if f.perPoint && !values[0].TS.IsZero() {
+		ts = values[0].TS
+	}
+	device := f.format.NewDevice(s.channelID, s.deviceID, s.deviceName, ts, values, s.points)
+	data := nbtemplate.NewData(f.configID, s.clientID, []nbtemplate.Device{device})
+
+	topic, err := f.renderTopic(&data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var payload []byte
+	if f.payloadTmpl != nil {
+		payload, err = nbtemplate.Execute(f.payloadTmpl, &data)
+		if err != nil {
+			return Message{}, fmt.Errorf("render payload template: %w", err)
+		}
+	} else {
+		payload, err = json.Marshal(f.defaultPayload(&data))
+		if err != nil {
+			return Message{}, err
+		}
+	}
+	return Message{Topic: topic, Payload: payload}, nil
+}
+
+func (f *payloadFormat) renderTopic(data *nbtemplate.Data) (string, error) {
+	if f.topicTmpl != nil {
+		topic, err := nbtemplate.Execute(f.topicTmpl, data)
+		if err != nil {
+			return "", fmt.Errorf("render topic template: %w", err)
+		}
+		return strings.TrimSpace(string(topic)), nil
+	}
+	if !strings.Contains(f.topic, "{") {
+		return f.topic, nil
+	}
+	return strings.NewReplacer(
+		"{client_id}", data.ClientID,
+		"{channel_id}", data.ChannelID,
+		"{device_id}", data.DeviceID,
+		"{device_name}", data.DeviceName,
+		"{point_id}", data.Point.ID,
+		"{timestamp}", strconv.FormatInt(data.Time.UnixMilli(), 10),
+	).Replace(f.topic), nil
+}
+
+// defaultPayload is the legacy AggregatedPayload shape.
+func (f *payloadFormat) defaultPayload(data *nbtemplate.Data) *AggregatedPayload {
+	payload := &AggregatedPayload{
+		Timestamp: data.Timestamp,
+		Node:      data.DeviceID,
+		Group:     data.ChannelID,
+		Values:    make(map[string]any, len(data.Points)),
+		Errors:    make(map[string]any),
+		Metas:     make(map[string]any),
+	}
+	for _, p := range data.Points {
+		payload.Values[p.ID] = p.Value
+		if !p.Good {
+			payload.Errors[p.ID] = p.Quality
+		}
+		if len(p.Meta) > 0 {
+			payload.Metas[p.ID] = p.Meta
+		}
+		if f.includeUnit && p.Unit != "" {
+			if payload.Units == nil {
+				payload.Units = make(map[string]string)
+			}
+			payload.Units[p.ID] = p.Unit
+		}
+	}
+	return payload
+}
+
+// Preview renders the messages cfg would publish for points, as read from
+// the device shadow.
+func Preview(cfg model.MQTTConfig, channelID, deviceID, deviceName string, points []model.PointData) ([]Message, error) {
+	f, err := newPayloadFormat(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := deviceSnapshot{
+		clientID:   cfg.ClientID,
+		channelID:  channelID,
+		deviceID:   deviceID,
+		deviceName: deviceName,
+		time:       time.Now(),
+		points:     make(map[string]nbtemplate.PointInfo, len(points)),
+	}
+	for _, p := range points {
+		ts := p.CollectedAt
+		if ts.IsZero() {
+			ts = p.Timestamp
+		}
+		s.values = append(s.values, model.Value{
+			ChannelID: channelID,
+			DeviceID:  deviceID,
+			PointID:   p.ID,
+			Value:     p.Value,
+			Quality:   p.Quality,
+			TS:        ts,
+		})
+		s.points[p.ID] = nbtemplate.PointInfo{Name: p.Name, Unit: p.Unit}
+	}
+	if len(s.values) == 0 {
+		return nil, fmt.Errorf("device %s has no point data", deviceID)
+	}
+	return f.render(s)
+}
@@ -0,0 +1,132 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	nbtemplate "github.com/anviod/edgex/internal/northbound/template"
+)
+
+func testSnapshot() deviceSnapshot {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	return deviceSnapshot{
+		clientID:  "gw-1",
+		channelID: "ch-1",
+		deviceID:  "dev-1",
+		time:      ts,
+		values: []model.Value{
+			{PointID: "temp", Value: 21.5, Quality: "Good", TS: ts},
+			{PointID: "hum", Value: 40, Quality: "Bad", TS: ts.Add(time.Second)},
+		},
+		points: map[string]nbtemplate.PointInfo{"temp": {Name: "Temperature", Unit: "°C"}},
+	}
+}
+
+func TestDefaultPayloadKeepsAggregatedShape(t *testing.T) {
+	f, err := newPayloadFormat(model.MQTTConfig{Topic: "edgex/{client_id}/{channel_id}/{device_id}", IncludeUnit: true})
+	if err != nil {
+		t.Fatalf("newPayloadFormat: %v", err)
+	}
+	msgs, err := f.render(testSnapshot())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Topic != "edgex/gw-1/ch-1/dev-1" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(msgs[0].Payload, &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload["timestamp"] != float64(1767323045678) || payload["node"] != "dev-1" || payload["group"] != "ch-1" {
+		t.Fatalf("payload = %v", payload)
+	}
+	if payload["errors"].(map[string]any)["hum"] != "Bad" {
+		t.Fatalf("errors = %v", payload["errors"])
+	}
+	if payload["units"].(map[string]any)["temp"] != "°C" {
+		t.Fatalf("units = %v", payload["units"])
+	}
+}
+
+func TestPerPointTemplateWithQualityMapAndTimestampFormat(t *testing.T) {
+	f, err := newPayloadFormat(model.MQTTConfig{
+		Topic:           "plant/{device_id}/{point_id}",
+		PayloadMode:     "point",
+		TimestampFormat: "rfc3339",
+		QualityMap:      map[string]string{"Good": "192", "Bad": "0"},
+		PayloadTemplate: `{"ts":{{json .Timestamp}},"name":{{json .Point.Name}},"v":{{json .Point.Value}},"q":{{.Point.Quality}},"unit":{{json .Point.Unit}}}`,
+	})
+	if err != nil {
+		t.Fatalf("newPayloadFormat: %v", err)
+	}
+	msgs, err := f.render(testSnapshot())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("msgs = %d, want one per point", len(msgs))
+	}
+	// Points are sorted by ID.
+	if msgs[0].Topic != "plant/dev-1/hum" || string(msgs[0].Payload) != `{"ts":"2026-01-02T03:04:06Z","name":"hum","v":40,"q":0,"unit":""}` {
+		t.Fatalf("msg[0] = %s %s", msgs[0].Topic, msgs[0].Payload)
+	}
+	if msgs[1].Topic != "plant/dev-1/temp" || string(msgs[1].Payload) != `{"ts":"2026-01-02T03:04:05Z","name":"Temperature","v":21.5,"q":192,"unit":"°C"}` {
+		t.Fatalf("msg[1] = %s %s", msgs[1].Topic, msgs[1].Payload)
+	}
+}
+
+func TestDeviceTemplateAndTopicTemplate(t *testing.T) {
+	f, err := newPayloadFormat(model.MQTTConfig{
+		Topic:           `{{if eq .ChannelID "ch-1"}}line1{{else}}other{{end}}/{{lower .DeviceID}}`,
+		TimestampFormat: "s",
+		PayloadTemplate: `{"ts":{{.Timestamp}},"values":{ {{- range $i, $p := .Points}}{{json $p.ID}}:{{json $p.Value}}{{if not (last $i $.Points)}},{{end}}{{end -}} }}`,
+	})
+	if err != nil {
+		t.Fatalf("newPayloadFormat: %v", err)
+	}
+	msgs, err := f.render(testSnapshot())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if msgs[0].Topic != "line1/dev-1" {
+		t.Fatalf("topic = %q", msgs[0].Topic)
+	}
+	if string(msgs[0].Payload) != `{"ts":1767323045,"values":{"hum":40,"temp":21.5}}` {
+		t.Fatalf("payload = %s", msgs[0].Payload)
+	}
+}
+
+func TestPayloadConfigValidation(t *testing.T) {
+	cases := map[string]model.MQTTConfig{
+		"mode":     {PayloadMode: "batch"},
+		"payload":  {PayloadTemplate: "{{.Values"},
+		"topic":    {Topic: "a/{{.DeviceID"},
+		"function": {PayloadTemplate: "{{nope .DeviceID}}"},
+	}
+	for name, cfg := range cases {
+		if err := ValidatePayloadConfig(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPreviewUsesShadowPoints(t *testing.T) {
+	ts := time.UnixMilli(1000)
+	msgs, err := Preview(model.MQTTConfig{Topic: "t/{device_id}", ClientID: "gw"}, "ch-1", "dev-1", "Boiler", []model.PointData{
+		{ID: "p1", Name: "Pressure", Value: 1.2, Quality: "Good", CollectedAt: ts, Unit: "bar"},
+	})
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Topic != "t/dev-1" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+
+	if _, err := Preview(model.MQTTConfig{}, "ch-1", "dev-1", "", nil); err == nil {
+		t.Fatal("expected error for a device without points")
+	}
+}
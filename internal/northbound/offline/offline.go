@@ -0,0 +1,244 @@
+// Package offline implements the store-and-forward buffer shared by the
+// northbound clients: one durable queue per connector with ordered,
+// rate-limited replay, per-message TTL and a disk quota.
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+const (
+	defaultMaxCount      = 1000
+	defaultFlushInterval = time.Minute
+	replayPage           = 50
+)
+
+var (
+	// ErrDisabled is returned by Store when caching is off or no storage is attached.
+	ErrDisabled = errors.New("offline cache disabled")
+	// ErrDiscard may be returned (or wrapped) by a replay send function to drop a
+	// message that can never be delivered instead of blocking the queue.
+	ErrDiscard = errors.New("offline message discarded")
+)
+
+// ValidateConfig checks the cache settings shared by all northbound clients.
+func ValidateConfig(cfg model.DataCacheConfig) error {
+	if cfg.FlushInterval != "" {
+		if d, err := time.ParseDuration(cfg.FlushInterval); err != nil || d <= 0 {
+			return fmt.Errorf("离线缓存重试间隔无效: %q", cfg.FlushInterval)
+		}
+	}
+	if cfg.TTL != "" {
+		if d, err := time.ParseDuration(cfg.TTL); err != nil || d <= 0 {
+			return fmt.Errorf("离线缓存 TTL 无效: %q", cfg.TTL)
+		}
+	}
+	if cfg.MaxCount < 0 || cfg.MaxBytes < 0 || cfg.ReplayRate < 0 {
+		return errors.New("离线缓存条数、字节配额与回放速率不能为负数")
+	}
+	return nil
+}
+
+// Buffer is the offline queue of one northbound connector.
+type Buffer struct {
+	queue *storage.OfflineQueue
+
+	mu  sync.RWMutex
+	cfg model.DataCacheConfig
+
+	wake      chan struct{}
+	reconfig  chan struct{}
+	replaying atomic.Bool
+	replayed  atomic.Int64
+	discarded atomic.Int64
+}
+
+// New returns the buffer for connector id. A nil storage yields a buffer that
+// is never enabled.
+func New(s *storage.Storage, id string, cfg model.DataCacheConfig) *Buffer {
+	b := &Buffer{cfg: cfg, wake: make(chan struct{}, 1), reconfig: make(chan struct{}, 1)}
+	if s != nil {
+		b.queue = s.OfflineQueue(id)
+	}
+	return b
+}
+
+// SetConfig applies new cache settings; queued messages are kept. A running
+// Run loop picks up a changed flush interval.
+func (b *Buffer) SetConfig(cfg model.DataCacheConfig) {
+	b.mu.Lock()
+	b.cfg = cfg
+	b.mu.Unlock()
+	select {
+	case b.reconfig <- struct{}{}:
+	default:
+	}
+}
+
+// Enabled reports whether messages should be cached.
+func (b *Buffer) Enabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.queue != nil && b.cfg.Enable
+}
+
+// Pending reports whether messages are queued. While it does, owners must
+// store new messages behind them instead of sending them directly, or a new
+// message would overtake older ones still waiting for replay.
+func (b *Buffer) Pending() bool {
+	return b.Enabled() && b.queue.Stats().Count > 0
+}
+
+// Kick asks the owner's Run loop to flush before its next tick.
+func (b *Buffer) Kick() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// FlushInterval returns how often the owner should call Replay.
+func (b *Buffer) FlushInterval() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if d, err := time.ParseDuration(b.cfg.FlushInterval); err == nil && d > 0 {
+		return d
+	}
+	return defaultFlushInterval
+}
+
+// Run calls flush every FlushInterval and after each Kick until stop is
+// closed. The ticker follows flush interval changes made by SetConfig.
+func (b *Buffer) Run(stop <-chan struct{}, flush func()) {
+	interval := b.FlushInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-b.reconfig:
+			if d := b.FlushInterval(); d != interval {
+				interval = d
+				ticker.Reset(d)
+			}
+			continue
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		flush()
+	}
+}
+
+// Store appends data to the queue, dropping the oldest messages when the
+// count or byte quota is exceeded.
+func (b *Buffer) Store(data []byte) error {
+	b.mu.RLock()
+	cfg := b.cfg
+	b.mu.RUnlock()
+
+	if b.queue == nil || !cfg.Enable {
+		return ErrDisabled
+	}
+	p := storage.OfflinePolicy{MaxCount: cfg.MaxCount, MaxBytes: cfg.MaxBytes}
+	if p.MaxCount <= 0 {
+		p.MaxCount = defaultMaxCount
+	}
+	if d, err := time.ParseDuration(cfg.TTL); err == nil && d > 0 {
+		p.TTL = d
+	}
+	return b.queue.Push(data, p)
+}
+
+// Replay sends queued messages oldest first at the configured rate until the
+// queue is empty, send fails or stop is closed. A send error stops the replay
+// so that ordering is kept; the message stays queued for the next attempt.
+// Only one replay runs at a time; concurrent calls return immediately.
+func (b *Buffer) Replay(stop <-chan struct{}, send func(data []byte) error) (int, error) {
+	if b.queue == nil || !b.Enabled() || !b.replaying.CompareAndSwap(false, true) {
+		return 0, nil
+	}
+	defer b.replaying.Store(false)
+
+	b.mu.RLock()
+	rate := b.cfg.ReplayRate
+	b.mu.RUnlock()
+
+	var tick <-chan time.Time
+	if rate > 0 {
+		t := time.NewTicker(time.Second / time.Duration(rate))
+		defer t.Stop()
+		tick = t.C
+	}
+
+	sent := 0
+	for {
+		msgs, err := b.queue.Peek(replayPage)
+		if err != nil || len(msgs) == 0 {
+			return sent, err
+		}
+		for _, m := range msgs {
+			if tick != nil && sent > 0 {
+				select {
+				case <-stop:
+					return sent, nil
+				case <-tick:
+				}
+			}
+			select {
+			case <-stop:
+				return sent, nil
+			default:
+			}
+
+			err := send(m.Data)
+			switch {
+			case err == nil:
+				b.replayed.Add(1)
+			case errors.Is(err, ErrDiscard):
+				b.discarded.Add(1)
+			default:
+				return sent, err
+			}
+			if err := b.queue.Ack(m.Seq); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+}
+
+// Stats returns backlog and replay metrics for the northbound stats.
+func (b *Buffer) Stats() map[string]int64 {
+	out := map[string]int64{
+		"offline_replayed":  b.replayed.Load(),
+		"offline_discarded": b.discarded.Load(),
+	}
+	if b.queue == nil {
+		return out
+	}
+	for k, v := range BacklogMetrics(b.queue.Stats()) {
+		out[k] = v
+	}
+	return out
+}
+
+// BacklogMetrics converts queue statistics to the stat keys exposed by every
+// northbound client.
+func BacklogMetrics(st storage.OfflineQueueStats) map[string]int64 {
+	return map[string]int64{
+		"backlog_count":   int64(st.Count),
+		"backlog_bytes":   st.Bytes,
+		"backlog_age_ms":  st.OldestAge.Milliseconds(),
+		"offline_dropped": st.Dropped,
+		"offline_expired": st.Expired,
+	}
+}
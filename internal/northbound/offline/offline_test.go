@@ -0,0 +1,128 @@
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+func newTestBuffer(t *testing.T, cfg model.DataCacheConfig) *Buffer {
+	t.Helper()
+	s, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return New(s, "nb", cfg)
+}
+
+func TestReplayInOrderAndStopsOnFailure(t *testing.T) {
+	b := newTestBuffer(t, model.DataCacheConfig{Enable: true})
+	for i := 0; i < 4; i++ {
+		if err := b.Store([]byte(fmt.Sprintf("m%d", i))); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+
+	var got []string
+	n, err := b.Replay(nil, func(data []byte) error {
+		switch string(data) {
+		case "m1":
+			return fmt.Errorf("bad payload: %w", ErrDiscard)
+		case "m2":
+			return errors.New("upstream down")
+		}
+		got = append(got, string(data))
+		return nil
+	})
+	if err == nil || n != 2 || len(got) != 1 || got[0] != "m0" {
+		t.Fatalf("replay = %d, %v, %v", n, got, err)
+	}
+	stats := b.Stats()
+	if stats["backlog_count"] != 2 || stats["offline_replayed"] != 1 || stats["offline_discarded"] != 1 {
+		t.Fatalf("stats = %v", stats)
+	}
+
+	got = nil
+	if n, err := b.Replay(nil, func(data []byte) error {
+		got = append(got, string(data))
+		return nil
+	}); err != nil || n != 2 || got[0] != "m2" || got[1] != "m3" {
+		t.Fatalf("second replay = %d, %v, %v", n, got, err)
+	}
+}
+
+func TestReplayRate(t *testing.T) {
+	b := newTestBuffer(t, model.DataCacheConfig{Enable: true, ReplayRate: 50})
+	for i := 0; i < 3; i++ {
+		_ = b.Store([]byte("x"))
+	}
+	start := time.Now()
+	if n, _ := b.Replay(nil, func([]byte) error { return nil }); n != 3 {
+		t.Fatalf("replayed %d", n)
+	}
+	// 3 条消息之间有 2 个 20ms 间隔
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Fatalf("replay not rate limited: %v", d)
+	}
+}
+
+func TestDisabledBuffer(t *testing.T) {
+	if err := New(nil, "nb", model.DataCacheConfig{Enable: true}).Store([]byte("x")); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("nil storage: %v", err)
+	}
+	if err := newTestBuffer(t, model.DataCacheConfig{}).Store([]byte("x")); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("disabled: %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	for _, cfg := range []model.DataCacheConfig{
+		{TTL: "soon"},
+		{FlushInterval: "-1s"},
+		{MaxBytes: -1},
+		{ReplayRate: -5},
+	} {
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("%+v: expected error", cfg)
+		}
+	}
+	if err := ValidateConfig(model.DataCacheConfig{Enable: true, TTL: "24h", MaxBytes: 1 << 20, ReplayRate: 100}); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+}
+
+func TestPendingAndRunFollowsFlushInterval(t *testing.T) {
+	b := newTestBuffer(t, model.DataCacheConfig{Enable: true, FlushInterval: "1h"})
+	if b.Pending() {
+		t.Fatal("empty buffer reported pending")
+	}
+	if err := b.Store([]byte("m0")); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if !b.Pending() {
+		t.Fatal("expected pending after store")
+	}
+
+	flushed := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go b.Run(stop, func() {
+		select {
+		case flushed <- struct{}{}:
+		default:
+		}
+	})
+
+	// The hourly ticker is replaced without waiting for it to fire.
+	b.SetConfig(model.DataCacheConfig{Enable: true, FlushInterval: "20ms"})
+	select {
+	case <-flushed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not pick up the new flush interval")
+	}
+}
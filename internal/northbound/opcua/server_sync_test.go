@@ -49,6 +49,7 @@ func TestServerSyncAddressSpaceInPlace(t *testing.T) {
 		Endpoint:    "/",
 		AuthMethods: []string{"Anonymous"},
 	}
+	cfg.CertFile, cfg.KeyFile = tempServerCert(t)
 	srv := NewServer(cfg, sb, nil)
 	if err := srv.Start(); err != nil {
 		t.Fatalf("Start: %v", err)
@@ -2,6 +2,7 @@ package opcua
 
 import (
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"testing"
@@ -159,6 +160,13 @@ func (m *MockSouthboundManager) ClearWriteHistory() {
 	m.writeHistory = []writeOperation{}
 }
 
+// tempServerCert returns cert paths in a temp dir so tests never rewrite the tracked server.crt/server.key
+func tempServerCert(t testing.TB) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	return filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+}
+
 // TestWriteViaOPCUA tests the WriteViaOPCUA method
 func TestWriteViaOPCUA(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
@@ -174,6 +182,7 @@ func TestWriteViaOPCUA(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -222,6 +231,7 @@ func TestBatchWrite(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -290,6 +300,7 @@ func TestGetWriteHistory(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -334,6 +345,7 @@ func TestWriteHistoryLimit(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -390,6 +402,7 @@ func TestServerStartStop(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -419,6 +432,7 @@ func TestServerUpdateConfigRebuild(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	srv := NewServer(config, sb, nil)
 	if err := srv.Start(); err != nil {
 		t.Fatalf("Failed to start server: %v", err)
@@ -450,6 +464,7 @@ func TestServerConcurrentUpdateConfig(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	srv := NewServer(config, sb, nil)
 	if err := srv.Start(); err != nil {
 		t.Fatalf("Failed to start server: %v", err)
@@ -495,6 +510,7 @@ func TestServerReadWrite(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -547,6 +563,7 @@ func TestServerUpdate(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -589,6 +606,7 @@ func BenchmarkServerRead(b *testing.B) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(b)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -621,6 +639,7 @@ func BenchmarkServerWrite(b *testing.B) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(b)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -653,6 +672,7 @@ func BenchmarkServerUpdate(b *testing.B) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(b)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
@@ -692,6 +712,7 @@ func TestServerStress(t *testing.T) {
 		AuthMethods: []string{"Anonymous"},
 	}
 
+	config.CertFile, config.KeyFile = tempServerCert(t)
 	server := NewServer(config, sb, nil)
 	err := server.Start()
 	if err != nil {
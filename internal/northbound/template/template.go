@@ -0,0 +1,196 @@
+// Package template implements the payload and topic templates shared by the
+// northbound connectors (MQTT, HTTP): one data model and one function set, so
+// a template written for one connector renders the same on the other.
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+// Data is the context of payload and topic templates. The device fields
+// describe the first device of the message; Devices lists every device, which
+// matters for HTTP batches that carry several.
+type Data struct {
+	ConfigID   string // Connector config ID
+	ClientID   string // MQTT client ID; empty for HTTP
+	ChannelID  string
+	DeviceID   string
+	DeviceName string
+	Timestamp  any // Formatted per timestamp_format
+	Time       time.Time
+	Points     []Point        // Points of the first device, sorted by ID
+	Point      Point          // The first point
+	Values     map[string]any // Point ID -> value of the first device
+	Devices    []Device       // All devices of the message
+}
+
+// Device is one device inside Data.
+type Device struct {
+	ChannelID  string
+	DeviceID   string
+	DeviceName string
+	Timestamp  any
+	Time       time.Time
+	Points     []Point
+	Values     map[string]any
+}
+
+// Point is one point inside a Device.
+type Point struct {
+	ID        string
+	Name      string
+	Value     any
+	Quality   string // Mapped through quality_map
+	Good      bool
+	Unit      string
+	Timestamp any
+	Time      time.Time
+	Meta      map[string]any
+}
+
+// PointInfo is point configuration the value itself does not carry.
+type PointInfo struct {
+	Name string
+	Unit string
+}
+
+// Funcs are the functions available to every northbound template.
+var Funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"formatTime": func(t time.Time, layout string) string { return t.Format(layout) },
+	"unix":       func(t time.Time) int64 { return t.Unix() },
+	"unixMilli":  func(t time.Time) int64 { return t.UnixMilli() },
+	"last":       last,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+}
+
+// last reports whether i is the last index of list (a slice or array).
+func last(i int, list any) (bool, error) {
+	v := reflect.ValueOf(list)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return i == v.Len()-1, nil
+	}
+	return false, fmt.Errorf("last: %T is not a list", list)
+}
+
+// Parse compiles a template with Funcs.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(Funcs).Parse(text)
+}
+
+// Execute renders t with data.
+func Execute(t *template.Template, data *Data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Format holds the connector options that shape template values.
+type Format struct {
+	TimestampFormat string            // ms (default), s, us, ns, rfc3339, rfc3339ms or a Go layout
+	QualityMap      map[string]string // Gateway quality -> published quality
+}
+
+// Time renders t per TimestampFormat: numeric epochs stay numbers.
+func (f Format) Time(t time.Time) any {
+	switch f.TimestampFormat {
+	case "", "ms":
+		return t.UnixMilli()
+	case "s":
+		return t.Unix()
+	case "us":
+		return t.UnixMicro()
+	case "ns":
+		return t.UnixNano()
+	case "rfc3339":
+		return t.UTC().Format(time.RFC3339)
+	case "rfc3339ms":
+		return t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	return t.Format(f.TimestampFormat)
+}
+
+// Quality maps q through QualityMap.
+func (f Format) Quality(q string) string {
+	if mapped, ok := f.QualityMap[q]; ok {
+		return mapped
+	}
+	return q
+}
+
+// NewDevice builds a Device at time t from values, sorted by point ID. points
+// supplies names and units; a point without a name is named after its ID.
+func (f Format) NewDevice(channelID, deviceID, deviceName string, t time.Time, values []model.Value, points map[string]PointInfo) Device {
+	values = append([]model.Value(nil), values...)
+	sort.Slice(values, func(i, j int) bool { return values[i].PointID < values[j].PointID })
+
+	d := Device{
+		ChannelID:  channelID,
+		DeviceID:   deviceID,
+		DeviceName: deviceName,
+		Timestamp:  f.Time(t),
+		Time:       t,
+		Points:     make([]Point, 0, len(values)),
+		Values:     make(map[string]any, len(values)),
+	}
+	if d.DeviceName == "" {
+		d.DeviceName = deviceID
+	}
+	for _, v := range values {
+		info := points[v.PointID]
+		p := Point{
+			ID:        v.PointID,
+			Name:      info.Name,
+			Value:     v.Value,
+			Quality:   f.Quality(v.Quality),
+			Good:      v.Quality == "Good",
+			Unit:      info.Unit,
+			Timestamp: f.Time(v.TS),
+			Time:      v.TS,
+			Meta:      v.Meta,
+		}
+		if p.Name == "" {
+			p.Name = v.PointID
+		}
+		d.Points = append(d.Points, p)
+		d.Values[v.PointID] = v.Value
+	}
+	return d
+}
+
+// NewData builds the template context for devices (at least one).
+func NewData(configID, clientID string, devices []Device) Data {
+	first := devices[0]
+	data := Data{
+		ConfigID:   configID,
+		ClientID:   clientID,
+		ChannelID:  first.ChannelID,
+		DeviceID:   first.DeviceID,
+		DeviceName: first.DeviceName,
+		Timestamp:  first.Timestamp,
+		Time:       first.Time,
+		Points:     first.Points,
+		Values:     first.Values,
+		Devices:    devices,
+	}
+	if len(first.Points) > 0 {
+		data.Point = first.Points[0]
+	}
+	return data
+}
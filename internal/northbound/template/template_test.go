@@ -0,0 +1,38 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func TestNewDataAndFuncs(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := Format{TimestampFormat: "s", QualityMap: map[string]string{"Good": "192"}}
+	d1 := f.NewDevice("ch-1", "d1", "", ts, []model.Value{
+		{PointID: "b", Value: 2, Quality: "Bad", TS: ts},
+		{PointID: "a", Value: 1, Quality: "Good", TS: ts},
+	}, map[string]PointInfo{"a": {Name: "A", Unit: "°C"}})
+	d2 := f.NewDevice("ch-1", "d2", "Device 2", ts, []model.Value{{PointID: "a", Value: 3, Quality: "Good", TS: ts}}, nil)
+
+	data := NewData("cfg", "gw", []Device{d1, d2})
+	if data.DeviceName != "d1" || data.Point.ID != "a" || data.Point.Name != "A" || data.Point.Quality != "192" {
+		t.Fatalf("data = %+v", data)
+	}
+	if data.Points[1].Name != "b" || data.Points[1].Good {
+		t.Fatalf("point b = %+v", data.Points[1])
+	}
+
+	tmpl, err := Parse("t", `{{.ConfigID}} {{.Timestamp}} {{range $i, $d := .Devices}}{{upper $d.DeviceName}}{{if not (last $i $.Devices)}},{{end}}{{end}} {{json .Values}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Execute(tmpl, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `cfg 1767323045 D1,DEVICE 2 {"a":1,"b":2}`; string(out) != want {
+		t.Fatalf("out = %s, want %s", out, want)
+	}
+}
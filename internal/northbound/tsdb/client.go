@@ -0,0 +1,605 @@
+package tsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/offline"
+	"github.com/anviod/edgex/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusDisconnected = 0
+	StatusConnected    = 1
+	StatusError        = 3
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultBatchInterval = time.Second
+	writeTimeout         = 10 * time.Second
+	metaRefreshInterval  = time.Minute
+	// maxPendingRows bounds memory while the database is slow; beyond it rows
+	// are flushed synchronously by the publisher.
+	maxPendingRows = 20000
+)
+
+// Row is one point sample as written to the database.
+type Row struct {
+	Time    time.Time `json:"t"`
+	Channel string    `json:"c,omitempty"`
+	Device  string    `json:"d"`
+	Point   string    `json:"p"`
+	Unit    string    `json:"u,omitempty"`
+	Group   string    `json:"g,omitempty"`
+	Value   any       `json:"v"` // float64, bool or string
+	Quality string    `json:"q,omitempty"`
+}
+
+// writer persists a batch of rows.
+type writer interface {
+	Write(ctx context.Context, rows []Row) error
+	Close()
+}
+
+// permanentError marks a write the database rejected for its content; it is
+// dropped instead of cached since retrying cannot succeed.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+type pointMeta struct {
+	unit  string
+	group string
+}
+
+type deviceMeta struct {
+	points    map[string]pointMeta
+	fetchedAt time.Time
+}
+
+type periodicItem struct {
+	values map[string]model.Value
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+type Client struct {
+	config    model.TSDBConfig
+	sb        model.SouthboundManager
+	offline   *offline.Buffer
+	writer    writer
+	newWriter func(model.TSDBConfig) (writer, error)
+	stopChan  chan struct{}
+	configMu  sync.RWMutex
+
+	lastValues sync.Map
+
+	batchMu sync.Mutex
+	batch   []Row
+	flushCh chan struct{}
+	writeMu sync.Mutex // serialises writes so batches land in order
+
+	periodicMu sync.Mutex
+	periodic   map[string]*periodicItem
+
+	metaMu sync.Mutex
+	meta   map[string]*deviceMeta
+
+	status int32
+
+	// Stats
+	successCount int64
+	failCount    int64
+	cachedCount  int64
+	droppedCount int64
+}
+
+func NewClient(cfg model.TSDBConfig, sb model.SouthboundManager, s *storage.Storage) *Client {
+	return &Client{
+		config:    cfg,
+		sb:        sb,
+		offline:   offline.New(s, cfg.ID, cfg.Cache),
+		newWriter: newWriter,
+		stopChan:  make(chan struct{}),
+		flushCh:   make(chan struct{}, 1),
+		periodic:  make(map[string]*periodicItem),
+		meta:      make(map[string]*deviceMeta),
+	}
+}
+
+func newWriter(cfg model.TSDBConfig) (writer, error) {
+	switch cfg.Type {
+	case "influxdb_v1", "influxdb_v2", "":
+		return newInfluxWriter(cfg)
+	case "postgres", "timescaledb":
+		return newSQLWriter(cfg)
+	}
+	return nil, fmt.Errorf("tsdb: unsupported type %q", cfg.Type)
+}
+
+func (c *Client) Start() error {
+	c.configMu.RLock()
+	cfg := c.config
+	c.configMu.RUnlock()
+
+	w, err := c.newWriter(cfg)
+	if err != nil {
+		atomic.StoreInt32(&c.status, StatusError)
+		return err
+	}
+	c.configMu.Lock()
+	c.writer = w
+	c.configMu.Unlock()
+
+	go c.batchLoop()
+	go c.retryLoop()
+	c.updatePeriodicTasks()
+	zap.L().Info("TSDB Northbound Client started", zap.String("id", cfg.ID), zap.String("type", cfg.Type))
+	return nil
+}
+
+func (c *Client) Stop() {
+	close(c.stopChan)
+	c.flush()
+
+	c.configMu.Lock()
+	w := c.writer
+	c.writer = nil
+	c.configMu.Unlock()
+	if w != nil {
+		w.Close()
+	}
+	atomic.StoreInt32(&c.status, StatusDisconnected)
+}
+
+// UpdateConfig applies cfg; the writer is rebuilt when the target changed.
+func (c *Client) UpdateConfig(cfg model.TSDBConfig) error {
+	c.configMu.Lock()
+	old := c.config
+	c.config = cfg
+	c.configMu.Unlock()
+	c.offline.SetConfig(cfg.Cache)
+	c.updatePeriodicTasks()
+
+	if writerConfigEqual(old, cfg) {
+		return nil
+	}
+	w, err := c.newWriter(cfg)
+	if err != nil {
+		atomic.StoreInt32(&c.status, StatusError)
+		return err
+	}
+	c.writeMu.Lock()
+	c.configMu.Lock()
+	oldWriter := c.writer
+	c.writer = w
+	c.configMu.Unlock()
+	c.writeMu.Unlock()
+	if oldWriter != nil {
+		oldWriter.Close()
+	}
+	atomic.StoreInt32(&c.status, StatusDisconnected)
+	return nil
+}
+
+func writerConfigEqual(a, b model.TSDBConfig) bool {
+	return a.Type == b.Type && a.URL == b.URL && a.Database == b.Database &&
+		a.RetentionPolicy == b.RetentionPolicy && a.Username == b.Username &&
+		a.Password == b.Password && a.Org == b.Org && a.Bucket == b.Bucket &&
+		a.Token == b.Token && a.Precision == b.Precision && a.Measurement == b.Measurement &&
+		strings.Join(a.Tags, ",") == strings.Join(b.Tags, ",") &&
+		a.DSN == b.DSN && a.Table == b.Table && a.AutoCreate == b.AutoCreate
+}
+
+func (c *Client) Publish(v model.Value) {
+	c.configMu.RLock()
+	enable := c.config.Enable
+	devCfg, ok := model.LookupNorthboundPublishConfig(v.DeviceID, c.config.Devices, c.config.VirtualDevices)
+	c.configMu.RUnlock()
+
+	if !enable || !ok {
+		return
+	}
+
+	if devCfg.Strategy == "cov" || devCfg.Strategy == "change" {
+		key := v.DeviceID + ":" + v.PointID
+		lastVal, loaded := c.lastValues.Load(key)
+		if loaded && lastVal == v.Value {
+			return
+		}
+		c.lastValues.Store(key, v.Value)
+	} else if devCfg.Strategy == "periodic" && time.Duration(devCfg.Interval) > 0 {
+		c.periodicMu.Lock()
+		if item, exists := c.periodic[v.DeviceID]; exists {
+			item.values[v.PointID] = v
+		}
+		c.periodicMu.Unlock()
+		return
+	}
+
+	if r, ok := c.toRow(v, v.TS); ok {
+		c.enqueue(r)
+	}
+}
+
+// toRow converts v; nil and non-finite values are skipped.
+func (c *Client) toRow(v model.Value, ts time.Time) (Row, bool) {
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	val, ok := normalizeValue(v.Value)
+	if !ok {
+		return Row{}, false
+	}
+	meta := c.pointMeta(v.ChannelID, v.DeviceID, v.PointID)
+	return Row{
+		Time:    ts,
+		Channel: v.ChannelID,
+		Device:  v.DeviceID,
+		Point:   v.PointID,
+		Unit:    meta.unit,
+		Group:   meta.group,
+		Value:   val,
+		Quality: v.Quality,
+	}, true
+}
+
+func normalizeValue(v any) (any, bool) {
+	switch x := v.(type) {
+	case nil:
+		return nil, false
+	case bool:
+		return x, true
+	case string:
+		return x, true
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, false
+		}
+		return x, true
+	case float32:
+		return normalizeValue(float64(x))
+	case int:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint8:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case []byte:
+		return string(x), true
+	case fmt.Stringer:
+		return x.String(), true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return string(data), true
+}
+
+// pointMeta returns unit and group from the southbound point configuration,
+// cached per device.
+func (c *Client) pointMeta(channelID, deviceID, pointID string) pointMeta {
+	if c.sb == nil {
+		return pointMeta{}
+	}
+	key := channelID + "/" + deviceID
+	c.metaMu.Lock()
+	defer c.metaMu.Unlock()
+
+	dm, ok := c.meta[key]
+	if !ok || time.Since(dm.fetchedAt) > metaRefreshInterval {
+		dm = &deviceMeta{points: make(map[string]pointMeta), fetchedAt: time.Now()}
+		if dev := c.sb.GetDevice(channelID, deviceID); dev != nil {
+			for _, p := range dev.Points {
+				dm.points[p.ID] = pointMeta{unit: p.Unit, group: p.Group}
+			}
+		}
+		c.meta[key] = dm
+	}
+	return dm.points[pointID]
+}
+
+func (c *Client) batchSize() int {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	if c.config.BatchSize > 0 {
+		return c.config.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func (c *Client) enqueue(rows ...Row) {
+	c.batchMu.Lock()
+	c.batch = append(c.batch, rows...)
+	n := len(c.batch)
+	c.batchMu.Unlock()
+
+	if n >= maxPendingRows {
+		c.flush()
+		return
+	}
+	if n >= c.batchSize() {
+		select {
+		case c.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (c *Client) batchLoop() {
+	c.configMu.RLock()
+	intervalStr := c.config.BatchInterval
+	c.configMu.RUnlock()
+
+	interval := defaultBatchInterval
+	if d, err := time.ParseDuration(intervalStr); err == nil && d > 0 {
+		interval = d
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			c.flush()
+		case <-c.flushCh:
+			c.flush()
+		}
+	}
+}
+
+// flush writes all pending rows in batches of BatchSize.
+func (c *Client) flush() {
+	c.batchMu.Lock()
+	rows := c.batch
+	c.batch = nil
+	c.batchMu.Unlock()
+
+	size := c.batchSize()
+	for len(rows) > 0 {
+		n := min(size, len(rows))
+		c.writeBatch(rows[:n])
+		rows = rows[n:]
+	}
+}
+
+func (c *Client) writeBatch(rows []Row) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	// Older batches are still cached: go behind them to keep the order.
+	if c.offline.Pending() {
+		c.cacheRows(rows)
+		return
+	}
+
+	c.configMu.RLock()
+	w := c.writer
+	c.configMu.RUnlock()
+
+	err := errors.New("writer not started")
+	if w != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		err = w.Write(ctx, rows)
+		cancel()
+	}
+	if err == nil {
+		atomic.AddInt64(&c.successCount, int64(len(rows)))
+		if atomic.SwapInt32(&c.status, StatusConnected) != StatusConnected {
+			// Database is back; replay the backlog without waiting for the next tick.
+			c.offline.Kick()
+		}
+		return
+	}
+
+	atomic.AddInt64(&c.failCount, int64(len(rows)))
+	var perm *permanentError
+	if errors.As(err, &perm) {
+		atomic.AddInt64(&c.droppedCount, int64(len(rows)))
+		zap.L().Error("TSDB rejected batch, dropped", zap.Int("rows", len(rows)), zap.Error(err))
+		return
+	}
+	atomic.StoreInt32(&c.status, StatusError)
+	zap.L().Warn("TSDB write failed", zap.Int("rows", len(rows)), zap.Error(err))
+	c.cacheRows(rows)
+}
+
+func (c *Client) cacheRows(rows []Row) {
+	if !c.offline.Enabled() {
+		return
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return
+	}
+	if err := c.offline.Store(data); err != nil {
+		zap.L().Error("Failed to cache TSDB batch", zap.Error(err))
+		return
+	}
+	atomic.AddInt64(&c.cachedCount, int64(len(rows)))
+}
+
+func (c *Client) updatePeriodicTasks() {
+	c.periodicMu.Lock()
+	defer c.periodicMu.Unlock()
+
+	c.configMu.RLock()
+	devices := c.config.Devices
+	virtualDevices := c.config.VirtualDevices
+	c.configMu.RUnlock()
+
+	isPeriodicEnabled := func(devID string) bool {
+		cfg, ok := model.LookupNorthboundPublishConfig(devID, devices, virtualDevices)
+		return ok && cfg.Enable && cfg.Strategy == "periodic" && time.Duration(cfg.Interval) > 0
+	}
+
+	for devID, item := range c.periodic {
+		if !isPeriodicEnabled(devID) {
+			close(item.stop)
+			item.ticker.Stop()
+			delete(c.periodic, devID)
+		}
+	}
+
+	startPeriodic := func(devID string, devCfg model.DevicePublishConfig) {
+		if !devCfg.Enable || devCfg.Strategy != "periodic" || time.Duration(devCfg.Interval) <= 0 {
+			return
+		}
+		if _, exists := c.periodic[devID]; exists {
+			return
+		}
+		item := &periodicItem{
+			values: make(map[string]model.Value),
+			ticker: time.NewTicker(time.Duration(devCfg.Interval)),
+			stop:   make(chan struct{}),
+		}
+		c.periodic[devID] = item
+		go c.runPeriodicTask(item)
+	}
+
+	for devID, devCfg := range devices {
+		startPeriodic(devID, devCfg)
+	}
+	for devID, devCfg := range virtualDevices {
+		startPeriodic(devID, devCfg)
+	}
+}
+
+func (c *Client) runPeriodicTask(item *periodicItem) {
+	for {
+		select {
+		case <-item.stop:
+			return
+		case <-c.stopChan:
+			return
+		case <-item.ticker.C:
+			c.flushPeriodic(item)
+		}
+	}
+}
+
+// flushPeriodic samples the latest value of every point at the tick time.
+func (c *Client) flushPeriodic(item *periodicItem) {
+	c.periodicMu.Lock()
+	values := make([]model.Value, 0, len(item.values))
+	for _, v := range item.values {
+		values = append(values, v)
+	}
+	c.periodicMu.Unlock()
+
+	now := time.Now()
+	rows := make([]Row, 0, len(values))
+	for _, v := range values {
+		if r, ok := c.toRow(v, now); ok {
+			rows = append(rows, r)
+		}
+	}
+	if len(rows) > 0 {
+		c.enqueue(rows...)
+	}
+}
+
+func (c *Client) retryLoop() {
+	stop := c.stopChan
+	c.offline.Run(stop, func() { c.flushOfflineMessages(stop) })
+}
+
+// flushOfflineMessages rewrites cached batches oldest first and stops at the
+// first failure.
+func (c *Client) flushOfflineMessages(stop <-chan struct{}) {
+	c.configMu.RLock()
+	configID := c.config.ID
+	c.configMu.RUnlock()
+
+	n, err := c.offline.Replay(stop, func(data []byte) error {
+		var rows []Row
+		if err := json.Unmarshal(data, &rows); err != nil || len(rows) == 0 {
+			return offline.ErrDiscard
+		}
+
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		c.configMu.RLock()
+		w := c.writer
+		c.configMu.RUnlock()
+		if w == nil {
+			return errors.New("writer not started")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		err := w.Write(ctx, rows)
+		cancel()
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			zap.L().Error("TSDB rejected cached batch, dropped", zap.Int("rows", len(rows)), zap.Error(err))
+			atomic.AddInt64(&c.droppedCount, int64(len(rows)))
+			return fmt.Errorf("%w: %v", offline.ErrDiscard, err)
+		}
+		if err != nil {
+			atomic.StoreInt32(&c.status, StatusError)
+			return err
+		}
+		atomic.AddInt64(&c.successCount, int64(len(rows)))
+		atomic.StoreInt32(&c.status, StatusConnected)
+		return nil
+	})
+	if n > 0 || err != nil {
+		zap.L().Info("Replayed offline TSDB batches", zap.String("client_id", configID), zap.Int("count", n), zap.Error(err))
+	}
+}
+
+func (c *Client) GetStatus() int {
+	return int(atomic.LoadInt32(&c.status))
+}
+
+func (c *Client) GetStats() map[string]int64 {
+	c.batchMu.Lock()
+	pending := len(c.batch)
+	c.batchMu.Unlock()
+	stats := c.offline.Stats()
+	stats["success_count"] = atomic.LoadInt64(&c.successCount)
+	stats["fail_count"] = atomic.LoadInt64(&c.failCount)
+	stats["cached_count"] = atomic.LoadInt64(&c.cachedCount)
+	stats["dropped_count"] = atomic.LoadInt64(&c.droppedCount)
+	stats["pending_count"] = int64(pending)
+	return stats
+}
+
+// formatFloat renders f in the shortest form that line protocol accepts.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
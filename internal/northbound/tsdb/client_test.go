@@ -0,0 +1,304 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/storage"
+)
+
+type fakeSouthbound struct {
+	model.SouthboundManager
+	device *model.Device
+}
+
+func (f *fakeSouthbound) GetDevice(channelID, deviceID string) *model.Device {
+	return f.device
+}
+
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches [][]Row
+	err     error
+}
+
+func (w *fakeWriter) Write(ctx context.Context, rows []Row) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.batches = append(w.batches, append([]Row(nil), rows...))
+	return nil
+}
+
+func (w *fakeWriter) Close() {}
+
+func (w *fakeWriter) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+func (w *fakeWriter) rows() []Row {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []Row
+	for _, b := range w.batches {
+		out = append(out, b...)
+	}
+	return out
+}
+
+func newTestClient(t *testing.T, cfg model.TSDBConfig, sb model.SouthboundManager, s *storage.Storage) (*Client, *fakeWriter) {
+	t.Helper()
+	fw := &fakeWriter{}
+	c := NewClient(cfg, sb, s)
+	c.newWriter = func(model.TSDBConfig) (writer, error) { return fw, nil }
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(c.Stop)
+	return c, fw
+}
+
+func TestPublishBatchesRowsWithPointMetadata(t *testing.T) {
+	sb := &fakeSouthbound{device: &model.Device{ID: "dev-1", Points: []model.Point{
+		{ID: "temp", Unit: "°C", Group: "env"},
+	}}}
+	c, fw := newTestClient(t, model.TSDBConfig{
+		ID:            "t1",
+		Enable:        true,
+		BatchSize:     2,
+		BatchInterval: "1h",
+		Devices:       model.OpcUaDeviceMap{"dev-1": {Enable: true, Strategy: "realtime"}},
+	}, sb, nil)
+
+	c.Publish(model.Value{ChannelID: "ch-1", DeviceID: "dev-1", PointID: "temp", Value: int16(21), Quality: "Good", TS: time.Now()})
+	c.Publish(model.Value{ChannelID: "ch-1", DeviceID: "dev-2", PointID: "temp", Value: 1, TS: time.Now()})
+	if n := len(fw.rows()); n != 0 {
+		t.Fatalf("rows written before batch is full: %d", n)
+	}
+	c.Publish(model.Value{ChannelID: "ch-1", DeviceID: "dev-1", PointID: "run", Value: true, TS: time.Now()})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(fw.rows()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	rows := fw.rows()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2 (disabled device must be skipped)", len(rows))
+	}
+	if rows[0].Value != 21.0 || rows[0].Unit != "°C" || rows[0].Group != "env" {
+		t.Fatalf("row = %+v", rows[0])
+	}
+	if rows[1].Value != true || rows[1].Unit != "" {
+		t.Fatalf("row = %+v", rows[1])
+	}
+}
+
+func TestFailedBatchIsCachedAndReplayed(t *testing.T) {
+	s, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	defer s.Close()
+
+	c, fw := newTestClient(t, model.TSDBConfig{
+		ID:            "t-cache",
+		Enable:        true,
+		BatchInterval: "1h",
+		Cache:         model.DataCacheConfig{Enable: true, MaxCount: 10},
+		Devices:       model.OpcUaDeviceMap{"dev-1": {Enable: true}},
+	}, nil, s)
+
+	fw.setErr(errors.New("connection refused"))
+	c.Publish(model.Value{DeviceID: "dev-1", PointID: "p1", Value: 1.5, TS: time.Unix(100, 0)})
+	c.Publish(model.Value{DeviceID: "dev-1", PointID: "p2", Value: "on", TS: time.Unix(100, 0)})
+	c.flush()
+
+	if stats := c.GetStats(); stats["fail_count"] != 2 || stats["cached_count"] != 2 || stats["backlog_count"] != 1 {
+		t.Fatalf("stats = %v", stats)
+	}
+
+	fw.setErr(nil)
+	c.flushOfflineMessages(nil)
+	rows := fw.rows()
+	if len(rows) != 2 || rows[0].Point != "p1" || rows[0].Value != 1.5 || rows[1].Value != "on" {
+		t.Fatalf("replayed rows = %+v", rows)
+	}
+	if !rows[0].Time.Equal(time.Unix(100, 0)) {
+		t.Fatalf("replayed time = %v", rows[0].Time)
+	}
+	if stats := c.GetStats(); stats["backlog_count"] != 0 {
+		t.Fatalf("cache not drained: %v", stats)
+	}
+	if c.GetStatus() != StatusConnected {
+		t.Fatalf("status = %d, want connected", c.GetStatus())
+	}
+}
+
+func TestPermanentErrorDropsBatch(t *testing.T) {
+	s, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	defer s.Close()
+
+	c, fw := newTestClient(t, model.TSDBConfig{
+		ID:      "t-perm",
+		Enable:  true,
+		Cache:   model.DataCacheConfig{Enable: true, MaxCount: 10},
+		Devices: model.OpcUaDeviceMap{"dev-1": {Enable: true}},
+	}, nil, s)
+
+	fw.setErr(&permanentError{err: errors.New("field type conflict")})
+	c.Publish(model.Value{DeviceID: "dev-1", PointID: "p1", Value: 1, TS: time.Now()})
+	c.flush()
+
+	if stats := c.GetStats(); stats["dropped_count"] != 1 || stats["backlog_count"] != 0 {
+		t.Fatalf("stats = %v", stats)
+	}
+}
+
+func TestInfluxV1LineProtocol(t *testing.T) {
+	var gotPath, gotQuery, gotBody, gotUser string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotUser, _, _ = r.BasicAuth()
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w, err := newInfluxWriter(model.TSDBConfig{
+		Type:        "influxdb_v1",
+		URL:         srv.URL,
+		Database:    "plant",
+		Username:    "edge",
+		Precision:   "s",
+		Measurement: "m_{channel_id}",
+		Tags:        []string{"device", "point", "unit"},
+	})
+	if err != nil {
+		t.Fatalf("newInfluxWriter: %v", err)
+	}
+	err = w.Write(context.Background(), []Row{
+		{Time: time.Unix(1700000000, 0), Channel: "line 1", Device: "dev,1", Point: "a=b", Value: 1.25, Quality: "Good"},
+		{Time: time.Unix(1700000001, 0), Channel: "line 1", Device: "d", Point: "msg", Value: `say "hi"`},
+		{Time: time.Unix(1700000002, 0), Channel: "line 1", Device: "d", Point: "run", Unit: "", Group: "g", Value: false},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if gotPath != "/write" || gotQuery != "db=plant&precision=s" || gotUser != "edge" {
+		t.Fatalf("request = %s?%s user=%q", gotPath, gotQuery, gotUser)
+	}
+	want := `m_line\ 1,device=dev\,1,point=a\=b value=1.25,quality="Good" 1700000000` + "\n" +
+		`m_line\ 1,device=d,point=msg value_str="say \"hi\"" 1700000001` + "\n" +
+		`m_line\ 1,device=d,point=run value_bool=false 1700000002` + "\n"
+	if gotBody != want {
+		t.Fatalf("body:\n%s\nwant:\n%s", gotBody, want)
+	}
+}
+
+func TestInfluxV2WriteAndErrors(t *testing.T) {
+	status := http.StatusNoContent
+	var gotPath, gotQuery, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w, err := newInfluxWriter(model.TSDBConfig{Type: "influxdb_v2", URL: srv.URL + "/", Org: "acme", Bucket: "edge", Token: "secret"})
+	if err != nil {
+		t.Fatalf("newInfluxWriter: %v", err)
+	}
+	rows := []Row{{Time: time.UnixMilli(5), Device: "d", Point: "p", Value: 1.0}}
+	if err := w.Write(context.Background(), rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if gotPath != "/api/v2/write" || gotQuery != "bucket=edge&org=acme&precision=ms" || gotAuth != "Token secret" {
+		t.Fatalf("request = %s?%s auth=%q", gotPath, gotQuery, gotAuth)
+	}
+
+	var perm *permanentError
+	status = http.StatusBadRequest
+	if err := w.Write(context.Background(), rows); !errors.As(err, &perm) {
+		t.Fatalf("400 should be permanent, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := w.Write(context.Background(), rows); err == nil || errors.As(err, &perm) {
+		t.Fatalf("503 should be retryable, got %v", err)
+	}
+}
+
+func TestWriterConfigValidation(t *testing.T) {
+	cases := map[string]model.TSDBConfig{
+		"bad type":      {Type: "graphite"},
+		"v1 no db":      {Type: "influxdb_v1", URL: "http://localhost:8086"},
+		"v2 no bucket":  {Type: "influxdb_v2", URL: "http://localhost:8086"},
+		"bad url":       {Type: "influxdb_v1", URL: "localhost", Database: "db"},
+		"precision":     {Type: "influxdb_v1", URL: "http://localhost:8086", Database: "db", Precision: "m"},
+		"tag":           {Type: "influxdb_v1", URL: "http://localhost:8086", Database: "db", Tags: []string{"site"}},
+		"no dsn":        {Type: "postgres"},
+		"table inject":  {Type: "postgres", DSN: "postgres://localhost/db", Table: "points; DROP TABLE x"},
+		"timescale dsn": {Type: "timescaledb", DSN: "::not a dsn"},
+	}
+	for name, cfg := range cases {
+		if w, err := newWriter(cfg); err == nil {
+			w.Close()
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	w, err := newWriter(model.TSDBConfig{Type: "timescaledb", DSN: "postgres://u:p@127.0.0.1:1/db", Table: "public.points"})
+	if err != nil {
+		t.Fatalf("valid sql config rejected: %v", err)
+	}
+	w.Close()
+}
+
+func TestBuildInsert(t *testing.T) {
+	ts := time.Unix(10, 0)
+	stmt, args := buildInsert("public.points", []Row{
+		{Time: ts, Channel: "c", Device: "d", Point: "p1", Unit: "V", Value: 3.5, Quality: "Good"},
+		{Time: ts, Device: "d", Point: "p2", Value: true},
+		{Time: ts, Device: "d", Point: "p3", Value: "text"},
+	})
+
+	if !strings.HasPrefix(stmt, "INSERT INTO public.points (time, channel_id, device_id, point_id, unit, point_group, value, value_text, quality) VALUES ") {
+		t.Fatalf("stmt = %s", stmt)
+	}
+	if !strings.HasSuffix(stmt, "($19,$20,$21,$22,$23,$24,$25,$26,$27)") {
+		t.Fatalf("stmt = %s", stmt)
+	}
+	if len(args) != 27 {
+		t.Fatalf("args = %d", len(args))
+	}
+	if args[6] != 3.5 || args[7] != nil || args[4] != "V" {
+		t.Fatalf("numeric row args = %v", args[:9])
+	}
+	if args[15] != 1.0 || args[16] != "true" {
+		t.Fatalf("bool row args = %v", args[9:18])
+	}
+	if args[24] != nil || args[25] != "text" {
+		t.Fatalf("text row args = %v", args[18:])
+	}
+
+	ddl := createTableStatements("public.points")
+	if !strings.Contains(ddl[1], "CREATE INDEX IF NOT EXISTS points_device_point_time_idx ON public.points") {
+		t.Fatalf("index ddl = %s", ddl[1])
+	}
+}
@@ -0,0 +1,90 @@
+package tsdb
+
+import (
+	"fmt"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+	"github.com/anviod/edgex/internal/northbound/offline"
+)
+
+// ConnectorType is the ConnectorConfig.Type of time-series database writers.
+const ConnectorType = "tsdb"
+
+func init() {
+	connector.Register(connector.Descriptor{
+		Type:        ConnectorType,
+		DisplayName: "时序数据库",
+		New:         NewConnector,
+		Validate:    ValidateConnectorConfig,
+	})
+}
+
+// Connector runs a Client as a registry-based northbound connector.
+type Connector struct {
+	*Client
+}
+
+// ConfigFromConnector decodes the TSDBConfig carried in cfg.Settings; its
+// "type" setting selects the database. ID, name, enable and the device maps
+// always come from cfg itself.
+func ConfigFromConnector(cfg model.ConnectorConfig) (model.TSDBConfig, error) {
+	var tc model.TSDBConfig
+	if err := cfg.DecodeSettings(&tc); err != nil {
+		return tc, fmt.Errorf("时序数据库配置无效: %w", err)
+	}
+	tc.ID = cfg.ID
+	tc.Name = cfg.Name
+	tc.Enable = cfg.Enable
+	tc.Devices = cfg.Devices
+	tc.VirtualDevices = cfg.VirtualDevices
+	return tc, nil
+}
+
+// ValidateConnectorConfig checks the settings before they are saved.
+func ValidateConnectorConfig(cfg model.ConnectorConfig) error {
+	tc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return err
+	}
+	return offline.ValidateConfig(tc.Cache)
+}
+
+// NewConnector creates a stopped writer. A start error is a configuration
+// problem (URL, DSN, table name); an unreachable database is handled by the
+// offline cache.
+func NewConnector(cfg model.ConnectorConfig, deps connector.Deps) (connector.Connector, error) {
+	tc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{Client: NewClient(tc, deps.Southbound, deps.Storage)}, nil
+}
+
+// UpdateConfig implements connector.Connector.
+func (c *Connector) UpdateConfig(cfg model.ConnectorConfig) error {
+	tc, err := ConfigFromConnector(cfg)
+	if err != nil {
+		return err
+	}
+	return c.Client.UpdateConfig(tc)
+}
+
+// Stats implements connector.Connector.
+func (c *Connector) Stats() map[string]any {
+	stats := make(map[string]any)
+	for k, v := range c.GetStats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// Status implements connector.StatusReporter.
+func (c *Connector) Status() int {
+	return c.GetStatus()
+}
+
+// Write implements connector.Connector; the writer only publishes.
+func (c *Connector) Write(channelID, deviceID, pointID string, value any) error {
+	return connector.ErrWriteNotSupported
+}
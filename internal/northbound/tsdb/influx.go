@@ -0,0 +1,234 @@
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+var allTags = []string{"channel", "device", "point", "unit", "group"}
+
+// influxWriter posts line protocol to the InfluxDB v1 (/write) or v2
+// (/api/v2/write) HTTP API.
+type influxWriter struct {
+	endpoint    string
+	v2          bool
+	username    string
+	password    string
+	token       string
+	precision   string
+	measurement string
+	tags        []string
+	httpClient  *http.Client
+}
+
+func newInfluxWriter(cfg model.TSDBConfig) (*influxWriter, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.URL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("tsdb: invalid url %q", cfg.URL)
+	}
+
+	precision := cfg.Precision
+	if precision == "" {
+		precision = "ms"
+	}
+	if _, ok := precisionUnits[precision]; !ok {
+		return nil, fmt.Errorf("tsdb: unsupported precision %q", cfg.Precision)
+	}
+
+	tags := allTags
+	if len(cfg.Tags) > 0 {
+		tags = nil
+		for _, t := range cfg.Tags {
+			if !isKnownTag(t) {
+				return nil, fmt.Errorf("tsdb: unknown tag %q", t)
+			}
+			tags = append(tags, t)
+		}
+	}
+
+	w := &influxWriter{
+		v2:          cfg.Type == "influxdb_v2",
+		username:    cfg.Username,
+		password:    cfg.Password,
+		token:       cfg.Token,
+		precision:   precision,
+		measurement: cfg.Measurement,
+		tags:        tags,
+		httpClient:  &http.Client{Timeout: writeTimeout},
+	}
+	if w.measurement == "" {
+		w.measurement = "edgex"
+	}
+
+	q := url.Values{}
+	if w.v2 {
+		if cfg.Bucket == "" {
+			return nil, fmt.Errorf("tsdb: bucket is required for influxdb_v2")
+		}
+		base.Path += "/api/v2/write"
+		q.Set("org", cfg.Org)
+		q.Set("bucket", cfg.Bucket)
+		q.Set("precision", precision)
+	} else {
+		if cfg.Database == "" {
+			return nil, fmt.Errorf("tsdb: database is required for influxdb_v1")
+		}
+		base.Path += "/write"
+		q.Set("db", cfg.Database)
+		if cfg.RetentionPolicy != "" {
+			q.Set("rp", cfg.RetentionPolicy)
+		}
+		q.Set("precision", v1Precision[precision])
+	}
+	base.RawQuery = q.Encode()
+	w.endpoint = base.String()
+	return w, nil
+}
+
+var precisionUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// v1Precision maps precision to the v1 API spelling, which uses n and u.
+var v1Precision = map[string]string{"ns": "n", "us": "u", "ms": "ms", "s": "s"}
+
+func isKnownTag(t string) bool {
+	for _, k := range allTags {
+		if k == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *influxWriter) Write(ctx context.Context, rows []Row) error {
+	body := w.encode(rows)
+	if len(body) == 0 {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.v2 {
+		if w.token != "" {
+			req.Header.Set("Authorization", "Token "+w.token)
+		}
+	} else if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	// 400 and 422 reject the points themselves (bad line, field type conflict).
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
+		return &permanentError{err: err}
+	}
+	return err
+}
+
+func (w *influxWriter) Close() {
+	w.httpClient.CloseIdleConnections()
+}
+
+func (w *influxWriter) encode(rows []Row) []byte {
+	var buf bytes.Buffer
+	unit := precisionUnits[w.precision]
+	for _, r := range rows {
+		w.encodeRow(&buf, r, unit)
+	}
+	return buf.Bytes()
+}
+
+func (w *influxWriter) encodeRow(buf *bytes.Buffer, r Row, unit time.Duration) {
+	buf.WriteString(escapeMeasurement(renderMeasurement(w.measurement, r)))
+	for _, t := range w.tags {
+		var v string
+		switch t {
+		case "channel":
+			v = r.Channel
+		case "device":
+			v = r.Device
+		case "point":
+			v = r.Point
+		case "unit":
+			v = r.Unit
+		case "group":
+			v = r.Group
+		}
+		if v == "" {
+			continue
+		}
+		buf.WriteByte(',')
+		buf.WriteString(t)
+		buf.WriteByte('=')
+		buf.WriteString(escapeTag(v))
+	}
+
+	buf.WriteByte(' ')
+	switch v := r.Value.(type) {
+	case float64:
+		buf.WriteString("value=")
+		buf.WriteString(formatFloat(v))
+	case bool:
+		buf.WriteString("value_bool=")
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		buf.WriteString("value_str=")
+		buf.WriteString(quoteField(v))
+	default:
+		buf.WriteString("value_str=")
+		buf.WriteString(quoteField(fmt.Sprint(v)))
+	}
+	if r.Quality != "" {
+		buf.WriteString(",quality=")
+		buf.WriteString(quoteField(r.Quality))
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatInt(r.Time.UnixNano()/int64(unit), 10))
+	buf.WriteByte('\n')
+}
+
+func renderMeasurement(tmpl string, r Row) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	return strings.NewReplacer("{channel_id}", r.Channel, "{device_id}", r.Device).Replace(tmpl)
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", " ", "\r", " ")
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", " ", "\r", " ")
+	fieldEscaper       = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+func escapeMeasurement(s string) string { return measurementEscaper.Replace(s) }
+
+// escapeTag escapes a tag value; a trailing backslash would escape the
+// following separator, so it is dropped.
+func escapeTag(s string) string { return tagEscaper.Replace(strings.TrimRight(s, `\`)) }
+
+func quoteField(s string) string { return `"` + fieldEscaper.Replace(s) + `"` }
@@ -0,0 +1,182 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/anviod/edgex/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTable = "edgex_points"
+	// sqlChunkRows keeps an INSERT well below the 65535 bind-parameter limit.
+	sqlChunkRows = 1000
+	sqlColumns   = 9
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// sqlWriter batches multi-row INSERTs into PostgreSQL or TimescaleDB.
+type sqlWriter struct {
+	pool        *pgxpool.Pool
+	table       string
+	autoCreate  bool
+	timescale   bool
+	createMu    sync.Mutex
+	tableExists bool
+}
+
+func newSQLWriter(cfg model.TSDBConfig) (*sqlWriter, error) {
+	table := cfg.Table
+	if table == "" {
+		table = defaultTable
+	}
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("tsdb: invalid table name %q", cfg.Table)
+	}
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("tsdb: dsn is required for %s", cfg.Type)
+	}
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("tsdb: invalid dsn: %w", err)
+	}
+	poolCfg.MaxConns = 4
+	// pgxpool connects lazily, so an unreachable server does not fail Start.
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlWriter{
+		pool:       pool,
+		table:      table,
+		autoCreate: cfg.AutoCreate,
+		timescale:  cfg.Type == "timescaledb",
+	}, nil
+}
+
+func (w *sqlWriter) Write(ctx context.Context, rows []Row) error {
+	if err := w.ensureTable(ctx); err != nil {
+		return err
+	}
+	for len(rows) > 0 {
+		n := min(sqlChunkRows, len(rows))
+		stmt, args := buildInsert(w.table, rows[:n])
+		if _, err := w.pool.Exec(ctx, stmt, args...); err != nil {
+			return classifyPgError(err)
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+func (w *sqlWriter) Close() {
+	w.pool.Close()
+}
+
+func (w *sqlWriter) ensureTable(ctx context.Context) error {
+	if !w.autoCreate {
+		return nil
+	}
+	w.createMu.Lock()
+	defer w.createMu.Unlock()
+	if w.tableExists {
+		return nil
+	}
+	for _, stmt := range createTableStatements(w.table) {
+		if _, err := w.pool.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if w.timescale {
+		// Requires the timescaledb extension; a plain table still works without it.
+		if _, err := w.pool.Exec(ctx, hypertableStatement(w.table)); err != nil {
+			zap.L().Warn("TSDB create_hypertable failed, using a plain table", zap.String("table", w.table), zap.Error(err))
+		}
+	}
+	w.tableExists = true
+	return nil
+}
+
+func createTableStatements(table string) []string {
+	// Index names cannot be schema-qualified; they live in the table's schema.
+	index := table[strings.IndexByte(table, '.')+1:] + "_device_point_time_idx"
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+	time        TIMESTAMPTZ      NOT NULL,
+	channel_id  TEXT             NOT NULL DEFAULT '',
+	device_id   TEXT             NOT NULL,
+	point_id    TEXT             NOT NULL,
+	unit        TEXT             NOT NULL DEFAULT '',
+	point_group TEXT             NOT NULL DEFAULT '',
+	value       DOUBLE PRECISION,
+	value_text  TEXT,
+	quality     TEXT             NOT NULL DEFAULT ''
+)`,
+		`CREATE INDEX IF NOT EXISTS ` + index + ` ON ` + table + ` (device_id, point_id, time DESC)`,
+	}
+}
+
+func hypertableStatement(table string) string {
+	return `SELECT create_hypertable('` + table + `', 'time', if_not_exists => TRUE, migrate_data => TRUE)`
+}
+
+// buildInsert renders one multi-row INSERT; table must already be validated.
+func buildInsert(table string, rows []Row) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(table)
+	sb.WriteString(" (time, channel_id, device_id, point_id, unit, point_group, value, value_text, quality) VALUES ")
+
+	args := make([]any, 0, len(rows)*sqlColumns)
+	for i, r := range rows {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('(')
+		for j := 1; j <= sqlColumns; j++ {
+			if j > 1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(i*sqlColumns + j))
+		}
+		sb.WriteByte(')')
+
+		var num, text any
+		switch v := r.Value.(type) {
+		case float64:
+			num = v
+		case bool:
+			// Booleans keep a numeric value so they can be aggregated.
+			if v {
+				num = 1.0
+			} else {
+				num = 0.0
+			}
+			text = strconv.FormatBool(v)
+		default:
+			text = fmt.Sprint(v)
+		}
+		args = append(args, r.Time, r.Channel, r.Device, r.Point, r.Unit, r.Group, num, text, r.Quality)
+	}
+	return sb.String(), args
+}
+
+// classifyPgError marks data (22) and integrity (23) violations as permanent.
+func classifyPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")) {
+		return &permanentError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+// Package bacnetsc implements the BACnet/SC (Secure Connect, ASHRAE 135 Annex AB) hub
+// connector as a bacnet datalink.DataLink, so the bacnet-ip driver and the northbound
+// BACnet server can reach devices across routed networks over TLS websockets.
+package bacnetsc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// BVLC-SC message functions (Annex AB.2).
+const (
+	FuncBVLCResult           byte = 0x00
+	FuncEncapsulatedNPDU     byte = 0x01
+	FuncAddressResolution    byte = 0x02
+	FuncAddressResolutionACK byte = 0x03
+	FuncAdvertisement        byte = 0x04
+	FuncAdvertisementSolicit byte = 0x05
+	FuncConnectRequest       byte = 0x06
+	FuncConnectAccept        byte = 0x07
+	FuncDisconnectRequest    byte = 0x08
+	FuncDisconnectACK        byte = 0x09
+	FuncHeartbeatRequest     byte = 0x0A
+	FuncHeartbeatACK         byte = 0x0B
+	FuncProprietaryMessage   byte = 0x0C
+)
+
+// Control flags and header option markers.
+const (
+	flagOriginatingVMAC    byte = 0x08
+	flagDestinationVMAC    byte = 0x04
+	flagDestinationOptions byte = 0x02
+	flagDataOptions        byte = 0x01
+	optionMoreFollows      byte = 0x80
+	optionMustUnderstand   byte = 0x40
+	optionHasData          byte = 0x20
+)
+
+const (
+	resultACK byte = 0x00
+	resultNAK byte = 0x01
+
+	errorClassCommunication       = 7
+	errorCodeOptionalNotSupported = 45
+
+	hubSubprotocol = "hub.bsc.bacnet.org"
+
+	// The bacnet library frames every NPDU with a BACnet/IP BVLL header; the SC
+	// datalink strips it on send and synthesizes it on receive.
+	bvlcIPType         byte = 0x81
+	bvlcIPUnicast      byte = 0x0A
+	bvlcIPBroadcast    byte = 0x0B
+	bvlcIPHeaderLength      = 4
+)
+
+// VMAC is a 6-octet BACnet/SC virtual MAC address.
+type VMAC [6]byte
+
+// BroadcastVMAC addresses every node connected to the hub.
+var BroadcastVMAC = VMAC{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+func (v VMAC) String() string {
+	return fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X", v[0], v[1], v[2], v[3], v[4], v[5])
+}
+
+// IsBroadcast reports whether v is the local broadcast VMAC.
+func (v VMAC) IsBroadcast() bool { return v == BroadcastVMAC }
+
+// Message is a decoded BVLC-SC message. Header options are parsed only to be skipped;
+// messages carrying a must-understand option are rejected by Decode.
+type Message struct {
+	Function    byte
+	MessageID   uint16
+	Originating *VMAC
+	Destination *VMAC
+	Payload     []byte
+}
+
+var (
+	errShortMessage   = errors.New("bvlc-sc: message truncated")
+	errMustUnderstand = errors.New("bvlc-sc: unsupported must-understand header option")
+)
+
+// Encode serializes m into a BVLC-SC frame. Options are never emitted.
+func (m Message) Encode() []byte {
+	size := 4 + len(m.Payload)
+	var flags byte
+	if m.Originating != nil {
+		flags |= flagOriginatingVMAC
+		size += 6
+	}
+	if m.Destination != nil {
+		flags |= flagDestinationVMAC
+		size += 6
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, m.Function, flags)
+	buf = binary.BigEndian.AppendUint16(buf, m.MessageID)
+	if m.Originating != nil {
+		buf = append(buf, m.Originating[:]...)
+	}
+	if m.Destination != nil {
+		buf = append(buf, m.Destination[:]...)
+	}
+	return append(buf, m.Payload...)
+}
+
+// Decode parses a BVLC-SC frame.
+func Decode(b []byte) (Message, error) {
+	var m Message
+	if len(b) < 4 {
+		return m, errShortMessage
+	}
+	m.Function = b[0]
+	flags := b[1]
+	m.MessageID = binary.BigEndian.Uint16(b[2:4])
+	p := 4
+	if flags&flagOriginatingVMAC != 0 {
+		if len(b) < p+6 {
+			return m, errShortMessage
+		}
+		var v VMAC
+		copy(v[:], b[p:p+6])
+		m.Originating = &v
+		p += 6
+	}
+	if flags&flagDestinationVMAC != 0 {
+		if len(b) < p+6 {
+			return m, errShortMessage
+		}
+		var v VMAC
+		copy(v[:], b[p:p+6])
+		m.Destination = &v
+		p += 6
+	}
+	for _, present := range []bool{flags&flagDestinationOptions != 0, flags&flagDataOptions != 0} {
+		if !present {
+			continue
+		}
+		next, err := skipOptions(b, p)
+		if err != nil {
+			return m, err
+		}
+		p = next
+	}
+	m.Payload = b[p:]
+	return m, nil
+}
+
+func skipOptions(b []byte, p int) (int, error) {
+	for {
+		if len(b) < p+1 {
+			return p, errShortMessage
+		}
+		marker := b[p]
+		p++
+		if marker&optionMustUnderstand != 0 {
+			return p, errMustUnderstand
+		}
+		if marker&optionHasData != 0 {
+			if len(b) < p+2 {
+				return p, errShortMessage
+			}
+			n := int(binary.BigEndian.Uint16(b[p : p+2]))
+			p += 2 + n
+			if len(b) < p {
+				return p, errShortMessage
+			}
+		}
+		if marker&optionMoreFollows == 0 {
+			return p, nil
+		}
+	}
+}
+
+// connectPayload is the body of Connect-Request and Connect-Accept.
+type connectPayload struct {
+	VMAC          VMAC
+	UUID          [16]byte
+	MaxBVLCLength uint16
+	MaxNPDULength uint16
+}
+
+func (c connectPayload) encode() []byte {
+	buf := make([]byte, 0, 26)
+	buf = append(buf, c.VMAC[:]...)
+	buf = append(buf, c.UUID[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, c.MaxBVLCLength)
+	return binary.BigEndian.AppendUint16(buf, c.MaxNPDULength)
+}
+
+func decodeConnectPayload(b []byte) (connectPayload, error) {
+	var c connectPayload
+	if len(b) < 26 {
+		return c, errShortMessage
+	}
+	copy(c.VMAC[:], b[0:6])
+	copy(c.UUID[:], b[6:22])
+	c.MaxBVLCLength = binary.BigEndian.Uint16(b[22:24])
+	c.MaxNPDULength = binary.BigEndian.Uint16(b[24:26])
+	return c, nil
+}
+
+// resultPayload is the body of a BVLC-Result.
+type resultPayload struct {
+	ForFunction byte
+	NAK         bool
+	ErrorClass  uint16
+	ErrorCode   uint16
+	Details     string
+}
+
+func (r resultPayload) encode() []byte {
+	if !r.NAK {
+		return []byte{r.ForFunction, resultACK}
+	}
+	buf := []byte{r.ForFunction, resultNAK, 0x00}
+	buf = binary.BigEndian.AppendUint16(buf, r.ErrorClass)
+	buf = binary.BigEndian.AppendUint16(buf, r.ErrorCode)
+	return append(buf, r.Details...)
+}
+
+func decodeResultPayload(b []byte) (resultPayload, error) {
+	var r resultPayload
+	if len(b) < 2 {
+		return r, errShortMessage
+	}
+	r.ForFunction = b[0]
+	r.NAK = b[1] == resultNAK
+	if r.NAK && len(b) >= 7 {
+		r.ErrorClass = binary.BigEndian.Uint16(b[3:5])
+		r.ErrorCode = binary.BigEndian.Uint16(b[5:7])
+		r.Details = string(b[7:])
+	}
+	return r, nil
+}
+
+func (r resultPayload) Error() string {
+	if r.Details != "" {
+		return fmt.Sprintf("bvlc-sc NAK for function 0x%02X: class=%d code=%d (%s)", r.ForFunction, r.ErrorClass, r.ErrorCode, r.Details)
+	}
+	return fmt.Sprintf("bvlc-sc NAK for function 0x%02X: class=%d code=%d", r.ForFunction, r.ErrorClass, r.ErrorCode)
+}
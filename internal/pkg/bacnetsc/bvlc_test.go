@@ -0,0 +1,80 @@
+package bacnetsc
+
+import (
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	orig := VMAC{0x02, 1, 2, 3, 4, 5}
+	msg := Message{
+		Function:    FuncEncapsulatedNPDU,
+		MessageID:   0xBEEF,
+		Originating: &orig,
+		Destination: &BroadcastVMAC,
+		Payload:     []byte{0x01, 0x00, 0x10, 0x08},
+	}
+	got, err := Decode(msg.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Function != msg.Function || got.MessageID != msg.MessageID {
+		t.Fatalf("header mismatch: %+v", got)
+	}
+	if got.Originating == nil || *got.Originating != orig {
+		t.Fatalf("originating = %v", got.Originating)
+	}
+	if got.Destination == nil || !got.Destination.IsBroadcast() {
+		t.Fatalf("destination = %v", got.Destination)
+	}
+	if string(got.Payload) != string(msg.Payload) {
+		t.Fatalf("payload = % X", got.Payload)
+	}
+}
+
+func TestDecodeSkipsHeaderOptions(t *testing.T) {
+	// Data option with 2 bytes of data, followed by payload 0xAA.
+	frame := []byte{FuncEncapsulatedNPDU, flagDataOptions, 0x00, 0x01, optionHasData | 0x1F, 0x00, 0x02, 0x11, 0x22, 0xAA}
+	msg, err := Decode(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Payload) != 1 || msg.Payload[0] != 0xAA {
+		t.Fatalf("payload = % X", msg.Payload)
+	}
+
+	frame[4] |= optionMustUnderstand
+	if _, err := Decode(frame); err != errMustUnderstand {
+		t.Fatalf("err = %v, want errMustUnderstand", err)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	if _, err := Decode([]byte{FuncEncapsulatedNPDU, flagOriginatingVMAC, 0, 1, 2}); err == nil {
+		t.Fatal("expected truncation error")
+	}
+}
+
+func TestResolveIdentity(t *testing.T) {
+	v1, u1, err := ResolveIdentity("ch-1", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, u2, _ := ResolveIdentity("ch-1", "", "")
+	if v1 != v2 || u1 != u2 {
+		t.Fatal("derived identity must be stable for the same seed")
+	}
+	if v1[0]&0x0F != 0x02 {
+		t.Fatalf("derived VMAC %s is not random-48 format", v1)
+	}
+
+	v, _, err := ResolveIdentity("ch-1", "0a:1b:2c:3d:4e:5f", "")
+	if err != nil || v.String() != "0A:1B:2C:3D:4E:5F" {
+		t.Fatalf("parsed VMAC = %s, err = %v", v, err)
+	}
+	if _, _, err := ResolveIdentity("ch-1", "FF:FF:FF:FF:FF:FF", ""); err == nil {
+		t.Fatal("broadcast VMAC must be rejected")
+	}
+	if _, _, err := ResolveIdentity("ch-1", "", "not-a-uuid"); err == nil {
+		t.Fatal("invalid UUID must be rejected")
+	}
+}
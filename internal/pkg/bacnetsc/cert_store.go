@@ -0,0 +1,129 @@
+package bacnetsc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func certStoreDir(nodeID string) string {
+	id := strings.TrimSpace(nodeID)
+	if id == "" {
+		id = "default"
+	}
+	return filepath.Join("data", "certs", "bacnet-sc", id)
+}
+
+// MaterializeNodeCerts writes DB-stored PEM to disk, or returns legacy file paths.
+// caFile is empty when no hub CA is configured (system roots are used).
+func MaterializeNodeCerts(nodeID string, cfg model.BACnetSCConfig) (certFile, keyFile, caFile string, err error) {
+	certPEM := strings.TrimSpace(cfg.CertPEM)
+	keyPEM := strings.TrimSpace(cfg.KeyPEM)
+	dir := certStoreDir(nodeID)
+
+	switch {
+	case certPEM != "" && keyPEM != "":
+		if err := ValidateNodePEMPair(certPEM, keyPEM); err != nil {
+			return "", "", "", err
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", "", "", fmt.Errorf("create cert dir: %w", err)
+		}
+		certFile = filepath.Join(dir, "node.crt")
+		keyFile = filepath.Join(dir, "node.key")
+		if err := os.WriteFile(certFile, []byte(normalizePEM(certPEM)), 0o600); err != nil {
+			return "", "", "", err
+		}
+		if err := os.WriteFile(keyFile, []byte(normalizePEM(keyPEM)), 0o600); err != nil {
+			return "", "", "", err
+		}
+	case certPEM != "" || keyPEM != "":
+		return "", "", "", fmt.Errorf("node certificate and private key must both be configured")
+	case cfg.CertFile != "" && cfg.KeyFile != "":
+		certFile, keyFile = cfg.CertFile, cfg.KeyFile
+	}
+
+	if len(cfg.CACertsPEM) == 0 {
+		return certFile, keyFile, cfg.CAFile, nil
+	}
+	var bundle strings.Builder
+	for i, raw := range cfg.CACertsPEM {
+		pemText := normalizePEM(raw)
+		if strings.TrimSpace(pemText) == "" {
+			continue
+		}
+		if _, err := parseCertificatePEM(pemText); err != nil {
+			return "", "", "", fmt.Errorf("hub CA cert %d: %w", i, err)
+		}
+		bundle.WriteString(pemText)
+	}
+	if bundle.Len() == 0 {
+		return certFile, keyFile, cfg.CAFile, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", "", "", fmt.Errorf("create cert dir: %w", err)
+	}
+	caFile = filepath.Join(dir, "hub_ca.pem")
+	if err := os.WriteFile(caFile, []byte(bundle.String()), 0o644); err != nil {
+		return "", "", "", err
+	}
+	return certFile, keyFile, caFile, nil
+}
+
+// LoadTLSConfig materializes the node certificates and builds the mutual-TLS client config.
+// BACnet/SC requires a node operational certificate, so a missing pair is an error.
+func LoadTLSConfig(nodeID string, cfg model.BACnetSCConfig) (*tls.Config, error) {
+	certFile, keyFile, caFile, err := MaterializeNodeCerts(nodeID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("bacnet/sc: node operational certificate is not configured")
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("bacnet/sc: load node certificate: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("bacnet/sc: read hub CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("bacnet/sc: no certificates found in hub CA %s", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+// ValidateNodePEMPair checks that cert and private key PEM match.
+func ValidateNodePEMPair(certPEM, keyPEM string) error {
+	if _, err := tls.X509KeyPair([]byte(normalizePEM(certPEM)), []byte(normalizePEM(keyPEM))); err != nil {
+		return fmt.Errorf("certificate and private key do not match: %w", err)
+	}
+	return nil
+}
+
+func normalizePEM(s string) string {
+	return strings.TrimSpace(s) + "\n"
+}
+
+func parseCertificatePEM(pemText string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(pemText))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("PEM block type CERTIFICATE required")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
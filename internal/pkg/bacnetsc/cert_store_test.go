@@ -0,0 +1,86 @@
+package bacnetsc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+)
+
+func generateTestPEMPair(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-bacnet-sc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestLoadTLSConfigFromPEM(t *testing.T) {
+	dir := t.TempDir()
+	origWD, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(origWD) })
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM, keyPEM := generateTestPEMPair(t)
+	caPEM, _ := generateTestPEMPair(t)
+	cfg := model.BACnetSCConfig{CertPEM: certPEM, KeyPEM: keyPEM, CACertsPEM: []string{caPEM}}
+
+	tlsCfg, err := LoadTLSConfig("nb-1", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsCfg.Certificates) != 1 || tlsCfg.RootCAs == nil {
+		t.Fatal("expected client certificate and hub CA pool")
+	}
+	for _, name := range []string{"node.crt", "node.key", "hub_ca.pem"} {
+		if _, err := os.Stat(filepath.Join("data", "certs", "bacnet-sc", "nb-1", name)); err != nil {
+			t.Fatalf("%s not materialized: %v", name, err)
+		}
+	}
+}
+
+func TestLoadTLSConfigRequiresNodeCertificate(t *testing.T) {
+	if _, err := LoadTLSConfig("nb-2", model.BACnetSCConfig{}); err == nil {
+		t.Fatal("expected error without node certificate")
+	}
+	certPEM, _ := generateTestPEMPair(t)
+	if _, _, _, err := MaterializeNodeCerts("nb-2", model.BACnetSCConfig{CertPEM: certPEM}); err == nil {
+		t.Fatal("expected error for certificate without key")
+	}
+	_, otherKey := generateTestPEMPair(t)
+	if err := ValidateNodePEMPair(certPEM, otherKey); err == nil {
+		t.Fatal("expected mismatch error")
+	}
+}
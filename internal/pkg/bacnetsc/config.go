@@ -0,0 +1,94 @@
+package bacnetsc
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHeartbeatInterval = 300 * time.Second
+	defaultConnectTimeout    = 10 * time.Second
+	defaultReconnectMin      = 2 * time.Second
+	defaultReconnectMax      = 300 * time.Second
+	defaultMaxBVLCLength     = 1600
+	defaultMaxNPDULength     = 1497
+)
+
+// Config configures a HubConnector.
+type Config struct {
+	PrimaryHubURI     string
+	FailoverHubURI    string
+	VMAC              VMAC
+	UUID              uuid.UUID
+	TLSConfig         *tls.Config
+	HeartbeatInterval time.Duration
+	ConnectTimeout    time.Duration
+	ReconnectMin      time.Duration
+	ReconnectMax      time.Duration
+	MaxBVLCLength     uint16
+	MaxNPDULength     uint16
+}
+
+// ConfigFromModel resolves identity, certificates and timers from a persisted config.
+// nodeID is the owning channel or northbound server ID; it seeds the derived VMAC/UUID
+// and names the certificate directory.
+func ConfigFromModel(nodeID string, cfg model.BACnetSCConfig) (Config, error) {
+	if strings.TrimSpace(cfg.PrimaryHubURI) == "" {
+		return Config{}, fmt.Errorf("bacnet/sc: primary hub URI is required")
+	}
+	vmac, id, err := ResolveIdentity(nodeID, cfg.VMAC, cfg.UUID)
+	if err != nil {
+		return Config{}, err
+	}
+	tlsCfg, err := LoadTLSConfig(nodeID, cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		PrimaryHubURI:     strings.TrimSpace(cfg.PrimaryHubURI),
+		FailoverHubURI:    strings.TrimSpace(cfg.FailoverHubURI),
+		VMAC:              vmac,
+		UUID:              id,
+		TLSConfig:         tlsCfg,
+		HeartbeatInterval: seconds(cfg.HeartbeatInterval),
+		ConnectTimeout:    seconds(cfg.ConnectTimeout),
+		ReconnectMin:      seconds(cfg.ReconnectMin),
+		ReconnectMax:      seconds(cfg.ReconnectMax),
+	}, nil
+}
+
+func seconds(n int) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+func (c *Config) applyDefaults() {
+	if c.HeartbeatInterval <= 0 {
+		c.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = defaultConnectTimeout
+	}
+	if c.ReconnectMin <= 0 {
+		c.ReconnectMin = defaultReconnectMin
+	}
+	if c.ReconnectMax < c.ReconnectMin {
+		c.ReconnectMax = defaultReconnectMax
+		if c.ReconnectMax < c.ReconnectMin {
+			c.ReconnectMax = c.ReconnectMin
+		}
+	}
+	if c.MaxBVLCLength == 0 {
+		c.MaxBVLCLength = defaultMaxBVLCLength
+	}
+	if c.MaxNPDULength == 0 {
+		c.MaxNPDULength = defaultMaxNPDULength
+	}
+}
@@ -0,0 +1,500 @@
+package bacnetsc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anviod/bacnet/btypes"
+	"github.com/anviod/bacnet/datalink"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Hub connection states reported by Status.
+const (
+	StateConnecting        = "connecting"
+	StateConnectedPrimary  = "connected_primary"
+	StateConnectedFailover = "connected_failover"
+	StateDisconnected      = "disconnected"
+	StateClosed            = "closed"
+)
+
+// ErrClosed is returned by Send/Receive after Close.
+var ErrClosed = errors.New("bacnet/sc: hub connector closed")
+
+// Status is a snapshot of the hub connection for stats/metrics APIs.
+type Status struct {
+	State       string    `json:"state"`
+	HubURI      string    `json:"hub_uri,omitempty"`
+	VMAC        string    `json:"vmac"`
+	UUID        string    `json:"uuid"`
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+	Reconnects  int64     `json:"reconnects"`
+	TxFrames    uint64    `json:"tx_frames"`
+	RxFrames    uint64    `json:"rx_frames"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+}
+
+type inboundNPDU struct {
+	src       VMAC
+	broadcast bool
+	npdu      []byte
+}
+
+// HubConnector is a BACnet/SC hub connector (Annex AB.5.2) that satisfies datalink.DataLink.
+// It keeps one websocket to the primary hub, falls back to the failover hub, and
+// reconnects with exponential backoff until Close is called.
+type HubConnector struct {
+	cfg    Config
+	dialer websocket.Dialer
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	status Status
+
+	writeMu sync.Mutex
+	msgID   atomic.Uint32
+	tx      atomic.Uint64
+	rx      atomic.Uint64
+
+	inbound   chan inboundNPDU
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ datalink.DataLink = (*HubConnector)(nil)
+
+// Dial connects to the primary hub (or the failover hub when the primary is unreachable)
+// and returns a running connector. An error means neither hub accepted the connection.
+func Dial(ctx context.Context, cfg Config) (*HubConnector, error) {
+	cfg.applyDefaults()
+	if cfg.PrimaryHubURI == "" {
+		return nil, fmt.Errorf("bacnet/sc: primary hub URI is required")
+	}
+	h := &HubConnector{
+		cfg: cfg,
+		dialer: websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: cfg.ConnectTimeout,
+			TLSClientConfig:  cfg.TLSConfig,
+			Subprotocols:     []string{hubSubprotocol},
+		},
+		inbound: make(chan inboundNPDU, 256),
+		done:    make(chan struct{}),
+	}
+	h.status = Status{State: StateConnecting, VMAC: cfg.VMAC.String(), UUID: cfg.UUID.String()}
+
+	conn, err := h.connectAny(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go h.supervise(conn)
+	return h, nil
+}
+
+// Status returns a snapshot of the hub connection.
+func (h *HubConnector) Status() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := h.status
+	st.TxFrames = h.tx.Load()
+	st.RxFrames = h.rx.Load()
+	return st
+}
+
+// GetMyAddress returns the local VMAC as a 6-octet BACnet MAC.
+func (h *HubConnector) GetMyAddress() *btypes.Address {
+	mac := h.cfg.VMAC
+	return &btypes.Address{Mac: mac[:], MacLen: 6}
+}
+
+// GetBroadcastAddress returns MacLen=0, which the bacnet library treats as local broadcast.
+func (h *HubConnector) GetBroadcastAddress() *btypes.Address {
+	mac := BroadcastVMAC
+	return &btypes.Address{Mac: mac[:], MacLen: 0}
+}
+
+// Send strips the library's BACnet/IP BVLL header and forwards the NPDU to the hub
+// as an Encapsulated-NPDU addressed to dest's VMAC (or the broadcast VMAC).
+func (h *HubConnector) Send(data []byte, _ *btypes.NPDU, dest *btypes.Address) (int, error) {
+	payload := data
+	if len(data) >= bvlcIPHeaderLength && data[0] == bvlcIPType {
+		payload = data[bvlcIPHeaderLength:]
+	}
+	dst := BroadcastVMAC
+	if dest != nil && !dest.IsBroadcast() && len(dest.Mac) >= 6 {
+		copy(dst[:], dest.Mac[:6])
+	}
+	msg := Message{
+		Function:    FuncEncapsulatedNPDU,
+		MessageID:   h.nextMessageID(),
+		Destination: &dst,
+		Payload:     payload,
+	}
+	if err := h.write(msg); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Receive blocks until the hub delivers an NPDU and returns it framed with a synthetic
+// BACnet/IP BVLL header so the bacnet library's decoder can consume it unchanged.
+func (h *HubConnector) Receive(data []byte) (*btypes.Address, int, error) {
+	select {
+	case <-h.done:
+		return nil, 0, ErrClosed
+	case in := <-h.inbound:
+		fn := bvlcIPUnicast
+		if in.broadcast {
+			fn = bvlcIPBroadcast
+		}
+		total := bvlcIPHeaderLength + len(in.npdu)
+		if len(data) < total {
+			return nil, 0, fmt.Errorf("bacnet/sc: receive buffer %d too small for %d byte frame", len(data), total)
+		}
+		data[0] = bvlcIPType
+		data[1] = fn
+		binary.BigEndian.PutUint16(data[2:4], uint16(total))
+		copy(data[bvlcIPHeaderLength:], in.npdu)
+		src := in.src
+		return &btypes.Address{Mac: src[:], MacLen: 6}, total, nil
+	}
+}
+
+// Close sends Disconnect-Request to the hub and stops reconnecting.
+func (h *HubConnector) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		h.mu.Lock()
+		conn := h.conn
+		h.conn = nil
+		h.status.State = StateClosed
+		h.mu.Unlock()
+		if conn != nil {
+			h.writeTo(conn, Message{Function: FuncDisconnectRequest, MessageID: h.nextMessageID()})
+			_ = conn.Close()
+		}
+	})
+	return nil
+}
+
+func (h *HubConnector) nextMessageID() uint16 {
+	return uint16(h.msgID.Add(1))
+}
+
+func (h *HubConnector) closed() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (h *HubConnector) write(msg Message) error {
+	h.mu.Lock()
+	conn := h.conn
+	h.mu.Unlock()
+	if h.closed() {
+		return ErrClosed
+	}
+	if conn == nil {
+		return fmt.Errorf("bacnet/sc: not connected to hub")
+	}
+	return h.writeTo(conn, msg)
+}
+
+func (h *HubConnector) writeTo(conn *websocket.Conn, msg Message) error {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+	_ = conn.SetWriteDeadline(time.Now().Add(h.cfg.ConnectTimeout))
+	if err := conn.WriteMessage(websocket.BinaryMessage, msg.Encode()); err != nil {
+		return fmt.Errorf("bacnet/sc: write to hub: %w", err)
+	}
+	h.tx.Add(1)
+	return nil
+}
+
+// connectAny tries the primary hub first and the failover hub second.
+func (h *HubConnector) connectAny(ctx context.Context) (*websocket.Conn, error) {
+	conn, err := h.connect(ctx, h.cfg.PrimaryHubURI, StateConnectedPrimary)
+	if err == nil {
+		return conn, nil
+	}
+	h.recordError(err)
+	if h.cfg.FailoverHubURI == "" {
+		return nil, err
+	}
+	zap.L().Warn("BACnet/SC primary hub unreachable, trying failover",
+		zap.String("primary", h.cfg.PrimaryHubURI), zap.Error(err))
+	conn, ferr := h.connect(ctx, h.cfg.FailoverHubURI, StateConnectedFailover)
+	if ferr != nil {
+		h.recordError(ferr)
+		return nil, fmt.Errorf("bacnet/sc: primary hub: %v; failover hub: %w", err, ferr)
+	}
+	return conn, nil
+}
+
+// connect opens the websocket and performs the Connect-Request/Connect-Accept exchange.
+func (h *HubConnector) connect(ctx context.Context, uri, state string) (*websocket.Conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, h.cfg.ConnectTimeout)
+	defer cancel()
+	conn, _, err := h.dialer.DialContext(dialCtx, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bacnet/sc: dial %s: %w", uri, err)
+	}
+	if conn.Subprotocol() != hubSubprotocol {
+		_ = conn.Close()
+		return nil, fmt.Errorf("bacnet/sc: %s did not negotiate %s", uri, hubSubprotocol)
+	}
+
+	req := Message{
+		Function:  FuncConnectRequest,
+		MessageID: h.nextMessageID(),
+		Payload: connectPayload{
+			VMAC:          h.cfg.VMAC,
+			UUID:          h.cfg.UUID,
+			MaxBVLCLength: h.cfg.MaxBVLCLength,
+			MaxNPDULength: h.cfg.MaxNPDULength,
+		}.encode(),
+	}
+	if err := h.writeTo(conn, req); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(h.cfg.ConnectTimeout))
+	_, frame, err := conn.ReadMessage()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("bacnet/sc: await Connect-Accept from %s: %w", uri, err)
+	}
+	msg, err := Decode(frame)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	switch msg.Function {
+	case FuncConnectAccept:
+		if _, err := decodeConnectPayload(msg.Payload); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("bacnet/sc: malformed Connect-Accept: %w", err)
+		}
+	case FuncBVLCResult:
+		_ = conn.Close()
+		res, derr := decodeResultPayload(msg.Payload)
+		if derr != nil {
+			return nil, derr
+		}
+		return nil, res
+	default:
+		_ = conn.Close()
+		return nil, fmt.Errorf("bacnet/sc: unexpected function 0x%02X during connect", msg.Function)
+	}
+
+	h.mu.Lock()
+	if h.closed() {
+		h.mu.Unlock()
+		_ = conn.Close()
+		return nil, ErrClosed
+	}
+	h.conn = conn
+	h.status.State = state
+	h.status.HubURI = uri
+	h.status.ConnectedAt = time.Now()
+	h.mu.Unlock()
+
+	zap.L().Info("BACnet/SC hub connected",
+		zap.String("hub", uri), zap.String("vmac", h.cfg.VMAC.String()))
+	return conn, nil
+}
+
+func (h *HubConnector) recordError(err error) {
+	if err == nil {
+		return
+	}
+	h.mu.Lock()
+	h.status.LastError = err.Error()
+	h.status.LastErrorAt = time.Now()
+	h.mu.Unlock()
+}
+
+// supervise serves conn until it fails, then reconnects with backoff until Close.
+func (h *HubConnector) supervise(conn *websocket.Conn) {
+	for conn != nil {
+		err := h.serve(conn)
+		_ = conn.Close()
+		if h.closed() {
+			return
+		}
+		h.recordError(err)
+		zap.L().Warn("BACnet/SC hub connection lost", zap.Error(err))
+
+		h.mu.Lock()
+		h.conn = nil
+		h.status.State = StateDisconnected
+		h.mu.Unlock()
+
+		conn = h.reconnect()
+	}
+}
+
+func (h *HubConnector) reconnect() *websocket.Conn {
+	wait := h.cfg.ReconnectMin
+	for {
+		select {
+		case <-h.done:
+			return nil
+		case <-time.After(wait):
+		}
+		h.mu.Lock()
+		h.status.State = StateConnecting
+		h.mu.Unlock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-h.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		conn, err := h.connectAny(ctx)
+		cancel()
+		if err == nil {
+			h.mu.Lock()
+			h.status.Reconnects++
+			h.mu.Unlock()
+			return conn
+		}
+		if h.closed() {
+			return nil
+		}
+		wait *= 2
+		if wait > h.cfg.ReconnectMax {
+			wait = h.cfg.ReconnectMax
+		}
+	}
+}
+
+// serve reads frames until the connection fails. A Heartbeat-Request is sent whenever
+// the link has been idle for HeartbeatInterval; silence for twice that drops the link.
+func (h *HubConnector) serve(conn *websocket.Conn) error {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var lastRx atomic.Int64
+	lastRx.Store(time.Now().UnixNano())
+	go func() {
+		ticker := time.NewTicker(h.cfg.HeartbeatInterval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-h.done:
+				return
+			case <-ticker.C:
+				if time.Since(time.Unix(0, lastRx.Load())) >= h.cfg.HeartbeatInterval {
+					_ = h.writeTo(conn, Message{Function: FuncHeartbeatRequest, MessageID: h.nextMessageID()})
+				}
+			}
+		}
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * h.cfg.HeartbeatInterval))
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		lastRx.Store(time.Now().UnixNano())
+		h.rx.Add(1)
+
+		msg, err := Decode(frame)
+		if err != nil {
+			zap.L().Debug("BACnet/SC dropped malformed frame", zap.Error(err))
+			continue
+		}
+		if err := h.handle(conn, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *HubConnector) handle(conn *websocket.Conn, msg Message) error {
+	switch msg.Function {
+	case FuncEncapsulatedNPDU:
+		if msg.Originating == nil {
+			return nil
+		}
+		in := inboundNPDU{
+			src:       *msg.Originating,
+			broadcast: msg.Destination != nil && msg.Destination.IsBroadcast(),
+			npdu:      append([]byte(nil), msg.Payload...),
+		}
+		select {
+		case h.inbound <- in:
+		case <-h.done:
+		}
+	case FuncHeartbeatRequest:
+		return h.writeTo(conn, Message{Function: FuncHeartbeatACK, MessageID: msg.MessageID})
+	case FuncHeartbeatACK, FuncAdvertisement, FuncAddressResolutionACK:
+	case FuncDisconnectRequest:
+		_ = h.writeTo(conn, Message{Function: FuncDisconnectACK, MessageID: msg.MessageID})
+		return fmt.Errorf("bacnet/sc: hub requested disconnect")
+	case FuncAdvertisementSolicit:
+		return h.writeTo(conn, Message{
+			Function:    FuncAdvertisement,
+			MessageID:   msg.MessageID,
+			Destination: msg.Originating,
+			Payload:     h.advertisement(),
+		})
+	case FuncAddressResolution:
+		// Direct connections are not accepted, so no WebSocket URIs are advertised.
+		return h.writeTo(conn, Message{Function: FuncAddressResolutionACK, MessageID: msg.MessageID, Destination: msg.Originating})
+	case FuncBVLCResult:
+		if res, err := decodeResultPayload(msg.Payload); err == nil && res.NAK {
+			zap.L().Debug("BACnet/SC hub returned NAK", zap.String("error", res.Error()))
+		}
+	default:
+		if msg.Destination != nil && msg.Destination.IsBroadcast() {
+			return nil
+		}
+		return h.writeTo(conn, Message{
+			Function:    FuncBVLCResult,
+			MessageID:   msg.MessageID,
+			Destination: msg.Originating,
+			Payload: resultPayload{
+				ForFunction: msg.Function,
+				NAK:         true,
+				ErrorClass:  errorClassCommunication,
+				ErrorCode:   errorCodeOptionalNotSupported,
+			}.encode(),
+		})
+	}
+	return nil
+}
+
+func (h *HubConnector) advertisement() []byte {
+	h.mu.Lock()
+	state := h.status.State
+	h.mu.Unlock()
+	var hubStatus byte
+	switch state {
+	case StateConnectedPrimary:
+		hubStatus = 1
+	case StateConnectedFailover:
+		hubStatus = 2
+	}
+	buf := []byte{hubStatus, 0} // accept direct connections: false
+	buf = binary.BigEndian.AppendUint16(buf, h.cfg.MaxBVLCLength)
+	return binary.BigEndian.AppendUint16(buf, h.cfg.MaxNPDULength)
+}
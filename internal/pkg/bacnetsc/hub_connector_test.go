@@ -0,0 +1,265 @@
+package bacnetsc
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anviod/bacnet/btypes"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// testHub is a minimal BACnet/SC hub function: it accepts Connect-Request and
+// records Encapsulated-NPDUs so tests can assert on connector output.
+type testHub struct {
+	t        *testing.T
+	srv      *httptest.Server
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	received chan Message
+	nak      bool
+}
+
+func newTestHub(t *testing.T) *testHub {
+	t.Helper()
+	h := &testHub{t: t, received: make(chan Message, 16)}
+	upgrader := websocket.Upgrader{Subprotocols: []string{hubSubprotocol}}
+	h.srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		go h.serve(conn)
+	}))
+	t.Cleanup(h.srv.Close)
+	return h
+}
+
+func (h *testHub) uri() string {
+	return "wss" + strings.TrimPrefix(h.srv.URL, "https")
+}
+
+func (h *testHub) serve(conn *websocket.Conn) {
+	_, frame, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	req, err := Decode(frame)
+	if err != nil || req.Function != FuncConnectRequest {
+		_ = conn.Close()
+		return
+	}
+	if h.nak {
+		_ = conn.WriteMessage(websocket.BinaryMessage, Message{
+			Function:  FuncBVLCResult,
+			MessageID: req.MessageID,
+			Payload:   resultPayload{ForFunction: FuncConnectRequest, NAK: true, ErrorClass: 7, ErrorCode: 1}.encode(),
+		}.Encode())
+		_ = conn.Close()
+		return
+	}
+	accept := connectPayload{VMAC: VMAC{0x12, 0, 0, 0, 0, 1}, MaxBVLCLength: 1600, MaxNPDULength: 1497}
+	_ = conn.WriteMessage(websocket.BinaryMessage, Message{Function: FuncConnectAccept, MessageID: req.MessageID, Payload: accept.encode()}.Encode())
+
+	h.mu.Lock()
+	h.conns = append(h.conns, conn)
+	h.mu.Unlock()
+
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if msg, err := Decode(frame); err == nil {
+			h.received <- msg
+		}
+	}
+}
+
+func (h *testHub) push(t *testing.T, msg Message) {
+	t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.conns) == 0 {
+		t.Fatal("no connector attached to hub")
+	}
+	if err := h.conns[len(h.conns)-1].WriteMessage(websocket.BinaryMessage, msg.Encode()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testConfig(primary, failover string) Config {
+	return Config{
+		PrimaryHubURI:  primary,
+		FailoverHubURI: failover,
+		VMAC:           DeriveVMAC("test-node"),
+		UUID:           uuid.New(),
+		TLSConfig:      &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // test hub uses a self-signed cert
+		ConnectTimeout: 2 * time.Second,
+		ReconnectMin:   50 * time.Millisecond,
+		ReconnectMax:   200 * time.Millisecond,
+	}
+}
+
+func TestHubConnectorSendStripsBVLLAndAddressesVMAC(t *testing.T) {
+	hub := newTestHub(t)
+	h, err := Dial(context.Background(), testConfig(hub.uri(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if st := h.Status(); st.State != StateConnectedPrimary {
+		t.Fatalf("state = %s, want %s", st.State, StateConnectedPrimary)
+	}
+
+	dest := VMAC{0x22, 1, 2, 3, 4, 5}
+	frame := []byte{bvlcIPType, bvlcIPUnicast, 0x00, 0x07, 0x01, 0x04, 0xAA}
+	if _, err := h.Send(frame, nil, &btypes.Address{Mac: dest[:], MacLen: 6}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-hub.received:
+		if msg.Function != FuncEncapsulatedNPDU || msg.Destination == nil || *msg.Destination != dest {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+		if string(msg.Payload) != string(frame[bvlcIPHeaderLength:]) {
+			t.Fatalf("payload = % X, want NPDU only", msg.Payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("hub did not receive NPDU")
+	}
+
+	if _, err := h.Send(frame, nil, h.GetBroadcastAddress()); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-hub.received
+	if msg.Destination == nil || !msg.Destination.IsBroadcast() {
+		t.Fatalf("broadcast send used destination %v", msg.Destination)
+	}
+}
+
+func TestHubConnectorReceiveSynthesizesBVLL(t *testing.T) {
+	hub := newTestHub(t)
+	h, err := Dial(context.Background(), testConfig(hub.uri(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	src := VMAC{0x32, 9, 8, 7, 6, 5}
+	hub.push(t, Message{
+		Function:    FuncEncapsulatedNPDU,
+		MessageID:   7,
+		Originating: &src,
+		Destination: &BroadcastVMAC,
+		Payload:     []byte{0x01, 0x20, 0xFF, 0xFF},
+	})
+
+	buf := make([]byte, 1500)
+	addr, n, err := h.Receive(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 || buf[0] != bvlcIPType || buf[1] != bvlcIPBroadcast || buf[3] != 8 {
+		t.Fatalf("frame = % X", buf[:n])
+	}
+	if addr.MacLen != 6 || string(addr.Mac) != string(src[:]) {
+		t.Fatalf("source address = %+v", addr)
+	}
+}
+
+func TestHubConnectorAnswersHeartbeat(t *testing.T) {
+	hub := newTestHub(t)
+	h, err := Dial(context.Background(), testConfig(hub.uri(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	hub.push(t, Message{Function: FuncHeartbeatRequest, MessageID: 42})
+	select {
+	case msg := <-hub.received:
+		if msg.Function != FuncHeartbeatACK || msg.MessageID != 42 {
+			t.Fatalf("unexpected reply %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no Heartbeat-ACK")
+	}
+}
+
+func TestHubConnectorFailover(t *testing.T) {
+	primary := newTestHub(t)
+	primary.nak = true
+	failover := newTestHub(t)
+
+	h, err := Dial(context.Background(), testConfig(primary.uri(), failover.uri()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	st := h.Status()
+	if st.State != StateConnectedFailover || st.HubURI != failover.uri() {
+		t.Fatalf("status = %+v", st)
+	}
+	if st.LastError == "" {
+		t.Fatal("primary NAK should be recorded as last error")
+	}
+}
+
+func TestHubConnectorReconnectsAfterHubDisconnect(t *testing.T) {
+	hub := newTestHub(t)
+	h, err := Dial(context.Background(), testConfig(hub.uri(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	hub.push(t, Message{Function: FuncDisconnectRequest, MessageID: 3})
+	if msg := <-hub.received; msg.Function != FuncDisconnectACK {
+		t.Fatalf("expected Disconnect-ACK, got %+v", msg)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if st := h.Status(); st.Reconnects > 0 && st.State == StateConnectedPrimary {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("connector did not reconnect: %+v", h.Status())
+}
+
+func TestHubConnectorCloseUnblocksReceive(t *testing.T) {
+	hub := newTestHub(t)
+	h, err := Dial(context.Background(), testConfig(hub.uri(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := h.Receive(make([]byte, 1500))
+		errCh <- err
+	}()
+	_ = h.Close()
+
+	select {
+	case err := <-errCh:
+		if err != ErrClosed {
+			t.Fatalf("Receive error = %v, want ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Receive did not return after Close")
+	}
+	if msg := <-hub.received; msg.Function != FuncDisconnectRequest {
+		t.Fatalf("expected Disconnect-Request on close, got %+v", msg)
+	}
+}
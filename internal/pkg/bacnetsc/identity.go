@@ -0,0 +1,62 @@
+package bacnetsc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// identityNamespace scopes UUIDs derived from gateway-local IDs.
+var identityNamespace = uuid.MustParse("5f0c2b8e-3d4a-4c61-9a57-bacbacbac5c0")
+
+// ParseVMAC accepts "0A:1B:2C:3D:4E:5F", "0a-1b-..." or plain 12-digit hex.
+func ParseVMAC(s string) (VMAC, error) {
+	var v VMAC
+	clean := strings.NewReplacer(":", "", "-", "", " ", "").Replace(strings.TrimSpace(s))
+	raw, err := hex.DecodeString(clean)
+	if err != nil || len(raw) != 6 {
+		return v, fmt.Errorf("invalid BACnet/SC VMAC %q: want 6 hex octets", s)
+	}
+	copy(v[:], raw)
+	if v.IsBroadcast() {
+		return v, fmt.Errorf("invalid BACnet/SC VMAC %q: broadcast VMAC cannot be assigned to a node", s)
+	}
+	return v, nil
+}
+
+// DeriveVMAC returns a stable random-48 VMAC for seed. Annex AB.1.5.2 requires the
+// low nibble of the first octet to be 0x2 (locally administered, unicast).
+func DeriveVMAC(seed string) VMAC {
+	sum := sha256.Sum256([]byte("bacnet-sc-vmac:" + seed))
+	var v VMAC
+	copy(v[:], sum[:6])
+	v[0] = (v[0] & 0xF0) | 0x02
+	return v
+}
+
+// ResolveIdentity returns the configured VMAC/UUID, deriving stable values from seed
+// when either is empty so a node keeps its identity across restarts.
+func ResolveIdentity(seed, vmac, id string) (VMAC, uuid.UUID, error) {
+	var v VMAC
+	if strings.TrimSpace(vmac) != "" {
+		parsed, err := ParseVMAC(vmac)
+		if err != nil {
+			return v, uuid.Nil, err
+		}
+		v = parsed
+	} else {
+		v = DeriveVMAC(seed)
+	}
+
+	if strings.TrimSpace(id) != "" {
+		u, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return v, uuid.Nil, fmt.Errorf("invalid BACnet/SC device UUID %q: %w", id, err)
+		}
+		return v, u, nil
+	}
+	return v, uuid.NewSHA1(identityNamespace, []byte(seed)), nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/anviod/edgex/internal/ai_agent"
 	"github.com/anviod/edgex/internal/ai_agent/aitypes"
 	"github.com/anviod/edgex/internal/ai_agent/pipeline"
+	"github.com/anviod/edgex/internal/model"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -162,6 +163,9 @@ func (s *Server) postAiValidate(c *fiber.Ctx) error {
 func (s *Server) postAiEdgeRuleDraft(c *fiber.Ctx) error {
 	var body struct {
 		Description string `json:"description"`
+		// 可选：保存前用样例值试运行编辑后的规则
+		Rule   *model.EdgeRule `json:"rule"`
+		Values []model.Value   `json:"values"`
 	}
 	if err := c.BodyParser(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -170,12 +174,20 @@ func (s *Server) postAiEdgeRuleDraft(c *fiber.Ctx) error {
 	}
 	runner := pipeline.NewMockRunner(s.ensureAiAgent().Mode())
 	draft := runner.GenerateEdgeRuleDraft(body.Description)
+	data := fiber.Map{
+		"draft": draft,
+		"mode":  s.ensureAiAgent().Mode(),
+	}
+	if body.Rule != nil && len(body.Values) > 0 && s.ecm != nil {
+		if sim, err := s.ecm.SimulateRule(*body.Rule, body.Values); err != nil {
+			data["simulation_error"] = err.Error()
+		} else {
+			data["simulation"] = sim
+		}
+	}
 	return c.JSON(fiber.Map{
 		"code": "0", "message": "success",
-		"data": fiber.Map{
-			"draft": draft,
-			"mode":  s.ensureAiAgent().Mode(),
-		},
+		"data": data,
 	})
 }
 
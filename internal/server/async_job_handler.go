@@ -93,3 +93,48 @@ func (s *Server) cancelAsyncJob(c *fiber.Ctx) error {
 	job, _ := jobs.Get(id)
 	return c.JSON(job)
 }
+
+// browseDevice returns one level of the device address space (sync; one Browse per call).
+// Query: node_id (default Objects folder), offset, limit.
+func (s *Server) browseDevice(c *fiber.Ctx) error {
+	params := map[string]any{
+		"node_id": c.Query("node_id"),
+		"offset":  c.Query("offset"),
+		"limit":   c.Query("limit"),
+	}
+	ctx, cancel := context.WithTimeout(c.UserContext(), 45*time.Second)
+	defer cancel()
+	result, err := s.cm.BrowseDevice(ctx, c.Params("channelId"), c.Params("deviceId"), params)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+// importBrowsedPoints expands selected nodes into points as an async job by default.
+// Body: {"node_ids": [...], "max_depth": 5, "max_points": 2000, "dry_run": false}
+func (s *Server) importBrowsedPoints(c *fiber.Ctx) error {
+	channelId := c.Params("channelId")
+	deviceId := c.Params("deviceId")
+
+	var params map[string]any
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid JSON body"})
+	}
+
+	if wantSyncScan(c) {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 180*time.Second)
+		defer cancel()
+		result, err := s.cm.ImportBrowsedPoints(ctx, channelId, deviceId, params)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.JSON(result)
+	}
+
+	job, err := s.cm.StartImportBrowsedPointsJob(channelId, deviceId, params)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// 规则版本历史、影子运行、晋升与回滚；作者取自登录用户，备注通过 ?comment= 传入
+
+func (s *Server) getEdgeRuleVersions(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	history, err := s.ecm.GetRuleHistory(c.Params("id"))
+	if err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(history)
+}
+
+func (s *Server) getEdgeRuleVersion(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "版本号无效"})
+	}
+	v, err := s.ecm.GetRuleVersion(c.Params("id"), version)
+	if err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(v)
+}
+
+func (s *Server) rollbackEdgeRule(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "版本号无效"})
+	}
+	author, _ := s.requestRole(c)
+	v, err := s.ecm.RollbackRule(c.Params("id"), version, author, c.Query("comment"))
+	if err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(v)
+}
+
+func (s *Server) getEdgeRuleShadow(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	status, err := s.ecm.ShadowStatus(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(status)
+}
+
+// stageEdgeRule 以影子模式运行规则的新版本，生效版本保持不变
+func (s *Server) stageEdgeRule(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	var rule model.EdgeRule
+	if err := c.BodyParser(&rule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	id := c.Params("id")
+	if rule.ID == "" {
+		rule.ID = id
+	}
+	if rule.ID != id {
+		return c.Status(400).JSON(fiber.Map{"error": "规则 ID 与路径不一致"})
+	}
+	author, _ := s.requestRole(c)
+	v, err := s.ecm.StageRule(rule, author, c.Query("comment"))
+	if err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(v)
+}
+
+func (s *Server) cancelEdgeRuleShadow(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	if err := s.ecm.CancelShadow(c.Params("id")); err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+func (s *Server) promoteEdgeRule(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	author, _ := s.requestRole(c)
+	v, err := s.ecm.PromoteShadow(c.Params("id"), author, c.Query("comment"))
+	if err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(v)
+}
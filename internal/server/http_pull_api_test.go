@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anviod/edgex/internal/core"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPPullEndpointUsesConnectorToken(t *testing.T) {
+	nbm := core.NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		return nil
+	})
+	srv := NewServer(nil, nil, nil, nbm, nil, nil, nil, nil, nil, nil)
+
+	post := func(cfg model.HTTPConfig) int {
+		payload, err := json.Marshal(cfg)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/northbound/http", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+GenerateTestToken())
+		resp, err := srv.app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	cfg := model.HTTPConfig{ID: "http-pull", Name: "Pull", Enable: true, Pull: model.HTTPPullConfig{Enable: true}}
+	assert.Equal(t, http.StatusBadRequest, post(cfg), "pull without a token must be rejected")
+	cfg.Pull.Token = "consumer-secret"
+	require.Equal(t, http.StatusOK, post(cfg))
+
+	pull := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/northbound/http/http-pull/pull?max=10", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, pull(""))
+	assert.Equal(t, http.StatusUnauthorized, pull(GenerateTestToken()), "a user JWT is not a pull token")
+	assert.Equal(t, http.StatusNoContent, pull("consumer-secret"))
+}
+
+func TestHTTPCommandEndpointRequiresConnectorToken(t *testing.T) {
+	nbm := core.NewNorthboundManager(model.NorthboundConfig{}, nil, nil, nil, func(cfg model.NorthboundConfig) error {
+		return nil
+	})
+	srv := NewServer(nil, nil, nil, nbm, nil, nil, nil, nil, nil, nil)
+
+	cfg := model.HTTPConfig{ID: "http-cmd", Name: "Cmd", Enable: true, Command: model.HTTPCommandConfig{Enable: true, Token: "cmd-secret"}}
+	payload, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/northbound/http", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+GenerateTestToken())
+	resp, err := srv.app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	command := func(id, token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/northbound/http/"+id+"/command", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, command("http-cmd", GenerateTestToken(), `{}`), "a user JWT is not a command token")
+	assert.Equal(t, http.StatusBadRequest, command("http-cmd", "cmd-secret", `{"channel_id":"ch-1"}`))
+	assert.Equal(t, http.StatusNotFound, command("missing", "cmd-secret", `{}`))
+}
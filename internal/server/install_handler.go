@@ -445,7 +445,10 @@ func (s *Server) initStorage() error {
 		return nil
 	}
 
-	dataDir := "data"
+	dataDir := s.dataDir
+	if dataDir == "" {
+		dataDir = "data"
+	}
 	store, err := storage.NewStorage(dataDir)
 	if err != nil {
 		return fmt.Errorf("创建数据库失败: %w", err)
@@ -48,6 +48,7 @@ func TestInstallStorageAttachHook(t *testing.T) {
 	pipeline := core.NewDataPipeline(10)
 	cm := core.NewChannelManager(pipeline, nil)
 	srv := NewServer(cm, nil, pipeline, nil, nil, sm, nil, cfgManager, nil, nil)
+	srv.dataDir = t.TempDir()
 
 	var attached *storage.Storage
 	srv.SetStorageAttachHook(func(st *storage.Storage) {
@@ -66,6 +67,7 @@ func TestInstallStorageAttachHook(t *testing.T) {
 
 	require.NotNil(t, attached, "storage attach hook should run after install")
 	require.NotNil(t, srv.storage)
+	t.Cleanup(func() { srv.storage.Close() })
 }
 
 func TestInstallSyncsUserForLogin(t *testing.T) {
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anviod/edgex/internal/core"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (s *Server) SetInterlockManager(im *core.InterlockManager) {
+	s.interlocks = im
+}
+
+// requestRole 返回当前登录用户的角色，与登录接口返回的 permissions 一致（未配置角色视为 admin）
+func (s *Server) requestRole(c *fiber.Ctx) (string, string) {
+	claims, ok := c.Locals("claims").(*CustomClaims)
+	if !ok || claims == nil {
+		return "", ""
+	}
+	if s.sm != nil {
+		if user, found := s.sm.GetUser(claims.Name); found && user.Role != "" {
+			return claims.Name, user.Role
+		}
+	}
+	return claims.Name, "admin"
+}
+
+// writeErrorStatus 联锁拒绝返回 409，其他写入错误返回 500
+func writeErrorStatus(err error) int {
+	var ilErr *core.InterlockError
+	if errors.As(err, &ilErr) {
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
+
+func (s *Server) listInterlocks(c *fiber.Ctx) error {
+	if s.interlocks == nil {
+		return c.JSON([]model.WriteInterlock{})
+	}
+	return c.JSON(s.interlocks.List())
+}
+
+// upsertInterlock 新增或修改联锁，仅管理员可操作（可用于停用联锁）
+func (s *Server) upsertInterlock(c *fiber.Ctx) error {
+	if s.interlocks == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "interlock manager not available"})
+	}
+	if _, role := s.requestRole(c); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "修改联锁需要管理员权限"})
+	}
+	var il model.WriteInterlock
+	if err := c.BodyParser(&il); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if il.ID == "" {
+		il.ID = uuid.New().String()
+	}
+	if err := s.interlocks.Upsert(il); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(il)
+}
+
+// deleteInterlock 删除联锁，仅管理员可操作
+func (s *Server) deleteInterlock(c *fiber.Ctx) error {
+	if s.interlocks == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "interlock manager not available"})
+	}
+	if _, role := s.requestRole(c); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "删除联锁需要管理员权限"})
+	}
+	if err := s.interlocks.Delete(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+// bypassInterlock 临时旁路联锁，仅管理员可操作
+func (s *Server) bypassInterlock(c *fiber.Ctx) error {
+	if s.interlocks == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "interlock manager not available"})
+	}
+	user, role := s.requestRole(c)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "旁路联锁需要管理员权限"})
+	}
+	var req struct {
+		Duration string `json:"duration"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "旁路时长无效: " + err.Error()})
+	}
+	bypass, err := s.interlocks.SetBypass(c.Params("id"), user, req.Reason, d)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(bypass)
+}
+
+func (s *Server) clearInterlockBypass(c *fiber.Ctx) error {
+	if s.interlocks == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "interlock manager not available"})
+	}
+	if _, role := s.requestRole(c); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "解除旁路需要管理员权限"})
+	}
+	if err := s.interlocks.ClearBypass(c.Params("id")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
@@ -782,7 +782,7 @@ func (s *Server) mcpCreateChannel(args json.RawMessage) (*mcp.CallToolResult, er
 		"channel_id": chID,
 		"name":       params.Name,
 		"protocol":   params.Protocol,
-		"config":     ch.Config,
+		"config":     model.SanitizeChannelConfigForClient(ch.Config),
 		"status":     "created",
 	}
 	resultJSON, _ := json.MarshalIndent(result, "", "  ")
@@ -1448,7 +1448,7 @@ func (s *Server) mcpGetChannelConfig(args json.RawMessage) (*mcp.CallToolResult,
 		"protocol":     ch.Protocol,
 		"enabled":      ch.Enable,
 		"status":       status,
-		"config":       ch.Config,
+		"config":       model.SanitizeChannelConfigForClient(ch.Config),
 		"device_count": len(devices),
 		"point_count":  totalPoints,
 		"devices":      deviceList,
@@ -1725,7 +1725,7 @@ func (s *Server) mcpExportConfig(args json.RawMessage) (*mcp.CallToolResult, err
 		for _, ch := range channels {
 			chData := map[string]any{
 				"channel_id": ch.ID, "name": ch.Name, "protocol": ch.Protocol,
-				"enabled": ch.Enable, "config": ch.Config,
+				"enabled": ch.Enable, "config": model.SanitizeChannelConfigForClient(ch.Config),
 			}
 			devices := s.cm.GetChannelDevices(ch.ID)
 			devList := make([]map[string]any, 0, len(devices))
@@ -1740,7 +1740,7 @@ func (s *Server) mcpExportConfig(args json.RawMessage) (*mcp.CallToolResult, err
 						"point_id": p.ID, "name": p.Name, "address": p.Address,
 						"datatype": p.DataType, "readwrite": p.ReadWrite, "scale": p.Scale,
 						"offset": p.Offset, "unit": p.Unit, "word_order": p.WordOrder,
-						"scan_class": p.ScanClass, "register_type": string(p.RegisterType),
+						"scan_class": p.ScanClass, "register_type": p.RegisterType.String(),
 						"function_code": p.FunctionCode,
 					})
 				}
@@ -2088,6 +2088,9 @@ func (s *Server) registerMCPResources(mcpSrv *mcp.MCPServer) {
 
 func (s *Server) mcpResourceChannels(uri string) (*mcp.ReadResourceResult, error) {
 	channels := s.cm.GetChannels()
+	for i := range channels {
+		channels[i] = model.SanitizeChannelForClient(channels[i])
+	}
 	data, _ := json.MarshalIndent(channels, "", "  ")
 	return &mcp.ReadResourceResult{
 		Contents: []mcp.ResourceContent{
@@ -2211,7 +2214,7 @@ func (s *Server) mcpResourceConfig(uri string) (*mcp.ReadResourceResult, error)
 				ptList = append(ptList, map[string]any{
 					"point_id": p.ID, "name": p.Name, "address": p.Address, "datatype": p.DataType,
 					"scale": p.Scale, "offset": p.Offset, "unit": p.Unit, "readwrite": p.ReadWrite,
-					"word_order": p.WordOrder, "scan_class": p.ScanClass, "register_type": string(p.RegisterType), "function_code": p.FunctionCode,
+					"word_order": p.WordOrder, "scan_class": p.ScanClass, "register_type": p.RegisterType.String(), "function_code": p.FunctionCode,
 				})
 			}
 			devData["points"] = ptList
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/anviod/edgex/internal/model"
+	"github.com/anviod/edgex/internal/northbound/connector"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// 插件式北向连接器的通用接口：所有通过 connector.Register 注册的类型共用同一组路由。
+
+// listConnectorTypes GET /api/northbound/connector-types
+func (s *Server) listConnectorTypes(c *fiber.Ctx) error {
+	types := connector.Types()
+	out := make([]fiber.Map, 0, len(types))
+	for _, d := range types {
+		out = append(out, fiber.Map{"type": d.Type, "display_name": d.DisplayName})
+	}
+	return c.JSON(out)
+}
+
+// upsertConnectorConfig POST /api/northbound/connectors
+func (s *Server) upsertConnectorConfig(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+
+	var cfg model.ConnectorConfig
+	if err := c.BodyParser(&cfg); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if cfg.ID == "" {
+		cfg.ID = uuid.New().String()
+	}
+
+	warning, err := s.nbm.UpsertConnectorConfig(cfg)
+	if err != nil {
+		return c.Status(northboundUpsertErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(northboundConfigJSON(cfg, warning))
+}
+
+// deleteConnectorConfig DELETE /api/northbound/connectors/:id
+func (s *Server) deleteConnectorConfig(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+	if err := s.nbm.DeleteConnectorConfig(c.Params("id")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+// getConnectorStats GET /api/northbound/connectors/:id/stats
+func (s *Server) getConnectorStats(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+	stats, err := s.nbm.GetConnectorStats(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(stats)
+}
+
+// writeConnector POST /api/northbound/connectors/:id/write
+func (s *Server) writeConnector(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+
+	var req opcuaWriteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.ChannelID == "" || req.DeviceID == "" || req.PointID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "channel_id, device_id, and point_id are required"})
+	}
+
+	err := s.nbm.WriteConnector(c.Params("id"), req.ChannelID, req.DeviceID, req.PointID, req.Value)
+	if errors.Is(err, connector.ErrWriteNotSupported) {
+		return c.Status(501).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "write success"})
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/anviod/edgex/internal/core"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (s *Server) SetNotificationManager(nm *core.NotificationManager) {
+	s.notifier = nm
+}
+
+// notificationErrorStatus 配置校验错误返回 400，对象不存在返回 404
+func notificationErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "not found") {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
+
+func (s *Server) getNotificationConfig(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.JSON(model.NotificationConfig{})
+	}
+	return c.JSON(s.notifier.Config())
+}
+
+func (s *Server) upsertNotificationChannel(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	var ch model.NotificationChannel
+	if err := c.BodyParser(&ch); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if ch.ID == "" {
+		ch.ID = uuid.New().String()
+	}
+	if err := s.notifier.UpsertChannel(ch); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(ch)
+}
+
+func (s *Server) deleteNotificationChannel(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	if err := s.notifier.DeleteChannel(c.Params("id")); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+// testNotificationChannel 通过通道向指定接收人发送一条测试通知
+func (s *Server) testNotificationChannel(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	var req struct {
+		RecipientID string `json:"recipient_id"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if err := s.notifier.TestChannel(c.UserContext(), c.Params("id"), req.RecipientID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func (s *Server) upsertNotificationRecipient(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	var r model.NotificationRecipient
+	if err := c.BodyParser(&r); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	if err := s.notifier.UpsertRecipient(r); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(r)
+}
+
+func (s *Server) deleteNotificationRecipient(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	if err := s.notifier.DeleteRecipient(c.Params("id")); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+func (s *Server) upsertNotificationPolicy(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	var p model.NotificationPolicy
+	if err := c.BodyParser(&p); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	if err := s.notifier.UpsertPolicy(p); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(p)
+}
+
+func (s *Server) deleteNotificationPolicy(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	if err := s.notifier.DeletePolicy(c.Params("id")); err != nil {
+		return c.Status(notificationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+func (s *Server) listNotifications(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.JSON([]model.Notification{})
+	}
+	return c.JSON(s.notifier.List(c.QueryBool("active")))
+}
+
+// ackNotification 确认通知，停止后续升级
+func (s *Server) ackNotification(c *fiber.Ctx) error {
+	if s.notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "notification manager not available"})
+	}
+	user, _ := s.requestRole(c)
+	n, err := s.notifier.Acknowledge(c.Params("id"), user)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(n)
+}
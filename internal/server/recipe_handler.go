@@ -0,0 +1,125 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/anviod/edgex/internal/core"
+	"github.com/anviod/edgex/internal/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *Server) SetRecipeManager(rm *core.RecipeManager) {
+	s.recipes = rm
+}
+
+// recipeErrorStatus 校验错误 400，不存在 404，状态冲突 409
+func recipeErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return fiber.StatusNotFound
+	case strings.Contains(msg, "无效"):
+		return fiber.StatusBadRequest
+	case strings.Contains(msg, "不允许"), strings.Contains(msg, "正在运行"):
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
+
+func (s *Server) listRecipes(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.JSON([]model.Recipe{})
+	}
+	return c.JSON(s.recipes.List())
+}
+
+func (s *Server) upsertRecipe(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "recipe manager not available"})
+	}
+	var r model.Recipe
+	if err := c.BodyParser(&r); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := s.recipes.Upsert(r); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(r)
+}
+
+func (s *Server) deleteRecipe(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "recipe manager not available"})
+	}
+	if err := s.recipes.Delete(c.Params("id")); err != nil {
+		return c.Status(recipeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+func (s *Server) startRecipe(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "recipe manager not available"})
+	}
+	var req struct {
+		Params map[string]any `json:"params"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	user, _ := s.requestRole(c)
+	run, err := s.recipes.StartRun(c.Params("id"), req.Params, user)
+	if err != nil {
+		return c.Status(recipeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(run)
+}
+
+// listRecipeRuns 查询运行记录，支持 recipe_id、active=true 与 limit 过滤
+func (s *Server) listRecipeRuns(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.JSON([]model.RecipeRun{})
+	}
+	return c.JSON(s.recipes.ListRuns(c.Query("recipe_id"), c.QueryBool("active"), c.QueryInt("limit", 100)))
+}
+
+func (s *Server) getRecipeRun(c *fiber.Ctx) error {
+	if s.recipes == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "recipe manager not available"})
+	}
+	run, err := s.recipes.GetRun(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(run)
+}
+
+func (s *Server) holdRecipeRun(c *fiber.Ctx) error {
+	return s.controlRecipeRun(c, s.recipes.Hold)
+}
+
+func (s *Server) resumeRecipeRun(c *fiber.Ctx) error {
+	return s.controlRecipeRun(c, s.recipes.Resume)
+}
+
+func (s *Server) abortRecipeRun(c *fiber.Ctx) error {
+	return s.controlRecipeRun(c, s.recipes.Abort)
+}
+
+func (s *Server) controlRecipeRun(c *fiber.Ctx, op func(runID, user string) error) error {
+	if s.recipes == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "recipe manager not available"})
+	}
+	user, _ := s.requestRole(c)
+	id := c.Params("id")
+	if err := op(id, user); err != nil {
+		return c.Status(recipeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	run, err := s.recipes.GetRun(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(run)
+}
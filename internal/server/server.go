@@ -21,6 +21,7 @@ import (
 	"github.com/anviod/edgex/internal/ai_agent"
 	"github.com/anviod/edgex/internal/config"
 	"github.com/anviod/edgex/internal/core"
+	"github.com/anviod/edgex/internal/driver"
 	"github.com/anviod/edgex/internal/mcp"
 	"github.com/anviod/edgex/internal/model"
 	"github.com/anviod/edgex/internal/northbound/opcua"
@@ -59,6 +60,9 @@ type Server struct {
 	shadowCore             *core.ShadowCore
 	virtualShadow          *core.VirtualShadowEngine
 	vsm                    *core.VirtualShadowManager
+	interlocks             *core.InterlockManager
+	notifier               *core.NotificationManager
+	recipes                *core.RecipeManager
 	hub                    *Hub
 	pipeline               *core.DataPipeline
 	nbm                    *core.NorthboundManager
@@ -80,6 +84,7 @@ type Server struct {
 	aiSettingsMem          *model.AICopilotSettings
 	mcpServer              *mcp.MCPServer // MCP 协议服务端（懒初始化）
 	storageAttachHook      func(*storage.Storage)
+	dataDir                string // 安装流程创建 DB 的目录，为空时为 data
 	runtimeStartHook       func()
 	shadowSubscribeOnce    sync.Once
 	runtimeCompactStop     chan struct{}
@@ -379,8 +384,13 @@ func (s *Server) setupRoutes() {
 	// WebSocket 实时值（注册在 JWT 之前；与上方 s.app 路由双保险）
 	api.Get("/ws/values", websocket.New(s.handleWebSocket))
 
+	// HTTP 北向拉取模式与写点命令（使用通道自身的 Token 认证，无需 JWT）
+	api.Get("/northbound/http/:id/pull", s.pullHTTPPayloads)
+	api.Post("/northbound/http/:id/command", s.commandHTTP)
+
 	// 应用 JWT 中间件到后续路由
 	api.Use(JWTAuth())
+	api.Use(s.haStandbyGuard)
 
 	// Authenticated Auth Routes
 	api.Post("/auth/change-password", s.handleChangePassword)
@@ -416,6 +426,8 @@ func (s *Server) setupRoutes() {
 	// 系统设置
 	api.Get("/system", s.getSystemConfig)
 	api.Put("/system", s.updateSystemConfig)
+	api.Get("/system/ha/status", s.getHAStatus)
+	api.Get("/system/time/status", s.getTimeStatus)
 
 	// 边缘计算日志
 	api.Get("/edge-compute/logs", s.getEdgeComputeLogs)
@@ -466,9 +478,13 @@ func (s *Server) setupRoutes() {
 	api.Put("/channels/:channelId/devices/:deviceId/points/:pointId", s.updatePoint)
 	api.Delete("/channels/:channelId/devices/:deviceId/points/:pointId", s.removePoint)
 	api.Delete("/channels/:channelId/devices/:deviceId/points", s.removePoints)
-	api.Post("/channels/:channelId/devices/:deviceId/scan", s.scanDevice)                  // New: Scan points in device
-	api.Get("/channels/:channelId/devices/:deviceId/points/export", s.exportDevicePoints)  // Export points
-	api.Post("/channels/:channelId/devices/:deviceId/points/import", s.importDevicePoints) // Import points
+	api.Post("/channels/:channelId/devices/:deviceId/scan", s.scanDevice)                     // New: Scan points in device
+	api.Get("/channels/:channelId/devices/:deviceId/browse", s.browseDevice)                  // Lazy address-space browse (OPC UA)
+	api.Post("/channels/:channelId/devices/:deviceId/browse/import", s.importBrowsedPoints)   // Bulk-create points from browsed nodes
+	api.Post("/channels/:channelId/devices/:deviceId/methods/call", s.callDeviceMethod)       // Invoke a server method (OPC UA Call)
+	api.Post("/channels/:channelId/devices/:deviceId/description", s.importDeviceDescription) // Upload ESI/GSDML to generate points
+	api.Get("/channels/:channelId/devices/:deviceId/points/export", s.exportDevicePoints)     // Export points
+	api.Post("/channels/:channelId/devices/:deviceId/points/import", s.importDevicePoints)    // Import points
 	api.Post("/channels/:channelId/devices/:deviceId/points/generate-registers", s.generateDeviceRegisters)
 
 	// 兼容路径：UI 可能会尝试直接通过设备 ID 访问点位（不带 channelId）
@@ -489,6 +505,36 @@ func (s *Server) setupRoutes() {
 	// 写入点位值
 	api.Post("/write", s.writePoint)
 
+	// 写联锁
+	api.Get("/interlocks", s.listInterlocks)
+	api.Post("/interlocks", s.upsertInterlock)
+	api.Delete("/interlocks/:id", s.deleteInterlock)
+	api.Post("/interlocks/:id/bypass", s.bypassInterlock)
+	api.Delete("/interlocks/:id/bypass", s.clearInterlockBypass)
+
+	// 告警通知
+	api.Get("/notifications/config", s.getNotificationConfig)
+	api.Post("/notifications/channels", s.upsertNotificationChannel)
+	api.Delete("/notifications/channels/:id", s.deleteNotificationChannel)
+	api.Post("/notifications/channels/:id/test", s.testNotificationChannel)
+	api.Post("/notifications/recipients", s.upsertNotificationRecipient)
+	api.Delete("/notifications/recipients/:id", s.deleteNotificationRecipient)
+	api.Post("/notifications/policies", s.upsertNotificationPolicy)
+	api.Delete("/notifications/policies/:id", s.deleteNotificationPolicy)
+	api.Get("/notifications", s.listNotifications)
+	api.Post("/notifications/:id/ack", s.ackNotification)
+
+	// 配方
+	api.Get("/recipes", s.listRecipes)
+	api.Post("/recipes", s.upsertRecipe)
+	api.Delete("/recipes/:id", s.deleteRecipe)
+	api.Post("/recipes/:id/start", s.startRecipe)
+	api.Get("/recipe-runs", s.listRecipeRuns)
+	api.Get("/recipe-runs/:id", s.getRecipeRun)
+	api.Post("/recipe-runs/:id/hold", s.holdRecipeRun)
+	api.Post("/recipe-runs/:id/resume", s.resumeRecipeRun)
+	api.Post("/recipe-runs/:id/abort", s.abortRecipeRun)
+
 	// 北向数据上报配置
 	api.Get("/northbound/config", s.getNorthboundConfig)
 	api.Post("/northbound/mqtt", s.updateMQTTConfig)
@@ -509,6 +555,7 @@ func (s *Server) setupRoutes() {
 	api.Get("/northbound/bacnet_server/:id/stats", s.getBACnetServerStats)
 	api.Get("/northbound/bacnet_server/:id/write-history", s.getBACnetServerWriteHistory)
 	api.Get("/northbound/mqtt/:id/stats", s.getMQTTStats)
+	api.Post("/northbound/mqtt/preview", s.previewMQTTPayload)
 	api.Post("/northbound/sparkplugb", s.upsertSparkplugBConfig)        // Sparkplug B Upsert
 	api.Delete("/northbound/sparkplug_b/:id", s.deleteSparkplugBConfig) // Sparkplug B Delete
 
@@ -524,6 +571,13 @@ func (s *Server) setupRoutes() {
 	api.Get("/northbound/edgeos-nats/:id/stats", s.getEdgeOSNATSStats)
 	api.Post("/northbound/edgeos-nats/publish", s.publishEdgeOSNATS)
 
+	// 插件式连接器（connector.Register 注册的类型，含 Kafka、时序数据库）
+	api.Get("/northbound/connector-types", s.listConnectorTypes)
+	api.Post("/northbound/connectors", s.upsertConnectorConfig)
+	api.Delete("/northbound/connectors/:id", s.deleteConnectorConfig)
+	api.Get("/northbound/connectors/:id/stats", s.getConnectorStats)
+	api.Post("/northbound/connectors/:id/write", s.writeConnector)
+
 	api.Get("/points", s.getAllPoints)
 
 	// Edge Compute
@@ -531,7 +585,19 @@ func (s *Server) setupRoutes() {
 	api.Post("/edge/rules", s.upsertEdgeRule)
 	api.Delete("/edge/rules/:id", s.deleteEdgeRule)
 	api.Get("/edge/states", s.getEdgeRuleStates)
+	api.Get("/edge/rules/graph", s.getEdgeRuleGraph)
+	api.Post("/edge/rules/simulate", s.simulateEdgeRule)
+	api.Get("/edge/rule-templates", s.getEdgeRuleTemplates)
+	api.Post("/edge/rule-templates", s.upsertEdgeRuleTemplate)
+	api.Delete("/edge/rule-templates/:id", s.deleteEdgeRuleTemplate)
 	api.Get("/edge/rules/:id/window", s.getEdgeWindowData)
+	api.Get("/edge/rules/:id/versions", s.getEdgeRuleVersions)
+	api.Get("/edge/rules/:id/versions/:version", s.getEdgeRuleVersion)
+	api.Post("/edge/rules/:id/rollback/:version", s.rollbackEdgeRule)
+	api.Get("/edge/rules/:id/shadow", s.getEdgeRuleShadow)
+	api.Post("/edge/rules/:id/shadow", s.stageEdgeRule)
+	api.Delete("/edge/rules/:id/shadow", s.cancelEdgeRuleShadow)
+	api.Post("/edge/rules/:id/promote", s.promoteEdgeRule)
 
 	api.Get("/virtual-shadows", s.listVirtualShadows)
 	api.Get("/virtual-shadows/sources", s.listVirtualShadowSources)
@@ -764,7 +830,7 @@ func (s *Server) addChannel(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.JSON(ch)
+	return c.JSON(model.SanitizeChannelForClient(ch))
 }
 
 func (s *Server) updateChannel(c *fiber.Ctx) error {
@@ -792,7 +858,7 @@ func (s *Server) updateChannel(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.JSON(ch)
+	return c.JSON(model.SanitizeChannelForClient(ch))
 }
 
 func (s *Server) removeChannel(c *fiber.Ctx) error {
@@ -980,6 +1046,9 @@ func (s *Server) getDashboardSummary(c *fiber.Ctx) error {
 // getChannels 获取所有采集通道
 func (s *Server) getChannels(c *fiber.Ctx) error {
 	channels := s.cm.GetChannels()
+	for i := range channels {
+		channels[i] = model.SanitizeChannelForClient(channels[i])
+	}
 	return c.JSON(channels)
 }
 
@@ -990,7 +1059,7 @@ func (s *Server) getChannel(c *fiber.Ctx) error {
 	if ch == nil {
 		return c.Status(404).JSON(fiber.Map{"error": "channel not found"})
 	}
-	return c.JSON(ch)
+	return c.JSON(model.SanitizeChannelForClient(*ch))
 }
 
 // getChannelDevices 获取通道下的所有设备
@@ -1490,12 +1559,70 @@ func (s *Server) writePoint(c *fiber.Ctx) error {
 	// 调用 ChannelManager 执行写入
 	err := s.cm.WritePoint(req.ChannelID, req.DeviceID, req.PointID, req.Value)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(writeErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{"message": "write success"})
 }
 
+// callDeviceMethod 调用设备方法（OPC UA Call），返回输出参数。
+// Body: {"object_id": "ns=2;s=Line1", "method_id": "ns=2;s=Line1.Start", "args": [1, {"type": "Double", "value": 2.5}]}
+func (s *Server) callDeviceMethod(c *fiber.Ctx) error {
+	var req driver.MethodCall
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	outputs, err := s.cm.CallMethod(c.Params("channelId"), c.Params("deviceId"), req)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"outputs": outputs})
+}
+
+// importDeviceDescription 上传设备描述文件（EtherCAT ESI / PROFINET GSDML）并生成点位。
+// multipart: file=<xml>，其余表单字段（dry_run、include_sdo、product_code ...）作为导入参数。
+func (s *Server) importDeviceDescription(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "file is required: " + err.Error()})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "failed to open uploaded file: " + err.Error()})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "failed to read uploaded file: " + err.Error()})
+	}
+
+	params := map[string]any{}
+	if form, err := c.MultipartForm(); err == nil {
+		for k, vals := range form.Value {
+			if len(vals) == 0 {
+				continue
+			}
+			switch strings.ToLower(vals[0]) {
+			case "true":
+				params[k] = true
+			case "false":
+				params[k] = false
+			default:
+				params[k] = vals[0]
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 60*time.Second)
+	defer cancel()
+	result, err := s.cm.ImportDeviceDescription(ctx, c.Params("channelId"), c.Params("deviceId"), data, params)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
+
 func (s *Server) getEdgeCache(c *fiber.Ctx) error {
 	if s.ecm == nil {
 		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute Manager not initialized"})
@@ -1654,18 +1781,122 @@ func (s *Server) upsertEdgeRule(c *fiber.Ctx) error {
 	if rule.ID == "" {
 		rule.ID = uuid.New().String()
 	}
-	if err := s.ecm.UpsertRule(rule); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	author, _ := s.requestRole(c)
+	if err := s.ecm.UpsertRuleBy(rule, author, c.Query("comment")); err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(rule)
 }
 
+func edgeRuleErrorStatus(err error) int {
+	msg := err.Error()
+	if strings.Contains(msg, "无效") || strings.Contains(msg, "重复") || strings.Contains(msg, "循环") || strings.Contains(msg, "由模板") {
+		return fiber.StatusBadRequest
+	}
+	if strings.Contains(msg, "未处于影子模式") {
+		return fiber.StatusConflict
+	}
+	if strings.Contains(msg, "not found") {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusInternalServerError
+}
+
+func (s *Server) getEdgeRuleGraph(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	return c.JSON(s.ecm.GetRuleGraph())
+}
+
+// simulateEdgeRule 试运行规则草案：按提供的值序列或设备历史时间段回放，动作不会执行
+func (s *Server) simulateEdgeRule(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	var req struct {
+		Rule    model.EdgeRule `json:"rule"`
+		Values  []model.Value  `json:"values"`
+		History *struct {
+			Start time.Time `json:"start"`
+			End   time.Time `json:"end"`
+			Limit int       `json:"limit"`
+		} `json:"history"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	values := req.Values
+	if len(values) == 0 && req.History != nil {
+		if s.dsm == nil {
+			return c.Status(503).JSON(fiber.Map{"error": "Device storage not initialized"})
+		}
+		if req.History.Start.IsZero() || !req.History.End.After(req.History.Start) {
+			return c.Status(400).JSON(fiber.Map{"error": "history.start/end 无效"})
+		}
+		var err error
+		values, err = s.dsm.SourceHistory(req.Rule.Sources, req.History.Start, req.History.End, req.History.Limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if len(values) == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "时间段内没有规则数据源的历史数据"})
+		}
+	}
+
+	res, err := s.ecm.SimulateRule(req.Rule, values)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(res)
+}
+
 func (s *Server) deleteEdgeRule(c *fiber.Ctx) error {
 	if s.ecm == nil {
 		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
 	}
-	id := c.Params("id")
-	if err := s.ecm.DeleteRule(id); err != nil {
+	author, _ := s.requestRole(c)
+	if err := s.ecm.DeleteRuleBy(c.Params("id"), author); err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(200)
+}
+
+func (s *Server) getEdgeRuleTemplates(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	return c.JSON(s.ecm.GetRuleTemplates())
+}
+
+func (s *Server) upsertEdgeRuleTemplate(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	var tpl model.EdgeRuleTemplate
+	if err := c.BodyParser(&tpl); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if tpl.ID == "" {
+		tpl.ID = uuid.New().String()
+	}
+	if err := s.ecm.UpsertRuleTemplate(tpl); err != nil {
+		return c.Status(edgeRuleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, saved := range s.ecm.GetRuleTemplates() {
+		if saved.ID == tpl.ID {
+			return c.JSON(saved)
+		}
+	}
+	return c.JSON(tpl)
+}
+
+func (s *Server) deleteEdgeRuleTemplate(c *fiber.Ctx) error {
+	if s.ecm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
+	}
+	if err := s.ecm.DeleteRuleTemplate(c.Params("id")); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.SendStatus(200)
@@ -1683,6 +1914,9 @@ func (s *Server) getEdgeWindowData(c *fiber.Ctx) error {
 		return c.Status(503).JSON(fiber.Map{"error": "Edge Compute manager not initialized"})
 	}
 	id := c.Params("id")
+	if c.QueryBool("by_key") {
+		return c.JSON(s.ecm.GetWindowDataByKey(id))
+	}
 	return c.JSON(s.ecm.GetWindowData(id))
 }
 
@@ -1956,6 +2190,36 @@ func (s *Server) getMQTTStats(c *fiber.Ctx) error {
 	return c.JSON(stats)
 }
 
+type mqttPreviewRequest struct {
+	Config    model.MQTTConfig `json:"config"`
+	ChannelID string           `json:"channel_id"`
+	DeviceID  string           `json:"device_id"`
+}
+
+// previewMQTTPayload renders an unsaved MQTT config against live shadow data
+func (s *Server) previewMQTTPayload(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+	var req mqttPreviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.DeviceID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "device_id is required"})
+	}
+
+	msgs, err := s.nbm.PreviewMQTTPayload(req.Config, req.ChannelID, req.DeviceID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	out := make([]fiber.Map, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, fiber.Map{"topic": m.Topic, "payload": string(m.Payload)})
+	}
+	return c.JSON(fiber.Map{"messages": out})
+}
+
 type randomWriteRequest struct {
 	ChannelID       string   `json:"channel_id"`
 	DeviceIDs       []string `json:"device_ids"`
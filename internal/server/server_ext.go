@@ -2,9 +2,12 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/anviod/edgex/internal/model"
+	nbhttp "github.com/anviod/edgex/internal/northbound/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -18,7 +21,7 @@ func northboundUpsertErrorStatus(err error) int {
 	if strings.Contains(msg, "已存在") {
 		return fiber.StatusConflict
 	}
-	if strings.Contains(msg, "不能为空") {
+	if strings.Contains(msg, "不能为空") || strings.Contains(msg, "无效") {
 		return fiber.StatusBadRequest
 	}
 	return fiber.StatusInternalServerError
@@ -71,6 +74,60 @@ func (s *Server) deleteHTTPConfig(c *fiber.Ctx) error {
 	return c.SendStatus(200)
 }
 
+// pullHTTPPayloads hands queued HTTP northbound payloads to a polling consumer.
+// It is registered outside JWT auth: the connector's pull token authenticates.
+func (s *Server) pullHTTPPayloads(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+	body, n, err := s.nbm.PullHTTP(c.Params("id"), token, c.QueryInt("max", 0))
+	if errors.Is(err, nbhttp.ErrPullUnauthorized) {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if n == 0 {
+		return c.SendStatus(204)
+	}
+	c.Set("X-Item-Count", strconv.Itoa(n))
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// commandHTTP accepts a point write command for an HTTP northbound connector.
+// Like the pull endpoint it is outside JWT auth; the connector's command token
+// authenticates. With a callback URL the command is acknowledged with 202 and
+// the result is posted to the callback; otherwise the result is returned.
+func (s *Server) commandHTTP(c *fiber.Ctx) error {
+	if s.nbm == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
+	}
+	var req nbhttp.CommandRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = c.Get("X-Correlation-ID")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+	result, async, err := s.nbm.CommandHTTP(c.Params("id"), token, req)
+	switch {
+	case errors.Is(err, nbhttp.ErrCommandUnauthorized):
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, nbhttp.ErrInvalidCommand):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set("X-Correlation-ID", result.CorrelationID)
+	if async {
+		return c.Status(202).JSON(result)
+	}
+	return c.JSON(result)
+}
+
 func (s *Server) deleteMQTTConfig(c *fiber.Ctx) error {
 	if s.nbm == nil {
 		return c.Status(503).JSON(fiber.Map{"error": "Northbound manager not initialized"})
@@ -2,8 +2,10 @@ package server
 
 import (
 	"os"
+	"strings"
 	"time"
 
+	"github.com/anviod/edgex/internal/core"
 	"github.com/anviod/edgex/internal/model"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,6 +22,10 @@ func (s *Server) updateSystemConfig(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	if err := core.ValidateHAConfig(newConfig.HA); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	previousPort := s.GetListenPort()
 
 	if err := s.sm.UpdateConfig(newConfig); err != nil {
@@ -108,3 +114,42 @@ func (s *Server) handleRestart(c *fiber.Ctx) error {
 	}()
 	return c.JSON(fiber.Map{"status": "success", "message": "System is restarting..."})
 }
+
+func (s *Server) getTimeStatus(c *fiber.Ctx) error {
+	return c.JSON(s.sm.TimeSync().Status())
+}
+
+func (s *Server) getHAStatus(c *fiber.Ctx) error {
+	var ha *core.HAManager
+	if s.sm != nil {
+		ha = s.sm.HAManager()
+	}
+	if ha == nil {
+		return c.JSON(core.HAStatus{State: core.HAStateActive, History: []core.HASwitchover{}})
+	}
+	return c.JSON(ha.Status())
+}
+
+// haStandbyGuard 备机只读：拒绝除系统设置、认证、AI/MCP 以外的写操作，
+// 避免修改被主机镜像覆盖，也避免备机触达现场设备。
+func (s *Server) haStandbyGuard(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+	default:
+		return c.Next()
+	}
+	if s.sm == nil {
+		return c.Next()
+	}
+	ha := s.sm.HAManager()
+	if ha == nil || !ha.IsStandby() {
+		return c.Next()
+	}
+	path := c.Path()
+	for _, prefix := range []string{"/api/system", "/api/auth", "/api/ai", "/api/mcp"} {
+		if strings.HasPrefix(path, prefix) {
+			return c.Next()
+		}
+	}
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "gateway is HA standby; apply changes on the active node"})
+}
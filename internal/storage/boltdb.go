@@ -7,7 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/anviod/edgex/internal/model"
 
@@ -18,6 +18,9 @@ type Storage struct {
 	configDB  *bbolt.DB
 	runtimeDB *bbolt.DB
 	dataDir   string
+
+	queuesMu sync.Mutex
+	queues   map[string]*OfflineQueue
 }
 
 func (s *Storage) GetPath() string {
@@ -48,103 +51,18 @@ func (s *Storage) getDBByBucket(bucketName string) *bbolt.DB {
 	return s.runtimeDB
 }
 
-func (s *Storage) SaveOfflineMessage(configID string, data []byte, maxCount int) error {
-	return s.runtimeDB.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(BucketNorthboundCache))
-		if b == nil {
-			return fmt.Errorf("bucket %s not found", BucketNorthboundCache)
-		}
-
-		key := fmt.Sprintf("%s_%d", configID, time.Now().UnixNano())
-
-		if err := b.Put([]byte(key), data); err != nil {
-			return err
-		}
-
-		c := b.Cursor()
-		prefix := []byte(configID + "_")
-		count := 0
-		var keysToDelete [][]byte
-
-		for k, _ := c.Seek(prefix); k != nil && len(k) > len(prefix) && string(k[:len(prefix)]) == string(prefix); k, _ = c.Next() {
-			count++
-		}
-
-		if count > maxCount {
-			toDelete := count - maxCount
-			for k, _ := c.Seek(prefix); k != nil && len(k) > len(prefix) && string(k[:len(prefix)]) == string(prefix); k, _ = c.Next() {
-				if toDelete <= 0 {
-					break
-				}
-				keysToDelete = append(keysToDelete, append([]byte{}, k...))
-				toDelete--
-			}
-		}
-
-		for _, k := range keysToDelete {
-			if err := b.Delete(k); err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
-}
-
-func (s *Storage) GetOfflineMessages(configID string, limit int) ([]OfflineMessage, error) {
-	var messages []OfflineMessage
-	err := s.runtimeDB.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(BucketNorthboundCache))
-		if b == nil {
-			return nil
-		}
-
-		c := b.Cursor()
-		prefix := []byte(configID + "_")
-
-		for k, v := c.Seek(prefix); k != nil && len(k) > len(prefix) && string(k[:len(prefix)]) == string(prefix); k, v = c.Next() {
-			if len(messages) >= limit {
-				break
-			}
-			dataCopy := make([]byte, len(v))
-			copy(dataCopy, v)
-
-			messages = append(messages, OfflineMessage{
-				Key:  string(k),
-				Data: dataCopy,
-			})
-		}
-		return nil
-	})
-	return messages, err
-}
-
-func (s *Storage) RemoveOfflineMessage(key string) error {
-	return s.runtimeDB.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(BucketNorthboundCache))
-		if b == nil {
-			return nil
-		}
-		return b.Delete([]byte(key))
-	})
-}
-
 const (
 	BucketValues          = "values"
 	BucketRuleState       = "RuleState"
 	BucketDataCache       = "DataCache"
 	BucketWindow          = "WindowData"
 	BucketNorthboundCache = "NorthboundCache"
+	BucketRecipeRuns      = "recipe_runs"
 
 	// legacyShadowWALBucket is a removed ShadowCore WAL bucket; dropped on startup.
 	legacyShadowWALBucket = "shadow_wal"
 )
 
-type OfflineMessage struct {
-	Key  string
-	Data []byte
-}
-
 var configBucketNames = []string{
 	BucketConfigVersion,
 	BucketChannels,
@@ -163,6 +81,7 @@ var runtimeBucketNames = []string{
 	BucketDataCache,
 	BucketWindow,
 	BucketNorthboundCache,
+	BucketRecipeRuns,
 }
 
 var edgeLogBucketNames = []string{
@@ -451,6 +370,7 @@ var (
 	runtimeBucketMap = map[string]bool{
 		"values":        true,
 		"shadow_values": true,
+		"recipe_runs":   true,
 	}
 
 	edgeLogBucketMap = map[string]bool{
@@ -613,6 +533,9 @@ func (s *Storage) ClearBucket(bucketName string) error {
 	if IsConfigBucket(bucketName) {
 		return fmt.Errorf("config bucket %s cannot be cleared", bucketName)
 	}
+	if bucketName == BucketNorthboundCache {
+		defer s.invalidateOfflineQueues()
+	}
 	return s.runtimeDB.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
 		if b == nil {
@@ -633,6 +556,7 @@ func (s *Storage) ClearBucket(bucketName string) error {
 // 配置 bucket 不应出现在 runtime.db；若检测到则报错。
 func (s *Storage) ClearAllRuntimeBuckets() ([]string, error) {
 	var cleared []string
+	defer s.invalidateOfflineQueues()
 
 	err := s.runtimeDB.Update(func(tx *bbolt.Tx) error {
 		var bucketNames []string
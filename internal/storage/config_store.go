@@ -20,6 +20,11 @@ const (
 	BucketUsers          = "Users"
 	BucketServer         = "Server"
 	BucketVirtualShadows = "VirtualShadows"
+	BucketRuleTemplates  = "EdgeRuleTemplates"
+	BucketInterlocks     = "WriteInterlocks"
+	BucketNotifications  = "Notifications"
+	BucketRuleHistory    = "EdgeRuleHistory"
+	BucketRecipes        = "Recipes"
 	BucketAICopilot      = "ai_copilot"
 	ConfigVersionKey     = "version"
 	ConfigVersionValue   = "1.0"
@@ -41,6 +46,11 @@ func NewConfigStore(db *bbolt.DB) (*ConfigStore, error) {
 			BucketUsers,
 			BucketServer,
 			BucketVirtualShadows,
+			BucketRuleTemplates,
+			BucketInterlocks,
+			BucketNotifications,
+			BucketRuleHistory,
+			BucketRecipes,
 			BucketAICopilot,
 		}
 		for _, bucket := range buckets {
@@ -165,6 +175,107 @@ func (cs *ConfigStore) LoadVirtualShadows() ([]model.VirtualShadowDeviceConfig,
 	return devices, nil
 }
 
+func (cs *ConfigStore) SaveEdgeRuleTemplates(templates []model.EdgeRuleTemplate) error {
+	return cs.saveJSON(BucketRuleTemplates, "templates", templates)
+}
+
+func (cs *ConfigStore) LoadEdgeRuleTemplates() ([]model.EdgeRuleTemplate, error) {
+	var templates []model.EdgeRuleTemplate
+	err := cs.loadJSON(BucketRuleTemplates, "templates", &templates)
+	if err != nil {
+		return nil, err
+	}
+	if templates == nil {
+		return []model.EdgeRuleTemplate{}, nil
+	}
+	return templates, nil
+}
+
+func (cs *ConfigStore) SaveInterlocks(interlocks []model.WriteInterlock) error {
+	return cs.saveJSON(BucketInterlocks, "interlocks", interlocks)
+}
+
+func (cs *ConfigStore) LoadInterlocks() ([]model.WriteInterlock, error) {
+	var interlocks []model.WriteInterlock
+	err := cs.loadJSON(BucketInterlocks, "interlocks", &interlocks)
+	if err != nil {
+		return nil, err
+	}
+	if interlocks == nil {
+		return []model.WriteInterlock{}, nil
+	}
+	return interlocks, nil
+}
+
+func (cs *ConfigStore) SaveNotificationConfig(cfg model.NotificationConfig) error {
+	return cs.saveJSON(BucketNotifications, "config", cfg)
+}
+
+func (cs *ConfigStore) LoadNotificationConfig() (model.NotificationConfig, error) {
+	var cfg model.NotificationConfig
+	err := cs.loadJSON(BucketNotifications, "config", &cfg)
+	return cfg, err
+}
+
+func (cs *ConfigStore) SaveRecipes(recipes []model.Recipe) error {
+	return cs.saveJSON(BucketRecipes, "recipes", recipes)
+}
+
+func (cs *ConfigStore) LoadRecipes() ([]model.Recipe, error) {
+	var recipes []model.Recipe
+	err := cs.loadJSON(BucketRecipes, "recipes", &recipes)
+	if err != nil {
+		return nil, err
+	}
+	if recipes == nil {
+		return []model.Recipe{}, nil
+	}
+	return recipes, nil
+}
+
+// SaveEdgeRuleHistory 保存单条规则的版本历史，Key 为规则 ID
+func (cs *ConfigStore) SaveEdgeRuleHistory(history model.EdgeRuleHistory) error {
+	return cs.saveJSON(BucketRuleHistory, history.RuleID, history)
+}
+
+// ReplaceEdgeRuleHistories 以给定集合替换全部规则版本历史（主备镜像导入）
+func (cs *ConfigStore) ReplaceEdgeRuleHistories(histories []model.EdgeRuleHistory) error {
+	return cs.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(BucketRuleHistory)) != nil {
+			if err := tx.DeleteBucket([]byte(BucketRuleHistory)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket([]byte(BucketRuleHistory)); err != nil {
+			return err
+		}
+		for _, h := range histories {
+			if err := saveToBucket(tx, BucketRuleHistory, h.RuleID, h); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (cs *ConfigStore) LoadEdgeRuleHistories() ([]model.EdgeRuleHistory, error) {
+	var histories []model.EdgeRuleHistory
+	err := cs.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketRuleHistory))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var h model.EdgeRuleHistory
+			if err := json.Unmarshal(v, &h); err == nil {
+				histories = append(histories, h)
+			}
+			return nil
+		})
+	})
+	return histories, err
+}
+
 func (cs *ConfigStore) SaveEdgeRules(rules []model.EdgeRule) error {
 	return cs.saveJSON(BucketEdgeRules, "edge_rules", rules)
 }
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// 北向离线队列：每个北向连接一个持久化 FIFO，保存在 NorthboundCache bucket 中。
+// key = "q\x00<configID>\x00" + 8 字节大端序号（按写入顺序排列），
+// value = 8 字节入队时间 + 8 字节过期时间（0 表示不过期，均为 UnixNano）+ 原始消息。
+
+const offlineHeaderSize = 16
+
+// OfflinePolicy 限制单个队列的容量与消息有效期，零值表示不限制。
+type OfflinePolicy struct {
+	MaxCount int
+	MaxBytes int64
+	TTL      time.Duration
+}
+
+// QueuedMessage 是队列中的一条消息，Seq 用于 Ack。
+type QueuedMessage struct {
+	Seq        uint64
+	Data       []byte
+	EnqueuedAt time.Time
+}
+
+// OfflineQueueStats 描述队列积压情况。
+type OfflineQueueStats struct {
+	Count     int
+	Bytes     int64
+	OldestAge time.Duration
+	Dropped   int64 // 因容量或配额被丢弃的消息
+	Expired   int64 // 因 TTL 过期被丢弃的消息
+}
+
+// OfflineQueue 是单个北向连接的持久化离线队列，并发安全。
+type OfflineQueue struct {
+	s      *Storage
+	id     string
+	prefix []byte
+
+	mu      sync.Mutex
+	loaded  bool
+	count   int
+	bytes   int64
+	oldest  int64 // 队首入队时间（UnixNano），队列为空时为 0
+	dropped int64
+	expired int64
+}
+
+// OfflineQueue 返回 configID 对应的离线队列，同一 ID 始终返回同一实例。
+func (s *Storage) OfflineQueue(configID string) *OfflineQueue {
+	s.queuesMu.Lock()
+	defer s.queuesMu.Unlock()
+	if s.queues == nil {
+		s.queues = make(map[string]*OfflineQueue)
+	}
+	q, ok := s.queues[configID]
+	if !ok {
+		q = &OfflineQueue{s: s, id: configID, prefix: []byte("q\x00" + configID + "\x00")}
+		s.queues[configID] = q
+	}
+	return q
+}
+
+// invalidateOfflineQueues 在 bucket 被外部清空后让队列重新统计积压。
+func (s *Storage) invalidateOfflineQueues() {
+	s.queuesMu.Lock()
+	defer s.queuesMu.Unlock()
+	for _, q := range s.queues {
+		q.mu.Lock()
+		q.loaded = false
+		q.mu.Unlock()
+	}
+}
+
+// Push 追加一条消息；超过条数或字节配额时从队首开始丢弃最旧的消息。
+func (q *OfflineQueue) Push(data []byte, p OfflinePolicy) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	return q.update(func(b *bbolt.Bucket) error {
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		var expires int64
+		if p.TTL > 0 {
+			expires = now.Add(p.TTL).UnixNano()
+		}
+		v := encodeOfflineValue(now.UnixNano(), expires, data)
+		if err := b.Put(q.key(seq), v); err != nil {
+			return err
+		}
+		q.count++
+		q.bytes += int64(len(v))
+		if q.oldest == 0 {
+			q.oldest = now.UnixNano()
+		}
+
+		// 过期消息先于配额淘汰，避免误删仍有效的消息
+		if err := q.trimHead(b, now, func() bool { return false }); err != nil {
+			return err
+		}
+		return q.trimHead(b, now, func() bool {
+			return (p.MaxCount > 0 && q.count > p.MaxCount) || (p.MaxBytes > 0 && q.bytes > p.MaxBytes)
+		})
+	})
+}
+
+// Peek 按入队顺序返回至多 limit 条未过期消息，过期消息在此时删除。
+func (q *OfflineQueue) Peek(limit int) ([]QueuedMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var out []QueuedMessage
+	now := time.Now().UnixNano()
+	err := q.update(func(b *bbolt.Bucket) error {
+		var stale [][]byte
+		c := b.Cursor()
+		for k, v := c.Seek(q.prefix); q.owns(k) && len(out) < limit; k, v = c.Next() {
+			enq, exp, data, ok := decodeOfflineValue(v)
+			if !ok || (exp > 0 && exp <= now) {
+				stale = append(stale, append([]byte(nil), k...))
+				q.count--
+				q.bytes -= int64(len(v))
+				q.expired++
+				continue
+			}
+			out = append(out, QueuedMessage{
+				Seq:        binary.BigEndian.Uint64(k[len(q.prefix):]),
+				Data:       append([]byte(nil), data...),
+				EnqueuedAt: time.Unix(0, enq),
+			})
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		if len(stale) > 0 {
+			q.oldest = q.headTime(b)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Ack 删除已成功转发的消息。
+func (q *OfflineQueue) Ack(seq uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.update(func(b *bbolt.Bucket) error {
+		k := q.key(seq)
+		v := b.Get(k)
+		if v == nil {
+			return nil
+		}
+		n := len(v)
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		q.count--
+		q.bytes -= int64(n)
+		q.oldest = q.headTime(b)
+		return nil
+	})
+}
+
+// Stats 返回当前积压条数、字节数、最旧消息等待时长及累计丢弃数。
+func (q *OfflineQueue) Stats() OfflineQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.loaded {
+		_ = q.update(func(*bbolt.Bucket) error { return nil })
+	}
+	st := OfflineQueueStats{Count: q.count, Bytes: q.bytes, Dropped: q.dropped, Expired: q.expired}
+	if q.oldest > 0 {
+		st.OldestAge = time.Since(time.Unix(0, q.oldest))
+	}
+	return st
+}
+
+// update 在写事务中执行 fn，首次使用时迁移旧格式数据并统计积压。调用方需持有 q.mu
+func (q *OfflineQueue) update(fn func(b *bbolt.Bucket) error) error {
+	err := q.s.runtimeDB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketNorthboundCache))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketNorthboundCache)
+		}
+		if !q.loaded {
+			if err := q.migrateLegacy(b); err != nil {
+				return err
+			}
+			q.load(b)
+		}
+		return fn(b)
+	})
+	if err != nil {
+		// 事务回滚后内存计数可能与磁盘不一致，下次访问时重新统计
+		q.loaded = false
+	}
+	return err
+}
+
+// load 扫描队列重建内存计数。调用方需持有 q.mu
+func (q *OfflineQueue) load(b *bbolt.Bucket) {
+	q.count, q.bytes, q.oldest = 0, 0, 0
+	c := b.Cursor()
+	for k, v := c.Seek(q.prefix); q.owns(k); k, v = c.Next() {
+		q.count++
+		q.bytes += int64(len(v))
+	}
+	q.oldest = q.headTime(b)
+	q.loaded = true
+}
+
+// migrateLegacy 将旧版 "<configID>_<unixnano>" 格式的离线消息按原顺序转入队列。
+func (q *OfflineQueue) migrateLegacy(b *bbolt.Bucket) error {
+	prefix := []byte(q.id + "_")
+	type legacy struct {
+		key, data []byte
+		ts        int64
+	}
+	var items []legacy
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		ts, err := strconv.ParseInt(string(k[len(prefix):]), 10, 64)
+		if err != nil {
+			continue
+		}
+		items = append(items, legacy{key: append([]byte(nil), k...), data: append([]byte(nil), v...), ts: ts})
+	}
+	for _, it := range items {
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := b.Put(q.key(seq), encodeOfflineValue(it.ts, 0, it.data)); err != nil {
+			return err
+		}
+		if err := b.Delete(it.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimHead 从队首删除已过期的消息，以及 over() 为真时的最旧消息。调用方需持有 q.mu
+func (q *OfflineQueue) trimHead(b *bbolt.Bucket, now time.Time, over func() bool) error {
+	c := b.Cursor()
+	for k, v := c.Seek(q.prefix); q.owns(k); k, v = c.Seek(q.prefix) {
+		_, exp, _, ok := decodeOfflineValue(v)
+		expired := !ok || (exp > 0 && exp <= now.UnixNano())
+		if !expired && !over() {
+			break
+		}
+		n := len(v)
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		q.count--
+		q.bytes -= int64(n)
+		if expired {
+			q.expired++
+		} else {
+			q.dropped++
+		}
+	}
+	q.oldest = q.headTime(b)
+	return nil
+}
+
+func (q *OfflineQueue) headTime(b *bbolt.Bucket) int64 {
+	k, v := b.Cursor().Seek(q.prefix)
+	if !q.owns(k) {
+		return 0
+	}
+	enq, _, _, _ := decodeOfflineValue(v)
+	return enq
+}
+
+func (q *OfflineQueue) key(seq uint64) []byte {
+	k := make([]byte, len(q.prefix)+8)
+	copy(k, q.prefix)
+	binary.BigEndian.PutUint64(k[len(q.prefix):], seq)
+	return k
+}
+
+func (q *OfflineQueue) owns(k []byte) bool {
+	return k != nil && len(k) == len(q.prefix)+8 && bytes.HasPrefix(k, q.prefix)
+}
+
+func encodeOfflineValue(enqueued, expires int64, data []byte) []byte {
+	v := make([]byte, offlineHeaderSize+len(data))
+	binary.BigEndian.PutUint64(v[0:8], uint64(enqueued))
+	binary.BigEndian.PutUint64(v[8:16], uint64(expires))
+	copy(v[offlineHeaderSize:], data)
+	return v
+}
+
+func decodeOfflineValue(v []byte) (enqueued, expires int64, data []byte, ok bool) {
+	if len(v) < offlineHeaderSize {
+		return 0, 0, nil, false
+	}
+	return int64(binary.BigEndian.Uint64(v[0:8])), int64(binary.BigEndian.Uint64(v[8:16])), v[offlineHeaderSize:], true
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+func TestOfflineQueueOrderAndAck(t *testing.T) {
+	s, err := NewStorage(testOutputDir(t))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	q := s.OfflineQueue("nb-1")
+	other := s.OfflineQueue("nb-10")
+	for i := 0; i < 3; i++ {
+		if err := q.Push([]byte(fmt.Sprintf("m%d", i)), OfflinePolicy{}); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	}
+	if err := other.Push([]byte("x"), OfflinePolicy{}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if s.OfflineQueue("nb-1") != q {
+		t.Fatal("queue instance must be shared per id")
+	}
+
+	msgs, err := q.Peek(10)
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("peek = %d, %v", len(msgs), err)
+	}
+	for i, m := range msgs {
+		if string(m.Data) != fmt.Sprintf("m%d", i) {
+			t.Fatalf("msg %d = %q", i, m.Data)
+		}
+	}
+	if err := q.Ack(msgs[0].Seq); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if st := q.Stats(); st.Count != 2 || st.Bytes != int64(2*(offlineHeaderSize+2)) {
+		t.Fatalf("stats = %+v", st)
+	}
+	if st := other.Stats(); st.Count != 1 {
+		t.Fatalf("other queue stats = %+v", st)
+	}
+}
+
+func TestOfflineQueueQuotaDropsOldest(t *testing.T) {
+	s, err := NewStorage(testOutputDir(t))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	q := s.OfflineQueue("nb-quota")
+	// 每条 16 字节头 + 4 字节数据，配额只容纳两条
+	p := OfflinePolicy{MaxBytes: 2 * (offlineHeaderSize + 4)}
+	for _, d := range []string{"aaaa", "bbbb", "cccc"} {
+		if err := q.Push([]byte(d), p); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	}
+	msgs, _ := q.Peek(10)
+	if len(msgs) != 2 || string(msgs[0].Data) != "bbbb" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+	if st := q.Stats(); st.Dropped != 1 || st.Count != 2 {
+		t.Fatalf("stats = %+v", st)
+	}
+
+	if err := q.Push([]byte("dddd"), OfflinePolicy{MaxCount: 1}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if msgs, _ := q.Peek(10); len(msgs) != 1 || string(msgs[0].Data) != "dddd" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+}
+
+func TestOfflineQueueTTL(t *testing.T) {
+	s, err := NewStorage(testOutputDir(t))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	q := s.OfflineQueue("nb-ttl")
+	if err := q.Push([]byte("old"), OfflinePolicy{TTL: time.Millisecond}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if err := q.Push([]byte("new"), OfflinePolicy{TTL: time.Hour}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	msgs, _ := q.Peek(10)
+	if len(msgs) != 1 || string(msgs[0].Data) != "new" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+	if st := q.Stats(); st.Expired != 1 || st.Count != 1 || st.OldestAge <= 0 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+func TestOfflineQueueMigratesLegacyEntries(t *testing.T) {
+	s, err := NewStorage(testOutputDir(t))
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	err = s.runtimeDB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketNorthboundCache))
+		for i, d := range []string{"first", "second"} {
+			if err := b.Put([]byte(fmt.Sprintf("legacy_%d", time.Now().UnixNano()+int64(i))), []byte(d)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	q := s.OfflineQueue("legacy")
+	if st := q.Stats(); st.Count != 2 {
+		t.Fatalf("stats = %+v", st)
+	}
+	msgs, _ := q.Peek(10)
+	if len(msgs) != 2 || string(msgs[0].Data) != "first" || string(msgs[1].Data) != "second" {
+		t.Fatalf("msgs = %+v", msgs)
+	}
+
+	if err := s.ClearBucket(BucketNorthboundCache); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if st := q.Stats(); st.Count != 0 || st.Bytes != 0 {
+		t.Fatalf("stats after clear = %+v", st)
+	}
+}
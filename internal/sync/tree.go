@@ -319,6 +319,11 @@ func buildNorthboundNodes(cfg *config.Config) []TreeSection {
 			items = append(items, sectionFromStruct("edgeos_nats", item.ID, item.Name, item.Enable, "northbound.yaml", item))
 		}
 	}
+	if len(cfg.Northbound.Connectors) > 0 {
+		for _, item := range cfg.Northbound.Connectors {
+			items = append(items, sectionFromStruct("connectors", item.ID, item.Name, item.Enable, "northbound.yaml", item))
+		}
+	}
 	sort.Slice(items, func(i, j int) bool {
 		if items[i].Section == items[j].Section {
 			return items[i].ID < items[j].ID
@@ -77,6 +77,62 @@ export const NORTHBOUND_PROTOCOLS = {
     hasStats: true,
     hasSync: false,
   },
+  kafka: {
+    key: 'kafka',
+    apiType: 'connectors',
+    label: 'Kafka 生产者',
+    shortLabel: 'Kafka',
+    mode: 'push',
+    color: '#231f20',
+    desc: '作为 Kafka 生产者向集群 Topic 批量写入数据，支持 SASL/TLS 与幂等投递',
+    icon: 'upload',
+    infoFields: (item) => [
+      { label: 'Brokers', value: (item.brokers || []).join(', ') || '-' },
+      { label: 'Topic', value: item.topic || 'edgex.{channel_id}' },
+      { label: 'Key', value: item.key_by || 'device' }
+    ],
+    hasConnection: true,
+    hasHelp: false,
+    hasStats: true,
+    hasSync: false,
+  },
+  tsdb: {
+    key: 'tsdb',
+    apiType: 'connectors',
+    label: '时序数据库',
+    shortLabel: 'TSDB',
+    mode: 'push',
+    color: '#22adf6',
+    desc: '以 Line Protocol 写入 InfluxDB，或批量 INSERT 到 PostgreSQL / TimescaleDB',
+    icon: 'storage',
+    infoFields: (item) => [
+      { label: '类型', value: item.type || 'influxdb_v2' },
+      { label: '目标', value: item.type === 'postgres' || item.type === 'timescaledb' ? (item.table || 'edgex_points') : (item.url || '-') },
+      { label: '批量', value: item.batch_size || 500 }
+    ],
+    hasConnection: true,
+    hasHelp: false,
+    hasStats: true,
+    hasSync: false,
+  },
+  connectors: {
+    key: 'connectors',
+    apiType: 'connectors',
+    label: '插件连接器',
+    shortLabel: 'Connector',
+    mode: 'push',
+    color: '#8c8c8c',
+    desc: '通过插件注册的北向连接器，参数由连接器类型定义',
+    icon: 'swap',
+    infoFields: (item) => [
+      { label: '类型', value: item.type || '-' },
+      { label: '参数', value: Object.keys(item.settings || {}).length + ' 项' }
+    ],
+    hasConnection: true,
+    hasHelp: false,
+    hasStats: true,
+    hasSync: false,
+  },
   edgeos_mqtt: {
     key: 'edgeos_mqtt',
     apiType: 'edgeos-mqtt',
@@ -174,6 +230,39 @@ export function flattenChannels(config) {
   return result
 }
 
+/** 以独立卡片展示的插件连接器类型（ConnectorConfig.type → 协议 key） */
+export const BUILTIN_CONNECTOR_TYPES = { kafka: 'kafka', tsdb: 'tsdb' }
+
+/** 将插件连接器配置展开为设置表单：settings 字段与 id / name / enable / devices 同级 */
+export function connectorToForm(c) {
+  return {
+    ...(c.settings || {}),
+    id: c.id,
+    name: c.name,
+    enable: c.enable,
+    devices: c.devices || {},
+    virtual_devices: c.virtual_devices || {}
+  }
+}
+
+/** connectorToForm 的逆操作，type 为连接器类型 */
+export function formToConnector(type, form) {
+  const { id, name, enable, devices, virtual_devices, ...settings } = form
+  return { id, name, type, enable, devices, virtual_devices, settings }
+}
+
+/** 拆分 /northbound/config 的 connectors：内置类型按协议 key 分组，其余保留为插件连接器 */
+export function splitConnectors(connectors) {
+  const result = { connectors: [] }
+  for (const key of Object.values(BUILTIN_CONNECTOR_TYPES)) result[key] = []
+  for (const c of connectors || []) {
+    const key = BUILTIN_CONNECTOR_TYPES[c.type]
+    if (key) result[key].push(connectorToForm(c))
+    else result.connectors.push(c)
+  }
+  return result
+}
+
 export function getProtocolMeta(key) {
   return NORTHBOUND_PROTOCOLS[key] || null
 }